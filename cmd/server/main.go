@@ -2,28 +2,39 @@ package main
 
 import (
 	"context"
+	"flag"
+	"fmt"
 	"log"
 	"net/http"
 	"os"
 	"os/signal"
+	"strings"
 	"syscall"
 	"time"
 
 	"github.com/testkube/dashboard/internal/database"
+	"github.com/testkube/dashboard/internal/demo"
 	"github.com/testkube/dashboard/internal/server"
+	"github.com/testkube/dashboard/internal/subscriptions"
+	"github.com/testkube/dashboard/internal/testids"
 	"github.com/testkube/dashboard/internal/testkube"
 	"github.com/testkube/dashboard/internal/users"
+	"github.com/testkube/dashboard/internal/worker"
 )
 
 func main() {
+	seedDemo := flag.Bool("seed-demo", false, "fill the database with demo history before serving; implies USE_MOCK")
+	seedWeeks := flag.Int("seed-weeks", demo.DefaultWeeks, "weeks of history generated by -seed-demo")
+	flag.Parse()
+
 	// Determine which client to use
 	var api testkube.Client
 	var err error
 
-	useMock := os.Getenv("USE_MOCK") == "true"
+	useMock := os.Getenv("USE_MOCK") == "true" || *seedDemo
 
 	if useMock {
-		log.Println("Using MOCK Testkube API client (USE_MOCK=true)")
+		log.Println("Using MOCK Testkube API client (USE_MOCK=true or -seed-demo)")
 		api = testkube.NewMockClient()
 	} else {
 		log.Println("Using REAL Testkube API client")
@@ -40,6 +51,20 @@ func main() {
 		log.Println("✓ Connected to Testkube API")
 	}
 
+	// Further clusters come from TESTKUBE_CLUSTERS_FILE; in mock mode each
+	// gets its own mock client
+	clusters := testkube.NewClientRegistry(testkube.DefaultClusterName(), api)
+	connect := testkube.ConnectCluster
+	if useMock {
+		connect = func(testkube.ClusterConfig) (testkube.Client, error) { return testkube.NewMockClient(), nil }
+	}
+	if err := clusters.Load(connect); err != nil {
+		log.Fatalf("Failed to load Testkube clusters: %v", err)
+	}
+	if names := clusters.Names(); len(names) > 1 {
+		log.Printf("✓ Testkube clusters: %s (default %s)", strings.Join(names, ", "), clusters.Default())
+	}
+
 	// Database still uses mock for Phase 2 (PostgreSQL comes in Phase 3)
 	db := database.NewMockDatabase()
 
@@ -58,6 +83,25 @@ func main() {
 	}
 
 	srv := server.NewServer(api, db, userGen, rootDir)
+	srv.SetClusters(clusters)
+
+	if *seedDemo {
+		if err := seedDemoData(api, db, srv, *seedWeeks); err != nil {
+			log.Fatalf("Seeding demo data failed: %v", err)
+		}
+	}
+
+	// Ingest finished executions into the database in the background
+	workerCtx, stopWorker := context.WithCancel(context.Background())
+	defer stopWorker()
+	if os.Getenv("WORKER_ENABLED") != "false" {
+		w := worker.NewWorker(api, db)
+		w.AddListener(srv)
+		w.AddListener(testids.NewLinker(db))
+		w.AddListener(subscriptions.NewEngine(db))
+		srv.SetWorker(w)
+		go w.Run(workerCtx)
+	}
 
 	port := ":8080"
 	httpServer := &http.Server{
@@ -71,6 +115,7 @@ func main() {
 		signal.Notify(sigCh, syscall.SIGINT, syscall.SIGTERM)
 		sig := <-sigCh
 		log.Printf("Received signal %v, shutting down...", sig)
+		stopWorker()
 
 		ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
 		defer cancel()
@@ -85,3 +130,16 @@ func main() {
 	}
 	log.Println("Server stopped.")
 }
+
+func seedDemoData(api testkube.Client, db database.Database, srv *server.Server, weeks int) error {
+	workflows, err := api.GetWorkflows(testkube.ListOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to list workflows: %w", err)
+	}
+	summary, err := demo.Seed(db, srv.Environments(), workflows, demo.Options{Weeks: weeks})
+	if err != nil {
+		return err
+	}
+	log.Printf("Seeded %d weeks of demo data: %s", weeks, summary)
+	return nil
+}
@@ -0,0 +1,236 @@
+package artifacts
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	// Register decoders for screenshots
+	_ "image/jpeg"
+)
+
+// DiffKind identifies how two artifacts can be compared
+type DiffKind string
+
+const (
+	KindJSON  DiffKind = "json"
+	KindImage DiffKind = "image"
+	KindNone  DiffKind = ""
+)
+
+// DiffKindFor returns how an artifact path can be diffed, by extension
+func DiffKindFor(path string) DiffKind {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+		return KindJSON
+	case ".png", ".jpg", ".jpeg":
+		return KindImage
+	}
+	return KindNone
+}
+
+// JSONChange is a single difference between two JSON documents
+type JSONChange struct {
+	Path   string `json:"path"` // e.g. "$.metrics.http_req_duration.values.avg"
+	Kind   string `json:"kind"` // added, removed or changed
+	Before string `json:"before,omitempty"`
+	After  string `json:"after,omitempty"`
+}
+
+// DiffJSONDocuments compares two JSON documents structurally. Objects are
+// compared by key and arrays by index; changes are sorted by path.
+func DiffJSONDocuments(before, after []byte) ([]JSONChange, error) {
+	a, err := decodeJSON(before)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse base document: %w", err)
+	}
+	b, err := decodeJSON(after)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse compared document: %w", err)
+	}
+
+	var changes []JSONChange
+	diffValues("$", a, b, &changes)
+	sort.SliceStable(changes, func(i, j int) bool {
+		return changes[i].Path < changes[j].Path
+	})
+	return changes, nil
+}
+
+func decodeJSON(data []byte) (interface{}, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var v interface{}
+	if err := dec.Decode(&v); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+func encodeJSON(v interface{}) string {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+	return string(data)
+}
+
+func diffValues(path string, a, b interface{}, changes *[]JSONChange) {
+	switch av := a.(type) {
+	case map[string]interface{}:
+		if bv, ok := b.(map[string]interface{}); ok {
+			for key, aChild := range av {
+				childPath := path + "." + key
+				if bChild, ok := bv[key]; ok {
+					diffValues(childPath, aChild, bChild, changes)
+				} else {
+					*changes = append(*changes, JSONChange{Path: childPath, Kind: "removed", Before: encodeJSON(aChild)})
+				}
+			}
+			for key, bChild := range bv {
+				if _, ok := av[key]; !ok {
+					*changes = append(*changes, JSONChange{Path: path + "." + key, Kind: "added", After: encodeJSON(bChild)})
+				}
+			}
+			return
+		}
+	case []interface{}:
+		if bv, ok := b.([]interface{}); ok {
+			for i := 0; i < max(len(av), len(bv)); i++ {
+				childPath := path + "[" + strconv.Itoa(i) + "]"
+				switch {
+				case i >= len(bv):
+					*changes = append(*changes, JSONChange{Path: childPath, Kind: "removed", Before: encodeJSON(av[i])})
+				case i >= len(av):
+					*changes = append(*changes, JSONChange{Path: childPath, Kind: "added", After: encodeJSON(bv[i])})
+				default:
+					diffValues(childPath, av[i], bv[i], changes)
+				}
+			}
+			return
+		}
+	}
+
+	if before, after := encodeJSON(a), encodeJSON(b); before != after {
+		*changes = append(*changes, JSONChange{Path: path, Kind: "changed", Before: before, After: after})
+	}
+}
+
+// DefaultImageThreshold is the perceptual distance (0-1) above which a pixel
+// counts as different; it ignores anti-aliasing and compression noise
+const DefaultImageThreshold = 0.1
+
+// maxYIQDelta is the largest possible squared YIQ distance between two colors
+const maxYIQDelta = 35215.0
+
+// ImageDiff is the result of comparing two screenshots
+type ImageDiff struct {
+	Width      int     `json:"width"`
+	Height     int     `json:"height"`
+	SizeDiffer bool    `json:"sizeDiffers"`
+	DiffPixels int     `json:"diffPixels"`
+	Ratio      float64 `json:"ratio"` // DiffPixels / total pixels
+	// Overlay is a PNG of the base image, faded, with differing pixels in red
+	Overlay []byte `json:"-"`
+}
+
+// DiffImages compares two PNG or JPEG images pixel by pixel using a
+// perceptual (YIQ) color distance, so small shifts in shade that people
+// can't see are not reported. Images of different sizes are compared over
+// the larger area, with pixels outside either image counted as different.
+func DiffImages(before, after []byte, threshold float64) (*ImageDiff, error) {
+	a, _, err := image.Decode(bytes.NewReader(before))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode base image: %w", err)
+	}
+	b, _, err := image.Decode(bytes.NewReader(after))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode compared image: %w", err)
+	}
+
+	ab, bb := a.Bounds(), b.Bounds()
+	width, height := max(ab.Dx(), bb.Dx()), max(ab.Dy(), bb.Dy())
+	diff := &ImageDiff{
+		Width:      width,
+		Height:     height,
+		SizeDiffer: ab.Size() != bb.Size(),
+	}
+
+	overlay := image.NewRGBA(image.Rect(0, 0, width, height))
+	highlight := color.RGBA{R: 255, A: 255}
+	limit := threshold * threshold * maxYIQDelta
+
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			inA := x < ab.Dx() && y < ab.Dy()
+			inB := x < bb.Dx() && y < bb.Dy()
+			if !inA || !inB {
+				diff.DiffPixels++
+				overlay.Set(x, y, highlight)
+				continue
+			}
+
+			ca := a.At(ab.Min.X+x, ab.Min.Y+y)
+			cb := b.At(bb.Min.X+x, bb.Min.Y+y)
+			if yiqDelta(ca, cb) > limit {
+				diff.DiffPixels++
+				overlay.Set(x, y, highlight)
+			} else {
+				overlay.Set(x, y, faded(ca))
+			}
+		}
+	}
+
+	if total := width * height; total > 0 {
+		diff.Ratio = float64(diff.DiffPixels) / float64(total)
+	}
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, overlay); err != nil {
+		return nil, fmt.Errorf("failed to encode diff image: %w", err)
+	}
+	diff.Overlay = buf.Bytes()
+	return diff, nil
+}
+
+// rgb255 returns a color's components in 0-255, blended onto white
+func rgb255(c color.Color) (r, g, b float64) {
+	cr, cg, cb, ca := c.RGBA()
+	alpha := float64(ca) / 0xffff
+	blend := func(v uint32) float64 {
+		return 255 + (float64(v)/0xffff*255-255)*alpha
+	}
+	if ca == 0 {
+		return 255, 255, 255
+	}
+	// RGBA() is alpha-premultiplied
+	return blend(cr * 0xffff / ca), blend(cg * 0xffff / ca), blend(cb * 0xffff / ca)
+}
+
+// yiqDelta is the squared perceptual distance between two colors, weighting
+// brightness over hue as in the YIQ color space
+func yiqDelta(c1, c2 color.Color) float64 {
+	r1, g1, b1 := rgb255(c1)
+	r2, g2, b2 := rgb255(c2)
+
+	y := (r1-r2)*0.29889531 + (g1-g2)*0.58662247 + (b1-b2)*0.11448223
+	i := (r1-r2)*0.59597799 - (g1-g2)*0.27417610 - (b1-b2)*0.32180189
+	q := (r1-r2)*0.21147017 - (g1-g2)*0.52261711 + (b1-b2)*0.31114694
+
+	return 0.5053*y*y + 0.299*i*i + 0.1957*q*q
+}
+
+// faded returns a light grey version of c, used as the overlay background
+func faded(c color.Color) color.Color {
+	r, g, b := rgb255(c)
+	luma := r*0.29889531 + g*0.58662247 + b*0.11448223
+	v := uint8(255 - (255-luma)*0.2)
+	return color.RGBA{R: v, G: v, B: v, A: 255}
+}
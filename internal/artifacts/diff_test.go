@@ -0,0 +1,101 @@
+package artifacts
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/png"
+	"testing"
+)
+
+func TestDiffJSONDocuments(t *testing.T) {
+	before := []byte(`{"total": 10, "failed": 1, "suites": [{"name": "a"}, {"name": "b"}], "meta": {"branch": "main"}}`)
+	after := []byte(`{"total": 10, "failed": 2, "suites": [{"name": "a"}], "meta": {"branch": "main", "sha": "abc"}}`)
+
+	changes, err := DiffJSONDocuments(before, after)
+	if err != nil {
+		t.Fatalf("DiffJSONDocuments failed: %v", err)
+	}
+
+	expected := []JSONChange{
+		{Path: "$.failed", Kind: "changed", Before: "1", After: "2"},
+		{Path: "$.meta.sha", Kind: "added", After: `"abc"`},
+		{Path: "$.suites[1]", Kind: "removed", Before: `{"name":"b"}`},
+	}
+	if len(changes) != len(expected) {
+		t.Fatalf("expected %d changes, got %+v", len(expected), changes)
+	}
+	for i := range expected {
+		if changes[i] != expected[i] {
+			t.Errorf("change %d: got %+v, expected %+v", i, changes[i], expected[i])
+		}
+	}
+
+	// Key order and number formatting don't matter
+	changes, _ = DiffJSONDocuments([]byte(`{"a": 1, "b": 2.50}`), []byte(`{"b": 2.50, "a": 1}`))
+	if len(changes) != 0 {
+		t.Errorf("expected no changes, got %+v", changes)
+	}
+
+	if _, err := DiffJSONDocuments([]byte(`{`), []byte(`{}`)); err == nil {
+		t.Error("expected error for invalid JSON")
+	}
+}
+
+func encodePNG(t *testing.T, w, h int, fill func(x, y int) color.Color) []byte {
+	t.Helper()
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			img.Set(x, y, fill(x, y))
+		}
+	}
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestDiffImages(t *testing.T) {
+	white := color.RGBA{255, 255, 255, 255}
+	base := encodePNG(t, 10, 10, func(x, y int) color.Color { return white })
+
+	// A barely different shade everywhere plus a clearly changed 2x2 block
+	changed := encodePNG(t, 10, 10, func(x, y int) color.Color {
+		if x < 2 && y < 2 {
+			return color.RGBA{0, 0, 0, 255}
+		}
+		return color.RGBA{252, 252, 252, 255}
+	})
+
+	diff, err := DiffImages(base, changed, DefaultImageThreshold)
+	if err != nil {
+		t.Fatalf("DiffImages failed: %v", err)
+	}
+	if diff.DiffPixels != 4 || diff.Ratio != 0.04 || diff.SizeDiffer {
+		t.Errorf("expected 4 differing pixels, got %+v", diff)
+	}
+
+	overlay, err := png.Decode(bytes.NewReader(diff.Overlay))
+	if err != nil {
+		t.Fatalf("overlay is not a PNG: %v", err)
+	}
+	if r, g, _, _ := overlay.At(0, 0).RGBA(); r != 0xffff || g != 0 {
+		t.Error("expected differing pixels to be highlighted red")
+	}
+
+	// Extra rows in a taller screenshot all count as different
+	taller := encodePNG(t, 10, 12, func(x, y int) color.Color { return white })
+	diff, err = DiffImages(base, taller, DefaultImageThreshold)
+	if err != nil {
+		t.Fatalf("DiffImages failed: %v", err)
+	}
+	if !diff.SizeDiffer || diff.DiffPixels != 20 || diff.Height != 12 {
+		t.Errorf("expected 20 differing pixels from the size change, got %+v", diff)
+	}
+
+	if _, err := DiffImages([]byte("not an image"), base, DefaultImageThreshold); err == nil {
+		t.Error("expected error for invalid image")
+	}
+}
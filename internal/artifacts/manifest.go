@@ -0,0 +1,77 @@
+package artifacts
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"hash"
+
+	"github.com/testkube/dashboard/internal/database"
+)
+
+// Integrity is the state of an artifact compared to the manifest recorded
+// when its execution was ingested
+type Integrity string
+
+const (
+	// IntegrityVerified means the content matches the recorded checksum
+	IntegrityVerified Integrity = "verified"
+	// IntegrityUnchecked means the size matches but the content hasn't been
+	// hashed, or no checksum was recorded
+	IntegrityUnchecked Integrity = "unchecked"
+	// IntegrityMismatch means the artifact changed since it was ingested
+	IntegrityMismatch Integrity = "mismatch"
+	// IntegrityMissing means a recorded artifact is no longer listed
+	IntegrityMissing Integrity = "missing"
+	// IntegrityUnrecorded means the artifact isn't in the manifest, e.g. the
+	// execution hasn't been ingested
+	IntegrityUnrecorded Integrity = "unrecorded"
+)
+
+// Warning reports whether the artifact may have been tampered with or
+// corrupted
+func (i Integrity) Warning() bool {
+	return i == IntegrityMismatch || i == IntegrityMissing
+}
+
+// Checksum returns the hex-encoded SHA-256 of data
+func Checksum(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// NewChecksum returns a hash that, hex-encoded, gives Checksum of the data
+// written to it, for content streamed rather than held in memory
+func NewChecksum() hash.Hash {
+	return sha256.New()
+}
+
+// VerifyChecksum compares an artifact's content, hashed as Checksum, to its
+// manifest record
+func VerifyChecksum(record *database.ArtifactRecord, checksum string) Integrity {
+	switch {
+	case record == nil:
+		return IntegrityUnrecorded
+	case record.SHA256 == "":
+		return IntegrityUnchecked
+	case checksum != record.SHA256:
+		return IntegrityMismatch
+	}
+	return IntegrityVerified
+}
+
+// Verify compares an artifact to its manifest record. Downloaded data is
+// checked against the recorded checksum; pass nil data to only compare the
+// listed size.
+func Verify(record *database.ArtifactRecord, size int64, data []byte) Integrity {
+	switch {
+	case record == nil:
+		return IntegrityUnrecorded
+	case data == nil && size != record.Size:
+		return IntegrityMismatch
+	case data == nil || record.SHA256 == "":
+		return IntegrityUnchecked
+	case Checksum(data) != record.SHA256:
+		return IntegrityMismatch
+	}
+	return IntegrityVerified
+}
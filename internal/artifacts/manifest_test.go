@@ -0,0 +1,65 @@
+package artifacts
+
+import (
+	"encoding/hex"
+	"testing"
+
+	"github.com/testkube/dashboard/internal/database"
+)
+
+func TestVerify(t *testing.T) {
+	data := []byte("CVE-2024-0001 HIGH")
+	record := &database.ArtifactRecord{Path: "trivy.json", Size: int64(len(data)), SHA256: Checksum(data)}
+	unhashed := &database.ArtifactRecord{Path: "video.webm", Size: int64(len(data))}
+
+	tests := []struct {
+		name   string
+		record *database.ArtifactRecord
+		size   int64
+		data   []byte
+		want   Integrity
+	}{
+		{"not in manifest", nil, 18, data, IntegrityUnrecorded},
+		{"listed size only", record, 18, nil, IntegrityUnchecked},
+		{"listed size differs", record, 17, nil, IntegrityMismatch},
+		{"same content", record, 18, data, IntegrityVerified},
+		{"edited content", record, 18, []byte("CVE-2024-0001 LOW!"), IntegrityMismatch},
+		{"truncated content", record, 10, data[:10], IntegrityMismatch},
+		{"no recorded checksum", unhashed, 18, data, IntegrityUnchecked},
+	}
+	for _, tt := range tests {
+		if got := Verify(tt.record, tt.size, tt.data); got != tt.want {
+			t.Errorf("%s: got %s, expected %s", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestVerifyChecksum(t *testing.T) {
+	data := []byte("trace.zip contents")
+	record := &database.ArtifactRecord{Path: "trace.zip", Size: int64(len(data)), SHA256: Checksum(data)}
+
+	sum := NewChecksum()
+	sum.Write(data[:5])
+	sum.Write(data[5:])
+	streamed := hex.EncodeToString(sum.Sum(nil))
+	if streamed != Checksum(data) {
+		t.Fatalf("got %s, expected the streamed checksum to equal Checksum", streamed)
+	}
+
+	tests := []struct {
+		name     string
+		record   *database.ArtifactRecord
+		checksum string
+		want     Integrity
+	}{
+		{"not in manifest", nil, streamed, IntegrityUnrecorded},
+		{"same content", record, streamed, IntegrityVerified},
+		{"edited content", record, Checksum([]byte("other")), IntegrityMismatch},
+		{"no recorded checksum", &database.ArtifactRecord{Path: "trace.zip"}, streamed, IntegrityUnchecked},
+	}
+	for _, tt := range tests {
+		if got := VerifyChecksum(tt.record, tt.checksum); got != tt.want {
+			t.Errorf("%s: got %s, expected %s", tt.name, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,210 @@
+// Package backup exports the dashboard's stored data and configuration to a
+// portable archive and restores it, for migrating between clusters and
+// disaster recovery drills.
+//
+// An archive is a gzipped tar of JSON files: a manifest, one file per
+// database table and the configuration bundle.
+package backup
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"time"
+
+	"github.com/testkube/dashboard/internal/configsync"
+	"github.com/testkube/dashboard/internal/database"
+)
+
+// FormatVersion is the current archive format version
+const FormatVersion = 1
+
+const (
+	manifestFile = "manifest.json"
+	configFile   = "config.json"
+)
+
+// ErrInvalidArchive is returned when an archive can't be read
+var ErrInvalidArchive = errors.New("invalid backup archive")
+
+// Manifest describes an archive
+type Manifest struct {
+	Version   int            `json:"version"`
+	CreatedAt time.Time      `json:"createdAt"`
+	Rows      map[string]int `json:"rows"` // per table file
+}
+
+// Archive is a backup of the database and configuration
+type Archive struct {
+	Manifest Manifest
+	Database *database.Snapshot
+	// Config is nil when the backup didn't include configuration
+	Config *configsync.Bundle
+}
+
+// table is a database table stored as its own file in the archive
+type table struct {
+	file string
+	rows interface{} // pointer to a slice of the snapshot
+}
+
+func tables(s *database.Snapshot) []table {
+	return []table{
+		{"executions.json", &s.Executions},
+		{"test_cases.json", &s.TestCases},
+		{"k6_metrics.json", &s.K6Metrics},
+		{"check_results.json", &s.CheckResults},
+		{"artifacts.json", &s.Artifacts},
+		{"feature_overrides.json", &s.FeatureOverrides},
+		{"variable_presets.json", &s.VariablePresets},
+		{"dead_letters.json", &s.DeadLetters},
+		{"events.json", &s.Events},
+		{"test_links.json", &s.TestLinks},
+		{"watches.json", &s.Watches},
+		{"user_channels.json", &s.UserChannels},
+		{"reports.json", &s.Reports},
+		{"ingest_cursors.json", &s.IngestCursors},
+		{"test_suites.json", &s.TestSuites},
+		{"evidence_records.json", &s.EvidenceRecords},
+		{"visual_baselines.json", &s.VisualBaselines},
+	}
+}
+
+func rowCounts(s *database.Snapshot) map[string]int {
+	rows := make(map[string]int)
+	for _, t := range tables(s) {
+		rows[t.file] = reflect.ValueOf(t.rows).Elem().Len()
+	}
+	return rows
+}
+
+// Create backs up everything in db and, when config is not nil, the
+// configuration of every registered section
+func Create(db database.Database, config *configsync.Registry) (*Archive, error) {
+	snapshot, err := db.Snapshot()
+	if err != nil {
+		return nil, fmt.Errorf("failed to snapshot database: %w", err)
+	}
+	archive := &Archive{
+		Manifest: Manifest{Version: FormatVersion, CreatedAt: time.Now().UTC(), Rows: rowCounts(snapshot)},
+		Database: snapshot,
+	}
+	if config != nil {
+		if archive.Config, err = config.Export(); err != nil {
+			return nil, fmt.Errorf("failed to export configuration: %w", err)
+		}
+	}
+	return archive, nil
+}
+
+// Write writes the archive as a gzipped tar
+func (a *Archive) Write(w io.Writer) error {
+	gz := gzip.NewWriter(w)
+	tw := tar.NewWriter(gz)
+
+	add := func(name string, v interface{}) error {
+		data, err := json.MarshalIndent(v, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to encode %s: %w", name, err)
+		}
+		header := &tar.Header{Name: name, Mode: 0644, Size: int64(len(data)), ModTime: a.Manifest.CreatedAt}
+		if err := tw.WriteHeader(header); err != nil {
+			return fmt.Errorf("failed to add %s: %w", name, err)
+		}
+		if _, err := tw.Write(data); err != nil {
+			return fmt.Errorf("failed to add %s: %w", name, err)
+		}
+		return nil
+	}
+
+	if err := add(manifestFile, a.Manifest); err != nil {
+		return err
+	}
+	for _, t := range tables(a.Database) {
+		if err := add(t.file, t.rows); err != nil {
+			return err
+		}
+	}
+	if a.Config != nil {
+		if err := add(configFile, a.Config); err != nil {
+			return err
+		}
+	}
+
+	if err := tw.Close(); err != nil {
+		return fmt.Errorf("failed to write archive: %w", err)
+	}
+	if err := gz.Close(); err != nil {
+		return fmt.Errorf("failed to write archive: %w", err)
+	}
+	return nil
+}
+
+// Read parses an archive written by Write. Tables missing from the archive
+// restore as empty.
+func Read(r io.Reader) (*Archive, error) {
+	gz, err := gzip.NewReader(r)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidArchive, err)
+	}
+	defer gz.Close()
+
+	archive := &Archive{Database: &database.Snapshot{}}
+	files := make(map[string]interface{})
+	for _, t := range tables(archive.Database) {
+		files[t.file] = t.rows
+	}
+	files[manifestFile] = &archive.Manifest
+
+	seen := make(map[string]bool)
+	tr := tar.NewReader(gz)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidArchive, err)
+		}
+
+		target, ok := files[header.Name]
+		if header.Name == configFile {
+			archive.Config = &configsync.Bundle{}
+			target, ok = archive.Config, true
+		}
+		if !ok {
+			return nil, fmt.Errorf("%w: unexpected file %s", ErrInvalidArchive, header.Name)
+		}
+		if err := json.NewDecoder(tr).Decode(target); err != nil {
+			return nil, fmt.Errorf("%w: failed to parse %s: %v", ErrInvalidArchive, header.Name, err)
+		}
+		seen[header.Name] = true
+	}
+
+	if !seen[manifestFile] {
+		return nil, fmt.Errorf("%w: missing %s", ErrInvalidArchive, manifestFile)
+	}
+	if archive.Manifest.Version > FormatVersion {
+		return nil, fmt.Errorf("%w: unsupported version %d (max %d)", ErrInvalidArchive, archive.Manifest.Version, FormatVersion)
+	}
+	return archive, nil
+}
+
+// Restore replaces everything in db with the archive's data and, when config
+// is not nil and the archive has configuration, imports it. Configuration is
+// imported first so a bundle the dashboard can't apply leaves the data alone.
+func (a *Archive) Restore(db database.Database, config *configsync.Registry) error {
+	if config != nil && a.Config != nil {
+		if err := config.Import(a.Config); err != nil {
+			return fmt.Errorf("failed to restore configuration: %w", err)
+		}
+	}
+	if err := db.Restore(a.Database); err != nil {
+		return fmt.Errorf("failed to restore database: %w", err)
+	}
+	return nil
+}
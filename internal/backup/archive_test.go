@@ -0,0 +1,112 @@
+package backup
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/testkube/dashboard/internal/configsync"
+	"github.com/testkube/dashboard/internal/database"
+	"github.com/testkube/dashboard/internal/testkube"
+)
+
+// setting is a configuration section holding one JSON value
+type setting struct {
+	value json.RawMessage
+}
+
+func (s *setting) Export() (json.RawMessage, error) { return s.value, nil }
+
+func (s *setting) Import(data json.RawMessage) error {
+	s.value = data
+	return nil
+}
+
+func TestArchive_RoundTrip(t *testing.T) {
+	src := database.NewMockDatabase()
+	src.InsertExecution(testkube.Execution{ID: "exec-1", WorkflowName: "api-tests", Status: "passed"})
+	src.InsertTestCase(database.TestCase{ExecutionID: "exec-1", TestName: "health", Status: "passed"})
+	src.InsertArtifactManifest("exec-1", []database.ArtifactRecord{{ExecutionID: "exec-1", Path: "report.json", SHA256: "abc"}})
+	src.InsertCheckResult(database.CheckResult{CheckID: "api", Time: time.Now(), OK: true})
+	src.SetFeatureOverride(database.FeatureOverride{Flag: "graphs", Enabled: true})
+
+	srcConfig := configsync.NewRegistry()
+	srcConfig.Register("schedules", &setting{json.RawMessage(`{"nightly":"0 2 * * *"}`)})
+
+	archive, err := Create(src, srcConfig)
+	if err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	if got := archive.Manifest.Rows["executions.json"]; got != 1 {
+		t.Errorf("executions in manifest: got %d, expected 1", got)
+	}
+	var buf bytes.Buffer
+	if err := archive.Write(&buf); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+
+	read, err := Read(&buf)
+	if err != nil {
+		t.Fatalf("Read failed: %v", err)
+	}
+	dst := database.NewMockDatabase()
+	dst.InsertExecution(testkube.Execution{ID: "stale"})
+	dstSchedules := &setting{}
+	dstConfig := configsync.NewRegistry()
+	dstConfig.Register("schedules", dstSchedules)
+	if err := read.Restore(dst, dstConfig); err != nil {
+		t.Fatalf("Restore failed: %v", err)
+	}
+
+	snapshot, _ := dst.Snapshot()
+	if len(snapshot.Executions) != 1 || snapshot.Executions[0].ID != "exec-1" {
+		t.Errorf("executions: got %+v, expected exec-1 only", snapshot.Executions)
+	}
+	if len(snapshot.TestCases) != 1 || len(snapshot.CheckResults) != 1 || len(snapshot.FeatureOverrides) != 1 {
+		t.Errorf("unexpected restored data: %+v", snapshot)
+	}
+	if manifest, _ := dst.GetArtifactManifest("exec-1"); len(manifest) != 1 || manifest[0].SHA256 != "abc" {
+		t.Errorf("artifact manifest: got %+v", manifest)
+	}
+	var schedules bytes.Buffer
+	json.Compact(&schedules, dstSchedules.value)
+	if schedules.String() != `{"nightly":"0 2 * * *"}` {
+		t.Errorf("config: got %s", dstSchedules.value)
+	}
+}
+
+func TestArchive_RestoreKeepsDataOnBadConfig(t *testing.T) {
+	src := database.NewMockDatabase()
+	srcConfig := configsync.NewRegistry()
+	srcConfig.Register("unknown", &setting{json.RawMessage(`{}`)})
+	archive, err := Create(src, srcConfig)
+	if err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+
+	dst := database.NewMockDatabase()
+	dst.InsertExecution(testkube.Execution{ID: "keep"})
+	if err := archive.Restore(dst, configsync.NewRegistry()); err == nil {
+		t.Fatal("expected an error for an unknown config section")
+	}
+	if snapshot, _ := dst.Snapshot(); len(snapshot.Executions) != 1 {
+		t.Errorf("executions: got %d, expected the existing data to be kept", len(snapshot.Executions))
+	}
+}
+
+func TestRead_Invalid(t *testing.T) {
+	if _, err := Read(bytes.NewReader([]byte("not an archive"))); !errors.Is(err, ErrInvalidArchive) {
+		t.Errorf("got %v, expected ErrInvalidArchive", err)
+	}
+
+	archive := &Archive{Manifest: Manifest{Version: FormatVersion + 1}, Database: &database.Snapshot{}}
+	var buf bytes.Buffer
+	if err := archive.Write(&buf); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+	if _, err := Read(&buf); !errors.Is(err, ErrInvalidArchive) {
+		t.Errorf("newer version: got %v, expected ErrInvalidArchive", err)
+	}
+}
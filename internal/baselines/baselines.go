@@ -0,0 +1,239 @@
+// Package baselines decides when a workflow's pass rate has dropped enough
+// to alert on. By default the threshold is a dynamic baseline, the workflow's
+// own mean daily pass rate less a number of standard deviations, so
+// inherently variable suites don't page on their usual noise.
+package baselines
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"path"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/testkube/dashboard/internal/database"
+)
+
+// Mode is how a workflow's alert threshold is set
+type Mode string
+
+const (
+	// ModeDynamic alerts when the pass rate falls StdDevs standard
+	// deviations below its mean over the baseline period
+	ModeDynamic Mode = "dynamic"
+	// ModeStatic alerts when the pass rate falls below MinPassRate
+	ModeStatic Mode = "static"
+	// ModeOff never alerts on the pass rate
+	ModeOff Mode = "off"
+)
+
+const (
+	DefaultStdDevs      = 2.0
+	DefaultBaselineDays = 30
+	// DefaultMinDays is how many days of history a dynamic baseline needs
+	// before it alerts
+	DefaultMinDays = 7
+	// DefaultMinRuns is how many runs a day needs before its pass rate counts
+	DefaultMinRuns = 3
+	// minStdDev keeps workflows that always pass from alerting on a single
+	// failure
+	minStdDev = 1.0
+)
+
+// Rule sets the alert threshold of workflows matching a glob
+type Rule struct {
+	Workflow     string  `json:"workflow"` // glob, e.g. "load-*"
+	Mode         Mode    `json:"mode,omitempty"`
+	StdDevs      float64 `json:"stdDevs,omitempty"`
+	BaselineDays int     `json:"baselineDays,omitempty"`
+	MinDays      int     `json:"minDays,omitempty"`
+	MinRuns      int     `json:"minRuns,omitempty"`
+	// MinPassRate is the threshold in ModeStatic, in percent
+	MinPassRate float64 `json:"minPassRate,omitempty"`
+}
+
+// withDefaults fills in the settings a rule leaves out
+func (r Rule) withDefaults() Rule {
+	if r.Mode == "" {
+		r.Mode = ModeDynamic
+	}
+	if r.StdDevs == 0 {
+		r.StdDevs = DefaultStdDevs
+	}
+	if r.BaselineDays == 0 {
+		r.BaselineDays = DefaultBaselineDays
+	}
+	if r.MinDays == 0 {
+		r.MinDays = DefaultMinDays
+	}
+	if r.MinRuns == 0 {
+		r.MinRuns = DefaultMinRuns
+	}
+	return r
+}
+
+type config struct {
+	Rules []Rule `json:"rules"`
+}
+
+// Policy holds the per-workflow alert thresholds
+type Policy struct {
+	config config
+	mu     sync.RWMutex
+}
+
+// NewPolicy creates a policy, loading rules from the JSON file in
+// PASS_RATE_ALERTS_FILE when set. Workflows without a rule use a dynamic
+// baseline with the defaults.
+func NewPolicy() (*Policy, error) {
+	p := &Policy{}
+
+	if file := os.Getenv("PASS_RATE_ALERTS_FILE"); file != "" {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return p, fmt.Errorf("failed to read pass rate alerts file: %w", err)
+		}
+		if err := p.Import(data); err != nil {
+			return p, err
+		}
+	}
+
+	return p, nil
+}
+
+// For returns the first rule matching the workflow, with defaults filled in
+func (p *Policy) For(workflow string) Rule {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	for _, rule := range p.config.Rules {
+		if ok, _ := path.Match(rule.Workflow, workflow); ok {
+			return rule.withDefaults()
+		}
+	}
+	return Rule{Workflow: workflow}.withDefaults()
+}
+
+// Export returns the rules as JSON, for configuration sync
+func (p *Policy) Export() (json.RawMessage, error) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return json.Marshal(p.config)
+}
+
+// Import replaces the rules
+func (p *Policy) Import(data json.RawMessage) error {
+	var cfg config
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return fmt.Errorf("failed to parse pass rate alerts: %w", err)
+	}
+
+	for _, rule := range cfg.Rules {
+		if _, err := path.Match(rule.Workflow, ""); err != nil {
+			return fmt.Errorf("invalid workflow pattern %q: %w", rule.Workflow, err)
+		}
+		switch rule.Mode {
+		case "", ModeDynamic, ModeOff:
+		case ModeStatic:
+			if rule.MinPassRate <= 0 || rule.MinPassRate > 100 {
+				return fmt.Errorf("static rule for %s needs a minPassRate between 0 and 100", rule.Workflow)
+			}
+		default:
+			return fmt.Errorf("invalid mode %q for %s: must be %q, %q or %q", rule.Mode, rule.Workflow, ModeDynamic, ModeStatic, ModeOff)
+		}
+		if rule.StdDevs < 0 || rule.BaselineDays < 0 || rule.MinDays < 0 || rule.MinRuns < 0 {
+			return fmt.Errorf("settings for %s must not be negative", rule.Workflow)
+		}
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.config = cfg
+	return nil
+}
+
+// Result is a workflow's pass rate today against its threshold
+type Result struct {
+	Workflow string  `json:"workflow"`
+	Mode     Mode    `json:"mode"`
+	Current  float64 `json:"current"` // today's pass rate, in percent
+	Runs     int     `json:"runs"`    // today's runs
+	// Mean and StdDev of the daily pass rate over the baseline period, for
+	// dynamic baselines
+	Mean      float64 `json:"mean,omitempty"`
+	StdDev    float64 `json:"stdDev,omitempty"`
+	Days      int     `json:"days,omitempty"`
+	Threshold float64 `json:"threshold"`
+	// Ready is false until there is enough history and enough runs today
+	Ready    bool `json:"ready"`
+	Breached bool `json:"breached"`
+}
+
+// Message describes a breach for a notification
+func (r Result) Message() string {
+	if r.Mode == ModeStatic {
+		return fmt.Sprintf("Pass rate of %s is %.1f%% today, below the %.1f%% threshold", r.Workflow, r.Current, r.Threshold)
+	}
+	return fmt.Sprintf("Pass rate of %s is %.1f%% today, below its baseline of %.1f%% ± %.1f (threshold %.1f%%)",
+		r.Workflow, r.Current, r.Mean, r.StdDev, r.Threshold)
+}
+
+// Evaluate compares today's pass rate with the rule's threshold, using the
+// workflow's daily pass rate trend. Days with fewer than MinRuns runs are
+// left out of the baseline.
+func (r Rule) Evaluate(workflow string, trend []database.DataPoint, now time.Time) Result {
+	rule := r.withDefaults()
+	result := Result{Workflow: workflow, Mode: rule.Mode}
+	if rule.Mode == ModeOff {
+		return result
+	}
+
+	y, m, d := now.Date()
+	today := time.Date(y, m, d, 0, 0, 0, 0, now.Location())
+	from := today.AddDate(0, 0, -rule.BaselineDays)
+
+	points := append([]database.DataPoint(nil), trend...)
+	sort.Slice(points, func(i, j int) bool { return points[i].Date.Before(points[j].Date) })
+	var history []float64
+	for _, p := range points {
+		switch {
+		case !p.Date.Before(today):
+			result.Current = p.PassRate
+			result.Runs = p.Count
+		case !p.Date.Before(from) && p.Count >= rule.MinRuns:
+			history = append(history, p.PassRate)
+		}
+	}
+
+	if rule.Mode == ModeStatic {
+		result.Threshold = rule.MinPassRate
+		result.Ready = result.Runs >= rule.MinRuns
+	} else {
+		result.Days = len(history)
+		result.Mean, result.StdDev = meanStdDev(history)
+		result.Threshold = math.Max(0, result.Mean-rule.StdDevs*math.Max(result.StdDev, minStdDev))
+		result.Ready = result.Runs >= rule.MinRuns && result.Days >= rule.MinDays
+	}
+	result.Breached = result.Ready && result.Current < result.Threshold
+	return result
+}
+
+// meanStdDev returns the mean and population standard deviation
+func meanStdDev(values []float64) (float64, float64) {
+	if len(values) == 0 {
+		return 0, 0
+	}
+	var sum float64
+	for _, v := range values {
+		sum += v
+	}
+	mean := sum / float64(len(values))
+	var squares float64
+	for _, v := range values {
+		squares += (v - mean) * (v - mean)
+	}
+	return mean, math.Sqrt(squares / float64(len(values)))
+}
@@ -0,0 +1,85 @@
+package baselines
+
+import (
+	"testing"
+	"time"
+
+	"github.com/testkube/dashboard/internal/database"
+)
+
+var now = time.Date(2024, 5, 31, 15, 0, 0, 0, time.UTC)
+
+// trend builds daily points, oldest first, ending with today's
+func trend(history []float64, today float64, runs int) []database.DataPoint {
+	var points []database.DataPoint
+	for i, rate := range history {
+		points = append(points, database.DataPoint{Date: now.AddDate(0, 0, i-len(history)), PassRate: rate, Count: 10})
+	}
+	return append(points, database.DataPoint{Date: now, PassRate: today, Count: runs})
+}
+
+func TestDynamicBaseline(t *testing.T) {
+	rule := Rule{}
+	// A noisy suite: 70-90%, mean 80, standard deviation 10
+	noisy := []float64{70, 90, 70, 90, 70, 90, 70, 90, 70, 90}
+
+	if r := rule.Evaluate("load", trend(noisy, 65, 5), now); r.Breached {
+		t.Errorf("got a breach at 65%% for a noisy suite, expected its threshold of %.1f to hold: %+v", r.Threshold, r)
+	}
+	r := rule.Evaluate("load", trend(noisy, 55, 5), now)
+	if !r.Breached || r.Mean != 80 || r.StdDev != 10 || r.Threshold != 60 {
+		t.Errorf("got %+v, expected a breach below 60%%", r)
+	}
+
+	// A stable suite alerts on a smaller drop
+	stable := []float64{100, 100, 100, 100, 100, 100, 100, 100}
+	if r := rule.Evaluate("api", trend(stable, 90, 10), now); !r.Breached {
+		t.Errorf("got %+v, expected a stable suite to alert at 90%%", r)
+	}
+
+	// Too little history, or too few runs today
+	if r := rule.Evaluate("api", trend(stable[:3], 0, 10), now); r.Ready || r.Breached {
+		t.Errorf("got %+v, expected no verdict with 3 days of history", r)
+	}
+	if r := rule.Evaluate("api", trend(stable, 0, 1), now); r.Ready || r.Breached {
+		t.Errorf("got %+v, expected no verdict after a single run today", r)
+	}
+}
+
+func TestPolicyRules(t *testing.T) {
+	p, err := NewPolicy()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := p.Import([]byte(`{"rules": [
+		{"workflow": "smoke-*", "mode": "static", "minPassRate": 95},
+		{"workflow": "soak", "mode": "off"},
+		{"workflow": "load-*", "stdDevs": 3, "baselineDays": 14}
+	]}`)); err != nil {
+		t.Fatal(err)
+	}
+
+	static := p.For("smoke-api")
+	if r := static.Evaluate("smoke-api", trend(nil, 90, 5), now); !r.Breached || r.Threshold != 95 {
+		t.Errorf("got %+v, expected a breach of the static threshold", r)
+	}
+	if r := p.For("soak").Evaluate("soak", trend(nil, 0, 50), now); r.Breached {
+		t.Errorf("got %+v, expected no alerts when off", r)
+	}
+	if rule := p.For("load-checkout"); rule.StdDevs != 3 || rule.BaselineDays != 14 || rule.MinDays != DefaultMinDays {
+		t.Errorf("got %+v, expected the load rule with defaults filled in", rule)
+	}
+	if rule := p.For("frontend"); rule.Mode != ModeDynamic || rule.StdDevs != DefaultStdDevs {
+		t.Errorf("got %+v, expected the default dynamic rule", rule)
+	}
+
+	for _, cfg := range []string{
+		`{"rules": [{"workflow": "a", "mode": "static"}]}`,
+		`{"rules": [{"workflow": "a", "mode": "sometimes"}]}`,
+		`{"rules": [{"workflow": "a", "stdDevs": -1}]}`,
+	} {
+		if err := p.Import([]byte(cfg)); err == nil {
+			t.Errorf("expected %s to be rejected", cfg)
+		}
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"io"
 	"strings"
+	"time"
 
 	"github.com/go-echarts/go-echarts/v2/charts"
 	"github.com/go-echarts/go-echarts/v2/opts"
@@ -71,6 +72,47 @@ func (g *Generator) DurationChart(data []database.DataPoint) string {
 	return g.renderToString(bar)
 }
 
+// DurationHistogramChart shows how many executions took how long, which
+// reveals fast failures hidden in an average duration
+func (g *Generator) DurationHistogramChart(buckets []database.DurationBucket) string {
+	bar := charts.NewBar()
+	bar.SetGlobalOptions(
+		charts.WithTitleOpts(opts.Title{Title: "Duration Distribution"}),
+		charts.WithTooltipOpts(opts.Tooltip{Show: opts.Bool(true)}),
+		charts.WithLegendOpts(opts.Legend{Show: opts.Bool(false)}),
+		charts.WithInitializationOpts(opts.Initialization{
+			Height: "200px",
+			Width: "100%",
+		}),
+	)
+
+	xAxis := make([]string, len(buckets))
+	counts := make([]opts.BarData, len(buckets))
+
+	for i, b := range buckets {
+		xAxis[i] = durationLabel(b.LowerMs) + "–" + durationLabel(b.UpperMs)
+		counts[i] = opts.BarData{Value: b.Count}
+	}
+
+	bar.SetXAxis(xAxis).
+		AddSeries("Executions", counts).
+		SetSeriesOptions(charts.WithBarChartOpts(opts.BarChart{BarCategoryGap: "0%"}))
+
+	return g.renderToString(bar)
+}
+
+// durationLabel rounds a bucket bound for an axis label
+func durationLabel(ms int64) string {
+	d := time.Duration(ms) * time.Millisecond
+	switch {
+	case d >= time.Minute:
+		return d.Round(time.Second).String()
+	case d >= time.Second:
+		return d.Round(100 * time.Millisecond).String()
+	}
+	return d.String()
+}
+
 func (g *Generator) Sparkline(values []float64) string {
 	if len(values) == 0 {
 		return ""
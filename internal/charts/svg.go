@@ -0,0 +1,127 @@
+package charts
+
+import (
+	"fmt"
+	"html"
+	"sort"
+	"strings"
+
+	"github.com/testkube/dashboard/internal/database"
+)
+
+// Layout of the static SVG charts, in user units
+const (
+	svgWidth   = 640
+	svgHeight  = 240
+	svgPadLeft = 48
+	svgPadTop  = 32
+	svgPadBot  = 28
+	svgPadEnd  = 16
+)
+
+// PassRateSVG renders a pass rate trend as a standalone SVG document. Unlike
+// PassRateChart it needs no JavaScript, so it displays anywhere, including
+// in offline report bundles.
+func (g *Generator) PassRateSVG(data []database.DataPoint) string {
+	data = byDate(data)
+	values := make([]float64, len(data))
+	for i, dp := range data {
+		values[i] = dp.PassRate
+	}
+
+	var b strings.Builder
+	svgOpen(&b, "Pass Rate Trend")
+	svgAxes(&b, data, 100, "%")
+	if len(values) > 0 {
+		points := make([]string, len(values))
+		for i, v := range values {
+			x, y := svgPoint(i, len(values), v, 100)
+			points[i] = fmt.Sprintf("%.1f,%.1f", x, y)
+		}
+		fmt.Fprintf(&b, `<polyline points="%s" fill="none" stroke="#16a34a" stroke-width="2"/>`+"\n", strings.Join(points, " "))
+	}
+	b.WriteString("</svg>\n")
+	return b.String()
+}
+
+// DurationSVG renders average and P95 duration trends as a standalone SVG
+// document of paired bars
+func (g *Generator) DurationSVG(data []database.DataPoint) string {
+	data = byDate(data)
+	max := 0.0
+	for _, dp := range data {
+		if dp.AvgDuration > max {
+			max = dp.AvgDuration
+		}
+		if dp.P95Duration > max {
+			max = dp.P95Duration
+		}
+	}
+	if max == 0 {
+		max = 1
+	}
+
+	var b strings.Builder
+	svgOpen(&b, "Test Duration Trend (average and P95)")
+	svgAxes(&b, data, max, "")
+	if len(data) > 0 {
+		slot := float64(svgWidth-svgPadLeft-svgPadEnd) / float64(len(data))
+		bar := slot * 0.4
+		base := float64(svgHeight - svgPadBot)
+		for i, dp := range data {
+			x := float64(svgPadLeft) + slot*float64(i) + slot*0.1
+			for j, v := range []float64{dp.AvgDuration, dp.P95Duration} {
+				h := v / max * float64(svgHeight-svgPadTop-svgPadBot)
+				fill := "#2563eb"
+				if j == 1 {
+					fill = "#93c5fd"
+				}
+				fmt.Fprintf(&b, `<rect x="%.1f" y="%.1f" width="%.1f" height="%.1f" fill="%s"/>`+"\n",
+					x+bar*float64(j), base-h, bar, h, fill)
+			}
+		}
+	}
+	b.WriteString("</svg>\n")
+	return b.String()
+}
+
+// byDate returns data points oldest first, as the charts plot them
+func byDate(data []database.DataPoint) []database.DataPoint {
+	data = append([]database.DataPoint(nil), data...)
+	sort.SliceStable(data, func(i, j int) bool { return data[i].Date.Before(data[j].Date) })
+	return data
+}
+
+func svgOpen(b *strings.Builder, title string) {
+	fmt.Fprintf(b, `<svg xmlns="http://www.w3.org/2000/svg" viewBox="0 0 %d %d" width="%d" height="%d" font-family="sans-serif" font-size="11">`+"\n",
+		svgWidth, svgHeight, svgWidth, svgHeight)
+	fmt.Fprintf(b, `<title>%s</title>`+"\n", html.EscapeString(title))
+	fmt.Fprintf(b, `<text x="%d" y="18" font-size="14" font-weight="bold">%s</text>`+"\n", svgPadLeft, html.EscapeString(title))
+}
+
+// svgAxes draws the axes, the y axis scaled from zero to max, and the first
+// and last dates
+func svgAxes(b *strings.Builder, data []database.DataPoint, max float64, unit string) {
+	base := svgHeight - svgPadBot
+	fmt.Fprintf(b, `<line x1="%d" y1="%d" x2="%d" y2="%d" stroke="#9ca3af"/>`+"\n", svgPadLeft, svgPadTop, svgPadLeft, base)
+	fmt.Fprintf(b, `<line x1="%d" y1="%d" x2="%d" y2="%d" stroke="#9ca3af"/>`+"\n", svgPadLeft, base, svgWidth-svgPadEnd, base)
+	fmt.Fprintf(b, `<text x="%d" y="%d" text-anchor="end">%.0f%s</text>`+"\n", svgPadLeft-4, svgPadTop+4, max, unit)
+	fmt.Fprintf(b, `<text x="%d" y="%d" text-anchor="end">0%s</text>`+"\n", svgPadLeft-4, base, unit)
+	if len(data) == 0 {
+		fmt.Fprintf(b, `<text x="%d" y="%d" text-anchor="middle" fill="#6b7280">No data</text>`+"\n", svgWidth/2, svgHeight/2)
+		return
+	}
+	fmt.Fprintf(b, `<text x="%d" y="%d">%s</text>`+"\n", svgPadLeft, svgHeight-8, data[0].Date.Format("Jan 02"))
+	fmt.Fprintf(b, `<text x="%d" y="%d" text-anchor="end">%s</text>`+"\n", svgWidth-svgPadEnd, svgHeight-8, data[len(data)-1].Date.Format("Jan 02"))
+}
+
+// svgPoint places the i-th of n values in the plot area
+func svgPoint(i, n int, v, max float64) (float64, float64) {
+	plotWidth := float64(svgWidth - svgPadLeft - svgPadEnd)
+	plotHeight := float64(svgHeight - svgPadTop - svgPadBot)
+	x := float64(svgPadLeft) + plotWidth/2
+	if n > 1 {
+		x = float64(svgPadLeft) + float64(i)*plotWidth/float64(n-1)
+	}
+	return x, float64(svgHeight-svgPadBot) - v/max*plotHeight
+}
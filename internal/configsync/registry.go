@@ -0,0 +1,142 @@
+package configsync
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// BundleVersion is the current configuration bundle format version
+const BundleVersion = 1
+
+// Bundle is the portable representation of the dashboard's configuration.
+// Each subsystem owns one named section and defines its own JSON shape.
+type Bundle struct {
+	Version  int                        `json:"version"`
+	Sections map[string]json.RawMessage `json:"sections"`
+}
+
+// Section is implemented by subsystems whose configuration can be exported,
+// imported and kept in sync with a Git repository.
+type Section interface {
+	Export() (json.RawMessage, error)
+	Import(data json.RawMessage) error
+}
+
+// Registry holds the configuration sections known to the dashboard
+type Registry struct {
+	sections map[string]Section
+	mu       sync.RWMutex
+}
+
+func NewRegistry() *Registry {
+	return &Registry{
+		sections: make(map[string]Section),
+	}
+}
+
+// Register adds a named section. Registering the same name twice replaces
+// the previous section.
+func (r *Registry) Register(name string, section Section) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.sections[name] = section
+}
+
+// Names returns the registered section names in sorted order
+func (r *Registry) Names() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	names := make([]string, 0, len(r.sections))
+	for name := range r.sections {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Export builds a bundle from the live configuration of every section
+func (r *Registry) Export() (*Bundle, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	bundle := &Bundle{
+		Version:  BundleVersion,
+		Sections: make(map[string]json.RawMessage, len(r.sections)),
+	}
+	for name, section := range r.sections {
+		data, err := section.Export()
+		if err != nil {
+			return nil, fmt.Errorf("failed to export section %s: %w", name, err)
+		}
+		bundle.Sections[name] = data
+	}
+	return bundle, nil
+}
+
+// Import applies every section present in the bundle. Sections the dashboard
+// doesn't know are reported as an error so typos don't go unnoticed;
+// sections missing from the bundle are left untouched.
+func (r *Registry) Import(bundle *Bundle) error {
+	if bundle.Version > BundleVersion {
+		return fmt.Errorf("unsupported bundle version %d (max %d)", bundle.Version, BundleVersion)
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for name := range bundle.Sections {
+		if _, ok := r.sections[name]; !ok {
+			return fmt.Errorf("unknown configuration section: %s", name)
+		}
+	}
+	for name, data := range bundle.Sections {
+		if err := r.sections[name].Import(data); err != nil {
+			return fmt.Errorf("failed to import section %s: %w", name, err)
+		}
+	}
+	return nil
+}
+
+// Drift returns the names of sections whose live configuration differs from
+// the bundle
+func (r *Registry) Drift(bundle *Bundle) ([]string, error) {
+	live, err := r.Export()
+	if err != nil {
+		return nil, err
+	}
+
+	var drifted []string
+	for name, want := range bundle.Sections {
+		got, ok := live.Sections[name]
+		if !ok {
+			continue
+		}
+		equal, err := jsonEqual(want, got)
+		if err != nil {
+			return nil, fmt.Errorf("failed to compare section %s: %w", name, err)
+		}
+		if !equal {
+			drifted = append(drifted, name)
+		}
+	}
+	sort.Strings(drifted)
+	return drifted, nil
+}
+
+// jsonEqual compares two JSON documents ignoring formatting and key order
+func jsonEqual(a, b json.RawMessage) (bool, error) {
+	var va, vb interface{}
+	if err := json.Unmarshal(a, &va); err != nil {
+		return false, err
+	}
+	if err := json.Unmarshal(b, &vb); err != nil {
+		return false, err
+	}
+	na, _ := json.Marshal(va)
+	nb, _ := json.Marshal(vb)
+	return bytes.Equal(na, nb), nil
+}
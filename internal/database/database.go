@@ -1,6 +1,9 @@
 package database
 
 import (
+	"context"
+	"encoding/json"
+	"slices"
 	"time"
 
 	"github.com/testkube/dashboard/internal/testkube"
@@ -22,12 +25,24 @@ type DataPoint struct {
 }
 
 type FlakyTest struct {
-	TestName    string
-	TotalRuns   int
-	FailedRuns  int
-	PassedRuns  int
-	FlakyScore  float64
-	LastFailure time.Time
+	TestName     string
+	WorkflowName string
+	FilePath     string
+	TotalRuns    int
+	FailedRuns   int
+	PassedRuns   int
+	FlakyScore   float64
+	LastFailure  time.Time
+}
+
+// RetryPassTest summarizes a test that passed only after being retried
+type RetryPassTest struct {
+	TestName      string
+	FilePath      string
+	TotalRuns     int
+	PassedOnRetry int
+	RetryPassRate float64 // PassedOnRetry / TotalRuns
+	LastSeen      time.Time
 }
 
 type TestCase struct {
@@ -38,6 +53,37 @@ type TestCase struct {
 	DurationMs   int
 	ErrorMessage string
 	RetryCount   int
+	// Suite is the Path of the innermost suite the test is in, if its
+	// report has suites
+	Suite string
+}
+
+// TestSuite is a group of tests within an execution, such as a project,
+// file or describe block. Its counts cover the tests in it and in the
+// suites nested in it.
+type TestSuite struct {
+	ExecutionID string `json:"executionId"`
+	// Path is the suite's name after those of the suites it's nested in,
+	// outermost first, joined by " > "
+	Path string `json:"path"`
+	Name string `json:"name"`
+	// Parent is the Path of the suite it's nested in, empty at the top
+	Parent     string `json:"parent,omitempty"`
+	Depth      int    `json:"depth"`
+	Total      int    `json:"total"`
+	Passed     int    `json:"passed"`
+	Failed     int    `json:"failed"`
+	Skipped    int    `json:"skipped"`
+	DurationMs int    `json:"durationMs"`
+}
+
+// PassRate is the percentage of the suite's tests that ran and passed
+func (s TestSuite) PassRate() int {
+	ran := s.Passed + s.Failed
+	if ran == 0 {
+		return 0
+	}
+	return s.Passed * 100 / ran
 }
 
 type K6MetricRecord struct {
@@ -51,17 +97,377 @@ type K6MetricRecord struct {
 	P99Value    float64
 }
 
+// CheckResult is one run of a synthetic uptime check
+type CheckResult struct {
+	CheckID    string    `json:"checkId"`
+	Time       time.Time `json:"time"`
+	StatusCode int       `json:"statusCode,omitempty"`
+	LatencyMs  int       `json:"latencyMs"`
+	OK         bool      `json:"ok"`
+	Error      string    `json:"error,omitempty"`
+}
+
+// TestLocation is a test file a workflow has run, used to map source
+// changes to the tests they affect
+type TestLocation struct {
+	Workflow string
+	TestName string
+	FilePath string
+	LastRun  time.Time
+}
+
+// ArtifactRecord is an artifact as it was when its execution was ingested,
+// kept so later downloads can be checked for tampering or corruption
+type ArtifactRecord struct {
+	ExecutionID string    `json:"executionId"`
+	Name        string    `json:"name"`
+	Path        string    `json:"path"`
+	Size        int64     `json:"size"`
+	SHA256      string    `json:"sha256,omitempty"` // empty for artifacts too large to hash
+	RecordedAt  time.Time `json:"recordedAt"`
+}
+
+// FeatureOverride switches a feature flag on or off, for one tenant or, with
+// an empty Tenant, for everyone
+type FeatureOverride struct {
+	Flag      string    `json:"flag"`
+	Tenant    string    `json:"tenant,omitempty"`
+	Enabled   bool      `json:"enabled"`
+	UpdatedBy string    `json:"updatedBy,omitempty"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+// VariablePreset is a named set of config variables a workflow can be run
+// with, e.g. "staging" or "perf-env"
+type VariablePreset struct {
+	Workflow  string            `json:"workflow"`
+	Name      string            `json:"name"`
+	Variables map[string]string `json:"variables"`
+	UpdatedBy string            `json:"updatedBy,omitempty"`
+	UpdatedAt time.Time         `json:"updatedAt"`
+}
+
+// DeadLetter is an artifact the ingestion worker gave up parsing after
+// repeated failures, kept to inspect and retry once the parser is fixed
+type DeadLetter struct {
+	ExecutionID  string    `json:"executionId"`
+	WorkflowName string    `json:"workflowName"`
+	Path         string    `json:"path"`
+	Parser       string    `json:"parser"`
+	Error        string    `json:"error"`
+	Attempts     int       `json:"attempts"`
+	FirstFailed  time.Time `json:"firstFailed"`
+	LastFailed   time.Time `json:"lastFailed"`
+}
+
+// Event is something done through the dashboard or by one of its
+// subsystems, recorded for the activity feed
+type Event struct {
+	Time    time.Time `json:"time"`
+	Type    string    `json:"type"`            // e.g. "run.triggered"
+	Actor   string    `json:"actor,omitempty"` // who did it, when known
+	Subject string    `json:"subject"`         // the workflow, environment or user acted on
+	Message string    `json:"message"`
+	URL     string    `json:"url,omitempty"`
+}
+
+// Statuses of a TestLink. Auto and merged links carry the old name's
+// history over to the new one; suggested links wait for someone to merge or
+// split them, and split links record that the tests only look alike.
+const (
+	TestLinkAuto      = "auto"
+	TestLinkSuggested = "suggested"
+	TestLinkMerged    = "merged"
+	TestLinkSplit     = "split"
+)
+
+// TestLink says a workflow's test named From was renamed To, so its
+// duration and flakiness history continues under the new name
+type TestLink struct {
+	Workflow   string    `json:"workflow"`
+	From       string    `json:"from"`
+	To         string    `json:"to"`
+	FilePath   string    `json:"filePath,omitempty"`
+	Similarity float64   `json:"similarity"`
+	Status     string    `json:"status"`
+	UpdatedBy  string    `json:"updatedBy,omitempty"`
+	UpdatedAt  time.Time `json:"updatedAt"`
+}
+
+// Applied reports whether history queries follow the link
+func (l TestLink) Applied() bool {
+	return l.Status == TestLinkAuto || l.Status == TestLinkMerged
+}
+
+// Events a Watch can subscribe to
+const (
+	WatchFailure  = "failure"
+	WatchRecovery = "recovery"
+	WatchFlaky    = "flaky"
+)
+
+// WatchEvents lists the events a Watch can subscribe to
+var WatchEvents = []string{WatchFailure, WatchRecovery, WatchFlaky}
+
+// Watch is a workflow, or with Test one of its tests, a user starred.
+// Events are those the user is notified of; a watch with none only keeps
+// it on their watchlist.
+type Watch struct {
+	User      string    `json:"user"`
+	Workflow  string    `json:"workflow"`
+	Test      string    `json:"test,omitempty"`
+	Events    []string  `json:"events"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// Subscribed reports whether the watch notifies of an event
+func (w Watch) Subscribed(event string) bool {
+	return slices.Contains(w.Events, event)
+}
+
+// UserChannel is where a user's subscriptions are delivered: a
+// Slack-compatible incoming webhook, e.g. one posting to their direct
+// messages
+type UserChannel struct {
+	User       string    `json:"user"`
+	WebhookURL string    `json:"webhookUrl"`
+	UpdatedAt  time.Time `json:"updatedAt"`
+}
+
+// StoredReport is a report generated on a schedule and kept for later
+// viewing, such as the weekly quality report keyed by the Monday its week
+// starts. Data is the report as JSON.
+type StoredReport struct {
+	Kind        string          `json:"kind"`
+	Period      string          `json:"period"`
+	GeneratedAt time.Time       `json:"generatedAt"`
+	Data        json.RawMessage `json:"data"`
+}
+
+// IngestCursor is how far the worker has ingested a workflow's executions,
+// in the order they finished: every execution that finished before
+// FinishedAt, or at it with an ID up to ExecutionID, has been stored
+type IngestCursor struct {
+	Workflow    string    `json:"workflow"`
+	FinishedAt  time.Time `json:"finishedAt"`
+	ExecutionID string    `json:"executionId,omitempty"`
+	UpdatedAt   time.Time `json:"updatedAt"`
+}
+
+// EvidenceRecord is a signature issued for an evidence bundle, kept so the
+// bundle still verifies after a restart
+type EvidenceRecord struct {
+	ExecutionID string    `json:"executionId"`
+	Digest      string    `json:"digest"`
+	Algorithm   string    `json:"algorithm"`
+	KeyID       string    `json:"keyId"`
+	Signature   string    `json:"signature"` // base64
+	Files       []string  `json:"files"`
+	SignedAt    time.Time `json:"signedAt"`
+	SignedBy    string    `json:"signedBy,omitempty"`
+}
+
+// VisualBaseline is the approved screenshot a workflow's screenshots at a
+// path are compared with
+type VisualBaseline struct {
+	Workflow    string    `json:"workflow"`
+	Path        string    `json:"path"`
+	ExecutionID string    `json:"executionId"`
+	ApprovedAt  time.Time `json:"approvedAt"`
+	ApprovedBy  string    `json:"approvedBy,omitempty"`
+	Image       []byte    `json:"image"`
+}
+
+// WorkflowHistory summarizes the executions ingested for a workflow
+type WorkflowHistory struct {
+	Workflow   string    `json:"workflow"`
+	Executions int       `json:"executions"`
+	FirstRun   time.Time `json:"firstRun"`
+	LastRun    time.Time `json:"lastRun"`
+}
+
+// EventFilter selects events. Zero fields match every event.
+type EventFilter struct {
+	Since time.Time
+	Types []string
+	Actor string
+	Limit int
+}
+
+// ExecutionQuery selects ingested executions by when they started, a page
+// at a time. Zero fields match every execution.
+type ExecutionQuery struct {
+	Workflows []string // any of these workflows
+	Status    string
+	Outcome   string    // e.g. testkube.OutcomeInfraError
+	From      time.Time // inclusive
+	To        time.Time // exclusive
+	// Labels matches executions carrying all of these labels, including
+	// those inherited from their workflow at ingestion
+	Labels map[string]string
+	// Branch and Commit match the source CI ran executions for
+	Branch string
+	Commit string
+	Limit  int
+	Offset int
+}
+
+// TestCaseQuery selects ingested test results across executions, a page at
+// a time. Zero fields match every result.
+type TestCaseQuery struct {
+	Name     string // part of the test name, in any case
+	Status   string
+	Workflow string
+	From     time.Time // inclusive, by the execution's start time
+	To       time.Time // exclusive
+	Limit    int
+	Offset   int
+}
+
+// TestCaseResult is a test result with the execution it ran in
+type TestCaseResult struct {
+	TestCase
+	WorkflowName string
+	StartedAt    time.Time
+}
+
+// Snapshot is everything the database stores, for backups and migrating
+// between clusters
+type Snapshot struct {
+	Executions       []testkube.Execution `json:"executions"`
+	TestCases        []TestCase           `json:"testCases"`
+	K6Metrics        []K6MetricRecord     `json:"k6Metrics"`
+	CheckResults     []CheckResult        `json:"checkResults"`
+	Artifacts        []ArtifactRecord     `json:"artifacts"`
+	FeatureOverrides []FeatureOverride    `json:"featureOverrides"`
+	VariablePresets  []VariablePreset     `json:"variablePresets"`
+	DeadLetters      []DeadLetter         `json:"deadLetters"`
+	Events           []Event              `json:"events"`
+	TestLinks        []TestLink           `json:"testLinks"`
+	Watches          []Watch              `json:"watches"`
+	UserChannels     []UserChannel        `json:"userChannels"`
+	Reports          []StoredReport       `json:"reports"`
+	IngestCursors    []IngestCursor       `json:"ingestCursors"`
+	TestSuites       []TestSuite          `json:"testSuites"`
+	EvidenceRecords  []EvidenceRecord     `json:"evidenceRecords"`
+	VisualBaselines  []VisualBaseline     `json:"visualBaselines"`
+}
+
 type Database interface {
+	// Ping checks the database is reachable
+	Ping(ctx context.Context) error
+
 	InsertExecution(exec testkube.Execution) error
 	InsertTestCase(tc TestCase) error
+	// InsertTestSuites replaces the suites recorded for an execution
+	InsertTestSuites(executionID string, suites []TestSuite) error
 	InsertK6Metric(metric K6MetricRecord) error
+	InsertCheckResult(result CheckResult) error
+	// InsertArtifactManifest replaces the artifacts recorded for an execution
+	InsertArtifactManifest(executionID string, artifacts []ArtifactRecord) error
+	// SetFeatureOverride replaces any override for the same flag and tenant
+	SetFeatureOverride(override FeatureOverride) error
+	DeleteFeatureOverride(flag, tenant string) error
+	// SaveVariablePreset replaces any preset with the same workflow and name
+	SaveVariablePreset(preset VariablePreset) error
+	DeleteVariablePreset(workflow, name string) error
+	// SaveDeadLetter replaces any dead letter for the same artifact
+	SaveDeadLetter(letter DeadLetter) error
+	DeleteDeadLetter(executionID, path string) error
+	InsertEvent(event Event) error
+	// SaveTestLink replaces any link between the same workflow's tests
+	SaveTestLink(link TestLink) error
+	// SaveWatch replaces any watch of the same user, workflow and test
+	SaveWatch(watch Watch) error
+	DeleteWatch(user, workflow, test string) error
+	// SaveUserChannel replaces the user's channel
+	SaveUserChannel(channel UserChannel) error
+	// SaveReport replaces any report of the same kind and period
+	SaveReport(report StoredReport) error
+	// SaveIngestCursor replaces the workflow's ingestion cursor
+	SaveIngestCursor(cursor IngestCursor) error
+	DeleteIngestCursor(workflow string) error
+	InsertEvidenceRecord(record EvidenceRecord) error
+	// SaveVisualBaseline replaces any baseline of the same workflow and path
+	SaveVisualBaseline(baseline VisualBaseline) error
+	// PurgeWorkflow deletes a workflow's executions with everything recorded
+	// for them, and its presets, test links, watches and ingestion cursor,
+	// returning how many executions it deleted. The activity feed is kept.
+	PurgeWorkflow(workflow string) (int, error)
 
 	GetTrends(days int) (*TrendData, error)
 	GetWorkflowMetrics(workflow string, days int) ([]DataPoint, error)
 	GetPassRateTrend(workflow string, days int) ([]DataPoint, error)
 	GetDurationTrend(workflow string, days int) ([]DataPoint, error)
+	// GetDurationHistogram buckets the durations of a workflow's finished
+	// executions over the last days, or of every workflow's for an empty name
+	GetDurationHistogram(workflow string, days, buckets int) ([]DurationBucket, error)
 	GetFlakyTests(threshold float64) ([]FlakyTest, error)
+	// GetFlakyTestsBetween scores tests run between from and to. Tests that
+	// only ever passed or only ever failed in the window are not flaky. Like
+	// the other per-test queries, it counts renamed tests' runs under their
+	// current names by following applied TestLinks.
+	GetFlakyTestsBetween(from, to time.Time) ([]FlakyTest, error)
+	GetPassedOnRetryTests(days int, limit int) ([]RetryPassTest, error)
 
+	// QueryExecutions returns a page of the ingested executions matching a
+	// query, newest first, and how many match across all pages. The
+	// started_at indexes keep date ranges cheap however long the history.
+	QueryExecutions(query ExecutionQuery) ([]testkube.Execution, int, error)
+	// GetWorkflowHistories summarizes every workflow with ingested
+	// executions, most recently run first
+	GetWorkflowHistories() ([]WorkflowHistory, error)
 	GetExecutionMetrics(executionID string) ([]TestCase, error)
+	// GetTestSuites returns an execution's suites, each before those nested
+	// in it
+	GetTestSuites(executionID string) ([]TestSuite, error)
+	// QueryTestCases returns a page of the ingested test results matching a
+	// query, newest first, and how many match across all pages. Renamed
+	// tests are matched and returned under their current names.
+	QueryTestCases(query TestCaseQuery) ([]TestCaseResult, int, error)
 	GetK6Metrics(executionID string) ([]K6MetricRecord, error)
+	// GetArtifactManifest returns the artifacts recorded when an execution was
+	// ingested, or none if it hasn't been
+	GetArtifactManifest(executionID string) ([]ArtifactRecord, error)
+	// GetCheckResults returns a synthetic check's results since a time, oldest first
+	GetCheckResults(checkID string, since time.Time) ([]CheckResult, error)
+	// GetTestLocations returns the distinct tests each workflow ran in the
+	// last days, with their file paths
+	GetTestLocations(days int) ([]TestLocation, error)
+	GetFeatureOverrides() ([]FeatureOverride, error)
+	// GetVariablePresets returns a workflow's presets, by name
+	GetVariablePresets(workflow string) ([]VariablePreset, error)
+	// GetDeadLetters returns the artifacts that failed to parse, most
+	// recently failed first
+	GetDeadLetters() ([]DeadLetter, error)
+	// GetEvents returns the events matching a filter, newest first
+	GetEvents(filter EventFilter) ([]Event, error)
+	// GetTestLinks returns a workflow's test links, or every workflow's for
+	// an empty name
+	GetTestLinks(workflow string) ([]TestLink, error)
+	// GetWatches returns a user's watches, or every user's for an empty
+	// name, by workflow and test
+	GetWatches(user string) ([]Watch, error)
+	// GetUserChannel returns the user's channel, or nil if they have none
+	GetUserChannel(user string) (*UserChannel, error)
+	// GetReport returns the report of a kind for a period, or nil if none
+	// was generated
+	GetReport(kind, period string) (*StoredReport, error)
+	// GetReports returns the reports of a kind, the latest period first
+	GetReports(kind string) ([]StoredReport, error)
+	// GetIngestCursors returns every workflow's ingestion cursor, by workflow
+	GetIngestCursors() ([]IngestCursor, error)
+	// GetEvidenceRecords returns the signatures issued for an execution's
+	// bundles, or every execution's for an empty ID, oldest first
+	GetEvidenceRecords(executionID string) ([]EvidenceRecord, error)
+	// GetEvidenceRecord returns the signature issued for the bundle with a
+	// digest, or nil if none was
+	GetEvidenceRecord(digest string) (*EvidenceRecord, error)
+	// GetVisualBaselines returns every screenshot baseline
+	GetVisualBaselines() ([]VisualBaseline, error)
+
+	// Snapshot returns everything stored
+	Snapshot() (*Snapshot, error)
+	// Restore replaces everything stored with the snapshot
+	Restore(snapshot *Snapshot) error
 }
@@ -0,0 +1,47 @@
+package database
+
+import (
+	"sort"
+	"time"
+)
+
+// DefaultHistogramBuckets is how many buckets a duration histogram has
+// unless asked for another number
+const DefaultHistogramBuckets = 20
+
+// DurationBucket counts the executions whose duration was at least LowerMs
+// and under UpperMs. The last bucket of a histogram also includes UpperMs.
+type DurationBucket struct {
+	LowerMs int64 `json:"lowerMs"`
+	UpperMs int64 `json:"upperMs"`
+	Count   int   `json:"count"`
+}
+
+// BucketDurations spreads durations over equal-width buckets from the
+// shortest to the longest. Unlike an average it keeps the shape of the
+// distribution, so a workflow that either fails fast or runs in full shows
+// two peaks.
+func BucketDurations(durations []time.Duration, buckets int) []DurationBucket {
+	if len(durations) == 0 || buckets <= 0 {
+		return []DurationBucket{}
+	}
+	ms := make([]int64, len(durations))
+	for i, d := range durations {
+		ms[i] = d.Milliseconds()
+	}
+	sort.Slice(ms, func(i, j int) bool { return ms[i] < ms[j] })
+	lo, hi := ms[0], ms[len(ms)-1]
+
+	// Widths are whole milliseconds, so a narrow range has fewer buckets
+	width := max((hi-lo+int64(buckets)-1)/int64(buckets), 1)
+	n := int(min((hi-lo)/width+1, int64(buckets)))
+	result := make([]DurationBucket, n)
+	for i := range result {
+		result[i] = DurationBucket{LowerMs: lo + int64(i)*width, UpperMs: lo + int64(i+1)*width}
+	}
+	for _, v := range ms {
+		i := min(int((v-lo)/width), n-1)
+		result[i].Count++
+	}
+	return result
+}
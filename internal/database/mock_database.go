@@ -1,7 +1,12 @@
 package database
 
 import (
+	"context"
 	"math/rand"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/testkube/dashboard/internal/testkube"
@@ -10,29 +15,436 @@ import (
 type MockDatabase struct {
 	executions []testkube.Execution
 	testCases  []TestCase
+	k6Metrics  []K6MetricRecord
+	checks     []CheckResult
+	manifests  map[string][]ArtifactRecord
+	overrides  []FeatureOverride
+	presets    []VariablePreset
+	dead       []DeadLetter
+	events     []Event
+	links      []TestLink
+	watches    []Watch
+	channels   []UserChannel
+	reports    []StoredReport
+	cursors    []IngestCursor
+	suites     []TestSuite
+	evidence   []EvidenceRecord
+	visual     []VisualBaseline
+	mu         sync.RWMutex
 }
 
 func NewMockDatabase() *MockDatabase {
 	return &MockDatabase{
 		executions: []testkube.Execution{},
 		testCases:  []TestCase{},
+		manifests:  make(map[string][]ArtifactRecord),
 	}
 }
 
+func (db *MockDatabase) Ping(ctx context.Context) error {
+	return nil
+}
+
 func (db *MockDatabase) InsertExecution(exec testkube.Execution) error {
+	db.mu.Lock()
+	defer db.mu.Unlock()
 	db.executions = append(db.executions, exec)
 	return nil
 }
 
 func (db *MockDatabase) InsertTestCase(tc TestCase) error {
+	db.mu.Lock()
+	defer db.mu.Unlock()
 	db.testCases = append(db.testCases, tc)
 	return nil
 }
 
+func (db *MockDatabase) InsertTestSuites(executionID string, suites []TestSuite) error {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	db.suites = slices.DeleteFunc(db.suites, func(s TestSuite) bool { return s.ExecutionID == executionID })
+	for _, suite := range suites {
+		suite.ExecutionID = executionID
+		db.suites = append(db.suites, suite)
+	}
+	return nil
+}
+
+func (db *MockDatabase) GetTestSuites(executionID string) ([]TestSuite, error) {
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+	var suites []TestSuite
+	for _, s := range db.suites {
+		if s.ExecutionID == executionID {
+			suites = append(suites, s)
+		}
+	}
+	return suites, nil
+}
+
 func (db *MockDatabase) InsertK6Metric(metric K6MetricRecord) error {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	db.k6Metrics = append(db.k6Metrics, metric)
+	return nil
+}
+
+func (db *MockDatabase) InsertCheckResult(result CheckResult) error {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	db.checks = append(db.checks, result)
+	return nil
+}
+
+func (db *MockDatabase) InsertArtifactManifest(executionID string, artifacts []ArtifactRecord) error {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	db.manifests[executionID] = append([]ArtifactRecord(nil), artifacts...)
+	return nil
+}
+
+func (db *MockDatabase) SetFeatureOverride(override FeatureOverride) error {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	for i, o := range db.overrides {
+		if o.Flag == override.Flag && o.Tenant == override.Tenant {
+			db.overrides[i] = override
+			return nil
+		}
+	}
+	db.overrides = append(db.overrides, override)
+	return nil
+}
+
+func (db *MockDatabase) DeleteFeatureOverride(flag, tenant string) error {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	for i, o := range db.overrides {
+		if o.Flag == flag && o.Tenant == tenant {
+			db.overrides = append(db.overrides[:i], db.overrides[i+1:]...)
+			return nil
+		}
+	}
+	return nil
+}
+
+func (db *MockDatabase) SaveVariablePreset(preset VariablePreset) error {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	for i, p := range db.presets {
+		if p.Workflow == preset.Workflow && p.Name == preset.Name {
+			db.presets[i] = preset
+			return nil
+		}
+	}
+	db.presets = append(db.presets, preset)
+	return nil
+}
+
+func (db *MockDatabase) DeleteVariablePreset(workflow, name string) error {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	for i, p := range db.presets {
+		if p.Workflow == workflow && p.Name == name {
+			db.presets = append(db.presets[:i], db.presets[i+1:]...)
+			return nil
+		}
+	}
+	return nil
+}
+
+func (db *MockDatabase) SaveWatch(watch Watch) error {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	for i, w := range db.watches {
+		if w.User == watch.User && w.Workflow == watch.Workflow && w.Test == watch.Test {
+			db.watches[i] = watch
+			return nil
+		}
+	}
+	db.watches = append(db.watches, watch)
+	return nil
+}
+
+func (db *MockDatabase) DeleteWatch(user, workflow, test string) error {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	db.watches = slices.DeleteFunc(db.watches, func(w Watch) bool {
+		return w.User == user && w.Workflow == workflow && w.Test == test
+	})
+	return nil
+}
+
+func (db *MockDatabase) GetWatches(user string) ([]Watch, error) {
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+	var watches []Watch
+	for _, w := range db.watches {
+		if user == "" || w.User == user {
+			watches = append(watches, w)
+		}
+	}
+	sort.SliceStable(watches, func(i, j int) bool {
+		if watches[i].Workflow != watches[j].Workflow {
+			return watches[i].Workflow < watches[j].Workflow
+		}
+		return watches[i].Test < watches[j].Test
+	})
+	return watches, nil
+}
+
+func (db *MockDatabase) SaveUserChannel(channel UserChannel) error {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	for i, c := range db.channels {
+		if c.User == channel.User {
+			db.channels[i] = channel
+			return nil
+		}
+	}
+	db.channels = append(db.channels, channel)
+	return nil
+}
+
+func (db *MockDatabase) GetUserChannel(user string) (*UserChannel, error) {
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+	for _, c := range db.channels {
+		if c.User == user {
+			return &c, nil
+		}
+	}
+	return nil, nil
+}
+
+func (db *MockDatabase) SaveReport(report StoredReport) error {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	for i, r := range db.reports {
+		if r.Kind == report.Kind && r.Period == report.Period {
+			db.reports[i] = report
+			return nil
+		}
+	}
+	db.reports = append(db.reports, report)
+	return nil
+}
+
+func (db *MockDatabase) GetReport(kind, period string) (*StoredReport, error) {
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+	for _, r := range db.reports {
+		if r.Kind == kind && r.Period == period {
+			return &r, nil
+		}
+	}
+	return nil, nil
+}
+
+func (db *MockDatabase) GetReports(kind string) ([]StoredReport, error) {
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+	var reports []StoredReport
+	for _, r := range db.reports {
+		if r.Kind == kind {
+			reports = append(reports, r)
+		}
+	}
+	sort.Slice(reports, func(i, j int) bool { return reports[i].Period > reports[j].Period })
+	return reports, nil
+}
+
+func (db *MockDatabase) SaveIngestCursor(cursor IngestCursor) error {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	for i, c := range db.cursors {
+		if c.Workflow == cursor.Workflow {
+			db.cursors[i] = cursor
+			return nil
+		}
+	}
+	db.cursors = append(db.cursors, cursor)
+	return nil
+}
+
+func (db *MockDatabase) DeleteIngestCursor(workflow string) error {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	db.cursors = slices.DeleteFunc(db.cursors, func(c IngestCursor) bool { return c.Workflow == workflow })
+	return nil
+}
+
+func (db *MockDatabase) GetIngestCursors() ([]IngestCursor, error) {
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+	cursors := append([]IngestCursor{}, db.cursors...)
+	sort.Slice(cursors, func(i, j int) bool { return cursors[i].Workflow < cursors[j].Workflow })
+	return cursors, nil
+}
+
+func (db *MockDatabase) InsertEvidenceRecord(record EvidenceRecord) error {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	db.evidence = append(db.evidence, record)
+	return nil
+}
+
+func (db *MockDatabase) GetEvidenceRecords(executionID string) ([]EvidenceRecord, error) {
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+	var records []EvidenceRecord
+	for _, r := range db.evidence {
+		if executionID == "" || r.ExecutionID == executionID {
+			records = append(records, r)
+		}
+	}
+	return records, nil
+}
+
+func (db *MockDatabase) GetEvidenceRecord(digest string) (*EvidenceRecord, error) {
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+	for _, r := range db.evidence {
+		if r.Digest == digest {
+			return &r, nil
+		}
+	}
+	return nil, nil
+}
+
+func (db *MockDatabase) SaveVisualBaseline(baseline VisualBaseline) error {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	for i, b := range db.visual {
+		if b.Workflow == baseline.Workflow && b.Path == baseline.Path {
+			db.visual[i] = baseline
+			return nil
+		}
+	}
+	db.visual = append(db.visual, baseline)
+	return nil
+}
+
+func (db *MockDatabase) GetVisualBaselines() ([]VisualBaseline, error) {
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+	return append([]VisualBaseline{}, db.visual...), nil
+}
+
+func (db *MockDatabase) PurgeWorkflow(workflow string) (int, error) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	ids := map[string]bool{}
+	db.executions = slices.DeleteFunc(db.executions, func(e testkube.Execution) bool {
+		if e.WorkflowName != workflow {
+			return false
+		}
+		ids[e.ID] = true
+		return true
+	})
+	db.testCases = slices.DeleteFunc(db.testCases, func(tc TestCase) bool { return ids[tc.ExecutionID] })
+	db.k6Metrics = slices.DeleteFunc(db.k6Metrics, func(m K6MetricRecord) bool { return ids[m.ExecutionID] })
+	db.suites = slices.DeleteFunc(db.suites, func(s TestSuite) bool { return ids[s.ExecutionID] })
+	for id := range ids {
+		delete(db.manifests, id)
+	}
+	db.dead = slices.DeleteFunc(db.dead, func(d DeadLetter) bool { return d.WorkflowName == workflow || ids[d.ExecutionID] })
+	db.presets = slices.DeleteFunc(db.presets, func(p VariablePreset) bool { return p.Workflow == workflow })
+	db.links = slices.DeleteFunc(db.links, func(l TestLink) bool { return l.Workflow == workflow })
+	db.watches = slices.DeleteFunc(db.watches, func(w Watch) bool { return w.Workflow == workflow })
+	db.cursors = slices.DeleteFunc(db.cursors, func(c IngestCursor) bool { return c.Workflow == workflow })
+	return len(ids), nil
+}
+
+func (db *MockDatabase) SaveDeadLetter(letter DeadLetter) error {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	for i, d := range db.dead {
+		if d.ExecutionID == letter.ExecutionID && d.Path == letter.Path {
+			db.dead[i] = letter
+			return nil
+		}
+	}
+	db.dead = append(db.dead, letter)
+	return nil
+}
+
+func (db *MockDatabase) DeleteDeadLetter(executionID, path string) error {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	for i, d := range db.dead {
+		if d.ExecutionID == executionID && d.Path == path {
+			db.dead = append(db.dead[:i], db.dead[i+1:]...)
+			return nil
+		}
+	}
+	return nil
+}
+
+func (db *MockDatabase) InsertEvent(event Event) error {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	db.events = append(db.events, event)
+	return nil
+}
+
+func (db *MockDatabase) SaveTestLink(link TestLink) error {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	for i, l := range db.links {
+		if l.Workflow == link.Workflow && l.From == link.From && l.To == link.To {
+			db.links[i] = link
+			return nil
+		}
+	}
+	db.links = append(db.links, link)
 	return nil
 }
 
+func (db *MockDatabase) GetTestLinks(workflow string) ([]TestLink, error) {
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+	var result []TestLink
+	for _, l := range db.links {
+		if workflow == "" || l.Workflow == workflow {
+			result = append(result, l)
+		}
+	}
+	sort.SliceStable(result, func(i, j int) bool {
+		if result[i].Workflow != result[j].Workflow {
+			return result[i].Workflow < result[j].Workflow
+		}
+		return result[i].UpdatedAt.After(result[j].UpdatedAt)
+	})
+	return result, nil
+}
+
+// testName returns a function giving a workflow's test's current name,
+// following applied links through every rename. Where a name was linked
+// to more than one test, the latest link wins. db.mu must be held.
+func (db *MockDatabase) testName() func(workflow, name string) string {
+	type key struct{ workflow, name string }
+	renamed := make(map[key]TestLink)
+	for _, l := range db.links {
+		k := key{l.Workflow, l.From}
+		if prev, ok := renamed[k]; l.Applied() && (!ok || l.UpdatedAt.After(prev.UpdatedAt)) {
+			renamed[k] = l
+		}
+	}
+	return func(workflow, name string) string {
+		seen := map[string]bool{name: true}
+		for {
+			l, ok := renamed[key{workflow, name}]
+			if !ok || seen[l.To] {
+				return name
+			}
+			name = l.To
+			seen[name] = true
+		}
+	}
+}
+
 func (db *MockDatabase) GetTrends(days int) (*TrendData, error) {
 	return &TrendData{
 		CurrentPassRate: 85.5,
@@ -67,6 +479,23 @@ func (db *MockDatabase) GetDurationTrend(workflow string, days int) ([]DataPoint
 	return db.GetWorkflowMetrics(workflow, days)
 }
 
+func (db *MockDatabase) GetDurationHistogram(workflow string, days, buckets int) ([]DurationBucket, error) {
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+	since := time.Now().AddDate(0, 0, -days)
+	var durations []time.Duration
+	for _, e := range db.executions {
+		if workflow != "" && e.WorkflowName != workflow {
+			continue
+		}
+		if e.Duration <= 0 || e.StartTime.Before(since) {
+			continue
+		}
+		durations = append(durations, e.Duration)
+	}
+	return BucketDurations(durations, buckets), nil
+}
+
 func (db *MockDatabase) GetFlakyTests(threshold float64) ([]FlakyTest, error) {
 	return []FlakyTest{
 		{TestName: "Checkout Process", FlakyScore: 0.45, LastFailure: time.Now().Add(-2 * time.Hour)},
@@ -74,7 +503,129 @@ func (db *MockDatabase) GetFlakyTests(threshold float64) ([]FlakyTest, error) {
 	}, nil
 }
 
+func (db *MockDatabase) GetFlakyTestsBetween(from, to time.Time) ([]FlakyTest, error) {
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+
+	executions := make(map[string]testkube.Execution, len(db.executions))
+	for _, e := range db.executions {
+		executions[e.ID] = e
+	}
+
+	testName := db.testName()
+	type key struct{ workflow, test string }
+	stats := make(map[key]*FlakyTest)
+	var order []key
+	for _, tc := range db.testCases {
+		exec, known := executions[tc.ExecutionID]
+		if !known || exec.StartTime.Before(from) || !exec.StartTime.Before(to) {
+			continue
+		}
+
+		k := key{exec.WorkflowName, testName(exec.WorkflowName, tc.TestName)}
+		stat, ok := stats[k]
+		if !ok {
+			stat = &FlakyTest{TestName: k.test, WorkflowName: exec.WorkflowName, FilePath: tc.FilePath}
+			stats[k] = stat
+			order = append(order, k)
+		}
+		stat.TotalRuns++
+		if tc.Status == "passed" {
+			stat.PassedRuns++
+		}
+		// A pass that needed retries is an intermittent failure too
+		if tc.Status == "failed" || (tc.Status == "passed" && tc.RetryCount > 0) {
+			stat.FailedRuns++
+			if exec.StartTime.After(stat.LastFailure) {
+				stat.LastFailure = exec.StartTime
+			}
+		}
+	}
+
+	var result []FlakyTest
+	for _, k := range order {
+		stat := stats[k]
+		if stat.FailedRuns == 0 || stat.PassedRuns == 0 {
+			continue
+		}
+		stat.FlakyScore = float64(stat.FailedRuns) / float64(stat.TotalRuns)
+		result = append(result, *stat)
+	}
+
+	sort.SliceStable(result, func(i, j int) bool {
+		return result[i].FlakyScore > result[j].FlakyScore
+	})
+	return result, nil
+}
+
+func (db *MockDatabase) GetPassedOnRetryTests(days int, limit int) ([]RetryPassTest, error) {
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+
+	executions := make(map[string]testkube.Execution, len(db.executions))
+	for _, e := range db.executions {
+		executions[e.ID] = e
+	}
+	since := time.Now().AddDate(0, 0, -days)
+	testName := db.testName()
+
+	stats := make(map[string]*RetryPassTest)
+	for _, tc := range db.testCases {
+		exec, known := executions[tc.ExecutionID]
+		started := exec.StartTime
+		if known && started.Before(since) {
+			continue
+		}
+
+		name := testName(exec.WorkflowName, tc.TestName)
+		stat, ok := stats[name]
+		if !ok {
+			stat = &RetryPassTest{TestName: name, FilePath: tc.FilePath}
+			stats[name] = stat
+		}
+		stat.TotalRuns++
+		if tc.Status == "passed" && tc.RetryCount > 0 {
+			stat.PassedOnRetry++
+			if started.After(stat.LastSeen) {
+				stat.LastSeen = started
+			}
+		}
+	}
+
+	var result []RetryPassTest
+	for _, stat := range stats {
+		if stat.PassedOnRetry == 0 {
+			continue
+		}
+		stat.RetryPassRate = float64(stat.PassedOnRetry) / float64(stat.TotalRuns)
+		result = append(result, *stat)
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].PassedOnRetry != result[j].PassedOnRetry {
+			return result[i].PassedOnRetry > result[j].PassedOnRetry
+		}
+		return result[i].TestName < result[j].TestName
+	})
+	if limit > 0 && len(result) > limit {
+		result = result[:limit]
+	}
+	return result, nil
+}
+
 func (db *MockDatabase) GetExecutionMetrics(executionID string) ([]TestCase, error) {
+	db.mu.RLock()
+	var stored []TestCase
+	for _, tc := range db.testCases {
+		if tc.ExecutionID == executionID {
+			stored = append(stored, tc)
+		}
+	}
+	db.mu.RUnlock()
+	if len(stored) > 0 {
+		return stored, nil
+	}
+
 	// Return dummy test cases for an execution
 	return []TestCase{
 		{TestName: "Login Page Loads", Status: "passed", DurationMs: 1200},
@@ -84,5 +635,308 @@ func (db *MockDatabase) GetExecutionMetrics(executionID string) ([]TestCase, err
 }
 
 func (db *MockDatabase) GetK6Metrics(executionID string) ([]K6MetricRecord, error) {
-	return []K6MetricRecord{}, nil
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+	metrics := []K6MetricRecord{}
+	for _, m := range db.k6Metrics {
+		if m.ExecutionID == executionID {
+			metrics = append(metrics, m)
+		}
+	}
+	return metrics, nil
+}
+
+func (db *MockDatabase) GetArtifactManifest(executionID string) ([]ArtifactRecord, error) {
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+	return append([]ArtifactRecord(nil), db.manifests[executionID]...), nil
+}
+
+func (db *MockDatabase) GetFeatureOverrides() ([]FeatureOverride, error) {
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+	return append([]FeatureOverride(nil), db.overrides...), nil
+}
+
+func (db *MockDatabase) GetVariablePresets(workflow string) ([]VariablePreset, error) {
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+
+	var presets []VariablePreset
+	for _, p := range db.presets {
+		if p.Workflow == workflow {
+			presets = append(presets, p)
+		}
+	}
+	sort.Slice(presets, func(i, j int) bool { return presets[i].Name < presets[j].Name })
+	return presets, nil
+}
+
+func (db *MockDatabase) GetDeadLetters() ([]DeadLetter, error) {
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+
+	letters := append([]DeadLetter(nil), db.dead...)
+	sort.Slice(letters, func(i, j int) bool { return letters[i].LastFailed.After(letters[j].LastFailed) })
+	return letters, nil
+}
+
+func (db *MockDatabase) GetEvents(filter EventFilter) ([]Event, error) {
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+
+	var events []Event
+	for _, e := range db.events {
+		if e.Time.Before(filter.Since) || (filter.Actor != "" && e.Actor != filter.Actor) {
+			continue
+		}
+		if len(filter.Types) > 0 && !slices.Contains(filter.Types, e.Type) {
+			continue
+		}
+		events = append(events, e)
+	}
+	sort.SliceStable(events, func(i, j int) bool { return events[i].Time.After(events[j].Time) })
+	if filter.Limit > 0 && len(events) > filter.Limit {
+		events = events[:filter.Limit]
+	}
+	return events, nil
+}
+
+func (db *MockDatabase) QueryExecutions(query ExecutionQuery) ([]testkube.Execution, int, error) {
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+
+	var matched []testkube.Execution
+	for _, e := range db.executions {
+		if len(query.Workflows) > 0 && !slices.Contains(query.Workflows, e.WorkflowName) {
+			continue
+		}
+		if query.Status != "" && e.Status != query.Status {
+			continue
+		}
+		if query.Outcome != "" && e.RunOutcome() != query.Outcome {
+			continue
+		}
+		if (query.Branch != "" && e.Branch != query.Branch) || (query.Commit != "" && e.Commit != query.Commit) {
+			continue
+		}
+		if e.StartTime.Before(query.From) || (!query.To.IsZero() && !e.StartTime.Before(query.To)) {
+			continue
+		}
+		if !hasLabels(e.Labels, query.Labels) {
+			continue
+		}
+		matched = append(matched, e)
+	}
+	sort.SliceStable(matched, func(i, j int) bool { return matched[i].StartTime.After(matched[j].StartTime) })
+
+	start := min(query.Offset, len(matched))
+	end := len(matched)
+	if query.Limit > 0 {
+		end = min(start+query.Limit, end)
+	}
+	return append([]testkube.Execution{}, matched[start:end]...), len(matched), nil
+}
+
+func (db *MockDatabase) QueryTestCases(query TestCaseQuery) ([]TestCaseResult, int, error) {
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+
+	executions := make(map[string]testkube.Execution, len(db.executions))
+	for _, e := range db.executions {
+		executions[e.ID] = e
+	}
+	testName := db.testName()
+	name := strings.ToLower(query.Name)
+
+	var matched []TestCaseResult
+	for _, tc := range db.testCases {
+		exec, known := executions[tc.ExecutionID]
+		if !known {
+			continue
+		}
+		if query.Workflow != "" && exec.WorkflowName != query.Workflow {
+			continue
+		}
+		if query.Status != "" && tc.Status != query.Status {
+			continue
+		}
+		if exec.StartTime.Before(query.From) || (!query.To.IsZero() && !exec.StartTime.Before(query.To)) {
+			continue
+		}
+		tc.TestName = testName(exec.WorkflowName, tc.TestName)
+		if !strings.Contains(strings.ToLower(tc.TestName), name) {
+			continue
+		}
+		matched = append(matched, TestCaseResult{TestCase: tc, WorkflowName: exec.WorkflowName, StartedAt: exec.StartTime})
+	}
+	sort.SliceStable(matched, func(i, j int) bool { return matched[i].StartedAt.After(matched[j].StartedAt) })
+
+	start := min(query.Offset, len(matched))
+	end := len(matched)
+	if query.Limit > 0 {
+		end = min(start+query.Limit, end)
+	}
+	return append([]TestCaseResult{}, matched[start:end]...), len(matched), nil
+}
+
+func (db *MockDatabase) GetWorkflowHistories() ([]WorkflowHistory, error) {
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+
+	byName := map[string]*WorkflowHistory{}
+	var histories []*WorkflowHistory
+	for _, e := range db.executions {
+		h, ok := byName[e.WorkflowName]
+		if !ok {
+			h = &WorkflowHistory{Workflow: e.WorkflowName, FirstRun: e.StartTime, LastRun: e.StartTime}
+			byName[e.WorkflowName] = h
+			histories = append(histories, h)
+		}
+		h.Executions++
+		if e.StartTime.Before(h.FirstRun) {
+			h.FirstRun = e.StartTime
+		}
+		if e.StartTime.After(h.LastRun) {
+			h.LastRun = e.StartTime
+		}
+	}
+	result := make([]WorkflowHistory, len(histories))
+	for i, h := range histories {
+		result[i] = *h
+	}
+	sort.SliceStable(result, func(i, j int) bool { return result[i].LastRun.After(result[j].LastRun) })
+	return result, nil
+}
+
+// hasLabels reports whether labels include every one of want
+func hasLabels(labels, want map[string]string) bool {
+	for key, value := range want {
+		if v, ok := labels[key]; !ok || v != value {
+			return false
+		}
+	}
+	return true
+}
+
+func (db *MockDatabase) Snapshot() (*Snapshot, error) {
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+
+	snapshot := &Snapshot{
+		Executions:       append([]testkube.Execution{}, db.executions...),
+		TestCases:        append([]TestCase{}, db.testCases...),
+		K6Metrics:        append([]K6MetricRecord{}, db.k6Metrics...),
+		CheckResults:     append([]CheckResult{}, db.checks...),
+		Artifacts:        []ArtifactRecord{},
+		FeatureOverrides: append([]FeatureOverride{}, db.overrides...),
+		VariablePresets:  append([]VariablePreset{}, db.presets...),
+		DeadLetters:      append([]DeadLetter{}, db.dead...),
+		Events:           append([]Event{}, db.events...),
+		TestLinks:        append([]TestLink{}, db.links...),
+		Watches:          append([]Watch{}, db.watches...),
+		UserChannels:     append([]UserChannel{}, db.channels...),
+		Reports:          append([]StoredReport{}, db.reports...),
+		IngestCursors:    append([]IngestCursor{}, db.cursors...),
+		TestSuites:       append([]TestSuite{}, db.suites...),
+		EvidenceRecords:  append([]EvidenceRecord{}, db.evidence...),
+		VisualBaselines:  append([]VisualBaseline{}, db.visual...),
+	}
+	ids := make([]string, 0, len(db.manifests))
+	for id := range db.manifests {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	for _, id := range ids {
+		snapshot.Artifacts = append(snapshot.Artifacts, db.manifests[id]...)
+	}
+	return snapshot, nil
+}
+
+func (db *MockDatabase) Restore(snapshot *Snapshot) error {
+	manifests := make(map[string][]ArtifactRecord)
+	for _, a := range snapshot.Artifacts {
+		manifests[a.ExecutionID] = append(manifests[a.ExecutionID], a)
+	}
+
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	db.executions = append([]testkube.Execution{}, snapshot.Executions...)
+	db.testCases = append([]TestCase{}, snapshot.TestCases...)
+	db.k6Metrics = append([]K6MetricRecord(nil), snapshot.K6Metrics...)
+	db.checks = append([]CheckResult(nil), snapshot.CheckResults...)
+	db.manifests = manifests
+	db.overrides = append([]FeatureOverride(nil), snapshot.FeatureOverrides...)
+	db.presets = append([]VariablePreset(nil), snapshot.VariablePresets...)
+	db.dead = append([]DeadLetter(nil), snapshot.DeadLetters...)
+	db.events = append([]Event(nil), snapshot.Events...)
+	db.links = append([]TestLink(nil), snapshot.TestLinks...)
+	db.watches = append([]Watch(nil), snapshot.Watches...)
+	db.channels = append([]UserChannel(nil), snapshot.UserChannels...)
+	db.reports = append([]StoredReport(nil), snapshot.Reports...)
+	db.cursors = append([]IngestCursor(nil), snapshot.IngestCursors...)
+	db.suites = append([]TestSuite(nil), snapshot.TestSuites...)
+	db.evidence = append([]EvidenceRecord(nil), snapshot.EvidenceRecords...)
+	db.visual = append([]VisualBaseline(nil), snapshot.VisualBaselines...)
+	return nil
+}
+
+func (db *MockDatabase) GetCheckResults(checkID string, since time.Time) ([]CheckResult, error) {
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+
+	var results []CheckResult
+	for _, r := range db.checks {
+		if r.CheckID == checkID && !r.Time.Before(since) {
+			results = append(results, r)
+		}
+	}
+	sort.Slice(results, func(i, j int) bool {
+		return results[i].Time.Before(results[j].Time)
+	})
+	return results, nil
+}
+
+func (db *MockDatabase) GetTestLocations(days int) ([]TestLocation, error) {
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+
+	executions := make(map[string]testkube.Execution, len(db.executions))
+	for _, e := range db.executions {
+		executions[e.ID] = e
+	}
+	since := time.Now().AddDate(0, 0, -days)
+
+	testName := db.testName()
+	locations := make(map[[2]string]*TestLocation)
+	for _, tc := range db.testCases {
+		exec, ok := executions[tc.ExecutionID]
+		if !ok || exec.StartTime.Before(since) {
+			continue
+		}
+		key := [2]string{exec.WorkflowName, testName(exec.WorkflowName, tc.TestName)}
+		loc, ok := locations[key]
+		if !ok {
+			loc = &TestLocation{Workflow: exec.WorkflowName, TestName: key[1]}
+			locations[key] = loc
+		}
+		if !exec.StartTime.Before(loc.LastRun) {
+			loc.LastRun = exec.StartTime
+			if tc.FilePath != "" {
+				loc.FilePath = tc.FilePath
+			}
+		}
+	}
+
+	result := make([]TestLocation, 0, len(locations))
+	for _, loc := range locations {
+		result = append(result, *loc)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Workflow != result[j].Workflow {
+			return result[i].Workflow < result[j].Workflow
+		}
+		return result[i].TestName < result[j].TestName
+	})
+	return result, nil
 }
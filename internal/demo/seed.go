@@ -0,0 +1,338 @@
+// Package demo generates plausible test history so the dashboard can be
+// evaluated without a live Testkube install.
+package demo
+
+import (
+	"fmt"
+	"math/rand"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/testkube/dashboard/internal/database"
+	"github.com/testkube/dashboard/internal/environments"
+	"github.com/testkube/dashboard/internal/testkube"
+)
+
+// Defaults for Options left at zero
+const (
+	DefaultWeeks      = 4
+	DefaultRunsPerDay = 3
+)
+
+// Options control how much data is generated
+type Options struct {
+	Weeks      int
+	RunsPerDay int // per workflow
+	// Seed makes the data reproducible; zero picks a random seed
+	Seed int64
+	Now  time.Time
+}
+
+// Summary counts the records generated
+type Summary struct {
+	Executions   int `json:"executions"`
+	TestCases    int `json:"testCases"`
+	K6Metrics    int `json:"k6Metrics"`
+	Environments int `json:"environments"`
+}
+
+func (s Summary) String() string {
+	return fmt.Sprintf("%d executions, %d test cases, %d k6 metrics, %d environments",
+		s.Executions, s.TestCases, s.K6Metrics, s.Environments)
+}
+
+// Workflow types that produce k6 metrics
+var loadTestTypes = map[string]bool{"k6": true, "emqtt-bench": true}
+
+// Test suites of the workflow types that produce test cases. The first two tests of each suite are
+// flaky: they fail now and then and pass on retry.
+var suites = map[string][]string{
+	"playwright": {
+		"checkout/payment.spec.ts:completes checkout with saved card",
+		"auth/login.spec.ts:logs in with OAuth",
+		"auth/login.spec.ts:logs in with password",
+		"auth/login.spec.ts:rejects a wrong password",
+		"cart/cart.spec.ts:adds an item",
+		"cart/cart.spec.ts:removes an item",
+		"cart/cart.spec.ts:applies a discount code",
+		"search/search.spec.ts:finds products by name",
+		"search/search.spec.ts:filters by category",
+		"profile/profile.spec.ts:updates the display name",
+		"profile/profile.spec.ts:uploads an avatar",
+		"devices/devices.spec.ts:pairs a new panel",
+	},
+	"vitest": {
+		"src/queue/consumer.test.ts:acknowledges after processing",
+		"src/cache/redis.test.ts:expires stale entries",
+		"src/api/devices.test.ts:lists devices for an account",
+		"src/api/devices.test.ts:rejects unknown devices",
+		"src/api/events.test.ts:paginates events",
+		"src/api/events.test.ts:filters by zone",
+		"src/auth/token.test.ts:refreshes expired tokens",
+		"src/auth/token.test.ts:rejects tampered tokens",
+		"src/db/migrations.test.ts:applies migrations in order",
+		"src/mqtt/bridge.test.ts:forwards panel status",
+	},
+	"thingboard": {
+		"telemetry/ingest.test:accepts batched telemetry",
+		"rules/alarm.test:raises an alarm on tamper",
+		"telemetry/ingest.test:rejects malformed payloads",
+		"rules/alarm.test:clears an alarm on restore",
+		"devices/provision.test:provisions a device",
+		"devices/provision.test:rotates credentials",
+	},
+}
+
+// People who start manual runs and own environments
+var owners = []string{"alice@example.com", "bob@example.com", "carol@example.com"}
+
+// Branches executions run on, most on main
+var branches = []string{"main", "main", "main", "main", "feature/payments-v2", "fix/login-timeout", "release/2.4"}
+
+type generator struct {
+	rng     *rand.Rand
+	db      database.Database
+	summary Summary
+}
+
+// Seed fills db with executions, test cases and k6 metrics for workflows
+// over the last weeks, and adds a few ready and historical environments to
+// envs when it is not nil.
+func Seed(db database.Database, envs *environments.Manager, workflows []testkube.Workflow, opts Options) (Summary, error) {
+	if opts.Weeks <= 0 {
+		opts.Weeks = DefaultWeeks
+	}
+	if opts.RunsPerDay <= 0 {
+		opts.RunsPerDay = DefaultRunsPerDay
+	}
+	if opts.Seed == 0 {
+		opts.Seed = time.Now().UnixNano()
+	}
+	if opts.Now.IsZero() {
+		opts.Now = time.Now()
+	}
+
+	g := &generator{rng: rand.New(rand.NewSource(opts.Seed)), db: db}
+	for _, wf := range workflows {
+		if err := g.workflow(wf, opts); err != nil {
+			return g.summary, fmt.Errorf("failed to seed %s: %w", wf.Name, err)
+		}
+	}
+	if envs != nil {
+		if err := g.environments(envs, opts.Now); err != nil {
+			return g.summary, err
+		}
+	}
+	return g.summary, nil
+}
+
+func (g *generator) workflow(wf testkube.Workflow, opts Options) error {
+	// Each workflow gets its own failure rate and typical duration
+	failureRate := 0.03 + g.rng.Float64()*0.15
+	baseDuration := time.Duration(1+g.rng.Intn(15)) * time.Minute
+
+	var starts []time.Time
+	days := opts.Weeks * 7
+	for day := days - 1; day >= 0; day-- {
+		midnight := opts.Now.AddDate(0, 0, -day).Truncate(24 * time.Hour)
+		for run := 0; run < opts.RunsPerDay; run++ {
+			start := midnight.Add(time.Duration(g.rng.Intn(24*60)) * time.Minute)
+			if start.Before(opts.Now) {
+				starts = append(starts, start)
+			}
+		}
+	}
+	sort.Slice(starts, func(i, j int) bool { return starts[i].Before(starts[j]) })
+
+	for i, start := range starts {
+		failed := g.rng.Float64() < failureRate
+		duration := baseDuration + time.Duration(g.rng.Int63n(int64(baseDuration/2)+1))
+		exec := testkube.Execution{
+			ID:           fmt.Sprintf("demo-%s-%d", wf.Name, i+1),
+			Name:         fmt.Sprintf("%s-%d", wf.Name, i+1),
+			WorkflowName: wf.Name,
+			Status:       "passed",
+			StartTime:    start,
+			EndTime:      start.Add(duration),
+			Duration:     duration,
+			Branch:       branches[g.rng.Intn(len(branches))],
+			Labels:       map[string]string{"demo": "true"},
+		}
+		exec.Trigger, exec.TriggeredBy = g.trigger()
+		var cases []database.TestCase
+		if suite, ok := suites[wf.Type]; ok {
+			cases = g.testCases(exec.ID, suite, failed)
+		}
+		for _, tc := range cases {
+			failed = failed || tc.Status == "failed"
+		}
+		if failed {
+			exec.Status = "failed"
+		}
+
+		if err := g.db.InsertExecution(exec); err != nil {
+			return err
+		}
+		g.summary.Executions++
+		for _, tc := range cases {
+			if err := g.db.InsertTestCase(tc); err != nil {
+				return err
+			}
+			g.summary.TestCases++
+		}
+		if loadTestTypes[wf.Type] {
+			if err := g.k6Metrics(exec, failed); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// trigger picks what started a run: mostly CI and nightly schedules, with
+// the odd manual debug run
+func (g *generator) trigger() (string, string) {
+	switch n := g.rng.Intn(10); {
+	case n < 5:
+		return testkube.TriggerCI, "bitbucket-pipelines"
+	case n < 8:
+		return testkube.TriggerSchedule, ""
+	default:
+		return testkube.TriggerManual, owners[g.rng.Intn(len(owners))]
+	}
+}
+
+// testCases generates a run of the suite. A failed execution fails one to
+// three tests; flaky tests sometimes fail, or pass only on retry.
+func (g *generator) testCases(executionID string, suite []string, failed bool) []database.TestCase {
+	failing := make(map[int]bool)
+	if failed {
+		for n := 1 + g.rng.Intn(3); n > 0; n-- {
+			failing[g.rng.Intn(len(suite))] = true
+		}
+	}
+
+	cases := make([]database.TestCase, len(suite))
+	for i, test := range suite {
+		file, name, _ := strings.Cut(test, ":")
+		tc := database.TestCase{
+			ExecutionID: executionID,
+			TestName:    name,
+			FilePath:    file,
+			Status:      "passed",
+			DurationMs:  200 + g.rng.Intn(8000),
+		}
+		switch {
+		case failing[i]:
+			tc.Status = "failed"
+			tc.ErrorMessage = failureMessages[g.rng.Intn(len(failureMessages))]
+		case i < 2 && g.rng.Float64() < 0.2:
+			if g.rng.Float64() < 0.6 {
+				tc.RetryCount = 1
+			} else {
+				tc.Status = "failed"
+				tc.ErrorMessage = "Timeout 30000ms exceeded while waiting for selector"
+			}
+		}
+		cases[i] = tc
+	}
+	return cases
+}
+
+var failureMessages = []string{
+	"expect(received).toBe(expected): expected 200, received 500",
+	"Timeout 30000ms exceeded while waiting for selector",
+	"AssertionError: expected 3 items, found 2",
+	"Error: connect ECONNREFUSED 10.0.4.12:5432",
+}
+
+// k6Metrics records request latency, throughput and error rate. Failed
+// runs breached their thresholds, so they are noticeably slower.
+func (g *generator) k6Metrics(exec testkube.Execution, failed bool) error {
+	latency := 80 + g.rng.Float64()*40
+	if failed {
+		latency *= 2.5
+	}
+	metrics := []database.K6MetricRecord{
+		{
+			MetricName: "http_req_duration",
+			MetricType: "trend",
+			MinValue:   latency * 0.2,
+			AvgValue:   latency,
+			P95Value:   latency * 2.1,
+			P99Value:   latency * 3.4,
+			MaxValue:   latency * 6,
+		},
+		{MetricName: "http_reqs", MetricType: "counter", AvgValue: float64(20000 + g.rng.Intn(10000))},
+		{MetricName: "http_req_failed", MetricType: "rate", AvgValue: g.errorRate(failed)},
+	}
+	for _, m := range metrics {
+		m.ExecutionID = exec.ID
+		if err := g.db.InsertK6Metric(m); err != nil {
+			return err
+		}
+		g.summary.K6Metrics++
+	}
+	return nil
+}
+
+func (g *generator) errorRate(failed bool) float64 {
+	if failed {
+		return 0.02 + g.rng.Float64()*0.05
+	}
+	return g.rng.Float64() * 0.004
+}
+
+// environments adds two running environments and a history of deleted ones
+// for the environment SLA report
+func (g *generator) environments(envs *environments.Manager, now time.Time) error {
+	ns := envs.Namespace()
+
+	add := func(name string, created time.Time, provision time.Duration, status environments.EnvironmentStatus) error {
+		ready := created.Add(provision)
+		env := environments.Environment{
+			ID:             fmt.Sprintf("demo%04d", g.summary.Environments+1),
+			Name:           name,
+			Owner:          owners[g.rng.Intn(len(owners))],
+			Type:           environments.TypeEphemeral,
+			Template:       environments.DefaultTemplate,
+			Status:         status,
+			CreatedAt:      created,
+			ExpiresAt:      created.Add(environments.DefaultEphemeralTTL),
+			ReadyAt:        &ready,
+			Namespace:      ns,
+			DatabaseSchema: "texecom_env_" + name,
+			Hostname:       name + ".demo.local",
+			URL:            "https://" + name + ".demo.local",
+			Branch:         branches[g.rng.Intn(len(branches))],
+		}
+		if status == environments.StatusReady {
+			env.ExpiresAt = now.Add(environments.DefaultEphemeralTTL)
+		}
+		if status == environments.StatusDeleted {
+			deleted := env.ExpiresAt
+			env.DeletedAt = &deleted
+		}
+		if err := envs.Add(env); err != nil {
+			return err
+		}
+		g.summary.Environments++
+		return nil
+	}
+
+	for i := 0; i < 12; i++ {
+		created := now.AddDate(0, 0, -2*(i+1)).Add(-time.Duration(g.rng.Intn(8)) * time.Hour)
+		provision := time.Duration(2+g.rng.Intn(10)) * time.Minute
+		if err := add(fmt.Sprintf("demo-pr-%d", 100+i), created, provision, environments.StatusDeleted); err != nil {
+			return err
+		}
+	}
+	for _, name := range []string{"demo-payments-v2", "demo-login-fix"} {
+		created := now.Add(-time.Duration(1+g.rng.Intn(4)) * time.Hour)
+		if err := add(name, created, 4*time.Minute, environments.StatusReady); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,93 @@
+package demo
+
+import (
+	"testing"
+	"time"
+
+	"github.com/testkube/dashboard/internal/database"
+	"github.com/testkube/dashboard/internal/environments"
+	"github.com/testkube/dashboard/internal/testkube"
+)
+
+func TestSeed(t *testing.T) {
+	db := database.NewMockDatabase()
+	envs := environments.NewManager()
+	now := time.Date(2025, 3, 14, 12, 0, 0, 0, time.UTC)
+	workflows := []testkube.Workflow{
+		{Name: "frontend-e2e", Type: "playwright"},
+		{Name: "api-load-test", Type: "k6"},
+		{Name: "cluster-security", Type: "trivy"},
+	}
+
+	summary, err := Seed(db, envs, workflows, Options{Weeks: 2, RunsPerDay: 4, Seed: 42, Now: now})
+	if err != nil {
+		t.Fatalf("Seed failed: %v", err)
+	}
+
+	snapshot, _ := db.Snapshot()
+	if len(snapshot.Executions) != summary.Executions || summary.Executions < 3*14*3 {
+		t.Errorf("executions: got %d stored, summary %d", len(snapshot.Executions), summary.Executions)
+	}
+	if len(snapshot.TestCases) != summary.TestCases || summary.TestCases == 0 {
+		t.Errorf("test cases: got %d stored, summary %d", len(snapshot.TestCases), summary.TestCases)
+	}
+	if len(snapshot.K6Metrics) != summary.K6Metrics || summary.K6Metrics == 0 {
+		t.Errorf("k6 metrics: got %d stored, summary %d", len(snapshot.K6Metrics), summary.K6Metrics)
+	}
+
+	start := now.AddDate(0, 0, -14)
+	for _, exec := range snapshot.Executions {
+		if exec.StartTime.Before(start) || exec.StartTime.After(now) {
+			t.Errorf("%s started at %s, outside the seeded weeks", exec.ID, exec.StartTime)
+		}
+	}
+
+	// Executions with failing tests are failed themselves
+	status := make(map[string]string)
+	for _, exec := range snapshot.Executions {
+		status[exec.ID] = exec.Status
+	}
+	for _, tc := range snapshot.TestCases {
+		if tc.Status == "failed" && status[tc.ExecutionID] != "failed" {
+			t.Errorf("%s failed in %s, which passed", tc.TestName, tc.ExecutionID)
+		}
+	}
+
+	flaky, err := db.GetFlakyTestsBetween(start, now)
+	if err != nil {
+		t.Fatalf("GetFlakyTestsBetween failed: %v", err)
+	}
+	if len(flaky) == 0 {
+		t.Error("expected flaky tests in the seeded history")
+	}
+
+	if got := len(envs.List(environments.ListEnvironmentsOptions{})); got != 2 {
+		t.Errorf("running environments: got %d, expected 2", got)
+	}
+	if got := len(envs.History(start.AddDate(0, 0, -14))); got != summary.Environments {
+		t.Errorf("environment history: got %d, expected %d", got, summary.Environments)
+	}
+}
+
+func TestSeed_Reproducible(t *testing.T) {
+	now := time.Now()
+	workflows := []testkube.Workflow{{Name: "backend-integration", Type: "vitest"}}
+	seed := func() *database.Snapshot {
+		db := database.NewMockDatabase()
+		if _, err := Seed(db, nil, workflows, Options{Weeks: 1, Seed: 7, Now: now}); err != nil {
+			t.Fatalf("Seed failed: %v", err)
+		}
+		snapshot, _ := db.Snapshot()
+		return snapshot
+	}
+
+	a, b := seed(), seed()
+	if len(a.Executions) != len(b.Executions) || len(a.TestCases) != len(b.TestCases) {
+		t.Fatalf("got %d and %d executions, expected the same data", len(a.Executions), len(b.Executions))
+	}
+	for i := range a.Executions {
+		if a.Executions[i].Status != b.Executions[i].Status || !a.Executions[i].StartTime.Equal(b.Executions[i].StartTime) {
+			t.Errorf("execution %d differs between runs with the same seed", i)
+		}
+	}
+}
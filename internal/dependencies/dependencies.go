@@ -0,0 +1,285 @@
+package dependencies
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultTimeout bounds each dependency check so a hung upstream can't
+// hold up a run for long
+const DefaultTimeout = 5 * time.Second
+
+// Mode is what happens to a run when a dependency is unhealthy
+type Mode string
+
+const (
+	// ModeBlock refuses to start the run
+	ModeBlock Mode = "block"
+	// ModeTag starts the run but tags the execution with the unhealthy dependencies
+	ModeTag Mode = "tag"
+)
+
+// UnhealthyTag is the execution tag listing dependencies that were down
+// when a run started in ModeTag
+const UnhealthyTag = "dependencies-unhealthy"
+
+// defaultPorts are used for targets whose URL has no explicit port
+var defaultPorts = map[string]string{
+	"postgres":   "5432",
+	"postgresql": "5432",
+	"mysql":      "3306",
+	"mongodb":    "27017",
+	"redis":      "6379",
+	"rediss":     "6379",
+	"amqp":       "5672",
+	"amqps":      "5671",
+	// MQTT clients such as paho also use tcp:// and ssl:// for brokers
+	"mqtt":  "1883",
+	"tcp":   "1883",
+	"mqtts": "8883",
+	"ssl":   "8883",
+}
+
+// Dependency is an external service a workflow needs. Target is a URL:
+// http(s) targets must respond without a server error, anything else
+// (database DSNs, mqtt://, tcp://) must accept a TCP connection.
+type Dependency struct {
+	Name   string `json:"name"`
+	Target string `json:"target"`
+}
+
+// address returns the host:port to dial for a non-HTTP target. MySQL
+// driver DSNs such as "user:pass@tcp(db:3306)/app" are accepted too.
+func (d Dependency) address() (string, error) {
+	if i := strings.Index(d.Target, "@tcp("); i >= 0 {
+		rest := d.Target[i+len("@tcp("):]
+		if j := strings.Index(rest, ")"); j > 0 {
+			return rest[:j], nil
+		}
+	}
+
+	u, err := url.Parse(d.Target)
+	if err != nil || u.Host == "" {
+		return "", fmt.Errorf("invalid target %q", d.Target)
+	}
+	if u.Port() != "" {
+		return u.Host, nil
+	}
+	port, ok := defaultPorts[u.Scheme]
+	if !ok {
+		return "", fmt.Errorf("no port in target %q", d.Target)
+	}
+	return net.JoinHostPort(u.Hostname(), port), nil
+}
+
+func (d Dependency) isHTTP() bool {
+	return strings.HasPrefix(d.Target, "http://") || strings.HasPrefix(d.Target, "https://")
+}
+
+// Rule declares the dependencies of workflows matching a glob
+type Rule struct {
+	Workflow     string       `json:"workflow"` // glob, e.g. "frontend-*"
+	Dependencies []Dependency `json:"dependencies"`
+	Mode         Mode         `json:"mode,omitempty"` // defaults to block
+}
+
+type config struct {
+	Rules []Rule `json:"rules"`
+}
+
+// Result is the outcome of checking one dependency
+type Result struct {
+	Dependency
+	OK        bool   `json:"ok"`
+	Error     string `json:"error,omitempty"`
+	LatencyMs int    `json:"latencyMs"`
+}
+
+// Report is the health of a workflow's dependencies before a run
+type Report struct {
+	Workflow string   `json:"workflow"`
+	Mode     Mode     `json:"mode"`
+	Results  []Result `json:"results"`
+}
+
+// Healthy reports whether every dependency passed
+func (r Report) Healthy() bool {
+	return len(r.Unhealthy()) == 0
+}
+
+// Unhealthy returns the failed checks
+func (r Report) Unhealthy() []Result {
+	var failed []Result
+	for _, res := range r.Results {
+		if !res.OK {
+			failed = append(failed, res)
+		}
+	}
+	return failed
+}
+
+// Message explains which dependencies are down, for the user who triggered the run
+func (r Report) Message() string {
+	var parts []string
+	for _, res := range r.Unhealthy() {
+		parts = append(parts, fmt.Sprintf("%s (%s)", res.Name, res.Error))
+	}
+	if len(parts) == 0 {
+		return fmt.Sprintf("All dependencies of %s are healthy", r.Workflow)
+	}
+	return fmt.Sprintf("Dependencies of %s are unhealthy: %s", r.Workflow, strings.Join(parts, "; "))
+}
+
+// Checker health-checks the external dependencies declared for workflows
+type Checker struct {
+	config     config
+	mu         sync.RWMutex
+	httpClient *http.Client
+	dialer     *net.Dialer
+}
+
+// NewChecker creates a checker, loading rules from the JSON file in
+// DEPENDENCIES_FILE when set
+func NewChecker() (*Checker, error) {
+	c := &Checker{
+		httpClient: &http.Client{Timeout: DefaultTimeout},
+		dialer:     &net.Dialer{Timeout: DefaultTimeout},
+	}
+
+	if file := os.Getenv("DEPENDENCIES_FILE"); file != "" {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return c, fmt.Errorf("failed to read dependencies file: %w", err)
+		}
+		if err := c.Import(data); err != nil {
+			return c, err
+		}
+	}
+
+	return c, nil
+}
+
+// For returns the dependencies declared for a workflow by the first
+// matching rule, and what to do when they are unhealthy
+func (c *Checker) For(workflow string) ([]Dependency, Mode) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	for _, rule := range c.config.Rules {
+		if ok, _ := path.Match(rule.Workflow, workflow); ok {
+			mode := rule.Mode
+			if mode == "" {
+				mode = ModeBlock
+			}
+			return rule.Dependencies, mode
+		}
+	}
+	return nil, ModeBlock
+}
+
+// Check runs the workflow's dependency checks concurrently
+func (c *Checker) Check(ctx context.Context, workflow string) Report {
+	deps, mode := c.For(workflow)
+	report := Report{Workflow: workflow, Mode: mode, Results: make([]Result, len(deps))}
+
+	var wg sync.WaitGroup
+	for i, dep := range deps {
+		wg.Add(1)
+		go func(i int, dep Dependency) {
+			defer wg.Done()
+			report.Results[i] = c.check(ctx, dep)
+		}(i, dep)
+	}
+	wg.Wait()
+
+	return report
+}
+
+func (c *Checker) check(ctx context.Context, dep Dependency) Result {
+	result := Result{Dependency: dep}
+	start := time.Now()
+
+	if dep.isHTTP() {
+		result.Error = c.checkHTTP(ctx, dep.Target)
+	} else {
+		result.Error = c.checkTCP(ctx, dep)
+	}
+	result.OK = result.Error == ""
+	result.LatencyMs = int(time.Since(start).Milliseconds())
+	return result
+}
+
+func (c *Checker) checkHTTP(ctx context.Context, target string) string {
+	req, err := http.NewRequestWithContext(ctx, "GET", target, nil)
+	if err != nil {
+		return fmt.Sprintf("invalid target: %v", err)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return err.Error()
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode >= 500 {
+		return fmt.Sprintf("status %d", resp.StatusCode)
+	}
+	return ""
+}
+
+func (c *Checker) checkTCP(ctx context.Context, dep Dependency) string {
+	addr, err := dep.address()
+	if err != nil {
+		return err.Error()
+	}
+
+	conn, err := c.dialer.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return err.Error()
+	}
+	conn.Close()
+	return ""
+}
+
+// Export returns the dependency rules as JSON, for configuration sync
+func (c *Checker) Export() (json.RawMessage, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return json.Marshal(c.config)
+}
+
+// Import replaces the dependency rules
+func (c *Checker) Import(data json.RawMessage) error {
+	var cfg config
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return fmt.Errorf("failed to parse dependency rules: %w", err)
+	}
+
+	for _, rule := range cfg.Rules {
+		if _, err := path.Match(rule.Workflow, ""); err != nil {
+			return fmt.Errorf("invalid workflow pattern %q: %w", rule.Workflow, err)
+		}
+		if rule.Mode != "" && rule.Mode != ModeBlock && rule.Mode != ModeTag {
+			return fmt.Errorf("invalid mode %q for %s: must be %q or %q", rule.Mode, rule.Workflow, ModeBlock, ModeTag)
+		}
+		for _, dep := range rule.Dependencies {
+			if dep.Name == "" || dep.Target == "" {
+				return fmt.Errorf("dependencies of %s need a name and target", rule.Workflow)
+			}
+		}
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.config = cfg
+	return nil
+}
@@ -8,6 +8,7 @@ import (
 	"fmt"
 	"log"
 	"os"
+	"slices"
 	"strings"
 	"sync"
 	"time"
@@ -34,6 +35,29 @@ type Manager struct {
 	redisHost     string
 	mqttHost      string
 	baseURL       string
+
+	// Provisioning steps and the wait before retrying a failed one
+	steps        []provisionStep
+	retryBackoff time.Duration
+
+	// Ingress TLS, and cert-manager status when the Kubernetes API is available
+	tls                     tlsConfig
+	certificates            CertificateGetter
+	certificatePollInterval time.Duration
+
+	// NetworkPolicy isolation between environments
+	network networkConfig
+
+	// Templates pick the provisioner; run executes helm and terraform
+	templates map[string]Template
+	run       commandRunner
+
+	// onExpire is told about environments before they're torn down for
+	// expiring, when set
+	onExpire func(env Environment)
+
+	// smokeTests runs the workflows environments are smoke tested with
+	smokeTests SmokeTestRunner
 }
 
 func NewManager() *Manager {
@@ -47,7 +71,17 @@ func NewManager() *Manager {
 		redisHost:     getEnvOrDefault("REDIS_HOST", "texecom-texecom-cloud-redis.texecom.svc.cluster.local"),
 		mqttHost:      getEnvOrDefault("MQTT_HOST", "texecom-texecom-cloud-emqx.texecom.svc.cluster.local"),
 		baseURL:       getEnvOrDefault("ENVIRONMENTS_BASE_URL", "envs.services.texecom-develop.com"),
+		retryBackoff:  defaultRetryBackoff,
+		tls:           tlsConfigFromEnv(),
+
+		certificatePollInterval: defaultCertificatePollInterval,
+		run:                     runCommand,
 	}
+	if err := m.loadTemplates(); err != nil {
+		log.Printf("Warning: failed to load environment templates: %v", err)
+	}
+	m.network = networkConfigFromEnv(m.mysqlHost, m.redisHost, m.mqttHost)
+	m.steps = m.provisionSteps()
 
 	// Start background cleanup goroutine
 	go m.cleanupLoop()
@@ -62,6 +96,11 @@ func getEnvOrDefault(key, defaultVal string) string {
 	return defaultVal
 }
 
+// Namespace returns the Kubernetes namespace environments are created in
+func (m *Manager) Namespace() string {
+	return m.namespace
+}
+
 func (m *Manager) generateID() string {
 	bytes := make([]byte, 4)
 	rand.Read(bytes)
@@ -78,6 +117,24 @@ func (m *Manager) Create(ctx context.Context, req CreateEnvironmentRequest) (*En
 	name = strings.ToLower(strings.ReplaceAll(name, " ", "-"))
 	name = strings.ReplaceAll(name, "_", "-")
 
+	templateName := req.Template
+	if templateName == "" {
+		templateName = DefaultTemplate
+	}
+	template, ok := m.template(templateName)
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownTemplate, templateName)
+	}
+	smokeWorkflow := req.SmokeWorkflow
+	if smokeWorkflow == "" && template.SmokeTest != nil {
+		smokeWorkflow = template.SmokeTest.Workflow
+	}
+
+	hostname, err := m.hostname(name, req.Subdomain)
+	if err != nil {
+		return nil, err
+	}
+
 	// Calculate TTL
 	ttl := DefaultEphemeralTTL
 	if req.Type == TypeDevSandbox {
@@ -91,7 +148,9 @@ func (m *Manager) Create(ctx context.Context, req CreateEnvironmentRequest) (*En
 		ID:             id,
 		Name:           name,
 		Owner:          req.Owner,
+		Team:           req.Team,
 		Type:           req.Type,
+		Template:       templateName,
 		Status:         StatusCreating,
 		CreatedAt:      time.Now(),
 		ExpiresAt:      time.Now().Add(ttl),
@@ -100,11 +159,25 @@ func (m *Manager) Create(ctx context.Context, req CreateEnvironmentRequest) (*En
 		RedisPrefix:    fmt.Sprintf("env:%s:", id),
 		MQTTPrefix:     fmt.Sprintf("env/%s/", id),
 		Branch:         req.Branch,
+		Commit:         req.Commit,
+		PullRequest:    req.PullRequest,
+		SmokeWorkflow:  smokeWorkflow,
 		InternalURL:    fmt.Sprintf("http://%s-fern.%s.svc.cluster.local:8080", name, m.namespace),
-		URL:            fmt.Sprintf("https://%s.%s", name, m.baseURL),
+		Hostname:       hostname,
+		URL:            fmt.Sprintf("https://%s", hostname),
 	}
+	if m.usesManifests(env) {
+		env.TLS = m.initialTLSStatus(env)
+	}
+	env.Steps = m.newSteps(env)
 
 	m.mu.Lock()
+	for _, other := range m.environments {
+		if other.Hostname == hostname && other.Status != StatusDeleted {
+			m.mu.Unlock()
+			return nil, fmt.Errorf("%w: %s is used by environment %s", ErrHostnameInUse, hostname, other.Name)
+		}
+	}
 	m.environments[id] = env
 	m.mu.Unlock()
 
@@ -114,35 +187,7 @@ func (m *Manager) Create(ctx context.Context, req CreateEnvironmentRequest) (*En
 	return env, nil
 }
 
-func (m *Manager) provisionEnvironment(env *Environment) {
-	log.Printf("Provisioning environment %s (%s)", env.Name, env.ID)
-
-	// Step 1: Create database schema
-	if err := m.createDatabaseSchema(env); err != nil {
-		m.setError(env, fmt.Sprintf("Failed to create database: %v", err))
-		return
-	}
-
-	// Step 2: Create Kubernetes resources
-	if err := m.createKubernetesResources(env); err != nil {
-		m.setError(env, fmt.Sprintf("Failed to create k8s resources: %v", err))
-		return
-	}
-
-	// Step 3: Wait for deployment to be ready
-	if err := m.waitForReady(env); err != nil {
-		m.setError(env, fmt.Sprintf("Environment failed to become ready: %v", err))
-		return
-	}
-
-	m.mu.Lock()
-	env.Status = StatusReady
-	m.mu.Unlock()
-
-	log.Printf("Environment %s is ready at %s", env.Name, env.URL)
-}
-
-func (m *Manager) createDatabaseSchema(env *Environment) error {
+func (m *Manager) createDatabaseSchema(ctx context.Context, env *Environment) error {
 	if m.mysqlPassword == "" {
 		log.Printf("Warning: No MySQL password configured, skipping schema creation")
 		return nil
@@ -156,7 +201,7 @@ func (m *Manager) createDatabaseSchema(env *Environment) error {
 	defer db.Close()
 
 	// Create schema
-	_, err = db.Exec(fmt.Sprintf("CREATE DATABASE IF NOT EXISTS `%s`", env.DatabaseSchema))
+	_, err = db.ExecContext(ctx, fmt.Sprintf("CREATE DATABASE IF NOT EXISTS `%s`", env.DatabaseSchema))
 	if err != nil {
 		return fmt.Errorf("failed to create schema: %w", err)
 	}
@@ -168,7 +213,7 @@ func (m *Manager) createDatabaseSchema(env *Environment) error {
 	return nil
 }
 
-func (m *Manager) createKubernetesResources(env *Environment) error {
+func (m *Manager) createKubernetesResources(ctx context.Context, env *Environment) error {
 	// Generate Kubernetes manifests and apply them
 	// Using kubectl exec for simplicity - in production use client-go
 
@@ -265,32 +310,6 @@ spec:
   ports:
     - port: 8080
       targetPort: 8080
----
-apiVersion: networking.k8s.io/v1
-kind: Ingress
-metadata:
-  name: %s-ingress
-  namespace: %s
-  labels:
-    env-id: %s
-  annotations:
-    kubernetes.io/ingress.class: alb
-    alb.ingress.kubernetes.io/scheme: internet-facing
-    alb.ingress.kubernetes.io/group.name: texecom-platform
-    alb.ingress.kubernetes.io/listen-ports: '[{"HTTPS":443}]'
-    alb.ingress.kubernetes.io/ssl-redirect: "443"
-spec:
-  rules:
-    - host: %s.%s
-      http:
-        paths:
-          - path: /
-            pathType: Prefix
-            backend:
-              service:
-                name: %s-fern
-                port:
-                  number: 8080
 `,
 		env.Name, env.Namespace, env.Name, env.ID,
 		env.ID, env.ID,
@@ -299,16 +318,18 @@ spec:
 		m.redisHost, env.RedisPrefix,
 		m.mqttHost, env.MQTTPrefix,
 		env.Name, env.Namespace, env.ID, env.ID,
-		env.Name, env.Namespace, env.ID,
-		env.Name, m.baseURL, env.Name,
-	)
+	) + m.generateIngress(env)
 }
 
-func (m *Manager) waitForReady(env *Environment) error {
+func (m *Manager) waitForReady(ctx context.Context, env *Environment) error {
 	// In production, poll Kubernetes for deployment readiness
 	// For now, just wait a bit
-	time.Sleep(5 * time.Second)
-	return nil
+	select {
+	case <-time.After(5 * time.Second):
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
 }
 
 func (m *Manager) setError(env *Environment, errMsg string) {
@@ -330,6 +351,21 @@ func (m *Manager) Get(id string) (*Environment, error) {
 	return env, nil
 }
 
+// Add records an environment that already exists without provisioning it,
+// e.g. demo data. Its ID must not be in use.
+func (m *Manager) Add(env Environment) error {
+	if env.ID == "" {
+		return fmt.Errorf("environment has no ID")
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, ok := m.environments[env.ID]; ok {
+		return fmt.Errorf("environment already exists: %s", env.ID)
+	}
+	m.environments[env.ID] = &env
+	return nil
+}
+
 func (m *Manager) List(opts ListEnvironmentsOptions) []*Environment {
 	m.mu.RLock()
 	defer m.mu.RUnlock()
@@ -339,6 +375,12 @@ func (m *Manager) List(opts ListEnvironmentsOptions) []*Environment {
 		if opts.Owner != "" && env.Owner != opts.Owner {
 			continue
 		}
+		if opts.Team != "" && env.Team != opts.Team {
+			continue
+		}
+		if opts.PullRequest != "" && env.PullRequest != opts.PullRequest {
+			continue
+		}
 		if opts.Status != "" && env.Status != opts.Status {
 			continue
 		}
@@ -354,6 +396,27 @@ func (m *Manager) List(opts ListEnvironmentsOptions) []*Environment {
 	return result
 }
 
+// History returns copies of every environment created since a time,
+// including deleted ones, for reporting
+func (m *Manager) History(since time.Time) []Environment {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	var result []Environment
+	for _, env := range m.environments {
+		if !env.CreatedAt.Before(since) {
+			copied := *env
+			copied.Steps = slices.Clone(env.Steps)
+			if env.TLS != nil {
+				tls := *env.TLS
+				copied.TLS = &tls
+			}
+			result = append(result, copied)
+		}
+	}
+	return result
+}
+
 func (m *Manager) Delete(id string) error {
 	m.mu.Lock()
 	env, ok := m.environments[id]
@@ -371,8 +434,12 @@ func (m *Manager) Delete(id string) error {
 func (m *Manager) teardownEnvironment(env *Environment) {
 	log.Printf("Tearing down environment %s", env.Name)
 
-	// Delete Kubernetes resources
-	// kubectl delete -l env-id=<id> --namespace=<ns>
+	ctx, cancel := context.WithTimeout(context.Background(), resourcesTimeout)
+	defer cancel()
+	m.deleteResources(ctx, env)
+	if m.network.enabled {
+		m.deleteNetworkPolicy(env)
+	}
 
 	// Drop database schema
 	if m.mysqlPassword != "" {
@@ -407,6 +474,14 @@ func (m *Manager) Extend(id string, hours int) error {
 	return nil
 }
 
+// OnExpire sets a function told about each environment as it expires,
+// before teardown starts
+func (m *Manager) OnExpire(fn func(env Environment)) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.onExpire = fn
+}
+
 func (m *Manager) cleanupLoop() {
 	ticker := time.NewTicker(1 * time.Minute)
 	defer ticker.Stop()
@@ -418,16 +493,20 @@ func (m *Manager) cleanupLoop() {
 
 func (m *Manager) checkExpired() {
 	m.mu.RLock()
-	var toDelete []string
-	for id, env := range m.environments {
+	var expired []Environment
+	for _, env := range m.environments {
 		if env.Status == StatusReady && time.Now().After(env.ExpiresAt) {
-			toDelete = append(toDelete, id)
+			expired = append(expired, *env)
 		}
 	}
+	onExpire := m.onExpire
 	m.mu.RUnlock()
 
-	for _, id := range toDelete {
-		log.Printf("Environment %s has expired, cleaning up", id)
-		m.Delete(id)
+	for _, env := range expired {
+		log.Printf("Environment %s has expired, cleaning up", env.ID)
+		if onExpire != nil {
+			onExpire(env)
+		}
+		m.Delete(env.ID)
 	}
 }
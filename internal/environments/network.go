@@ -0,0 +1,213 @@
+package environments
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// networkPeer is a destination environments may reach outside their own pods
+type networkPeer struct {
+	namespace string // a namespace, or
+	cidr      string // an IP block
+	port      int
+}
+
+// networkConfig controls the NetworkPolicy isolating each environment
+type networkConfig struct {
+	enabled           bool
+	ingressNamespaces []string // where the ingress controller runs
+	ingressCIDRs      []string // e.g. load balancer subnets for ALB IP targets
+	egress            []networkPeer
+}
+
+// networkConfigFromEnv reads ENVIRONMENTS_NETWORK_POLICY ("false" disables
+// isolation), ENVIRONMENTS_INGRESS_NAMESPACES, ENVIRONMENTS_INGRESS_CIDRS and
+// ENVIRONMENTS_EGRESS_ALLOW, a list of extra "namespace:port" or "cidr:port"
+// destinations. MySQL, Redis and MQTT are always allowed.
+func networkConfigFromEnv(mysqlHost, redisHost, mqttHost string) networkConfig {
+	cfg := networkConfig{
+		enabled:           os.Getenv("ENVIRONMENTS_NETWORK_POLICY") != "false",
+		ingressNamespaces: splitList(getEnvOrDefault("ENVIRONMENTS_INGRESS_NAMESPACES", "kube-system")),
+		ingressCIDRs:      splitList(os.Getenv("ENVIRONMENTS_INGRESS_CIDRS")),
+		egress: []networkPeer{
+			servicePeer(mysqlHost, 3306),
+			servicePeer(redisHost, 6379),
+			servicePeer(mqttHost, 1883),
+			servicePeer(mqttHost, 8883),
+		},
+	}
+
+	for _, entry := range splitList(os.Getenv("ENVIRONMENTS_EGRESS_ALLOW")) {
+		peer, err := parsePeer(entry)
+		if err != nil {
+			log.Printf("Warning: ignoring ENVIRONMENTS_EGRESS_ALLOW entry %q: %v", entry, err)
+			continue
+		}
+		cfg.egress = append(cfg.egress, peer)
+	}
+	return cfg
+}
+
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// servicePeer allows a shared service by its host: the namespace of a
+// "name.namespace.svc..." service, or the address itself. Hosts outside the
+// cluster can't be matched by a policy, so only their port is restricted.
+func servicePeer(host string, port int) networkPeer {
+	if ip := net.ParseIP(host); ip != nil {
+		return networkPeer{cidr: ip.String() + "/32", port: port}
+	}
+	labels := strings.Split(host, ".")
+	if len(labels) == 2 || (len(labels) > 2 && labels[2] == "svc") {
+		return networkPeer{namespace: labels[1], port: port}
+	}
+	return networkPeer{cidr: "0.0.0.0/0", port: port}
+}
+
+// parsePeer parses "namespace:port" or "cidr:port"
+func parsePeer(entry string) (networkPeer, error) {
+	i := strings.LastIndex(entry, ":")
+	if i < 0 {
+		return networkPeer{}, fmt.Errorf("expected namespace:port or cidr:port")
+	}
+	port, err := strconv.Atoi(entry[i+1:])
+	if err != nil || port < 1 || port > 65535 {
+		return networkPeer{}, fmt.Errorf("invalid port %q", entry[i+1:])
+	}
+
+	target := entry[:i]
+	if strings.Contains(target, "/") {
+		if _, _, err := net.ParseCIDR(target); err != nil {
+			return networkPeer{}, fmt.Errorf("invalid CIDR %q", target)
+		}
+		return networkPeer{cidr: target, port: port}, nil
+	}
+	if !dnsLabel.MatchString(target) {
+		return networkPeer{}, fmt.Errorf("invalid namespace %q", target)
+	}
+	return networkPeer{namespace: target, port: port}, nil
+}
+
+func (m *Manager) networkPolicyEnabled(env *Environment) bool {
+	return m.network.enabled
+}
+
+func networkPolicyName(env *Environment) string {
+	return env.Name + "-isolation"
+}
+
+func networkPolicyFile(env *Environment) string {
+	return fmt.Sprintf("/tmp/env-%s-network-policy.yaml", env.ID)
+}
+
+// generateNetworkPolicy returns a NetworkPolicy confining the environment's
+// pods to each other, the ingress controller, DNS and the shared services.
+// Environments share a namespace, so pods are matched by env-id rather than
+// by namespace.
+func (m *Manager) generateNetworkPolicy(env *Environment) string {
+	var b strings.Builder
+
+	fmt.Fprintf(&b, `---
+apiVersion: networking.k8s.io/v1
+kind: NetworkPolicy
+metadata:
+  name: %s
+  namespace: %s
+  labels:
+    env-id: %s
+spec:
+  podSelector:
+    matchLabels:
+      env-id: %s
+  policyTypes:
+    - Ingress
+    - Egress
+  ingress:
+    - from:
+        - podSelector:
+            matchLabels:
+              env-id: %s
+`, networkPolicyName(env), env.Namespace, env.ID, env.ID, env.ID)
+
+	for _, ns := range m.network.ingressNamespaces {
+		fmt.Fprintf(&b, `        - namespaceSelector:
+            matchLabels:
+              kubernetes.io/metadata.name: %s
+`, ns)
+	}
+	for _, cidr := range m.network.ingressCIDRs {
+		fmt.Fprintf(&b, `        - ipBlock:
+            cidr: %s
+`, cidr)
+	}
+
+	fmt.Fprintf(&b, `  egress:
+    - to:
+        - podSelector:
+            matchLabels:
+              env-id: %s
+    - to:
+        - namespaceSelector:
+            matchLabels:
+              kubernetes.io/metadata.name: kube-system
+      ports:
+        - protocol: UDP
+          port: 53
+        - protocol: TCP
+          port: 53
+`, env.ID)
+
+	for _, peer := range m.network.egress {
+		b.WriteString("    - to:\n")
+		if peer.namespace != "" {
+			fmt.Fprintf(&b, `        - namespaceSelector:
+            matchLabels:
+              kubernetes.io/metadata.name: %s
+`, peer.namespace)
+		} else {
+			fmt.Fprintf(&b, `        - ipBlock:
+            cidr: %s
+`, peer.cidr)
+		}
+		fmt.Fprintf(&b, `      ports:
+        - protocol: TCP
+          port: %d
+`, peer.port)
+	}
+
+	return b.String()
+}
+
+// createNetworkPolicy isolates the environment before its pods start
+func (m *Manager) createNetworkPolicy(ctx context.Context, env *Environment) error {
+	file := networkPolicyFile(env)
+	if err := os.WriteFile(file, []byte(m.generateNetworkPolicy(env)), 0644); err != nil {
+		return fmt.Errorf("failed to write network policy: %w", err)
+	}
+
+	// This would be replaced with proper Kubernetes client in production
+	log.Printf("Network policy generated for %s", env.Name)
+	log.Printf("Apply with: kubectl apply -f %s", file)
+	return nil
+}
+
+// deleteNetworkPolicy removes the environment's isolation on teardown
+func (m *Manager) deleteNetworkPolicy(env *Environment) {
+	log.Printf("Delete with: kubectl delete networkpolicy %s --namespace=%s", networkPolicyName(env), env.Namespace)
+	if err := os.Remove(networkPolicyFile(env)); err != nil && !os.IsNotExist(err) {
+		log.Printf("Warning: failed to remove network policy manifest for %s: %v", env.Name, err)
+	}
+}
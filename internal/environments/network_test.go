@@ -0,0 +1,76 @@
+package environments
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestServicePeer(t *testing.T) {
+	tests := []struct {
+		host string
+		want networkPeer
+	}{
+		{"texecom-cloud-mysql.texecom.svc.cluster.local", networkPeer{namespace: "texecom", port: 3306}},
+		{"redis.shared", networkPeer{namespace: "shared", port: 3306}},
+		{"10.0.4.12", networkPeer{cidr: "10.0.4.12/32", port: 3306}},
+		{"db.example.rds.amazonaws.com", networkPeer{cidr: "0.0.0.0/0", port: 3306}},
+	}
+	for _, tt := range tests {
+		if got := servicePeer(tt.host, 3306); got != tt.want {
+			t.Errorf("servicePeer(%q) = %+v, want %+v", tt.host, got, tt.want)
+		}
+	}
+}
+
+func TestParsePeer(t *testing.T) {
+	if peer, err := parsePeer("observability:4317"); err != nil || peer != (networkPeer{namespace: "observability", port: 4317}) {
+		t.Errorf("parsePeer(namespace) = %+v, %v", peer, err)
+	}
+	if peer, err := parsePeer("10.20.0.0/16:443"); err != nil || peer != (networkPeer{cidr: "10.20.0.0/16", port: 443}) {
+		t.Errorf("parsePeer(cidr) = %+v, %v", peer, err)
+	}
+	for _, bad := range []string{"observability", "observability:http", "10.20.0.0/99:443", "Bad_NS:80"} {
+		if _, err := parsePeer(bad); err == nil {
+			t.Errorf("parsePeer(%q) should fail", bad)
+		}
+	}
+}
+
+func TestGenerateNetworkPolicy(t *testing.T) {
+	m := newTestManager()
+	m.network = networkConfig{
+		enabled:           true,
+		ingressNamespaces: []string{"kube-system"},
+		ingressCIDRs:      []string{"10.0.0.0/16"},
+		egress: []networkPeer{
+			servicePeer("mysql.texecom.svc.cluster.local", 3306),
+			{cidr: "10.20.0.0/16", port: 443},
+		},
+	}
+	env := &Environment{ID: "abc", Name: "demo", Namespace: "envs"}
+
+	policy := m.generateNetworkPolicy(env)
+	for _, want := range []string{
+		"kind: NetworkPolicy\nmetadata:\n  name: demo-isolation\n  namespace: envs",
+		"  podSelector:\n    matchLabels:\n      env-id: abc",
+		"    - from:\n        - podSelector:\n            matchLabels:\n              env-id: abc",
+		"        - ipBlock:\n            cidr: 10.0.0.0/16",
+		"    - to:\n        - podSelector:\n            matchLabels:\n              env-id: abc",
+		"              kubernetes.io/metadata.name: texecom\n      ports:\n        - protocol: TCP\n          port: 3306",
+		"            cidr: 10.20.0.0/16\n      ports:\n        - protocol: TCP\n          port: 443",
+		"          port: 53",
+	} {
+		if !strings.Contains(policy, want) {
+			t.Errorf("network policy missing %q:\n%s", want, policy)
+		}
+	}
+
+	m.steps = m.provisionSteps()
+	if i := stepIndex(m.newSteps(env), StepNetworkPolicy); i != 1 {
+		t.Errorf("network policy step at %d, want 1, right after the database", i)
+	}
+	m.network.enabled = false
+	if i := stepIndex(m.newSteps(env), StepNetworkPolicy); i >= 0 {
+		t.Error("network policy step should be skipped when disabled")
+	}
+}
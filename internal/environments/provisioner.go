@@ -0,0 +1,415 @@
+package environments
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"os/exec"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Provisioner names
+const (
+	ProvisionerManifests = "manifests"
+	ProvisionerHelm      = "helm"
+	ProvisionerTerraform = "terraform"
+)
+
+// DefaultTemplate is used for environments created without a template
+const DefaultTemplate = "default"
+
+// ErrUnknownTemplate is returned when creating an environment from a
+// template that doesn't exist
+var ErrUnknownTemplate = errors.New("unknown environment template")
+
+// Provisioner creates and removes the resources behind an environment.
+// Provision must be safe to run again, so a failed step can be retried.
+type Provisioner interface {
+	Provision(ctx context.Context, env *Environment) error
+	Teardown(ctx context.Context, env *Environment) error
+}
+
+// HelmConfig installs a chart per environment. String values may use the
+// placeholders described at expandPlaceholders.
+type HelmConfig struct {
+	Chart   string                 `json:"chart"`
+	Repo    string                 `json:"repo,omitempty"`
+	Version string                 `json:"version,omitempty"`
+	Values  map[string]interface{} `json:"values,omitempty"`
+	// Editable whitelists values users may override per environment;
+	// defaults to replicaCount, image.tag and featureFlags.*
+	Editable []EditableValue `json:"editable,omitempty"`
+}
+
+// TerraformConfig applies a Terraform module per environment, each in its
+// own workspace
+type TerraformConfig struct {
+	Dir  string            `json:"dir"`
+	Vars map[string]string `json:"vars,omitempty"`
+}
+
+// Template is a named kind of environment users can create
+type Template struct {
+	Name        string           `json:"name"`
+	Description string           `json:"description,omitempty"`
+	Provisioner string           `json:"provisioner"` // manifests, helm or terraform
+	Helm        *HelmConfig      `json:"helm,omitempty"`
+	Terraform   *TerraformConfig `json:"terraform,omitempty"`
+	// SmokeTest runs against new environments before they're ready
+	SmokeTest *SmokeTest `json:"smokeTest,omitempty"`
+}
+
+func (t Template) validate() error {
+	switch t.Provisioner {
+	case ProvisionerManifests:
+	case ProvisionerHelm:
+		if t.Helm == nil || t.Helm.Chart == "" {
+			return fmt.Errorf("template %s: helm provisioner needs a chart", t.Name)
+		}
+		for _, e := range t.Helm.Editable {
+			if e.Path == "" || (e.Type != ValueInt && e.Type != ValueBool && e.Type != ValueString) {
+				return fmt.Errorf("template %s: editable value %q needs a path and a type of int, bool or string", t.Name, e.Path)
+			}
+		}
+	case ProvisionerTerraform:
+		if t.Terraform == nil || t.Terraform.Dir == "" {
+			return fmt.Errorf("template %s: terraform provisioner needs a dir", t.Name)
+		}
+	default:
+		return fmt.Errorf("template %s: unknown provisioner %q", t.Name, t.Provisioner)
+	}
+	if t.SmokeTest != nil && t.SmokeTest.Workflow == "" {
+		return fmt.Errorf("template %s: smoke test needs a workflow", t.Name)
+	}
+	return nil
+}
+
+type templatesConfig struct {
+	Templates []Template `json:"templates"`
+}
+
+// defaultTemplates is the built-in template, the raw manifests
+// environments have always been created from
+func defaultTemplates() map[string]Template {
+	return map[string]Template{
+		DefaultTemplate: {Name: DefaultTemplate, Description: "Fern deployment from raw manifests", Provisioner: ProvisionerManifests},
+	}
+}
+
+// loadTemplates reads templates from the JSON file in
+// ENVIRONMENT_TEMPLATES_FILE when set
+func (m *Manager) loadTemplates() error {
+	m.templates = defaultTemplates()
+	file := os.Getenv("ENVIRONMENT_TEMPLATES_FILE")
+	if file == "" {
+		return nil
+	}
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return fmt.Errorf("failed to read environment templates file: %w", err)
+	}
+	return m.Import(data)
+}
+
+// Templates lists the environment templates by name
+func (m *Manager) Templates() []Template {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	templates := make([]Template, 0, len(m.templates))
+	for _, t := range m.templates {
+		templates = append(templates, t)
+	}
+	sort.Slice(templates, func(i, j int) bool { return templates[i].Name < templates[j].Name })
+	return templates
+}
+
+func (m *Manager) template(name string) (Template, bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	t, ok := m.templates[name]
+	return t, ok
+}
+
+// Export returns the environment templates as JSON, for configuration sync
+func (m *Manager) Export() (json.RawMessage, error) {
+	return json.Marshal(templatesConfig{Templates: m.Templates()})
+}
+
+// Import replaces the environment templates. The default template is kept
+// unless overridden.
+func (m *Manager) Import(data json.RawMessage) error {
+	var cfg templatesConfig
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return fmt.Errorf("failed to parse environment templates: %w", err)
+	}
+
+	templates := defaultTemplates()
+	for _, t := range cfg.Templates {
+		if t.Name == "" {
+			return fmt.Errorf("environment template without a name")
+		}
+		if t.Provisioner == "" {
+			t.Provisioner = ProvisionerManifests
+		}
+		if err := t.validate(); err != nil {
+			return err
+		}
+		templates[t.Name] = t
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.templates = templates
+	return nil
+}
+
+// provisioner returns the provisioner for an environment's template
+func (m *Manager) provisioner(env *Environment) (Provisioner, error) {
+	t, ok := m.template(env.Template)
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownTemplate, env.Template)
+	}
+	switch t.Provisioner {
+	case ProvisionerHelm:
+		return &helmProvisioner{config: *t.Helm, run: m.run}, nil
+	case ProvisionerTerraform:
+		return &terraformProvisioner{config: *t.Terraform, run: m.run}, nil
+	}
+	return &manifestProvisioner{m: m}, nil
+}
+
+// usesManifests reports whether the dashboard renders the environment's
+// ingress itself, so can manage its certificate
+func (m *Manager) usesManifests(env *Environment) bool {
+	t, ok := m.template(env.Template)
+	return ok && t.Provisioner == ProvisionerManifests
+}
+
+// createResources is the provisioning step creating the environment's
+// resources through its template's provisioner
+func (m *Manager) createResources(ctx context.Context, env *Environment) error {
+	p, err := m.provisioner(env)
+	if err != nil {
+		return err
+	}
+	return p.Provision(ctx, env)
+}
+
+// deleteResources removes the environment's resources on teardown
+func (m *Manager) deleteResources(ctx context.Context, env *Environment) {
+	p, err := m.provisioner(env)
+	if err == nil {
+		err = p.Teardown(ctx, env)
+	}
+	if err != nil {
+		log.Printf("Warning: failed to tear down resources of %s: %v", env.Name, err)
+	}
+}
+
+// commandRunner runs an external tool with extra environment variables
+type commandRunner func(ctx context.Context, env []string, name string, args ...string) ([]byte, error)
+
+func runCommand(ctx context.Context, env []string, name string, args ...string) ([]byte, error) {
+	cmd := exec.CommandContext(ctx, name, args...)
+	cmd.Env = append(os.Environ(), env...)
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		return out, fmt.Errorf("%s %s: %w: %s", name, strings.Join(args, " "), err, strings.TrimSpace(string(out)))
+	}
+	return out, nil
+}
+
+var placeholder = regexp.MustCompile(`\$\{[A-Z_]+\}`)
+
+// expandPlaceholders replaces ${ENV_ID}, ${ENV_NAME}, ${HOSTNAME}, ${URL},
+// ${NAMESPACE}, ${DATABASE_SCHEMA}, ${REDIS_PREFIX}, ${MQTT_PREFIX} and
+// ${BRANCH} with the environment's values. Other references are left as is.
+func expandPlaceholders(s string, env *Environment) string {
+	values := map[string]string{
+		"ENV_ID":          env.ID,
+		"ENV_NAME":        env.Name,
+		"HOSTNAME":        env.Hostname,
+		"URL":             env.URL,
+		"NAMESPACE":       env.Namespace,
+		"DATABASE_SCHEMA": env.DatabaseSchema,
+		"REDIS_PREFIX":    env.RedisPrefix,
+		"MQTT_PREFIX":     env.MQTTPrefix,
+		"BRANCH":          env.Branch,
+	}
+	return placeholder.ReplaceAllStringFunc(s, func(ref string) string {
+		if value, ok := values[ref[2:len(ref)-1]]; ok {
+			return value
+		}
+		return ref
+	})
+}
+
+// expandValues returns a copy of chart values with placeholders expanded
+func expandValues(v interface{}, env *Environment) interface{} {
+	switch v := v.(type) {
+	case string:
+		return expandPlaceholders(v, env)
+	case map[string]interface{}:
+		expanded := make(map[string]interface{}, len(v))
+		for key, value := range v {
+			expanded[key] = expandValues(value, env)
+		}
+		return expanded
+	case []interface{}:
+		expanded := make([]interface{}, len(v))
+		for i, value := range v {
+			expanded[i] = expandValues(value, env)
+		}
+		return expanded
+	}
+	return v
+}
+
+// manifestProvisioner applies the built-in Fern manifests
+type manifestProvisioner struct {
+	m *Manager
+}
+
+func (p *manifestProvisioner) Provision(ctx context.Context, env *Environment) error {
+	return p.m.createKubernetesResources(ctx, env)
+}
+
+func (p *manifestProvisioner) Teardown(ctx context.Context, env *Environment) error {
+	// kubectl delete -l env-id=<id> --namespace=<ns>
+	log.Printf("Delete with: kubectl delete all,ingress,certificate -l env-id=%s --namespace=%s", env.ID, env.Namespace)
+	return nil
+}
+
+// helmProvisioner installs a chart as one release per environment
+type helmProvisioner struct {
+	config HelmConfig
+	run    commandRunner
+}
+
+// helmReleaseName keeps release names within Helm's 53 character limit
+func helmReleaseName(env *Environment) string {
+	name := "env-" + env.Name
+	if len(name) > 53 {
+		name = strings.TrimRight(name[:53], "-")
+	}
+	return name
+}
+
+func helmValuesFile(env *Environment) string {
+	return fmt.Sprintf("/tmp/env-%s-values.json", env.ID)
+}
+
+// values renders the chart values for an environment, with its overrides
+func (p *helmProvisioner) values(env *Environment) map[string]interface{} {
+	values, _ := expandValues(p.config.Values, env).(map[string]interface{})
+	if values == nil {
+		values = make(map[string]interface{})
+	}
+	for path, value := range env.ValueOverrides {
+		setPath(values, path, value)
+	}
+	return values
+}
+
+func (p *helmProvisioner) Provision(ctx context.Context, env *Environment) error {
+	data, err := json.MarshalIndent(p.values(env), "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to render chart values: %w", err)
+	}
+	// Helm reads JSON values files as YAML
+	file := helmValuesFile(env)
+	if err := os.WriteFile(file, data, 0644); err != nil {
+		return fmt.Errorf("failed to write chart values: %w", err)
+	}
+
+	args := []string{"upgrade", "--install", helmReleaseName(env), p.config.Chart,
+		"--namespace", env.Namespace, "--values", file}
+	if p.config.Repo != "" {
+		args = append(args, "--repo", p.config.Repo)
+	}
+	if p.config.Version != "" {
+		args = append(args, "--version", p.config.Version)
+	}
+	if _, err := p.run(ctx, nil, "helm", args...); err != nil {
+		return err
+	}
+
+	log.Printf("Installed Helm release %s for %s", helmReleaseName(env), env.Name)
+	return nil
+}
+
+func (p *helmProvisioner) Teardown(ctx context.Context, env *Environment) error {
+	_, err := p.run(ctx, nil, "helm", "uninstall", helmReleaseName(env), "--namespace", env.Namespace)
+	os.Remove(helmValuesFile(env))
+	return err
+}
+
+// terraformProvisioner applies a module in a workspace per environment.
+// The workspace is chosen with TF_WORKSPACE so environments provisioning at
+// the same time don't switch each other's workspace.
+type terraformProvisioner struct {
+	config TerraformConfig
+	run    commandRunner
+}
+
+func terraformWorkspace(env *Environment) string {
+	return "env-" + env.ID
+}
+
+// vars returns the -var arguments, always including the environment's
+// id and name
+func (p *terraformProvisioner) vars(env *Environment) []string {
+	vars := map[string]string{"env_id": env.ID, "env_name": env.Name}
+	for key, value := range p.config.Vars {
+		vars[key] = expandPlaceholders(value, env)
+	}
+
+	keys := make([]string, 0, len(vars))
+	for key := range vars {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var args []string
+	for _, key := range keys {
+		args = append(args, "-var", key+"="+vars[key])
+	}
+	return args
+}
+
+func (p *terraformProvisioner) terraform(ctx context.Context, env *Environment, args ...string) error {
+	args = append([]string{"-chdir=" + p.config.Dir}, args...)
+	_, err := p.run(ctx, []string{"TF_WORKSPACE=" + terraformWorkspace(env), "TF_IN_AUTOMATION=1"}, "terraform", args...)
+	return err
+}
+
+func (p *terraformProvisioner) Provision(ctx context.Context, env *Environment) error {
+	// Creating the workspace fails harmlessly when a retry finds it exists
+	p.run(ctx, nil, "terraform", "-chdir="+p.config.Dir, "workspace", "new", terraformWorkspace(env))
+
+	if err := p.terraform(ctx, env, "init", "-input=false"); err != nil {
+		return err
+	}
+	args := append([]string{"apply", "-auto-approve", "-input=false"}, p.vars(env)...)
+	if err := p.terraform(ctx, env, args...); err != nil {
+		return err
+	}
+
+	log.Printf("Applied Terraform workspace %s for %s", terraformWorkspace(env), env.Name)
+	return nil
+}
+
+func (p *terraformProvisioner) Teardown(ctx context.Context, env *Environment) error {
+	args := append([]string{"destroy", "-auto-approve", "-input=false"}, p.vars(env)...)
+	if err := p.terraform(ctx, env, args...); err != nil {
+		return err
+	}
+	_, err := p.run(ctx, nil, "terraform", "-chdir="+p.config.Dir, "workspace", "delete", terraformWorkspace(env))
+	return err
+}
@@ -0,0 +1,169 @@
+package environments
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"strings"
+	"testing"
+)
+
+type recordedCommand struct {
+	env  []string
+	args string
+}
+
+func recordCommands(m *Manager) *[]recordedCommand {
+	var commands []recordedCommand
+	m.run = func(ctx context.Context, env []string, name string, args ...string) ([]byte, error) {
+		commands = append(commands, recordedCommand{env: env, args: name + " " + strings.Join(args, " ")})
+		return nil, nil
+	}
+	return &commands
+}
+
+const testTemplates = `{"templates": [
+	{"name": "fern-chart", "provisioner": "helm", "helm": {
+		"chart": "fern", "repo": "https://charts.example.com", "version": "1.2.0",
+		"values": {"ingress": {"host": "${HOSTNAME}"}, "replicas": 1, "env": ["DB=${DATABASE_SCHEMA}"]}
+	}},
+	{"name": "full-stack", "provisioner": "terraform", "terraform": {
+		"dir": "/infra/env", "vars": {"hostname": "${HOSTNAME}"}
+	}}
+]}`
+
+func TestImportTemplates(t *testing.T) {
+	m := newTestManager()
+	if err := m.Import(json.RawMessage(testTemplates)); err != nil {
+		t.Fatalf("Import: %v", err)
+	}
+
+	var names []string
+	for _, tmpl := range m.Templates() {
+		names = append(names, tmpl.Name+"="+tmpl.Provisioner)
+	}
+	if got := strings.Join(names, ","); got != "default=manifests,fern-chart=helm,full-stack=terraform" {
+		t.Errorf("templates = %s", got)
+	}
+
+	for _, bad := range []string{
+		`{"templates": [{"name": "x", "provisioner": "pulumi"}]}`,
+		`{"templates": [{"name": "x", "provisioner": "helm"}]}`,
+		`{"templates": [{"name": "x", "provisioner": "terraform", "terraform": {}}]}`,
+		`{"templates": [{"provisioner": "manifests"}]}`,
+	} {
+		if err := m.Import(json.RawMessage(bad)); err == nil {
+			t.Errorf("Import(%s) should fail", bad)
+		}
+	}
+	if len(m.Templates()) != 3 {
+		t.Error("a failed import should keep the existing templates")
+	}
+}
+
+func TestCreateFromTemplate(t *testing.T) {
+	m := newTLSManager(TLSWildcard)
+	if err := m.Import(json.RawMessage(testTemplates)); err != nil {
+		t.Fatalf("Import: %v", err)
+	}
+
+	if _, err := m.Create(context.Background(), CreateEnvironmentRequest{Template: "missing"}); !errors.Is(err, ErrUnknownTemplate) {
+		t.Errorf("err = %v, want ErrUnknownTemplate", err)
+	}
+
+	env, err := m.Create(context.Background(), CreateEnvironmentRequest{Name: "chart", Template: "fern-chart"})
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	if env.Template != "fern-chart" || env.TLS != nil {
+		t.Errorf("template = %s, TLS = %+v; chart environments manage their own TLS", env.Template, env.TLS)
+	}
+
+	env, err = m.Create(context.Background(), CreateEnvironmentRequest{Name: "plain"})
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	if env.Template != DefaultTemplate || env.TLS == nil {
+		t.Errorf("template = %s, TLS = %+v", env.Template, env.TLS)
+	}
+}
+
+func TestHelmProvisioner(t *testing.T) {
+	m := newTestManager()
+	if err := m.Import(json.RawMessage(testTemplates)); err != nil {
+		t.Fatalf("Import: %v", err)
+	}
+	commands := recordCommands(m)
+
+	env := &Environment{ID: "abc123", Name: "chart", Template: "fern-chart", Namespace: "envs",
+		Hostname: "chart.envs.example.com", DatabaseSchema: "texecom_env_abc123"}
+	if err := m.createResources(context.Background(), env); err != nil {
+		t.Fatalf("createResources: %v", err)
+	}
+	defer os.Remove(helmValuesFile(env))
+
+	want := "helm upgrade --install env-chart fern --namespace envs --values /tmp/env-abc123-values.json --repo https://charts.example.com --version 1.2.0"
+	if len(*commands) != 1 || (*commands)[0].args != want {
+		t.Fatalf("commands = %+v, want %s", *commands, want)
+	}
+
+	data, err := os.ReadFile(helmValuesFile(env))
+	if err != nil {
+		t.Fatalf("values file: %v", err)
+	}
+	var values struct {
+		Ingress  struct{ Host string }
+		Replicas int
+		Env      []string
+	}
+	if err := json.Unmarshal(data, &values); err != nil {
+		t.Fatalf("values: %v", err)
+	}
+	if values.Ingress.Host != "chart.envs.example.com" || values.Replicas != 1 || values.Env[0] != "DB=texecom_env_abc123" {
+		t.Errorf("values = %s", data)
+	}
+
+	m.deleteResources(context.Background(), env)
+	if got := (*commands)[1].args; got != "helm uninstall env-chart --namespace envs" {
+		t.Errorf("teardown = %s", got)
+	}
+}
+
+func TestTerraformProvisioner(t *testing.T) {
+	m := newTestManager()
+	if err := m.Import(json.RawMessage(testTemplates)); err != nil {
+		t.Fatalf("Import: %v", err)
+	}
+	commands := recordCommands(m)
+
+	env := &Environment{ID: "abc123", Name: "stack", Template: "full-stack", Hostname: "stack.envs.example.com"}
+	if err := m.createResources(context.Background(), env); err != nil {
+		t.Fatalf("createResources: %v", err)
+	}
+
+	want := []string{
+		"terraform -chdir=/infra/env workspace new env-abc123",
+		"terraform -chdir=/infra/env init -input=false",
+		"terraform -chdir=/infra/env apply -auto-approve -input=false -var env_id=abc123 -var env_name=stack -var hostname=stack.envs.example.com",
+	}
+	if len(*commands) != len(want) {
+		t.Fatalf("commands = %+v", *commands)
+	}
+	for i, cmd := range *commands {
+		if cmd.args != want[i] {
+			t.Errorf("command %d = %s, want %s", i, cmd.args, want[i])
+		}
+	}
+	if env := (*commands)[2].env; len(env) == 0 || env[0] != "TF_WORKSPACE=env-abc123" {
+		t.Errorf("apply env = %v, want the environment's workspace", env)
+	}
+}
+
+func TestExpandPlaceholders(t *testing.T) {
+	env := &Environment{ID: "abc", Name: "demo", Branch: "feature/x"}
+	got := expandPlaceholders("${ENV_NAME}-${BRANCH} keeps ${UNKNOWN} and $HOME", env)
+	if got != "demo-feature/x keeps ${UNKNOWN} and $HOME" {
+		t.Errorf("expandPlaceholders = %q", got)
+	}
+}
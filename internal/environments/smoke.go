@@ -0,0 +1,78 @@
+package environments
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// smokeTestTimeout bounds the smoke test step, including time the run
+// spends waiting in the run queue
+const smokeTestTimeout = 30 * time.Minute
+
+// ErrSmokeTestFailed is returned by smoke test runners when the smoke test
+// ran and did not pass
+var ErrSmokeTestFailed = errors.New("workflow did not pass")
+
+// SmokeTest is a workflow run against each environment created from a
+// template once its resources are ready. The environment only becomes
+// ready if the run passes.
+type SmokeTest struct {
+	Workflow string `json:"workflow"`
+	// Config sets workflow config variables; values may use the
+	// placeholders described at expandPlaceholders, e.g. "${URL}"
+	Config map[string]string `json:"config,omitempty"`
+}
+
+// SmokeTestRunner runs a smoke test against an environment and waits for
+// it to finish. It returns the execution's ID, when it started one, and
+// an error wrapping ErrSmokeTestFailed when the run didn't pass.
+type SmokeTestRunner func(ctx context.Context, env Environment, test SmokeTest) (string, error)
+
+// SetSmokeTestRunner sets what runs smoke test workflows. Without one,
+// environments with a smoke test fail at that step.
+func (m *Manager) SetSmokeTestRunner(run SmokeTestRunner) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.smokeTests = run
+}
+
+// hasSmokeTest reports whether an environment runs a smoke test
+func hasSmokeTest(env *Environment) bool {
+	return env.SmokeWorkflow != ""
+}
+
+// smokeTest returns the smoke test for an environment: its workflow, with
+// the config of its template's smoke test
+func (m *Manager) smokeTest(env *Environment) SmokeTest {
+	test := SmokeTest{Workflow: env.SmokeWorkflow}
+	if t, ok := m.template(env.Template); ok && t.SmokeTest != nil {
+		test.Config = make(map[string]string, len(t.SmokeTest.Config))
+		for name, value := range t.SmokeTest.Config {
+			test.Config[name] = expandPlaceholders(value, env)
+		}
+	}
+	return test
+}
+
+// runSmokeTest is the provisioning step running the environment's smoke
+// test, recording its execution so a failure links to it
+func (m *Manager) runSmokeTest(ctx context.Context, env *Environment) error {
+	m.mu.Lock()
+	run := m.smokeTests
+	env.SmokeExecution = ""
+	snapshot := *env
+	m.mu.Unlock()
+	if run == nil {
+		return fmt.Errorf("no smoke test runner configured for workflow %s", snapshot.SmokeWorkflow)
+	}
+
+	id, err := run(ctx, snapshot, m.smokeTest(&snapshot))
+	if id != "" {
+		m.mu.Lock()
+		env.SmokeExecution = id
+		m.mu.Unlock()
+	}
+	return err
+}
@@ -0,0 +1,83 @@
+package environments
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestSmokeTestGatesReady(t *testing.T) {
+	m := newTestManager()
+	m.steps = []provisionStep{{
+		name:        StepSmokeTest,
+		description: "pass smoke test",
+		timeout:     smokeTestTimeout,
+		maxAttempts: 1,
+		run:         m.runSmokeTest,
+		applies:     hasSmokeTest,
+	}}
+	m.templates["fern"] = Template{
+		Name:        "fern",
+		Provisioner: ProvisionerManifests,
+		SmokeTest:   &SmokeTest{Workflow: "fern-smoke", Config: map[string]string{"baseUrl": "${URL}/api"}},
+	}
+
+	passed := true
+	var got SmokeTest
+	m.SetSmokeTestRunner(func(ctx context.Context, env Environment, test SmokeTest) (string, error) {
+		got = test
+		if !passed {
+			return "exec-2", fmt.Errorf("%w: fern-smoke execution failed", ErrSmokeTestFailed)
+		}
+		return "exec-1", nil
+	})
+
+	// Environments without a smoke test skip the step
+	plain := addEnvironment(m, "plain")
+	if len(plain.Steps) != 0 {
+		t.Errorf("steps = %+v, want none without a smoke test", plain.Steps)
+	}
+
+	env := &Environment{ID: "smoke", Name: "smoke", Template: "fern", SmokeWorkflow: "fern-smoke", URL: "https://smoke.example.com", Status: StatusCreating}
+	env.Steps = m.newSteps(env)
+	m.environments[env.ID] = env
+	m.provisionEnvironment(env)
+	if env.Status != StatusReady || env.SmokeExecution != "exec-1" {
+		t.Errorf("status = %s, execution = %q, want ready after exec-1 passed", env.Status, env.SmokeExecution)
+	}
+	if got.Workflow != "fern-smoke" || got.Config["baseUrl"] != "https://smoke.example.com/api" {
+		t.Errorf("smoke test = %+v, want fern-smoke with the environment's URL", got)
+	}
+
+	// A failing run fails the environment and links to the execution
+	passed = false
+	env = &Environment{ID: "broken", Name: "broken", Template: "fern", SmokeWorkflow: "fern-smoke", Status: StatusCreating}
+	env.Steps = m.newSteps(env)
+	m.environments[env.ID] = env
+	m.provisionEnvironment(env)
+	if env.Status != StatusFailed || env.SmokeExecution != "exec-2" || !strings.Contains(env.Error, "fern-smoke execution failed") {
+		t.Errorf("status = %s, execution = %q, error = %q, want failed linking exec-2", env.Status, env.SmokeExecution, env.Error)
+	}
+	if env.ReadyAt != nil {
+		t.Error("a failed smoke test must not mark the environment ready")
+	}
+}
+
+func TestSmokeTestWithoutRunner(t *testing.T) {
+	m := newTestManager()
+	env := &Environment{ID: "orphan", SmokeWorkflow: "fern-smoke"}
+	m.environments[env.ID] = env
+	if err := m.runSmokeTest(context.Background(), env); err == nil || errors.Is(err, ErrSmokeTestFailed) {
+		t.Errorf("got %v, want an error for the missing runner", err)
+	}
+}
+
+func TestTemplateSmokeTestNeedsWorkflow(t *testing.T) {
+	m := newTestManager()
+	err := m.Import([]byte(`{"templates": [{"name": "fern", "smokeTest": {"config": {"url": "${URL}"}}}]}`))
+	if err == nil {
+		t.Error("expected a smoke test without a workflow to be rejected")
+	}
+}
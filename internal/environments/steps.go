@@ -0,0 +1,228 @@
+package environments
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+)
+
+// StepStatus is the progress of one provisioning step
+type StepStatus string
+
+const (
+	StepPending   StepStatus = "pending"
+	StepRunning   StepStatus = "running"
+	StepSucceeded StepStatus = "succeeded"
+	StepFailed    StepStatus = "failed"
+)
+
+// Provisioning step names
+const (
+	StepDatabase      = "database"
+	StepNetworkPolicy = "network-policy"
+	StepResources     = "resources"
+	StepCertificate   = "certificate"
+	StepReady         = "ready"
+	StepSmokeTest     = "smoke-test"
+)
+
+// resourcesTimeout bounds provisioning or tearing down an environment's
+// resources, which for Terraform can take a while
+const resourcesTimeout = 15 * time.Minute
+
+// defaultRetryBackoff is the wait before a step's first retry; it doubles
+// for each further attempt
+const defaultRetryBackoff = 2 * time.Second
+
+// Step records the state of one provisioning step on an environment
+type Step struct {
+	Name       string     `json:"name"`
+	Status     StepStatus `json:"status"`
+	Attempts   int        `json:"attempts"`
+	Error      string     `json:"error,omitempty"`
+	StartedAt  *time.Time `json:"startedAt,omitempty"`
+	FinishedAt *time.Time `json:"finishedAt,omitempty"`
+}
+
+// provisionStep is one unit of provisioning work. Steps must be safe to
+// run again after a failed or timed out attempt.
+type provisionStep struct {
+	name        string
+	description string // used in the environment's error, e.g. "create database"
+	timeout     time.Duration
+	maxAttempts int
+	run         func(ctx context.Context, env *Environment) error
+	// applies limits the step to some environments; nil means all
+	applies func(env *Environment) bool
+}
+
+// provisionSteps are the steps run, in order, to provision an environment
+func (m *Manager) provisionSteps() []provisionStep {
+	return []provisionStep{
+		{name: StepDatabase, description: "create database", timeout: 30 * time.Second, maxAttempts: 3, run: m.createDatabaseSchema},
+		{name: StepNetworkPolicy, description: "isolate network", timeout: 30 * time.Second, maxAttempts: 3, run: m.createNetworkPolicy, applies: m.networkPolicyEnabled},
+		{name: StepResources, description: "create resources", timeout: resourcesTimeout, maxAttempts: 3, run: m.createResources},
+		{name: StepCertificate, description: "issue TLS certificate", timeout: 10 * time.Minute, maxAttempts: 1, run: m.waitForCertificate, applies: usesCertManager},
+		{name: StepReady, description: "become ready", timeout: 5 * time.Minute, maxAttempts: 1, run: m.waitForReady},
+		{name: StepSmokeTest, description: "pass smoke test", timeout: smokeTestTimeout, maxAttempts: 1, run: m.runSmokeTest, applies: hasSmokeTest},
+	}
+}
+
+// newSteps returns the initial, all pending, states of the steps that
+// apply to an environment
+func (m *Manager) newSteps(env *Environment) []Step {
+	var steps []Step
+	for _, step := range m.steps {
+		if step.applies == nil || step.applies(env) {
+			steps = append(steps, Step{Name: step.name, Status: StepPending})
+		}
+	}
+	return steps
+}
+
+func (m *Manager) provisionStep(name string) (provisionStep, bool) {
+	for _, step := range m.steps {
+		if step.name == name {
+			return step, true
+		}
+	}
+	return provisionStep{}, false
+}
+
+func stepIndex(steps []Step, name string) int {
+	for i, step := range steps {
+		if step.Name == name {
+			return i
+		}
+	}
+	return -1
+}
+
+// provisionEnvironment runs every step that has not yet succeeded, so a
+// retried environment resumes where it failed
+func (m *Manager) provisionEnvironment(env *Environment) {
+	log.Printf("Provisioning environment %s (%s)", env.Name, env.ID)
+
+	for i := range env.Steps {
+		m.mu.RLock()
+		state := env.Steps[i]
+		m.mu.RUnlock()
+		step, ok := m.provisionStep(state.Name)
+		if !ok || state.Status == StepSucceeded {
+			continue
+		}
+
+		if err := m.runStep(env, i, step); err != nil {
+			m.setError(env, fmt.Sprintf("Failed to %s: %v", step.description, err))
+			return
+		}
+	}
+
+	m.mu.Lock()
+	now := time.Now()
+	env.Status = StatusReady
+	env.ReadyAt = &now
+	m.mu.Unlock()
+
+	log.Printf("Environment %s is ready at %s", env.Name, env.URL)
+}
+
+// runStep runs a step with its timeout, retrying with exponential backoff
+// until it succeeds or runs out of attempts
+func (m *Manager) runStep(env *Environment, i int, step provisionStep) error {
+	backoff := m.retryBackoff
+	for attempt := 1; ; attempt++ {
+		m.mu.Lock()
+		state := &env.Steps[i]
+		started := time.Now()
+		state.Status = StepRunning
+		state.Attempts++
+		state.StartedAt = &started
+		state.FinishedAt = nil
+		m.mu.Unlock()
+
+		ctx, cancel := context.WithTimeout(context.Background(), step.timeout)
+		err := step.run(ctx, env)
+		if err == nil && ctx.Err() != nil {
+			err = fmt.Errorf("timed out after %s", step.timeout)
+		}
+		cancel()
+
+		m.mu.Lock()
+		finished := time.Now()
+		state.FinishedAt = &finished
+		if err == nil {
+			state.Status = StepSucceeded
+			state.Error = ""
+		} else {
+			state.Error = err.Error()
+			if attempt >= step.maxAttempts {
+				state.Status = StepFailed
+			}
+		}
+		m.mu.Unlock()
+
+		if err == nil || attempt >= step.maxAttempts {
+			return err
+		}
+
+		log.Printf("Environment %s step %s failed (attempt %d of %d), retrying in %s: %v",
+			env.Name, step.name, attempt, step.maxAttempts, backoff, err)
+		time.Sleep(backoff)
+		backoff *= 2
+	}
+}
+
+// RetryStep re-runs a failed environment's provisioning from the named
+// step. Earlier steps that succeeded are not run again.
+func (m *Manager) RetryStep(id, name string) error {
+	m.mu.Lock()
+	env, ok := m.environments[id]
+	if !ok {
+		m.mu.Unlock()
+		return fmt.Errorf("environment not found: %s", id)
+	}
+	if env.Status != StatusFailed {
+		m.mu.Unlock()
+		return fmt.Errorf("environment %s is %s, only failed environments can be retried", env.Name, env.Status)
+	}
+	i := stepIndex(env.Steps, name)
+	if i < 0 {
+		m.mu.Unlock()
+		return fmt.Errorf("unknown provisioning step %q", name)
+	}
+
+	for j := i; j < len(env.Steps); j++ {
+		env.Steps[j].Status = StepPending
+		env.Steps[j].Error = ""
+	}
+	env.Status = StatusCreating
+	env.Error = ""
+	m.mu.Unlock()
+
+	log.Printf("Retrying environment %s from step %s", env.Name, name)
+	go m.provisionEnvironment(env)
+	return nil
+}
+
+// Resume retries a failed environment from its first unfinished step
+func (m *Manager) Resume(id string) error {
+	m.mu.RLock()
+	env, ok := m.environments[id]
+	var name string
+	if ok {
+		for _, step := range env.Steps {
+			if step.Status != StepSucceeded {
+				name = step.Name
+				break
+			}
+		}
+	}
+	m.mu.RUnlock()
+
+	if !ok {
+		return fmt.Errorf("environment not found: %s", id)
+	}
+	return m.RetryStep(id, name)
+}
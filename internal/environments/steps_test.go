@@ -0,0 +1,135 @@
+package environments
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+// newTestManager returns a manager running the given steps without
+// waiting between retries
+func newTestManager(steps ...provisionStep) *Manager {
+	return &Manager{
+		environments: make(map[string]*Environment),
+		steps:        steps,
+		templates:    defaultTemplates(),
+	}
+}
+
+func addEnvironment(m *Manager, id string) *Environment {
+	env := &Environment{ID: id, Name: id, Status: StatusCreating, CreatedAt: time.Now()}
+	env.Steps = m.newSteps(env)
+	m.environments[id] = env
+	return env
+}
+
+func waitForStatus(t *testing.T, m *Manager, id string, status EnvironmentStatus) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for time.Now().Before(deadline) {
+		m.mu.RLock()
+		current := m.environments[id].Status
+		m.mu.RUnlock()
+		if current == status {
+			return
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	t.Fatalf("environment %s did not become %s", id, status)
+}
+
+func TestProvisionRetriesTransientFailures(t *testing.T) {
+	calls := 0
+	m := newTestManager(provisionStep{
+		name:        StepDatabase,
+		description: "create database",
+		timeout:     time.Second,
+		maxAttempts: 3,
+		run: func(ctx context.Context, env *Environment) error {
+			calls++
+			if calls < 3 {
+				return errors.New("connection refused")
+			}
+			return nil
+		},
+	})
+	env := addEnvironment(m, "blip")
+
+	m.provisionEnvironment(env)
+
+	if env.Status != StatusReady || env.ReadyAt == nil {
+		t.Fatalf("status = %s, want ready", env.Status)
+	}
+	if step := env.Steps[0]; step.Status != StepSucceeded || step.Attempts != 3 || step.Error != "" {
+		t.Errorf("step = %+v, want succeeded after 3 attempts", step)
+	}
+}
+
+func TestProvisionStepTimeout(t *testing.T) {
+	m := newTestManager(provisionStep{
+		name:        StepReady,
+		description: "become ready",
+		timeout:     10 * time.Millisecond,
+		maxAttempts: 1,
+		run: func(ctx context.Context, env *Environment) error {
+			<-ctx.Done()
+			return ctx.Err()
+		},
+	})
+	env := addEnvironment(m, "slow")
+
+	m.provisionEnvironment(env)
+
+	if env.Status != StatusFailed || env.Steps[0].Status != StepFailed {
+		t.Fatalf("status = %s, step = %+v, want failed", env.Status, env.Steps[0])
+	}
+	if env.Error != "Failed to become ready: context deadline exceeded" {
+		t.Errorf("error = %q", env.Error)
+	}
+}
+
+func TestRetryStepResumesFromFailedStep(t *testing.T) {
+	databaseRuns, broken := 0, true
+	m := newTestManager(
+		provisionStep{name: StepDatabase, timeout: time.Second, maxAttempts: 1, run: func(ctx context.Context, env *Environment) error {
+			databaseRuns++
+			return nil
+		}},
+		provisionStep{name: StepResources, description: "create resources", timeout: time.Second, maxAttempts: 2, run: func(ctx context.Context, env *Environment) error {
+			if broken {
+				return errors.New("quota exceeded")
+			}
+			return nil
+		}},
+	)
+	env := addEnvironment(m, "resume")
+
+	m.provisionEnvironment(env)
+	if env.Status != StatusFailed || env.Steps[1].Attempts != 2 {
+		t.Fatalf("status = %s, resources step = %+v, want failed after 2 attempts", env.Status, env.Steps[1])
+	}
+
+	if err := m.RetryStep("resume", "unknown"); err == nil {
+		t.Error("expected an error retrying an unknown step")
+	}
+
+	m.mu.Lock()
+	broken = false
+	m.mu.Unlock()
+	if err := m.Resume("resume"); err != nil {
+		t.Fatalf("Resume: %v", err)
+	}
+	waitForStatus(t, m, "resume", StatusReady)
+
+	if databaseRuns != 1 {
+		t.Errorf("database step ran %d times, want 1", databaseRuns)
+	}
+	if env.Error != "" || env.Steps[1].Status != StepSucceeded || env.Steps[1].Attempts != 3 {
+		t.Errorf("error = %q, resources step = %+v", env.Error, env.Steps[1])
+	}
+
+	if err := m.RetryStep("resume", StepDatabase); err == nil {
+		t.Error("expected an error retrying a ready environment")
+	}
+}
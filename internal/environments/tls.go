@@ -0,0 +1,253 @@
+package environments
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/testkube/dashboard/internal/kube"
+)
+
+// TLSMode is how an environment's ingress gets its certificate
+type TLSMode string
+
+const (
+	// TLSWildcard relies on a wildcard certificate for the base URL, which
+	// only covers hosts one level below it
+	TLSWildcard TLSMode = "wildcard"
+	// TLSCertManager creates a cert-manager Certificate per environment
+	TLSCertManager TLSMode = "cert-manager"
+	// TLSACM attaches an ACM certificate to the ALB through an annotation
+	TLSACM TLSMode = "acm"
+)
+
+var (
+	// ErrInvalidHostname is returned for subdomains that can't be used
+	ErrInvalidHostname = errors.New("invalid subdomain")
+	// ErrHostnameInUse is returned when another environment has the hostname
+	ErrHostnameInUse = errors.New("hostname already in use")
+)
+
+// defaultCertificatePollInterval is how often a pending certificate is checked
+const defaultCertificatePollInterval = 5 * time.Second
+
+var dnsLabel = regexp.MustCompile(`^[a-z0-9]([a-z0-9-]{0,61}[a-z0-9])?$`)
+
+// TLSStatus is the state of an environment's certificate
+type TLSStatus struct {
+	Mode       TLSMode    `json:"mode"`
+	SecretName string     `json:"secretName,omitempty"` // cert-manager only
+	Ready      bool       `json:"ready"`
+	Message    string     `json:"message,omitempty"`
+	NotAfter   *time.Time `json:"notAfter,omitempty"`
+	CheckedAt  *time.Time `json:"checkedAt,omitempty"`
+}
+
+// tlsConfig configures how environment ingresses are exposed
+type tlsConfig struct {
+	mode         TLSMode
+	ingressClass string
+	issuer       string
+	issuerKind   string
+	acmCertARN   string
+}
+
+// tlsConfigFromEnv reads ENVIRONMENTS_TLS ("wildcard", "cert-manager" or
+// "acm"), ENVIRONMENTS_INGRESS_CLASS, ENVIRONMENTS_CERT_ISSUER,
+// ENVIRONMENTS_CERT_ISSUER_KIND and ENVIRONMENTS_ACM_CERT_ARN
+func tlsConfigFromEnv() tlsConfig {
+	cfg := tlsConfig{
+		mode:         TLSMode(getEnvOrDefault("ENVIRONMENTS_TLS", string(TLSWildcard))),
+		ingressClass: getEnvOrDefault("ENVIRONMENTS_INGRESS_CLASS", "alb"),
+		issuer:       getEnvOrDefault("ENVIRONMENTS_CERT_ISSUER", "letsencrypt"),
+		issuerKind:   getEnvOrDefault("ENVIRONMENTS_CERT_ISSUER_KIND", "ClusterIssuer"),
+		acmCertARN:   os.Getenv("ENVIRONMENTS_ACM_CERT_ARN"),
+	}
+
+	switch cfg.mode {
+	case TLSWildcard, TLSCertManager:
+	case TLSACM:
+		if cfg.acmCertARN == "" {
+			log.Printf("Warning: ENVIRONMENTS_TLS=acm requires ENVIRONMENTS_ACM_CERT_ARN, using the wildcard certificate")
+			cfg.mode = TLSWildcard
+		}
+	default:
+		log.Printf("Warning: unknown ENVIRONMENTS_TLS %q, using the wildcard certificate", cfg.mode)
+		cfg.mode = TLSWildcard
+	}
+	return cfg
+}
+
+// CertificateGetter reads cert-manager Certificates, see kube.Client
+type CertificateGetter interface {
+	GetCertificate(ctx context.Context, namespace, name string) (*kube.Certificate, error)
+}
+
+// SetCertificateGetter lets the manager report cert-manager certificate
+// status. Without one, certificates are requested but not waited for.
+func (m *Manager) SetCertificateGetter(certs CertificateGetter) {
+	m.certificates = certs
+}
+
+// hostname returns the host an environment is served on: the custom
+// subdomain, or the environment name, under the base URL
+func (m *Manager) hostname(name, subdomain string) (string, error) {
+	if subdomain == "" {
+		return name + "." + m.baseURL, nil
+	}
+
+	subdomain = strings.ToLower(strings.TrimSuffix(subdomain, "."))
+	labels := strings.Split(subdomain, ".")
+	for _, label := range labels {
+		if !dnsLabel.MatchString(label) {
+			return "", fmt.Errorf("%w: %q is not a valid DNS label", ErrInvalidHostname, label)
+		}
+	}
+	if len(labels) > 1 && m.tls.mode == TLSWildcard {
+		return "", fmt.Errorf("%w: the wildcard certificate for %s only covers one level, so %q needs a per-environment certificate",
+			ErrInvalidHostname, m.baseURL, subdomain)
+	}
+	return subdomain + "." + m.baseURL, nil
+}
+
+// certificateName is the name of an environment's Certificate and the
+// secret it is issued into
+func certificateName(env *Environment) string {
+	return env.Name + "-tls"
+}
+
+// initialTLSStatus describes the certificate a new environment will use
+func (m *Manager) initialTLSStatus(env *Environment) *TLSStatus {
+	switch m.tls.mode {
+	case TLSCertManager:
+		return &TLSStatus{
+			Mode:       TLSCertManager,
+			SecretName: certificateName(env),
+			Message:    fmt.Sprintf("Requesting certificate from %s %s", m.tls.issuerKind, m.tls.issuer),
+		}
+	case TLSACM:
+		return &TLSStatus{Mode: TLSACM, Ready: true, Message: "ACM certificate attached to the load balancer"}
+	}
+	return &TLSStatus{Mode: TLSWildcard, Ready: true, Message: fmt.Sprintf("Covered by the *.%s certificate", m.baseURL)}
+}
+
+func usesCertManager(env *Environment) bool {
+	return env.TLS != nil && env.TLS.Mode == TLSCertManager
+}
+
+// waitForCertificate waits for cert-manager to issue the environment's
+// certificate, recording its status as it goes
+func (m *Manager) waitForCertificate(ctx context.Context, env *Environment) error {
+	if m.certificates == nil {
+		m.setTLSMessage(env, "Certificate requested; status unavailable without Kubernetes API access")
+		return nil
+	}
+
+	for {
+		cert, err := m.certificates.GetCertificate(ctx, env.Namespace, env.TLS.SecretName)
+		now := time.Now()
+
+		m.mu.Lock()
+		env.TLS.CheckedAt = &now
+		if err != nil {
+			env.TLS.Message = fmt.Sprintf("Failed to check certificate: %v", err)
+		} else {
+			env.TLS.Ready, env.TLS.Message = cert.Ready()
+			env.TLS.NotAfter = cert.Status.NotAfter
+		}
+		ready, message := env.TLS.Ready, env.TLS.Message
+		m.mu.Unlock()
+
+		if ready {
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("certificate not issued: %s", message)
+		case <-time.After(m.certificatePollInterval):
+		}
+	}
+}
+
+func (m *Manager) setTLSMessage(env *Environment, message string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	env.TLS.Message = message
+}
+
+// generateIngress returns the Ingress manifest, plus a cert-manager
+// Certificate when the environment uses one
+func (m *Manager) generateIngress(env *Environment) string {
+	var b strings.Builder
+
+	if usesCertManager(env) {
+		fmt.Fprintf(&b, `---
+apiVersion: cert-manager.io/v1
+kind: Certificate
+metadata:
+  name: %s
+  namespace: %s
+  labels:
+    env-id: %s
+spec:
+  secretName: %s
+  dnsNames:
+    - %s
+  issuerRef:
+    name: %s
+    kind: %s
+`, certificateName(env), env.Namespace, env.ID, env.TLS.SecretName, env.Hostname, m.tls.issuer, m.tls.issuerKind)
+	}
+
+	fmt.Fprintf(&b, `---
+apiVersion: networking.k8s.io/v1
+kind: Ingress
+metadata:
+  name: %s-ingress
+  namespace: %s
+  labels:
+    env-id: %s
+  annotations:
+    kubernetes.io/ingress.class: %s
+`, env.Name, env.Namespace, env.ID, m.tls.ingressClass)
+
+	if m.tls.ingressClass == "alb" {
+		b.WriteString(`    alb.ingress.kubernetes.io/scheme: internet-facing
+    alb.ingress.kubernetes.io/group.name: texecom-platform
+    alb.ingress.kubernetes.io/listen-ports: '[{"HTTPS":443}]'
+    alb.ingress.kubernetes.io/ssl-redirect: "443"
+`)
+	}
+	if env.TLS != nil && env.TLS.Mode == TLSACM {
+		fmt.Fprintf(&b, "    alb.ingress.kubernetes.io/certificate-arn: %s\n", m.tls.acmCertARN)
+	}
+
+	b.WriteString("spec:\n")
+	if usesCertManager(env) {
+		fmt.Fprintf(&b, `  tls:
+    - hosts:
+        - %s
+      secretName: %s
+`, env.Hostname, env.TLS.SecretName)
+	}
+	fmt.Fprintf(&b, `  rules:
+    - host: %s
+      http:
+        paths:
+          - path: /
+            pathType: Prefix
+            backend:
+              service:
+                name: %s-fern
+                port:
+                  number: 8080
+`, env.Hostname, env.Name)
+
+	return b.String()
+}
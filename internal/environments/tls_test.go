@@ -0,0 +1,135 @@
+package environments
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/testkube/dashboard/internal/kube"
+)
+
+type fakeCertificates struct {
+	ready bool
+}
+
+func (f *fakeCertificates) GetCertificate(ctx context.Context, namespace, name string) (*kube.Certificate, error) {
+	cert := &kube.Certificate{}
+	if f.ready {
+		cert.Status.Conditions = []kube.CertificateCondition{{Type: "Ready", Status: "True", Message: "Certificate is up to date"}}
+	}
+	return cert, nil
+}
+
+func newTLSManager(mode TLSMode) *Manager {
+	m := newTestManager()
+	m.baseURL = "envs.example.com"
+	m.namespace = "envs"
+	m.tls = tlsConfig{mode: mode, ingressClass: "alb", issuer: "letsencrypt", issuerKind: "ClusterIssuer", acmCertARN: "arn:aws:acm:eu-west-2:123:certificate/abc"}
+	m.certificatePollInterval = time.Millisecond
+	return m
+}
+
+func TestHostname(t *testing.T) {
+	wildcard := newTLSManager(TLSWildcard)
+	certManager := newTLSManager(TLSCertManager)
+
+	tests := []struct {
+		m         *Manager
+		subdomain string
+		want      string
+		wantErr   bool
+	}{
+		{wildcard, "", "my-env.envs.example.com", false},
+		{wildcard, "Demo", "demo.envs.example.com", false},
+		{wildcard, "demo.team", "", true},
+		{certManager, "demo.team", "demo.team.envs.example.com", false},
+		{certManager, "bad_label", "", true},
+		{certManager, "-demo", "", true},
+	}
+	for _, tt := range tests {
+		got, err := tt.m.hostname("my-env", tt.subdomain)
+		if tt.wantErr {
+			if !errors.Is(err, ErrInvalidHostname) {
+				t.Errorf("hostname(%q) in %s mode: err = %v, want ErrInvalidHostname", tt.subdomain, tt.m.tls.mode, err)
+			}
+			continue
+		}
+		if err != nil || got != tt.want {
+			t.Errorf("hostname(%q) = %q, %v, want %q", tt.subdomain, got, err, tt.want)
+		}
+	}
+}
+
+func TestCreateRejectsHostnameInUse(t *testing.T) {
+	m := newTLSManager(TLSWildcard)
+
+	env, err := m.Create(context.Background(), CreateEnvironmentRequest{Name: "first", Subdomain: "demo"})
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	if env.URL != "https://demo.envs.example.com" || env.TLS.Mode != TLSWildcard || !env.TLS.Ready {
+		t.Errorf("URL = %s, TLS = %+v", env.URL, env.TLS)
+	}
+
+	if _, err := m.Create(context.Background(), CreateEnvironmentRequest{Name: "second", Subdomain: "demo"}); !errors.Is(err, ErrHostnameInUse) {
+		t.Errorf("err = %v, want ErrHostnameInUse", err)
+	}
+}
+
+func TestGenerateIngress(t *testing.T) {
+	m := newTLSManager(TLSCertManager)
+	env := &Environment{ID: "abc", Name: "demo", Namespace: "envs", Hostname: "demo.team.envs.example.com"}
+	env.TLS = m.initialTLSStatus(env)
+
+	manifest := m.generateIngress(env)
+	for _, want := range []string{
+		"kind: Certificate",
+		"secretName: demo-tls",
+		"  dnsNames:\n    - demo.team.envs.example.com",
+		"    name: letsencrypt\n    kind: ClusterIssuer",
+		"  tls:\n    - hosts:\n        - demo.team.envs.example.com",
+		"    - host: demo.team.envs.example.com",
+	} {
+		if !strings.Contains(manifest, want) {
+			t.Errorf("cert-manager manifest missing %q:\n%s", want, manifest)
+		}
+	}
+
+	m = newTLSManager(TLSACM)
+	env.TLS = m.initialTLSStatus(env)
+	manifest = m.generateIngress(env)
+	if strings.Contains(manifest, "kind: Certificate") || strings.Contains(manifest, "  tls:") {
+		t.Errorf("ACM manifest should not request a certificate:\n%s", manifest)
+	}
+	if !strings.Contains(manifest, "alb.ingress.kubernetes.io/certificate-arn: arn:aws:acm:eu-west-2:123:certificate/abc") {
+		t.Errorf("ACM manifest missing certificate ARN:\n%s", manifest)
+	}
+}
+
+func TestWaitForCertificate(t *testing.T) {
+	m := newTLSManager(TLSCertManager)
+	certs := &fakeCertificates{}
+	m.SetCertificateGetter(certs)
+
+	env := &Environment{ID: "abc", Name: "demo", Namespace: "envs"}
+	env.TLS = m.initialTLSStatus(env)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if err := m.waitForCertificate(ctx, env); err == nil {
+		t.Fatal("expected an error while the certificate is pending")
+	}
+	if env.TLS.Ready || env.TLS.CheckedAt == nil {
+		t.Errorf("TLS = %+v, want checked and not ready", env.TLS)
+	}
+
+	certs.ready = true
+	if err := m.waitForCertificate(context.Background(), env); err != nil {
+		t.Fatalf("waitForCertificate: %v", err)
+	}
+	if !env.TLS.Ready || env.TLS.Message != "Certificate is up to date" {
+		t.Errorf("TLS = %+v, want ready", env.TLS)
+	}
+}
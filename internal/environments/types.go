@@ -17,6 +17,7 @@ const (
 	StatusPending  EnvironmentStatus = "pending"
 	StatusCreating EnvironmentStatus = "creating"
 	StatusReady    EnvironmentStatus = "ready"
+	StatusUpgrading EnvironmentStatus = "upgrading" // chart values being applied
 	StatusExpired  EnvironmentStatus = "expired"
 	StatusDeleting EnvironmentStatus = "deleting"
 	StatusDeleted  EnvironmentStatus = "deleted"
@@ -27,13 +28,18 @@ type Environment struct {
 	ID          string            `json:"id"`
 	Name        string            `json:"name"`
 	Owner       string            `json:"owner"`       // email or username
+	// Team shares the environment: its members may extend, change and
+	// delete it like the owner
+	Team        string            `json:"team,omitempty"`
 	Type        EnvironmentType   `json:"type"`
+	Template    string            `json:"template,omitempty"`
 	Status      EnvironmentStatus `json:"status"`
 
 	// Timestamps
 	CreatedAt   time.Time         `json:"createdAt"`
 	ExpiresAt   time.Time         `json:"expiresAt,omitempty"`
 	DeletedAt   *time.Time        `json:"deletedAt,omitempty"`
+	ReadyAt     *time.Time        `json:"readyAt,omitempty"` // when provisioning finished
 
 	// Resource info
 	Namespace   string            `json:"namespace"`
@@ -42,27 +48,52 @@ type Environment struct {
 	MQTTPrefix  string            `json:"mqttPrefix,omitempty"`
 
 	// Access info
+	Hostname    string            `json:"hostname"`
 	URL         string            `json:"url"`
 	InternalURL string            `json:"internalUrl"`
+	TLS         *TLSStatus        `json:"tls,omitempty"`
 
 	// Branch/commit being tested
 	Branch      string            `json:"branch,omitempty"`
 	Commit      string            `json:"commit,omitempty"`
+	// Pull request the environment previews, for environments created from
+	// its webhooks
+	PullRequest string            `json:"pullRequest,omitempty"`
 
 	// Error info if failed
 	Error       string            `json:"error,omitempty"`
+
+	// Workflow run against the environment before it's marked ready, and
+	// its latest execution
+	SmokeWorkflow  string         `json:"smokeWorkflow,omitempty"`
+	SmokeExecution string         `json:"smokeExecution,omitempty"`
+
+	// Provisioning progress, in the order the steps run
+	Steps       []Step            `json:"steps,omitempty"`
+
+	// Chart value overrides for Helm environments, by dot-separated path
+	ValueOverrides map[string]interface{} `json:"valueOverrides,omitempty"`
+	ValueHistory   []ValueChange          `json:"valueHistory,omitempty"`
 }
 
 type CreateEnvironmentRequest struct {
 	Name   string          `json:"name"`
 	Owner  string          `json:"owner"`
+	Team   string          `json:"team,omitempty"`
 	Type   EnvironmentType `json:"type"`
 	Branch string          `json:"branch,omitempty"`
+	Commit string          `json:"commit,omitempty"`
+	PullRequest string     `json:"pullRequest,omitempty"` // URL of the pull request it previews
 	TTLHours int           `json:"ttlHours,omitempty"` // Override default TTL
+	Subdomain string       `json:"subdomain,omitempty"` // Host under the base URL, defaults to the name
+	Template string        `json:"template,omitempty"`  // Defaults to the built-in manifests
+	SmokeWorkflow string   `json:"smokeWorkflow,omitempty"` // Overrides the template's smoke test workflow
 }
 
 type ListEnvironmentsOptions struct {
 	Owner  string
+	Team   string
+	PullRequest string
 	Status EnvironmentStatus
 	Type   EnvironmentType
 }
@@ -0,0 +1,308 @@
+package environments
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Editable value types
+const (
+	ValueInt    = "int"
+	ValueBool   = "bool"
+	ValueString = "string"
+)
+
+var (
+	// ErrNotHelm is returned when editing values of an environment that
+	// isn't installed from a Helm chart
+	ErrNotHelm = errors.New("environment is not provisioned with Helm")
+	// ErrValueNotEditable is returned for chart values outside the template's
+	// whitelist, or of the wrong type
+	ErrValueNotEditable = errors.New("chart value can't be overridden")
+	// ErrNotReady is returned when changing values of an environment that is
+	// still provisioning or already being upgraded
+	ErrNotReady = errors.New("environment is not ready")
+)
+
+// EditableValue whitelists a chart value users may override per
+// environment. A path ending in ".*" allows any key under it, e.g. feature
+// flags.
+type EditableValue struct {
+	Path        string `json:"path"` // dot-separated, e.g. "image.tag"
+	Type        string `json:"type"` // int, bool or string
+	Description string `json:"description,omitempty"`
+}
+
+// defaultEditableValues apply to charts whose template doesn't list its own
+var defaultEditableValues = []EditableValue{
+	{Path: "replicaCount", Type: ValueInt, Description: "Number of replicas"},
+	{Path: "image.tag", Type: ValueString, Description: "Image tag to deploy"},
+	{Path: "featureFlags.*", Type: ValueBool, Description: "Feature flags"},
+}
+
+func (e EditableValue) matches(path string) bool {
+	if prefix, ok := strings.CutSuffix(e.Path, ".*"); ok {
+		key, found := strings.CutPrefix(path, prefix+".")
+		return found && key != "" && !strings.Contains(key, ".")
+	}
+	return e.Path == path
+}
+
+// convert checks a value against the editable type. Strings are parsed so
+// form input can be used as is.
+func (e EditableValue) convert(value interface{}) (interface{}, error) {
+	switch e.Type {
+	case ValueInt:
+		switch v := value.(type) {
+		case float64:
+			if v == float64(int(v)) {
+				return int(v), nil
+			}
+		case int:
+			return v, nil
+		case string:
+			if n, err := strconv.Atoi(strings.TrimSpace(v)); err == nil {
+				return n, nil
+			}
+		}
+	case ValueBool:
+		switch v := value.(type) {
+		case bool:
+			return v, nil
+		case string:
+			if b, err := strconv.ParseBool(strings.TrimSpace(v)); err == nil {
+				return b, nil
+			}
+		}
+	case ValueString:
+		if v, ok := value.(string); ok {
+			return v, nil
+		}
+	}
+	return nil, fmt.Errorf("%w: %s must be a %s", ErrValueNotEditable, e.Path, e.Type)
+}
+
+// ValueChange records one override being set or removed
+type ValueChange struct {
+	Path string      `json:"path"`
+	From interface{} `json:"from"`
+	To   interface{} `json:"to"` // nil when reset to the chart default
+	By   string      `json:"by,omitempty"`
+	At   time.Time   `json:"at"`
+}
+
+// ChartValue is the state of one editable value in an environment
+type ChartValue struct {
+	EditableValue
+	Key        string      `json:"key"` // the concrete path
+	Default    interface{} `json:"default"`
+	Value      interface{} `json:"value"`
+	Overridden bool        `json:"overridden"`
+}
+
+// ChartValues is the editable values of a Helm environment and their history
+type ChartValues struct {
+	Editable []EditableValue `json:"editable"`
+	Values   []ChartValue    `json:"values"`
+	History  []ValueChange   `json:"history"`
+}
+
+func (m *Manager) helmTemplate(env *Environment) (*HelmConfig, error) {
+	t, ok := m.template(env.Template)
+	if !ok || t.Provisioner != ProvisionerHelm {
+		return nil, ErrNotHelm
+	}
+	return t.Helm, nil
+}
+
+func (h *HelmConfig) editable() []EditableValue {
+	if len(h.Editable) > 0 {
+		return h.Editable
+	}
+	return defaultEditableValues
+}
+
+func (h *HelmConfig) editableFor(path string) (EditableValue, bool) {
+	for _, e := range h.editable() {
+		if e.matches(path) {
+			return e, true
+		}
+	}
+	return EditableValue{}, false
+}
+
+// Values returns the environment's editable chart values, with the
+// template defaults and any overrides
+func (m *Manager) Values(id string) (*ChartValues, error) {
+	env, err := m.Get(id)
+	if err != nil {
+		return nil, err
+	}
+	helm, err := m.helmTemplate(env)
+	if err != nil {
+		return nil, err
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	defaults, _ := expandValues(helm.Values, env).(map[string]interface{})
+	result := &ChartValues{Editable: helm.editable(), History: append([]ValueChange{}, env.ValueHistory...)}
+	for _, e := range helm.editable() {
+		keys := []string{e.Path}
+		if prefix, ok := strings.CutSuffix(e.Path, ".*"); ok {
+			keys = mapKeys(prefix, getPath(defaults, prefix), env.ValueOverrides)
+		}
+		for _, key := range keys {
+			value := ChartValue{EditableValue: e, Key: key, Default: getPath(defaults, key)}
+			value.Value, value.Overridden = env.ValueOverrides[key]
+			if !value.Overridden {
+				value.Value = value.Default
+			}
+			result.Values = append(result.Values, value)
+		}
+	}
+	return result, nil
+}
+
+// mapKeys lists the keys under prefix, from the defaults and overrides
+func mapKeys(prefix string, defaults interface{}, overrides map[string]interface{}) []string {
+	seen := make(map[string]bool)
+	if m, ok := defaults.(map[string]interface{}); ok {
+		for key := range m {
+			seen[prefix+"."+key] = true
+		}
+	}
+	for key := range overrides {
+		if strings.HasPrefix(key, prefix+".") {
+			seen[key] = true
+		}
+	}
+
+	keys := make([]string, 0, len(seen))
+	for key := range seen {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// SetValues overrides chart values of a ready Helm environment and upgrades
+// its release in place. A nil value removes the override. When the upgrade
+// fails the previous overrides are restored. The upgrade runs to completion
+// or resourcesTimeout even if the caller goes away, so the environment
+// isn't left upgrading.
+func (m *Manager) SetValues(id string, values map[string]interface{}, user string) ([]ValueChange, error) {
+	env, err := m.Get(id)
+	if err != nil {
+		return nil, err
+	}
+	helm, err := m.helmTemplate(env)
+	if err != nil {
+		return nil, err
+	}
+
+	converted := make(map[string]interface{}, len(values))
+	for path, value := range values {
+		e, ok := helm.editableFor(path)
+		if !ok {
+			return nil, fmt.Errorf("%w: %s is not in the template's editable values", ErrValueNotEditable, path)
+		}
+		if value == nil {
+			converted[path] = nil
+			continue
+		}
+		if converted[path], err = e.convert(value); err != nil {
+			return nil, err
+		}
+	}
+
+	m.mu.Lock()
+	if env.Status != StatusReady {
+		m.mu.Unlock()
+		return nil, fmt.Errorf("%w: %s is %s", ErrNotReady, env.Name, env.Status)
+	}
+	previous := make(map[string]interface{}, len(env.ValueOverrides))
+	for k, v := range env.ValueOverrides {
+		previous[k] = v
+	}
+
+	now := time.Now()
+	var changes []ValueChange
+	paths := make([]string, 0, len(converted))
+	for path := range converted {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	for _, path := range paths {
+		from, to := previous[path], converted[path]
+		if reflect.DeepEqual(from, to) {
+			continue
+		}
+		if env.ValueOverrides == nil {
+			env.ValueOverrides = make(map[string]interface{})
+		}
+		if to == nil {
+			delete(env.ValueOverrides, path)
+		} else {
+			env.ValueOverrides[path] = to
+		}
+		changes = append(changes, ValueChange{Path: path, From: from, To: to, By: user, At: now})
+	}
+	if len(changes) == 0 {
+		m.mu.Unlock()
+		return nil, nil
+	}
+	env.Status = StatusUpgrading
+	m.mu.Unlock()
+
+	ctx, cancel := context.WithTimeout(context.Background(), resourcesTimeout)
+	upgradeErr := m.createResources(ctx, env)
+	cancel()
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	env.Status = StatusReady
+	if upgradeErr != nil {
+		env.ValueOverrides = previous
+		return nil, fmt.Errorf("failed to upgrade release: %w", upgradeErr)
+	}
+	env.ValueHistory = append(env.ValueHistory, changes...)
+	log.Printf("Upgraded environment %s with %d changed values", env.Name, len(changes))
+	return changes, nil
+}
+
+// getPath reads a dot-separated path from nested maps
+func getPath(values map[string]interface{}, path string) interface{} {
+	var current interface{} = values
+	for _, key := range strings.Split(path, ".") {
+		m, ok := current.(map[string]interface{})
+		if !ok {
+			return nil
+		}
+		current = m[key]
+	}
+	return current
+}
+
+// setPath writes a dot-separated path, creating maps along the way
+func setPath(values map[string]interface{}, path string, value interface{}) {
+	keys := strings.Split(path, ".")
+	current := values
+	for _, key := range keys[:len(keys)-1] {
+		next, ok := current[key].(map[string]interface{})
+		if !ok {
+			next = make(map[string]interface{})
+			current[key] = next
+		}
+		current = next
+	}
+	current[keys[len(keys)-1]] = value
+}
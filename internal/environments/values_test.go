@@ -0,0 +1,128 @@
+package environments
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"testing"
+)
+
+const valuesTemplates = `{"templates": [{"name": "fern-chart", "provisioner": "helm", "helm": {
+	"chart": "fern",
+	"values": {"replicaCount": 1, "image": {"tag": "latest"}, "featureFlags": {"newCheckout": false}, "secret": "x"}
+}}]}`
+
+func newHelmEnvironment(t *testing.T) (*Manager, *Environment, *[]recordedCommand) {
+	t.Helper()
+	m := newTestManager()
+	if err := m.Import(json.RawMessage(valuesTemplates)); err != nil {
+		t.Fatalf("Import: %v", err)
+	}
+	commands := recordCommands(m)
+	env := addEnvironment(m, "chart")
+	env.Template = "fern-chart"
+	env.Namespace = "envs"
+	env.Status = StatusReady
+	t.Cleanup(func() { os.Remove(helmValuesFile(env)) })
+	return m, env, commands
+}
+
+func TestValues(t *testing.T) {
+	m, _, _ := newHelmEnvironment(t)
+
+	values, err := m.Values("chart")
+	if err != nil {
+		t.Fatalf("Values: %v", err)
+	}
+	got := make(map[string]interface{})
+	for _, v := range values.Values {
+		got[v.Key] = v.Value
+	}
+	if len(got) != 3 || got["replicaCount"] != float64(1) || got["image.tag"] != "latest" || got["featureFlags.newCheckout"] != false {
+		t.Errorf("values = %v", got)
+	}
+
+	plain := addEnvironment(m, "plain")
+	plain.Template = DefaultTemplate
+	if _, err := m.Values("plain"); !errors.Is(err, ErrNotHelm) {
+		t.Errorf("err = %v, want ErrNotHelm", err)
+	}
+}
+
+func TestSetValuesUpgradesRelease(t *testing.T) {
+	m, env, commands := newHelmEnvironment(t)
+
+	for _, bad := range []map[string]interface{}{
+		{"secret": "y"},
+		{"replicaCount": "many"},
+		{"featureFlags.nested.flag": true},
+	} {
+		if _, err := m.SetValues("chart", bad, "alice"); !errors.Is(err, ErrValueNotEditable) {
+			t.Errorf("SetValues(%v) err = %v, want ErrValueNotEditable", bad, err)
+		}
+	}
+	if len(*commands) != 0 {
+		t.Fatalf("rejected changes should not upgrade, ran %v", *commands)
+	}
+
+	changes, err := m.SetValues("chart", map[string]interface{}{
+		"replicaCount":        "3",
+		"featureFlags.darkUI": true,
+		"image.tag":           "v1.4.2",
+	}, "alice")
+	if err != nil {
+		t.Fatalf("SetValues: %v", err)
+	}
+	if len(changes) != 3 || changes[0].Path != "featureFlags.darkUI" || changes[0].By != "alice" {
+		t.Errorf("changes = %+v", changes)
+	}
+	if len(*commands) != 1 {
+		t.Fatalf("commands = %v, want one helm upgrade", *commands)
+	}
+
+	data, err := os.ReadFile(helmValuesFile(env))
+	if err != nil {
+		t.Fatalf("values file: %v", err)
+	}
+	var rendered struct {
+		ReplicaCount int
+		Image        struct{ Tag string }
+		FeatureFlags map[string]bool
+	}
+	json.Unmarshal(data, &rendered)
+	if rendered.ReplicaCount != 3 || rendered.Image.Tag != "v1.4.2" || !rendered.FeatureFlags["darkUI"] || rendered.FeatureFlags["newCheckout"] {
+		t.Errorf("rendered values = %s", data)
+	}
+
+	// Resetting to the default is a change; repeating a value is not
+	changes, err = m.SetValues("chart", map[string]interface{}{"replicaCount": nil, "image.tag": "v1.4.2"}, "bob")
+	if err != nil {
+		t.Fatalf("SetValues: %v", err)
+	}
+	if len(changes) != 1 || changes[0].From != 3 || changes[0].To != nil {
+		t.Errorf("changes = %+v", changes)
+	}
+
+	values, _ := m.Values("chart")
+	if len(values.History) != 4 {
+		t.Errorf("history has %d changes, want 4", len(values.History))
+	}
+	if env.Status != StatusReady {
+		t.Errorf("status = %s, want ready after the upgrade", env.Status)
+	}
+}
+
+func TestSetValuesRestoresOverridesOnFailure(t *testing.T) {
+	m, env, _ := newHelmEnvironment(t)
+	m.run = func(ctx context.Context, env []string, name string, args ...string) ([]byte, error) {
+		return nil, errors.New("UPGRADE FAILED: timed out waiting for the condition")
+	}
+
+	if _, err := m.SetValues("chart", map[string]interface{}{"replicaCount": 2}, "alice"); err == nil {
+		t.Fatal("expected the failed upgrade to be reported")
+	}
+	if len(env.ValueOverrides) != 0 || len(env.ValueHistory) != 0 || env.Status != StatusReady {
+		t.Errorf("overrides = %v, history = %v, status = %s", env.ValueOverrides, env.ValueHistory, env.Status)
+	}
+}
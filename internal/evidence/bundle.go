@@ -0,0 +1,172 @@
+// Package evidence packages execution results into signed bundles, so
+// auditors can check test evidence wasn't modified after the fact.
+package evidence
+
+import (
+	"archive/zip"
+	"bytes"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/testkube/dashboard/internal/database"
+)
+
+// ManifestFile is the name of the bundle's index of files and checksums
+const ManifestFile = "manifest.json"
+
+var (
+	// ErrUnknownBundle is returned when verifying a bundle the dashboard
+	// never signed, including any that were modified afterwards
+	ErrUnknownBundle = errors.New("no signature recorded for this bundle")
+	// ErrBadSignature is returned when a recorded signature doesn't match
+	// the bundle under the current key
+	ErrBadSignature = errors.New("bundle signature is invalid")
+)
+
+// File is one file in an evidence bundle
+type File struct {
+	Name string
+	Data []byte
+}
+
+// ManifestEntry lists a file of the bundle in its manifest
+type ManifestEntry struct {
+	Name   string `json:"name"`
+	Size   int    `json:"size"`
+	SHA256 string `json:"sha256"`
+}
+
+// Bundle zips files with a manifest of their checksums. Entries are sorted
+// and timestamped with modified, so the same evidence always produces the
+// same bytes and digest.
+func Bundle(files []File, modified time.Time) ([]byte, error) {
+	files = append([]File(nil), files...)
+	sort.Slice(files, func(i, j int) bool { return files[i].Name < files[j].Name })
+
+	manifest := make([]ManifestEntry, len(files))
+	for i, f := range files {
+		if f.Name == ManifestFile {
+			return nil, fmt.Errorf("%s is reserved for the bundle manifest", ManifestFile)
+		}
+		sum := sha256.Sum256(f.Data)
+		manifest[i] = ManifestEntry{Name: f.Name, Size: len(f.Data), SHA256: hex.EncodeToString(sum[:])}
+	}
+	index, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode manifest: %w", err)
+	}
+
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for _, f := range append([]File{{Name: ManifestFile, Data: index}}, files...) {
+		w, err := zw.CreateHeader(&zip.FileHeader{Name: f.Name, Method: zip.Deflate, Modified: modified.UTC()})
+		if err != nil {
+			return nil, fmt.Errorf("failed to add %s: %w", f.Name, err)
+		}
+		if _, err := w.Write(f.Data); err != nil {
+			return nil, fmt.Errorf("failed to add %s: %w", f.Name, err)
+		}
+	}
+	if err := zw.Close(); err != nil {
+		return nil, fmt.Errorf("failed to write bundle: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// Digest returns the hex-encoded SHA-256 of a bundle, which is what gets signed
+func Digest(bundle []byte) string {
+	sum := sha256.Sum256(bundle)
+	return hex.EncodeToString(sum[:])
+}
+
+// Record is a signature issued for a bundle
+type Record struct {
+	ExecutionID string    `json:"executionId"`
+	Digest      string    `json:"digest"`
+	Algorithm   string    `json:"algorithm"`
+	KeyID       string    `json:"keyId"`
+	Signature   string    `json:"signature"` // base64
+	Files       []string  `json:"files"`
+	SignedAt    time.Time `json:"signedAt"`
+	SignedBy    string    `json:"signedBy,omitempty"`
+}
+
+// Store signs bundles and records the signatures issued in the database
+type Store struct {
+	signer Signer
+	db     database.Database
+}
+
+func NewStore(signer Signer, db database.Database) *Store {
+	return &Store{signer: signer, db: db}
+}
+
+// Signer returns the signer bundles are signed with
+func (s *Store) Signer() Signer {
+	return s.signer
+}
+
+// Sign signs a bundle and records the signature
+func (s *Store) Sign(executionID string, bundle []byte, files []string, user string) (Record, error) {
+	digest := Digest(bundle)
+	raw, _ := hex.DecodeString(digest)
+	record := Record{
+		ExecutionID: executionID,
+		Digest:      digest,
+		Algorithm:   s.signer.Algorithm(),
+		KeyID:       s.signer.KeyID(),
+		Signature:   base64.StdEncoding.EncodeToString(s.signer.Sign(raw)),
+		Files:       files,
+		SignedAt:    time.Now(),
+		SignedBy:    user,
+	}
+	if err := s.db.InsertEvidenceRecord(database.EvidenceRecord(record)); err != nil {
+		return record, fmt.Errorf("failed to record signature: %w", err)
+	}
+	return record, nil
+}
+
+// Records returns the signatures issued for an execution's bundles, oldest first
+func (s *Store) Records(executionID string) ([]Record, error) {
+	stored, err := s.db.GetEvidenceRecords(executionID)
+	if err != nil {
+		return nil, err
+	}
+	records := make([]Record, len(stored))
+	for i, r := range stored {
+		records[i] = Record(r)
+	}
+	return records, nil
+}
+
+// Len returns the number of signatures issued
+func (s *Store) Len() (int, error) {
+	stored, err := s.db.GetEvidenceRecords("")
+	return len(stored), err
+}
+
+// Verify finds the signature recorded for a bundle and checks it
+func (s *Store) Verify(bundle []byte) (*Record, error) {
+	digest := Digest(bundle)
+	stored, err := s.db.GetEvidenceRecord(digest)
+	if err != nil {
+		return nil, fmt.Errorf("failed to look up signature: %w", err)
+	}
+	if stored == nil {
+		return nil, ErrUnknownBundle
+	}
+	record := Record(*stored)
+
+	raw, _ := hex.DecodeString(digest)
+	signature, err := base64.StdEncoding.DecodeString(record.Signature)
+	if err != nil || record.KeyID != s.signer.KeyID() || !s.signer.Verify(raw, signature) {
+		return &record, ErrBadSignature
+	}
+	return &record, nil
+}
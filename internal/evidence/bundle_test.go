@@ -0,0 +1,92 @@
+package evidence
+
+import (
+	"archive/zip"
+	"bytes"
+	"crypto/ed25519"
+	"encoding/json"
+	"errors"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/testkube/dashboard/internal/database"
+)
+
+var testFiles = []File{
+	{Name: "results.json", Data: []byte(`[{"test": "login", "status": "passed"}]`)},
+	{Name: "findings/trivy.json", Data: []byte(`{"Results": []}`)},
+}
+
+func TestBundleIsDeterministic(t *testing.T) {
+	modified := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	first, err := Bundle(testFiles, modified)
+	if err != nil {
+		t.Fatalf("Bundle failed: %v", err)
+	}
+	second, _ := Bundle([]File{testFiles[1], testFiles[0]}, modified)
+	if Digest(first) != Digest(second) {
+		t.Error("the same files should produce the same bundle")
+	}
+
+	zr, err := zip.NewReader(bytes.NewReader(first), int64(len(first)))
+	if err != nil {
+		t.Fatalf("bundle is not a zip: %v", err)
+	}
+	if len(zr.File) != 3 || zr.File[0].Name != ManifestFile {
+		t.Fatalf("expected the manifest and 2 files, got %d files", len(zr.File))
+	}
+	rc, _ := zr.File[0].Open()
+	data, _ := io.ReadAll(rc)
+	var manifest []ManifestEntry
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		t.Fatalf("manifest: %v", err)
+	}
+	if len(manifest) != 2 || manifest[0].Name != "findings/trivy.json" || len(manifest[0].SHA256) != 64 {
+		t.Errorf("unexpected manifest: %+v", manifest)
+	}
+
+	if _, err := Bundle([]File{{Name: ManifestFile}}, modified); err == nil {
+		t.Error("a file named like the manifest should be rejected")
+	}
+}
+
+func TestStoreVerify(t *testing.T) {
+	key := ed25519.NewKeyFromSeed(bytes.Repeat([]byte{7}, ed25519.SeedSize))
+	for _, signer := range []Signer{NewHMACSigner([]byte("secret")), NewEd25519Signer(key)} {
+		store := NewStore(signer, database.NewMockDatabase())
+		bundle, _ := Bundle(testFiles, time.Now())
+		record, err := store.Sign("exec-1", bundle, []string{"results.json"}, "auditor")
+		if err != nil {
+			t.Fatalf("Sign failed: %v", err)
+		}
+
+		got, err := store.Verify(bundle)
+		if err != nil || got.Digest != record.Digest {
+			t.Errorf("%s: expected the bundle to verify, got %v", signer.Algorithm(), err)
+		}
+		if records, _ := store.Records("exec-1"); len(records) != 1 || records[0].SignedBy != "auditor" {
+			t.Errorf("%s: unexpected records %+v", signer.Algorithm(), records)
+		}
+
+		tampered := append([]byte(nil), bundle...)
+		tampered[len(tampered)-1] ^= 0xff
+		if _, err := store.Verify(tampered); !errors.Is(err, ErrUnknownBundle) {
+			t.Errorf("%s: expected a modified bundle to be unknown, got %v", signer.Algorithm(), err)
+		}
+	}
+
+	// Signatures made under another key don't verify
+	db := database.NewMockDatabase()
+	store := NewStore(NewHMACSigner([]byte("old")), db)
+	bundle, _ := Bundle(testFiles, time.Now())
+	store.Sign("exec-1", bundle, nil, "")
+	if _, err := NewStore(NewHMACSigner([]byte("new")), db).Verify(bundle); !errors.Is(err, ErrBadSignature) {
+		t.Errorf("expected ErrBadSignature after a key change, got %v", err)
+	}
+
+	// Signatures outlive the store, so bundles verify after a restart
+	if _, err := NewStore(NewHMACSigner([]byte("old")), db).Verify(bundle); err != nil {
+		t.Errorf("expected the bundle to verify with a new store, got %v", err)
+	}
+}
@@ -0,0 +1,108 @@
+package evidence
+
+import (
+	"crypto/ed25519"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"os"
+)
+
+// Signing algorithms
+const (
+	AlgorithmHMAC    = "hmac-sha256"
+	AlgorithmEd25519 = "ed25519"
+)
+
+// Signer signs bundle digests
+type Signer interface {
+	Algorithm() string
+	// KeyID identifies the key so signatures survive key rotation audits
+	KeyID() string
+	Sign(digest []byte) []byte
+	Verify(digest, signature []byte) bool
+}
+
+// HMACSigner signs with a shared secret. Anyone verifying needs the secret,
+// so bundles can only be checked through the dashboard.
+type HMACSigner struct {
+	key []byte
+}
+
+func NewHMACSigner(key []byte) *HMACSigner {
+	return &HMACSigner{key: key}
+}
+
+func (s *HMACSigner) Algorithm() string { return AlgorithmHMAC }
+
+func (s *HMACSigner) KeyID() string { return keyID(s.key) }
+
+func (s *HMACSigner) Sign(digest []byte) []byte {
+	mac := hmac.New(sha256.New, s.key)
+	mac.Write(digest)
+	return mac.Sum(nil)
+}
+
+func (s *HMACSigner) Verify(digest, signature []byte) bool {
+	return hmac.Equal(s.Sign(digest), signature)
+}
+
+// Ed25519Signer signs with a private key, like cosign's detached
+// signatures: auditors can verify bundles offline with the public key.
+type Ed25519Signer struct {
+	key ed25519.PrivateKey
+}
+
+func NewEd25519Signer(key ed25519.PrivateKey) *Ed25519Signer {
+	return &Ed25519Signer{key: key}
+}
+
+func (s *Ed25519Signer) Algorithm() string { return AlgorithmEd25519 }
+
+func (s *Ed25519Signer) KeyID() string { return keyID(s.PublicKey()) }
+
+// PublicKey returns the key auditors verify signatures with
+func (s *Ed25519Signer) PublicKey() ed25519.PublicKey {
+	return s.key.Public().(ed25519.PublicKey)
+}
+
+func (s *Ed25519Signer) Sign(digest []byte) []byte {
+	return ed25519.Sign(s.key, digest)
+}
+
+func (s *Ed25519Signer) Verify(digest, signature []byte) bool {
+	return ed25519.Verify(s.PublicKey(), digest, signature)
+}
+
+func keyID(key []byte) string {
+	sum := sha256.Sum256(key)
+	return hex.EncodeToString(sum[:8])
+}
+
+// NewSignerFromEnv signs with the base64 Ed25519 seed in
+// EVIDENCE_SIGNING_KEY, or the secret in EVIDENCE_HMAC_KEY. Without either,
+// a throwaway Ed25519 key is generated and bundles can't be verified after
+// a restart.
+func NewSignerFromEnv() (Signer, error) {
+	if val := os.Getenv("EVIDENCE_SIGNING_KEY"); val != "" {
+		seed, err := base64.StdEncoding.DecodeString(val)
+		if err != nil || len(seed) != ed25519.SeedSize {
+			return nil, fmt.Errorf("EVIDENCE_SIGNING_KEY must be a base64 %d-byte Ed25519 seed", ed25519.SeedSize)
+		}
+		return NewEd25519Signer(ed25519.NewKeyFromSeed(seed)), nil
+	}
+	if val := os.Getenv("EVIDENCE_HMAC_KEY"); val != "" {
+		return NewHMACSigner([]byte(val)), nil
+	}
+
+	_, key, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate signing key: %w", err)
+	}
+	log.Printf("Warning: EVIDENCE_SIGNING_KEY not set, evidence bundles are signed with a temporary key")
+	return NewEd25519Signer(key), nil
+}
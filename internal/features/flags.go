@@ -0,0 +1,352 @@
+// Package features decides which optional subsystems are switched on, so
+// they can be rolled out gradually and turned off quickly without a
+// redeploy.
+//
+// A flag's state is resolved per tenant, first match wins:
+//
+//  1. a database override for the tenant
+//  2. a database override for everyone
+//  3. the tenant's setting in the config
+//  4. the config default
+//  5. the flag's built-in default
+package features
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/testkube/dashboard/internal/database"
+)
+
+// Flags guarding the dashboard's optional subsystems
+const (
+	// Graphs renders pass rate and duration charts
+	Graphs = "graphs"
+	// LiveLogs streams logs of running executions to the browser
+	LiveLogs = "live-logs"
+	// Notifications sends failure alerts to the notification webhook
+	Notifications = "notifications"
+	// Evidence allows signed evidence bundles to be created
+	Evidence = "evidence"
+	// Redaction scrubs emails, tokens and IP addresses from what share
+	// links show
+	Redaction = "redaction"
+)
+
+// DefaultRefreshInterval is how often overrides are re-read from the
+// database, so changes made by another replica are picked up
+const DefaultRefreshInterval = 30 * time.Second
+
+// ErrUnknownFlag is returned for a flag that isn't defined
+var ErrUnknownFlag = errors.New("unknown feature flag")
+
+// Flag is a feature that can be switched on or off
+type Flag struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	Default     bool   `json:"default"`
+}
+
+// Flags lists every feature flag
+var Flags = []Flag{
+	{Name: Graphs, Description: "Pass rate and duration charts on the dashboard and workflow pages", Default: false},
+	{Name: LiveLogs, Description: "Live log streaming for running executions", Default: true},
+	{Name: Notifications, Description: "Failure alerts sent to the notification webhook", Default: true},
+	{Name: Evidence, Description: "Signed evidence bundles for executions", Default: true},
+	{Name: Redaction, Description: "Redaction of emails, tokens and IP addresses in share links", Default: true},
+}
+
+func lookup(name string) (Flag, bool) {
+	for _, f := range Flags {
+		if f.Name == name {
+			return f, true
+		}
+	}
+	return Flag{}, false
+}
+
+// Config sets flags from configuration. Tenants override Defaults.
+type Config struct {
+	Defaults map[string]bool            `json:"defaults,omitempty"`
+	Tenants  map[string]map[string]bool `json:"tenants,omitempty"`
+}
+
+func (c Config) validate() error {
+	check := func(flags map[string]bool) error {
+		for name := range flags {
+			if _, ok := lookup(name); !ok {
+				return fmt.Errorf("%w: %s", ErrUnknownFlag, name)
+			}
+		}
+		return nil
+	}
+	if err := check(c.Defaults); err != nil {
+		return err
+	}
+	for _, flags := range c.Tenants {
+		if err := check(flags); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// parseFlagList parses FEATURE_FLAGS, e.g. "graphs=true,live-logs=false".
+// A name on its own switches the flag on.
+func parseFlagList(s string) (map[string]bool, error) {
+	flags := make(map[string]bool)
+	for _, item := range strings.Split(s, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		name, value, found := strings.Cut(item, "=")
+		enabled := true
+		if found {
+			switch strings.ToLower(strings.TrimSpace(value)) {
+			case "true", "on", "1":
+			case "false", "off", "0":
+				enabled = false
+			default:
+				return nil, fmt.Errorf("invalid value for feature flag %s: %q", name, value)
+			}
+		}
+		flags[strings.TrimSpace(name)] = enabled
+	}
+	return flags, nil
+}
+
+// State is a flag's effective state for a tenant and where it comes from
+type State struct {
+	Flag
+	Enabled bool   `json:"enabled"`
+	Source  string `json:"source"` // override, config or default
+}
+
+// Store resolves flags from the config and the overrides in the database
+type Store struct {
+	db      database.Database
+	refresh time.Duration
+
+	config    Config
+	overrides []database.FeatureOverride
+	loaded    time.Time
+	mu        sync.RWMutex
+	now       func() time.Time
+}
+
+// NewStore creates a store reading defaults from FEATURE_FLAGS_FILE (JSON)
+// and FEATURE_FLAGS when set, and overrides from db. FEATURE_FLAGS wins over
+// the file's defaults.
+func NewStore(db database.Database) (*Store, error) {
+	s := newStore(db)
+
+	if file := os.Getenv("FEATURE_FLAGS_FILE"); file != "" {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return s, fmt.Errorf("failed to read feature flags file: %w", err)
+		}
+		if err := s.Import(data); err != nil {
+			return s, err
+		}
+	}
+
+	if list := os.Getenv("FEATURE_FLAGS"); list != "" {
+		flags, err := parseFlagList(list)
+		if err != nil {
+			return s, err
+		}
+		config := s.Config()
+		if config.Defaults == nil {
+			config.Defaults = make(map[string]bool)
+		}
+		for name, enabled := range flags {
+			config.Defaults[name] = enabled
+		}
+		if err := s.SetConfig(config); err != nil {
+			return s, err
+		}
+	}
+
+	return s, nil
+}
+
+func newStore(db database.Database) *Store {
+	refresh := DefaultRefreshInterval
+	if val := os.Getenv("FEATURE_FLAGS_REFRESH"); val != "" {
+		if d, err := time.ParseDuration(val); err == nil && d > 0 {
+			refresh = d
+		} else {
+			log.Printf("Warning: invalid FEATURE_FLAGS_REFRESH %q, using %s", val, refresh)
+		}
+	}
+	return &Store{db: db, refresh: refresh, now: time.Now}
+}
+
+// Config returns the flags set by configuration
+func (s *Store) Config() Config {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.config
+}
+
+// SetConfig replaces the flags set by configuration
+func (s *Store) SetConfig(config Config) error {
+	if err := config.validate(); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.config = config
+	return nil
+}
+
+// Export returns the config as JSON, for configuration sync
+func (s *Store) Export() (json.RawMessage, error) {
+	return json.Marshal(s.Config())
+}
+
+// Import replaces the config from JSON
+func (s *Store) Import(data json.RawMessage) error {
+	var config Config
+	if err := json.Unmarshal(data, &config); err != nil {
+		return fmt.Errorf("failed to parse feature flags: %w", err)
+	}
+	return s.SetConfig(config)
+}
+
+// currentOverrides returns the database overrides, re-reading them once the
+// cached copy is older than the refresh interval. A failed read keeps the
+// previous overrides.
+func (s *Store) currentOverrides() []database.FeatureOverride {
+	s.mu.RLock()
+	overrides, fresh := s.overrides, !s.loaded.IsZero() && s.now().Sub(s.loaded) < s.refresh
+	s.mu.RUnlock()
+	if fresh {
+		return overrides
+	}
+	return s.reload()
+}
+
+// Refresh re-reads the overrides from the database now, e.g. after a restore
+func (s *Store) Refresh() {
+	s.reload()
+}
+
+func (s *Store) reload() []database.FeatureOverride {
+	loaded, err := s.db.GetFeatureOverrides()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err != nil {
+		log.Printf("Warning: failed to load feature flag overrides: %v", err)
+	} else {
+		s.overrides = loaded
+	}
+	s.loaded = s.now()
+	return s.overrides
+}
+
+// State resolves a flag for a tenant
+func (s *Store) State(name, tenant string) (State, error) {
+	flag, ok := lookup(name)
+	if !ok {
+		return State{}, fmt.Errorf("%w: %s", ErrUnknownFlag, name)
+	}
+	return s.resolve(flag, tenant, s.currentOverrides()), nil
+}
+
+func (s *Store) resolve(flag Flag, tenant string, overrides []database.FeatureOverride) State {
+	var global *database.FeatureOverride
+	for i, o := range overrides {
+		if o.Flag != flag.Name {
+			continue
+		}
+		if tenant != "" && o.Tenant == tenant {
+			return State{Flag: flag, Enabled: o.Enabled, Source: "override"}
+		}
+		if o.Tenant == "" {
+			global = &overrides[i]
+		}
+	}
+	if global != nil {
+		return State{Flag: flag, Enabled: global.Enabled, Source: "override"}
+	}
+
+	config := s.Config()
+	if enabled, ok := config.Tenants[tenant][flag.Name]; ok && tenant != "" {
+		return State{Flag: flag, Enabled: enabled, Source: "config"}
+	}
+	if enabled, ok := config.Defaults[flag.Name]; ok {
+		return State{Flag: flag, Enabled: enabled, Source: "config"}
+	}
+	return State{Flag: flag, Enabled: flag.Default, Source: "default"}
+}
+
+// Enabled reports whether a flag is on for a tenant. Unknown flags are off.
+func (s *Store) Enabled(name, tenant string) bool {
+	state, err := s.State(name, tenant)
+	return err == nil && state.Enabled
+}
+
+// States resolves every flag for a tenant
+func (s *Store) States(tenant string) []State {
+	overrides := s.currentOverrides()
+	states := make([]State, len(Flags))
+	for i, f := range Flags {
+		states[i] = s.resolve(f, tenant, overrides)
+	}
+	return states
+}
+
+// Overrides returns the database overrides, by flag then tenant
+func (s *Store) Overrides() []database.FeatureOverride {
+	overrides := append([]database.FeatureOverride(nil), s.currentOverrides()...)
+	sort.Slice(overrides, func(i, j int) bool {
+		if overrides[i].Flag != overrides[j].Flag {
+			return overrides[i].Flag < overrides[j].Flag
+		}
+		return overrides[i].Tenant < overrides[j].Tenant
+	})
+	return overrides
+}
+
+// SetOverride switches a flag on or off for a tenant, or for everyone when
+// tenant is empty. It takes effect immediately on this replica.
+func (s *Store) SetOverride(name, tenant string, enabled bool, user string) error {
+	if _, ok := lookup(name); !ok {
+		return fmt.Errorf("%w: %s", ErrUnknownFlag, name)
+	}
+	err := s.db.SetFeatureOverride(database.FeatureOverride{
+		Flag:      name,
+		Tenant:    tenant,
+		Enabled:   enabled,
+		UpdatedBy: user,
+		UpdatedAt: s.now(),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to save feature flag override: %w", err)
+	}
+	s.reload()
+	return nil
+}
+
+// ClearOverride removes a tenant's override, or the override for everyone
+// when tenant is empty
+func (s *Store) ClearOverride(name, tenant string) error {
+	if _, ok := lookup(name); !ok {
+		return fmt.Errorf("%w: %s", ErrUnknownFlag, name)
+	}
+	if err := s.db.DeleteFeatureOverride(name, tenant); err != nil {
+		return fmt.Errorf("failed to remove feature flag override: %w", err)
+	}
+	s.reload()
+	return nil
+}
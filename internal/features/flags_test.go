@@ -0,0 +1,111 @@
+package features
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/testkube/dashboard/internal/database"
+)
+
+func TestStore_Precedence(t *testing.T) {
+	s := newStore(database.NewMockDatabase())
+
+	if s.Enabled(Graphs, "acme") {
+		t.Error("graphs should be off by default")
+	}
+	if state, _ := s.State(LiveLogs, "acme"); !state.Enabled || state.Source != "default" {
+		t.Errorf("live-logs: got %+v, expected enabled by default", state)
+	}
+
+	err := s.SetConfig(Config{
+		Defaults: map[string]bool{Graphs: true},
+		Tenants:  map[string]map[string]bool{"acme": {Graphs: false}},
+	})
+	if err != nil {
+		t.Fatalf("SetConfig failed: %v", err)
+	}
+	if !s.Enabled(Graphs, "globex") {
+		t.Error("config default should switch graphs on")
+	}
+	if s.Enabled(Graphs, "acme") {
+		t.Error("tenant config should win over the config default")
+	}
+
+	if err := s.SetOverride(Graphs, "", false, "alice"); err != nil {
+		t.Fatalf("SetOverride failed: %v", err)
+	}
+	if s.Enabled(Graphs, "globex") {
+		t.Error("global override should win over config")
+	}
+	if err := s.SetOverride(Graphs, "acme", true, "alice"); err != nil {
+		t.Fatalf("SetOverride failed: %v", err)
+	}
+	if state, _ := s.State(Graphs, "acme"); !state.Enabled || state.Source != "override" {
+		t.Errorf("acme: got %+v, expected enabled by override", state)
+	}
+	if s.Enabled(Graphs, "globex") {
+		t.Error("a tenant override shouldn't affect other tenants")
+	}
+
+	if err := s.ClearOverride(Graphs, ""); err != nil {
+		t.Fatalf("ClearOverride failed: %v", err)
+	}
+	if !s.Enabled(Graphs, "globex") {
+		t.Error("clearing the global override should fall back to config")
+	}
+	if got := len(s.Overrides()); got != 1 {
+		t.Errorf("overrides: got %d, expected 1", got)
+	}
+}
+
+func TestStore_UnknownFlag(t *testing.T) {
+	s := newStore(database.NewMockDatabase())
+
+	if s.Enabled("teleport", "") {
+		t.Error("unknown flags should be off")
+	}
+	if err := s.SetOverride("teleport", "", true, ""); !errors.Is(err, ErrUnknownFlag) {
+		t.Errorf("SetOverride: got %v, expected ErrUnknownFlag", err)
+	}
+	if err := s.Import([]byte(`{"defaults": {"teleport": true}}`)); !errors.Is(err, ErrUnknownFlag) {
+		t.Errorf("Import: got %v, expected ErrUnknownFlag", err)
+	}
+}
+
+func TestStore_RefreshesOverrides(t *testing.T) {
+	db := database.NewMockDatabase()
+	s := newStore(db)
+	now := time.Now()
+	s.now = func() time.Time { return now }
+
+	if !s.Enabled(Notifications, "") {
+		t.Fatal("notifications should be on by default")
+	}
+
+	// Another replica switches the flag off
+	db.SetFeatureOverride(database.FeatureOverride{Flag: Notifications, Enabled: false})
+	if !s.Enabled(Notifications, "") {
+		t.Error("overrides should be cached until the refresh interval passes")
+	}
+	now = now.Add(s.refresh)
+	if s.Enabled(Notifications, "") {
+		t.Error("overrides should be re-read after the refresh interval")
+	}
+}
+
+func TestParseFlagList(t *testing.T) {
+	flags, err := parseFlagList("graphs, live-logs=false,evidence=on")
+	if err != nil {
+		t.Fatalf("parseFlagList failed: %v", err)
+	}
+	expected := map[string]bool{Graphs: true, LiveLogs: false, Evidence: true}
+	for name, enabled := range expected {
+		if flags[name] != enabled {
+			t.Errorf("%s: got %v, expected %v", name, flags[name], enabled)
+		}
+	}
+	if _, err := parseFlagList("graphs=maybe"); err == nil {
+		t.Error("expected an error for an invalid value")
+	}
+}
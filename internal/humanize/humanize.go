@@ -0,0 +1,77 @@
+// Package humanize formats durations, times and sizes for people, the same
+// way across pages and API responses.
+package humanize
+
+import (
+	"fmt"
+	"time"
+)
+
+// Duration formats d with at most two units, e.g. "850ms", "42s",
+// "3m 12s", "2h 5m" or "3d 4h"
+func Duration(d time.Duration) string {
+	if d < 0 {
+		d = -d
+	}
+	switch {
+	case d == 0:
+		return "0s"
+	case d < time.Second:
+		return fmt.Sprintf("%dms", d.Milliseconds())
+	case d < time.Minute:
+		return fmt.Sprintf("%ds", int(d.Seconds()))
+	case d < time.Hour:
+		return twoUnits(int(d.Minutes()), "m", int(d.Seconds())%60, "s")
+	case d < 24*time.Hour:
+		return twoUnits(int(d.Hours()), "h", int(d.Minutes())%60, "m")
+	default:
+		return twoUnits(int(d.Hours())/24, "d", int(d.Hours())%24, "h")
+	}
+}
+
+func twoUnits(major int, majorUnit string, minor int, minorUnit string) string {
+	if minor == 0 {
+		return fmt.Sprintf("%d%s", major, majorUnit)
+	}
+	return fmt.Sprintf("%d%s %d%s", major, majorUnit, minor, minorUnit)
+}
+
+// Milliseconds formats a duration given in milliseconds, as test results
+// and checks record them, like Duration
+func Milliseconds(ms int) string {
+	return Duration(time.Duration(ms) * time.Millisecond)
+}
+
+// Relative formats t relative to now, e.g. "5m ago" or "in 2h", and times
+// over a month ago as dates
+func Relative(t, now time.Time) string {
+	if t.IsZero() {
+		return "-"
+	}
+
+	d := now.Sub(t)
+	switch {
+	case d < -time.Minute:
+		return "in " + Duration(-d.Truncate(time.Minute))
+	case d < time.Minute:
+		return "just now"
+	case d < 30*24*time.Hour:
+		return Duration(d.Truncate(time.Minute)) + " ago"
+	default:
+		return t.Format("Jan 02, 2006")
+	}
+}
+
+// Bytes formats a size in binary units, e.g. "512 B", "2.0 KB" or "1.5 GB"
+func Bytes(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	value, exp := float64(n)/unit, 0
+	for value >= unit && exp < 4 {
+		value /= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %cB", value, "KMGTP"[exp])
+}
@@ -0,0 +1,68 @@
+package humanize
+
+import (
+	"testing"
+	"time"
+)
+
+func TestDuration(t *testing.T) {
+	tests := []struct {
+		in   time.Duration
+		want string
+	}{
+		{0, "0s"},
+		{850 * time.Millisecond, "850ms"},
+		{42 * time.Second, "42s"},
+		{3*time.Minute + 12*time.Second, "3m 12s"},
+		{2 * time.Hour, "2h"},
+		{2*time.Hour + 5*time.Minute + 30*time.Second, "2h 5m"},
+		{76 * time.Hour, "3d 4h"},
+		{-42 * time.Second, "42s"},
+	}
+	for _, tt := range tests {
+		if got := Duration(tt.in); got != tt.want {
+			t.Errorf("Duration(%s): got %q, expected %q", tt.in, got, tt.want)
+		}
+	}
+	if got := Milliseconds(125000); got != "2m 5s" {
+		t.Errorf("Milliseconds(125000): got %q, expected 2m 5s", got)
+	}
+}
+
+func TestRelative(t *testing.T) {
+	now := time.Date(2026, 6, 1, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		in   time.Time
+		want string
+	}{
+		{time.Time{}, "-"},
+		{now.Add(-20 * time.Second), "just now"},
+		{now.Add(-5*time.Minute - 10*time.Second), "5m ago"},
+		{now.Add(-3*time.Hour - 10*time.Minute), "3h 10m ago"},
+		{now.Add(2 * time.Hour), "in 2h"},
+		{time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC), "Mar 01, 2026"},
+	}
+	for _, tt := range tests {
+		if got := Relative(tt.in, now); got != tt.want {
+			t.Errorf("Relative(%v): got %q, expected %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestBytes(t *testing.T) {
+	tests := []struct {
+		in   int64
+		want string
+	}{
+		{0, "0 B"},
+		{512, "512 B"},
+		{2048, "2.0 KB"},
+		{1536 * 1024, "1.5 MB"},
+		{5 << 30, "5.0 GB"},
+	}
+	for _, tt := range tests {
+		if got := Bytes(tt.in); got != tt.want {
+			t.Errorf("Bytes(%d): got %q, expected %q", tt.in, got, tt.want)
+		}
+	}
+}
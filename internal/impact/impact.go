@@ -0,0 +1,327 @@
+package impact
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/testkube/dashboard/internal/database"
+)
+
+// DefaultHistoryDays is how far back test history is searched for tests
+// matching changed files by name
+const DefaultHistoryDays = 30
+
+// testSuffixes are stripped from test file names to find the source file
+// they exercise, e.g. login.spec.ts tests login.ts
+var testSuffixes = []string{".spec", ".test", ".e2e", ".cy", "_test", "-test", "_spec", "-spec"}
+
+// genericStems are file names too common to map by convention
+var genericStems = map[string]bool{
+	"index": true, "main": true, "app": true, "util": true, "utils": true,
+	"types": true, "helpers": true, "constants": true, "config": true,
+}
+
+// Rule maps source paths to the workflows that must run when they change.
+// Patterns ending in "/**" match everything below a directory; patterns
+// without a slash match the file name anywhere, as in .gitignore.
+type Rule struct {
+	Paths     []string `json:"paths"`
+	Workflows []string `json:"workflows"`
+}
+
+type config struct {
+	Rules []Rule `json:"rules"`
+	// Ignore lists paths that never affect tests, e.g. "*.md" or "docs/**"
+	Ignore []string `json:"ignore,omitempty"`
+}
+
+// Test is a single test recommended to run
+type Test struct {
+	Name     string `json:"name"`
+	FilePath string `json:"filePath,omitempty"`
+}
+
+// Workflow is a workflow recommended to run. When Tests is empty the whole
+// workflow is affected; otherwise running just those tests is enough.
+type Workflow struct {
+	Name    string   `json:"name"`
+	Tests   []Test   `json:"tests,omitempty"`
+	Reasons []string `json:"reasons"`
+}
+
+// Result is the recommended subset of tests for a change
+type Result struct {
+	ChangedFiles int        `json:"changedFiles"`
+	Workflows    []Workflow `json:"workflows"`
+	// Unmatched lists changed files no coverage, rule or convention maps to
+	Unmatched []string `json:"unmatched,omitempty"`
+	// RunAll is set when some changes can't be mapped, so the subset may
+	// miss affected tests and pipelines should run everything
+	RunAll bool `json:"runAll"`
+}
+
+type testRef struct {
+	workflow string
+	test     Test
+}
+
+// Analyzer maps changed files to the workflows and tests they affect,
+// using recorded coverage, configured path rules and test history
+type Analyzer struct {
+	db     database.Database
+	config config
+	// coverage maps a source file to the tests that executed it
+	coverage map[string]map[testRef]bool
+	mu       sync.RWMutex
+}
+
+// NewAnalyzer creates an analyzer, loading path rules from the JSON file in
+// IMPACT_FILE when set
+func NewAnalyzer(db database.Database) (*Analyzer, error) {
+	a := &Analyzer{
+		db:       db,
+		coverage: make(map[string]map[testRef]bool),
+	}
+
+	if file := os.Getenv("IMPACT_FILE"); file != "" {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return a, fmt.Errorf("failed to read test impact file: %w", err)
+		}
+		if err := a.Import(data); err != nil {
+			return a, err
+		}
+	}
+
+	return a, nil
+}
+
+// RecordCoverage stores the source files a test executed, typically
+// uploaded by CI from a coverage report
+func (a *Analyzer) RecordCoverage(workflow string, test Test, files []string) error {
+	if workflow == "" || test.Name == "" {
+		return fmt.Errorf("coverage needs a workflow and test name")
+	}
+
+	ref := testRef{workflow: workflow, test: test}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	for _, file := range files {
+		file = cleanPath(file)
+		if a.coverage[file] == nil {
+			a.coverage[file] = make(map[testRef]bool)
+		}
+		a.coverage[file][ref] = true
+	}
+	return nil
+}
+
+func cleanPath(file string) string {
+	return strings.TrimPrefix(path.Clean(strings.TrimSpace(file)), "./")
+}
+
+// matchPath reports whether a file matches a rule or ignore pattern
+func matchPath(pattern, file string) bool {
+	if dir, ok := strings.CutSuffix(pattern, "/**"); ok {
+		return dir == "" || file == dir || strings.HasPrefix(file, dir+"/")
+	}
+	if !strings.Contains(pattern, "/") {
+		ok, _ := path.Match(pattern, path.Base(file))
+		return ok
+	}
+	ok, _ := path.Match(pattern, file)
+	return ok
+}
+
+// stem returns the lower-cased file name without extensions or test
+// suffixes, so src/Login.tsx and tests/login.spec.ts share a stem
+func stem(file string) string {
+	name := strings.ToLower(path.Base(file))
+	if i := strings.Index(name, "."); i > 0 {
+		for _, suffix := range testSuffixes {
+			if strings.HasPrefix(name[i:], suffix+".") {
+				return name[:i]
+			}
+		}
+		name = name[:i]
+	}
+	for _, suffix := range testSuffixes {
+		if trimmed, ok := strings.CutSuffix(name, suffix); ok && trimmed != "" {
+			return trimmed
+		}
+	}
+	return name
+}
+
+// impactSet accumulates affected workflows and tests
+type impactSet struct {
+	workflows map[string]*Workflow
+	whole     map[string]bool
+	tests     map[testRef]bool
+}
+
+func (s *impactSet) workflow(name string) *Workflow {
+	if w, ok := s.workflows[name]; ok {
+		return w
+	}
+	w := &Workflow{Name: name}
+	s.workflows[name] = w
+	return w
+}
+
+func (s *impactSet) addWorkflow(name, reason string) {
+	s.whole[name] = true
+	w := s.workflow(name)
+	w.Reasons = append(w.Reasons, reason)
+}
+
+func (s *impactSet) addTest(ref testRef, reason string) {
+	w := s.workflow(ref.workflow)
+	if !s.tests[ref] {
+		s.tests[ref] = true
+		w.Tests = append(w.Tests, ref.test)
+	}
+	w.Reasons = append(w.Reasons, reason)
+}
+
+// Analyze recommends the workflows and tests to run for a list of changed
+// files. Each file is mapped by, in order: recorded coverage, path rules,
+// and naming conventions against the tests run in the last historyDays.
+func (a *Analyzer) Analyze(changed []string, historyDays int) (Result, error) {
+	if historyDays <= 0 {
+		historyDays = DefaultHistoryDays
+	}
+	locations, err := a.db.GetTestLocations(historyDays)
+	if err != nil {
+		return Result{}, fmt.Errorf("failed to load test history: %w", err)
+	}
+
+	byFile := make(map[string][]testRef)
+	byStem := make(map[string][]testRef)
+	for _, loc := range locations {
+		if loc.FilePath == "" {
+			continue
+		}
+		ref := testRef{workflow: loc.Workflow, test: Test{Name: loc.TestName, FilePath: loc.FilePath}}
+		file := cleanPath(loc.FilePath)
+		byFile[file] = append(byFile[file], ref)
+		byStem[stem(file)] = append(byStem[stem(file)], ref)
+	}
+
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
+	set := &impactSet{
+		workflows: make(map[string]*Workflow),
+		whole:     make(map[string]bool),
+		tests:     make(map[testRef]bool),
+	}
+	result := Result{}
+
+	seen := make(map[string]bool)
+	for _, file := range changed {
+		file = cleanPath(file)
+		if file == "." || seen[file] {
+			continue
+		}
+		seen[file] = true
+		result.ChangedFiles++
+
+		if a.ignored(file) {
+			continue
+		}
+
+		matched := false
+		for ref := range a.coverage[file] {
+			set.addTest(ref, fmt.Sprintf("%s is covered by %s", file, ref.test.Name))
+			matched = true
+		}
+		for _, rule := range a.config.Rules {
+			for _, pattern := range rule.Paths {
+				if matchPath(pattern, file) {
+					for _, wf := range rule.Workflows {
+						set.addWorkflow(wf, fmt.Sprintf("%s matches %s", file, pattern))
+					}
+					matched = true
+					break
+				}
+			}
+		}
+		for _, ref := range byFile[file] {
+			set.addTest(ref, fmt.Sprintf("%s is a test file of this workflow", file))
+			matched = true
+		}
+		if s := stem(file); !matched && !genericStems[s] {
+			for _, ref := range byStem[s] {
+				set.addTest(ref, fmt.Sprintf("%s shares a name with %s", file, ref.test.FilePath))
+				matched = true
+			}
+		}
+
+		if !matched {
+			result.Unmatched = append(result.Unmatched, file)
+		}
+	}
+
+	for name, w := range set.workflows {
+		if set.whole[name] {
+			// The whole workflow runs, so individual tests add nothing
+			w.Tests = nil
+		}
+		sort.Slice(w.Tests, func(i, j int) bool { return w.Tests[i].Name < w.Tests[j].Name })
+		result.Workflows = append(result.Workflows, *w)
+	}
+	sort.Slice(result.Workflows, func(i, j int) bool {
+		return result.Workflows[i].Name < result.Workflows[j].Name
+	})
+	result.RunAll = len(result.Unmatched) > 0
+	return result, nil
+}
+
+func (a *Analyzer) ignored(file string) bool {
+	for _, pattern := range a.config.Ignore {
+		if matchPath(pattern, file) {
+			return true
+		}
+	}
+	return false
+}
+
+// Export returns the path rules as JSON, for configuration sync. Recorded
+// coverage is not exported; CI uploads it again on its next run.
+func (a *Analyzer) Export() (json.RawMessage, error) {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	return json.Marshal(a.config)
+}
+
+// Import replaces the path rules
+func (a *Analyzer) Import(data json.RawMessage) error {
+	var cfg config
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return fmt.Errorf("failed to parse test impact rules: %w", err)
+	}
+
+	patterns := append([]string{}, cfg.Ignore...)
+	for _, rule := range cfg.Rules {
+		if len(rule.Workflows) == 0 {
+			return fmt.Errorf("test impact rule for %v has no workflows", rule.Paths)
+		}
+		patterns = append(patterns, rule.Paths...)
+	}
+	for _, pattern := range patterns {
+		if _, err := path.Match(strings.TrimSuffix(pattern, "/**"), ""); err != nil {
+			return fmt.Errorf("invalid path pattern %q: %w", pattern, err)
+		}
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.config = cfg
+	return nil
+}
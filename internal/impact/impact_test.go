@@ -0,0 +1,135 @@
+package impact
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/testkube/dashboard/internal/database"
+	"github.com/testkube/dashboard/internal/testkube"
+)
+
+func newTestAnalyzer(t *testing.T, rules string) *Analyzer {
+	t.Helper()
+	db := database.NewMockDatabase()
+	runs := []struct{ exec, workflow string }{
+		{"exec-1", "frontend-e2e"},
+		{"exec-2", "backend-integration"},
+	}
+	for _, run := range runs {
+		db.InsertExecution(testkube.Execution{ID: run.exec, WorkflowName: run.workflow, StartTime: time.Now()})
+	}
+	db.InsertTestCase(database.TestCase{ExecutionID: "exec-1", TestName: "login works", FilePath: "tests/login.spec.ts"})
+	db.InsertTestCase(database.TestCase{ExecutionID: "exec-1", TestName: "checkout works", FilePath: "tests/checkout.spec.ts"})
+	db.InsertTestCase(database.TestCase{ExecutionID: "exec-2", TestName: "TestOrders", FilePath: "api/orders_test.go"})
+
+	a, err := NewAnalyzer(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rules != "" {
+		if err := a.Import([]byte(rules)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return a
+}
+
+func workflowNames(r Result) []string {
+	var names []string
+	for _, w := range r.Workflows {
+		names = append(names, w.Name)
+	}
+	return names
+}
+
+func TestAnalyzeByConvention(t *testing.T) {
+	a := newTestAnalyzer(t, "")
+
+	result, err := a.Analyze([]string{"src/pages/Login.tsx", "./api/orders.go", "tests/checkout.spec.ts"}, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := workflowNames(result); !reflect.DeepEqual(got, []string{"backend-integration", "frontend-e2e"}) {
+		t.Fatalf("workflows = %v", got)
+	}
+	frontend := result.Workflows[1]
+	if len(frontend.Tests) != 2 || frontend.Tests[0].Name != "checkout works" || frontend.Tests[1].Name != "login works" {
+		t.Errorf("frontend tests = %+v", frontend.Tests)
+	}
+	if result.RunAll || len(result.Unmatched) != 0 {
+		t.Errorf("every file should map to tests, unmatched %v", result.Unmatched)
+	}
+}
+
+func TestAnalyzeRulesAndIgnore(t *testing.T) {
+	a := newTestAnalyzer(t, `{
+		"rules": [{"paths": ["charts/**", "Dockerfile"], "workflows": ["k8s-compliance"]}],
+		"ignore": ["*.md", "docs/**"]
+	}`)
+
+	result, err := a.Analyze([]string{"charts/app/values.yaml", "build/Dockerfile", "README.md", "docs/setup.txt"}, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := workflowNames(result); !reflect.DeepEqual(got, []string{"k8s-compliance"}) {
+		t.Fatalf("workflows = %v", got)
+	}
+	if w := result.Workflows[0]; w.Tests != nil || len(w.Reasons) != 2 {
+		t.Errorf("a rule should select the whole workflow, got %+v", w)
+	}
+	if result.ChangedFiles != 4 || result.RunAll {
+		t.Errorf("changed = %d, runAll = %v", result.ChangedFiles, result.RunAll)
+	}
+}
+
+func TestAnalyzeCoverageAndUnmatched(t *testing.T) {
+	a := newTestAnalyzer(t, "")
+	if err := a.RecordCoverage("frontend-e2e", Test{Name: "login works"}, []string{"./src/session/store.ts"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := a.RecordCoverage("", Test{Name: "x"}, nil); err == nil {
+		t.Error("expected an error for coverage without a workflow")
+	}
+
+	result, err := a.Analyze([]string{"src/session/store.ts", "src/index.ts", "lib/unknown.go"}, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result.Workflows) != 1 || result.Workflows[0].Tests[0].Name != "login works" {
+		t.Errorf("workflows = %+v", result.Workflows)
+	}
+	// index.ts is too generic to map by name
+	if !result.RunAll || !reflect.DeepEqual(result.Unmatched, []string{"src/index.ts", "lib/unknown.go"}) {
+		t.Errorf("unmatched = %v, runAll = %v", result.Unmatched, result.RunAll)
+	}
+}
+
+func TestStem(t *testing.T) {
+	tests := map[string]string{
+		"tests/login.spec.ts":   "login",
+		"src/Login.tsx":         "login",
+		"api/orders_test.go":    "orders",
+		"cypress/cart.cy.js":    "cart",
+		"pkg/handler-test.py":   "handler",
+		"Makefile":              "makefile",
+		"web/.eslintrc":         ".eslintrc",
+		"src/user.service.ts":   "user",
+		"tests/user.service.ts": "user",
+	}
+	for file, want := range tests {
+		if got := stem(file); got != want {
+			t.Errorf("stem(%q) = %q, want %q", file, got, want)
+		}
+	}
+}
+
+func TestImportRejectsRulesWithoutWorkflows(t *testing.T) {
+	a := newTestAnalyzer(t, "")
+	if err := a.Import([]byte(`{"rules": [{"paths": ["src/**"]}]}`)); err == nil {
+		t.Error("expected an error for a rule without workflows")
+	}
+	if err := a.Import([]byte(`{"ignore": ["[bad"]}`)); err == nil {
+		t.Error("expected an error for an invalid pattern")
+	}
+}
@@ -0,0 +1,49 @@
+package kube
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// Certificate is the subset of a cert-manager Certificate needed to report
+// whether it has been issued
+type Certificate struct {
+	Metadata struct {
+		Name      string `json:"name"`
+		Namespace string `json:"namespace"`
+	} `json:"metadata"`
+	Status struct {
+		NotAfter   *time.Time             `json:"notAfter,omitempty"`
+		Conditions []CertificateCondition `json:"conditions,omitempty"`
+	} `json:"status"`
+}
+
+// CertificateCondition is a status condition of a cert-manager Certificate
+type CertificateCondition struct {
+	Type    string `json:"type"`
+	Status  string `json:"status"`
+	Reason  string `json:"reason,omitempty"`
+	Message string `json:"message,omitempty"`
+}
+
+// Ready reports whether the certificate has been issued, with cert-manager's
+// explanation of its Ready condition
+func (c *Certificate) Ready() (bool, string) {
+	for _, cond := range c.Status.Conditions {
+		if cond.Type == "Ready" {
+			return cond.Status == "True", cond.Message
+		}
+	}
+	return false, "Waiting for cert-manager to process the certificate"
+}
+
+// GetCertificate fetches a cert-manager Certificate
+func (c *Client) GetCertificate(ctx context.Context, namespace, name string) (*Certificate, error) {
+	var cert Certificate
+	path := fmt.Sprintf("/apis/cert-manager.io/v1/namespaces/%s/certificates/%s", namespace, name)
+	if err := c.get(ctx, path, &cert); err != nil {
+		return nil, err
+	}
+	return &cert, nil
+}
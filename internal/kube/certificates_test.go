@@ -0,0 +1,53 @@
+package kube
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestGetCertificate(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/apis/cert-manager.io/v1/namespaces/envs/certificates/demo-tls":
+			w.Write([]byte(`{
+				"metadata": {"name": "demo-tls", "namespace": "envs"},
+				"status": {
+					"notAfter": "2026-08-01T00:00:00Z",
+					"conditions": [{"type": "Ready", "status": "True", "message": "Certificate is up to date and has not expired"}]
+				}
+			}`))
+		case "/apis/cert-manager.io/v1/namespaces/envs/certificates/pending-tls":
+			w.Write([]byte(`{"metadata": {"name": "pending-tls"}, "status": {}}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer ts.Close()
+
+	client := NewClient(ts.URL, "", nil)
+
+	cert, err := client.GetCertificate(context.Background(), "envs", "demo-tls")
+	if err != nil {
+		t.Fatalf("GetCertificate failed: %v", err)
+	}
+	if ready, msg := cert.Ready(); !ready || msg != "Certificate is up to date and has not expired" {
+		t.Errorf("Ready() = %v, %q", ready, msg)
+	}
+	if cert.Status.NotAfter == nil || cert.Status.NotAfter.Year() != 2026 {
+		t.Errorf("NotAfter = %v", cert.Status.NotAfter)
+	}
+
+	cert, err = client.GetCertificate(context.Background(), "envs", "pending-tls")
+	if err != nil {
+		t.Fatalf("GetCertificate failed: %v", err)
+	}
+	if ready, _ := cert.Ready(); ready {
+		t.Error("certificate without conditions should not be ready")
+	}
+
+	if _, err := client.GetCertificate(context.Background(), "envs", "missing-tls"); err == nil {
+		t.Error("expected an error for a missing certificate")
+	}
+}
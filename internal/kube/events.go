@@ -0,0 +1,238 @@
+package kube
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"sort"
+	"sync"
+	"time"
+)
+
+const (
+	DefaultEventPollInterval = time.Minute
+	DefaultEventRetention    = 24 * time.Hour
+	// DefaultEventWindow is how far either side of an execution an event
+	// may fall and still be considered related to it
+	DefaultEventWindow = 5 * time.Minute
+)
+
+// infraReasons are event reasons that point at the cluster rather than the
+// test: evictions, node pressure, node failures and OOM kills
+var infraReasons = map[string]bool{
+	"Evicted":                   true,
+	"EvictionThresholdMet":      true,
+	"Preempted":                 true,
+	"Preempting":                true,
+	"NodeNotReady":              true,
+	"NodeNotSchedulable":        true,
+	"NodeHasDiskPressure":       true,
+	"NodeHasInsufficientMemory": true,
+	"NodeHasInsufficientPID":    true,
+	"FreeDiskSpaceFailed":       true,
+	"ImageGCFailed":             true,
+	"SystemOOM":                 true,
+	"OOMKilling":                true,
+	"Rebooted":                  true,
+	"NodeShutdown":              true,
+	"TerminatingEvictedPod":     true,
+}
+
+// IsInfraReason reports whether an event reason indicates a cluster problem
+func IsInfraReason(reason string) bool {
+	return infraReasons[reason]
+}
+
+// Event is the subset of a Kubernetes core/v1 Event we care about
+type Event struct {
+	Metadata struct {
+		UID       string `json:"uid"`
+		Namespace string `json:"namespace"`
+	} `json:"metadata"`
+	InvolvedObject struct {
+		Kind      string `json:"kind"`
+		Name      string `json:"name"`
+		Namespace string `json:"namespace"`
+	} `json:"involvedObject"`
+	Reason         string    `json:"reason"`
+	Message        string    `json:"message"`
+	Type           string    `json:"type"`
+	Count          int       `json:"count"`
+	FirstTimestamp time.Time `json:"firstTimestamp"`
+	LastTimestamp  time.Time `json:"lastTimestamp"`
+	EventTime      time.Time `json:"eventTime"`
+	Source         struct {
+		Host string `json:"host"`
+	} `json:"source"`
+}
+
+// Time returns when the event last occurred
+func (e Event) Time() time.Time {
+	switch {
+	case !e.LastTimestamp.IsZero():
+		return e.LastTimestamp
+	case !e.EventTime.IsZero():
+		return e.EventTime
+	default:
+		return e.FirstTimestamp
+	}
+}
+
+// Start returns when the event first occurred
+func (e Event) Start() time.Time {
+	if !e.FirstTimestamp.IsZero() {
+		return e.FirstTimestamp
+	}
+	return e.Time()
+}
+
+// Node returns the node the event concerns, if known
+func (e Event) Node() string {
+	if e.InvolvedObject.Kind == "Node" {
+		return e.InvolvedObject.Name
+	}
+	return e.Source.Host
+}
+
+// Summary is a one-line description such as "Evicted Pod testkube/run-1 on node-a"
+func (e Event) Summary() string {
+	s := fmt.Sprintf("%s %s", e.Reason, e.InvolvedObject.Kind)
+	if e.InvolvedObject.Namespace != "" {
+		s += " " + e.InvolvedObject.Namespace + "/" + e.InvolvedObject.Name
+	} else {
+		s += " " + e.InvolvedObject.Name
+	}
+	if node := e.Node(); node != "" && node != e.InvolvedObject.Name {
+		s += " on " + node
+	}
+	return s
+}
+
+// ListEvents lists events in a namespace, or across the cluster when
+// namespace is empty
+func (c *Client) ListEvents(ctx context.Context, namespace string) ([]Event, error) {
+	path := "/api/v1/events"
+	if namespace != "" {
+		path = fmt.Sprintf("/api/v1/namespaces/%s/events", namespace)
+	}
+
+	var list struct {
+		Items []Event `json:"items"`
+	}
+	if err := c.get(ctx, path, &list); err != nil {
+		return nil, err
+	}
+	return list.Items, nil
+}
+
+// EventHistory keeps cluster infrastructure events for longer than the API
+// server does (events typically expire after an hour) so failed executions
+// can be correlated with them after the fact.
+type EventHistory struct {
+	client    *Client
+	interval  time.Duration
+	retention time.Duration
+	window    time.Duration
+
+	events map[string]Event // by UID
+	mu     sync.RWMutex
+	now    func() time.Time
+}
+
+// NewEventHistory creates a history and starts polling cluster events
+func NewEventHistory(client *Client) *EventHistory {
+	h := newEventHistory(client)
+	go h.pollLoop()
+	return h
+}
+
+func newEventHistory(client *Client) *EventHistory {
+	return &EventHistory{
+		client:    client,
+		interval:  durationFromEnv("KUBE_EVENT_POLL_INTERVAL", DefaultEventPollInterval),
+		retention: durationFromEnv("KUBE_EVENT_RETENTION", DefaultEventRetention),
+		window:    durationFromEnv("KUBE_EVENT_WINDOW", DefaultEventWindow),
+		events:    make(map[string]Event),
+		now:       time.Now,
+	}
+}
+
+func durationFromEnv(key string, defaultVal time.Duration) time.Duration {
+	if val := os.Getenv(key); val != "" {
+		if d, err := time.ParseDuration(val); err == nil && d > 0 {
+			return d
+		}
+	}
+	return defaultVal
+}
+
+func (h *EventHistory) pollLoop() {
+	if err := h.Poll(context.Background()); err != nil {
+		log.Printf("Error polling cluster events: %v", err)
+	}
+
+	ticker := time.NewTicker(h.interval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		if err := h.Poll(context.Background()); err != nil {
+			log.Printf("Error polling cluster events: %v", err)
+		}
+	}
+}
+
+// Poll fetches current cluster events, records the infrastructure ones and
+// drops those older than the retention period
+func (h *EventHistory) Poll(ctx context.Context) error {
+	events, err := h.client.ListEvents(ctx, "")
+	if err != nil {
+		return fmt.Errorf("failed to list events: %w", err)
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for _, e := range events {
+		if IsInfraReason(e.Reason) {
+			key := e.Metadata.UID
+			if key == "" {
+				key = e.Metadata.Namespace + "/" + e.InvolvedObject.Name + "/" + e.Reason + "/" + e.Start().String()
+			}
+			h.events[key] = e
+		}
+	}
+
+	cutoff := h.now().Add(-h.retention)
+	for key, e := range h.events {
+		if e.Time().Before(cutoff) {
+			delete(h.events, key)
+		}
+	}
+	return nil
+}
+
+// Correlate returns the infrastructure events that overlap the period from
+// start to end, widened by the correlation window, oldest first. A zero end
+// means the execution is still running.
+func (h *EventHistory) Correlate(start, end time.Time) []Event {
+	if end.IsZero() {
+		end = h.now()
+	}
+	from, to := start.Add(-h.window), end.Add(h.window)
+
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	var matched []Event
+	for _, e := range h.events {
+		if !e.Time().Before(from) && !e.Start().After(to) {
+			matched = append(matched, e)
+		}
+	}
+
+	sort.Slice(matched, func(i, j int) bool {
+		return matched[i].Time().Before(matched[j].Time())
+	})
+	return matched
+}
@@ -0,0 +1,74 @@
+package kube
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestEventHistory_Correlate(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/v1/events" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Write([]byte(`{"items": [
+			{
+				"metadata": {"uid": "1", "namespace": "testkube"},
+				"involvedObject": {"kind": "Pod", "name": "e2e-run-7", "namespace": "testkube"},
+				"reason": "Evicted", "message": "The node was low on resource: memory.",
+				"source": {"host": "node-a"},
+				"firstTimestamp": "2026-01-05T10:02:00Z", "lastTimestamp": "2026-01-05T10:02:00Z"
+			},
+			{
+				"metadata": {"uid": "2"},
+				"involvedObject": {"kind": "Node", "name": "node-b"},
+				"reason": "NodeHasDiskPressure", "count": 3,
+				"firstTimestamp": "2026-01-05T08:00:00Z", "lastTimestamp": "2026-01-05T09:50:00Z"
+			},
+			{
+				"metadata": {"uid": "3", "namespace": "testkube"},
+				"involvedObject": {"kind": "Pod", "name": "e2e-run-7", "namespace": "testkube"},
+				"reason": "Pulled", "type": "Normal",
+				"lastTimestamp": "2026-01-05T10:01:00Z"
+			},
+			{
+				"metadata": {"uid": "4"},
+				"involvedObject": {"kind": "Node", "name": "node-c"},
+				"reason": "NodeNotReady",
+				"eventTime": "2026-01-04T09:00:00Z"
+			}
+		]}`))
+	}))
+	defer ts.Close()
+
+	now := time.Date(2026, 1, 5, 12, 0, 0, 0, time.UTC)
+	h := newEventHistory(NewClient(ts.URL, "", nil))
+	h.now = func() time.Time { return now }
+
+	if err := h.Poll(context.Background()); err != nil {
+		t.Fatalf("Poll failed: %v", err)
+	}
+	if len(h.events) != 2 {
+		t.Fatalf("expected normal and expired events to be dropped, got %d events", len(h.events))
+	}
+
+	start := time.Date(2026, 1, 5, 9, 55, 0, 0, time.UTC)
+	events := h.Correlate(start, start.Add(5*time.Minute))
+	if len(events) != 2 {
+		t.Fatalf("expected 2 correlated events, got %d", len(events))
+	}
+	if events[0].Reason != "NodeHasDiskPressure" || events[1].Reason != "Evicted" {
+		t.Errorf("expected events oldest first, got %s then %s", events[0].Reason, events[1].Reason)
+	}
+	if got := events[1].Summary(); got != "Evicted Pod testkube/e2e-run-7 on node-a" {
+		t.Errorf("unexpected summary %q", got)
+	}
+
+	// Outside the window nothing matches
+	if events := h.Correlate(start.Add(time.Hour), start.Add(70*time.Minute)); len(events) != 0 {
+		t.Errorf("expected no events an hour later, got %d", len(events))
+	}
+}
@@ -0,0 +1,212 @@
+package kube
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const (
+	DefaultQuotaWarnRatio = 0.8
+	DefaultQuotaDenyRatio = 0.95
+)
+
+type QuotaLevel string
+
+const (
+	QuotaOK   QuotaLevel = "ok"
+	QuotaWarn QuotaLevel = "warn"
+	QuotaDeny QuotaLevel = "deny"
+)
+
+// ResourceQuota is the subset of a Kubernetes ResourceQuota we care about
+type ResourceQuota struct {
+	Metadata struct {
+		Name      string `json:"name"`
+		Namespace string `json:"namespace"`
+	} `json:"metadata"`
+	Status struct {
+		Hard map[string]string `json:"hard"`
+		Used map[string]string `json:"used"`
+	} `json:"status"`
+}
+
+// ResourceUsage describes consumption of a single quota-limited resource
+type ResourceUsage struct {
+	Quota    string     `json:"quota"`
+	Resource string     `json:"resource"`
+	Used     string     `json:"used"`
+	Hard     string     `json:"hard"`
+	Ratio    float64    `json:"ratio"`
+	Level    QuotaLevel `json:"level"`
+}
+
+// QuotaStatus summarizes quota pressure in a namespace
+type QuotaStatus struct {
+	Namespace string          `json:"namespace"`
+	Level     QuotaLevel      `json:"level"`
+	Resources []ResourceUsage `json:"resources"`
+}
+
+// Message returns a human-readable explanation of the resources that are
+// near or at their limits, suitable for showing to the user who triggered
+// the action.
+func (s *QuotaStatus) Message() string {
+	var parts []string
+	for _, r := range s.Resources {
+		if r.Level == QuotaOK {
+			continue
+		}
+		parts = append(parts, fmt.Sprintf("%s is at %s of %s (%d%%, quota %s)",
+			r.Resource, r.Used, r.Hard, int(math.Round(r.Ratio*100)), r.Quota))
+	}
+	if len(parts) == 0 {
+		return fmt.Sprintf("Namespace %s has sufficient quota", s.Namespace)
+	}
+	return fmt.Sprintf("Namespace %s is near its resource quota: %s. Free up resources or ask a cluster admin to raise the quota.",
+		s.Namespace, strings.Join(parts, "; "))
+}
+
+// GetResourceQuotas lists ResourceQuotas in a namespace
+func (c *Client) GetResourceQuotas(ctx context.Context, namespace string) ([]ResourceQuota, error) {
+	var list struct {
+		Items []ResourceQuota `json:"items"`
+	}
+	if err := c.get(ctx, fmt.Sprintf("/api/v1/namespaces/%s/resourcequotas", namespace), &list); err != nil {
+		return nil, err
+	}
+	return list.Items, nil
+}
+
+// QuotaChecker evaluates ResourceQuota usage against warn/deny thresholds
+type QuotaChecker struct {
+	client    *Client
+	warnRatio float64
+	denyRatio float64
+}
+
+func NewQuotaChecker(client *Client) *QuotaChecker {
+	return &QuotaChecker{
+		client:    client,
+		warnRatio: getRatioOrDefault("KUBE_QUOTA_WARN_RATIO", DefaultQuotaWarnRatio),
+		denyRatio: getRatioOrDefault("KUBE_QUOTA_DENY_RATIO", DefaultQuotaDenyRatio),
+	}
+}
+
+func getRatioOrDefault(key string, defaultVal float64) float64 {
+	if val := os.Getenv(key); val != "" {
+		if f, err := strconv.ParseFloat(val, 64); err == nil && f > 0 {
+			return f
+		}
+	}
+	return defaultVal
+}
+
+// Check fetches quotas for the namespace and classifies the most constrained
+// resource. A namespace without quotas is always QuotaOK.
+func (q *QuotaChecker) Check(ctx context.Context, namespace string) (*QuotaStatus, error) {
+	quotas, err := q.client.GetResourceQuotas(ctx, namespace)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get resource quotas: %w", err)
+	}
+	return q.evaluate(namespace, quotas), nil
+}
+
+func (q *QuotaChecker) evaluate(namespace string, quotas []ResourceQuota) *QuotaStatus {
+	status := &QuotaStatus{Namespace: namespace, Level: QuotaOK}
+
+	for _, quota := range quotas {
+		for resource, hardStr := range quota.Status.Hard {
+			hard, err := ParseQuantity(hardStr)
+			if err != nil || hard <= 0 {
+				continue
+			}
+			usedStr := quota.Status.Used[resource]
+			used, err := ParseQuantity(usedStr)
+			if err != nil {
+				continue
+			}
+
+			usage := ResourceUsage{
+				Quota:    quota.Metadata.Name,
+				Resource: resource,
+				Used:     usedStr,
+				Hard:     hardStr,
+				Ratio:    used / hard,
+				Level:    QuotaOK,
+			}
+			switch {
+			case usage.Ratio >= q.denyRatio:
+				usage.Level = QuotaDeny
+			case usage.Ratio >= q.warnRatio:
+				usage.Level = QuotaWarn
+			}
+
+			if usage.Level == QuotaDeny || (usage.Level == QuotaWarn && status.Level == QuotaOK) {
+				status.Level = usage.Level
+			}
+			status.Resources = append(status.Resources, usage)
+		}
+	}
+
+	// Most constrained first
+	sort.Slice(status.Resources, func(i, j int) bool {
+		return status.Resources[i].Ratio > status.Resources[j].Ratio
+	})
+
+	return status
+}
+
+var quantitySuffixes = map[string]float64{
+	"n":  1e-9,
+	"u":  1e-6,
+	"m":  1e-3,
+	"k":  1e3,
+	"M":  1e6,
+	"G":  1e9,
+	"T":  1e12,
+	"P":  1e15,
+	"Ki": 1 << 10,
+	"Mi": 1 << 20,
+	"Gi": 1 << 30,
+	"Ti": 1 << 40,
+	"Pi": 1 << 50,
+}
+
+// ParseQuantity converts a Kubernetes resource quantity (e.g. "500m", "2Gi",
+// "10") to a float. Precision is sufficient for ratio comparisons.
+func ParseQuantity(s string) (float64, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return 0, fmt.Errorf("empty quantity")
+	}
+
+	number, suffix := s, ""
+	for i := len(s) - 1; i >= 0; i-- {
+		if (s[i] >= '0' && s[i] <= '9') || s[i] == '.' {
+			number, suffix = s[:i+1], s[i+1:]
+			break
+		}
+	}
+
+	// Exponent form such as "1e3" is handled by ParseFloat directly
+	if _, ok := quantitySuffixes[suffix]; !ok && suffix != "" {
+		if f, err := strconv.ParseFloat(s, 64); err == nil {
+			return f, nil
+		}
+		return 0, fmt.Errorf("invalid quantity suffix in %q", s)
+	}
+
+	f, err := strconv.ParseFloat(number, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid quantity %q: %w", s, err)
+	}
+	if mult, ok := quantitySuffixes[suffix]; ok {
+		f *= mult
+	}
+	return f, nil
+}
@@ -0,0 +1,71 @@
+package kube
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestParseQuantity(t *testing.T) {
+	tests := []struct {
+		in       string
+		expected float64
+	}{
+		{"10", 10},
+		{"500m", 0.5},
+		{"2Gi", 2 * (1 << 30)},
+		{"256Mi", 256 * (1 << 20)},
+		{"1.5", 1.5},
+		{"3k", 3000},
+		{"1e3", 1000},
+	}
+
+	for _, tt := range tests {
+		got, err := ParseQuantity(tt.in)
+		if err != nil {
+			t.Errorf("ParseQuantity(%q) failed: %v", tt.in, err)
+			continue
+		}
+		if got != tt.expected {
+			t.Errorf("ParseQuantity(%q) = %v, expected %v", tt.in, got, tt.expected)
+		}
+	}
+
+	if _, err := ParseQuantity("12xyz"); err == nil {
+		t.Error("expected error for invalid suffix")
+	}
+}
+
+func TestQuotaChecker_Check(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/v1/namespaces/testkube/resourcequotas" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Write([]byte(`{"items": [{
+			"metadata": {"name": "compute", "namespace": "testkube"},
+			"status": {
+				"hard": {"requests.cpu": "4", "requests.memory": "8Gi", "pods": "20"},
+				"used": {"requests.cpu": "3500m", "requests.memory": "2Gi", "pods": "20"}
+			}
+		}]}`))
+	}))
+	defer ts.Close()
+
+	checker := NewQuotaChecker(NewClient(ts.URL, "", nil))
+	status, err := checker.Check(context.Background(), "testkube")
+	if err != nil {
+		t.Fatalf("Check failed: %v", err)
+	}
+
+	if status.Level != QuotaDeny {
+		t.Errorf("expected deny level, got %s", status.Level)
+	}
+	if len(status.Resources) != 3 {
+		t.Fatalf("expected 3 resources, got %d", len(status.Resources))
+	}
+	if status.Resources[0].Resource != "pods" || status.Resources[1].Level != QuotaWarn {
+		t.Errorf("unexpected resource ordering/levels: %+v", status.Resources)
+	}
+}
@@ -0,0 +1,298 @@
+package notify
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/testkube/dashboard/internal/database"
+	"github.com/testkube/dashboard/internal/testkube"
+)
+
+const (
+	// DefaultDigestInterval is how often rolling updates for recurring failures are sent
+	DefaultDigestInterval = 1 * time.Hour
+	// DefaultDigestTTL is how long an alert stays open without new failures
+	DefaultDigestTTL = 24 * time.Hour
+)
+
+// AlertPassRate is the kind of alert opened when a workflow's pass rate
+// drops below its threshold, rather than for a failing test
+const AlertPassRate = "pass-rate"
+
+// Alert is a rolling alert for a test that keeps failing. The first failure
+// is sent immediately; later ones only bump the counter until the next digest.
+type Alert struct {
+	Kind            string    `json:"kind,omitempty"`
+	WorkflowName    string    `json:"workflowName"`
+	TestName        string    `json:"testName,omitempty"`
+	ErrorMessage    string    `json:"errorMessage,omitempty"`
+	Occurrences     int       `json:"occurrences"`
+	FirstSeen       time.Time `json:"firstSeen"`
+	LastSeen        time.Time `json:"lastSeen"`
+	LastExecutionID string    `json:"lastExecutionId"`
+	// NotifiedOccurrences is the counter value included in the last message sent
+	NotifiedOccurrences int `json:"notifiedOccurrences"`
+}
+
+func (a Alert) subject() string {
+	if a.Kind == AlertPassRate {
+		return fmt.Sprintf("%s pass rate", a.WorkflowName)
+	}
+	if a.TestName == "" {
+		return a.WorkflowName
+	}
+	return fmt.Sprintf("%s: %s", a.WorkflowName, a.TestName)
+}
+
+// Digest collapses repeated failures of the same test into a single rolling
+// alert per test rather than notifying on every run.
+type Digest struct {
+	notifier Notifier
+	interval time.Duration
+	ttl      time.Duration
+	baseURL  string
+
+	alerts map[string]*Alert
+	mu     sync.Mutex
+	now    func() time.Time
+}
+
+// NewDigest creates a digest and starts its background flush loop. Intervals
+// are configured via NOTIFY_DIGEST_INTERVAL and NOTIFY_DIGEST_TTL.
+func NewDigest(notifier Notifier) *Digest {
+	d := newDigest(notifier)
+	go d.flushLoop()
+	return d
+}
+
+func newDigest(notifier Notifier) *Digest {
+	return &Digest{
+		notifier: notifier,
+		interval: durationFromEnv("NOTIFY_DIGEST_INTERVAL", DefaultDigestInterval),
+		ttl:      durationFromEnv("NOTIFY_DIGEST_TTL", DefaultDigestTTL),
+		baseURL:  strings.TrimSuffix(os.Getenv("DASHBOARD_URL"), "/"),
+		alerts:   make(map[string]*Alert),
+		now:      time.Now,
+	}
+}
+
+func durationFromEnv(key string, def time.Duration) time.Duration {
+	val := os.Getenv(key)
+	if val == "" {
+		return def
+	}
+	d, err := time.ParseDuration(val)
+	if err != nil || d <= 0 {
+		log.Printf("Warning: invalid %s %q, using %s", key, val, def)
+		return def
+	}
+	return d
+}
+
+func alertKey(workflow, test string) string {
+	return workflow + "\x00" + test
+}
+
+// Observe records the outcome of an ingested execution. Failed tests open or
+// bump alerts; tests that pass again close theirs.
+func (d *Digest) Observe(exec testkube.Execution, cases []database.TestCase) {
+	seen := exec.EndTime
+	if seen.IsZero() {
+		seen = d.now()
+	}
+
+	var fresh []Alert
+	d.mu.Lock()
+	failedCases := 0
+	for _, tc := range cases {
+		switch tc.Status {
+		case "failed":
+			failedCases++
+			if alert, isNew := d.record(exec, tc.TestName, tc.ErrorMessage, seen); isNew {
+				fresh = append(fresh, alert)
+			}
+		case "passed":
+			delete(d.alerts, alertKey(exec.WorkflowName, tc.TestName))
+		}
+	}
+	switch {
+	case exec.Status == "failed" && failedCases == 0:
+		if alert, isNew := d.record(exec, "", "", seen); isNew {
+			fresh = append(fresh, alert)
+		}
+	case exec.Status == "passed":
+		delete(d.alerts, alertKey(exec.WorkflowName, ""))
+	}
+	d.mu.Unlock()
+
+	for _, alert := range fresh {
+		d.send(Message{
+			Title: fmt.Sprintf("New failure: %s", alert.subject()),
+			Text:  alert.ErrorMessage,
+			URL:   d.executionURL(alert.LastExecutionID),
+		})
+	}
+}
+
+// ObservePassRate opens or bumps a workflow's pass rate alert while the
+// pass rate is below its threshold, and closes it once it recovers
+func (d *Digest) ObservePassRate(exec testkube.Execution, breached bool, message string) {
+	key := exec.WorkflowName + "\x00" + AlertPassRate
+	seen := exec.EndTime
+	if seen.IsZero() {
+		seen = d.now()
+	}
+
+	d.mu.Lock()
+	if !breached {
+		delete(d.alerts, key)
+		d.mu.Unlock()
+		return
+	}
+	alert, ok := d.alerts[key]
+	if ok {
+		alert.Occurrences++
+		alert.LastExecutionID = exec.ID
+		alert.ErrorMessage = message
+		if seen.After(alert.LastSeen) {
+			alert.LastSeen = seen
+		}
+		d.mu.Unlock()
+		return
+	}
+	alert = &Alert{
+		Kind:                AlertPassRate,
+		WorkflowName:        exec.WorkflowName,
+		ErrorMessage:        message,
+		Occurrences:         1,
+		FirstSeen:           seen,
+		LastSeen:            seen,
+		LastExecutionID:     exec.ID,
+		NotifiedOccurrences: 1,
+	}
+	d.alerts[key] = alert
+	fresh := *alert
+	d.mu.Unlock()
+
+	d.send(Message{
+		Title: fmt.Sprintf("Pass rate dropped: %s", exec.WorkflowName),
+		Text:  fresh.ErrorMessage,
+		URL:   d.executionURL(fresh.LastExecutionID),
+	})
+}
+
+// record must be called with d.mu held
+func (d *Digest) record(exec testkube.Execution, test, errorMessage string, seen time.Time) (Alert, bool) {
+	key := alertKey(exec.WorkflowName, test)
+	if alert, ok := d.alerts[key]; ok {
+		alert.Occurrences++
+		alert.LastExecutionID = exec.ID
+		if seen.After(alert.LastSeen) {
+			alert.LastSeen = seen
+		}
+		if errorMessage != "" {
+			alert.ErrorMessage = errorMessage
+		}
+		return *alert, false
+	}
+
+	alert := &Alert{
+		WorkflowName:        exec.WorkflowName,
+		TestName:            test,
+		ErrorMessage:        errorMessage,
+		Occurrences:         1,
+		FirstSeen:           seen,
+		LastSeen:            seen,
+		LastExecutionID:     exec.ID,
+		NotifiedOccurrences: 1,
+	}
+	d.alerts[key] = alert
+	return *alert, true
+}
+
+// Alerts returns the open alerts, most frequent first
+func (d *Digest) Alerts() []Alert {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	alerts := make([]Alert, 0, len(d.alerts))
+	for _, alert := range d.alerts {
+		alerts = append(alerts, *alert)
+	}
+	sort.Slice(alerts, func(i, j int) bool {
+		if alerts[i].Occurrences != alerts[j].Occurrences {
+			return alerts[i].Occurrences > alerts[j].Occurrences
+		}
+		return alerts[i].LastSeen.After(alerts[j].LastSeen)
+	})
+	return alerts
+}
+
+func (d *Digest) flushLoop() {
+	ticker := time.NewTicker(d.interval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		d.Flush()
+	}
+}
+
+// Flush sends one rolling update per alert that failed again since it was
+// last notified, and expires alerts that have been quiet for the TTL.
+func (d *Digest) Flush() {
+	now := d.now()
+
+	var updates []Alert
+	d.mu.Lock()
+	for key, alert := range d.alerts {
+		if now.Sub(alert.LastSeen) > d.ttl {
+			delete(d.alerts, key)
+			continue
+		}
+		if alert.Occurrences > alert.NotifiedOccurrences {
+			alert.NotifiedOccurrences = alert.Occurrences
+			updates = append(updates, *alert)
+		}
+	}
+	d.mu.Unlock()
+
+	sort.Slice(updates, func(i, j int) bool {
+		return updates[i].Occurrences > updates[j].Occurrences
+	})
+
+	for _, alert := range updates {
+		title := fmt.Sprintf("Still failing: %s (%d times)", alert.subject(), alert.Occurrences)
+		text := fmt.Sprintf("First seen %s, last seen %s",
+			alert.FirstSeen.Format(time.RFC1123), alert.LastSeen.Format(time.RFC1123))
+		if alert.Kind == AlertPassRate {
+			title = fmt.Sprintf("Still below threshold: %s (%d runs)", alert.subject(), alert.Occurrences)
+			text = alert.ErrorMessage + "\n" + text
+		}
+		d.send(Message{
+			Title: title,
+			Text:  text,
+			URL:   d.executionURL(alert.LastExecutionID),
+		})
+	}
+}
+
+func (d *Digest) executionURL(id string) string {
+	if d.baseURL == "" || id == "" {
+		return ""
+	}
+	return fmt.Sprintf("%s/executions/%s", d.baseURL, id)
+}
+
+func (d *Digest) send(msg Message) {
+	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+	defer cancel()
+	if err := d.notifier.Send(ctx, msg); err != nil {
+		log.Printf("Error sending notification %q: %v", msg.Title, err)
+	}
+}
@@ -0,0 +1,119 @@
+package notify
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/testkube/dashboard/internal/database"
+	"github.com/testkube/dashboard/internal/testkube"
+)
+
+type recordingNotifier struct {
+	messages []Message
+	mu       sync.Mutex
+}
+
+func (n *recordingNotifier) Send(ctx context.Context, msg Message) error {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.messages = append(n.messages, msg)
+	return nil
+}
+
+func TestDigestCollapsesRecurringFailures(t *testing.T) {
+	notifier := &recordingNotifier{}
+	d := newDigest(notifier)
+	now := time.Date(2026, 3, 2, 10, 0, 0, 0, time.UTC)
+	d.now = func() time.Time { return now }
+
+	failing := []database.TestCase{{TestName: "checkout", Status: "failed", ErrorMessage: "timeout"}}
+	for i := 0; i < 3; i++ {
+		d.Observe(testkube.Execution{
+			ID:           "exec-" + string(rune('a'+i)),
+			WorkflowName: "e2e",
+			Status:       "failed",
+			EndTime:      now.Add(time.Duration(i) * 10 * time.Minute),
+		}, failing)
+	}
+
+	if len(notifier.messages) != 1 || !strings.HasPrefix(notifier.messages[0].Title, "New failure") {
+		t.Fatalf("expected a single new-failure notification, got %+v", notifier.messages)
+	}
+
+	alerts := d.Alerts()
+	if len(alerts) != 1 || alerts[0].Occurrences != 3 || !alerts[0].FirstSeen.Equal(now) || !alerts[0].LastSeen.Equal(now.Add(20*time.Minute)) {
+		t.Fatalf("unexpected alerts: %+v", alerts)
+	}
+
+	d.Flush()
+	if len(notifier.messages) != 2 || !strings.Contains(notifier.messages[1].Title, "3 times") {
+		t.Fatalf("expected rolling update with occurrence count, got %+v", notifier.messages)
+	}
+
+	// Nothing new since the last update
+	d.Flush()
+	if len(notifier.messages) != 2 {
+		t.Fatalf("expected no repeat update, got %d messages", len(notifier.messages))
+	}
+
+	// Passing again closes the alert
+	d.Observe(testkube.Execution{ID: "exec-d", WorkflowName: "e2e", Status: "passed"},
+		[]database.TestCase{{TestName: "checkout", Status: "passed"}})
+	if len(d.Alerts()) != 0 {
+		t.Fatal("expected alert to close after the test passed")
+	}
+}
+
+func TestDigestExpiresQuietAlerts(t *testing.T) {
+	d := newDigest(&recordingNotifier{})
+	now := time.Date(2026, 3, 2, 10, 0, 0, 0, time.UTC)
+	d.now = func() time.Time { return now }
+
+	d.Observe(testkube.Execution{ID: "e1", WorkflowName: "api", Status: "failed", EndTime: now}, nil)
+	if alerts := d.Alerts(); len(alerts) != 1 || alerts[0].TestName != "" {
+		t.Fatalf("expected workflow-level alert, got %+v", alerts)
+	}
+
+	now = now.Add(d.ttl + time.Minute)
+	d.Flush()
+	if len(d.Alerts()) != 0 {
+		t.Fatal("expected quiet alert to expire")
+	}
+}
+
+func TestDigestPassRateAlerts(t *testing.T) {
+	notifier := &recordingNotifier{}
+	d := newDigest(notifier)
+	now := time.Date(2026, 3, 2, 10, 0, 0, 0, time.UTC)
+	d.now = func() time.Time { return now }
+
+	exec := testkube.Execution{ID: "e1", WorkflowName: "load", Status: "failed", EndTime: now}
+	d.ObservePassRate(exec, true, "Pass rate of load is 40.0% today")
+	exec.ID = "e2"
+	d.ObservePassRate(exec, true, "Pass rate of load is 35.0% today")
+	if len(notifier.messages) != 1 || !strings.HasPrefix(notifier.messages[0].Title, "Pass rate dropped") {
+		t.Fatalf("expected a single pass rate notification, got %+v", notifier.messages)
+	}
+	alerts := d.Alerts()
+	if len(alerts) != 1 || alerts[0].Kind != AlertPassRate || alerts[0].Occurrences != 2 || alerts[0].ErrorMessage != "Pass rate of load is 35.0% today" {
+		t.Fatalf("unexpected alerts: %+v", alerts)
+	}
+
+	d.Flush()
+	if len(notifier.messages) != 2 || !strings.Contains(notifier.messages[1].Text, "35.0%") {
+		t.Fatalf("expected rolling update with the latest pass rate, got %+v", notifier.messages)
+	}
+
+	// Test failures and the pass rate alert are tracked apart
+	d.Observe(exec, nil)
+	if len(d.Alerts()) != 2 {
+		t.Fatalf("expected a failure alert next to the pass rate alert, got %+v", d.Alerts())
+	}
+	d.ObservePassRate(exec, false, "")
+	if alerts := d.Alerts(); len(alerts) != 1 || alerts[0].Kind != "" {
+		t.Fatalf("expected the pass rate alert to close, got %+v", alerts)
+	}
+}
@@ -0,0 +1,72 @@
+package notify
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/smtp"
+	"os"
+	"strings"
+	"time"
+)
+
+// EmailNotifier sends messages as plain text email through an SMTP relay
+type EmailNotifier struct {
+	addr string
+	from string
+	to   []string
+	auth smtp.Auth
+	// sendMail is smtp.SendMail, replaced in tests
+	sendMail func(addr string, a smtp.Auth, from string, to []string, msg []byte) error
+}
+
+// NewEmailNotifierFromEnv returns a notifier emailing the recipients through
+// the relay at SMTP_ADDR (host:port) from SMTP_FROM, logging in with
+// SMTP_USERNAME and SMTP_PASSWORD when set. It returns nil when SMTP_ADDR
+// or the recipients are missing.
+func NewEmailNotifierFromEnv(to []string) *EmailNotifier {
+	addr := os.Getenv("SMTP_ADDR")
+	if addr == "" || len(to) == 0 {
+		return nil
+	}
+	n := &EmailNotifier{addr: addr, from: os.Getenv("SMTP_FROM"), to: to, sendMail: smtp.SendMail}
+	if n.from == "" {
+		n.from = "testkube-dashboard@localhost"
+	}
+	if user := os.Getenv("SMTP_USERNAME"); user != "" {
+		host, _, _ := net.SplitHostPort(addr)
+		n.auth = smtp.PlainAuth("", user, os.Getenv("SMTP_PASSWORD"), host)
+	}
+	return n
+}
+
+// Send emails the message to every recipient. The SMTP client can't be
+// cancelled, so ctx is only checked before sending.
+func (n *EmailNotifier) Send(ctx context.Context, msg Message) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	body := msg.Text
+	if msg.URL != "" {
+		body += "\n\n" + msg.URL
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "From: %s\r\n", n.from)
+	fmt.Fprintf(&b, "To: %s\r\n", strings.Join(n.to, ", "))
+	fmt.Fprintf(&b, "Subject: %s\r\n", headerValue(msg.Title))
+	fmt.Fprintf(&b, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	b.WriteString("MIME-Version: 1.0\r\n")
+	b.WriteString("Content-Type: text/plain; charset=utf-8\r\n\r\n")
+	b.WriteString(strings.ReplaceAll(body, "\n", "\r\n") + "\r\n")
+
+	if err := n.sendMail(n.addr, n.auth, n.from, n.to, []byte(b.String())); err != nil {
+		return fmt.Errorf("failed to send email: %w", err)
+	}
+	return nil
+}
+
+// headerValue keeps a title on one header line
+func headerValue(s string) string {
+	return strings.NewReplacer("\r", " ", "\n", " ").Replace(s)
+}
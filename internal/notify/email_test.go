@@ -0,0 +1,35 @@
+package notify
+
+import (
+	"context"
+	"net/smtp"
+	"strings"
+	"testing"
+)
+
+func TestEmailNotifier(t *testing.T) {
+	if NewEmailNotifierFromEnv([]string{"lead@example.com"}) != nil {
+		t.Fatal("expected no notifier without SMTP_ADDR")
+	}
+	t.Setenv("SMTP_ADDR", "smtp.example.com:587")
+	t.Setenv("SMTP_FROM", "dashboard@example.com")
+	n := NewEmailNotifierFromEnv([]string{"lead@example.com", "qa@example.com"})
+
+	var sent string
+	var recipients []string
+	n.sendMail = func(addr string, a smtp.Auth, from string, to []string, msg []byte) error {
+		sent, recipients = string(msg), to
+		return nil
+	}
+	if err := n.Send(context.Background(), Message{Title: "Weekly report\r\nBcc: evil@example.com", Text: "120 runs", URL: "https://dash/reports/weekly/2026-05-11"}); err != nil {
+		t.Fatal(err)
+	}
+	if len(recipients) != 2 {
+		t.Errorf("got recipients %v, expected both", recipients)
+	}
+	for _, want := range []string{"From: dashboard@example.com\r\n", "To: lead@example.com, qa@example.com\r\n", "Subject: Weekly report  Bcc: evil@example.com\r\n", "120 runs\r\n\r\nhttps://dash/reports/weekly/2026-05-11"} {
+		if !strings.Contains(sent, want) {
+			t.Errorf("message missing %q:\n%s", want, sent)
+		}
+	}
+}
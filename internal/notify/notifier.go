@@ -0,0 +1,75 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"time"
+)
+
+// Message is a channel notification
+type Message struct {
+	Title string
+	Text  string
+	URL   string
+}
+
+// Notifier delivers messages to a channel
+type Notifier interface {
+	Send(ctx context.Context, msg Message) error
+}
+
+// WebhookNotifier posts messages to a Slack-compatible incoming webhook
+type WebhookNotifier struct {
+	url        string
+	httpClient *http.Client
+}
+
+func NewWebhookNotifier(url string) *WebhookNotifier {
+	return &WebhookNotifier{
+		url:        url,
+		httpClient: &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// NewNotifierFromEnv returns a webhook notifier for NOTIFY_WEBHOOK_URL, or
+// nil when notifications are not configured.
+func NewNotifierFromEnv() Notifier {
+	url := os.Getenv("NOTIFY_WEBHOOK_URL")
+	if url == "" {
+		return nil
+	}
+	return NewWebhookNotifier(url)
+}
+
+func (n *WebhookNotifier) Send(ctx context.Context, msg Message) error {
+	text := fmt.Sprintf("*%s*\n%s", msg.Title, msg.Text)
+	if msg.URL != "" {
+		text += "\n" + msg.URL
+	}
+
+	body, err := json.Marshal(map[string]string{"text": text})
+	if err != nil {
+		return fmt.Errorf("failed to encode message: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", n.url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := n.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send notification: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook returned status %d", resp.StatusCode)
+	}
+	return nil
+}
@@ -0,0 +1,119 @@
+// Package pagecache keeps rendered pages for a few seconds, so that many
+// people refreshing the dashboard during an incident don't each repeat its
+// database queries and chart rendering. Pages are dropped as soon as new
+// executions are ingested, so the TTL only bounds how stale other changes
+// can look.
+package pagecache
+
+import (
+	"log"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+)
+
+const (
+	// DefaultTTL is how long a page is served from the cache
+	DefaultTTL = 15 * time.Second
+	// maxPages bounds the cache; the oldest page makes room for a new one
+	maxPages = 500
+)
+
+// Page is a rendered response
+type Page struct {
+	Status   int
+	Header   http.Header
+	Body     []byte
+	StoredAt time.Time
+}
+
+// Cache holds pages by key
+type Cache struct {
+	ttl   time.Duration
+	mu    sync.Mutex
+	pages map[string]Page
+	now   func() time.Time
+}
+
+// NewCache creates an empty cache. The TTL is read from PAGE_CACHE_TTL,
+// e.g. "30s", and "0" turns caching off.
+func NewCache() *Cache {
+	ttl := DefaultTTL
+	if val := os.Getenv("PAGE_CACHE_TTL"); val != "" {
+		if d, err := time.ParseDuration(val); err == nil && d >= 0 {
+			ttl = d
+		} else {
+			log.Printf("Warning: invalid PAGE_CACHE_TTL %q, using %s", val, DefaultTTL)
+		}
+	}
+	return &Cache{ttl: ttl, pages: make(map[string]Page), now: time.Now}
+}
+
+// Enabled reports whether pages are cached at all
+func (c *Cache) Enabled() bool {
+	return c.ttl > 0
+}
+
+// Get returns the page stored under key, unless it has expired
+func (c *Cache) Get(key string) (Page, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	page, ok := c.pages[key]
+	if !ok {
+		return Page{}, false
+	}
+	if c.now().Sub(page.StoredAt) >= c.ttl {
+		delete(c.pages, key)
+		return Page{}, false
+	}
+	return page, true
+}
+
+// Put stores a page under key
+func (c *Cache) Put(key string, page Page) {
+	if !c.Enabled() {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	now := c.now()
+	page.StoredAt = now
+	if _, ok := c.pages[key]; !ok && len(c.pages) >= maxPages {
+		c.evict(now)
+	}
+	c.pages[key] = page
+}
+
+// evict drops the expired pages, or the oldest one when none have
+// expired. The caller holds the lock.
+func (c *Cache) evict(now time.Time) {
+	var oldest string
+	for key, page := range c.pages {
+		if now.Sub(page.StoredAt) >= c.ttl {
+			delete(c.pages, key)
+			continue
+		}
+		if oldest == "" || page.StoredAt.Before(c.pages[oldest].StoredAt) {
+			oldest = key
+		}
+	}
+	if len(c.pages) >= maxPages {
+		delete(c.pages, oldest)
+	}
+}
+
+// Invalidate drops every page, e.g. when new executions change what they
+// show
+func (c *Cache) Invalidate() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	clear(c.pages)
+}
+
+// Len is the number of pages stored, including expired ones not yet dropped
+func (c *Cache) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.pages)
+}
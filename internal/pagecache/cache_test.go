@@ -0,0 +1,64 @@
+package pagecache
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestCache(t *testing.T) {
+	c := NewCache()
+	now := time.Date(2025, 3, 14, 12, 0, 0, 0, time.UTC)
+	c.now = func() time.Time { return now }
+
+	c.Put("/workflows", Page{Status: 200, Body: []byte("list")})
+	page, ok := c.Get("/workflows")
+	if !ok || string(page.Body) != "list" {
+		t.Errorf("got %q, %v, expected the stored page", page.Body, ok)
+	}
+	if _, ok := c.Get("/"); ok {
+		t.Error("expected no page for a key never stored")
+	}
+
+	now = now.Add(DefaultTTL)
+	if _, ok := c.Get("/workflows"); ok {
+		t.Error("expected the page to expire after the TTL")
+	}
+
+	c.Put("/workflows", Page{Status: 200})
+	c.Put("/", Page{Status: 200})
+	c.Invalidate()
+	if c.Len() != 0 {
+		t.Errorf("got %d pages after invalidating, expected 0", c.Len())
+	}
+}
+
+func TestCacheEviction(t *testing.T) {
+	c := NewCache()
+	now := time.Date(2025, 3, 14, 12, 0, 0, 0, time.UTC)
+	c.now = func() time.Time { return now }
+
+	for i := 0; i < maxPages; i++ {
+		c.Put(fmt.Sprintf("/page/%d", i), Page{Status: 200})
+		now = now.Add(time.Millisecond)
+	}
+	c.Put("/new", Page{Status: 200})
+	if c.Len() != maxPages {
+		t.Errorf("got %d pages, expected %d", c.Len(), maxPages)
+	}
+	if _, ok := c.Get("/page/0"); ok {
+		t.Error("expected the oldest page to make room")
+	}
+	if _, ok := c.Get("/new"); !ok {
+		t.Error("expected the new page to be stored")
+	}
+}
+
+func TestCacheDisabled(t *testing.T) {
+	t.Setenv("PAGE_CACHE_TTL", "0")
+	c := NewCache()
+	c.Put("/", Page{Status: 200})
+	if c.Enabled() || c.Len() != 0 {
+		t.Errorf("got %d pages, expected caching to be off", c.Len())
+	}
+}
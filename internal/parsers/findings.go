@@ -0,0 +1,93 @@
+package parsers
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// Finding is one issue reported by a security scanner
+type Finding struct {
+	ID       string
+	Severity string
+	Title    string
+	// Target is the image, file or resource the finding is in, when the
+	// report groups findings by one
+	Target string
+}
+
+// Critical reports whether the finding's severity is critical
+func (f Finding) Critical() bool {
+	return strings.EqualFold(f.Severity, "critical")
+}
+
+// Keys scanners use for a finding's identifier and title, in order of
+// preference
+var (
+	findingIDKeys     = []string{"VulnerabilityID", "ID", "id", "rule_id", "check_id", "controlID", "key"}
+	findingTitleKeys  = []string{"Title", "title", "name", "message", "Description", "description"}
+	findingTargetKeys = []string{"Target", "target", "file_path", "path", "component"}
+)
+
+// ParseFindings lists the findings in a security scanner's JSON report.
+// Scanners nest findings differently (Trivy under
+// Results[].Vulnerabilities, DefectDojo under findings, ...) but agree on a
+// severity field, so any object with a "severity" is a finding.
+func ParseFindings(data []byte) ([]Finding, error) {
+	var report interface{}
+	if err := json.Unmarshal(data, &report); err != nil {
+		return nil, fmt.Errorf("failed to parse security report: %w", err)
+	}
+	return appendFindings(nil, report, ""), nil
+}
+
+// CountCriticalFindings counts the critical findings in a security scanner's
+// JSON report
+func CountCriticalFindings(data []byte) (int, error) {
+	findings, err := ParseFindings(data)
+	if err != nil {
+		return 0, err
+	}
+	count := 0
+	for _, f := range findings {
+		if f.Critical() {
+			count++
+		}
+	}
+	return count, nil
+}
+
+func appendFindings(findings []Finding, v interface{}, target string) []Finding {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		if t := firstString(v, findingTargetKeys); t != "" {
+			target = t
+		}
+		for key, value := range v {
+			if severity, ok := value.(string); ok && strings.EqualFold(key, "severity") {
+				findings = append(findings, Finding{
+					ID:       firstString(v, findingIDKeys),
+					Severity: severity,
+					Title:    firstString(v, findingTitleKeys),
+					Target:   target,
+				})
+				continue
+			}
+			findings = appendFindings(findings, value, target)
+		}
+	case []interface{}:
+		for _, item := range v {
+			findings = appendFindings(findings, item, target)
+		}
+	}
+	return findings
+}
+
+func firstString(m map[string]interface{}, keys []string) string {
+	for _, key := range keys {
+		if s, ok := m[key].(string); ok && s != "" {
+			return s
+		}
+	}
+	return ""
+}
@@ -0,0 +1,68 @@
+package parsers
+
+import "testing"
+
+func TestCountCriticalFindings(t *testing.T) {
+	trivy := `{
+  "Results": [
+    {"Target": "alpine:3.18", "Vulnerabilities": [
+      {"VulnerabilityID": "CVE-2024-0001", "Severity": "CRITICAL"},
+      {"VulnerabilityID": "CVE-2024-0002", "Severity": "HIGH"}
+    ]},
+    {"Target": "deployment.yaml", "Misconfigurations": [
+      {"ID": "KSV001", "Severity": "CRITICAL"}
+    ]}
+  ]
+}`
+	count, err := CountCriticalFindings([]byte(trivy))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if count != 2 {
+		t.Errorf("got %d critical findings, expected 2", count)
+	}
+
+	count, err = CountCriticalFindings([]byte(`{"findings": [{"title": "SQL injection", "severity": "Critical"}, {"severity": "Low"}]}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if count != 1 {
+		t.Errorf("got %d critical findings, expected 1", count)
+	}
+
+	if _, err := CountCriticalFindings([]byte("not json")); err == nil {
+		t.Error("expected an error for a report that isn't JSON")
+	}
+}
+
+func TestParseFindings(t *testing.T) {
+	trivy := `{
+  "Results": [
+    {"Target": "alpine:3.18", "Vulnerabilities": [
+      {"VulnerabilityID": "CVE-2024-0001", "Severity": "CRITICAL", "Title": "openssl: buffer overflow"}
+    ]}
+  ]
+}`
+	findings, err := ParseFindings([]byte(trivy))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(findings) != 1 {
+		t.Fatalf("got %d findings, expected 1", len(findings))
+	}
+	expected := Finding{ID: "CVE-2024-0001", Severity: "CRITICAL", Title: "openssl: buffer overflow", Target: "alpine:3.18"}
+	if findings[0] != expected {
+		t.Errorf("got %+v, expected %+v", findings[0], expected)
+	}
+	if !findings[0].Critical() {
+		t.Error("expected a CRITICAL finding to be critical")
+	}
+
+	findings, err = ParseFindings([]byte(`{"results": [{"check_id": "go.lang.sql-injection", "path": "db.go", "extra": {"severity": "ERROR", "message": "Tainted query"}}]}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(findings) != 1 || findings[0].Title != "Tainted query" || findings[0].Target != "db.go" {
+		t.Errorf("got %+v, expected the semgrep finding with its message and file", findings)
+	}
+}
@@ -0,0 +1,140 @@
+package parsers
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// K6Summary is the headline numbers of a k6 load test, from the file
+// written by `k6 run --summary-export` or a handleSummary JSON report.
+// Durations are in milliseconds.
+type K6Summary struct {
+	Requests     int
+	RequestRate  float64 // per second
+	FailedRate   float64 // fraction of requests that failed, 0 to 1
+	AvgDuration  float64
+	P90Duration  float64
+	P95Duration  float64
+	MaxDuration  float64
+	Iterations   int
+	MaxVUs       int
+	ChecksPassed int
+	ChecksFailed int
+	// Thresholds maps each metric threshold to whether it was crossed
+	Thresholds map[string]bool
+}
+
+// FailedPercent is the percentage of requests that failed
+func (s *K6Summary) FailedPercent() float64 {
+	return s.FailedRate * 100
+}
+
+// k6Metric is one metric of a summary. Which values are set depends on the
+// metric's type (counter, rate, trend or gauge). Summary exports put them
+// on the metric itself, handleSummary reports under "values".
+type k6Metric struct {
+	flat   map[string]float64
+	Values map[string]float64 `json:"values"`
+	// Thresholds are a bool, whether crossed, in summary exports and an
+	// object saying whether they're ok in handleSummary reports
+	Thresholds map[string]json.RawMessage `json:"thresholds"`
+}
+
+func (m *k6Metric) UnmarshalJSON(data []byte) error {
+	type metric k6Metric
+	if err := json.Unmarshal(data, (*metric)(m)); err != nil {
+		return err
+	}
+	var fields map[string]interface{}
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return err
+	}
+	m.flat = make(map[string]float64)
+	for key, value := range fields {
+		if f, ok := value.(float64); ok {
+			m.flat[key] = f
+		}
+	}
+	return nil
+}
+
+func (m k6Metric) value(key string) float64 {
+	if v, ok := m.Values[key]; ok {
+		return v
+	}
+	return m.flat[key]
+}
+
+// crossed lists whether each of the metric's thresholds was crossed
+func (m k6Metric) crossed() map[string]bool {
+	crossed := make(map[string]bool, len(m.Thresholds))
+	for threshold, raw := range m.Thresholds {
+		var failed bool
+		if err := json.Unmarshal(raw, &failed); err == nil {
+			crossed[threshold] = failed
+			continue
+		}
+		var result struct {
+			OK bool `json:"ok"`
+		}
+		if err := json.Unmarshal(raw, &result); err == nil {
+			crossed[threshold] = !result.OK
+		}
+	}
+	return crossed
+}
+
+type k6Export struct {
+	Metrics map[string]k6Metric `json:"metrics"`
+}
+
+// IsK6Summary reports whether data looks like a k6 summary
+func IsK6Summary(data []byte) bool {
+	var probe struct {
+		Metrics map[string]json.RawMessage `json:"metrics"`
+	}
+	if err := json.Unmarshal(data, &probe); err != nil {
+		return false
+	}
+	_, ok := probe.Metrics["http_req_duration"]
+	if !ok {
+		_, ok = probe.Metrics["iterations"]
+	}
+	return ok
+}
+
+// ParseK6Summary reads a k6 summary
+func ParseK6Summary(data []byte) (*K6Summary, error) {
+	var export k6Export
+	if err := json.Unmarshal(data, &export); err != nil {
+		return nil, fmt.Errorf("failed to parse k6 summary: %w", err)
+	}
+
+	m := export.Metrics
+	summary := &K6Summary{
+		Requests:     int(m["http_reqs"].value("count")),
+		RequestRate:  m["http_reqs"].value("rate"),
+		FailedRate:   m["http_req_failed"].value("value"),
+		AvgDuration:  m["http_req_duration"].value("avg"),
+		P90Duration:  m["http_req_duration"].value("p(90)"),
+		P95Duration:  m["http_req_duration"].value("p(95)"),
+		MaxDuration:  m["http_req_duration"].value("max"),
+		Iterations:   int(m["iterations"].value("count")),
+		MaxVUs:       int(m["vus_max"].value("value")),
+		ChecksPassed: int(m["checks"].value("passes")),
+		ChecksFailed: int(m["checks"].value("fails")),
+	}
+	if summary.FailedRate == 0 {
+		// handleSummary reports give a rate's value as "rate"
+		summary.FailedRate = m["http_req_failed"].value("rate")
+	}
+	for name, metric := range m {
+		for threshold, crossed := range metric.crossed() {
+			if summary.Thresholds == nil {
+				summary.Thresholds = make(map[string]bool)
+			}
+			summary.Thresholds[name+": "+threshold] = crossed
+		}
+	}
+	return summary, nil
+}
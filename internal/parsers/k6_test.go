@@ -0,0 +1,66 @@
+package parsers
+
+import "testing"
+
+const k6SummaryExport = `{
+  "metrics": {
+    "http_reqs": {"count": 1200, "rate": 40.5},
+    "http_req_failed": {"passes": 12, "fails": 1188, "value": 0.01},
+    "http_req_duration": {"avg": 120.5, "min": 20, "med": 100, "max": 900, "p(90)": 210, "p(95)": 340.2,
+      "thresholds": {"p(95)<500": false}},
+    "iterations": {"count": 600, "rate": 20},
+    "vus_max": {"value": 50, "min": 50, "max": 50},
+    "checks": {"passes": 590, "fails": 10, "value": 0.98}
+  }
+}`
+
+func TestParseK6Summary(t *testing.T) {
+	if !IsK6Summary([]byte(k6SummaryExport)) {
+		t.Fatal("expected a summary export to be detected")
+	}
+	if IsK6Summary([]byte(`{"suites": []}`)) {
+		t.Error("expected a Playwright report not to be detected")
+	}
+
+	summary, err := ParseK6Summary([]byte(k6SummaryExport))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if summary.Requests != 1200 || summary.RequestRate != 40.5 {
+		t.Errorf("got %d requests at %g/s, expected 1200 at 40.5/s", summary.Requests, summary.RequestRate)
+	}
+	if summary.P95Duration != 340.2 || summary.MaxDuration != 900 {
+		t.Errorf("got p95 %g and max %g, expected 340.2 and 900", summary.P95Duration, summary.MaxDuration)
+	}
+	if summary.FailedRate != 0.01 || summary.ChecksFailed != 10 || summary.MaxVUs != 50 {
+		t.Errorf("got %+v", summary)
+	}
+	if crossed, ok := summary.Thresholds["http_req_duration: p(95)<500"]; !ok || crossed {
+		t.Errorf("got thresholds %v, expected p(95)<500 not crossed", summary.Thresholds)
+	}
+
+	if _, err := ParseK6Summary([]byte("not json")); err == nil {
+		t.Error("expected an error for a summary that isn't JSON")
+	}
+}
+
+func TestParseK6HandleSummary(t *testing.T) {
+	report := `{"metrics": {
+  "http_req_duration": {"type": "trend", "values": {"min": 50, "max": 200, "avg": 120, "p(95)": 180},
+    "thresholds": {"p(95)<150": {"ok": false}}},
+  "http_req_failed": {"type": "rate", "values": {"rate": 0.05, "passes": 5, "fails": 95}}
+}}`
+	if !IsK6Summary([]byte(report)) {
+		t.Fatal("expected a handleSummary report to be detected")
+	}
+	summary, err := ParseK6Summary([]byte(report))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if summary.AvgDuration != 120 || summary.P95Duration != 180 || summary.FailedRate != 0.05 {
+		t.Errorf("got %+v, expected the nested values", summary)
+	}
+	if !summary.Thresholds["http_req_duration: p(95)<150"] {
+		t.Errorf("got thresholds %v, expected p(95)<150 crossed", summary.Thresholds)
+	}
+}
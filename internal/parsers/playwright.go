@@ -0,0 +1,138 @@
+package parsers
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/testkube/dashboard/internal/database"
+)
+
+// playwrightReport is the subset of Playwright's JSON reporter output we use
+type playwrightReport struct {
+	Suites []playwrightSuite `json:"suites"`
+}
+
+type playwrightSuite struct {
+	Title  string            `json:"title"`
+	File   string            `json:"file"`
+	Specs  []playwrightSpec  `json:"specs"`
+	Suites []playwrightSuite `json:"suites"`
+}
+
+type playwrightSpec struct {
+	Title string           `json:"title"`
+	File  string           `json:"file"`
+	Tests []playwrightTest `json:"tests"`
+}
+
+type playwrightTest struct {
+	ProjectName string             `json:"projectName"`
+	Status      string             `json:"status"` // expected, unexpected, flaky, skipped
+	Results     []playwrightResult `json:"results"`
+}
+
+type playwrightResult struct {
+	Status   string `json:"status"` // passed, failed, timedOut, skipped, interrupted
+	Duration int    `json:"duration"`
+	Retry    int    `json:"retry"`
+	Error    *struct {
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+// IsPlaywrightReport reports whether data looks like Playwright JSON reporter output
+func IsPlaywrightReport(data []byte) bool {
+	var probe struct {
+		Suites []json.RawMessage `json:"suites"`
+	}
+	if err := json.Unmarshal(data, &probe); err != nil {
+		return false
+	}
+	return probe.Suites != nil
+}
+
+// ParsePlaywright converts a Playwright JSON report into test cases. Each
+// test's final attempt determines its status, and RetryCount records how
+// many extra attempts the runner needed. A test's suite is its project,
+// if named, then its file and describe blocks.
+func ParsePlaywright(executionID string, data []byte) ([]database.TestCase, error) {
+	var report playwrightReport
+	if err := json.Unmarshal(data, &report); err != nil {
+		return nil, fmt.Errorf("failed to parse playwright report: %w", err)
+	}
+
+	var cases []database.TestCase
+	for _, suite := range report.Suites {
+		cases = appendSuite(cases, executionID, suite, nil)
+	}
+	return cases, nil
+}
+
+func appendSuite(cases []database.TestCase, executionID string, suite playwrightSuite, titles []string) []database.TestCase {
+	// The top-level suite title is the file name, which is stored separately
+	if suite.Title != "" && suite.Title != suite.File {
+		titles = append(titles, suite.Title)
+	}
+
+	for _, spec := range suite.Specs {
+		file := spec.File
+		if file == "" {
+			file = suite.File
+		}
+		name := strings.Join(append(append([]string{}, titles...), spec.Title), " > ")
+
+		for _, test := range spec.Tests {
+			if len(test.Results) == 0 {
+				continue
+			}
+			testName := name
+			var suite []string
+			if test.ProjectName != "" {
+				testName = fmt.Sprintf("[%s] %s", test.ProjectName, name)
+				suite = append(suite, test.ProjectName)
+			}
+			if file != "" {
+				suite = append(suite, file)
+			}
+			suite = append(suite, titles...)
+
+			final := test.Results[len(test.Results)-1]
+			tc := database.TestCase{
+				ExecutionID: executionID,
+				TestName:    testName,
+				FilePath:    file,
+				Status:      normalizePlaywrightStatus(final.Status),
+				DurationMs:  final.Duration,
+				RetryCount:  final.Retry,
+				Suite:       strings.Join(suite, SuiteSeparator),
+			}
+
+			// Keep the most recent failure message, even if a retry passed
+			for i := len(test.Results) - 1; i >= 0; i-- {
+				if test.Results[i].Error != nil {
+					tc.ErrorMessage = test.Results[i].Error.Message
+					break
+				}
+			}
+
+			cases = append(cases, tc)
+		}
+	}
+
+	for _, child := range suite.Suites {
+		cases = appendSuite(cases, executionID, child, titles)
+	}
+	return cases
+}
+
+func normalizePlaywrightStatus(status string) string {
+	switch status {
+	case "passed":
+		return "passed"
+	case "skipped":
+		return "skipped"
+	default:
+		return "failed"
+	}
+}
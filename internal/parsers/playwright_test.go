@@ -0,0 +1,67 @@
+package parsers
+
+import "testing"
+
+const playwrightReportJSON = `{
+  "config": {},
+  "suites": [{
+    "title": "login.spec.ts",
+    "file": "login.spec.ts",
+    "specs": [{
+      "title": "logs in with password",
+      "file": "login.spec.ts",
+      "tests": [{
+        "projectName": "chromium",
+        "status": "flaky",
+        "results": [
+          {"status": "failed", "duration": 3000, "retry": 0, "error": {"message": "Timeout 3000ms exceeded"}},
+          {"status": "passed", "duration": 1200, "retry": 1}
+        ]
+      }]
+    }],
+    "suites": [{
+      "title": "oauth",
+      "file": "login.spec.ts",
+      "specs": [{
+        "title": "redirects to provider",
+        "tests": [{"status": "expected", "results": [{"status": "passed", "duration": 800, "retry": 0}]}]
+      }]
+    }]
+  }]
+}`
+
+func TestParsePlaywright(t *testing.T) {
+	if !IsPlaywrightReport([]byte(playwrightReportJSON)) {
+		t.Fatal("expected report to be detected as Playwright")
+	}
+	if IsPlaywrightReport([]byte(`{"metrics": {}}`)) {
+		t.Error("k6 summary should not be detected as Playwright")
+	}
+
+	cases, err := ParsePlaywright("exec-1", []byte(playwrightReportJSON))
+	if err != nil {
+		t.Fatalf("ParsePlaywright failed: %v", err)
+	}
+	if len(cases) != 2 {
+		t.Fatalf("expected 2 test cases, got %d", len(cases))
+	}
+
+	retried := cases[0]
+	if retried.TestName != "[chromium] logs in with password" {
+		t.Errorf("unexpected test name: %q", retried.TestName)
+	}
+	if retried.Status != "passed" || retried.RetryCount != 1 {
+		t.Errorf("expected passed after 1 retry, got %s after %d", retried.Status, retried.RetryCount)
+	}
+	if retried.ErrorMessage != "Timeout 3000ms exceeded" {
+		t.Errorf("expected failure message from first attempt, got %q", retried.ErrorMessage)
+	}
+
+	nested := cases[1]
+	if nested.TestName != "oauth > redirects to provider" || nested.FilePath != "login.spec.ts" {
+		t.Errorf("unexpected nested test: %+v", nested)
+	}
+	if retried.Suite != "chromium > login.spec.ts" || nested.Suite != "login.spec.ts > oauth" {
+		t.Errorf("unexpected suites: %q and %q", retried.Suite, nested.Suite)
+	}
+}
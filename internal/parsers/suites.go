@@ -0,0 +1,53 @@
+package parsers
+
+import (
+	"strings"
+
+	"github.com/testkube/dashboard/internal/database"
+)
+
+// SuiteSeparator joins the names in a suite's path
+const SuiteSeparator = " > "
+
+// BuildSuites rolls test cases up into the suites they're in and those
+// suites are nested in, each suite before its nested ones, in the order
+// their first tests ran. Cases outside any suite are left out.
+func BuildSuites(executionID string, cases []database.TestCase) []database.TestSuite {
+	var suites []database.TestSuite
+	index := make(map[string]int)
+	for _, tc := range cases {
+		if tc.Suite == "" {
+			continue
+		}
+		names := strings.Split(tc.Suite, SuiteSeparator)
+		for depth := range names {
+			path := strings.Join(names[:depth+1], SuiteSeparator)
+			i, ok := index[path]
+			if !ok {
+				suite := database.TestSuite{ExecutionID: executionID, Path: path, Name: names[depth], Depth: depth}
+				if depth > 0 {
+					suite.Parent = strings.Join(names[:depth], SuiteSeparator)
+				}
+				i = len(suites)
+				index[path] = i
+				suites = append(suites, suite)
+			}
+			count(&suites[i], tc)
+		}
+	}
+	return suites
+}
+
+// count adds a test case to a suite's results
+func count(suite *database.TestSuite, tc database.TestCase) {
+	suite.Total++
+	suite.DurationMs += tc.DurationMs
+	switch tc.Status {
+	case "passed":
+		suite.Passed++
+	case "skipped":
+		suite.Skipped++
+	default:
+		suite.Failed++
+	}
+}
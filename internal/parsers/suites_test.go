@@ -0,0 +1,46 @@
+package parsers
+
+import (
+	"testing"
+
+	"github.com/testkube/dashboard/internal/database"
+)
+
+func TestBuildSuites(t *testing.T) {
+	cases := []database.TestCase{
+		{TestName: "adds", Status: "passed", DurationMs: 10, Suite: "unit > math.spec.ts"},
+		{TestName: "divides", Status: "failed", DurationMs: 20, Suite: "unit > math.spec.ts"},
+		{TestName: "logs in", Status: "passed", DurationMs: 300, Suite: "integration > login.spec.ts > oauth"},
+		{TestName: "logs out", Status: "skipped", Suite: "integration > login.spec.ts"},
+		{TestName: "outside", Status: "failed"},
+	}
+
+	suites := BuildSuites("exec-1", cases)
+	var paths []string
+	for _, s := range suites {
+		paths = append(paths, s.Path)
+	}
+	expected := []string{"unit", "unit > math.spec.ts", "integration", "integration > login.spec.ts", "integration > login.spec.ts > oauth"}
+	if len(paths) != len(expected) {
+		t.Fatalf("got %v, expected %v", paths, expected)
+	}
+	for i := range expected {
+		if paths[i] != expected[i] {
+			t.Errorf("got %q, expected %q", paths[i], expected[i])
+		}
+	}
+
+	unit := suites[0]
+	if unit.Total != 2 || unit.Passed != 1 || unit.Failed != 1 || unit.DurationMs != 30 || unit.PassRate() != 50 {
+		t.Errorf("got %+v, expected 1 of 2 unit tests to pass", unit)
+	}
+	// A suite counts the tests of those nested in it
+	integration := suites[2]
+	if integration.Total != 2 || integration.Passed != 1 || integration.Skipped != 1 || integration.PassRate() != 100 {
+		t.Errorf("got %+v, expected the oauth test to count towards integration", integration)
+	}
+	oauth := suites[4]
+	if oauth.Name != "oauth" || oauth.Parent != "integration > login.spec.ts" || oauth.Depth != 2 || oauth.ExecutionID != "exec-1" {
+		t.Errorf("got %+v, expected oauth nested in login.spec.ts", oauth)
+	}
+}
@@ -0,0 +1,98 @@
+package previews
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+)
+
+// Commenter posts comments on pull requests
+type Commenter interface {
+	Comment(ctx context.Context, pr PullRequest, body string) error
+}
+
+// APICommenter comments through the GitHub and GitLab REST APIs
+type APICommenter struct {
+	GitHubURL   string
+	GitHubToken string
+	GitLabURL   string
+	GitLabToken string
+
+	httpClient *http.Client
+}
+
+// NewCommenterFromEnv returns a commenter for the providers with a token in
+// GITHUB_TOKEN or GITLAB_TOKEN, at GITHUB_API_URL and GITLAB_URL for
+// self-hosted installations, or nil when neither token is set
+func NewCommenterFromEnv() Commenter {
+	c := &APICommenter{
+		GitHubURL:   envOr("GITHUB_API_URL", "https://api.github.com"),
+		GitHubToken: os.Getenv("GITHUB_TOKEN"),
+		GitLabURL:   envOr("GITLAB_URL", "https://gitlab.com"),
+		GitLabToken: os.Getenv("GITLAB_TOKEN"),
+		httpClient:  &http.Client{Timeout: 10 * time.Second},
+	}
+	if c.GitHubToken == "" && c.GitLabToken == "" {
+		return nil
+	}
+	return c
+}
+
+func envOr(key, def string) string {
+	if val := os.Getenv(key); val != "" {
+		return strings.TrimSuffix(val, "/")
+	}
+	return def
+}
+
+func (c *APICommenter) Comment(ctx context.Context, pr PullRequest, body string) error {
+	var apiURL string
+	header := http.Header{"Content-Type": {"application/json"}}
+	switch pr.Provider {
+	case ProviderGitHub:
+		if c.GitHubToken == "" {
+			return fmt.Errorf("GITHUB_TOKEN is not set")
+		}
+		apiURL = fmt.Sprintf("%s/repos/%s/issues/%d/comments", c.GitHubURL, pr.Repository, pr.Number)
+		header.Set("Authorization", "Bearer "+c.GitHubToken)
+		header.Set("Accept", "application/vnd.github+json")
+	case ProviderGitLab:
+		if c.GitLabToken == "" {
+			return fmt.Errorf("GITLAB_TOKEN is not set")
+		}
+		apiURL = fmt.Sprintf("%s/api/v4/projects/%d/merge_requests/%d/notes", c.GitLabURL, pr.ProjectID, pr.Number)
+		header.Set("PRIVATE-TOKEN", c.GitLabToken)
+	default:
+		return fmt.Errorf("unknown provider %q", pr.Provider)
+	}
+
+	payload, err := json.Marshal(map[string]string{"body": body})
+	if err != nil {
+		return fmt.Errorf("failed to encode comment: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, "POST", apiURL, bytes.NewReader(payload))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header = header
+
+	httpClient := c.httpClient
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to comment: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("%s returned status %d", pr.Provider, resp.StatusCode)
+	}
+	return nil
+}
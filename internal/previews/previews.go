@@ -0,0 +1,265 @@
+// Package previews turns pull requests into preview environments: it reads
+// GitHub and GitLab pull request webhooks and comments on the pull request
+// once its environment is up.
+package previews
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+)
+
+const (
+	ProviderGitHub = "github"
+	ProviderGitLab = "gitlab"
+
+	// DefaultTTL is how long a preview environment lives after the last push
+	// to its pull request, unless PREVIEW_TTL says otherwise
+	DefaultTTL = 72 * time.Hour
+
+	// maxPayloadSize bounds webhook bodies
+	maxPayloadSize = 5 * 1024 * 1024
+	// maxNameLength keeps environment names, and the hostnames made from
+	// them, within a DNS label
+	maxNameLength = 40
+)
+
+var (
+	// ErrInvalidSignature is returned for webhooks not signed with the secret
+	ErrInvalidSignature = errors.New("invalid webhook signature")
+	// ErrUnsupportedEvent is returned for webhooks about anything other than
+	// pull requests
+	ErrUnsupportedEvent = errors.New("not a pull request event")
+)
+
+// Action is what a pull request event means for its preview environment
+type Action string
+
+const (
+	// ActionDeploy creates the environment, or keeps it alive on a push
+	ActionDeploy Action = "deploy"
+	// ActionTeardown deletes the environment of a merged or closed pull request
+	ActionTeardown Action = "teardown"
+	// ActionIgnore is for events that don't affect the environment, such as
+	// labels or reviews
+	ActionIgnore Action = "ignore"
+)
+
+// PullRequest is a GitHub pull request or GitLab merge request
+type PullRequest struct {
+	Provider   string `json:"provider"`
+	Repository string `json:"repository"` // e.g. "org/repo"
+	ProjectID  int    `json:"projectId,omitempty"`
+	Number     int    `json:"number"`
+	URL        string `json:"url"`
+	Branch     string `json:"branch"`
+	Commit     string `json:"commit,omitempty"`
+	Author     string `json:"author,omitempty"`
+}
+
+// EnvironmentName is the name of the pull request's preview environment,
+// e.g. "pr-42-shop"
+func (pr PullRequest) EnvironmentName() string {
+	repo := pr.Repository
+	if i := strings.LastIndex(repo, "/"); i >= 0 {
+		repo = repo[i+1:]
+	}
+	var b strings.Builder
+	for _, r := range strings.ToLower(repo) {
+		if (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') {
+			b.WriteRune(r)
+		} else {
+			b.WriteRune('-')
+		}
+	}
+	name := fmt.Sprintf("pr-%d-%s", pr.Number, strings.Trim(b.String(), "-"))
+	if len(name) > maxNameLength {
+		name = name[:maxNameLength]
+	}
+	return strings.TrimRight(name, "-")
+}
+
+// Event is a pull request webhook
+type Event struct {
+	Action      Action      `json:"action"`
+	PullRequest PullRequest `json:"pullRequest"`
+}
+
+// Config is how pull requests become preview environments
+type Config struct {
+	// Secret verifies webhooks: GitHub signs with it, GitLab sends it as
+	// the token. Without one, webhooks are refused.
+	Secret string
+	// Template is the environment template previews use; empty is the default
+	Template string
+	TTL      time.Duration
+}
+
+// ConfigFromEnv reads PR_WEBHOOK_SECRET, PREVIEW_TEMPLATE and PREVIEW_TTL
+func ConfigFromEnv() Config {
+	cfg := Config{
+		Secret:   os.Getenv("PR_WEBHOOK_SECRET"),
+		Template: os.Getenv("PREVIEW_TEMPLATE"),
+		TTL:      DefaultTTL,
+	}
+	if val := os.Getenv("PREVIEW_TTL"); val != "" {
+		if d, err := time.ParseDuration(val); err == nil && d > 0 {
+			cfg.TTL = d
+		} else {
+			log.Printf("Warning: invalid PREVIEW_TTL %q, using %s", val, cfg.TTL)
+		}
+	}
+	return cfg
+}
+
+// Enabled reports whether webhooks are accepted
+func (c Config) Enabled() bool {
+	return c.Secret != ""
+}
+
+// ParseWebhook verifies a GitHub or GitLab webhook against the secret and
+// reads the pull request event in it
+func ParseWebhook(r *http.Request, secret string) (*Event, error) {
+	body, err := io.ReadAll(io.LimitReader(r.Body, maxPayloadSize))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read webhook: %w", err)
+	}
+
+	switch {
+	case r.Header.Get("X-GitHub-Event") != "":
+		if !validGitHubSignature(body, r.Header.Get("X-Hub-Signature-256"), secret) {
+			return nil, ErrInvalidSignature
+		}
+		if r.Header.Get("X-GitHub-Event") != "pull_request" {
+			return nil, ErrUnsupportedEvent
+		}
+		return parseGitHub(body)
+	case r.Header.Get("X-Gitlab-Event") != "":
+		if subtle.ConstantTimeCompare([]byte(r.Header.Get("X-Gitlab-Token")), []byte(secret)) != 1 {
+			return nil, ErrInvalidSignature
+		}
+		if r.Header.Get("X-Gitlab-Event") != "Merge Request Hook" {
+			return nil, ErrUnsupportedEvent
+		}
+		return parseGitLab(body)
+	}
+	return nil, ErrUnsupportedEvent
+}
+
+func validGitHubSignature(body []byte, signature, secret string) bool {
+	sig, ok := strings.CutPrefix(signature, "sha256=")
+	if !ok {
+		return false
+	}
+	got, err := hex.DecodeString(sig)
+	if err != nil {
+		return false
+	}
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return hmac.Equal(got, mac.Sum(nil))
+}
+
+func parseGitHub(body []byte) (*Event, error) {
+	var payload struct {
+		Action      string `json:"action"`
+		PullRequest struct {
+			Number  int    `json:"number"`
+			HTMLURL string `json:"html_url"`
+			Head    struct {
+				Ref string `json:"ref"`
+				SHA string `json:"sha"`
+			} `json:"head"`
+			User struct {
+				Login string `json:"login"`
+			} `json:"user"`
+		} `json:"pull_request"`
+		Repository struct {
+			FullName string `json:"full_name"`
+		} `json:"repository"`
+	}
+	if err := json.Unmarshal(body, &payload); err != nil {
+		return nil, fmt.Errorf("invalid pull request event: %w", err)
+	}
+
+	pr := payload.PullRequest
+	event := &Event{
+		Action: ActionIgnore,
+		PullRequest: PullRequest{
+			Provider:   ProviderGitHub,
+			Repository: payload.Repository.FullName,
+			Number:     pr.Number,
+			URL:        pr.HTMLURL,
+			Branch:     pr.Head.Ref,
+			Commit:     pr.Head.SHA,
+			Author:     pr.User.Login,
+		},
+	}
+	switch payload.Action {
+	case "opened", "reopened", "synchronize":
+		event.Action = ActionDeploy
+	case "closed":
+		event.Action = ActionTeardown
+	}
+	return event, nil
+}
+
+func parseGitLab(body []byte) (*Event, error) {
+	var payload struct {
+		ObjectKind string `json:"object_kind"`
+		User       struct {
+			Username string `json:"username"`
+		} `json:"user"`
+		Project struct {
+			ID                int    `json:"id"`
+			PathWithNamespace string `json:"path_with_namespace"`
+		} `json:"project"`
+		Attributes struct {
+			IID          int    `json:"iid"`
+			URL          string `json:"url"`
+			SourceBranch string `json:"source_branch"`
+			Action       string `json:"action"`
+			LastCommit   struct {
+				ID string `json:"id"`
+			} `json:"last_commit"`
+		} `json:"object_attributes"`
+	}
+	if err := json.Unmarshal(body, &payload); err != nil {
+		return nil, fmt.Errorf("invalid merge request event: %w", err)
+	}
+	if payload.ObjectKind != "merge_request" {
+		return nil, ErrUnsupportedEvent
+	}
+
+	mr := payload.Attributes
+	event := &Event{
+		Action: ActionIgnore,
+		PullRequest: PullRequest{
+			Provider:   ProviderGitLab,
+			Repository: payload.Project.PathWithNamespace,
+			ProjectID:  payload.Project.ID,
+			Number:     mr.IID,
+			URL:        mr.URL,
+			Branch:     mr.SourceBranch,
+			Commit:     mr.LastCommit.ID,
+			Author:     payload.User.Username,
+		},
+	}
+	switch mr.Action {
+	case "open", "reopen", "update":
+		event.Action = ActionDeploy
+	case "close", "merge":
+		event.Action = ActionTeardown
+	}
+	return event, nil
+}
@@ -0,0 +1,140 @@
+package previews
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+const githubOpened = `{
+	"action": "opened",
+	"pull_request": {
+		"number": 42,
+		"html_url": "https://github.com/acme/Shop_Front/pull/42",
+		"head": {"ref": "feature/cart", "sha": "abc123"},
+		"user": {"login": "alice"}
+	},
+	"repository": {"full_name": "acme/Shop_Front"}
+}`
+
+func sign(body, secret string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(body))
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+func TestParseWebhook_GitHub(t *testing.T) {
+	req := httptest.NewRequest("POST", "/hooks/pr", strings.NewReader(githubOpened))
+	req.Header.Set("X-GitHub-Event", "pull_request")
+	req.Header.Set("X-Hub-Signature-256", sign(githubOpened, "s3cret"))
+
+	event, err := ParseWebhook(req, "s3cret")
+	if err != nil {
+		t.Fatalf("ParseWebhook failed: %v", err)
+	}
+	if event.Action != ActionDeploy {
+		t.Errorf("got action %s, expected %s", event.Action, ActionDeploy)
+	}
+	pr := event.PullRequest
+	if pr.Provider != ProviderGitHub || pr.Number != 42 || pr.Branch != "feature/cart" || pr.Commit != "abc123" || pr.Author != "alice" {
+		t.Errorf("got pull request %+v", pr)
+	}
+	if name := pr.EnvironmentName(); name != "pr-42-shop-front" {
+		t.Errorf("got environment name %q, expected pr-42-shop-front", name)
+	}
+
+	req = httptest.NewRequest("POST", "/hooks/pr", strings.NewReader(githubOpened))
+	req.Header.Set("X-GitHub-Event", "pull_request")
+	req.Header.Set("X-Hub-Signature-256", sign(githubOpened, "wrong"))
+	if _, err := ParseWebhook(req, "s3cret"); !errors.Is(err, ErrInvalidSignature) {
+		t.Errorf("got %v for a bad signature, expected ErrInvalidSignature", err)
+	}
+
+	req = httptest.NewRequest("POST", "/hooks/pr", strings.NewReader(`{}`))
+	req.Header.Set("X-GitHub-Event", "push")
+	req.Header.Set("X-Hub-Signature-256", sign(`{}`, "s3cret"))
+	if _, err := ParseWebhook(req, "s3cret"); !errors.Is(err, ErrUnsupportedEvent) {
+		t.Errorf("got %v for a push, expected ErrUnsupportedEvent", err)
+	}
+}
+
+func TestParseWebhook_GitLab(t *testing.T) {
+	for action, expected := range map[string]Action{
+		"open":     ActionDeploy,
+		"update":   ActionDeploy,
+		"merge":    ActionTeardown,
+		"close":    ActionTeardown,
+		"approved": ActionIgnore,
+	} {
+		body := `{
+			"object_kind": "merge_request",
+			"user": {"username": "bob"},
+			"project": {"id": 7, "path_with_namespace": "acme/api"},
+			"object_attributes": {"iid": 3, "url": "https://gitlab.com/acme/api/-/merge_requests/3",
+				"source_branch": "fix", "action": "` + action + `", "last_commit": {"id": "def456"}}
+		}`
+		req := httptest.NewRequest("POST", "/hooks/pr", strings.NewReader(body))
+		req.Header.Set("X-Gitlab-Event", "Merge Request Hook")
+		req.Header.Set("X-Gitlab-Token", "s3cret")
+
+		event, err := ParseWebhook(req, "s3cret")
+		if err != nil {
+			t.Fatalf("ParseWebhook failed for %s: %v", action, err)
+		}
+		if event.Action != expected {
+			t.Errorf("got action %s for %s, expected %s", event.Action, action, expected)
+		}
+		if event.PullRequest.ProjectID != 7 || event.PullRequest.Number != 3 {
+			t.Errorf("got pull request %+v", event.PullRequest)
+		}
+	}
+
+	req := httptest.NewRequest("POST", "/hooks/pr", strings.NewReader(`{}`))
+	req.Header.Set("X-Gitlab-Event", "Merge Request Hook")
+	req.Header.Set("X-Gitlab-Token", "guess")
+	if _, err := ParseWebhook(req, "s3cret"); !errors.Is(err, ErrInvalidSignature) {
+		t.Errorf("got %v for a bad token, expected ErrInvalidSignature", err)
+	}
+}
+
+func TestAPICommenter(t *testing.T) {
+	var paths, auths []string
+	var bodies []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		paths = append(paths, r.URL.Path)
+		auths = append(auths, r.Header.Get("Authorization")+r.Header.Get("PRIVATE-TOKEN"))
+		var payload map[string]string
+		json.NewDecoder(r.Body).Decode(&payload)
+		bodies = append(bodies, payload["body"])
+		w.WriteHeader(http.StatusCreated)
+	}))
+	defer server.Close()
+
+	c := &APICommenter{GitHubURL: server.URL, GitHubToken: "gh", GitLabURL: server.URL, GitLabToken: "gl"}
+	if err := c.Comment(context.Background(), PullRequest{Provider: ProviderGitHub, Repository: "acme/shop", Number: 42}, "hello"); err != nil {
+		t.Fatalf("GitHub comment failed: %v", err)
+	}
+	if err := c.Comment(context.Background(), PullRequest{Provider: ProviderGitLab, ProjectID: 7, Number: 3}, "hi"); err != nil {
+		t.Fatalf("GitLab comment failed: %v", err)
+	}
+
+	expected := []string{"/repos/acme/shop/issues/42/comments", "/api/v4/projects/7/merge_requests/3/notes"}
+	for i := range expected {
+		if paths[i] != expected[i] {
+			t.Errorf("got path %s, expected %s", paths[i], expected[i])
+		}
+	}
+	if auths[0] != "Bearer gh" || auths[1] != "gl" {
+		t.Errorf("got credentials %v", auths)
+	}
+	if bodies[0] != "hello" || bodies[1] != "hi" {
+		t.Errorf("got bodies %v", bodies)
+	}
+}
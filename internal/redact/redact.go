@@ -0,0 +1,163 @@
+// Package redact scrubs personal data and credentials from logs and text
+// artifacts before they are shown outside the team.
+package redact
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"regexp"
+	"sync"
+	"unicode/utf8"
+)
+
+// Rule replaces every match of a pattern
+type Rule struct {
+	Name        string `json:"name"`
+	Pattern     string `json:"pattern"`
+	Replacement string `json:"replacement,omitempty"` // defaults to [REDACTED <name>]
+
+	re *regexp.Regexp
+}
+
+func (r *Rule) compile() error {
+	re, err := regexp.Compile(r.Pattern)
+	if err != nil {
+		return fmt.Errorf("invalid pattern for redaction rule %s: %w", r.Name, err)
+	}
+	r.re = re
+	if r.Replacement == "" {
+		r.Replacement = fmt.Sprintf("[REDACTED %s]", r.Name)
+	}
+	return nil
+}
+
+// DefaultRules redact emails, credentials and IP addresses. Credentials come
+// first so a token containing an email-like string is redacted whole.
+var DefaultRules = []Rule{
+	{Name: "private-key", Pattern: `-----BEGIN [A-Z ]*PRIVATE KEY-----[\s\S]*?-----END [A-Z ]*PRIVATE KEY-----`},
+	{Name: "jwt", Pattern: `\beyJ[A-Za-z0-9_-]+\.[A-Za-z0-9_-]+\.[A-Za-z0-9_-]+`},
+	{Name: "bearer", Pattern: `(?i)\bbearer\s+[A-Za-z0-9._~+/=-]{8,}`},
+	{Name: "aws-key", Pattern: `\b(?:AKIA|ASIA)[A-Z0-9]{16}\b`},
+	{Name: "secret", Pattern: `(?i)\b(password|passwd|secret|token|api[_-]?key)(["']?\s*[:=]\s*["']?)[^\s"',;]+`, Replacement: "${1}${2}[REDACTED secret]"},
+	{Name: "url-credentials", Pattern: `([a-z][a-z0-9+.-]*://)[^\s/:@]+:[^\s/@]+@`, Replacement: "${1}[REDACTED credentials]@"},
+	{Name: "email", Pattern: `[A-Za-z0-9._%+-]+@[A-Za-z0-9.-]+\.[A-Za-z]{2,}`},
+	{Name: "ipv4", Pattern: `\b(?:25[0-5]|2[0-4]\d|1?\d?\d)(?:\.(?:25[0-5]|2[0-4]\d|1?\d?\d)){3}\b`},
+	{Name: "ipv6", Pattern: `\b(?:[0-9a-fA-F]{1,4}:){7}[0-9a-fA-F]{1,4}\b`},
+}
+
+// Redactor applies the default rules followed by any custom ones
+type Redactor struct {
+	defaults []Rule
+	custom   []Rule
+	mu       sync.RWMutex
+}
+
+// NewRedactor creates a redactor with the default rules and custom
+func NewRedactor(custom []Rule) (*Redactor, error) {
+	r := &Redactor{defaults: make([]Rule, len(DefaultRules))}
+	copy(r.defaults, DefaultRules)
+	for i := range r.defaults {
+		if err := r.defaults[i].compile(); err != nil {
+			return nil, err
+		}
+	}
+	if err := r.SetRules(custom); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// NewRedactorFromEnv creates a redactor with the custom rules in the JSON
+// file named by REDACT_RULES_FILE, when set
+func NewRedactorFromEnv() (*Redactor, error) {
+	r, err := NewRedactor(nil)
+	if err != nil {
+		return nil, err
+	}
+	if file := os.Getenv("REDACT_RULES_FILE"); file != "" {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return r, fmt.Errorf("failed to read redaction rules file: %w", err)
+		}
+		if err := r.Import(data); err != nil {
+			return r, err
+		}
+	}
+	return r, nil
+}
+
+// Rules returns the custom rules
+func (r *Redactor) Rules() []Rule {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return append([]Rule(nil), r.custom...)
+}
+
+// SetRules replaces the custom rules
+func (r *Redactor) SetRules(rules []Rule) error {
+	compiled := make([]Rule, len(rules))
+	for i, rule := range rules {
+		if rule.Name == "" {
+			return fmt.Errorf("redaction rule %d has no name", i+1)
+		}
+		if err := rule.compile(); err != nil {
+			return err
+		}
+		compiled[i] = rule
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.custom = compiled
+	return nil
+}
+
+// Export returns the custom rules as JSON, for configuration sync
+func (r *Redactor) Export() (json.RawMessage, error) {
+	rules := r.Rules()
+	if rules == nil {
+		rules = []Rule{}
+	}
+	return json.Marshal(rules)
+}
+
+// Import replaces the custom rules from JSON
+func (r *Redactor) Import(data json.RawMessage) error {
+	var rules []Rule
+	if err := json.Unmarshal(data, &rules); err != nil {
+		return fmt.Errorf("failed to parse redaction rules: %w", err)
+	}
+	return r.SetRules(rules)
+}
+
+// Redact returns s with every rule applied
+func (r *Redactor) Redact(s string) string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	for _, rules := range [][]Rule{r.defaults, r.custom} {
+		for _, rule := range rules {
+			s = rule.re.ReplaceAllString(s, rule.Replacement)
+		}
+	}
+	return s
+}
+
+// IsText reports whether data looks like text that can be redacted, as
+// opposed to images, archives and other binary files
+func IsText(data []byte) bool {
+	sample := data
+	if len(sample) > 8192 {
+		sample = sample[:8192]
+		// Don't let a multi-byte character split at the cut fail the check
+		for i := 0; i < utf8.UTFMax && !utf8.Valid(sample); i++ {
+			sample = sample[:len(sample)-1]
+		}
+	}
+	for _, b := range sample {
+		if b == 0 {
+			return false
+		}
+	}
+	return utf8.Valid(sample)
+}
@@ -0,0 +1,341 @@
+package reports
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/testkube/dashboard/internal/database"
+	"github.com/testkube/dashboard/internal/humanize"
+	"github.com/testkube/dashboard/internal/testkube"
+)
+
+const (
+	// maxComparisonExecutions caps the executions read per window
+	maxComparisonExecutions = 2000
+	// comparisonTestCasePage is how many test results each read of a
+	// window's results returns
+	comparisonTestCasePage = 5000
+)
+
+// Window is a span of execution start times; From is inclusive and To
+// exclusive
+type Window struct {
+	From time.Time
+	To   time.Time
+}
+
+// Before returns the window of the same length that ends where w starts
+func (w Window) Before() Window {
+	return Window{From: w.From.Add(-w.To.Sub(w.From)), To: w.From}
+}
+
+// ComparisonScope is what a comparison covers: one workflow, or the
+// workflows a suite includes
+type ComparisonScope struct {
+	Name     string
+	Suite    bool
+	Includes func(workflow string) bool
+}
+
+// WindowSummary is how a workflow or suite did in one window
+type WindowSummary struct {
+	Window
+	// Runs counts the finished executions; PassRate is the percentage of
+	// them that passed
+	Runs     int
+	Passed   int
+	Failed   int
+	PassRate float64
+
+	P50Duration time.Duration
+	P90Duration time.Duration
+	P95Duration time.Duration
+
+	FailingTests int // distinct tests with a failure
+	FlakyTests   int
+	// Truncated is set when the window had more executions than were read
+	Truncated bool
+}
+
+// MarshalJSON adds each percentile duration humanized, alongside its
+// nanoseconds
+func (w WindowSummary) MarshalJSON() ([]byte, error) {
+	type summary WindowSummary
+	return json.Marshal(struct {
+		summary
+		P50DurationHuman string
+		P90DurationHuman string
+		P95DurationHuman string
+	}{summary(w), humanize.Duration(w.P50Duration), humanize.Duration(w.P90Duration), humanize.Duration(w.P95Duration)})
+}
+
+// TestChange is a test whose failures differ between the windows
+type TestChange struct {
+	TestName     string
+	WorkflowName string
+	Runs         int // in the window it failed in
+	Failures     int
+	ErrorMessage string // of the latest failure
+}
+
+// FlakyChange is a test that became flaky, or flakier, in the current window
+type FlakyChange struct {
+	TestName      string
+	WorkflowName  string
+	PreviousScore float64
+	CurrentScore  float64
+}
+
+// ComparisonReport compares a workflow's or suite's executions in two
+// windows, e.g. this week against last week
+type ComparisonReport struct {
+	Scope    string
+	Suite    bool
+	Current  WindowSummary
+	Previous WindowSummary
+	// NewFailures failed in the current window but not the previous one,
+	// Fixed failed in the previous window and only passed in the current one
+	NewFailures []TestChange
+	Fixed       []TestChange
+	NewlyFlaky  []FlakyChange
+}
+
+// PassRateChange is the change in pass rate, in percentage points
+func (r *ComparisonReport) PassRateChange() float64 {
+	return r.Current.PassRate - r.Previous.PassRate
+}
+
+// P95Change is the change in p95 duration
+func (r *ComparisonReport) P95Change() time.Duration {
+	return r.Current.P95Duration - r.Previous.P95Duration
+}
+
+// testStats are one test's runs in a window
+type testStats struct {
+	TestChange
+	lastFailure time.Time
+}
+
+// BuildComparisonReport compares the scope's ingested executions in the
+// current window with the previous one
+func BuildComparisonReport(db database.Database, scope ComparisonScope, current, previous Window, limit int) (*ComparisonReport, error) {
+	report := &ComparisonReport{Scope: scope.Name, Suite: scope.Suite}
+
+	var currentTests, previousTests map[string]*testStats
+	var err error
+	if report.Current, currentTests, err = summarizeWindow(db, scope, current); err != nil {
+		return nil, fmt.Errorf("failed to summarize current window: %w", err)
+	}
+	if report.Previous, previousTests, err = summarizeWindow(db, scope, previous); err != nil {
+		return nil, fmt.Errorf("failed to summarize previous window: %w", err)
+	}
+
+	for key, t := range currentTests {
+		if t.Failures > 0 {
+			if before, ok := previousTests[key]; !ok || before.Failures == 0 {
+				report.NewFailures = append(report.NewFailures, t.TestChange)
+			}
+		}
+	}
+	for key, t := range previousTests {
+		if t.Failures > 0 {
+			if after, ok := currentTests[key]; ok && after.Failures == 0 {
+				report.Fixed = append(report.Fixed, t.TestChange)
+			}
+		}
+	}
+	sortTestChanges(report.NewFailures)
+	sortTestChanges(report.Fixed)
+	report.NewFailures = truncate(report.NewFailures, limit)
+	report.Fixed = truncate(report.Fixed, limit)
+
+	currentFlaky, err := flakyTestsIn(db, scope, current)
+	if err != nil {
+		return nil, fmt.Errorf("failed to score current window: %w", err)
+	}
+	previousFlaky, err := flakyTestsIn(db, scope, previous)
+	if err != nil {
+		return nil, fmt.Errorf("failed to score previous window: %w", err)
+	}
+	report.Current.FlakyTests = len(currentFlaky)
+	report.Previous.FlakyTests = len(previousFlaky)
+	previousScores := make(map[string]float64, len(previousFlaky))
+	for _, t := range previousFlaky {
+		previousScores[testKey(t.WorkflowName, t.TestName)] = t.FlakyScore
+	}
+	for _, t := range currentFlaky {
+		before := previousScores[testKey(t.WorkflowName, t.TestName)]
+		if t.FlakyScore > before {
+			report.NewlyFlaky = append(report.NewlyFlaky, FlakyChange{
+				TestName:      t.TestName,
+				WorkflowName:  t.WorkflowName,
+				PreviousScore: before,
+				CurrentScore:  t.FlakyScore,
+			})
+		}
+	}
+	sort.SliceStable(report.NewlyFlaky, func(i, j int) bool {
+		a, b := report.NewlyFlaky[i], report.NewlyFlaky[j]
+		return a.CurrentScore-a.PreviousScore > b.CurrentScore-b.PreviousScore
+	})
+	report.NewlyFlaky = truncate(report.NewlyFlaky, limit)
+
+	return report, nil
+}
+
+func summarizeWindow(db database.Database, scope ComparisonScope, w Window) (WindowSummary, map[string]*testStats, error) {
+	summary := WindowSummary{Window: w}
+	executions, truncated, err := windowExecutions(db, scope, w)
+	if err != nil {
+		return summary, nil, err
+	}
+	summary.Truncated = truncated
+	testCases, err := windowTestCases(db, scope, w, executions)
+	if err != nil {
+		return summary, nil, err
+	}
+
+	tests := make(map[string]*testStats)
+	var durations []time.Duration
+	for _, exec := range executions {
+		summary.Runs++
+		if exec.Status == "passed" {
+			summary.Passed++
+		} else {
+			summary.Failed++
+		}
+		durations = append(durations, exec.Duration)
+
+		for _, tc := range testCases[exec.ID] {
+			key := testKey(exec.WorkflowName, tc.TestName)
+			t, ok := tests[key]
+			if !ok {
+				t = &testStats{TestChange: TestChange{TestName: tc.TestName, WorkflowName: exec.WorkflowName}}
+				tests[key] = t
+			}
+			t.Runs++
+			if tc.Status == "failed" {
+				t.Failures++
+				if exec.StartTime.After(t.lastFailure) {
+					t.lastFailure = exec.StartTime
+					t.ErrorMessage = tc.ErrorMessage
+				}
+			}
+		}
+	}
+
+	if summary.Runs > 0 {
+		summary.PassRate = float64(summary.Passed) * 100 / float64(summary.Runs)
+		sort.Slice(durations, func(i, j int) bool { return durations[i] < durations[j] })
+		summary.P50Duration = nearestRank(durations, 50)
+		summary.P90Duration = nearestRank(durations, 90)
+		summary.P95Duration = nearestRank(durations, 95)
+	}
+	for _, t := range tests {
+		if t.Failures > 0 {
+			summary.FailingTests++
+		}
+	}
+	return summary, tests, nil
+}
+
+// windowExecutions reads the scope's finished executions in a window, up to
+// maxComparisonExecutions of them, and whether there were more. A suite's
+// executions are read across every workflow a page at a time, keeping the
+// suite's, so other workflows' runs don't crowd them out.
+func windowExecutions(db database.Database, scope ComparisonScope, w Window) ([]testkube.Execution, bool, error) {
+	query := database.ExecutionQuery{From: w.From, To: w.To, Limit: maxComparisonExecutions}
+	if !scope.Suite {
+		query.Workflows = []string{scope.Name}
+	}
+	var executions []testkube.Execution
+	for {
+		page, total, err := db.QueryExecutions(query)
+		if err != nil {
+			return nil, false, err
+		}
+		for _, exec := range page {
+			if !scope.Includes(exec.WorkflowName) || (exec.Status != "passed" && exec.Status != "failed") {
+				continue
+			}
+			if len(executions) == maxComparisonExecutions {
+				return executions, true, nil
+			}
+			executions = append(executions, exec)
+		}
+		query.Offset += len(page)
+		if len(page) == 0 || query.Offset >= total {
+			return executions, false, nil
+		}
+	}
+}
+
+// windowTestCases reads the test results of executions in a window at once,
+// by execution
+func windowTestCases(db database.Database, scope ComparisonScope, w Window, executions []testkube.Execution) (map[string][]database.TestCase, error) {
+	byExecution := make(map[string][]database.TestCase, len(executions))
+	if len(executions) == 0 {
+		return byExecution, nil
+	}
+	wanted := make(map[string]bool, len(executions))
+	for _, exec := range executions {
+		wanted[exec.ID] = true
+	}
+
+	query := database.TestCaseQuery{From: w.From, To: w.To, Limit: comparisonTestCasePage}
+	if !scope.Suite {
+		query.Workflow = scope.Name
+	}
+	for {
+		page, total, err := db.QueryTestCases(query)
+		if err != nil {
+			return nil, err
+		}
+		for _, result := range page {
+			if wanted[result.ExecutionID] {
+				byExecution[result.ExecutionID] = append(byExecution[result.ExecutionID], result.TestCase)
+			}
+		}
+		query.Offset += len(page)
+		if len(page) == 0 || query.Offset >= total {
+			return byExecution, nil
+		}
+	}
+}
+
+func flakyTestsIn(db database.Database, scope ComparisonScope, w Window) ([]database.FlakyTest, error) {
+	all, err := db.GetFlakyTestsBetween(w.From, w.To)
+	if err != nil {
+		return nil, err
+	}
+	var flaky []database.FlakyTest
+	for _, t := range all {
+		if scope.Includes(t.WorkflowName) {
+			flaky = append(flaky, t)
+		}
+	}
+	return flaky, nil
+}
+
+// nearestRank returns the nearest-rank percentile of sorted durations
+func nearestRank(sorted []time.Duration, p int) time.Duration {
+	return sorted[(len(sorted)*p+99)/100-1]
+}
+
+func sortTestChanges(changes []TestChange) {
+	sort.Slice(changes, func(i, j int) bool {
+		if changes[i].Failures != changes[j].Failures {
+			return changes[i].Failures > changes[j].Failures
+		}
+		if changes[i].WorkflowName != changes[j].WorkflowName {
+			return changes[i].WorkflowName < changes[j].WorkflowName
+		}
+		return changes[i].TestName < changes[j].TestName
+	})
+}
+
+func testKey(workflow, test string) string {
+	return workflow + "\x00" + test
+}
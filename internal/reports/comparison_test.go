@@ -0,0 +1,117 @@
+package reports
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/testkube/dashboard/internal/database"
+	"github.com/testkube/dashboard/internal/testkube"
+)
+
+func seedExecution(t *testing.T, db *database.MockDatabase, workflow string, start time.Time, duration time.Duration, tests map[string]string) {
+	t.Helper()
+	status := "passed"
+	for _, s := range tests {
+		if s == "failed" {
+			status = "failed"
+		}
+	}
+	id := fmt.Sprintf("%s-%d", workflow, start.UnixNano())
+	db.InsertExecution(testkube.Execution{ID: id, WorkflowName: workflow, Status: status, StartTime: start, Duration: duration})
+	for name, s := range tests {
+		db.InsertTestCase(database.TestCase{ExecutionID: id, TestName: name, Status: s, ErrorMessage: map[string]string{"failed": name + " broke"}[s]})
+	}
+}
+
+func TestBuildComparisonReport(t *testing.T) {
+	db := database.NewMockDatabase()
+	now := time.Date(2026, 5, 15, 12, 0, 0, 0, time.UTC)
+	current := Window{From: now.AddDate(0, 0, -7), To: now}
+	previous := current.Before()
+	if !previous.To.Equal(current.From) || !previous.From.Equal(now.AddDate(0, 0, -14)) {
+		t.Fatalf("got previous window %v, expected the week before %v", previous, current)
+	}
+
+	lastWeek := now.AddDate(0, 0, -10)
+	for i := 0; i < 4; i++ {
+		seedExecution(t, db, "e2e", lastWeek.Add(time.Duration(i)*time.Hour), time.Duration(i+1)*time.Minute,
+			map[string]string{"login": "failed", "checkout": "passed"})
+	}
+	thisWeek := now.AddDate(0, 0, -3)
+	for i, s := range []string{"failed", "passed", "passed", "passed"} {
+		seedExecution(t, db, "e2e", thisWeek.Add(time.Duration(i)*time.Hour), time.Duration(i+5)*time.Minute,
+			map[string]string{"login": "passed", "checkout": s})
+	}
+	seedExecution(t, db, "api", thisWeek, time.Minute, map[string]string{"health": "failed"})
+
+	scope := ComparisonScope{Name: "e2e", Includes: func(name string) bool { return name == "e2e" }}
+	report, err := BuildComparisonReport(db, scope, current, previous, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if report.Previous.Runs != 4 || report.Previous.PassRate != 0 {
+		t.Errorf("got previous %d runs at %.0f%%, expected 4 at 0%%", report.Previous.Runs, report.Previous.PassRate)
+	}
+	if report.Current.Runs != 4 || report.Current.PassRate != 75 || report.PassRateChange() != 75 {
+		t.Errorf("got current %d runs at %.0f%%, expected 4 at 75%%", report.Current.Runs, report.Current.PassRate)
+	}
+	if report.Current.P50Duration != 6*time.Minute || report.Current.P95Duration != 8*time.Minute || report.P95Change() != 4*time.Minute {
+		t.Errorf("got current p50 %s and p95 %s, expected 6m and 8m", report.Current.P50Duration, report.Current.P95Duration)
+	}
+	if len(report.NewFailures) != 1 || report.NewFailures[0].TestName != "checkout" || report.NewFailures[0].ErrorMessage != "checkout broke" {
+		t.Errorf("got new failures %+v, expected checkout", report.NewFailures)
+	}
+	if len(report.Fixed) != 1 || report.Fixed[0].TestName != "login" || report.Fixed[0].Failures != 4 {
+		t.Errorf("got fixed %+v, expected login", report.Fixed)
+	}
+	// login failed consistently last week, so it's broken rather than flaky
+	if len(report.NewlyFlaky) != 1 || report.NewlyFlaky[0].TestName != "checkout" || report.Previous.FlakyTests != 0 {
+		t.Errorf("got newly flaky %+v, expected checkout", report.NewlyFlaky)
+	}
+
+	suite := ComparisonScope{Name: "all", Suite: true, Includes: func(string) bool { return true }}
+	report, err = BuildComparisonReport(db, suite, current, previous, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Current.Runs != 5 || len(report.NewFailures) != 2 {
+		t.Errorf("got %d runs and new failures %+v, expected the api workflow included", report.Current.Runs, report.NewFailures)
+	}
+}
+
+// bulkOnlyDatabase fails reads of one execution's test results, which a
+// window's summary reads all at once instead
+type bulkOnlyDatabase struct {
+	*database.MockDatabase
+}
+
+func (db bulkOnlyDatabase) GetExecutionMetrics(executionID string) ([]database.TestCase, error) {
+	return nil, fmt.Errorf("unexpected read of %s's test results", executionID)
+}
+
+func TestSuiteWindowSkipsOtherWorkflows(t *testing.T) {
+	db := database.NewMockDatabase()
+	now := time.Date(2026, 5, 15, 12, 0, 0, 0, time.UTC)
+	current := Window{From: now.AddDate(0, 0, -7), To: now}
+	seedExecution(t, db, "e2e", now.AddDate(0, 0, -6), time.Minute, map[string]string{"login": "failed"})
+	// More runs of a workflow outside the suite than a window reads, all
+	// newer than the suite's
+	for i := 0; i < maxComparisonExecutions; i++ {
+		db.InsertExecution(testkube.Execution{ID: fmt.Sprintf("noise-%d", i), WorkflowName: "noise", Status: "passed",
+			StartTime: now.AddDate(0, 0, -1).Add(time.Duration(i) * time.Second)})
+	}
+
+	suite := ComparisonScope{Name: "e2e-only", Suite: true, Includes: func(name string) bool { return name == "e2e" }}
+	summary, tests, err := summarizeWindow(bulkOnlyDatabase{db}, suite, current)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if summary.Runs != 1 || summary.Failed != 1 || summary.Truncated {
+		t.Errorf("got %d runs (truncated %v), expected the suite's one failed run", summary.Runs, summary.Truncated)
+	}
+	if stats := tests[testKey("e2e", "login")]; stats == nil || stats.Failures != 1 {
+		t.Errorf("got %+v, expected login's failure", tests)
+	}
+}
@@ -0,0 +1,165 @@
+package reports
+
+import (
+	"encoding/json"
+	"sort"
+	"time"
+
+	"github.com/testkube/dashboard/internal/environments"
+	"github.com/testkube/dashboard/internal/humanize"
+)
+
+const (
+	// DefaultProvisioningSLO is the promised time from request to a ready
+	// environment
+	DefaultProvisioningSLO = 5 * time.Minute
+	// DefaultSLOTarget is the share of environments, in percent, that must
+	// be ready within the SLO
+	DefaultSLOTarget = 95.0
+)
+
+// EnvironmentTypeSLA summarizes provisioning and lifetime for one
+// environment type
+type EnvironmentTypeSLA struct {
+	Type    environments.EnvironmentType
+	Created int
+	Ready   int // environments that finished provisioning
+	Failed  int // environments that failed before becoming ready
+	// FailureRate is Failed as a percentage of finished provisioning attempts
+	FailureRate float64
+
+	MeanProvisioning time.Duration
+	P95Provisioning  time.Duration
+	MaxProvisioning  time.Duration
+	// WithinSLO is the percentage of ready environments provisioned within
+	// the SLO; failures count against it
+	WithinSLO float64
+	Breaches  int
+
+	// MeanLifetime is the average time from creation to deletion of the
+	// environments that have been deleted
+	MeanLifetime time.Duration
+	Ended        int
+}
+
+// Attempts is the number of environments that finished provisioning,
+// successfully or not
+func (t EnvironmentTypeSLA) Attempts() int {
+	return t.Ready + t.Failed
+}
+
+// Meeting reports whether the type meets the SLO target. Types with no
+// finished provisioning attempts have nothing to measure and meet it.
+func (t EnvironmentTypeSLA) Meeting(target float64) bool {
+	return t.Attempts() == 0 || t.WithinSLO >= target
+}
+
+// MarshalJSON adds each duration humanized, alongside its nanoseconds
+func (t EnvironmentTypeSLA) MarshalJSON() ([]byte, error) {
+	type sla EnvironmentTypeSLA
+	return json.Marshal(struct {
+		sla
+		MeanProvisioningHuman string
+		P95ProvisioningHuman  string
+		MaxProvisioningHuman  string
+		MeanLifetimeHuman     string
+	}{sla(t), humanize.Duration(t.MeanProvisioning), humanize.Duration(t.P95Provisioning),
+		humanize.Duration(t.MaxProvisioning), humanize.Duration(t.MeanLifetime)})
+}
+
+// EnvironmentSLAReport is the provisioning SLA of each environment type over
+// a window
+type EnvironmentSLAReport struct {
+	From   time.Time
+	To     time.Time
+	SLO    time.Duration
+	Target float64
+	Types  []EnvironmentTypeSLA
+	// Slowest lists the environments that took longest to become ready
+	Slowest []environments.Environment
+}
+
+// Days returns the window length in days
+func (r *EnvironmentSLAReport) Days() int {
+	return int(r.To.Sub(r.From).Hours() / 24)
+}
+
+// BuildEnvironmentSLAReport measures environments created between from and
+// to against the provisioning SLO
+func BuildEnvironmentSLAReport(envs []environments.Environment, from, to time.Time, slo time.Duration, target float64, limit int) *EnvironmentSLAReport {
+	report := &EnvironmentSLAReport{From: from, To: to, SLO: slo, Target: target}
+
+	byType := make(map[environments.EnvironmentType][]environments.Environment)
+	var ready []environments.Environment
+	for _, env := range envs {
+		if env.CreatedAt.Before(from) || env.CreatedAt.After(to) {
+			continue
+		}
+		byType[env.Type] = append(byType[env.Type], env)
+		if env.ReadyAt != nil {
+			ready = append(ready, env)
+		}
+	}
+
+	for envType, list := range byType {
+		report.Types = append(report.Types, environmentTypeSLA(envType, list, slo))
+	}
+	sort.Slice(report.Types, func(i, j int) bool {
+		return report.Types[i].Type < report.Types[j].Type
+	})
+
+	sort.SliceStable(ready, func(i, j int) bool {
+		return provisioningTime(ready[i]) > provisioningTime(ready[j])
+	})
+	report.Slowest = truncate(ready, limit)
+	return report
+}
+
+func provisioningTime(env environments.Environment) time.Duration {
+	return env.ReadyAt.Sub(env.CreatedAt)
+}
+
+func environmentTypeSLA(envType environments.EnvironmentType, envs []environments.Environment, slo time.Duration) EnvironmentTypeSLA {
+	sla := EnvironmentTypeSLA{Type: envType, Created: len(envs)}
+
+	var durations []time.Duration
+	var total, lifetime time.Duration
+	within := 0
+	for _, env := range envs {
+		switch {
+		case env.ReadyAt != nil:
+			sla.Ready++
+			d := provisioningTime(env)
+			durations = append(durations, d)
+			total += d
+			if d <= slo {
+				within++
+			} else {
+				sla.Breaches++
+			}
+		case env.Error != "":
+			sla.Failed++
+			sla.Breaches++
+		}
+
+		if env.DeletedAt != nil {
+			sla.Ended++
+			lifetime += env.DeletedAt.Sub(env.CreatedAt)
+		}
+	}
+
+	if attempts := sla.Attempts(); attempts > 0 {
+		sla.FailureRate = float64(sla.Failed) / float64(attempts) * 100
+		sla.WithinSLO = float64(within) / float64(attempts) * 100
+	}
+	if len(durations) > 0 {
+		sort.Slice(durations, func(i, j int) bool { return durations[i] < durations[j] })
+		sla.MeanProvisioning = total / time.Duration(len(durations))
+		sla.P95Provisioning = durations[(len(durations)*95+99)/100-1]
+		sla.MaxProvisioning = durations[len(durations)-1]
+	}
+	if sla.Ended > 0 {
+		sla.MeanLifetime = lifetime / time.Duration(sla.Ended)
+	}
+	return sla
+}
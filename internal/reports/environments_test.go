@@ -0,0 +1,67 @@
+package reports
+
+import (
+	"testing"
+	"time"
+
+	"github.com/testkube/dashboard/internal/environments"
+)
+
+func TestBuildEnvironmentSLAReport(t *testing.T) {
+	now := time.Date(2026, 5, 15, 12, 0, 0, 0, time.UTC)
+	at := func(d time.Duration) *time.Time {
+		t := now.Add(-48 * time.Hour).Add(d)
+		return &t
+	}
+	created := now.Add(-48 * time.Hour)
+
+	envs := []environments.Environment{
+		{ID: "fast", Type: environments.TypeEphemeral, CreatedAt: created, ReadyAt: at(2 * time.Minute), DeletedAt: at(8 * time.Hour)},
+		{ID: "ok", Type: environments.TypeEphemeral, CreatedAt: created, ReadyAt: at(4 * time.Minute), DeletedAt: at(4 * time.Hour)},
+		{ID: "slow", Type: environments.TypeEphemeral, CreatedAt: created, ReadyAt: at(9 * time.Minute)},
+		{ID: "broken", Type: environments.TypeEphemeral, CreatedAt: created, Status: environments.StatusFailed, Error: "Failed to create database"},
+		{ID: "creating", Type: environments.TypeEphemeral, CreatedAt: created, Status: environments.StatusCreating},
+		{ID: "sandbox", Type: environments.TypeDevSandbox, CreatedAt: created, ReadyAt: at(time.Minute)},
+		{ID: "old", Type: environments.TypeEphemeral, CreatedAt: now.AddDate(0, 0, -40), ReadyAt: at(time.Hour)},
+	}
+
+	report := BuildEnvironmentSLAReport(envs, now.AddDate(0, 0, -30), now, DefaultProvisioningSLO, DefaultSLOTarget, 2)
+
+	if report.Days() != 30 {
+		t.Errorf("Days() = %d, want 30", report.Days())
+	}
+	if len(report.Types) != 2 {
+		t.Fatalf("got %d types, want 2", len(report.Types))
+	}
+
+	ephemeral := report.Types[0]
+	if ephemeral.Type != environments.TypeEphemeral {
+		t.Fatalf("first type = %s, want ephemeral", ephemeral.Type)
+	}
+	if ephemeral.Created != 5 || ephemeral.Ready != 3 || ephemeral.Failed != 1 {
+		t.Errorf("created/ready/failed = %d/%d/%d, want 5/3/1", ephemeral.Created, ephemeral.Ready, ephemeral.Failed)
+	}
+	if ephemeral.FailureRate != 25 {
+		t.Errorf("FailureRate = %v, want 25", ephemeral.FailureRate)
+	}
+	if ephemeral.WithinSLO != 50 || ephemeral.Breaches != 2 {
+		t.Errorf("WithinSLO = %v with %d breaches, want 50 with 2", ephemeral.WithinSLO, ephemeral.Breaches)
+	}
+	if ephemeral.Meeting(report.Target) {
+		t.Error("ephemeral should not meet the SLO target")
+	}
+	if ephemeral.MeanProvisioning != 5*time.Minute || ephemeral.MaxProvisioning != 9*time.Minute || ephemeral.P95Provisioning != 9*time.Minute {
+		t.Errorf("provisioning mean/p95/max = %s/%s/%s", ephemeral.MeanProvisioning, ephemeral.P95Provisioning, ephemeral.MaxProvisioning)
+	}
+	if ephemeral.Ended != 2 || ephemeral.MeanLifetime != 6*time.Hour {
+		t.Errorf("MeanLifetime = %s over %d, want 6h over 2", ephemeral.MeanLifetime, ephemeral.Ended)
+	}
+
+	if sandbox := report.Types[1]; !sandbox.Meeting(report.Target) || sandbox.WithinSLO != 100 {
+		t.Errorf("sandbox WithinSLO = %v, want 100 and meeting", sandbox.WithinSLO)
+	}
+
+	if len(report.Slowest) != 2 || report.Slowest[0].ID != "slow" || report.Slowest[1].ID != "ok" {
+		t.Errorf("Slowest = %+v, want slow then ok", report.Slowest)
+	}
+}
@@ -0,0 +1,180 @@
+package reports
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/testkube/dashboard/internal/database"
+)
+
+// DefaultLeaderboardSize is how many entries each leaderboard section lists
+const DefaultLeaderboardSize = 10
+
+// TeamResolver maps a test to the team that owns it
+type TeamResolver interface {
+	Resolve(workflow, filePath string) (team, owner string)
+}
+
+// ImprovedTest is a test whose flaky score dropped compared to the previous window
+type ImprovedTest struct {
+	TestName      string
+	WorkflowName  string
+	PreviousScore float64
+	CurrentScore  float64
+}
+
+// Improvement is the drop in flaky score, in percentage points
+func (t ImprovedTest) Improvement() float64 {
+	return (t.PreviousScore - t.CurrentScore) * 100
+}
+
+// TeamDebt is a team's accumulated flakiness in the window
+type TeamDebt struct {
+	Team       string
+	FlakyTests int
+	// Debt sums the flaky scores of the team's tests, i.e. the expected number
+	// of intermittent failures per run of all its tests
+	Debt float64
+}
+
+// FlakinessReport is the flakiness leaderboard for one window, compared with
+// the window before it.
+type FlakinessReport struct {
+	From         time.Time
+	To           time.Time
+	MostFlaky    []database.FlakyTest
+	MostImproved []ImprovedTest
+	TeamDebt     []TeamDebt
+}
+
+// Days returns the window length in days
+func (r *FlakinessReport) Days() int {
+	return int(r.To.Sub(r.From).Hours() / 24)
+}
+
+// BuildFlakinessReport scores tests over the days before now and ranks them
+// against the preceding window of the same length.
+func BuildFlakinessReport(db database.Database, teams TeamResolver, now time.Time, days, limit int) (*FlakinessReport, error) {
+	from := now.AddDate(0, 0, -days)
+	current, err := db.GetFlakyTestsBetween(from, now)
+	if err != nil {
+		return nil, fmt.Errorf("failed to score current window: %w", err)
+	}
+	previous, err := db.GetFlakyTestsBetween(from.AddDate(0, 0, -days), from)
+	if err != nil {
+		return nil, fmt.Errorf("failed to score previous window: %w", err)
+	}
+
+	report := &FlakinessReport{From: from, To: now}
+
+	report.MostFlaky = append([]database.FlakyTest(nil), current...)
+	sort.SliceStable(report.MostFlaky, func(i, j int) bool {
+		a, b := report.MostFlaky[i], report.MostFlaky[j]
+		if a.FlakyScore != b.FlakyScore {
+			return a.FlakyScore > b.FlakyScore
+		}
+		return a.TotalRuns > b.TotalRuns
+	})
+	report.MostFlaky = truncate(report.MostFlaky, limit)
+
+	currentScores := make(map[string]float64, len(current))
+	for _, t := range current {
+		currentScores[t.WorkflowName+"\x00"+t.TestName] = t.FlakyScore
+	}
+	for _, t := range previous {
+		score := currentScores[t.WorkflowName+"\x00"+t.TestName]
+		if score < t.FlakyScore {
+			report.MostImproved = append(report.MostImproved, ImprovedTest{
+				TestName:      t.TestName,
+				WorkflowName:  t.WorkflowName,
+				PreviousScore: t.FlakyScore,
+				CurrentScore:  score,
+			})
+		}
+	}
+	sort.SliceStable(report.MostImproved, func(i, j int) bool {
+		return report.MostImproved[i].Improvement() > report.MostImproved[j].Improvement()
+	})
+	report.MostImproved = truncate(report.MostImproved, limit)
+
+	debts := make(map[string]*TeamDebt)
+	for _, t := range current {
+		team, _ := teams.Resolve(t.WorkflowName, t.FilePath)
+		debt, ok := debts[team]
+		if !ok {
+			debt = &TeamDebt{Team: team}
+			debts[team] = debt
+		}
+		debt.FlakyTests++
+		debt.Debt += t.FlakyScore
+	}
+	for _, debt := range debts {
+		report.TeamDebt = append(report.TeamDebt, *debt)
+	}
+	sort.Slice(report.TeamDebt, func(i, j int) bool {
+		if report.TeamDebt[i].Debt != report.TeamDebt[j].Debt {
+			return report.TeamDebt[i].Debt > report.TeamDebt[j].Debt
+		}
+		return report.TeamDebt[i].Team < report.TeamDebt[j].Team
+	})
+
+	return report, nil
+}
+
+func truncate[T any](items []T, limit int) []T {
+	if limit > 0 && len(items) > limit {
+		return items[:limit]
+	}
+	return items
+}
+
+// Markdown renders the report for pasting into retro notes
+func (r *FlakinessReport) Markdown() string {
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "# Flakiness Report: %s to %s\n\n", r.From.Format("2006-01-02"), r.To.Format("2006-01-02"))
+
+	b.WriteString("## Most Flaky Tests\n\n")
+	if len(r.MostFlaky) == 0 {
+		b.WriteString("No flaky tests in this window.\n")
+	} else {
+		b.WriteString("| # | Test | Workflow | Flaky Score | Failed / Runs | Last Failure |\n")
+		b.WriteString("|---|------|----------|-------------|---------------|--------------|\n")
+		for i, t := range r.MostFlaky {
+			fmt.Fprintf(&b, "| %d | %s | %s | %.0f%% | %d / %d | %s |\n",
+				i+1, escapeCell(t.TestName), escapeCell(t.WorkflowName), t.FlakyScore*100,
+				t.FailedRuns, t.TotalRuns, t.LastFailure.Format("2006-01-02 15:04"))
+		}
+	}
+
+	b.WriteString("\n## Most Improved\n\n")
+	if len(r.MostImproved) == 0 {
+		b.WriteString("No tests improved compared to the previous window.\n")
+	} else {
+		b.WriteString("| Test | Workflow | Before | Now |\n")
+		b.WriteString("|------|----------|--------|-----|\n")
+		for _, t := range r.MostImproved {
+			fmt.Fprintf(&b, "| %s | %s | %.0f%% | %.0f%% |\n",
+				escapeCell(t.TestName), escapeCell(t.WorkflowName), t.PreviousScore*100, t.CurrentScore*100)
+		}
+	}
+
+	b.WriteString("\n## Flaky Debt by Team\n\n")
+	if len(r.TeamDebt) == 0 {
+		b.WriteString("No team has flaky tests in this window.\n")
+	} else {
+		b.WriteString("| Team | Flaky Tests | Debt |\n")
+		b.WriteString("|------|-------------|------|\n")
+		for _, d := range r.TeamDebt {
+			fmt.Fprintf(&b, "| %s | %d | %.2f |\n", escapeCell(d.Team), d.FlakyTests, d.Debt)
+		}
+	}
+
+	return b.String()
+}
+
+func escapeCell(s string) string {
+	return strings.ReplaceAll(s, "|", `\|`)
+}
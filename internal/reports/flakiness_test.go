@@ -0,0 +1,62 @@
+package reports
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/testkube/dashboard/internal/database"
+	"github.com/testkube/dashboard/internal/testkube"
+)
+
+type staticTeams map[string]string
+
+func (t staticTeams) Resolve(workflow, filePath string) (string, string) {
+	return t[workflow], ""
+}
+
+func seedRuns(t *testing.T, db *database.MockDatabase, workflow, test string, start time.Time, statuses ...string) {
+	t.Helper()
+	for i, status := range statuses {
+		id := fmt.Sprintf("%s-%s-%d-%d", workflow, test, start.Unix(), i)
+		db.InsertExecution(testkube.Execution{ID: id, WorkflowName: workflow, StartTime: start.Add(time.Duration(i) * time.Hour)})
+		db.InsertTestCase(database.TestCase{ExecutionID: id, TestName: test, Status: status})
+	}
+}
+
+func TestBuildFlakinessReport(t *testing.T) {
+	db := database.NewMockDatabase()
+	now := time.Date(2026, 5, 15, 12, 0, 0, 0, time.UTC)
+	thisWeek := now.AddDate(0, 0, -3)
+	lastWeek := now.AddDate(0, 0, -10)
+
+	seedRuns(t, db, "e2e", "checkout", thisWeek, "failed", "passed", "failed", "passed")
+	seedRuns(t, db, "e2e", "login", lastWeek, "failed", "passed", "failed", "failed")
+	seedRuns(t, db, "e2e", "login", thisWeek, "passed", "passed", "passed", "failed")
+	seedRuns(t, db, "api", "health", thisWeek, "failed", "failed")
+
+	report, err := BuildFlakinessReport(db, staticTeams{"e2e": "web", "api": "platform"}, now, 7, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(report.MostFlaky) != 2 || report.MostFlaky[0].TestName != "checkout" {
+		t.Fatalf("unexpected most flaky: %+v", report.MostFlaky)
+	}
+	if len(report.MostImproved) != 1 || report.MostImproved[0].TestName != "login" ||
+		report.MostImproved[0].PreviousScore != 0.75 || report.MostImproved[0].CurrentScore != 0.25 {
+		t.Fatalf("unexpected most improved: %+v", report.MostImproved)
+	}
+	// Consistently failing tests are broken, not flaky, so carry no debt
+	if len(report.TeamDebt) != 1 || report.TeamDebt[0].Team != "web" || report.TeamDebt[0].Debt != 0.75 {
+		t.Fatalf("unexpected team debt: %+v", report.TeamDebt)
+	}
+
+	md := report.Markdown()
+	for _, want := range []string{"# Flakiness Report: 2026-05-08 to 2026-05-15", "| 1 | checkout | e2e | 50% | 2 / 4 |", "| login | e2e | 75% | 25% |", "| web | 2 | 0.75 |"} {
+		if !strings.Contains(md, want) {
+			t.Errorf("markdown missing %q:\n%s", want, md)
+		}
+	}
+}
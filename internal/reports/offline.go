@@ -0,0 +1,296 @@
+package reports
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"strings"
+	"time"
+
+	"github.com/testkube/dashboard/internal/charts"
+	"github.com/testkube/dashboard/internal/database"
+	"github.com/testkube/dashboard/internal/humanize"
+	"github.com/testkube/dashboard/internal/testkube"
+)
+
+// DefaultOfflineDays is how much history an offline bundle covers by default
+const DefaultOfflineDays = 30
+
+// MaxOfflineRuns caps the executions packaged into one offline bundle
+const MaxOfflineRuns = 200
+
+// OfflineRun is one execution in an offline bundle, with its test results
+// and the artifacts recorded at ingestion
+type OfflineRun struct {
+	testkube.Execution
+	Cases     []database.TestCase
+	Artifacts []database.ArtifactRecord
+}
+
+// Page is the run's page within the bundle
+func (r OfflineRun) Page() string {
+	return "runs/" + bundleFileName(r.ID) + ".html"
+}
+
+// Passed and Failed count the run's test cases by status
+func (r OfflineRun) Passed() int { return countCases(r.Cases, "passed") }
+func (r OfflineRun) Failed() int { return countCases(r.Cases, "failed") }
+
+// OfflineReport is a workflow's recent history, packaged by Bundle into
+// static pages that open without the dashboard, e.g. to hand results to a
+// customer whose environment is air-gapped
+type OfflineReport struct {
+	Workflow    string
+	From        time.Time
+	GeneratedAt time.Time
+	Runs        []OfflineRun
+	Trend       []database.DataPoint
+	Duration    []database.DataPoint
+}
+
+// PassRate is the percentage of the report's finished runs that passed
+func (r *OfflineReport) PassRate() int {
+	passed, finished := 0, 0
+	for _, run := range r.Runs {
+		switch run.Status {
+		case "passed":
+			passed++
+			finished++
+		case "failed":
+			finished++
+		}
+	}
+	if finished == 0 {
+		return 0
+	}
+	return passed * 100 / finished
+}
+
+// BuildOfflineReport collects a workflow's executions started in the days
+// before now, newest first and at most MaxOfflineRuns, with their results,
+// artifact manifests and the workflow's trends
+func BuildOfflineReport(db database.Database, workflow string, executions []testkube.Execution, now time.Time, days int) (*OfflineReport, error) {
+	report := &OfflineReport{Workflow: workflow, From: now.AddDate(0, 0, -days), GeneratedAt: now}
+	for _, exec := range executions {
+		if exec.WorkflowName != workflow || exec.StartTime.Before(report.From) {
+			continue
+		}
+		if len(report.Runs) == MaxOfflineRuns {
+			break
+		}
+		cases, err := db.GetExecutionMetrics(exec.ID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get test results for %s: %w", exec.ID, err)
+		}
+		manifest, err := db.GetArtifactManifest(exec.ID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get artifact manifest for %s: %w", exec.ID, err)
+		}
+		report.Runs = append(report.Runs, OfflineRun{Execution: exec, Cases: cases, Artifacts: manifest})
+	}
+
+	var err error
+	if report.Trend, err = db.GetPassRateTrend(workflow, days); err != nil {
+		return nil, fmt.Errorf("failed to get pass rate trend: %w", err)
+	}
+	if report.Duration, err = db.GetDurationTrend(workflow, days); err != nil {
+		return nil, fmt.Errorf("failed to get duration trend: %w", err)
+	}
+	return report, nil
+}
+
+// Bundle zips the report as static HTML: index.html with the trends as SVG
+// and a page per run, plus artifacts.json listing every run's artifacts.
+// Nothing in it loads from the network.
+func (r *OfflineReport) Bundle() ([]byte, error) {
+	type file struct {
+		name string
+		data []byte
+	}
+	var files []file
+	add := func(name string, data []byte) {
+		files = append(files, file{name, data})
+	}
+	render := func(name string, tmpl *template.Template, data interface{}) error {
+		var buf bytes.Buffer
+		if err := tmpl.Execute(&buf, data); err != nil {
+			return fmt.Errorf("failed to render %s: %w", name, err)
+		}
+		add(name, buf.Bytes())
+		return nil
+	}
+
+	if err := render("index.html", offlineIndex, r); err != nil {
+		return nil, err
+	}
+	for _, run := range r.Runs {
+		if err := render(run.Page(), offlineRun, map[string]interface{}{"Report": r, "Run": run}); err != nil {
+			return nil, err
+		}
+	}
+	gen := charts.NewGenerator()
+	add("charts/pass-rate.svg", []byte(gen.PassRateSVG(r.Trend)))
+	add("charts/duration.svg", []byte(gen.DurationSVG(r.Duration)))
+	add("style.css", []byte(offlineStyle))
+
+	manifest := []database.ArtifactRecord{}
+	for _, run := range r.Runs {
+		manifest = append(manifest, run.Artifacts...)
+	}
+	index, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode artifact manifest: %w", err)
+	}
+	add("artifacts.json", index)
+
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for _, f := range files {
+		w, err := zw.CreateHeader(&zip.FileHeader{Name: f.name, Method: zip.Deflate, Modified: r.GeneratedAt.UTC()})
+		if err != nil {
+			return nil, fmt.Errorf("failed to add %s: %w", f.name, err)
+		}
+		if _, err := w.Write(f.data); err != nil {
+			return nil, fmt.Errorf("failed to add %s: %w", f.name, err)
+		}
+	}
+	if err := zw.Close(); err != nil {
+		return nil, fmt.Errorf("failed to write bundle: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+func countCases(cases []database.TestCase, status string) int {
+	n := 0
+	for _, c := range cases {
+		if c.Status == status {
+			n++
+		}
+	}
+	return n
+}
+
+// bundleFileName makes an execution ID safe to use as a file name
+func bundleFileName(id string) string {
+	return strings.Map(func(r rune) rune {
+		if r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '-' || r == '_' || r == '.' {
+			return r
+		}
+		return '_'
+	}, id)
+}
+
+var offlineFuncs = template.FuncMap{
+	"duration": func(d time.Duration) string {
+		if d <= 0 {
+			return "-"
+		}
+		return humanize.Duration(d)
+	},
+	"size": humanize.Bytes,
+	"ms":   humanize.Milliseconds,
+}
+
+var offlineIndex = template.Must(template.New("index").Funcs(offlineFuncs).Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>{{.Workflow}} test history</title>
+<link rel="stylesheet" href="style.css">
+</head>
+<body>
+<h1>{{.Workflow}}</h1>
+<p class="meta">Runs started {{.From.Format "Jan 02, 2006"}} to {{.GeneratedAt.Format "Jan 02, 2006 15:04 MST"}}
+&middot; {{len .Runs}} runs &middot; {{.PassRate}}% of finished runs passed</p>
+
+<div class="charts">
+<img src="charts/pass-rate.svg" alt="Pass rate trend">
+<img src="charts/duration.svg" alt="Duration trend">
+</div>
+
+<table>
+<thead><tr><th>Execution</th><th>Status</th><th>Started</th><th>Duration</th><th>Branch</th><th>Trigger</th><th>Tests</th></tr></thead>
+<tbody>
+{{range .Runs}}
+<tr>
+<td><a href="{{.Page}}">{{.Name}}</a></td>
+<td class="status-{{.Status}}">{{.Status}}</td>
+<td>{{.StartTime.Format "Jan 02 15:04"}}</td>
+<td>{{duration .Duration}}</td>
+<td>{{.Branch}}</td>
+<td>{{.Trigger}}{{if .TriggeredBy}} by {{.TriggeredBy}}{{end}}</td>
+<td>{{if .Cases}}{{.Passed}} passed, {{.Failed}} failed{{else}}-{{end}}</td>
+</tr>
+{{else}}
+<tr><td colspan="7">No executions in this period.</td></tr>
+{{end}}
+</tbody>
+</table>
+<p class="meta">Artifacts are listed in artifacts.json, with the checksums recorded when each run was ingested.</p>
+</body>
+</html>
+`))
+
+var offlineRun = template.Must(template.New("run").Funcs(offlineFuncs).Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>{{.Run.Name}} &middot; {{.Report.Workflow}}</title>
+<link rel="stylesheet" href="../style.css">
+</head>
+<body>
+{{with .Run}}
+<p><a href="../index.html">&larr; {{$.Report.Workflow}}</a></p>
+<h1>{{.Name}}</h1>
+<p class="meta"><span class="status-{{.Status}}">{{.Status}}</span>
+&middot; started {{.StartTime.Format "Jan 02, 2006 15:04 MST"}}
+&middot; {{duration .Duration}}
+{{if .Branch}}&middot; branch {{.Branch}}{{end}}
+{{if .Trigger}}&middot; {{.Trigger}}{{if .TriggeredBy}} by {{.TriggeredBy}}{{end}}{{end}}</p>
+
+<h2>Tests</h2>
+<table>
+<thead><tr><th>Test</th><th>File</th><th>Status</th><th>Duration</th><th>Error</th></tr></thead>
+<tbody>
+{{range .Cases}}
+<tr>
+<td>{{.TestName}}</td>
+<td>{{.FilePath}}</td>
+<td class="status-{{.Status}}">{{.Status}}{{if .RetryCount}} after {{.RetryCount}} retries{{end}}</td>
+<td>{{ms .DurationMs}}</td>
+<td><pre>{{.ErrorMessage}}</pre></td>
+</tr>
+{{else}}
+<tr><td colspan="5">No test results were recorded.</td></tr>
+{{end}}
+</tbody>
+</table>
+
+<h2>Artifacts</h2>
+<table>
+<thead><tr><th>Name</th><th>Path</th><th>Size</th><th>SHA-256</th></tr></thead>
+<tbody>
+{{range .Artifacts}}
+<tr><td>{{.Name}}</td><td>{{.Path}}</td><td>{{size .Size}}</td><td><code>{{.SHA256}}</code></td></tr>
+{{else}}
+<tr><td colspan="4">No artifacts were recorded.</td></tr>
+{{end}}
+</tbody>
+</table>
+{{end}}
+</body>
+</html>
+`))
+
+const offlineStyle = `body { font-family: sans-serif; margin: 2rem; color: #111827; }
+h1 { margin-bottom: 0.25rem; }
+.meta { color: #6b7280; }
+.charts img { max-width: 100%; margin: 1rem 1rem 1rem 0; }
+table { border-collapse: collapse; width: 100%; margin-bottom: 2rem; }
+th, td { border-bottom: 1px solid #e5e7eb; padding: 0.4rem 0.6rem; text-align: left; vertical-align: top; }
+pre { margin: 0; white-space: pre-wrap; }
+.status-passed { color: #16a34a; }
+.status-failed { color: #dc2626; }
+`
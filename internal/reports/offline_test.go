@@ -0,0 +1,74 @@
+package reports
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/testkube/dashboard/internal/database"
+	"github.com/testkube/dashboard/internal/testkube"
+)
+
+func TestOfflineReportBundle(t *testing.T) {
+	db := database.NewMockDatabase()
+	now := time.Date(2026, 5, 15, 12, 0, 0, 0, time.UTC)
+	executions := []testkube.Execution{
+		{ID: "exec/2", Name: "e2e-2", WorkflowName: "e2e", Status: "failed", StartTime: now.Add(-time.Hour), Duration: 90 * time.Second},
+		{ID: "exec-1", Name: "e2e-1", WorkflowName: "e2e", Status: "passed", StartTime: now.AddDate(0, 0, -2)},
+		{ID: "exec-0", Name: "e2e-0", WorkflowName: "e2e", Status: "passed", StartTime: now.AddDate(0, 0, -40)},
+	}
+	db.InsertTestCase(database.TestCase{ExecutionID: "exec/2", TestName: "checkout <form>", Status: "failed", ErrorMessage: "timeout"})
+	db.InsertArtifactManifest("exec/2", []database.ArtifactRecord{{ExecutionID: "exec/2", Name: "trace.zip", Path: "trace.zip", Size: 2048, SHA256: "abc"}})
+
+	report, err := BuildOfflineReport(db, "e2e", executions, now, 30)
+	if err != nil {
+		t.Fatalf("BuildOfflineReport failed: %v", err)
+	}
+	if len(report.Runs) != 2 || report.PassRate() != 50 {
+		t.Errorf("got %d runs passing %d%%, expected the 2 runs in the window passing 50%%", len(report.Runs), report.PassRate())
+	}
+
+	bundle, err := report.Bundle()
+	if err != nil {
+		t.Fatalf("Bundle failed: %v", err)
+	}
+	zr, err := zip.NewReader(bytes.NewReader(bundle), int64(len(bundle)))
+	if err != nil {
+		t.Fatalf("bundle is not a zip: %v", err)
+	}
+	files := map[string]string{}
+	for _, f := range zr.File {
+		rc, err := f.Open()
+		if err != nil {
+			t.Fatalf("failed to open %s: %v", f.Name, err)
+		}
+		data, _ := io.ReadAll(rc)
+		rc.Close()
+		files[f.Name] = string(data)
+	}
+
+	for _, name := range []string{"index.html", "runs/exec_2.html", "runs/exec-1.html", "charts/pass-rate.svg", "charts/duration.svg", "style.css", "artifacts.json"} {
+		if _, ok := files[name]; !ok {
+			t.Errorf("bundle is missing %s", name)
+		}
+	}
+	if !strings.Contains(files["index.html"], `href="runs/exec_2.html"`) || strings.Contains(files["index.html"], "e2e-0") {
+		t.Errorf("got index %q, expected links to the runs in the window only", files["index.html"])
+	}
+	if !strings.Contains(files["runs/exec_2.html"], "checkout &lt;form&gt;") || !strings.Contains(files["runs/exec_2.html"], "2.0 KB") {
+		t.Errorf("got run page %q, expected its escaped test results and artifacts", files["runs/exec_2.html"])
+	}
+	for name, data := range files {
+		if strings.Contains(data, "http://") && !strings.Contains(data, "http://www.w3.org/2000/svg") || strings.Contains(data, "https://") {
+			t.Errorf("%s refers to the network, expected a self-contained bundle", name)
+		}
+	}
+	var manifest []database.ArtifactRecord
+	if err := json.Unmarshal([]byte(files["artifacts.json"]), &manifest); err != nil || len(manifest) != 1 || manifest[0].SHA256 != "abc" {
+		t.Errorf("got manifest %+v, %v, expected the run's artifact", manifest, err)
+	}
+}
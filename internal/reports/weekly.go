@@ -0,0 +1,414 @@
+package reports
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/testkube/dashboard/internal/database"
+	"github.com/testkube/dashboard/internal/environments"
+	"github.com/testkube/dashboard/internal/testkube"
+)
+
+// WeeklyReportKind is the kind weekly quality reports are stored under,
+// keyed by the Monday their week starts
+const WeeklyReportKind = "weekly"
+
+// DefaultTrendWeeks is how many weeks the flaky debt trend covers
+const DefaultTrendWeeks = 8
+
+// WeeklyOptions tune the weekly quality report
+type WeeklyOptions struct {
+	// CostPerRunnerHour prices the time executions ran for; zero leaves
+	// the cost out
+	CostPerRunnerHour float64
+	// Limit caps the regressions listed
+	Limit int
+	// TrendWeeks is how many weeks the flaky debt trend covers
+	TrendWeeks int
+}
+
+// runCounts tallies executions by whether they count towards pass rates
+type runCounts struct {
+	Runs     int
+	Passed   int
+	Failed   int
+	Excluded int // infra errors, timeouts, aborted and skipped runs
+}
+
+func (c *runCounts) add(exec testkube.Execution) {
+	c.Runs++
+	switch outcome := exec.RunOutcome(); {
+	case !testkube.CountsTowardsPassRate(outcome):
+		c.Excluded++
+	case outcome == testkube.OutcomePassed:
+		c.Passed++
+	default:
+		c.Failed++
+	}
+}
+
+// passRate is the percentage of counted runs that passed, and false when
+// no runs counted
+func (c runCounts) passRate() (float64, bool) {
+	if counted := c.Passed + c.Failed; counted > 0 {
+		return float64(c.Passed) / float64(counted) * 100, true
+	}
+	return 0, false
+}
+
+// TeamQuality is one team's runs in the week, with its pass rate the week
+// before for comparison
+type TeamQuality struct {
+	Team     string
+	Runs     int
+	Passed   int
+	Failed   int
+	Excluded int
+	PassRate float64
+	// PreviousPassRate is only meaningful with HasPrevious set
+	PreviousPassRate float64
+	HasPrevious      bool
+}
+
+// Change is the pass rate's change since the previous week, in percentage
+// points
+func (t TeamQuality) Change() float64 {
+	if !t.HasPrevious {
+		return 0
+	}
+	return t.PassRate - t.PreviousPassRate
+}
+
+// Regression is a workflow whose pass rate dropped since the previous week
+type Regression struct {
+	Workflow         string
+	Team             string
+	Runs             int
+	Failed           int
+	PassRate         float64
+	PreviousPassRate float64
+}
+
+// Drop is the fall in pass rate, in percentage points
+func (r Regression) Drop() float64 {
+	return r.PreviousPassRate - r.PassRate
+}
+
+// FlakyDebtWeek is the org's flaky debt in one week of the trend
+type FlakyDebtWeek struct {
+	Week       time.Time
+	FlakyTests int
+	Debt       float64
+}
+
+// EnvironmentUsage is how much one environment type was used in the week
+type EnvironmentUsage struct {
+	Type    environments.EnvironmentType
+	Created int
+	// Hours sums the time environments of the type existed in the week
+	Hours float64
+}
+
+// WeeklyReport summarizes the org's test quality over one week, Monday to
+// Monday in UTC
+type WeeklyReport struct {
+	Week        time.Time
+	To          time.Time
+	GeneratedAt time.Time
+
+	Runs             int
+	Passed           int
+	Failed           int
+	Excluded         int
+	PassRate         float64
+	PreviousPassRate float64
+	HasPrevious      bool
+
+	Teams        []TeamQuality
+	Regressions  []Regression
+	FlakyDebt    []FlakyDebtWeek // oldest first, ending with this week
+	Environments []EnvironmentUsage
+
+	RunnerHours       float64
+	CostPerRunnerHour float64
+	Cost              float64
+}
+
+// Period is the key the report is stored under, the date its week starts
+func (r *WeeklyReport) Period() string {
+	return r.Week.Format("2006-01-02")
+}
+
+// Change is the org pass rate's change since the previous week, in
+// percentage points
+func (r *WeeklyReport) Change() float64 {
+	if !r.HasPrevious {
+		return 0
+	}
+	return r.PassRate - r.PreviousPassRate
+}
+
+// WeekStart returns the Monday, at midnight UTC, of the week t falls in
+func WeekStart(t time.Time) time.Time {
+	t = t.UTC()
+	day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+	return day.AddDate(0, 0, -((int(day.Weekday()) + 6) % 7))
+}
+
+// BuildWeeklyReport compiles the report for the week starting at week, a
+// Monday as returned by WeekStart, from the ingested executions, flaky
+// scores and the environments envs lists. now bounds the usage of
+// environments still running.
+func BuildWeeklyReport(db database.Database, teams TeamResolver, envs []environments.Environment, week, now time.Time, opts WeeklyOptions) (*WeeklyReport, error) {
+	to := week.AddDate(0, 0, 7)
+	report := &WeeklyReport{Week: week, To: to, GeneratedAt: now, CostPerRunnerHour: opts.CostPerRunnerHour}
+
+	current, _, err := db.QueryExecutions(database.ExecutionQuery{From: week, To: to})
+	if err != nil {
+		return nil, fmt.Errorf("failed to query the week's executions: %w", err)
+	}
+	previous, _, err := db.QueryExecutions(database.ExecutionQuery{From: week.AddDate(0, 0, -7), To: week})
+	if err != nil {
+		return nil, fmt.Errorf("failed to query the previous week's executions: %w", err)
+	}
+
+	var org, previousOrg runCounts
+	byWorkflow, previousByWorkflow := map[string]*runCounts{}, map[string]*runCounts{}
+	var runTime time.Duration
+	for _, exec := range current {
+		org.add(exec)
+		tally(byWorkflow, exec.WorkflowName).add(exec)
+		runTime += exec.Duration
+	}
+	for _, exec := range previous {
+		previousOrg.add(exec)
+		tally(previousByWorkflow, exec.WorkflowName).add(exec)
+	}
+	report.Runs, report.Passed, report.Failed, report.Excluded = org.Runs, org.Passed, org.Failed, org.Excluded
+	report.PassRate, _ = org.passRate()
+	report.PreviousPassRate, report.HasPrevious = previousOrg.passRate()
+	report.RunnerHours = runTime.Hours()
+	report.Cost = report.RunnerHours * opts.CostPerRunnerHour
+
+	report.Teams = teamQuality(teams, byWorkflow, previousByWorkflow)
+	report.Regressions = truncate(regressions(teams, byWorkflow, previousByWorkflow), opts.Limit)
+
+	trendWeeks := opts.TrendWeeks
+	if trendWeeks <= 0 {
+		trendWeeks = DefaultTrendWeeks
+	}
+	for i := trendWeeks - 1; i >= 0; i-- {
+		from := week.AddDate(0, 0, -7*i)
+		flaky, err := db.GetFlakyTestsBetween(from, from.AddDate(0, 0, 7))
+		if err != nil {
+			return nil, fmt.Errorf("failed to score flaky tests: %w", err)
+		}
+		point := FlakyDebtWeek{Week: from, FlakyTests: len(flaky)}
+		for _, t := range flaky {
+			point.Debt += t.FlakyScore
+		}
+		report.FlakyDebt = append(report.FlakyDebt, point)
+	}
+
+	end := to
+	if now.Before(end) {
+		end = now
+	}
+	report.Environments = environmentUsage(envs, week, end)
+	return report, nil
+}
+
+func tally(counts map[string]*runCounts, key string) *runCounts {
+	c, ok := counts[key]
+	if !ok {
+		c = &runCounts{}
+		counts[key] = c
+	}
+	return c
+}
+
+// teamQuality rolls workflows up to the teams owning them, by runs
+func teamQuality(teams TeamResolver, current, previous map[string]*runCounts) []TeamQuality {
+	byTeam, previousByTeam := map[string]*runCounts{}, map[string]*runCounts{}
+	for workflow, c := range current {
+		team, _ := teams.Resolve(workflow, "")
+		t := tally(byTeam, team)
+		t.Runs += c.Runs
+		t.Passed += c.Passed
+		t.Failed += c.Failed
+		t.Excluded += c.Excluded
+	}
+	for workflow, c := range previous {
+		team, _ := teams.Resolve(workflow, "")
+		t := tally(previousByTeam, team)
+		t.Passed += c.Passed
+		t.Failed += c.Failed
+	}
+
+	var quality []TeamQuality
+	for team, c := range byTeam {
+		q := TeamQuality{Team: team, Runs: c.Runs, Passed: c.Passed, Failed: c.Failed, Excluded: c.Excluded}
+		q.PassRate, _ = c.passRate()
+		if p, ok := previousByTeam[team]; ok {
+			q.PreviousPassRate, q.HasPrevious = p.passRate()
+		}
+		quality = append(quality, q)
+	}
+	sort.Slice(quality, func(i, j int) bool {
+		if quality[i].Runs != quality[j].Runs {
+			return quality[i].Runs > quality[j].Runs
+		}
+		return quality[i].Team < quality[j].Team
+	})
+	return quality
+}
+
+// regressions lists the workflows whose pass rate dropped, the largest
+// drop first
+func regressions(teams TeamResolver, current, previous map[string]*runCounts) []Regression {
+	var list []Regression
+	for workflow, c := range current {
+		rate, ok := c.passRate()
+		if !ok {
+			continue
+		}
+		p, ok := previous[workflow]
+		if !ok {
+			continue
+		}
+		previousRate, ok := p.passRate()
+		if !ok || rate >= previousRate {
+			continue
+		}
+		team, _ := teams.Resolve(workflow, "")
+		list = append(list, Regression{Workflow: workflow, Team: team, Runs: c.Runs, Failed: c.Failed, PassRate: rate, PreviousPassRate: previousRate})
+	}
+	sort.Slice(list, func(i, j int) bool {
+		if list[i].Drop() != list[j].Drop() {
+			return list[i].Drop() > list[j].Drop()
+		}
+		return list[i].Workflow < list[j].Workflow
+	})
+	return list
+}
+
+// environmentUsage counts the environments created in the window by type
+// and the hours each type's environments existed in it
+func environmentUsage(envs []environments.Environment, from, to time.Time) []EnvironmentUsage {
+	byType := map[environments.EnvironmentType]*EnvironmentUsage{}
+	for _, env := range envs {
+		end := to
+		if env.DeletedAt != nil && env.DeletedAt.Before(end) {
+			end = *env.DeletedAt
+		}
+		start := env.CreatedAt
+		if start.Before(from) {
+			start = from
+		}
+		if !end.After(start) {
+			continue
+		}
+		usage, ok := byType[env.Type]
+		if !ok {
+			usage = &EnvironmentUsage{Type: env.Type}
+			byType[env.Type] = usage
+		}
+		if !env.CreatedAt.Before(from) {
+			usage.Created++
+		}
+		usage.Hours += end.Sub(start).Hours()
+	}
+
+	usage := make([]EnvironmentUsage, 0, len(byType))
+	for _, u := range byType {
+		usage = append(usage, *u)
+	}
+	sort.Slice(usage, func(i, j int) bool { return usage[i].Type < usage[j].Type })
+	return usage
+}
+
+// Summary is the report in a few lines, for a chat message linking to it
+func (r *WeeklyReport) Summary() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%d runs, %.1f%% passed", r.Runs, r.PassRate)
+	if r.HasPrevious {
+		fmt.Fprintf(&b, " (%+.1f points)", r.Change())
+	}
+	if r.Excluded > 0 {
+		fmt.Fprintf(&b, ", %d infra errors, timeouts or aborted runs left out", r.Excluded)
+	}
+	b.WriteString("\n")
+	if len(r.Regressions) > 0 {
+		top := r.Regressions[0]
+		fmt.Fprintf(&b, "Top regression: %s, %.0f%% to %.0f%%\n", top.Workflow, top.PreviousPassRate, top.PassRate)
+	}
+	if n := len(r.FlakyDebt); n > 0 {
+		fmt.Fprintf(&b, "Flaky debt: %.2f across %d tests\n", r.FlakyDebt[n-1].Debt, r.FlakyDebt[n-1].FlakyTests)
+	}
+	if r.CostPerRunnerHour > 0 {
+		fmt.Fprintf(&b, "Cost: %.2f for %.1f runner hours\n", r.Cost, r.RunnerHours)
+	}
+	return strings.TrimSuffix(b.String(), "\n")
+}
+
+// Markdown renders the report for pasting into leadership updates
+func (r *WeeklyReport) Markdown() string {
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "# Weekly Quality Report: %s to %s\n\n", r.Week.Format("2006-01-02"), r.To.AddDate(0, 0, -1).Format("2006-01-02"))
+	b.WriteString(r.Summary() + "\n")
+
+	b.WriteString("\n## Pass Rate by Team\n\n")
+	if len(r.Teams) == 0 {
+		b.WriteString("No runs this week.\n")
+	} else {
+		b.WriteString("| Team | Runs | Pass Rate | Change | Left Out |\n")
+		b.WriteString("|------|------|-----------|--------|----------|\n")
+		for _, t := range r.Teams {
+			change := "-"
+			if t.HasPrevious {
+				change = fmt.Sprintf("%+.1f", t.Change())
+			}
+			fmt.Fprintf(&b, "| %s | %d | %.1f%% | %s | %d |\n", escapeCell(t.Team), t.Runs, t.PassRate, change, t.Excluded)
+		}
+	}
+
+	b.WriteString("\n## Top Regressions\n\n")
+	if len(r.Regressions) == 0 {
+		b.WriteString("No workflow's pass rate dropped this week.\n")
+	} else {
+		b.WriteString("| Workflow | Team | Before | Now | Failed / Runs |\n")
+		b.WriteString("|----------|------|--------|-----|---------------|\n")
+		for _, reg := range r.Regressions {
+			fmt.Fprintf(&b, "| %s | %s | %.0f%% | %.0f%% | %d / %d |\n",
+				escapeCell(reg.Workflow), escapeCell(reg.Team), reg.PreviousPassRate, reg.PassRate, reg.Failed, reg.Runs)
+		}
+	}
+
+	b.WriteString("\n## Flaky Debt Trend\n\n")
+	b.WriteString("| Week | Flaky Tests | Debt |\n")
+	b.WriteString("|------|-------------|------|\n")
+	for _, w := range r.FlakyDebt {
+		fmt.Fprintf(&b, "| %s | %d | %.2f |\n", w.Week.Format("2006-01-02"), w.FlakyTests, w.Debt)
+	}
+
+	b.WriteString("\n## Environment Usage\n\n")
+	if len(r.Environments) == 0 {
+		b.WriteString("No environments were used this week.\n")
+	} else {
+		b.WriteString("| Type | Created | Hours |\n")
+		b.WriteString("|------|---------|-------|\n")
+		for _, e := range r.Environments {
+			fmt.Fprintf(&b, "| %s | %d | %.1f |\n", e.Type, e.Created, e.Hours)
+		}
+	}
+
+	fmt.Fprintf(&b, "\n## Cost\n\n%.1f runner hours", r.RunnerHours)
+	if r.CostPerRunnerHour > 0 {
+		fmt.Fprintf(&b, " at %.2f an hour: %.2f", r.CostPerRunnerHour, r.Cost)
+	}
+	b.WriteString("\n")
+	return b.String()
+}
@@ -0,0 +1,96 @@
+package reports
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/testkube/dashboard/internal/database"
+	"github.com/testkube/dashboard/internal/environments"
+	"github.com/testkube/dashboard/internal/testkube"
+)
+
+func TestWeekStart(t *testing.T) {
+	for _, tc := range []struct{ in, want string }{
+		{"2026-05-13T15:04:05Z", "2026-05-11"}, // Wednesday
+		{"2026-05-11T00:00:00Z", "2026-05-11"}, // Monday
+		{"2026-05-17T23:59:59Z", "2026-05-11"}, // Sunday
+	} {
+		in, _ := time.Parse(time.RFC3339, tc.in)
+		if got := WeekStart(in).Format("2006-01-02"); got != tc.want {
+			t.Errorf("WeekStart(%s): got %s, expected %s", tc.in, got, tc.want)
+		}
+	}
+}
+
+func TestBuildWeeklyReport(t *testing.T) {
+	db := database.NewMockDatabase()
+	week := time.Date(2026, 5, 11, 0, 0, 0, 0, time.UTC)
+	runs := func(workflow string, start time.Time, outcomes ...string) (ids []string) {
+		for i, outcome := range outcomes {
+			exec := testkube.Execution{
+				ID:           fmt.Sprintf("%s-%d-%d", workflow, start.Unix(), i),
+				WorkflowName: workflow,
+				Status:       outcome,
+				Outcome:      outcome,
+				StartTime:    start.Add(time.Duration(i) * time.Hour),
+				Duration:     30 * time.Minute,
+			}
+			if outcome == testkube.OutcomeInfraError {
+				exec.Status = "failed"
+			}
+			db.InsertExecution(exec)
+			ids = append(ids, exec.ID)
+		}
+		return ids
+	}
+	runs("checkout-e2e", week.AddDate(0, 0, -6), "passed", "passed", "passed", "passed")
+	for i, id := range runs("checkout-e2e", week.AddDate(0, 0, 1), "passed", "failed", "failed", "passed") {
+		db.InsertTestCase(database.TestCase{ExecutionID: id, TestName: "pay", Status: []string{"passed", "failed"}[i%2]})
+	}
+	runs("api-smoke", week.AddDate(0, 0, -6), "passed", "failed")
+	runs("api-smoke", week.AddDate(0, 0, 2), "passed", "passed", testkube.OutcomeInfraError)
+
+	deleted := week.AddDate(0, 0, 1)
+	envs := []environments.Environment{
+		{Type: environments.TypeEphemeral, CreatedAt: week.Add(-2 * time.Hour), DeletedAt: &deleted},
+		{Type: environments.TypeEphemeral, CreatedAt: week.AddDate(0, 0, 6)},
+		{Type: environments.TypeDevSandbox, CreatedAt: week.AddDate(0, 0, -30), DeletedAt: &week},
+	}
+
+	now := week.AddDate(0, 0, 7).Add(time.Hour)
+	report, err := BuildWeeklyReport(db, staticTeams{"checkout-e2e": "web", "api-smoke": "platform"}, envs, week, now, WeeklyOptions{CostPerRunnerHour: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if report.Runs != 7 || report.Passed != 4 || report.Failed != 2 || report.Excluded != 1 {
+		t.Errorf("got %d passed, %d failed and %d excluded, expected 4, 2 and the infra error", report.Passed, report.Failed, report.Excluded)
+	}
+	if !report.HasPrevious || int(report.PreviousPassRate) != 83 {
+		t.Errorf("got previous pass rate %v, expected 83%%", report.PreviousPassRate)
+	}
+	if len(report.Regressions) != 1 || report.Regressions[0].Workflow != "checkout-e2e" || report.Regressions[0].Drop() != 50 {
+		t.Errorf("got regressions %+v, expected checkout-e2e down 50 points", report.Regressions)
+	}
+	if len(report.Teams) != 2 || report.Teams[0].Team != "web" || report.Teams[1].Change() != 50 {
+		t.Errorf("got teams %+v, expected web first and platform up 50 points", report.Teams)
+	}
+	if n := len(report.FlakyDebt); n != DefaultTrendWeeks || report.FlakyDebt[n-1].FlakyTests != 1 {
+		t.Errorf("got flaky debt %+v, expected %d weeks ending with one flaky test", report.FlakyDebt, DefaultTrendWeeks)
+	}
+	if len(report.Environments) != 1 || report.Environments[0].Created != 1 || report.Environments[0].Hours != 48 {
+		t.Errorf("got environments %+v, expected one ephemeral created and 48 hours", report.Environments)
+	}
+	if report.RunnerHours != 3.5 || report.Cost != 7 {
+		t.Errorf("got %v runner hours costing %v", report.RunnerHours, report.Cost)
+	}
+
+	md := report.Markdown()
+	for _, want := range []string{"# Weekly Quality Report: 2026-05-11 to 2026-05-17", "| checkout-e2e | web | 100% | 50% | 2 / 4 |", "| platform | 3 | 100.0% | +50.0 | 1 |", "| ephemeral | 1 | 48.0 |"} {
+		if !strings.Contains(md, want) {
+			t.Errorf("markdown missing %q:\n%s", want, md)
+		}
+	}
+}
@@ -0,0 +1,103 @@
+package runqueue
+
+import (
+	"fmt"
+	"log"
+	"maps"
+	"os"
+	"strings"
+
+	"github.com/testkube/dashboard/internal/testkube"
+)
+
+// Priority is the class of a run request. Higher classes start first when
+// runs wait for a slot and, where configured, get a higher Kubernetes pod
+// priority.
+type Priority string
+
+const (
+	PriorityCritical Priority = "critical"
+	PriorityNormal   Priority = "normal"
+	PriorityBulk     Priority = "bulk"
+)
+
+// PriorityTag records a run's priority class on its execution
+const PriorityTag = "priority"
+
+// Priorities lists the classes from highest to lowest
+var Priorities = []Priority{PriorityCritical, PriorityNormal, PriorityBulk}
+
+// ParsePriority validates a priority class; empty means normal
+func ParsePriority(s string) (Priority, error) {
+	if s == "" {
+		return PriorityNormal, nil
+	}
+	for _, p := range Priorities {
+		if string(p) == strings.ToLower(s) {
+			return p, nil
+		}
+	}
+	return "", fmt.Errorf("invalid priority %q: must be critical, normal or bulk", s)
+}
+
+// rank orders classes, lowest first
+func (p Priority) rank() int {
+	switch p {
+	case PriorityCritical:
+		return 0
+	case PriorityBulk:
+		return 2
+	}
+	return 1
+}
+
+// priorityMapping passes priority classes on to Testkube. Workflows that
+// template their pod's priorityClassName from a config variable, e.g.
+// `priorityClassName: "{{ config.priorityClass }}"`, get the Kubernetes
+// PriorityClass mapped from the run's class.
+type priorityMapping struct {
+	variable string
+	classes  map[Priority]string
+}
+
+// priorityMappingFromEnv reads PRIORITY_CONFIG_VARIABLE, the workflow config
+// variable to set, and PRIORITY_CLASSES, e.g.
+// "critical=tests-critical,bulk=tests-bulk". Classes without a mapping only
+// tag the execution.
+func priorityMappingFromEnv() priorityMapping {
+	m := priorityMapping{
+		variable: os.Getenv("PRIORITY_CONFIG_VARIABLE"),
+		classes:  make(map[Priority]string),
+	}
+	for _, pair := range strings.Split(os.Getenv("PRIORITY_CLASSES"), ",") {
+		name, class, ok := strings.Cut(strings.TrimSpace(pair), "=")
+		if !ok {
+			continue
+		}
+		p, err := ParsePriority(name)
+		if err != nil {
+			log.Printf("Warning: ignoring PRIORITY_CLASSES entry %q: %v", pair, err)
+			continue
+		}
+		m.classes[p] = strings.TrimSpace(class)
+	}
+	return m
+}
+
+// apply returns opts with the priority tag and, when mapped, the pod
+// priority class config variable
+func (m priorityMapping) apply(opts testkube.RunOptions, p Priority) testkube.RunOptions {
+	tags := maps.Clone(opts.Tags)
+	if tags == nil {
+		tags = make(map[string]string)
+	}
+	tags[PriorityTag] = string(p)
+	opts.Tags = tags
+
+	if class := m.classes[p]; class != "" && m.variable != "" {
+		config := map[string]string{m.variable: class}
+		maps.Copy(config, opts.Config)
+		opts.Config = config
+	}
+	return opts
+}
@@ -0,0 +1,529 @@
+package runqueue
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path"
+	"slices"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/testkube/dashboard/internal/testkube"
+)
+
+const (
+	// dispatchInterval is how often queued runs are checked for a free slot
+	dispatchInterval = 10 * time.Second
+	// startGrace counts runs the dashboard started as active until the
+	// Testkube API lists them, which can lag a few seconds
+	startGrace = 30 * time.Second
+	// dispatchedRetention is how long the execution a queued run started
+	// as is remembered
+	dispatchedRetention = time.Hour
+	// activePageSize bounds how many active executions are counted per status
+	activePageSize = 100
+)
+
+// activeStatuses are the execution states that occupy a concurrency slot
+var activeStatuses = []string{"queued", "running"}
+
+// ErrQueueFull is returned when a workflow already has MaxQueued runs waiting
+var ErrQueueFull = errors.New("run queue is full")
+
+// Limit caps how many runs of matching workflows may be active at once
+type Limit struct {
+	Workflow      string `json:"workflow"` // glob, e.g. "*-load-test"
+	MaxConcurrent int    `json:"maxConcurrent"`
+	// MaxQueued bounds how many runs may wait for a slot; 0 means no bound
+	MaxQueued int `json:"maxQueued,omitempty"`
+}
+
+type limitsConfig struct {
+	Limits []Limit `json:"limits"`
+}
+
+// Entry is a run waiting for a concurrency slot or its run window
+type Entry struct {
+	ID       string              `json:"id"`
+	Workflow string              `json:"workflow"`
+	Priority Priority            `json:"priority"`
+	Options  testkube.RunOptions `json:"options"`
+	QueuedAt time.Time           `json:"queuedAt"`
+	// NotBefore holds the run until this time, e.g. when its window opens
+	NotBefore time.Time `json:"notBefore,omitzero"`
+	// Position is 1 for the next run of the workflow to start; higher
+	// priorities go first, then the oldest
+	Position int `json:"position"`
+}
+
+func (e *Entry) due(now time.Time) bool {
+	return e.NotBefore.IsZero() || !now.Before(e.NotBefore)
+}
+
+type dispatchedRun struct {
+	executionID string
+	at          time.Time
+}
+
+type recentStart struct {
+	workflow string
+	at       time.Time
+}
+
+// Queue starts workflow runs, holding them locally while their workflow is
+// at its concurrency limit and starting them in order as slots free up.
+// Every run path (run buttons, reruns, schedules) should go through Submit.
+type Queue struct {
+	api      testkube.Client
+	config   limitsConfig
+	priority priorityMapping
+
+	entries []*Entry
+	// recent are runs started here that the API may not list yet
+	recent map[string]recentStart
+	// dispatched maps queued runs that have started to their execution,
+	// for callers waiting on a run they queued
+	dispatched map[string]dispatchedRun
+	mu         sync.Mutex
+	// admitMu serializes counting active runs and starting one, so two
+	// concurrent submissions can't both take the last slot
+	admitMu sync.Mutex
+	now     func() time.Time
+}
+
+// NewQueue creates a queue, loading limits from the JSON file in
+// RUN_LIMITS_FILE when set, and starts dispatching queued runs
+func NewQueue(api testkube.Client) (*Queue, error) {
+	q := newQueue(api)
+	go q.dispatchLoop()
+
+	if file := os.Getenv("RUN_LIMITS_FILE"); file != "" {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return q, fmt.Errorf("failed to read run limits file: %w", err)
+		}
+		if err := q.Import(data); err != nil {
+			return q, err
+		}
+	}
+
+	return q, nil
+}
+
+func newQueue(api testkube.Client) *Queue {
+	return &Queue{
+		api:        api,
+		priority:   priorityMappingFromEnv(),
+		recent:     make(map[string]recentStart),
+		dispatched: make(map[string]dispatchedRun),
+		now:        time.Now,
+	}
+}
+
+func generateID() string {
+	bytes := make([]byte, 4)
+	rand.Read(bytes)
+	return hex.EncodeToString(bytes)
+}
+
+// LimitFor returns the limit of the first rule matching the workflow
+func (q *Queue) LimitFor(workflow string) (Limit, bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return q.limitFor(workflow)
+}
+
+func (q *Queue) limitFor(workflow string) (Limit, bool) {
+	for _, limit := range q.config.Limits {
+		if ok, _ := path.Match(limit.Workflow, workflow); ok {
+			return limit, true
+		}
+	}
+	return Limit{}, false
+}
+
+// Submit starts a run now if its workflow has a free slot, returning the
+// execution, or queues it and returns the queue entry. It returns
+// ErrQueueFull when the workflow's queue is at MaxQueued.
+func (q *Queue) Submit(workflow string, priority Priority, opts testkube.RunOptions) (*testkube.Execution, *Entry, error) {
+	q.admitMu.Lock()
+	defer q.admitMu.Unlock()
+
+	limit, limited := q.LimitFor(workflow)
+	if !limited {
+		return q.start(workflow, priority, opts)
+	}
+
+	// Runs already waiting for a slot go first
+	if !q.hasDue(workflow) {
+		active, err := q.active(workflow)
+		if err != nil {
+			return nil, nil, err
+		}
+		if active < limit.MaxConcurrent {
+			return q.start(workflow, priority, opts)
+		}
+	}
+
+	entry, err := q.enqueue(workflow, priority, opts, time.Time{})
+	if err != nil {
+		return nil, nil, err
+	}
+	log.Printf("Queued run %s of %s at position %d (limit of %d concurrent runs)", entry.ID, workflow, entry.Position, limit.MaxConcurrent)
+	return nil, entry, nil
+}
+
+// SubmitAfter queues a run to start once notBefore has passed and its
+// workflow has a free slot
+func (q *Queue) SubmitAfter(workflow string, priority Priority, opts testkube.RunOptions, notBefore time.Time) (*Entry, error) {
+	entry, err := q.enqueue(workflow, priority, opts, notBefore)
+	if err != nil {
+		return nil, err
+	}
+	log.Printf("Queued run %s of %s until %s", entry.ID, workflow, notBefore.Format(time.RFC3339))
+	return entry, nil
+}
+
+func (q *Queue) enqueue(workflow string, priority Priority, opts testkube.RunOptions, notBefore time.Time) (*Entry, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	waiting := q.waitingLocked(workflow)
+	if limit, ok := q.limitFor(workflow); ok && limit.MaxQueued > 0 && waiting >= limit.MaxQueued {
+		return nil, fmt.Errorf("%w: %s already has %d runs waiting", ErrQueueFull, workflow, waiting)
+	}
+	entry := &Entry{
+		ID:        generateID(),
+		Workflow:  workflow,
+		Priority:  priority,
+		Options:   opts,
+		QueuedAt:  q.now(),
+		NotBefore: notBefore,
+	}
+	q.entries = append(q.entries, entry)
+	q.sortLocked()
+
+	for _, e := range q.entries {
+		if e.Workflow == workflow {
+			entry.Position++
+		}
+		if e == entry {
+			break
+		}
+	}
+	copied := *entry
+	return &copied, nil
+}
+
+// sortLocked keeps entries in start order: by priority, then oldest first
+func (q *Queue) sortLocked() {
+	sort.SliceStable(q.entries, func(i, j int) bool {
+		a, b := q.entries[i], q.entries[j]
+		if a.Priority.rank() != b.Priority.rank() {
+			return a.Priority.rank() < b.Priority.rank()
+		}
+		if !a.QueuedAt.Equal(b.QueuedAt) {
+			return a.QueuedAt.Before(b.QueuedAt)
+		}
+		return a.ID < b.ID
+	})
+}
+
+// SetPriority changes the priority of a waiting run
+func (q *Queue) SetPriority(id string, priority Priority) (Entry, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	for _, e := range q.entries {
+		if e.ID == id {
+			e.Priority = priority
+			q.sortLocked()
+			return *e, nil
+		}
+	}
+	return Entry{}, fmt.Errorf("queued run not found: %s", id)
+}
+
+func (q *Queue) start(workflow string, priority Priority, opts testkube.RunOptions) (*testkube.Execution, *Entry, error) {
+	exec, err := q.api.RunWorkflow(workflow, q.priority.apply(opts, priority))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	q.mu.Lock()
+	q.recent[exec.ID] = recentStart{workflow: workflow, at: q.now()}
+	q.mu.Unlock()
+	return exec, nil, nil
+}
+
+// active counts the workflow's queued and running executions in Testkube,
+// plus runs started here that it doesn't know about yet
+func (q *Queue) active(workflow string) (int, error) {
+	ids := make(map[string]bool)
+	for _, status := range activeStatuses {
+		executions, err := q.api.GetExecutions(testkube.ListOptions{
+			Workflow: workflow,
+			Status:   status,
+			PageSize: activePageSize,
+		})
+		if err != nil {
+			return 0, fmt.Errorf("failed to count active runs of %s: %w", workflow, err)
+		}
+		for _, e := range executions {
+			ids[e.ID] = true
+		}
+	}
+
+	q.mu.Lock()
+	now := q.now()
+	var unlisted []string
+	for id, start := range q.recent {
+		if now.Sub(start.at) > startGrace {
+			delete(q.recent, id)
+			continue
+		}
+		if start.workflow == workflow && !ids[id] {
+			unlisted = append(unlisted, id)
+		}
+	}
+	q.mu.Unlock()
+
+	// A recent run missing from the active list has either finished already
+	// or isn't visible in the API yet; only the latter holds a slot
+	for _, id := range unlisted {
+		exec, err := q.api.GetExecution(id)
+		if err == nil && !slices.Contains(activeStatuses, exec.Status) {
+			q.mu.Lock()
+			delete(q.recent, id)
+			q.mu.Unlock()
+			continue
+		}
+		ids[id] = true
+	}
+	return len(ids), nil
+}
+
+// due counts the workflow's waiting runs that may start now
+func (q *Queue) due(workflow string) int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	now := q.now()
+	n := 0
+	for _, e := range q.entries {
+		if e.Workflow == workflow && e.due(now) {
+			n++
+		}
+	}
+	return n
+}
+
+func (q *Queue) hasDue(workflow string) bool {
+	return q.due(workflow) > 0
+}
+
+func (q *Queue) waitingLocked(workflow string) int {
+	n := 0
+	for _, e := range q.entries {
+		if e.Workflow == workflow {
+			n++
+		}
+	}
+	return n
+}
+
+// Entries returns the waiting runs in the order they will start
+func (q *Queue) Entries() []Entry {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	positions := make(map[string]int)
+	list := make([]Entry, 0, len(q.entries))
+	for _, e := range q.entries {
+		positions[e.Workflow]++
+		entry := *e
+		entry.Position = positions[e.Workflow]
+		list = append(list, entry)
+	}
+	return list
+}
+
+// Cancel removes a waiting run from the queue
+func (q *Queue) Cancel(id string) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	for i, e := range q.entries {
+		if e.ID == id {
+			q.entries = append(q.entries[:i], q.entries[i+1:]...)
+			return nil
+		}
+	}
+	return fmt.Errorf("queued run not found: %s", id)
+}
+
+// dispatchLoop dispatches on every tick, and as soon as a run of a workflow
+// with queued runs finishes and frees its slot
+func (q *Queue) dispatchLoop() {
+	ticker := time.NewTicker(dispatchInterval)
+	defer ticker.Stop()
+
+	events, err := q.api.WatchExecutions(context.Background())
+	if err != nil {
+		log.Printf("Warning: not watching executions, dispatching queued runs every %s: %v", dispatchInterval, err)
+	}
+	for {
+		select {
+		case <-ticker.C:
+		case event, ok := <-events:
+			if !ok {
+				events = nil
+				continue
+			}
+			if !event.Finished() || !slices.Contains(q.queuedWorkflows(), event.Execution.WorkflowName) {
+				continue
+			}
+		}
+		q.Dispatch()
+	}
+}
+
+// Dispatch starts due runs whose workflow has free slots, oldest first,
+// and returns the executions it started. Runs that fail to start are
+// dropped from the queue and logged.
+func (q *Queue) Dispatch() []testkube.Execution {
+	q.admitMu.Lock()
+	defer q.admitMu.Unlock()
+
+	var started []testkube.Execution
+	for _, workflow := range q.queuedWorkflows() {
+		limit, limited := q.LimitFor(workflow)
+		free := 0
+		if limited {
+			active, err := q.active(workflow)
+			if err != nil {
+				log.Printf("Error dispatching queued runs: %v", err)
+				continue
+			}
+			free = limit.MaxConcurrent - active
+		} else {
+			// Unlimited, or the limit was removed; only time holds these back
+			free = q.due(workflow)
+		}
+
+		for ; free > 0; free-- {
+			entry := q.pop(workflow)
+			if entry == nil {
+				break
+			}
+			exec, _, err := q.start(workflow, entry.Priority, entry.Options)
+			if err != nil {
+				log.Printf("Error starting queued run %s of %s: %v", entry.ID, workflow, err)
+				continue
+			}
+			log.Printf("Started queued run %s of %s as execution %s", entry.ID, workflow, exec.ID)
+			q.mu.Lock()
+			q.dispatched[entry.ID] = dispatchedRun{executionID: exec.ID, at: q.now()}
+			q.mu.Unlock()
+			started = append(started, *exec)
+		}
+	}
+
+	q.mu.Lock()
+	for id, run := range q.dispatched {
+		if q.now().Sub(run.at) > dispatchedRetention {
+			delete(q.dispatched, id)
+		}
+	}
+	q.mu.Unlock()
+	return started
+}
+
+// Started returns the execution a queued run started as. It reports false
+// while the run is waiting, and for runs that were cancelled, failed to
+// start or started over an hour ago.
+func (q *Queue) Started(id string) (string, bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	run, ok := q.dispatched[id]
+	return run.executionID, ok
+}
+
+// Waiting reports whether a run is still in the queue
+func (q *Queue) Waiting(id string) bool {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return slices.ContainsFunc(q.entries, func(e *Entry) bool { return e.ID == id })
+}
+
+// queuedWorkflows lists workflows with due runs, in queue order
+func (q *Queue) queuedWorkflows() []string {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	now := q.now()
+	seen := make(map[string]bool)
+	var workflows []string
+	for _, e := range q.entries {
+		if e.due(now) && !seen[e.Workflow] {
+			seen[e.Workflow] = true
+			workflows = append(workflows, e.Workflow)
+		}
+	}
+	return workflows
+}
+
+// pop removes and returns the oldest due run of a workflow
+func (q *Queue) pop(workflow string) *Entry {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	now := q.now()
+	for i, e := range q.entries {
+		if e.Workflow == workflow && e.due(now) {
+			q.entries = append(q.entries[:i], q.entries[i+1:]...)
+			return e
+		}
+	}
+	return nil
+}
+
+// Export returns the concurrency limits as JSON, for configuration sync
+func (q *Queue) Export() (json.RawMessage, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return json.Marshal(q.config)
+}
+
+// Import replaces the concurrency limits. Waiting runs stay queued and are
+// dispatched under the new limits.
+func (q *Queue) Import(data json.RawMessage) error {
+	var cfg limitsConfig
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return fmt.Errorf("failed to parse run limits: %w", err)
+	}
+
+	for _, limit := range cfg.Limits {
+		if _, err := path.Match(limit.Workflow, ""); err != nil {
+			return fmt.Errorf("invalid workflow pattern %q: %w", limit.Workflow, err)
+		}
+		if limit.MaxConcurrent < 1 {
+			return fmt.Errorf("maxConcurrent for %s must be at least 1", limit.Workflow)
+		}
+		if limit.MaxQueued < 0 {
+			return fmt.Errorf("maxQueued for %s must not be negative", limit.Workflow)
+		}
+	}
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.config = cfg
+	return nil
+}
@@ -0,0 +1,292 @@
+package runqueue
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/testkube/dashboard/internal/testkube"
+)
+
+// fakeClient tracks active executions per workflow
+type fakeClient struct {
+	testkube.Client
+	mu      sync.Mutex
+	active  map[string][]testkube.Execution
+	started int
+	// hidden executions are not visible in the API yet
+	hidden map[string]bool
+	// configs are the config variables each execution was started with
+	configs map[string]map[string]string
+}
+
+func (c *fakeClient) GetExecutions(opts testkube.ListOptions) ([]testkube.Execution, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	var result []testkube.Execution
+	for _, e := range c.active[opts.Workflow] {
+		if e.Status == opts.Status {
+			result = append(result, e)
+		}
+	}
+	return result, nil
+}
+
+func (c *fakeClient) RunWorkflow(name string, opts testkube.RunOptions) (*testkube.Execution, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.started++
+	exec := testkube.Execution{ID: fmt.Sprintf("exec-%d", c.started), WorkflowName: name, Status: "running", Labels: opts.Tags}
+	c.active[name] = append(c.active[name], exec)
+	c.configs[exec.ID] = opts.Config
+	return &exec, nil
+}
+
+func (c *fakeClient) GetExecution(id string) (*testkube.Execution, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.hidden[id] {
+		return nil, fmt.Errorf("execution not found")
+	}
+	for _, executions := range c.active {
+		for _, e := range executions {
+			if e.ID == id {
+				return &e, nil
+			}
+		}
+	}
+	return &testkube.Execution{ID: id, Status: "passed"}, nil
+}
+
+// finish marks the workflow's oldest active execution as done
+func (c *fakeClient) finish(workflow string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.active[workflow] = c.active[workflow][1:]
+}
+
+func newTestQueue(t *testing.T, limits string) (*Queue, *fakeClient) {
+	t.Helper()
+	api := &fakeClient{active: make(map[string][]testkube.Execution), hidden: make(map[string]bool), configs: make(map[string]map[string]string)}
+	q := newQueue(api)
+	if err := q.Import([]byte(limits)); err != nil {
+		t.Fatal(err)
+	}
+	return q, api
+}
+
+func TestSubmitQueuesAtLimit(t *testing.T) {
+	q, api := newTestQueue(t, `{"limits": [{"workflow": "*-load-test", "maxConcurrent": 2}]}`)
+
+	for i := 0; i < 2; i++ {
+		if exec, entry, err := q.Submit("api-load-test", PriorityNormal, testkube.RunOptions{}); err != nil || exec == nil || entry != nil {
+			t.Fatalf("run %d: exec=%v entry=%v err=%v, want started", i, exec, entry, err)
+		}
+	}
+
+	exec, entry, err := q.Submit("api-load-test", PriorityNormal, testkube.RunOptions{Tags: map[string]string{"n": "3"}})
+	if err != nil || exec != nil || entry == nil || entry.Position != 1 {
+		t.Fatalf("third run: exec=%v entry=%+v err=%v, want queued at 1", exec, entry, err)
+	}
+	if _, entry, _ := q.Submit("api-load-test", PriorityNormal, testkube.RunOptions{}); entry == nil || entry.Position != 2 {
+		t.Errorf("fourth run: entry=%+v, want queued at 2", entry)
+	}
+
+	// Unlimited workflows are never queued
+	if exec, _, _ := q.Submit("frontend-e2e", PriorityNormal, testkube.RunOptions{}); exec == nil {
+		t.Error("unlimited workflow should start immediately")
+	}
+
+	if started := q.Dispatch(); len(started) != 0 {
+		t.Errorf("dispatched %d runs with no free slots", len(started))
+	}
+
+	api.finish("api-load-test")
+	started := q.Dispatch()
+	if len(started) != 1 || started[0].Labels["n"] != "3" {
+		t.Fatalf("dispatched %+v, want the oldest queued run", started)
+	}
+	if entries := q.Entries(); len(entries) != 1 || entries[0].Position != 1 {
+		t.Errorf("entries = %+v, want one at position 1", entries)
+	}
+}
+
+func TestStartedTracksDispatchedRuns(t *testing.T) {
+	q, api := newTestQueue(t, `{"limits": [{"workflow": "k6", "maxConcurrent": 1}]}`)
+
+	q.Submit("k6", PriorityNormal, testkube.RunOptions{})
+	_, entry, _ := q.Submit("k6", PriorityNormal, testkube.RunOptions{})
+	if !q.Waiting(entry.ID) {
+		t.Fatal("the second run should be waiting")
+	}
+	if _, ok := q.Started(entry.ID); ok {
+		t.Error("a waiting run has not started")
+	}
+
+	api.finish("k6")
+	started := q.Dispatch()
+	if len(started) != 1 {
+		t.Fatalf("dispatched %d runs, want 1", len(started))
+	}
+	if id, ok := q.Started(entry.ID); !ok || id != started[0].ID {
+		t.Errorf("Started = %q, %v, want %q", id, ok, started[0].ID)
+	}
+	if q.Waiting(entry.ID) {
+		t.Error("a started run is no longer waiting")
+	}
+}
+
+func TestRecentStartsCountUntilListed(t *testing.T) {
+	q, api := newTestQueue(t, `{"limits": [{"workflow": "k6", "maxConcurrent": 1}]}`)
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	q.now = func() time.Time { return now }
+
+	exec, _, _ := q.Submit("k6", PriorityNormal, testkube.RunOptions{})
+	// The API doesn't know about the run yet
+	api.finish("k6")
+	api.hidden[exec.ID] = true
+
+	if _, entry, _ := q.Submit("k6", PriorityNormal, testkube.RunOptions{}); entry == nil {
+		t.Fatal("a run started moments ago should still hold the slot")
+	}
+
+	now = now.Add(startGrace + time.Second)
+	if started := q.Dispatch(); len(started) != 1 {
+		t.Errorf("dispatched %d runs after the grace period, want 1", len(started))
+	}
+}
+
+func TestQueueFullAndCancel(t *testing.T) {
+	q, _ := newTestQueue(t, `{"limits": [{"workflow": "soak", "maxConcurrent": 1, "maxQueued": 1}]}`)
+
+	q.Submit("soak", PriorityNormal, testkube.RunOptions{})
+	_, entry, err := q.Submit("soak", PriorityNormal, testkube.RunOptions{})
+	if err != nil || entry == nil {
+		t.Fatalf("second run should queue: %v", err)
+	}
+	if _, _, err := q.Submit("soak", PriorityNormal, testkube.RunOptions{}); !errors.Is(err, ErrQueueFull) {
+		t.Errorf("err = %v, want ErrQueueFull", err)
+	}
+
+	if err := q.Cancel(entry.ID); err != nil {
+		t.Fatal(err)
+	}
+	if err := q.Cancel(entry.ID); err == nil {
+		t.Error("expected an error cancelling a run twice")
+	}
+	if _, entry, err := q.Submit("soak", PriorityNormal, testkube.RunOptions{}); err != nil || entry == nil {
+		t.Errorf("cancelling should free the queue: entry=%v err=%v", entry, err)
+	}
+}
+
+func TestImportValidatesLimits(t *testing.T) {
+	q, _ := newTestQueue(t, `{"limits": []}`)
+	for _, data := range []string{
+		`{"limits": [{"workflow": "x", "maxConcurrent": 0}]}`,
+		`{"limits": [{"workflow": "[", "maxConcurrent": 1}]}`,
+		`{"limits": [{"workflow": "x", "maxConcurrent": 1, "maxQueued": -1}]}`,
+	} {
+		if err := q.Import([]byte(data)); err == nil {
+			t.Errorf("expected an error importing %s", data)
+		}
+	}
+}
+
+func TestSubmitAfterWaitsForTime(t *testing.T) {
+	q, _ := newTestQueue(t, `{"limits": []}`)
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	q.now = func() time.Time { return now }
+
+	entry, err := q.SubmitAfter("api-load-test", PriorityNormal, testkube.RunOptions{}, now.Add(6*time.Hour))
+	if err != nil || entry == nil {
+		t.Fatalf("SubmitAfter: entry=%v err=%v", entry, err)
+	}
+	if started := q.Dispatch(); len(started) != 0 {
+		t.Fatalf("dispatched %d runs before their time", len(started))
+	}
+
+	now = now.Add(6 * time.Hour)
+	if started := q.Dispatch(); len(started) != 1 {
+		t.Errorf("dispatched %d runs once due, want 1", len(started))
+	}
+}
+
+func TestPriorityOrdersQueue(t *testing.T) {
+	q, api := newTestQueue(t, `{"limits": [{"workflow": "k6", "maxConcurrent": 1}]}`)
+	q.priority = priorityMapping{variable: "priorityClass", classes: map[Priority]string{PriorityCritical: "tests-critical"}}
+
+	q.Submit("k6", PriorityNormal, testkube.RunOptions{})
+	_, bulk, _ := q.Submit("k6", PriorityBulk, testkube.RunOptions{})
+	_, normal, _ := q.Submit("k6", PriorityNormal, testkube.RunOptions{})
+	_, critical, _ := q.Submit("k6", PriorityCritical, testkube.RunOptions{})
+	if bulk.Position != 1 || normal.Position != 1 || critical.Position != 1 {
+		t.Errorf("positions = %d, %d, %d, want each to go ahead of bulk", bulk.Position, normal.Position, critical.Position)
+	}
+
+	var order []string
+	for _, e := range q.Entries() {
+		order = append(order, e.ID)
+	}
+	if want := []string{critical.ID, normal.ID, bulk.ID}; !reflect.DeepEqual(order, want) {
+		t.Errorf("order = %v, want %v", order, want)
+	}
+
+	if _, err := q.SetPriority(bulk.ID, PriorityCritical); err != nil {
+		t.Fatal(err)
+	}
+	if entries := q.Entries(); entries[0].ID != bulk.ID || entries[0].Position != 1 {
+		t.Errorf("promoted run should go ahead of the newer critical run, got %+v", entries)
+	}
+
+	api.finish("k6")
+	started := q.Dispatch()
+	if len(started) != 1 || started[0].Labels[PriorityTag] != "critical" {
+		t.Fatalf("started %+v, want the critical run", started)
+	}
+	api.mu.Lock()
+	defer api.mu.Unlock()
+	if got := api.configs[started[0].ID]["priorityClass"]; got != "tests-critical" {
+		t.Errorf("priorityClass config = %q, want tests-critical", got)
+	}
+}
+
+func TestSetPriorityKeepsOldestFirst(t *testing.T) {
+	q, _ := newTestQueue(t, `{"limits": [{"workflow": "k6", "maxConcurrent": 1}]}`)
+	now := time.Date(2026, 10, 17, 9, 0, 0, 0, time.UTC)
+	q.now = func() time.Time { return now }
+
+	q.Submit("k6", PriorityNormal, testkube.RunOptions{})
+	_, older, _ := q.Submit("k6", PriorityNormal, testkube.RunOptions{})
+	now = now.Add(time.Minute)
+	_, newer, _ := q.Submit("k6", PriorityNormal, testkube.RunOptions{})
+
+	if _, err := q.SetPriority(older.ID, PriorityBulk); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := q.SetPriority(older.ID, PriorityNormal); err != nil {
+		t.Fatal(err)
+	}
+
+	var order []string
+	for _, e := range q.Entries() {
+		order = append(order, e.ID)
+	}
+	if want := []string{older.ID, newer.ID}; !reflect.DeepEqual(order, want) {
+		t.Errorf("order = %v, want %v", order, want)
+	}
+}
+
+func TestParsePriority(t *testing.T) {
+	if p, err := ParsePriority(""); err != nil || p != PriorityNormal {
+		t.Errorf("empty priority = %q, %v, want normal", p, err)
+	}
+	if p, err := ParsePriority("Critical"); err != nil || p != PriorityCritical {
+		t.Errorf("Critical = %q, %v", p, err)
+	}
+	if _, err := ParsePriority("urgent"); err == nil {
+		t.Error("expected an error for an unknown class")
+	}
+}
@@ -0,0 +1,296 @@
+package runwindows
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Mode is what happens to a run requested outside its allowed times
+type Mode string
+
+const (
+	// ModeQueue holds the run and starts it at the next allowed time
+	ModeQueue Mode = "queue"
+	// ModeReject refuses the run, reporting the next allowed time
+	ModeReject Mode = "reject"
+)
+
+// searchDays is how far ahead the next allowed time is looked for
+const searchDays = 8
+
+var weekdays = map[string]time.Weekday{
+	"sun": time.Sunday, "mon": time.Monday, "tue": time.Tuesday, "wed": time.Wednesday,
+	"thu": time.Thursday, "fri": time.Friday, "sat": time.Saturday,
+}
+
+// Window is a weekly recurring period, e.g. weekdays 09:00-17:00. A window
+// whose end is before its start runs past midnight; Days are the days it
+// starts on, and no days means every day.
+type Window struct {
+	Days  []string `json:"days,omitempty"` // "mon" ... "sun"
+	Start string   `json:"start"`          // "HH:MM"
+	End   string   `json:"end"`            // "HH:MM"
+}
+
+func parseClock(s string) (time.Duration, error) {
+	t, err := time.Parse("15:04", s)
+	if err != nil {
+		return 0, fmt.Errorf("invalid time %q, expected HH:MM", s)
+	}
+	return time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute, nil
+}
+
+func (w Window) validate() error {
+	for _, day := range w.Days {
+		if _, ok := weekdays[strings.ToLower(day)]; !ok {
+			return fmt.Errorf("invalid day %q", day)
+		}
+	}
+	if _, err := parseClock(w.Start); err != nil {
+		return err
+	}
+	_, err := parseClock(w.End)
+	return err
+}
+
+func (w Window) onDay(day time.Weekday) bool {
+	if len(w.Days) == 0 {
+		return true
+	}
+	for _, d := range w.Days {
+		if weekdays[strings.ToLower(d)] == day {
+			return true
+		}
+	}
+	return false
+}
+
+// occurrence returns the window's span starting on the given local midnight
+func (w Window) occurrence(midnight time.Time) (time.Time, time.Time) {
+	start, _ := parseClock(w.Start)
+	end, _ := parseClock(w.End)
+	if end <= start {
+		end += 24 * time.Hour
+	}
+	return midnight.Add(start), midnight.Add(end)
+}
+
+// contains reports whether t falls inside an occurrence of the window,
+// including one that started the day before and runs past midnight
+func (w Window) contains(t time.Time) bool {
+	today := midnight(t)
+	for _, day := range []time.Time{today.AddDate(0, 0, -1), today} {
+		if !w.onDay(day.Weekday()) {
+			continue
+		}
+		if start, end := w.occurrence(day); !t.Before(start) && t.Before(end) {
+			return true
+		}
+	}
+	return false
+}
+
+func midnight(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+}
+
+// Blackout is a one-off period when runs are not allowed, e.g. a release freeze
+type Blackout struct {
+	From   time.Time `json:"from"`
+	To     time.Time `json:"to"`
+	Reason string    `json:"reason,omitempty"`
+}
+
+// Rule restricts when workflows matching a glob may be triggered. With
+// Allow set, runs must fall inside one of its windows; runs are never
+// allowed inside a Deny window or a blackout.
+type Rule struct {
+	Workflow  string     `json:"workflow"`           // glob, e.g. "*-load-test"
+	Mode      Mode       `json:"mode,omitempty"`     // defaults to queue
+	Timezone  string     `json:"timezone,omitempty"` // IANA name, defaults to UTC
+	Allow     []Window   `json:"allow,omitempty"`
+	Deny      []Window   `json:"deny,omitempty"`
+	Blackouts []Blackout `json:"blackouts,omitempty"`
+
+	location *time.Location
+}
+
+// allows reports whether the rule permits a run at t, and why not
+func (r Rule) allows(t time.Time) (bool, string) {
+	for _, b := range r.Blackouts {
+		if !t.Before(b.From) && t.Before(b.To) {
+			reason := "blackout period"
+			if b.Reason != "" {
+				reason += ": " + b.Reason
+			}
+			return false, reason
+		}
+	}
+
+	local := t.In(r.location)
+	for _, w := range r.Deny {
+		if w.contains(local) {
+			return false, fmt.Sprintf("blocked %s-%s", w.Start, w.End)
+		}
+	}
+	if len(r.Allow) == 0 {
+		return true, ""
+	}
+	for _, w := range r.Allow {
+		if w.contains(local) {
+			return true, ""
+		}
+	}
+	return false, "outside allowed run windows"
+}
+
+// boundaries returns the times after t, within searchDays, where the
+// rule's answer can change
+func (r Rule) boundaries(t time.Time) []time.Time {
+	var times []time.Time
+	for _, b := range r.Blackouts {
+		times = append(times, b.From, b.To)
+	}
+
+	first := midnight(t.In(r.location)).AddDate(0, 0, -1)
+	for i := 0; i <= searchDays; i++ {
+		day := first.AddDate(0, 0, i)
+		for _, windows := range [][]Window{r.Allow, r.Deny} {
+			for _, w := range windows {
+				if w.onDay(day.Weekday()) {
+					start, end := w.occurrence(day)
+					times = append(times, start, end)
+				}
+			}
+		}
+	}
+
+	var after []time.Time
+	for _, b := range times {
+		if b.After(t) {
+			after = append(after, b)
+		}
+	}
+	sort.Slice(after, func(i, j int) bool { return after[i].Before(after[j]) })
+	return after
+}
+
+// Decision is whether a workflow may run at a given time
+type Decision struct {
+	Allowed bool   `json:"allowed"`
+	Mode    Mode   `json:"mode,omitempty"`
+	Reason  string `json:"reason,omitempty"`
+	// Next is the next time a run is allowed; zero if none within a week
+	Next time.Time `json:"next,omitzero"`
+}
+
+type config struct {
+	Rules []Rule `json:"rules"`
+}
+
+// Policy decides when workflows may be triggered
+type Policy struct {
+	config config
+	mu     sync.RWMutex
+}
+
+// NewPolicy creates a policy, loading rules from the JSON file in
+// RUN_WINDOWS_FILE when set. Without rules every run is allowed.
+func NewPolicy() (*Policy, error) {
+	p := &Policy{}
+
+	if file := os.Getenv("RUN_WINDOWS_FILE"); file != "" {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return p, fmt.Errorf("failed to read run windows file: %w", err)
+		}
+		if err := p.Import(data); err != nil {
+			return p, err
+		}
+	}
+
+	return p, nil
+}
+
+// Check decides whether the workflow may run at t, using the first rule
+// matching it
+func (p *Policy) Check(workflow string, t time.Time) Decision {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	for _, rule := range p.config.Rules {
+		if ok, _ := path.Match(rule.Workflow, workflow); !ok {
+			continue
+		}
+
+		allowed, reason := rule.allows(t)
+		if allowed {
+			return Decision{Allowed: true}
+		}
+		decision := Decision{Mode: rule.Mode, Reason: reason}
+		for _, b := range rule.boundaries(t) {
+			if ok, _ := rule.allows(b); ok {
+				decision.Next = b
+				break
+			}
+		}
+		return decision
+	}
+	return Decision{Allowed: true}
+}
+
+// Export returns the run window rules as JSON, for configuration sync
+func (p *Policy) Export() (json.RawMessage, error) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return json.Marshal(p.config)
+}
+
+// Import replaces the run window rules
+func (p *Policy) Import(data json.RawMessage) error {
+	var cfg config
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return fmt.Errorf("failed to parse run windows: %w", err)
+	}
+
+	for i, rule := range cfg.Rules {
+		if _, err := path.Match(rule.Workflow, ""); err != nil {
+			return fmt.Errorf("invalid workflow pattern %q: %w", rule.Workflow, err)
+		}
+		switch rule.Mode {
+		case "":
+			cfg.Rules[i].Mode = ModeQueue
+		case ModeQueue, ModeReject:
+		default:
+			return fmt.Errorf("invalid mode %q for %s: must be %q or %q", rule.Mode, rule.Workflow, ModeQueue, ModeReject)
+		}
+
+		loc, err := time.LoadLocation(rule.Timezone)
+		if err != nil {
+			return fmt.Errorf("invalid timezone %q for %s: %w", rule.Timezone, rule.Workflow, err)
+		}
+		cfg.Rules[i].location = loc
+
+		for _, w := range append(append([]Window{}, rule.Allow...), rule.Deny...) {
+			if err := w.validate(); err != nil {
+				return fmt.Errorf("invalid run window for %s: %w", rule.Workflow, err)
+			}
+		}
+		for _, b := range rule.Blackouts {
+			if !b.To.After(b.From) {
+				return fmt.Errorf("blackout for %s must end after it starts", rule.Workflow)
+			}
+		}
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.config = cfg
+	return nil
+}
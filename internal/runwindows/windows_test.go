@@ -0,0 +1,115 @@
+package runwindows
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+func newTestPolicy(t *testing.T, rules string) *Policy {
+	t.Helper()
+	p, err := NewPolicy()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := p.Import([]byte(rules)); err != nil {
+		t.Fatal(err)
+	}
+	return p
+}
+
+// 2024-05-01 is a Wednesday
+func at(day int, clock string) time.Time {
+	t, _ := time.Parse("2006-01-02 15:04", fmt.Sprintf("2024-05-%02d %s", day, clock))
+	return t
+}
+
+func TestBusinessHoursDeny(t *testing.T) {
+	p := newTestPolicy(t, `{"rules": [{
+		"workflow": "*-load-test",
+		"mode": "reject",
+		"deny": [{"days": ["mon", "tue", "wed", "thu", "fri"], "start": "09:00", "end": "17:00"}]
+	}]}`)
+
+	d := p.Check("api-load-test", at(1, "10:30"))
+	if d.Allowed || d.Mode != ModeReject {
+		t.Fatalf("got %+v, want rejected during business hours", d)
+	}
+	if !d.Next.Equal(at(1, "17:00")) {
+		t.Errorf("next = %v, want 17:00 the same day", d.Next)
+	}
+
+	if d := p.Check("api-load-test", at(1, "18:00")); !d.Allowed {
+		t.Errorf("evening run should be allowed, got %+v", d)
+	}
+	// Saturday
+	if d := p.Check("api-load-test", at(4, "10:30")); !d.Allowed {
+		t.Errorf("weekend run should be allowed, got %+v", d)
+	}
+	if d := p.Check("frontend-e2e", at(1, "10:30")); !d.Allowed {
+		t.Errorf("unmatched workflow should be allowed, got %+v", d)
+	}
+}
+
+func TestOvernightAllowWindow(t *testing.T) {
+	p := newTestPolicy(t, `{"rules": [{
+		"workflow": "soak",
+		"allow": [{"start": "22:00", "end": "06:00"}]
+	}]}`)
+
+	for _, tc := range []struct {
+		t       time.Time
+		allowed bool
+	}{
+		{at(1, "23:00"), true},
+		{at(2, "05:59"), true},
+		{at(2, "06:00"), false},
+		{at(2, "12:00"), false},
+	} {
+		if d := p.Check("soak", tc.t); d.Allowed != tc.allowed {
+			t.Errorf("at %v: allowed = %v, want %v", tc.t, d.Allowed, tc.allowed)
+		}
+	}
+
+	d := p.Check("soak", at(2, "12:00"))
+	if d.Mode != ModeQueue || !d.Next.Equal(at(2, "22:00")) {
+		t.Errorf("got %+v, want queued until 22:00", d)
+	}
+}
+
+func TestBlackoutAndTimezone(t *testing.T) {
+	p := newTestPolicy(t, `{"rules": [{
+		"workflow": "*",
+		"timezone": "America/New_York",
+		"deny": [{"start": "09:00", "end": "10:00"}],
+		"blackouts": [{"from": "2024-05-03T00:00:00Z", "to": "2024-05-04T00:00:00Z", "reason": "release freeze"}]
+	}]}`)
+
+	// 13:30 UTC is 09:30 in New York (EDT)
+	if d := p.Check("any", at(1, "13:30")); d.Allowed || !d.Next.Equal(at(1, "14:00")) {
+		t.Errorf("got %+v, want denied until 14:00 UTC", d)
+	}
+
+	d := p.Check("any", at(3, "02:00"))
+	if d.Allowed || d.Reason != "blackout period: release freeze" {
+		t.Fatalf("got %+v, want blackout", d)
+	}
+	if !d.Next.Equal(at(4, "00:00")) {
+		t.Errorf("next = %v, want the end of the blackout", d.Next)
+	}
+}
+
+func TestImportValidatesRules(t *testing.T) {
+	p := newTestPolicy(t, `{"rules": []}`)
+	for _, data := range []string{
+		`{"rules": [{"workflow": "x", "mode": "sometimes"}]}`,
+		`{"rules": [{"workflow": "x", "timezone": "Mars/Olympus"}]}`,
+		`{"rules": [{"workflow": "x", "deny": [{"start": "9am", "end": "17:00"}]}]}`,
+		`{"rules": [{"workflow": "x", "allow": [{"days": ["someday"], "start": "09:00", "end": "17:00"}]}]}`,
+		`{"rules": [{"workflow": "x", "blackouts": [{"from": "2024-05-02T00:00:00Z", "to": "2024-05-01T00:00:00Z"}]}]}`,
+	} {
+		if err := p.Import([]byte(data)); err == nil {
+			t.Errorf("expected an error importing %s", data)
+		}
+	}
+}
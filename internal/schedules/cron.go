@@ -0,0 +1,179 @@
+package schedules
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// macros are the shorthand schedules cron accepts
+var macros = map[string]string{
+	"@yearly":   "0 0 1 1 *",
+	"@annually": "0 0 1 1 *",
+	"@monthly":  "0 0 1 * *",
+	"@weekly":   "0 0 * * 0",
+	"@daily":    "0 0 * * *",
+	"@midnight": "0 0 * * *",
+	"@hourly":   "0 * * * *",
+}
+
+// field is the set of values a cron field allows
+type field struct {
+	values map[int]bool
+	any    bool // "*", which matters for the day fields
+}
+
+// Cron is a parsed five-field cron expression: minute, hour, day of month,
+// month and day of week
+type Cron struct {
+	minute, hour, dom, month, dow field
+}
+
+// ParseCron parses a cron expression such as "0 2 * * 1-5" or "@daily"
+func ParseCron(spec string) (*Cron, error) {
+	expr := strings.TrimSpace(spec)
+	if m, ok := macros[expr]; ok {
+		expr = m
+	}
+	parts := strings.Fields(expr)
+	if len(parts) != 5 {
+		return nil, fmt.Errorf("invalid cron %q: expected 5 fields", spec)
+	}
+
+	var c Cron
+	bounds := []struct {
+		f      *field
+		lo, hi int
+		name   string
+	}{
+		{&c.minute, 0, 59, "minute"},
+		{&c.hour, 0, 23, "hour"},
+		{&c.dom, 1, 31, "day of month"},
+		{&c.month, 1, 12, "month"},
+		{&c.dow, 0, 7, "day of week"},
+	}
+	for i, b := range bounds {
+		f, err := parseField(parts[i], b.lo, b.hi)
+		if err != nil {
+			return nil, fmt.Errorf("invalid cron %q: %s: %w", spec, b.name, err)
+		}
+		*b.f = f
+	}
+	// 7 is Sunday too
+	if c.dow.values[7] {
+		c.dow.values[0] = true
+	}
+	return &c, nil
+}
+
+// parseField parses a comma-separated list of values, ranges and steps
+func parseField(s string, lo, hi int) (field, error) {
+	f := field{values: make(map[int]bool), any: s == "*"}
+	for _, part := range strings.Split(s, ",") {
+		rng, step := part, 1
+		if i := strings.Index(part, "/"); i >= 0 {
+			n, err := strconv.Atoi(part[i+1:])
+			if err != nil || n <= 0 {
+				return f, fmt.Errorf("invalid step %q", part[i+1:])
+			}
+			rng, step = part[:i], n
+		}
+
+		from, to := lo, hi
+		if rng != "*" {
+			bounds := strings.SplitN(rng, "-", 2)
+			var err error
+			if from, err = strconv.Atoi(bounds[0]); err != nil {
+				return f, fmt.Errorf("invalid value %q", bounds[0])
+			}
+			to = from
+			if len(bounds) == 2 {
+				if to, err = strconv.Atoi(bounds[1]); err != nil {
+					return f, fmt.Errorf("invalid value %q", bounds[1])
+				}
+			} else if step > 1 {
+				// "5/15" means every 15 from 5
+				to = hi
+			}
+		}
+		if from < lo || to > hi || from > to {
+			return f, fmt.Errorf("%q is outside %d-%d", rng, lo, hi)
+		}
+		for v := from; v <= to; v += step {
+			f.values[v] = true
+		}
+	}
+	return f, nil
+}
+
+// onDay reports whether the expression runs on a day. As in cron, when both
+// day fields are restricted a day matching either of them is enough.
+func (c *Cron) onDay(day time.Time) bool {
+	if !c.month.values[int(day.Month())] {
+		return false
+	}
+	dom, dow := c.dom.values[day.Day()], c.dow.values[int(day.Weekday())]
+	switch {
+	case c.dom.any && c.dow.any:
+		return true
+	case c.dom.any:
+		return dow
+	case c.dow.any:
+		return dom
+	}
+	return dom || dow
+}
+
+// Between returns the times from from, inclusive, to to, exclusive, that
+// the expression fires at in the given location
+func (c *Cron) Between(from, to time.Time, loc *time.Location) []time.Time {
+	var times []time.Time
+	start := from.In(loc)
+	day := time.Date(start.Year(), start.Month(), start.Day(), 0, 0, 0, 0, loc)
+	for ; day.Before(to); day = day.AddDate(0, 0, 1) {
+		if !c.onDay(day) {
+			continue
+		}
+		for h := 0; h < 24; h++ {
+			if !c.hour.values[h] {
+				continue
+			}
+			for m := 0; m < 60; m++ {
+				if !c.minute.values[m] {
+					continue
+				}
+				t := time.Date(day.Year(), day.Month(), day.Day(), h, m, 0, 0, loc)
+				if !t.Before(from) && t.Before(to) {
+					times = append(times, t)
+				}
+			}
+		}
+	}
+	return times
+}
+
+// maxNextDays bounds the search for the next run, long enough for a leap
+// day schedule
+const maxNextDays = 8 * 366
+
+// Next returns the first time after after that the expression fires at in
+// the given location, or false if it never does (e.g. "0 0 31 2 *")
+func (c *Cron) Next(after time.Time, loc *time.Location) (time.Time, bool) {
+	from := after.Add(time.Nanosecond)
+	start := from.In(loc)
+	day := time.Date(start.Year(), start.Month(), start.Day(), 0, 0, 0, 0, loc)
+	for i := 0; i < maxNextDays; i, day = i+1, day.AddDate(0, 0, 1) {
+		if !c.onDay(day) {
+			continue
+		}
+		lo := from
+		if day.After(lo) {
+			lo = day
+		}
+		if times := c.Between(lo, day.AddDate(0, 0, 1), loc); len(times) > 0 {
+			return times[0], true
+		}
+	}
+	return time.Time{}, false
+}
@@ -0,0 +1,13 @@
+// Package schedules parses cron expressions and expands them into the
+// times workflows are expected to run, so missed runs can be told apart
+// from workflows that were never scheduled.
+package schedules
+
+import "time"
+
+// Slot is a time a workflow was scheduled to run
+type Slot struct {
+	Workflow string    `json:"workflow"`
+	Time     time.Time `json:"time"`
+	Cron     string    `json:"cron"`
+}
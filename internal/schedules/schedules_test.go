@@ -0,0 +1,65 @@
+package schedules
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCronBetween(t *testing.T) {
+	// Monday 3 June 2024 to Monday 10 June
+	from := time.Date(2024, 6, 3, 0, 0, 0, 0, time.UTC)
+	to := from.AddDate(0, 0, 7)
+
+	for _, tc := range []struct {
+		spec  string
+		count int
+		first time.Time
+	}{
+		{"0 2 * * *", 7, time.Date(2024, 6, 3, 2, 0, 0, 0, time.UTC)},
+		{"@daily", 7, from},
+		{"30 22 * * 1-5", 5, time.Date(2024, 6, 3, 22, 30, 0, 0, time.UTC)},
+		{"0 */6 * * 6,7", 8, time.Date(2024, 6, 8, 0, 0, 0, 0, time.UTC)},
+		// Either day field matches when both are restricted: the 5th or Sundays
+		{"0 0 5 * 0", 2, time.Date(2024, 6, 5, 0, 0, 0, 0, time.UTC)},
+	} {
+		c, err := ParseCron(tc.spec)
+		if err != nil {
+			t.Fatalf("%s: %v", tc.spec, err)
+		}
+		times := c.Between(from, to, time.UTC)
+		if len(times) != tc.count || !times[0].Equal(tc.first) {
+			t.Errorf("%s: got %d times starting %v, expected %d starting %v", tc.spec, len(times), times, tc.count, tc.first)
+		}
+	}
+
+	for _, spec := range []string{"", "* * * *", "60 * * * *", "0 0 * * 8", "*/0 * * * *", "a * * * *"} {
+		if _, err := ParseCron(spec); err == nil {
+			t.Errorf("expected %q to be rejected", spec)
+		}
+	}
+}
+
+func TestCronNext(t *testing.T) {
+	// Monday 3 June 2024, 02:00
+	after := time.Date(2024, 6, 3, 2, 0, 0, 0, time.UTC)
+	for _, tc := range []struct {
+		spec string
+		next time.Time
+	}{
+		// The time itself is excluded
+		{"0 2 * * *", time.Date(2024, 6, 4, 2, 0, 0, 0, time.UTC)},
+		{"*/15 * * * *", time.Date(2024, 6, 3, 2, 15, 0, 0, time.UTC)},
+		{"0 9 * * 6", time.Date(2024, 6, 8, 9, 0, 0, 0, time.UTC)},
+		{"0 0 29 2 *", time.Date(2028, 2, 29, 0, 0, 0, 0, time.UTC)},
+	} {
+		c, _ := ParseCron(tc.spec)
+		if next, ok := c.Next(after, time.UTC); !ok || !next.Equal(tc.next) {
+			t.Errorf("%s: got %v, %v, expected %v", tc.spec, next, ok, tc.next)
+		}
+	}
+
+	c, _ := ParseCron("0 0 31 2 *")
+	if next, ok := c.Next(after, time.UTC); ok {
+		t.Errorf("got %v, expected February 31st never to come", next)
+	}
+}
@@ -0,0 +1,61 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/testkube/dashboard/internal/testkube"
+)
+
+// abortExecution stops an execution, returning the status to respond with
+// on error
+func (s *Server) abortExecution(id string, r *http.Request) (int, error) {
+	if _, err := s.apiFor(r).GetExecution(id); err != nil {
+		return http.StatusNotFound, err
+	}
+	if err := s.apiFor(r).AbortExecution(id); err != nil {
+		if errors.Is(err, testkube.ErrExecutionFinished) {
+			return http.StatusConflict, err
+		}
+		log.Printf("Error aborting execution %s: %v", id, err)
+		return http.StatusInternalServerError, errors.New("failed to abort execution")
+	}
+	user := proxyUser(r)
+	if user == "" {
+		user = "unknown user"
+	}
+	log.Printf("Execution %s aborted by %s", id, user)
+	return http.StatusOK, nil
+}
+
+// handleAbortExecution stops a runaway execution from its page
+func (s *Server) handleAbortExecution(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	if status, err := s.abortExecution(id, r); err != nil {
+		http.Error(w, err.Error(), status)
+		return
+	}
+	w.Header().Set("HX-Trigger", `{"showMessage": "Execution aborted"}`)
+	w.Header().Set("HX-Redirect", "/executions/"+id)
+	w.WriteHeader(http.StatusOK)
+}
+
+// handleAbortExecutionAPI stops an execution, returning it as it is now
+func (s *Server) handleAbortExecutionAPI(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	if status, err := s.abortExecution(id, r); err != nil {
+		http.Error(w, err.Error(), status)
+		return
+	}
+	exec, err := s.apiFor(r).GetExecution(id)
+	if err != nil {
+		log.Printf("Error getting execution: %v", err)
+		http.Error(w, "Failed to load execution", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(exec)
+}
@@ -0,0 +1,70 @@
+package server
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"runtime/debug"
+
+	"github.com/testkube/dashboard/internal/testkube"
+)
+
+// clusterServerInfo is what one cluster's API server reports, or why it couldn't
+type clusterServerInfo struct {
+	Name    string
+	Default bool
+	Info    *testkube.ServerInfo
+	Error   string
+}
+
+// dashboardVersion is the dashboard's module version and the Go version
+// it was built with
+func dashboardVersion() (version, goVersion string) {
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return "unknown", "unknown"
+	}
+	return info.Main.Version, info.GoVersion
+}
+
+// clusterServerInfos asks every cluster's API server about itself
+func (s *Server) clusterServerInfos() []clusterServerInfo {
+	var infos []clusterServerInfo
+	for _, name := range s.clusters.Names() {
+		ci := clusterServerInfo{Name: name, Default: name == s.clusters.Default()}
+		api, err := s.clusters.Get(name)
+		if err == nil {
+			ci.Info, err = api.GetServerInfo()
+		}
+		if err != nil {
+			log.Printf("Error getting server info of cluster %s: %v", name, err)
+			ci.Error = err.Error()
+		}
+		infos = append(infos, ci)
+	}
+	return infos
+}
+
+// handleAbout shows which Testkube API servers the dashboard is connected
+// to: their version, agent mode and feature flags
+func (s *Server) handleAbout(w http.ResponseWriter, r *http.Request) {
+	version, goVersion := dashboardVersion()
+	s.render(w, "about.html", map[string]interface{}{
+		"Clusters":  s.clusterServerInfos(),
+		"Version":   version,
+		"GoVersion": goVersion,
+		"Page":      "about",
+	})
+}
+
+// handleServerInfoAPI returns the server info of the request's cluster
+func (s *Server) handleServerInfoAPI(w http.ResponseWriter, r *http.Request) {
+	info, err := s.apiFor(r).GetServerInfo()
+	if err != nil {
+		log.Printf("Error getting server info: %v", err)
+		http.Error(w, "Failed to get Testkube server info", http.StatusBadGateway)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(info)
+}
@@ -0,0 +1,234 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/testkube/dashboard/internal/database"
+	"github.com/testkube/dashboard/internal/environments"
+	"github.com/testkube/dashboard/internal/notify"
+	"github.com/testkube/dashboard/internal/testkube"
+)
+
+// Activity event types
+const (
+	activityRunTriggered       = "run.triggered"
+	activityScheduleFired      = "schedule.fired"
+	activityEnvironmentCreated = "environment.created"
+	activityEnvironmentDeleted = "environment.deleted"
+	activityEnvironmentExpired = "environment.expired"
+	activityUserGenerated      = "user.generated"
+	activityAlertSent          = "alert.sent"
+	activityWorkflowCreated    = "workflow.created"
+	activityWorkflowUpdated    = "workflow.updated"
+	activityWorkflowDeleted    = "workflow.deleted"
+	activityWorkflowPurged     = "workflow.purged"
+)
+
+// activityTypes lists the event types, for the feed's filter
+var activityTypes = []string{
+	activityRunTriggered,
+	activityScheduleFired,
+	activityEnvironmentCreated,
+	activityEnvironmentDeleted,
+	activityEnvironmentExpired,
+	activityUserGenerated,
+	activityAlertSent,
+	activityWorkflowCreated,
+	activityWorkflowUpdated,
+	activityWorkflowDeleted,
+	activityWorkflowPurged,
+}
+
+const (
+	defaultActivityDays  = 7
+	maxActivityDays      = 90
+	defaultActivityLimit = 100
+	maxActivityLimit     = 500
+)
+
+// recordEvent adds an event to the activity feed. Failures are only logged,
+// so recording never fails the action itself.
+func (s *Server) recordEvent(event database.Event) {
+	if event.Time.IsZero() {
+		event.Time = time.Now()
+	}
+	if err := s.db.InsertEvent(event); err != nil {
+		log.Printf("Error recording %s event: %v", event.Type, err)
+	}
+}
+
+// recordRun records a run started or queued through the dashboard
+func (s *Server) recordRun(workflow string, opts testkube.RunOptions, exec *testkube.Execution) {
+	event := database.Event{
+		Type:    activityRunTriggered,
+		Actor:   opts.Tags[testkube.TriggeredByTag],
+		Subject: workflow,
+		URL:     "/queue",
+	}
+	source := opts.Tags[testkube.TriggerTag]
+	if exec != nil {
+		event.Message = fmt.Sprintf("Started %s run %s", source, exec.Name)
+		event.URL = "/executions/" + exec.ID
+	} else {
+		event.Message = fmt.Sprintf("Queued %s run", source)
+	}
+	s.recordEvent(event)
+}
+
+// recordExpiredEnvironment is told by the environment manager about each
+// environment it tears down for expiring
+func (s *Server) recordExpiredEnvironment(env environments.Environment) {
+	s.recordEvent(database.Event{
+		Type:    activityEnvironmentExpired,
+		Actor:   env.Owner,
+		Subject: env.Name,
+		Message: fmt.Sprintf("Expired after %s", formatDuration(time.Since(env.CreatedAt))),
+	})
+}
+
+// recordingNotifier records the alerts a notifier sends in the activity feed
+type recordingNotifier struct {
+	notify.Notifier
+	db database.Database
+}
+
+func (n recordingNotifier) Send(ctx context.Context, msg notify.Message) error {
+	if err := n.Notifier.Send(ctx, msg); err != nil {
+		return err
+	}
+	event := database.Event{Time: time.Now(), Type: activityAlertSent, Subject: msg.Title, Message: msg.Text, URL: msg.URL}
+	if err := n.db.InsertEvent(event); err != nil {
+		log.Printf("Error recording %s event: %v", event.Type, err)
+	}
+	return nil
+}
+
+// scheduledRunEvents returns the runs cron schedules started since a time.
+// Testkube starts these itself, so they come from its execution history
+// rather than the event table.
+func (s *Server) scheduledRunEvents(since time.Time, limit int) ([]database.Event, error) {
+	executions, err := s.api.GetExecutions(testkube.ListOptions{PageSize: limit})
+	if err != nil {
+		return nil, err
+	}
+	var events []database.Event
+	for _, e := range executions {
+		if e.Trigger != testkube.TriggerSchedule || e.StartTime.Before(since) {
+			continue
+		}
+		events = append(events, database.Event{
+			Time:    e.StartTime,
+			Type:    activityScheduleFired,
+			Subject: e.WorkflowName,
+			Message: fmt.Sprintf("Schedule started %s", e.Name),
+			URL:     "/executions/" + e.ID,
+		})
+	}
+	return events, nil
+}
+
+// activityFilter reads the feed's filters: ?type= (repeatable or comma
+// separated), ?actor=, ?days= and ?limit=
+func activityFilter(r *http.Request) (database.EventFilter, error) {
+	q := r.URL.Query()
+	filter := database.EventFilter{
+		Actor: strings.TrimSpace(q.Get("actor")),
+		Limit: min(queryInt(r, "limit", defaultActivityLimit), maxActivityLimit),
+	}
+	for _, val := range q["type"] {
+		for _, t := range strings.Split(val, ",") {
+			if t = strings.TrimSpace(t); t == "" {
+				continue
+			}
+			if !slices.Contains(activityTypes, t) {
+				return filter, fmt.Errorf("unknown activity type %q", t)
+			}
+			filter.Types = append(filter.Types, t)
+		}
+	}
+	days := queryInt(r, "days", defaultActivityDays)
+	if days > maxActivityDays {
+		return filter, fmt.Errorf("days must be at most %d", maxActivityDays)
+	}
+	filter.Since = time.Now().AddDate(0, 0, -days)
+	return filter, nil
+}
+
+// activity merges the recorded events with schedule runs, newest first
+func (s *Server) activity(filter database.EventFilter) ([]database.Event, error) {
+	events, err := s.db.GetEvents(filter)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load events: %w", err)
+	}
+
+	// Schedules have no actor, so an actor filter excludes their runs
+	wantScheduled := len(filter.Types) == 0 || slices.Contains(filter.Types, activityScheduleFired)
+	if wantScheduled && filter.Actor == "" {
+		scheduled, err := s.scheduledRunEvents(filter.Since, filter.Limit)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load scheduled runs: %w", err)
+		}
+		events = append(events, scheduled...)
+	}
+
+	sort.SliceStable(events, func(i, j int) bool { return events[i].Time.After(events[j].Time) })
+	if len(events) > filter.Limit {
+		events = events[:filter.Limit]
+	}
+	return events, nil
+}
+
+// handleActivity shows recent events across the dashboard: runs, schedules,
+// environments, generated users and alerts
+func (s *Server) handleActivity(w http.ResponseWriter, r *http.Request) {
+	filter, err := activityFilter(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	events, err := s.activity(filter)
+	if err != nil {
+		log.Printf("Error loading activity: %v", err)
+		http.Error(w, "Failed to load activity", http.StatusInternalServerError)
+		return
+	}
+
+	selected := make(map[string]bool)
+	for _, t := range filter.Types {
+		selected[t] = true
+	}
+	s.renderPage(w, r, "activity.html", map[string]interface{}{
+		"Events":   events,
+		"Types":    activityTypes,
+		"Selected": selected,
+		"Actor":    filter.Actor,
+		"Days":     queryInt(r, "days", defaultActivityDays),
+	})
+}
+
+func (s *Server) handleActivityAPI(w http.ResponseWriter, r *http.Request) {
+	filter, err := activityFilter(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	events, err := s.activity(filter)
+	if err != nil {
+		log.Printf("Error loading activity: %v", err)
+		http.Error(w, "Failed to load activity", http.StatusInternalServerError)
+		return
+	}
+	if events == nil {
+		events = []database.Event{}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(events)
+}
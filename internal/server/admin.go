@@ -0,0 +1,351 @@
+package server
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+
+	"github.com/testkube/dashboard/internal/environments"
+	"github.com/testkube/dashboard/internal/visual"
+	"github.com/testkube/dashboard/internal/worker"
+)
+
+// parseAdmins reads a comma-separated list of users, as asserted by the
+// authenticating proxy, who may perform admin actions
+func parseAdmins(value string) map[string]bool {
+	admins := make(map[string]bool)
+	for _, user := range strings.Split(value, ",") {
+		if user = strings.TrimSpace(user); user != "" {
+			admins[user] = true
+		}
+	}
+	return admins
+}
+
+// isAdmin reports whether the request may perform admin actions. Without
+// ADMIN_USERS every user may, as the dashboard has no other access control.
+func (s *Server) isAdmin(r *http.Request) bool {
+	if len(s.admins) == 0 {
+		return true
+	}
+	return s.admins[proxyUser(r)]
+}
+
+// requireAdmin writes a 403 response and returns false for non-admins
+func (s *Server) requireAdmin(w http.ResponseWriter, r *http.Request) bool {
+	if !s.isAdmin(r) {
+		http.Error(w, "Admin access required", http.StatusForbidden)
+		return false
+	}
+	return true
+}
+
+// SetWorker shows the ingestion worker's queue on the admin panel. It must be
+// called before the router serves requests.
+func (s *Server) SetWorker(w *worker.Worker) {
+	s.worker = w
+}
+
+// Environments returns the ephemeral environment manager, e.g. for seeding
+// demo environments
+func (s *Server) Environments() *environments.Manager {
+	return s.envMgr
+}
+
+// configVar is an environment variable the dashboard reads at startup
+type configVar struct {
+	Name    string
+	Default string
+	Secret  bool // never shown
+	URL     bool // shown with any credentials removed
+}
+
+// configVars lists the dashboard's settings for the admin panel
+var configVars = []configVar{
+	{Name: "USE_MOCK", Default: "false"},
+	{Name: "MOCK_FIXTURES_FILE"},
+	{Name: "TESTKUBE_API_URL", Default: "http://testkube-api-server:8088", URL: true},
+	{Name: "TESTKUBE_NAMESPACE", Default: "testkube"},
+	{Name: "TESTKUBE_API_TOKEN", Secret: true},
+	{Name: "TESTKUBE_API_MODE", Default: "oss"},
+	{Name: "TESTKUBE_CLOUD_ORG_ID"},
+	{Name: "TESTKUBE_CLOUD_ENV_ID"},
+	{Name: "TESTKUBE_OIDC_TOKEN_URL", URL: true},
+	{Name: "TESTKUBE_OIDC_CLIENT_ID"},
+	{Name: "TESTKUBE_OIDC_CLIENT_SECRET", Secret: true},
+	{Name: "TESTKUBE_OIDC_REFRESH_TOKEN", Secret: true},
+	{Name: "TESTKUBE_OIDC_SCOPES"},
+	{Name: "TESTKUBE_CLUSTER_NAME", Default: "default"},
+	{Name: "TESTKUBE_CLUSTERS_FILE"},
+	{Name: "TESTKUBE_ENRICHMENT_TTL", Default: "30s"},
+	{Name: "TESTKUBE_WATCH_INTERVAL", Default: "5s"},
+	{Name: "TESTKUBE_LOGS_TRANSPORT", Default: "http"},
+	{Name: "TESTKUBE_LOGS_GRPC_ADDRESS", Default: "testkube-logs:9090"},
+	{Name: "TESTKUBE_RETRIES", Default: "3"},
+	{Name: "TESTKUBE_RETRY_BACKOFF", Default: "200ms"},
+	{Name: "TESTKUBE_RETRY_MAX_BACKOFF", Default: "5s"},
+	{Name: "TESTKUBE_RETRY_JITTER", Default: "0.2"},
+	{Name: "TESTKUBE_BREAKER_THRESHOLD", Default: "5"},
+	{Name: "TESTKUBE_BREAKER_COOLDOWN", Default: "30s"},
+	{Name: "TESTKUBE_REQUEST_TIMEOUT", Default: "30s"},
+	{Name: "TESTKUBE_DOWNLOAD_TIMEOUT", Default: "10m"},
+	{Name: "TESTKUBE_CA_FILE"},
+	{Name: "TESTKUBE_INSECURE_SKIP_VERIFY", Default: "false"},
+	{Name: "TESTKUBE_PROXY_URL", URL: true},
+	{Name: "TESTKUBE_MAX_CONCURRENT_REQUESTS", Default: "16"},
+	{Name: "TESTKUBE_REQUESTS_PER_SECOND"},
+	{Name: "TESTKUBE_REQUEST_BURST", Default: "1"},
+	{Name: "DASHBOARD_URL", URL: true},
+	{Name: "WEEKLY_REPORTS", Default: "true"},
+	{Name: "WEEKLY_REPORT_WEBHOOK_URL", Secret: true},
+	{Name: "WEEKLY_REPORT_EMAIL_TO"},
+	{Name: "REPORT_COST_PER_RUNNER_HOUR"},
+	{Name: "SMTP_ADDR"},
+	{Name: "SMTP_FROM"},
+	{Name: "SMTP_USERNAME"},
+	{Name: "SMTP_PASSWORD", Secret: true},
+	{Name: "ADMIN_USERS"},
+	{Name: "READ_ONLY", Default: "false"},
+	{Name: "DEV_MODE", Default: "false"},
+	{Name: "REFRESH_INTERVALS", Default: "dashboard=30s,queue=10s,synthetics=30s"},
+	{Name: "RUNTIME_SETTINGS_FILE"},
+	{Name: "FEATURE_FLAGS"},
+	{Name: "FEATURE_FLAGS_FILE"},
+	{Name: "FEATURE_FLAGS_REFRESH", Default: "30s"},
+	{Name: "WORKER_ENABLED", Default: "true"},
+	{Name: "WORKER_POLL_INTERVAL", Default: "1m"},
+	{Name: "WORKER_MAX_PARSE_ATTEMPTS", Default: "3"},
+	{Name: "WORKER_CATCHUP_PAGES", Default: "10"},
+	{Name: "WORKER_INHERIT_LABELS", Default: "team,component,criticality"},
+	{Name: "DATABASE_URL", URL: true},
+	{Name: "DATABASE_HOST"},
+	{Name: "DATABASE_USER"},
+	{Name: "DATABASE_PASSWORD", Secret: true},
+	{Name: "DATABASE_SCHEMA_PATTERN"},
+	{Name: "DATABASE_DEFAULT_SCHEMA"},
+	{Name: "TEST_USER_EMAIL_DOMAIN"},
+	{Name: "NOTIFY_WEBHOOK_URL", Secret: true},
+	{Name: "NOTIFY_DIGEST_INTERVAL"},
+	{Name: "NOTIFY_DIGEST_TTL"},
+	{Name: "CONFIG_SYNC_REPO", URL: true},
+	{Name: "CONFIG_SYNC_BRANCH", Default: "main"},
+	{Name: "CONFIG_SYNC_PATH", Default: "dashboard-config.json"},
+	{Name: "CONFIG_SYNC_INTERVAL", Default: "5m"},
+	{Name: "CONFIG_SYNC_ENFORCE", Default: "false"},
+	{Name: "OWNERSHIP_FILE"},
+	{Name: "DEPENDENCIES_FILE"},
+	{Name: "SYNTHETICS_FILE"},
+	{Name: "IMPACT_FILE"},
+	{Name: "RUN_LIMITS_FILE"},
+	{Name: "RUN_WINDOWS_FILE"},
+	{Name: "PRIORITY_CLASSES"},
+	{Name: "PRIORITY_CONFIG_VARIABLE"},
+	{Name: "RERUN_FAILED_VARIABLE"},
+	{Name: "TRIAGE_ACK_SLA"},
+	{Name: "TRIAGE_RESOLVE_SLA"},
+	{Name: "VISUAL_FAIL_RATIO"},
+	{Name: "KUBE_QUOTA_WARN_RATIO"},
+	{Name: "KUBE_QUOTA_DENY_RATIO"},
+	{Name: "KUBE_EVENT_POLL_INTERVAL"},
+	{Name: "KUBE_EVENT_RETENTION"},
+	{Name: "KUBE_EVENT_WINDOW"},
+	{Name: "EVIDENCE_SIGNING_KEY", Secret: true},
+	{Name: "EVIDENCE_HMAC_KEY", Secret: true},
+	{Name: "SHARE_LINK_TTL", Default: "168h"},
+	{Name: "PAGE_CACHE_TTL", Default: "15s"},
+	{Name: "REDACT_RULES_FILE"},
+	{Name: "SUITES_FILE"},
+	{Name: "PASS_RATE_ALERTS_FILE"},
+	{Name: "WORKFLOW_TYPES_FILE"},
+	{Name: "ENVIRONMENTS_NAMESPACE", Default: "texecom-envs"},
+	{Name: "ENVIRONMENTS_BASE_URL", Default: "envs.services.texecom-develop.com"},
+	{Name: "ENVIRONMENTS_TLS", Default: "wildcard"},
+	{Name: "ENVIRONMENTS_NETWORK_POLICY", Default: "true"},
+	{Name: "ENVIRONMENT_TEMPLATES_FILE"},
+	{Name: "ENVIRONMENT_PROVISION_SLO"},
+	{Name: "PR_WEBHOOK_SECRET", Secret: true},
+	{Name: "PREVIEW_TEMPLATE"},
+	{Name: "PREVIEW_TTL", Default: "72h"},
+	{Name: "GITHUB_TOKEN", Secret: true},
+	{Name: "GITHUB_API_URL", Default: "https://api.github.com", URL: true},
+	{Name: "GITLAB_TOKEN", Secret: true},
+	{Name: "GITLAB_URL", Default: "https://gitlab.com", URL: true},
+	{Name: "MYSQL_ROOT_PASSWORD", Secret: true},
+}
+
+// configEntry is a setting's effective value, as shown in the admin panel
+type configEntry struct {
+	Name   string `json:"name"`
+	Value  string `json:"value"`
+	Source string `json:"source"` // env or default
+	Masked bool   `json:"masked,omitempty"`
+}
+
+func effectiveConfig() []configEntry {
+	entries := make([]configEntry, len(configVars))
+	for i, v := range configVars {
+		entry := configEntry{Name: v.Name, Value: v.Default, Source: "default"}
+		if val, ok := os.LookupEnv(v.Name); ok {
+			entry.Value, entry.Source = val, "env"
+		}
+		switch {
+		case v.Secret && entry.Value != "":
+			entry.Value, entry.Masked = "********", true
+		case v.URL:
+			if masked := maskURL(entry.Value); masked != entry.Value {
+				entry.Value, entry.Masked = masked, true
+			}
+		}
+		entries[i] = entry
+	}
+	return entries
+}
+
+// maskURL hides the password of a URL with credentials
+func maskURL(value string) string {
+	u, err := url.Parse(value)
+	if err != nil || u.User == nil {
+		return value
+	}
+	if _, ok := u.User.Password(); ok {
+		u.User = url.UserPassword(u.User.Username(), "xxxxx")
+	}
+	return u.String()
+}
+
+// adminFeature is a subsystem that is switched on or off by configuration
+type adminFeature struct {
+	Name    string `json:"name"`
+	Enabled bool   `json:"enabled"`
+	Detail  string `json:"detail,omitempty"`
+}
+
+func (s *Server) adminFeatures() []adminFeature {
+	return []adminFeature{
+		{Name: "Mock Testkube API", Enabled: os.Getenv("USE_MOCK") == "true", Detail: "USE_MOCK"},
+		{Name: "Ingestion worker", Enabled: s.worker != nil, Detail: "WORKER_ENABLED"},
+		{Name: "Kubernetes integration", Enabled: s.clusterEvents != nil, Detail: "in-cluster service account"},
+		{Name: "Failure notifications", Enabled: s.notifier != nil, Detail: "NOTIFY_WEBHOOK_URL"},
+		{Name: "Weekly report delivery", Enabled: len(s.weeklyChannels) > 0, Detail: "WEEKLY_REPORT_WEBHOOK_URL or WEEKLY_REPORT_EMAIL_TO with SMTP_ADDR"},
+		{Name: "Config sync", Enabled: s.configSync != nil, Detail: "CONFIG_SYNC_REPO"},
+		{Name: "User generator", Enabled: s.userGen != nil, Detail: "DATABASE_URL"},
+		{Name: "Evidence signing", Enabled: s.evidence != nil, Detail: "EVIDENCE_SIGNING_KEY or EVIDENCE_HMAC_KEY"},
+		{Name: "Environment network policies", Enabled: os.Getenv("ENVIRONMENTS_NETWORK_POLICY") != "false", Detail: "ENVIRONMENTS_NETWORK_POLICY"},
+		{Name: "Access control", Enabled: len(s.admins) > 0, Detail: "ADMIN_USERS"},
+	}
+}
+
+// adminStats are the in-memory caches and queues
+type adminStats struct {
+	Worker        *worker.Stats `json:"worker,omitempty"`
+	RunQueue      int           `json:"runQueue"`
+	Templates     int           `json:"templates"`
+	Visual        visual.Stats  `json:"visual"`
+	TableStates   int           `json:"tableStates"`
+	Environments  int           `json:"environments"`
+	EvidenceSigns int           `json:"evidenceSignatures"`
+	ShareLinks    int           `json:"shareLinks"`
+	CachedPages   int           `json:"cachedPages"`
+}
+
+func (s *Server) adminStats() adminStats {
+	stats := adminStats{
+		Templates:    len(s.templates),
+		Visual:       s.visual.Stats(),
+		TableStates:  s.tableStates.Len(),
+		Environments: len(s.envMgr.List(environments.ListEnvironmentsOptions{})),
+		ShareLinks:   s.shares.Len(),
+		CachedPages:  s.pages.Len(),
+	}
+	if s.worker != nil {
+		ws := s.worker.Stats()
+		stats.Worker = &ws
+	}
+	if s.runs != nil {
+		stats.RunQueue = len(s.runs.Entries())
+	}
+	if s.evidence != nil {
+		if n, err := s.evidence.Len(); err == nil {
+			stats.EvidenceSigns = n
+		} else {
+			log.Printf("Error counting evidence signatures: %v", err)
+		}
+	}
+	return stats
+}
+
+func (s *Server) adminData() map[string]interface{} {
+	data := s.featureFlagsData()
+	data["Settings"] = s.runtime.Settings()
+	data["Persisted"] = s.runtime.Persisted()
+	data["Features"] = s.adminFeatures()
+	data["Stats"] = s.adminStats()
+	data["Config"] = effectiveConfig()
+	data["DeadLetters"] = s.deadLetters()
+	data["Cursors"] = s.ingestCursors()
+	data["Page"] = "admin"
+	return data
+}
+
+func (s *Server) handleAdminPage(w http.ResponseWriter, r *http.Request) {
+	if !s.requireAdmin(w, r) {
+		return
+	}
+	s.renderPage(w, r, "admin.html", s.adminData())
+}
+
+// handleAdminSettings applies the runtime settings form
+func (s *Server) handleAdminSettings(w http.ResponseWriter, r *http.Request) {
+	if !s.requireAdmin(w, r) {
+		return
+	}
+	settings := runtimeSettings{
+		ReadOnly: r.FormValue("readOnly") == "true",
+		DevMode:  r.FormValue("devMode") == "true",
+	}
+	if err := s.runtime.Update(settings); err != nil {
+		log.Printf("Error saving runtime settings: %v", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	log.Printf("Runtime settings changed by %q: read-only %t, dev mode %t", proxyUser(r), settings.ReadOnly, settings.DevMode)
+
+	w.Header().Set("HX-Trigger", `{"showMessage": "Settings saved"}`)
+	s.executeTemplate(w, "admin.html", "admin-settings", s.adminData())
+}
+
+func (s *Server) handleAdminStatusAPI(w http.ResponseWriter, r *http.Request) {
+	if !s.requireAdmin(w, r) {
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"settings": s.runtime.Settings(),
+		"features": s.adminFeatures(),
+		"stats":    s.adminStats(),
+		"config":   effectiveConfig(),
+	})
+}
+
+func (s *Server) handleAdminSettingsAPI(w http.ResponseWriter, r *http.Request) {
+	if !s.requireAdmin(w, r) {
+		return
+	}
+	settings := s.runtime.Settings()
+	if err := json.NewDecoder(r.Body).Decode(&settings); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	if err := s.runtime.Update(settings); err != nil {
+		log.Printf("Error saving runtime settings: %v", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	log.Printf("Runtime settings changed by %q: read-only %t, dev mode %t", proxyUser(r), settings.ReadOnly, settings.DevMode)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(settings)
+}
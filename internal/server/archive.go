@@ -0,0 +1,217 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/testkube/dashboard/internal/database"
+	"github.com/testkube/dashboard/internal/testkube"
+)
+
+// errWorkflowNotArchived is returned for a purge of a workflow that still
+// exists in Testkube
+var errWorkflowNotArchived = errors.New("workflow still exists in Testkube; delete it before purging its history")
+
+// archivedRun is an ingested execution of a removed workflow, with its test
+// counts since there's no execution page to link to
+type archivedRun struct {
+	testkube.Execution
+	Passed int
+	Failed int
+}
+
+// archivedWorkflows lists the workflows with ingested history that no
+// longer exist in Testkube. Only the default cluster's executions are
+// ingested, so it is the one they are checked against.
+func (s *Server) archivedWorkflows() ([]database.WorkflowHistory, error) {
+	histories, err := s.db.GetWorkflowHistories()
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", errHistoryUnavailable, err)
+	}
+	workflows, err := s.listWorkflows(s.api, "", testkube.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	exists := make(map[string]bool, len(workflows))
+	for _, wf := range workflows {
+		exists[wf.Name] = true
+	}
+	archived := []database.WorkflowHistory{}
+	for _, h := range histories {
+		if !exists[h.Workflow] {
+			archived = append(archived, h)
+		}
+	}
+	return archived, nil
+}
+
+// workflowRemoved reports whether a workflow is gone from Testkube, as
+// opposed to Testkube failing to say
+func (s *Server) workflowRemoved(name string) (bool, error) {
+	_, err := s.api.GetWorkflow(name)
+	if errors.Is(err, testkube.ErrWorkflowNotFound) {
+		return true, nil
+	}
+	return false, err
+}
+
+// purgeWorkflow deletes a removed workflow's ingested history
+func (s *Server) purgeWorkflow(r *http.Request, name string) (int, error) {
+	removed, err := s.workflowRemoved(name)
+	if err != nil {
+		return 0, err
+	}
+	if !removed {
+		return 0, fmt.Errorf("%w: %s", errWorkflowNotArchived, name)
+	}
+	purged, err := s.db.PurgeWorkflow(name)
+	if err != nil {
+		return 0, fmt.Errorf("%w: %v", errHistoryUnavailable, err)
+	}
+	s.recordEvent(database.Event{
+		Type:    activityWorkflowPurged,
+		Actor:   proxyUser(r),
+		Subject: name,
+		Message: fmt.Sprintf("Purged the history of %d executions", purged),
+	})
+	return purged, nil
+}
+
+// purgeError responds to a purge that failed
+func (s *Server) purgeError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, errWorkflowNotArchived):
+		http.Error(w, err.Error(), http.StatusConflict)
+	case errors.Is(err, errHistoryUnavailable):
+		s.databaseError(w, "the workflow's history", err)
+	default:
+		s.listError(w, "the workflow", err)
+	}
+}
+
+// handleArchivedWorkflows lists the removed workflows whose history is kept
+func (s *Server) handleArchivedWorkflows(w http.ResponseWriter, r *http.Request) {
+	archived, err := s.archivedWorkflows()
+	if err != nil {
+		s.listError(w, "archived workflows", err)
+		return
+	}
+	s.render(w, "archived_workflows.html", map[string]interface{}{"Workflows": archived})
+}
+
+// handleArchivedWorkflow pages through a removed workflow's ingested
+// executions. A workflow that exists again is sent to its own page.
+func (s *Server) handleArchivedWorkflow(w http.ResponseWriter, r *http.Request) {
+	name := chi.URLParam(r, "name")
+	removed, err := s.workflowRemoved(name)
+	if err != nil {
+		s.listError(w, "the workflow", err)
+		return
+	}
+	if !removed {
+		http.Redirect(w, r, "/workflows/"+name, http.StatusSeeOther)
+		return
+	}
+
+	page := queryInt(r, "page", 1)
+	executions, total, err := s.db.QueryExecutions(database.ExecutionQuery{
+		Workflows: []string{name},
+		Limit:     executionPageSize,
+		Offset:    (page - 1) * executionPageSize,
+	})
+	if err != nil {
+		s.databaseError(w, "the workflow's history", err)
+		return
+	}
+	if total == 0 {
+		http.Error(w, "No history for workflow "+name, http.StatusNotFound)
+		return
+	}
+
+	runs := make([]archivedRun, len(executions))
+	for i, exec := range executions {
+		runs[i] = archivedRun{Execution: exec}
+		cases, err := s.db.GetExecutionMetrics(exec.ID)
+		if err != nil {
+			log.Printf("Error getting test cases of %s: %v", exec.ID, err)
+		}
+		runs[i].Passed = countStatus(cases, "passed")
+		runs[i].Failed = countStatus(cases, "failed")
+	}
+
+	data := map[string]interface{}{
+		"Name":     name,
+		"Runs":     runs,
+		"Total":    total,
+		"Newest":   executions[0].StartTime,
+		"IsAdmin":  s.isAdmin(r),
+		"PrevPage": page - 1,
+		"NextPage": 0,
+	}
+	if page*executionPageSize < total {
+		data["NextPage"] = page + 1
+	}
+	if page > 1 {
+		// The newest run, not the newest on this page
+		if latest, _, err := s.db.QueryExecutions(database.ExecutionQuery{Workflows: []string{name}, Limit: 1}); err == nil && len(latest) > 0 {
+			data["Newest"] = latest[0].StartTime
+		}
+	}
+	s.render(w, "archived_workflow.html", data)
+}
+
+// handlePurgeWorkflow purges a removed workflow's history from its archive
+// page, sending htmx back to the archive. Only admins may purge.
+func (s *Server) handlePurgeWorkflow(w http.ResponseWriter, r *http.Request) {
+	if !s.requireAdmin(w, r) {
+		return
+	}
+	name := chi.URLParam(r, "name")
+	purged, err := s.purgeWorkflow(r, name)
+	if err != nil {
+		s.purgeError(w, err)
+		return
+	}
+	trigger, _ := json.Marshal(map[string]string{"showMessage": fmt.Sprintf("Purged %d executions of %s", purged, name)})
+	w.Header().Set("HX-Trigger", string(trigger))
+	w.Header().Set("HX-Redirect", "/workflows/archived")
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) handleArchivedWorkflowsAPI(w http.ResponseWriter, r *http.Request) {
+	archived, err := s.archivedWorkflows()
+	if err != nil {
+		s.listError(w, "archived workflows", err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(archived)
+}
+
+// handlePurgeWorkflowAPI responds with {"purged": <executions deleted>}
+func (s *Server) handlePurgeWorkflowAPI(w http.ResponseWriter, r *http.Request) {
+	if !s.requireAdmin(w, r) {
+		return
+	}
+	purged, err := s.purgeWorkflow(r, chi.URLParam(r, "name"))
+	if err != nil {
+		s.purgeError(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]int{"purged": purged})
+}
+
+func countStatus(cases []database.TestCase, status string) int {
+	n := 0
+	for _, tc := range cases {
+		if tc.Status == status {
+			n++
+		}
+	}
+	return n
+}
@@ -0,0 +1,227 @@
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/testkube/dashboard/internal/artifacts"
+	"github.com/testkube/dashboard/internal/testkube"
+)
+
+// artifactRow is an artifact in the execution artifact list
+type artifactRow struct {
+	testkube.Artifact
+	Diffable  bool
+	SHA256    string // recorded when the execution was ingested
+	Integrity artifacts.Integrity
+}
+
+func artifactRows(list []testkube.Artifact) []artifactRow {
+	rows := make([]artifactRow, len(list))
+	for i, a := range list {
+		rows[i] = artifactRow{Artifact: a, Diffable: artifacts.DiffKindFor(a.Path) != artifacts.KindNone}
+	}
+	return rows
+}
+
+// artifactPair is the same-named artifact from two executions
+type artifactPair struct {
+	Execution *testkube.Execution
+	Base      *testkube.Execution
+	Path      string
+	Kind      artifacts.DiffKind
+	Before    []byte // from Base
+	After     []byte // from Execution
+}
+
+// query returns the query string identifying the pair for diff links
+func (p *artifactPair) query() string {
+	return url.Values{"path": {p.Path}, "against": {p.Base.ID}}.Encode()
+}
+
+// previousExecution returns the most recent finished execution of the same
+// workflow that started before exec
+func (s *Server) previousExecution(api testkube.Client, exec *testkube.Execution) (*testkube.Execution, error) {
+	executions, err := api.GetExecutions(testkube.ListOptions{
+		Workflow: exec.WorkflowName,
+		PageSize: tableRowLimit,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var previous *testkube.Execution
+	for i, e := range executions {
+		if e.ID == exec.ID || !e.StartTime.Before(exec.StartTime) || (e.Status != "passed" && e.Status != "failed") {
+			continue
+		}
+		if previous == nil || e.StartTime.After(previous.StartTime) {
+			previous = &executions[i]
+		}
+	}
+	if previous == nil {
+		return nil, fmt.Errorf("no earlier execution of %s to compare with", exec.WorkflowName)
+	}
+	return previous, nil
+}
+
+// loadArtifactPair downloads the artifact in ?path from the execution and
+// from ?against, defaulting to the previous execution of the workflow. It
+// writes an error response and returns nil when the pair can't be loaded.
+func (s *Server) loadArtifactPair(w http.ResponseWriter, r *http.Request) *artifactPair {
+	pair := &artifactPair{Path: r.URL.Query().Get("path")}
+	pair.Kind = artifacts.DiffKindFor(pair.Path)
+	if pair.Kind == artifacts.KindNone {
+		http.Error(w, "Only JSON and image artifacts can be compared", http.StatusBadRequest)
+		return nil
+	}
+
+	api := s.apiFor(r)
+	exec, err := api.GetExecution(chi.URLParam(r, "id"))
+	if err != nil {
+		log.Printf("Error getting execution: %v", err)
+		http.Error(w, "Execution not found", http.StatusNotFound)
+		return nil
+	}
+	pair.Execution = exec
+
+	if against := r.URL.Query().Get("against"); against != "" {
+		pair.Base, err = api.GetExecution(against)
+	} else {
+		pair.Base, err = s.previousExecution(api, exec)
+	}
+	if err != nil {
+		http.Error(w, fmt.Sprintf("No execution to compare with: %v", err), http.StatusNotFound)
+		return nil
+	}
+
+	if pair.Before, err = api.DownloadArtifact(pair.Base.ID, pair.Path); err != nil {
+		log.Printf("Error downloading artifact %s of %s: %v", pair.Path, pair.Base.ID, err)
+		http.Error(w, "Failed to download base artifact", http.StatusBadGateway)
+		return nil
+	}
+	if pair.After, err = api.DownloadArtifact(exec.ID, pair.Path); err != nil {
+		log.Printf("Error downloading artifact %s of %s: %v", pair.Path, exec.ID, err)
+		http.Error(w, "Failed to download artifact", http.StatusBadGateway)
+		return nil
+	}
+	return pair
+}
+
+// imageThreshold reads the perceptual threshold from ?threshold
+func imageThreshold(r *http.Request) float64 {
+	if t, err := strconv.ParseFloat(r.URL.Query().Get("threshold"), 64); err == nil && t >= 0 && t <= 1 {
+		return t
+	}
+	return artifacts.DefaultImageThreshold
+}
+
+func indentJSON(data []byte) string {
+	var buf bytes.Buffer
+	if err := json.Indent(&buf, data, "", "  "); err != nil {
+		return string(data)
+	}
+	return buf.String()
+}
+
+// handleArtifactDiff renders two executions' copies of an artifact side by
+// side with their differences
+func (s *Server) handleArtifactDiff(w http.ResponseWriter, r *http.Request) {
+	pair := s.loadArtifactPair(w, r)
+	if pair == nil {
+		return
+	}
+
+	data := map[string]interface{}{
+		"Execution": pair.Execution,
+		"Base":      pair.Base,
+		"Path":      pair.Path,
+		"Kind":      string(pair.Kind),
+	}
+
+	switch pair.Kind {
+	case artifacts.KindJSON:
+		changes, err := artifacts.DiffJSONDocuments(pair.Before, pair.After)
+		if err != nil {
+			data["Error"] = err.Error()
+		}
+		data["Changes"] = changes
+		data["Before"] = indentJSON(pair.Before)
+		data["After"] = indentJSON(pair.After)
+	case artifacts.KindImage:
+		diff, err := artifacts.DiffImages(pair.Before, pair.After, imageThreshold(r))
+		if err != nil {
+			data["Error"] = err.Error()
+			break
+		}
+		data["Image"] = diff
+		data["DiffPercent"] = diff.Ratio * 100
+	}
+
+	s.render(w, "artifact_diff.html", data)
+}
+
+// handleArtifactDiffImage serves the highlighted difference between two
+// screenshots as a PNG
+func (s *Server) handleArtifactDiffImage(w http.ResponseWriter, r *http.Request) {
+	pair := s.loadArtifactPair(w, r)
+	if pair == nil {
+		return
+	}
+	if pair.Kind != artifacts.KindImage {
+		http.Error(w, "Not an image artifact", http.StatusBadRequest)
+		return
+	}
+
+	diff, err := artifacts.DiffImages(pair.Before, pair.After, imageThreshold(r))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+		return
+	}
+
+	w.Header().Set("Content-Type", "image/png")
+	w.Write(diff.Overlay)
+}
+
+func (s *Server) handleArtifactDiffAPI(w http.ResponseWriter, r *http.Request) {
+	pair := s.loadArtifactPair(w, r)
+	if pair == nil {
+		return
+	}
+
+	result := map[string]interface{}{
+		"executionId": pair.Execution.ID,
+		"baseId":      pair.Base.ID,
+		"path":        pair.Path,
+		"kind":        pair.Kind,
+	}
+
+	switch pair.Kind {
+	case artifacts.KindJSON:
+		changes, err := artifacts.DiffJSONDocuments(pair.Before, pair.After)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+			return
+		}
+		result["identical"] = len(changes) == 0
+		result["changes"] = changes
+	case artifacts.KindImage:
+		diff, err := artifacts.DiffImages(pair.Before, pair.After, imageThreshold(r))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+			return
+		}
+		result["identical"] = diff.DiffPixels == 0
+		result["image"] = diff
+		result["overlayUrl"] = "/executions/" + pair.Execution.ID + "/diff/image?" + pair.query()
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(result)
+}
@@ -0,0 +1,179 @@
+package server
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"log"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/testkube/dashboard/internal/artifacts"
+	"github.com/testkube/dashboard/internal/database"
+	"github.com/testkube/dashboard/internal/testkube"
+)
+
+// artifactManifest returns the artifacts recorded when the execution was
+// ingested, by path
+func (s *Server) artifactManifest(executionID string) map[string]*database.ArtifactRecord {
+	records, err := s.db.GetArtifactManifest(executionID)
+	if err != nil {
+		log.Printf("Error getting artifact manifest for %s: %v", executionID, err)
+		return nil
+	}
+
+	manifest := make(map[string]*database.ArtifactRecord, len(records))
+	for i := range records {
+		manifest[records[i].Path] = &records[i]
+	}
+	return manifest
+}
+
+// clusterManifest is artifactManifest for an execution of the cluster api
+// belongs to. Ingestion, which records manifests, covers the default
+// cluster only, so other clusters' artifacts are unrecorded.
+func (s *Server) clusterManifest(api testkube.Client, executionID string) map[string]*database.ArtifactRecord {
+	if api != s.api {
+		return nil
+	}
+	return s.artifactManifest(executionID)
+}
+
+// downloadVerifiedArtifact downloads an artifact and checks it against the
+// execution's manifest, logging a warning when its content has changed
+func (s *Server) downloadVerifiedArtifact(api testkube.Client, executionID, path string) ([]byte, artifacts.Integrity, error) {
+	data, err := api.DownloadArtifact(executionID, path)
+	if err != nil {
+		return nil, "", err
+	}
+
+	integrity := artifacts.Verify(s.clusterManifest(api, executionID)[path], 0, data)
+	if integrity.Warning() {
+		log.Printf("Warning: artifact %s of execution %s does not match the checksum recorded at ingestion", path, executionID)
+	}
+	return data, integrity, nil
+}
+
+// artifactIntegrityHeader carries an artifact's integrity in download
+// responses
+const artifactIntegrityHeader = "X-Artifact-Integrity"
+
+// streamVerifiedArtifact copies an artifact to w as it downloads, so large
+// artifacts such as Playwright traces aren't held in memory. A recorded
+// checksum is checked as the content passes through, with the result sent
+// in the X-Artifact-Integrity trailer; otherwise the header says why there
+// was nothing to check. The error is only for failures before the response
+// is written.
+func (s *Server) streamVerifiedArtifact(w http.ResponseWriter, api testkube.Client, executionID, path string) error {
+	body, err := api.DownloadArtifactStream(executionID, path)
+	if err != nil {
+		return err
+	}
+	defer body.Close()
+
+	record := s.clusterManifest(api, executionID)[path]
+	if record == nil || record.SHA256 == "" {
+		w.Header().Set(artifactIntegrityHeader, string(artifacts.VerifyChecksum(record, "")))
+		if _, err := io.Copy(w, body); err != nil {
+			log.Printf("Error streaming artifact %s of execution %s: %v", path, executionID, err)
+		}
+		return nil
+	}
+
+	w.Header().Set("Trailer", artifactIntegrityHeader)
+	sum := artifacts.NewChecksum()
+	if _, err := io.Copy(io.MultiWriter(w, sum), body); err != nil {
+		log.Printf("Error streaming artifact %s of execution %s: %v", path, executionID, err)
+		return nil
+	}
+
+	integrity := artifacts.VerifyChecksum(record, hex.EncodeToString(sum.Sum(nil)))
+	if integrity.Warning() {
+		log.Printf("Warning: artifact %s of execution %s does not match the checksum recorded at ingestion", path, executionID)
+	}
+	w.Header().Set(artifactIntegrityHeader, string(integrity))
+	return nil
+}
+
+// checkArtifacts compares the listed artifacts to the manifest, adding rows
+// for recorded artifacts that are no longer listed. With download set, every
+// recorded artifact is downloaded and its checksum verified.
+func (s *Server) checkArtifacts(api testkube.Client, executionID string, rows []artifactRow, download bool) []artifactRow {
+	manifest := s.clusterManifest(api, executionID)
+	listed := make(map[string]bool, len(rows))
+	for i := range rows {
+		row := &rows[i]
+		listed[row.Path] = true
+
+		record := manifest[row.Path]
+		if record != nil {
+			row.SHA256 = record.SHA256
+		}
+		row.Integrity = artifacts.Verify(record, row.Size, nil)
+		if !download || row.Integrity != artifacts.IntegrityUnchecked || row.SHA256 == "" {
+			continue
+		}
+
+		data, err := api.DownloadArtifact(executionID, row.Path)
+		if err != nil {
+			log.Printf("Error downloading artifact %s to verify: %v", row.Path, err)
+			continue
+		}
+		row.Integrity = artifacts.Verify(record, row.Size, data)
+	}
+
+	for _, record := range manifest {
+		if listed[record.Path] {
+			continue
+		}
+		rows = append(rows, artifactRow{
+			Artifact:  testkube.Artifact{Name: record.Name, Path: record.Path, Size: record.Size},
+			SHA256:    record.SHA256,
+			Integrity: artifacts.IntegrityMissing,
+		})
+	}
+
+	for _, row := range rows {
+		if row.Integrity.Warning() {
+			log.Printf("Warning: artifact %s of execution %s is %s compared to its manifest", row.Path, executionID, row.Integrity)
+		}
+	}
+	return rows
+}
+
+// artifactVerification is an artifact's integrity in the verify API
+type artifactVerification struct {
+	Name      string              `json:"name"`
+	Path      string              `json:"path"`
+	Size      int64               `json:"size"`
+	SHA256    string              `json:"sha256,omitempty"`
+	Integrity artifacts.Integrity `json:"integrity"`
+}
+
+// handleVerifyArtifactsAPI downloads every artifact of an execution and
+// checks it against the manifest recorded at ingestion
+func (s *Server) handleVerifyArtifactsAPI(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	api := s.apiFor(r)
+	list, err := api.GetArtifacts(id)
+	if err != nil {
+		log.Printf("Error getting artifacts: %v", err)
+		http.Error(w, "Failed to load artifacts", http.StatusInternalServerError)
+		return
+	}
+
+	rows := s.checkArtifacts(api, id, artifactRows(list), true)
+	result := make([]artifactVerification, len(rows))
+	for i, row := range rows {
+		result[i] = artifactVerification{
+			Name:      row.Name,
+			Path:      row.Path,
+			Size:      row.Size,
+			SHA256:    row.SHA256,
+			Integrity: row.Integrity,
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(result)
+}
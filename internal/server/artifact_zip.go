@@ -0,0 +1,175 @@
+package server
+
+import (
+	"archive/zip"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/testkube/dashboard/internal/artifacts"
+	"github.com/testkube/dashboard/internal/testkube"
+)
+
+// artifactZipFetches is how many artifacts of a zip download are fetched
+// at once. Each holds an open response rather than its content, so a zip
+// of large artifacts doesn't grow the dashboard's memory.
+const artifactZipFetches = 4
+
+// artifactZipErrors lists the artifacts that couldn't be added to the zip
+const artifactZipErrors = "ERRORS.txt"
+
+// storedExtensions are already compressed, so deflating them again only
+// costs CPU
+var storedExtensions = []string{".png", ".jpg", ".jpeg", ".gif", ".webp", ".zip", ".gz", ".tgz", ".mp4", ".webm"}
+
+// artifactFetch is an artifact download as it starts
+type artifactFetch struct {
+	body io.ReadCloser
+	err  error
+}
+
+// fetchArtifacts starts downloading the artifacts in order, at most
+// artifactZipFetches at a time. The caller receives each one from its
+// channel and calls release once done with it; closing done stops the
+// fetches, closing the responses nobody will read.
+func fetchArtifacts(api testkube.Client, executionID string, list []testkube.Artifact, done <-chan struct{}) (fetches []chan artifactFetch, release func()) {
+	slots := make(chan struct{}, artifactZipFetches)
+	fetches = make([]chan artifactFetch, len(list))
+	for i := range fetches {
+		fetches[i] = make(chan artifactFetch)
+	}
+
+	go func() {
+		for i, artifact := range list {
+			select {
+			case slots <- struct{}{}:
+			case <-done:
+				return
+			}
+			go func() {
+				body, err := api.DownloadArtifactStream(executionID, artifact.Path)
+				select {
+				case fetches[i] <- artifactFetch{body: body, err: err}:
+				case <-done:
+					if body != nil {
+						body.Close()
+					}
+					<-slots
+				}
+			}()
+		}
+	}()
+	return fetches, func() { <-slots }
+}
+
+// artifactZipMethod stores already compressed artifacts and deflates the rest
+func artifactZipMethod(name string) uint16 {
+	ext := strings.ToLower(filepath.Ext(name))
+	for _, stored := range storedExtensions {
+		if ext == stored {
+			return zip.Store
+		}
+	}
+	return zip.Deflate
+}
+
+// zipEntryName keeps an artifact's path inside the folder the zip is
+// extracted to
+func zipEntryName(artifactPath string) string {
+	name := strings.TrimLeft(path.Clean("/"+artifactPath), "/")
+	if name == "" {
+		return "artifact"
+	}
+	return name
+}
+
+// handleArtifactsZip streams every artifact of an execution as one zip,
+// assembled as the artifacts download. Artifacts that fail to download, or
+// no longer match the checksum recorded at ingestion, are listed in
+// ERRORS.txt at the end of the zip, since the response has started by then.
+func (s *Server) handleArtifactsZip(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	api := s.apiFor(r)
+	list, err := api.GetArtifacts(id)
+	if err != nil {
+		log.Printf("Error getting artifacts: %v", err)
+		http.Error(w, "Failed to load artifacts", http.StatusInternalServerError)
+		return
+	}
+	if len(list) == 0 {
+		http.Error(w, "No artifacts found", http.StatusNotFound)
+		return
+	}
+
+	done := make(chan struct{})
+	defer close(done)
+	fetches, release := fetchArtifacts(api, id, list, done)
+	manifest := s.clusterManifest(api, id)
+
+	w.Header().Set("Content-Type", "application/zip")
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s-artifacts.zip"`, id))
+	zw := zip.NewWriter(w)
+	modified := time.Now().UTC()
+	var problems []string
+	for i, artifact := range list {
+		var fetch artifactFetch
+		select {
+		case fetch = <-fetches[i]:
+		case <-r.Context().Done():
+			return
+		}
+		if fetch.err != nil {
+			release()
+			log.Printf("Error downloading artifact %s for zip: %v", artifact.Path, fetch.err)
+			problems = append(problems, fmt.Sprintf("%s: download failed: %v", artifact.Path, fetch.err))
+			continue
+		}
+
+		entry, err := zw.CreateHeader(&zip.FileHeader{Name: zipEntryName(artifact.Path), Method: artifactZipMethod(artifact.Path), Modified: modified})
+		if err != nil {
+			fetch.body.Close()
+			release()
+			log.Printf("Error writing artifacts zip of execution %s: %v", id, err)
+			return
+		}
+		sum := artifacts.NewChecksum()
+		_, err = io.Copy(io.MultiWriter(entry, sum), fetch.body)
+		fetch.body.Close()
+		release()
+		if err != nil {
+			// Either the client left or the artifact broke off mid-way;
+			// the zip can't be completed in both cases
+			log.Printf("Error streaming artifact %s of execution %s into zip: %v", artifact.Path, id, err)
+			return
+		}
+
+		if record := manifest[artifact.Path]; record != nil && record.SHA256 != "" {
+			integrity := artifacts.VerifyChecksum(record, hex.EncodeToString(sum.Sum(nil)))
+			if integrity.Warning() {
+				log.Printf("Warning: artifact %s of execution %s does not match the checksum recorded at ingestion", artifact.Path, id)
+				problems = append(problems, fmt.Sprintf("%s: %s compared to the checksum recorded at ingestion", artifact.Path, integrity))
+			}
+		}
+	}
+
+	if len(problems) > 0 {
+		entry, err := zw.CreateHeader(&zip.FileHeader{Name: artifactZipErrors, Method: zip.Deflate, Modified: modified})
+		if err == nil {
+			_, err = io.WriteString(entry, strings.Join(problems, "\n")+"\n")
+		}
+		if err != nil {
+			log.Printf("Error writing artifacts zip of execution %s: %v", id, err)
+			return
+		}
+	}
+	if err := zw.Close(); err != nil {
+		log.Printf("Error writing artifacts zip of execution %s: %v", id, err)
+	}
+}
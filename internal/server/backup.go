@@ -0,0 +1,145 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/testkube/dashboard/internal/backup"
+)
+
+// Largest backup archive accepted for restore
+const maxBackupSize = 1 << 30
+
+// createBackup snapshots the database and, with includeConfig, the
+// configuration of every section
+func (s *Server) createBackup(includeConfig bool) (*backup.Archive, error) {
+	config := s.config
+	if !includeConfig {
+		config = nil
+	}
+	return backup.Create(s.db, config)
+}
+
+// WriteBackup writes an archive of the database and, with includeConfig,
+// the configuration of every section
+func (s *Server) WriteBackup(w io.Writer, includeConfig bool) (*backup.Manifest, error) {
+	archive, err := s.createBackup(includeConfig)
+	if err != nil {
+		return nil, err
+	}
+	if err := archive.Write(w); err != nil {
+		return nil, err
+	}
+	return &archive.Manifest, nil
+}
+
+// RestoreBackup replaces the database with an archive's data and, with
+// includeConfig, imports its configuration
+func (s *Server) RestoreBackup(r io.Reader, includeConfig bool) (*backup.Manifest, error) {
+	archive, err := backup.Read(r)
+	if err != nil {
+		return nil, err
+	}
+	config := s.config
+	if !includeConfig {
+		config = nil
+	}
+	if err := archive.Restore(s.db, config); err != nil {
+		return nil, err
+	}
+	// Overrides restored with the data apply straight away
+	s.features.Refresh()
+	return &archive.Manifest, nil
+}
+
+// backupIncludesConfig reads ?config=false, which leaves configuration out
+// of a backup or restore
+func backupIncludesConfig(r *http.Request) bool {
+	return r.URL.Query().Get("config") != "false"
+}
+
+func (s *Server) handleBackupAPI(w http.ResponseWriter, r *http.Request) {
+	if !s.requireAdmin(w, r) {
+		return
+	}
+	archive, err := s.createBackup(backupIncludesConfig(r))
+	if err != nil {
+		log.Printf("Error creating backup: %v", err)
+		http.Error(w, "Failed to create backup", http.StatusInternalServerError)
+		return
+	}
+
+	filename := fmt.Sprintf("dashboard-backup-%s.tar.gz", archive.Manifest.CreatedAt.Format("20060102-150405"))
+	w.Header().Set("Content-Type", "application/gzip")
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, filename))
+	if err := archive.Write(w); err != nil {
+		// Headers are already sent once the archive has started
+		log.Printf("Error writing backup: %v", err)
+		return
+	}
+	log.Printf("Backup downloaded by %q: %v", proxyUser(r), archive.Manifest.Rows)
+}
+
+// restoreUpload returns the uploaded archive: the "archive" file of a
+// multipart form, or else the request body
+func restoreUpload(w http.ResponseWriter, r *http.Request) (io.ReadCloser, error) {
+	r.Body = http.MaxBytesReader(w, r.Body, maxBackupSize)
+	if !strings.HasPrefix(r.Header.Get("Content-Type"), "multipart/form-data") {
+		return r.Body, nil
+	}
+	file, _, err := r.FormFile("archive")
+	return file, err
+}
+
+func (s *Server) restore(w http.ResponseWriter, r *http.Request) (*backup.Manifest, bool) {
+	upload, err := restoreUpload(w, r)
+	if err != nil {
+		http.Error(w, "Request must include a backup archive", http.StatusBadRequest)
+		return nil, false
+	}
+	defer upload.Close()
+
+	manifest, err := s.RestoreBackup(upload, backupIncludesConfig(r))
+	if err != nil {
+		log.Printf("Error restoring backup: %v", err)
+		if errors.Is(err, backup.ErrInvalidArchive) {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return nil, false
+		}
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return nil, false
+	}
+	log.Printf("Backup from %s restored by %q: %v", manifest.CreatedAt.Format(time.RFC3339), proxyUser(r), manifest.Rows)
+	return manifest, true
+}
+
+func (s *Server) handleRestoreAPI(w http.ResponseWriter, r *http.Request) {
+	if !s.requireAdmin(w, r) {
+		return
+	}
+	manifest, ok := s.restore(w, r)
+	if !ok {
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(manifest)
+}
+
+// handleAdminRestore restores the archive uploaded from the admin panel
+func (s *Server) handleAdminRestore(w http.ResponseWriter, r *http.Request) {
+	if !s.requireAdmin(w, r) {
+		return
+	}
+	manifest, ok := s.restore(w, r)
+	if !ok {
+		return
+	}
+	w.Header().Set("HX-Trigger", `{"showMessage": "Backup restored"}`)
+	s.executeTemplate(w, "admin.html", "admin-backup", map[string]interface{}{"Restored": manifest})
+}
@@ -0,0 +1,46 @@
+package server
+
+import (
+	"html/template"
+	"log"
+	"net/url"
+	"slices"
+
+	"github.com/testkube/dashboard/internal/testkube"
+)
+
+// branchListRuns is how many of a workflow's latest executions the history
+// page's branch filter offers the branches of
+const branchListRuns = 100
+
+// workflowBranches lists the branches a workflow's latest executions ran
+// for, in order, with the selected one even if it has dropped off
+func workflowBranches(api testkube.Client, workflow, selected string) []string {
+	var branches []string
+	if selected != "" {
+		branches = append(branches, selected)
+	}
+	executions, err := api.GetExecutions(testkube.ListOptions{Workflow: workflow, PageSize: branchListRuns})
+	if err != nil {
+		log.Printf("Error listing branches of %s: %v", workflow, err)
+	}
+	for _, e := range executions {
+		if e.Branch != "" {
+			branches = append(branches, e.Branch)
+		}
+	}
+	slices.Sort(branches)
+	return slices.Compact(branches)
+}
+
+// sourceQuery keeps a page's branch and commit filters in its links
+func sourceQuery(opts testkube.ListOptions) template.URL {
+	params := url.Values{}
+	if opts.Branch != "" {
+		params.Set("branch", opts.Branch)
+	}
+	if opts.Commit != "" {
+		params.Set("commit", opts.Commit)
+	}
+	return template.URL(params.Encode())
+}
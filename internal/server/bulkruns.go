@@ -0,0 +1,288 @@
+package server
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/testkube/dashboard/internal/runqueue"
+	"github.com/testkube/dashboard/internal/testkube"
+)
+
+// RunGroupTag links the executions started by one bulk run
+const RunGroupTag = "run-group"
+
+const (
+	// maxBulkRuns caps how many workflows one bulk run may start
+	maxBulkRuns = 200
+	// runGroupPageSize is how many recent executions are searched for a
+	// group's runs
+	runGroupPageSize = 200
+)
+
+// errNoBulkWorkflows is returned for a bulk run naming and matching nothing
+var errNoBulkWorkflows = errors.New("no workflows to run")
+
+// bulkRunRequest is the body of a bulk run: the workflows named and those
+// matching the label selector, with the parameters every run gets
+type bulkRunRequest struct {
+	Workflows []string                  `json:"workflows"`
+	Selector  string                    `json:"selector"`
+	Config    map[string]string         `json:"config"`
+	Tags      map[string]string         `json:"tags"`
+	Target    *testkube.ExecutionTarget `json:"target"`
+	CI        ciMetadata                `json:"ci"`
+}
+
+// bulkRun is how one workflow of a bulk run went. Status is the code
+// its own run request would have responded with.
+type bulkRun struct {
+	Workflow    string `json:"workflow"`
+	Status      int    `json:"status"`
+	ExecutionID string `json:"executionId,omitempty"`
+	QueueID     string `json:"queueId,omitempty"`
+	Error       string `json:"error,omitempty"`
+}
+
+type bulkRunResponse struct {
+	GroupID string    `json:"groupId"`
+	Runs    []bulkRun `json:"runs"`
+	Started int       `json:"started"`
+	Queued  int       `json:"queued"`
+	Failed  int       `json:"failed"`
+}
+
+// runGroup is the progress of a bulk run's executions
+type runGroup struct {
+	GroupID    string               `json:"groupId"`
+	Executions []testkube.Execution `json:"executions"`
+	Queued     []string             `json:"queued"` // workflows waiting in the run queue
+	Statuses   map[string]int       `json:"statuses"`
+	// Finished is set once every run has finished
+	Finished bool `json:"finished"`
+}
+
+// runResponse holds the error response prepareRun or submitRun writes for
+// one workflow of a bulk run, so the rest still start
+type runResponse struct {
+	header http.Header
+	status int
+	body   strings.Builder
+}
+
+func (rr *runResponse) Header() http.Header {
+	if rr.header == nil {
+		rr.header = http.Header{}
+	}
+	return rr.header
+}
+
+func (rr *runResponse) WriteHeader(status int) {
+	if rr.status == 0 {
+		rr.status = status
+	}
+}
+
+func (rr *runResponse) Write(b []byte) (int, error) {
+	rr.WriteHeader(http.StatusOK)
+	return rr.body.Write(b)
+}
+
+func newRunGroupID() string {
+	bytes := make([]byte, 6)
+	rand.Read(bytes)
+	return hex.EncodeToString(bytes)
+}
+
+// bulkWorkflows resolves a bulk run's workflows: those named, then the
+// TestWorkflows matching the selector, each once
+func (s *Server) bulkWorkflows(api testkube.Client, names []string, selector string) ([]string, error) {
+	var workflows []string
+	for _, name := range names {
+		if name = strings.TrimSpace(name); name != "" && !slices.Contains(workflows, name) {
+			workflows = append(workflows, name)
+		}
+	}
+	if selector != "" {
+		matched, err := s.listWorkflows(api, testkube.KindTestWorkflow, testkube.ListOptions{Selector: selector})
+		if err != nil {
+			return nil, err
+		}
+		for _, wf := range matched {
+			if !slices.Contains(workflows, wf.Name) {
+				workflows = append(workflows, wf.Name)
+			}
+		}
+	}
+	if len(workflows) == 0 {
+		return nil, errNoBulkWorkflows
+	}
+	if len(workflows) > maxBulkRuns {
+		return nil, fmt.Errorf("%w: %d workflows selected, at most %d run at once", errNoBulkWorkflows, len(workflows), maxBulkRuns)
+	}
+	return workflows, nil
+}
+
+// runBulk starts each workflow through the same checks and run queue as
+// its Run button, tagging every run with a new group. A workflow failing a
+// check is reported in its result rather than failing the others.
+func (s *Server) runBulk(r *http.Request, workflows []string, trigger string, req runRequest, ci ciMetadata) bulkRunResponse {
+	resp := bulkRunResponse{GroupID: newRunGroupID(), Runs: make([]bulkRun, len(workflows))}
+	for i, name := range workflows {
+		run := bulkRun{Workflow: name}
+		rr := &runResponse{}
+		opts, _, ok := s.prepareRun(rr, r, name, trigger, ci)
+		var exec *testkube.Execution
+		var queued *runqueue.Entry
+		if ok {
+			req.apply(&opts)
+			opts.Tags[RunGroupTag] = resp.GroupID
+			exec, queued, ok = s.submitRun(rr, r, name, opts)
+		}
+		switch {
+		case !ok:
+			run.Status = rr.status
+			run.Error = strings.TrimSpace(rr.body.String())
+			resp.Failed++
+		case exec != nil:
+			run.Status = http.StatusCreated
+			run.ExecutionID = exec.ID
+			resp.Started++
+		default:
+			run.Status = http.StatusAccepted
+			run.QueueID = queued.ID
+			resp.Queued++
+		}
+		resp.Runs[i] = run
+	}
+	log.Printf("Bulk run %s: started %d, queued %d and failed %d of %d workflows",
+		resp.GroupID, resp.Started, resp.Queued, resp.Failed, len(workflows))
+	return resp
+}
+
+// runGroupStatus finds a bulk run's executions among the recent ones, and
+// its runs still waiting in the run queue
+func (s *Server) runGroupStatus(api testkube.Client, groupID string) (*runGroup, error) {
+	executions, err := api.GetExecutions(testkube.ListOptions{PageSize: runGroupPageSize})
+	if err != nil {
+		return nil, err
+	}
+	group := &runGroup{GroupID: groupID, Executions: []testkube.Execution{}, Queued: []string{}, Statuses: map[string]int{}, Finished: true}
+	for _, exec := range executions {
+		if exec.Labels[RunGroupTag] != groupID {
+			continue
+		}
+		group.Executions = append(group.Executions, exec)
+		group.Statuses[exec.Status]++
+		if executionVerdict(exec.Status) == "" {
+			group.Finished = false
+		}
+	}
+	for _, entry := range s.runs.Entries() {
+		if entry.Options.Tags[RunGroupTag] == groupID {
+			group.Queued = append(group.Queued, entry.Workflow)
+			group.Statuses["waiting"]++
+			group.Finished = false
+		}
+	}
+	if len(group.Executions) == 0 && len(group.Queued) == 0 {
+		return nil, nil
+	}
+	return group, nil
+}
+
+// handleBulkRun runs every workflow matching the workflow list's label
+// selector, or the workflows checked, from the list page
+func (s *Server) handleBulkRun(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, "Invalid form", http.StatusBadRequest)
+		return
+	}
+	workflows, err := s.bulkWorkflows(s.apiFor(r), r.Form["workflow"], strings.TrimSpace(r.FormValue("selector")))
+	if errors.Is(err, errNoBulkWorkflows) {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err != nil {
+		s.listError(w, "workflows", err)
+		return
+	}
+
+	ci, err := parseCIMetadata(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	resp := s.runBulk(r, workflows, testkube.TriggerManual, formRunRequest(r), ci)
+	message := fmt.Sprintf("Run group %s: started %d and queued %d of %d workflows", resp.GroupID, resp.Started, resp.Queued, len(workflows))
+	if resp.Failed > 0 {
+		var failed []string
+		for _, run := range resp.Runs {
+			if run.Error != "" {
+				failed = append(failed, run.Workflow)
+			}
+		}
+		message += fmt.Sprintf("; %s failed to start", strings.Join(failed, ", "))
+	}
+	trigger, _ := json.Marshal(map[string]string{"showMessage": message})
+	w.Header().Set("HX-Trigger", string(trigger))
+	w.WriteHeader(http.StatusAccepted)
+}
+
+// handleBulkRunAPI takes {"workflows": [...], "selector": ..., "config":
+// {...}, "tags": {...}, "target": {...}, "ci": {...}} and runs the workflows named and
+// matching the selector. It responds 201 when every run started or was
+// queued, with the group ID tagging them and each workflow's outcome, or
+// 207 when some failed to start.
+func (s *Server) handleBulkRunAPI(w http.ResponseWriter, r *http.Request) {
+	var req bulkRunRequest
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxRunRequestSize)).Decode(&req); err != nil {
+		http.Error(w, "Invalid bulk run request: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	ci, err := req.CI.checked()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	workflows, err := s.bulkWorkflows(s.apiFor(r), req.Workflows, strings.TrimSpace(req.Selector))
+	if errors.Is(err, errNoBulkWorkflows) {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err != nil {
+		s.listError(w, "workflows", err)
+		return
+	}
+
+	resp := s.runBulk(r, workflows, testkube.TriggerCI, runRequest{Config: req.Config, Tags: req.Tags, Target: req.Target}, ci)
+	w.Header().Set("Content-Type", "application/json")
+	if resp.Failed > 0 {
+		w.WriteHeader(http.StatusMultiStatus)
+	} else {
+		w.WriteHeader(http.StatusCreated)
+	}
+	json.NewEncoder(w).Encode(resp)
+}
+
+// handleRunGroupAPI reports the progress of a bulk run by its group ID
+func (s *Server) handleRunGroupAPI(w http.ResponseWriter, r *http.Request) {
+	group, err := s.runGroupStatus(s.apiFor(r), chi.URLParam(r, "id"))
+	if err != nil {
+		s.listError(w, "executions", err)
+		return
+	}
+	if group == nil {
+		http.Error(w, "Run group not found", http.StatusNotFound)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(group)
+}
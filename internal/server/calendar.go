@@ -0,0 +1,295 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/testkube/dashboard/internal/schedules"
+	"github.com/testkube/dashboard/internal/testkube"
+)
+
+const (
+	// calendarPageSize and maxCalendarPages bound how much history is read
+	// to fill a calendar
+	calendarPageSize = 100
+	maxCalendarPages = 50
+	// slotEarly and slotLate are how far from a scheduled time an execution
+	// may start and still count as that slot's run
+	slotEarly = 5 * time.Minute
+	slotLate  = 30 * time.Minute
+)
+
+// Slot statuses besides the matched execution's own
+const (
+	slotMissed   = "missed"
+	slotUpcoming = "upcoming"
+)
+
+// calendarSlot is a scheduled run and what became of it
+type calendarSlot struct {
+	Workflow    string    `json:"workflow"`
+	Time        time.Time `json:"time"`
+	Status      string    `json:"status"`
+	ExecutionID string    `json:"executionId,omitempty"`
+}
+
+// calendarDay is one cell of the calendar
+type calendarDay struct {
+	Date time.Time `json:"date"`
+	// Outside is set for the days before and after the month shown
+	Outside bool           `json:"outside,omitempty"`
+	Today   bool           `json:"today,omitempty"`
+	Slots   []calendarSlot `json:"slots"`
+	// Runs are the day's executions that weren't scheduled
+	Runs []testkube.Execution `json:"-"`
+	// Counts are all the day's executions by status
+	Counts map[string]int `json:"counts"`
+	Missed int            `json:"missed"`
+}
+
+// calendar is a month or week of scheduled and actual runs
+type calendar struct {
+	View     string          `json:"view"`
+	Title    string          `json:"title"`
+	Workflow string          `json:"workflow,omitempty"`
+	From     time.Time       `json:"from"`
+	To       time.Time       `json:"to"`
+	Weeks    [][]calendarDay `json:"weeks"`
+	Missed   int             `json:"missed"`
+	// Scheduled is set when any of the workflows shown has a cron schedule
+	Scheduled bool   `json:"-"`
+	Prev      string `json:"-"`
+	Next      string `json:"-"`
+}
+
+// calendarRange returns the days a view covers, starting on a Monday, and
+// the month or week being shown within them
+func calendarRange(view string, date time.Time) (from, to, shownFrom, shownTo time.Time) {
+	day := time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, time.UTC)
+	monday := func(t time.Time) time.Time {
+		return t.AddDate(0, 0, -(int(t.Weekday())+6)%7)
+	}
+	if view == "week" {
+		from = monday(day)
+		return from, from.AddDate(0, 0, 7), from, from.AddDate(0, 0, 7)
+	}
+	shownFrom = day.AddDate(0, 0, 1-day.Day())
+	shownTo = shownFrom.AddDate(0, 1, 0)
+	from = monday(shownFrom)
+	to = monday(shownTo.AddDate(0, 0, 6))
+	return from, to, shownFrom, shownTo
+}
+
+// buildCalendar lays out the days from from to to, matching each scheduled
+// slot with the execution of its workflow that started nearest to it
+func buildCalendar(from, to, shownFrom, shownTo time.Time, slots []schedules.Slot, executions []testkube.Execution, now time.Time) [][]calendarDay {
+	var days []calendarDay
+	index := make(map[string]int)
+	for d := from; d.Before(to); d = d.AddDate(0, 0, 1) {
+		index[d.Format(time.DateOnly)] = len(days)
+		days = append(days, calendarDay{
+			Date:    d,
+			Outside: d.Before(shownFrom) || !d.Before(shownTo),
+			Today:   d.Format(time.DateOnly) == now.UTC().Format(time.DateOnly),
+			Slots:   []calendarSlot{},
+			Counts:  make(map[string]int),
+		})
+	}
+	dayOf := func(t time.Time) (*calendarDay, bool) {
+		i, ok := index[t.UTC().Format(time.DateOnly)]
+		if !ok {
+			return nil, false
+		}
+		return &days[i], true
+	}
+
+	used := make(map[string]bool)
+	for _, slot := range slots {
+		day, ok := dayOf(slot.Time)
+		if !ok {
+			continue
+		}
+		cs := calendarSlot{Workflow: slot.Workflow, Time: slot.Time.UTC()}
+		var best time.Duration
+		for _, e := range executions {
+			if e.WorkflowName != slot.Workflow || used[e.ID] {
+				continue
+			}
+			if e.StartTime.Before(slot.Time.Add(-slotEarly)) || e.StartTime.After(slot.Time.Add(slotLate)) {
+				continue
+			}
+			if off := e.StartTime.Sub(slot.Time).Abs(); cs.ExecutionID == "" || off < best {
+				cs.ExecutionID, cs.Status, best = e.ID, e.Status, off
+			}
+		}
+		switch {
+		case cs.ExecutionID != "":
+			used[cs.ExecutionID] = true
+		case now.After(slot.Time.Add(slotLate)):
+			cs.Status = slotMissed
+			day.Missed++
+		default:
+			cs.Status = slotUpcoming
+		}
+		day.Slots = append(day.Slots, cs)
+	}
+
+	for _, e := range executions {
+		day, ok := dayOf(e.StartTime)
+		if !ok {
+			continue
+		}
+		day.Counts[e.Status]++
+		if !used[e.ID] {
+			day.Runs = append(day.Runs, e)
+		}
+	}
+
+	var weeks [][]calendarDay
+	for len(days) > 0 {
+		n := min(7, len(days))
+		weeks = append(weeks, days[:n])
+		days = days[n:]
+	}
+	return weeks
+}
+
+// executionsSince pages through a workflow's executions, or every
+// workflow's for an empty name, until reaching ones started before since
+func (s *Server) executionsSince(workflow string, since time.Time) ([]testkube.Execution, error) {
+	var result []testkube.Execution
+	for page := 1; page <= maxCalendarPages; page++ {
+		executions, err := s.api.GetExecutions(testkube.ListOptions{Workflow: workflow, Page: page, PageSize: calendarPageSize})
+		if err != nil {
+			return nil, err
+		}
+		for _, e := range executions {
+			if !e.StartTime.Before(since) {
+				result = append(result, e)
+			}
+		}
+		// Executions are listed newest first
+		if len(executions) < calendarPageSize || executions[len(executions)-1].StartTime.Before(since) {
+			break
+		}
+	}
+	return result, nil
+}
+
+// scheduleSlots expands the workflows' cron triggers into the times they
+// were due to run from from to to, in time order. Paused triggers have no
+// slots, and a workflow whose schedules can't be read is left out.
+func (s *Server) scheduleSlots(workflows []string, from, to time.Time) []schedules.Slot {
+	var slots []schedules.Slot
+	for _, wf := range workflows {
+		list, err := s.api.GetWorkflowSchedules(wf)
+		if err != nil {
+			log.Printf("Error getting schedules of %s: %v", wf, err)
+			continue
+		}
+		for _, sched := range list {
+			for _, t := range sched.Between(from, to) {
+				slots = append(slots, schedules.Slot{Workflow: wf, Time: t, Cron: sched.Cron})
+			}
+		}
+	}
+	sort.SliceStable(slots, func(i, j int) bool { return slots[i].Time.Before(slots[j].Time) })
+	return slots
+}
+
+// calendarFor builds the calendar a request asks for with ?view= (month or
+// week), ?date= (a day in it, defaulting to today) and ?workflow=
+func (s *Server) calendarFor(r *http.Request) (calendar, int, error) {
+	q := r.URL.Query()
+	view := q.Get("view")
+	if view != "week" {
+		view = "month"
+	}
+	now := time.Now()
+	date := now.UTC()
+	if val := q.Get("date"); val != "" {
+		d, err := time.Parse(time.DateOnly, val)
+		if err != nil {
+			return calendar{}, http.StatusBadRequest, fmt.Errorf("invalid date %q, expected YYYY-MM-DD", val)
+		}
+		date = d
+	}
+	from, to, shownFrom, shownTo := calendarRange(view, date)
+
+	workflow := q.Get("workflow")
+	names := []string{workflow}
+	if workflow == "" {
+		workflows, err := s.api.GetWorkflows(testkube.ListOptions{})
+		if err != nil {
+			return calendar{}, http.StatusInternalServerError, err
+		}
+		names = names[:0]
+		for _, wf := range workflows {
+			names = append(names, wf.Name)
+		}
+	}
+	executions, err := s.executionsSince(workflow, from)
+	if err != nil {
+		return calendar{}, http.StatusInternalServerError, err
+	}
+
+	slots := s.scheduleSlots(names, from, to)
+	c := calendar{
+		View:      view,
+		Workflow:  workflow,
+		From:      from,
+		To:        to,
+		Weeks:     buildCalendar(from, to, shownFrom, shownTo, slots, executions, now),
+		Scheduled: len(slots) > 0,
+	}
+	if view == "week" {
+		c.Title = "Week of " + from.Format("2 January 2006")
+		c.Prev, c.Next = from.AddDate(0, 0, -7).Format(time.DateOnly), to.Format(time.DateOnly)
+	} else {
+		c.Title = shownFrom.Format("January 2006")
+		c.Prev, c.Next = shownFrom.AddDate(0, -1, 0).Format(time.DateOnly), shownTo.Format(time.DateOnly)
+	}
+	for _, week := range c.Weeks {
+		for _, day := range week {
+			c.Missed += day.Missed
+		}
+	}
+	return c, http.StatusOK, nil
+}
+
+// calendarError responds with a bad request's error, or logs a failure
+func calendarError(w http.ResponseWriter, status int, err error) {
+	if status == http.StatusBadRequest {
+		http.Error(w, err.Error(), status)
+		return
+	}
+	log.Printf("Error building calendar: %v", err)
+	http.Error(w, "Failed to load calendar", status)
+}
+
+// handleCalendar shows scheduled run slots and actual executions by day, so
+// schedules that silently stopped firing stand out
+func (s *Server) handleCalendar(w http.ResponseWriter, r *http.Request) {
+	c, status, err := s.calendarFor(r)
+	if err != nil {
+		calendarError(w, status, err)
+		return
+	}
+	s.renderPage(w, r, "calendar.html", map[string]interface{}{
+		"Calendar": c,
+	})
+}
+
+func (s *Server) handleCalendarAPI(w http.ResponseWriter, r *http.Request) {
+	c, status, err := s.calendarFor(r)
+	if err != nil {
+		calendarError(w, status, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(c)
+}
@@ -0,0 +1,109 @@
+package server
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/testkube/dashboard/internal/testkube"
+)
+
+// Tags recording the CI build that requested a run
+const (
+	CIBuildURLTag      = "ci-build-url"
+	CIPipelineTag      = "ci-pipeline"
+	CIBranchTag        = testkube.BranchTag
+	CICommitTag        = testkube.CommitTag
+	CICommitURLTag     = "ci-commit-url"
+	CICommitMessageTag = "ci-commit-message"
+	CIAuthorTag        = "ci-author"
+)
+
+// maxCITagLength truncates long values, such as commit messages, so they
+// fit in an execution tag
+const maxCITagLength = 256
+
+// ciMetadata identifies the CI build that triggered a run, so its execution
+// links back to the pipeline
+type ciMetadata struct {
+	BuildURL      string `json:"buildUrl,omitempty"`
+	Pipeline      string `json:"pipeline,omitempty"` // e.g. "deploy #1234"
+	Branch        string `json:"branch,omitempty"`
+	Commit        string `json:"commit,omitempty"`
+	CommitURL     string `json:"commitUrl,omitempty"`
+	CommitMessage string `json:"commitMessage,omitempty"`
+	Author        string `json:"author,omitempty"`
+}
+
+// ciFields are the form fields of a run request's CI metadata
+var ciFields = []struct {
+	form, tag string
+	field     func(*ciMetadata) *string
+}{
+	{"ciBuildUrl", CIBuildURLTag, func(m *ciMetadata) *string { return &m.BuildURL }},
+	{"ciPipeline", CIPipelineTag, func(m *ciMetadata) *string { return &m.Pipeline }},
+	{"ciBranch", CIBranchTag, func(m *ciMetadata) *string { return &m.Branch }},
+	{"ciCommit", CICommitTag, func(m *ciMetadata) *string { return &m.Commit }},
+	{"ciCommitUrl", CICommitURLTag, func(m *ciMetadata) *string { return &m.CommitURL }},
+	{"ciCommitMessage", CICommitMessageTag, func(m *ciMetadata) *string { return &m.CommitMessage }},
+	{"ciAuthor", CIAuthorTag, func(m *ciMetadata) *string { return &m.Author }},
+}
+
+// parseCIMetadata reads a run request's CI metadata from its ci* form
+// fields
+func parseCIMetadata(r *http.Request) (ciMetadata, error) {
+	var m ciMetadata
+	for _, f := range ciFields {
+		*f.field(&m) = r.FormValue(f.form)
+	}
+	return m.checked()
+}
+
+// checked validates the metadata's links, and keeps only a commit
+// message's subject line
+func (m ciMetadata) checked() (ciMetadata, error) {
+	for _, link := range []string{m.BuildURL, m.CommitURL} {
+		if link == "" {
+			continue
+		}
+		if u, err := url.Parse(link); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return m, fmt.Errorf("invalid CI link %q: expected an http(s) URL", link)
+		}
+	}
+	m.CommitMessage, _, _ = strings.Cut(m.CommitMessage, "\n")
+	return m, nil
+}
+
+// tags records the metadata on a run
+func (m ciMetadata) tags() map[string]string {
+	tags := make(map[string]string)
+	for _, f := range ciFields {
+		value := strings.TrimSpace(*f.field(&m))
+		if utf8.RuneCountInString(value) > maxCITagLength {
+			value = string([]rune(value)[:maxCITagLength]) + "…"
+		}
+		if value != "" {
+			tags[f.tag] = value
+		}
+	}
+	return tags
+}
+
+// executionCIMetadata reads the CI metadata recorded on an execution, nil
+// when there is none
+func executionCIMetadata(labels map[string]string) *ciMetadata {
+	var m ciMetadata
+	found := false
+	for _, f := range ciFields {
+		if value := labels[f.tag]; value != "" {
+			*f.field(&m) = value
+			found = true
+		}
+	}
+	if !found {
+		return nil
+	}
+	return &m
+}
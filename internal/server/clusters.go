@@ -0,0 +1,133 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+
+	"github.com/testkube/dashboard/internal/testkube"
+)
+
+const (
+	// clusterParam selects the cluster a request is for, and clusterCookie
+	// remembers the browser's choice so links within a cluster keep it
+	clusterParam  = "cluster"
+	clusterHeader = "X-Testkube-Cluster"
+	clusterCookie = "testkube_cluster"
+)
+
+// clusterKey is the request context key of the cluster a request is for
+type clusterKey struct{}
+
+// requestCluster is the Testkube installation a request reads from and acts on
+type requestCluster struct {
+	name string
+	api  testkube.Client
+}
+
+// SetClusters lets requests choose among several Testkube installations.
+// The registry's default client should be the one the server was created
+// with. It must be called before the router serves requests.
+func (s *Server) SetClusters(clusters *testkube.ClientRegistry) {
+	s.clusters = clusters
+}
+
+// withCluster selects the cluster a request is for: the one named by
+// ?cluster=, the X-Testkube-Cluster header, or the browser's last choice,
+// falling back to the default. Executions, workflows, logs and artifacts
+// are read from the selected cluster and runs start there; ingestion,
+// analytics, suites and share links cover the default cluster.
+func (s *Server) withCluster(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		name := r.URL.Query().Get(clusterParam)
+		fromQuery := name != ""
+		if name == "" {
+			name = r.Header.Get(clusterHeader)
+		}
+		explicit := name != ""
+		if !explicit {
+			if cookie, err := r.Cookie(clusterCookie); err == nil {
+				name = cookie.Value
+			}
+		}
+
+		api, err := s.clusters.Get(name)
+		if err != nil {
+			if explicit {
+				http.Error(w, err.Error(), http.StatusNotFound)
+				return
+			}
+			// A remembered cluster that has since been removed
+			name, api = "", s.api
+		}
+		if name == "" {
+			name = s.clusters.Default()
+		}
+		if fromQuery {
+			http.SetCookie(w, &http.Cookie{
+				Name:     clusterCookie,
+				Value:    name,
+				Path:     "/",
+				HttpOnly: true,
+				SameSite: http.SameSiteLaxMode,
+			})
+		}
+		ctx := context.WithValue(r.Context(), clusterKey{}, requestCluster{name: name, api: api})
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// apiFor returns the client of the cluster a request is for
+func (s *Server) apiFor(r *http.Request) testkube.Client {
+	if c, ok := r.Context().Value(clusterKey{}).(requestCluster); ok {
+		return c.api
+	}
+	return s.api
+}
+
+// clusterName returns the name of the cluster a request is for
+func (s *Server) clusterName(r *http.Request) string {
+	if c, ok := r.Context().Value(clusterKey{}).(requestCluster); ok {
+		return c.name
+	}
+	return s.clusters.Default()
+}
+
+// onDefaultCluster reports whether a request is for the default cluster,
+// the one the run queue, ingestion and analytics cover
+func (s *Server) onDefaultCluster(r *http.Request) bool {
+	return s.clusterName(r) == s.clusters.Default()
+}
+
+// clusterInfo describes a cluster for the clusters API and switcher
+type clusterInfo struct {
+	Name     string `json:"name"`
+	Default  bool   `json:"default"`
+	Selected bool   `json:"selected"`
+}
+
+func (s *Server) clusterInfos(r *http.Request) []clusterInfo {
+	selected := s.clusterName(r)
+	var infos []clusterInfo
+	for _, name := range s.clusters.Names() {
+		infos = append(infos, clusterInfo{Name: name, Default: name == s.clusters.Default(), Selected: name == selected})
+	}
+	return infos
+}
+
+// handleClustersAPI lists the Testkube clusters the dashboard spans
+func (s *Server) handleClustersAPI(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(s.clusterInfos(r))
+}
+
+// handleClusterSwitcher renders the navigation's cluster picker, or nothing
+// when there is only one cluster
+func (s *Server) handleClusterSwitcher(w http.ResponseWriter, r *http.Request) {
+	clusters := s.clusterInfos(r)
+	if len(clusters) < 2 {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+	s.executeTemplate(w, "clusters.html", "cluster-switcher", map[string]interface{}{"Clusters": clusters})
+}
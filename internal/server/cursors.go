@@ -0,0 +1,98 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+
+	"github.com/testkube/dashboard/internal/database"
+)
+
+// ingestCursor is a workflow's ingestion cursor, with the execution holding
+// it back, if any
+type ingestCursor struct {
+	database.IngestCursor
+	HeldBy string `json:"heldBy,omitempty"`
+}
+
+// ingestCursors lists how far the worker has ingested each workflow
+func (s *Server) ingestCursors() []ingestCursor {
+	stored, err := s.db.GetIngestCursors()
+	if err != nil {
+		log.Printf("Error getting ingestion cursors: %v", err)
+	}
+	var held map[string]string
+	if s.worker != nil {
+		held = s.worker.Stats().Held
+	}
+	cursors := make([]ingestCursor, len(stored))
+	for i, c := range stored {
+		cursors[i] = ingestCursor{IngestCursor: c, HeldBy: held[c.Workflow]}
+	}
+	return cursors
+}
+
+// resetCursor moves a workflow's ingestion cursor to to, a time as in
+// ?from= or empty for now, returning the status to respond with on error
+func (s *Server) resetCursor(workflow, to string) (int, error) {
+	if s.worker == nil {
+		return http.StatusServiceUnavailable, fmt.Errorf("the ingestion worker is not running")
+	}
+	if workflow == "" {
+		return http.StatusBadRequest, fmt.Errorf("no workflow given")
+	}
+	at, err := parseRangeBound(to, false)
+	if err != nil {
+		return http.StatusBadRequest, fmt.Errorf("invalid time: %w", err)
+	}
+	if err := s.worker.ResetCursor(workflow, at); err != nil {
+		return http.StatusInternalServerError, err
+	}
+	return http.StatusOK, nil
+}
+
+// handleResetCursor resets a cursor from the admin page
+func (s *Server) handleResetCursor(w http.ResponseWriter, r *http.Request) {
+	if !s.requireAdmin(w, r) {
+		return
+	}
+	workflow := r.FormValue("workflow")
+	if status, err := s.resetCursor(workflow, r.FormValue("to")); err != nil {
+		http.Error(w, err.Error(), status)
+		return
+	}
+
+	trigger, _ := json.Marshal(map[string]string{"showMessage": fmt.Sprintf("Reset the ingestion cursor of %s", workflow)})
+	w.Header().Set("HX-Trigger", string(trigger))
+	s.executeTemplate(w, "admin.html", "admin-cursors", map[string]interface{}{"Cursors": s.ingestCursors()})
+}
+
+func (s *Server) handleCursorsAPI(w http.ResponseWriter, r *http.Request) {
+	if !s.requireAdmin(w, r) {
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(s.ingestCursors())
+}
+
+// handleResetCursorAPI resets {"workflow": ..., "to": ...}, with to
+// defaulting to now
+func (s *Server) handleResetCursorAPI(w http.ResponseWriter, r *http.Request) {
+	if !s.requireAdmin(w, r) {
+		return
+	}
+	var req struct {
+		Workflow string `json:"workflow"`
+		To       string `json:"to"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	if status, err := s.resetCursor(req.Workflow, req.To); err != nil {
+		http.Error(w, err.Error(), status)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
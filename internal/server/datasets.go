@@ -0,0 +1,165 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/testkube/dashboard/internal/database"
+)
+
+// maxTestCasesPageSize caps a page of /api/v1/test-cases
+const maxTestCasesPageSize = 1000
+
+// flakySorts order /api/v1/flaky-tests by ?sort=; each puts the flakiest,
+// busiest or most recent first
+var flakySorts = map[string]func(a, b database.FlakyTest) bool{
+	"score":    func(a, b database.FlakyTest) bool { return a.FlakyScore > b.FlakyScore },
+	"runs":     func(a, b database.FlakyTest) bool { return a.TotalRuns > b.TotalRuns },
+	"failures": func(a, b database.FlakyTest) bool { return a.FailedRuns > b.FailedRuns },
+	"recent":   func(a, b database.FlakyTest) bool { return a.LastFailure.After(b.LastFailure) },
+	"name":     func(a, b database.FlakyTest) bool { return a.TestName < b.TestName },
+}
+
+// flakySortNames lists the accepted ?sort= values, for error messages
+func flakySortNames() string {
+	names := make([]string, 0, len(flakySorts))
+	for name := range flakySorts {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return strings.Join(names, ", ")
+}
+
+// queryCount reads a non-negative count from the query, with def when it's
+// missing, or an error for anything else
+func queryCount(r *http.Request, key string, def int) (int, error) {
+	val := r.URL.Query().Get(key)
+	if val == "" {
+		return def, nil
+	}
+	n, err := strconv.Atoi(val)
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("invalid %s %q: use a whole number", key, val)
+	}
+	return n, nil
+}
+
+// handleFlakyTestsAPI lists flaky tests. Without ?days= it returns the
+// tests over the flaky threshold as it always has; with it, the tests
+// scored over that many days. Either list narrows to ?workflow=, ?team=
+// (by the ownership mapping) and tests with at least ?min_runs= runs, is
+// ordered by ?sort= (score, runs, failures, recent or name) and is cut to
+// ?limit=.
+func (s *Server) handleFlakyTestsAPI(w http.ResponseWriter, r *http.Request) {
+	days, err := queryCount(r, "days", 0)
+	var minRuns, limit int
+	if err == nil {
+		minRuns, err = queryCount(r, "min_runs", 0)
+	}
+	if err == nil {
+		limit, err = queryCount(r, "limit", 0)
+	}
+	order := r.URL.Query().Get("sort")
+	if order == "" {
+		order = "score"
+	}
+	less, ok := flakySorts[order]
+	if err == nil && !ok {
+		err = fmt.Errorf("invalid sort %q: use one of %s", order, flakySortNames())
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	key, read := "flaky-tests", func() ([]database.FlakyTest, error) { return s.db.GetFlakyTests(0.1) }
+	if days > 0 {
+		key = fmt.Sprintf("flaky-tests:%d", days)
+		read = func() ([]database.FlakyTest, error) {
+			now := time.Now()
+			return s.db.GetFlakyTestsBetween(now.AddDate(0, 0, -days), now)
+		}
+	}
+	flakyTests, _, err := dbRead(s, key, read)
+	if err != nil {
+		s.databaseError(w, "flaky tests", err)
+		return
+	}
+
+	workflow, team := r.URL.Query().Get("workflow"), r.URL.Query().Get("team")
+	filtered := []database.FlakyTest{}
+	for _, t := range flakyTests {
+		if workflow != "" && t.WorkflowName != workflow {
+			continue
+		}
+		if t.TotalRuns < minRuns {
+			continue
+		}
+		if team != "" {
+			if owning, _ := s.ownership.Resolve(t.WorkflowName, t.FilePath); owning != team {
+				continue
+			}
+		}
+		filtered = append(filtered, t)
+	}
+	sort.SliceStable(filtered, func(i, j int) bool { return less(filtered[i], filtered[j]) })
+	if limit > 0 && len(filtered) > limit {
+		filtered = filtered[:limit]
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(filtered)
+}
+
+// handleTestCasesAPI returns ingested test results across executions,
+// newest first: those whose name contains ?name=, with ?status=, in
+// ?workflow= and run between ?from= and ?to=. ?limit= (at most
+// maxTestCasesPageSize) and ?offset= page through them, and the
+// X-Total-Count header says how many match.
+func (s *Server) handleTestCasesAPI(w http.ResponseWriter, r *http.Request) {
+	dr, err := parseDateRange(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	limit, err := queryCount(r, "limit", maxTestCasesPageSize)
+	if err == nil && (limit == 0 || limit > maxTestCasesPageSize) {
+		err = fmt.Errorf("invalid limit %d: use 1 to %d", limit, maxTestCasesPageSize)
+	}
+	var offset int
+	if err == nil {
+		offset, err = queryCount(r, "offset", 0)
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	q := r.URL.Query()
+	results, total, err := s.db.QueryTestCases(database.TestCaseQuery{
+		Name:     strings.TrimSpace(q.Get("name")),
+		Status:   q.Get("status"),
+		Workflow: q.Get("workflow"),
+		From:     dr.From,
+		To:       dr.To,
+		Limit:    limit,
+		Offset:   offset,
+	})
+	if err != nil {
+		s.databaseError(w, "test cases", err)
+		return
+	}
+	s.dbHealth.record(nil)
+	if results == nil {
+		results = []database.TestCaseResult{}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("X-Total-Count", strconv.Itoa(total))
+	json.NewEncoder(w).Encode(results)
+}
@@ -0,0 +1,155 @@
+package server
+
+import (
+	"errors"
+	"fmt"
+	"html/template"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/testkube/dashboard/internal/database"
+	"github.com/testkube/dashboard/internal/testkube"
+)
+
+// dateInputLayout is the format of datetime-local inputs, used to show a
+// range back in the filter form and its links
+const dateInputLayout = "2006-01-02T15:04"
+
+// errHistoryUnavailable is returned for a date range while the database,
+// which the ranges are served from, is unavailable
+var errHistoryUnavailable = errors.New("execution history unavailable")
+
+// errHistoryOtherCluster is returned for a date range on a cluster other
+// than the default, whose executions aren't ingested
+var errHistoryOtherCluster = errors.New("date ranges search the ingested history, which covers the default cluster only")
+
+// dateRange bounds execution lists by start time: From is inclusive and To
+// exclusive, and either may be zero
+type dateRange struct {
+	From time.Time
+	To   time.Time
+}
+
+// parseDateRange reads ?from= and ?to=. Each is a date, a datetime-local
+// value or RFC 3339, in the server's time zone unless it says otherwise; a
+// date alone for to includes that whole day.
+func parseDateRange(r *http.Request) (dateRange, error) {
+	var dr dateRange
+	var err error
+	if dr.From, err = parseRangeBound(r.URL.Query().Get("from"), false); err != nil {
+		return dr, fmt.Errorf("invalid from: %w", err)
+	}
+	if dr.To, err = parseRangeBound(r.URL.Query().Get("to"), true); err != nil {
+		return dr, fmt.Errorf("invalid to: %w", err)
+	}
+	if !dr.From.IsZero() && !dr.To.IsZero() && !dr.From.Before(dr.To) {
+		return dr, fmt.Errorf("from must be before to")
+	}
+	return dr, nil
+}
+
+func parseRangeBound(value string, end bool) (time.Time, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return time.Time{}, nil
+	}
+	if t, err := time.ParseInLocation(time.DateOnly, value, time.Local); err == nil {
+		if end {
+			t = t.AddDate(0, 0, 1)
+		}
+		return t, nil
+	}
+	if t, err := time.ParseInLocation(dateInputLayout, value, time.Local); err == nil {
+		return t, nil
+	}
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("%q is not a date, 2006-01-02T15:04 or RFC 3339 time", value)
+	}
+	return t, nil
+}
+
+func (dr dateRange) IsZero() bool {
+	return dr.From.IsZero() && dr.To.IsZero()
+}
+
+// FromInput and ToInput format the bounds for datetime-local inputs
+func (dr dateRange) FromInput() string { return formatRangeBound(dr.From) }
+func (dr dateRange) ToInput() string   { return formatRangeBound(dr.To) }
+
+// Query encodes the range for links that keep it, e.g. to the next page
+func (dr dateRange) Query() template.URL {
+	params := url.Values{}
+	if !dr.From.IsZero() {
+		params.Set("from", dr.FromInput())
+	}
+	if !dr.To.IsZero() {
+		params.Set("to", dr.ToInput())
+	}
+	return template.URL(params.Encode())
+}
+
+func formatRangeBound(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.In(time.Local).Format(dateInputLayout)
+}
+
+// executionPage lists a page of executions. Without a date range the page
+// comes from Testkube; with one it comes from the ingested history, whose
+// start time index finds "last Tuesday night" without paging through
+// everything since.
+func (s *Server) executionPage(api testkube.Client, opts testkube.ListOptions, dr dateRange) (*testkube.ExecutionPage, error) {
+	if dr.IsZero() {
+		return api.GetExecutionPage(opts)
+	}
+	if api != s.api {
+		return nil, errHistoryOtherCluster
+	}
+
+	page := max(opts.Page, 1)
+	pageSize := opts.PageSize
+	if pageSize <= 0 {
+		pageSize = testkube.DefaultPageSize
+	}
+	query := database.ExecutionQuery{
+		Status: opts.Status,
+		From:   dr.From,
+		To:     dr.To,
+		Branch: opts.Branch,
+		Commit: opts.Commit,
+		Limit:  pageSize,
+		Offset: (page - 1) * pageSize,
+	}
+	if opts.Workflow != "" {
+		query.Workflows = []string{opts.Workflow}
+	}
+	// Ingested executions don't carry their workflow's labels, so a selector
+	// is resolved to the workflows it matches
+	if opts.Selector != "" {
+		workflows, err := s.api.GetWorkflows(testkube.ListOptions{Selector: opts.Selector})
+		if err != nil {
+			return nil, err
+		}
+		var names []string
+		for _, wf := range workflows {
+			if opts.Workflow == "" || wf.Name == opts.Workflow {
+				names = append(names, wf.Name)
+			}
+		}
+		if len(names) == 0 {
+			return &testkube.ExecutionPage{Page: page, PageSize: pageSize}, nil
+		}
+		query.Workflows = names
+	}
+
+	executions, total, err := s.db.QueryExecutions(query)
+	s.dbHealth.record(err)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", errHistoryUnavailable, err)
+	}
+	return &testkube.ExecutionPage{Executions: executions, Page: page, PageSize: pageSize, Total: total}, nil
+}
@@ -0,0 +1,132 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+)
+
+const (
+	// dbPingTimeout bounds the database check made by /readyz
+	dbPingTimeout = 2 * time.Second
+	// dbRetryAfter is suggested to clients of analytics APIs while the
+	// database is down
+	dbRetryAfter = 30 * time.Second
+	// maxLastKnown bounds the last-known results kept, as reads of an
+	// execution's data each add one; the oldest makes room for a new one
+	maxLastKnown = 500
+)
+
+// dbHealth tracks whether the database is reachable, from the outcome of
+// the reads and pings made against it. Testkube data doesn't need the
+// database, so pages keep working while it's down: the reads they depend on
+// fall back to the last result that succeeded, and analytics panels say
+// they're unavailable rather than showing zeros.
+type dbHealth struct {
+	mu        sync.Mutex
+	downSince time.Time // zero while reachable
+	lastError string
+	lastKnown map[string]lastKnown
+}
+
+// lastKnown is a read's last successful result
+type lastKnown struct {
+	value interface{}
+	at    time.Time
+}
+
+// databaseStatus is the database's health, for the degraded banner and the
+// health endpoints
+type databaseStatus struct {
+	Available bool       `json:"available"`
+	DownSince *time.Time `json:"downSince,omitempty"`
+	Error     string     `json:"error,omitempty"`
+}
+
+func newDBHealth() *dbHealth {
+	return &dbHealth{lastKnown: make(map[string]lastKnown)}
+}
+
+// record notes the outcome of a database call
+func (h *dbHealth) record(err error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if err == nil {
+		if !h.downSince.IsZero() {
+			log.Printf("Database available again after %s", formatDuration(time.Since(h.downSince)))
+		}
+		h.downSince, h.lastError = time.Time{}, ""
+		return
+	}
+	if h.downSince.IsZero() {
+		log.Printf("Database unavailable, serving last-known analytics: %v", err)
+		h.downSince = time.Now()
+	}
+	h.lastError = err.Error()
+}
+
+func (h *dbHealth) status() databaseStatus {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.downSince.IsZero() {
+		return databaseStatus{Available: true}
+	}
+	since := h.downSince
+	return databaseStatus{DownSince: &since, Error: h.lastError}
+}
+
+// dbRead runs a database read a page depends on, recording the database's
+// health. When the read fails, the last result read under key is returned
+// with the time it was read; the error is only returned when there is no
+// earlier result.
+func dbRead[T any](s *Server, key string, read func() (T, error)) (T, time.Time, error) {
+	value, err := read()
+	h := s.dbHealth
+	h.record(err)
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if err == nil {
+		if _, ok := h.lastKnown[key]; !ok && len(h.lastKnown) >= maxLastKnown {
+			h.evictOldest()
+		}
+		h.lastKnown[key] = lastKnown{value: value, at: time.Now()}
+		return value, time.Time{}, nil
+	}
+	if cached, ok := h.lastKnown[key]; ok {
+		return cached.value.(T), cached.at, nil
+	}
+	return value, time.Time{}, err
+}
+
+// evictOldest drops the result read longest ago. The caller holds the lock.
+func (h *dbHealth) evictOldest() {
+	var oldest string
+	for key, known := range h.lastKnown {
+		if oldest == "" || known.at.Before(h.lastKnown[oldest].at) {
+			oldest = key
+		}
+	}
+	delete(h.lastKnown, oldest)
+}
+
+// pingDatabase checks the database is reachable, recording the result
+func (s *Server) pingDatabase(ctx context.Context) databaseStatus {
+	ctx, cancel := context.WithTimeout(ctx, dbPingTimeout)
+	defer cancel()
+	s.dbHealth.record(s.db.Ping(ctx))
+	return s.dbHealth.status()
+}
+
+// databaseError responds to a failed analytics read with a 503 and a
+// Retry-After, since the rest of the dashboard still works without the
+// database; what names the data for the error message.
+func (s *Server) databaseError(w http.ResponseWriter, what string, err error) {
+	log.Printf("Error getting %s: %v", what, err)
+	s.dbHealth.record(err)
+	w.Header().Set("Retry-After", fmt.Sprint(int(dbRetryAfter.Seconds())))
+	http.Error(w, fmt.Sprintf("The database is unavailable, so %s can't be loaded right now", what), http.StatusServiceUnavailable)
+}
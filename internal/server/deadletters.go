@@ -0,0 +1,110 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+
+	"github.com/testkube/dashboard/internal/database"
+)
+
+// deadLetters lists the artifacts the worker gave up parsing
+func (s *Server) deadLetters() []database.DeadLetter {
+	letters, err := s.db.GetDeadLetters()
+	if err != nil {
+		log.Printf("Error getting dead letters: %v", err)
+	}
+	return letters
+}
+
+// retryDeadLetter parses a dead-lettered artifact again, returning the
+// number of test cases recorded and the status to respond with on error
+func (s *Server) retryDeadLetter(executionID, path string) (int, int, error) {
+	if s.worker == nil {
+		return 0, http.StatusServiceUnavailable, fmt.Errorf("the ingestion worker is not running")
+	}
+	for _, letter := range s.deadLetters() {
+		if letter.ExecutionID == executionID && letter.Path == path {
+			count, err := s.worker.RetryDeadLetter(letter)
+			if err != nil {
+				return 0, http.StatusUnprocessableEntity, err
+			}
+			return count, http.StatusOK, nil
+		}
+	}
+	return 0, http.StatusNotFound, fmt.Errorf("no dead letter for %s of execution %s", path, executionID)
+}
+
+// handleRetryDeadLetter retries an artifact from the admin page
+func (s *Server) handleRetryDeadLetter(w http.ResponseWriter, r *http.Request) {
+	if !s.requireAdmin(w, r) {
+		return
+	}
+	id, path := r.FormValue("executionId"), r.FormValue("path")
+	count, status, err := s.retryDeadLetter(id, path)
+	if err != nil && status != http.StatusUnprocessableEntity {
+		http.Error(w, err.Error(), status)
+		return
+	}
+
+	message := fmt.Sprintf("Parsed %s: %d test cases recorded", path, count)
+	if err != nil {
+		message = fmt.Sprintf("Still failing: %v", err)
+	}
+	trigger, _ := json.Marshal(map[string]string{"showMessage": message})
+	w.Header().Set("HX-Trigger", string(trigger))
+	s.executeTemplate(w, "admin.html", "admin-dead-letters", map[string]interface{}{"DeadLetters": s.deadLetters()})
+}
+
+func (s *Server) handleDeadLettersAPI(w http.ResponseWriter, r *http.Request) {
+	if !s.requireAdmin(w, r) {
+		return
+	}
+	letters := s.deadLetters()
+	if letters == nil {
+		letters = []database.DeadLetter{}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(letters)
+}
+
+// handleRetryDeadLetterAPI retries {"executionId": ..., "path": ...}
+func (s *Server) handleRetryDeadLetterAPI(w http.ResponseWriter, r *http.Request) {
+	if !s.requireAdmin(w, r) {
+		return
+	}
+	var req struct {
+		ExecutionID string `json:"executionId"`
+		Path        string `json:"path"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	count, status, err := s.retryDeadLetter(req.ExecutionID, req.Path)
+	if err != nil {
+		http.Error(w, err.Error(), status)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]int{"testCases": count})
+}
+
+// handleMetrics serves the ingestion worker's metrics to Prometheus
+func (s *Server) handleMetrics(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	if s.worker != nil {
+		s.worker.WriteMetrics(w)
+	}
+	fmt.Fprintln(w, "# HELP testkube_dashboard_dead_letters Artifacts in the dead-letter table awaiting a retry.")
+	fmt.Fprintln(w, "# TYPE testkube_dashboard_dead_letters gauge")
+	fmt.Fprintf(w, "testkube_dashboard_dead_letters %d\n", len(s.deadLetters()))
+	up := 0
+	if s.pingDatabase(r.Context()).Available {
+		up = 1
+	}
+	fmt.Fprintln(w, "# HELP testkube_dashboard_database_up Whether the database is reachable; analytics show last-known data while it isn't.")
+	fmt.Fprintln(w, "# TYPE testkube_dashboard_database_up gauge")
+	fmt.Fprintf(w, "testkube_dashboard_database_up %d\n", up)
+}
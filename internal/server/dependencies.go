@@ -0,0 +1,48 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/testkube/dashboard/internal/dependencies"
+)
+
+// checkDependencies health-checks the workflow's declared dependencies right
+// before a run. When any are down it either writes a 409 response and returns
+// false (block mode), or returns tags marking the run so its failures can be
+// discounted (tag mode).
+func (s *Server) checkDependencies(ctx context.Context, w http.ResponseWriter, workflow string) (map[string]string, bool) {
+	report := s.dependencies.Check(ctx, workflow)
+	if report.Healthy() {
+		return nil, true
+	}
+
+	if report.Mode == dependencies.ModeBlock {
+		log.Printf("Blocked run of %s: %s", workflow, report.Message())
+		http.Error(w, report.Message(), http.StatusConflict)
+		return nil, false
+	}
+
+	log.Printf("Tagging run of %s: %s", workflow, report.Message())
+	var names []string
+	for _, res := range report.Unhealthy() {
+		names = append(names, res.Name)
+	}
+	return map[string]string{dependencies.UnhealthyTag: strings.Join(names, ",")}, true
+}
+
+func (s *Server) handleDependenciesAPI(w http.ResponseWriter, r *http.Request) {
+	report := s.dependencies.Check(r.Context(), chi.URLParam(r, "name"))
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"workflow": report.Workflow,
+		"mode":     report.Mode,
+		"healthy":  report.Healthy(),
+		"results":  report.Results,
+	})
+}
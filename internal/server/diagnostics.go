@@ -0,0 +1,95 @@
+package server
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/testkube/dashboard/internal/kube"
+	"github.com/testkube/dashboard/internal/testkube"
+)
+
+// infraEvents returns cluster events (evictions, node pressure, OOM kills)
+// around a failed execution, which point at a possible infrastructure cause
+func (s *Server) infraEvents(exec *testkube.Execution) []kube.Event {
+	if s.clusterEvents == nil || exec.Status != "failed" {
+		return nil
+	}
+
+	end := exec.EndTime
+	if end.IsZero() && exec.Duration > 0 {
+		end = exec.StartTime.Add(exec.Duration)
+	}
+	return s.clusterEvents.Correlate(exec.StartTime, end)
+}
+
+func (s *Server) handleInfraEventsAPI(w http.ResponseWriter, r *http.Request) {
+	if s.clusterEvents == nil {
+		http.Error(w, "Kubernetes API not configured", http.StatusServiceUnavailable)
+		return
+	}
+
+	exec, err := s.apiFor(r).GetExecution(chi.URLParam(r, "id"))
+	if err != nil {
+		log.Printf("Error getting execution: %v", err)
+		http.Error(w, "Execution not found", http.StatusNotFound)
+		return
+	}
+
+	events := s.infraEvents(exec)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"executionId":        exec.ID,
+		"possibleInfraCause": len(events) > 0,
+		"events":             events,
+	})
+}
+
+// resourceRow is a line of the execution resource usage table, with memory
+// in whole bytes for humanizeBytes
+type resourceRow struct {
+	Name      string
+	CPUAvg    float64
+	CPUMax    float64
+	MemoryAvg int64
+	MemoryMax int64
+	OOMKilled bool
+	Error     string
+}
+
+func newResourceRow(name string, cpu, memory testkube.ResourceStats) resourceRow {
+	return resourceRow{
+		Name:      name,
+		CPUAvg:    cpu.Avg,
+		CPUMax:    cpu.Max,
+		MemoryAvg: int64(memory.Avg),
+		MemoryMax: int64(memory.Max),
+	}
+}
+
+// handleExecutionResources renders an execution's pod placement and
+// resource usage, loaded separately by the execution page
+func (s *Server) handleExecutionResources(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	usage, err := s.apiFor(r).GetExecutionResources(id)
+	if err != nil {
+		log.Printf("Error getting execution resources: %v", err)
+		http.Error(w, "Failed to load resource usage", http.StatusInternalServerError)
+		return
+	}
+
+	rows := []resourceRow{newResourceRow("Execution", usage.CPUMillicores, usage.MemoryBytes)}
+	for _, step := range usage.Steps {
+		row := newResourceRow(step.Name, step.CPUMillicores, step.MemoryBytes)
+		if row.Name == "" {
+			row.Name = step.Ref
+		}
+		row.OOMKilled, row.Error = step.OOMKilled, step.Error
+		rows = append(rows, row)
+	}
+	s.executeTemplate(w, "execution_detail.html", "resources", map[string]interface{}{
+		"Usage": usage,
+		"Rows":  rows,
+	})
+}
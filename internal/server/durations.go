@@ -0,0 +1,50 @@
+package server
+
+import (
+	"encoding/json"
+	"html/template"
+	"log"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/testkube/dashboard/internal/charts"
+	"github.com/testkube/dashboard/internal/database"
+	"github.com/testkube/dashboard/internal/features"
+)
+
+// maxHistogramBuckets caps ?buckets= on the duration histogram API
+const maxHistogramBuckets = 100
+
+// durationHistogram renders a workflow's duration distribution over the
+// trend chart period. It is empty when the graphs flag is off or there are
+// no finished executions to show.
+func (s *Server) durationHistogram(r *http.Request, workflow string) template.HTML {
+	if !s.featureEnabled(r, features.Graphs) {
+		return ""
+	}
+	buckets, _, err := dbRead(s, "duration-histogram:"+workflow, func() ([]database.DurationBucket, error) {
+		return s.db.GetDurationHistogram(workflow, trendChartDays, database.DefaultHistogramBuckets)
+	})
+	if err != nil {
+		log.Printf("Error getting duration histogram: %v", err)
+		return ""
+	}
+	if len(buckets) == 0 {
+		return ""
+	}
+	return template.HTML(charts.NewGenerator().DurationHistogramChart(buckets))
+}
+
+// handleDurationHistogramAPI buckets a workflow's execution durations over
+// the last ?days= (30 by default) into ?buckets= equal-width buckets
+func (s *Server) handleDurationHistogramAPI(w http.ResponseWriter, r *http.Request) {
+	days := queryInt(r, "days", trendChartDays)
+	buckets := min(queryInt(r, "buckets", database.DefaultHistogramBuckets), maxHistogramBuckets)
+	histogram, err := s.db.GetDurationHistogram(chi.URLParam(r, "name"), days, buckets)
+	if err != nil {
+		s.databaseError(w, "the duration histogram", err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(histogram)
+}
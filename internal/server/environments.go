@@ -0,0 +1,208 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/testkube/dashboard/internal/environments"
+	"github.com/testkube/dashboard/internal/triage"
+)
+
+// canEditEnvironment reports whether the request may change, extend or
+// delete an environment: admins, the environment's owner, and members of
+// its team
+func (s *Server) canEditEnvironment(r *http.Request, env *environments.Environment) bool {
+	user := proxyUser(r)
+	return s.isAdmin(r) || (user != "" && user == env.Owner) ||
+		(env.Team != "" && slices.Contains(proxyGroups(r), env.Team))
+}
+
+// editableEnvironments returns the IDs of the environments the request may
+// change, for showing their actions
+func (s *Server) editableEnvironments(r *http.Request, envs []*environments.Environment) map[string]bool {
+	editable := make(map[string]bool, len(envs))
+	for _, env := range envs {
+		editable[env.ID] = s.canEditEnvironment(r, env)
+	}
+	return editable
+}
+
+// environmentTeams lists the teams an environment can be shared with: the
+// user's own and those in the ownership rules
+func (s *Server) environmentTeams(r *http.Request) []string {
+	teams := proxyGroups(r)
+	for _, team := range s.ownership.Teams() {
+		if team != triage.UnownedTeam && !slices.Contains(teams, team) {
+			teams = append(teams, team)
+		}
+	}
+	return teams
+}
+
+// environmentValuesError writes the response for a failed values lookup
+// or change
+func environmentValuesError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, environments.ErrNotHelm), errors.Is(err, environments.ErrNotReady):
+		http.Error(w, err.Error(), http.StatusConflict)
+	case errors.Is(err, environments.ErrValueNotEditable):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	default:
+		log.Printf("Error changing chart values: %v", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+func (s *Server) environmentValuesData(r *http.Request, env *environments.Environment) (map[string]interface{}, error) {
+	values, err := s.envMgr.Values(env.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	var wildcards []string
+	for _, e := range values.Editable {
+		if prefix, ok := strings.CutSuffix(e.Path, ".*"); ok {
+			wildcards = append(wildcards, prefix)
+		}
+	}
+
+	return map[string]interface{}{
+		"Environment": env,
+		"Values":      values,
+		"Wildcards":   wildcards,
+		"CanEdit":     s.canEditEnvironment(r, env),
+		"Page":        "environments",
+	}, nil
+}
+
+func (s *Server) handleEnvironmentValues(w http.ResponseWriter, r *http.Request) {
+	env, err := s.envMgr.Get(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, "Environment not found", http.StatusNotFound)
+		return
+	}
+
+	data, err := s.environmentValuesData(r, env)
+	if err != nil {
+		environmentValuesError(w, err)
+		return
+	}
+	s.renderPage(w, r, "environment_values.html", data)
+}
+
+// formValueOverrides reads the values form: "value:<path>" fields, where
+// an empty field resets the value to the chart default, and optionally a
+// new key under a wildcard path in "new-key" and "new-value"
+func formValueOverrides(r *http.Request, current *environments.ChartValues) map[string]interface{} {
+	overrides := make(map[string]interface{})
+	for _, v := range current.Values {
+		posted, ok := r.PostForm["value:"+v.Key]
+		if !ok {
+			continue
+		}
+		value := strings.TrimSpace(posted[0])
+		switch {
+		case value == "" && v.Overridden:
+			overrides[v.Key] = nil
+		case value != "" && (!v.Overridden || value != fmt.Sprint(v.Value)):
+			overrides[v.Key] = value
+		}
+	}
+
+	if key := strings.TrimSpace(r.PostForm.Get("new-key")); key != "" {
+		overrides[key] = r.PostForm.Get("new-value")
+	}
+	return overrides
+}
+
+// handleSetEnvironmentValues applies the values form and re-renders it
+func (s *Server) handleSetEnvironmentValues(w http.ResponseWriter, r *http.Request) {
+	env, err := s.envMgr.Get(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, "Environment not found", http.StatusNotFound)
+		return
+	}
+	if !s.canEditEnvironment(r, env) {
+		http.Error(w, "Only the environment's owner, its team or an admin can change its values", http.StatusForbidden)
+		return
+	}
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, "Invalid form", http.StatusBadRequest)
+		return
+	}
+
+	current, err := s.envMgr.Values(env.ID)
+	if err != nil {
+		environmentValuesError(w, err)
+		return
+	}
+	changes, err := s.envMgr.SetValues(env.ID, formValueOverrides(r, current), proxyUser(r))
+	if err != nil {
+		environmentValuesError(w, err)
+		return
+	}
+	if len(changes) > 0 {
+		trigger, _ := json.Marshal(map[string]string{"showMessage": fmt.Sprintf("Upgraded %s with %d changed values", env.Name, len(changes))})
+		w.Header().Set("HX-Trigger", string(trigger))
+	}
+
+	data, err := s.environmentValuesData(r, env)
+	if err != nil {
+		environmentValuesError(w, err)
+		return
+	}
+	s.executeTemplate(w, "environment_values.html", "environment-values", data)
+}
+
+func (s *Server) handleEnvironmentValuesAPI(w http.ResponseWriter, r *http.Request) {
+	values, err := s.envMgr.Values(chi.URLParam(r, "id"))
+	if err != nil {
+		if _, getErr := s.envMgr.Get(chi.URLParam(r, "id")); getErr != nil {
+			http.Error(w, "Environment not found", http.StatusNotFound)
+			return
+		}
+		environmentValuesError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(values)
+}
+
+// handleSetEnvironmentValuesAPI overrides chart values, e.g.
+// {"values": {"replicaCount": 2, "image.tag": null}}; null resets a value
+// to the chart default
+func (s *Server) handleSetEnvironmentValuesAPI(w http.ResponseWriter, r *http.Request) {
+	env, err := s.envMgr.Get(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, "Environment not found", http.StatusNotFound)
+		return
+	}
+	if !s.canEditEnvironment(r, env) {
+		http.Error(w, "Only the environment's owner, its team or an admin can change its values", http.StatusForbidden)
+		return
+	}
+
+	var req struct {
+		Values map[string]interface{} `json:"values"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || len(req.Values) == 0 {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	changes, err := s.envMgr.SetValues(env.ID, req.Values, proxyUser(r))
+	if err != nil {
+		environmentValuesError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{"changes": changes})
+}
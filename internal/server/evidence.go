@@ -0,0 +1,238 @@
+package server
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/testkube/dashboard/internal/artifacts"
+	"github.com/testkube/dashboard/internal/evidence"
+	"github.com/testkube/dashboard/internal/features"
+	"github.com/testkube/dashboard/internal/testkube"
+)
+
+const (
+	// Findings larger than this are left out of evidence bundles
+	maxEvidenceArtifactSize = 50 * 1024 * 1024
+	// Largest bundle accepted for verification
+	maxEvidenceBundleSize = 512 * 1024 * 1024
+)
+
+// securityWorkflow reports whether a workflow type's artifacts are security
+// findings, included in full in evidence bundles
+func (s *Server) securityWorkflow(workflowType string) bool {
+	return s.types.Get(workflowType).Category == testkube.CategorySecurity
+}
+
+// errEvidenceModified is returned when an artifact no longer matches the
+// checksum recorded at ingestion, so it can't be attested
+var errEvidenceModified = errors.New("artifact changed since ingestion")
+
+// evidenceEnabled responds with an error when no signing key could be
+// loaded or the evidence flag is off
+func (s *Server) evidenceEnabled(w http.ResponseWriter, r *http.Request) bool {
+	if !s.featureEnabled(r, features.Evidence) {
+		http.Error(w, "Evidence bundles are disabled", http.StatusNotFound)
+		return false
+	}
+	if s.evidence == nil {
+		http.Error(w, "Evidence signing is not configured", http.StatusServiceUnavailable)
+		return false
+	}
+	return true
+}
+
+// evidenceFiles collects an execution's results, logs, artifact manifest and
+// security findings
+func (s *Server) evidenceFiles(exec *testkube.Execution) ([]evidence.File, error) {
+	id := exec.ID
+	var files []evidence.File
+	add := func(name string, v interface{}) error {
+		data, err := json.MarshalIndent(v, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to encode %s: %w", name, err)
+		}
+		files = append(files, evidence.File{Name: name, Data: data})
+		return nil
+	}
+
+	if err := add("execution.json", exec); err != nil {
+		return nil, err
+	}
+	cases, err := s.db.GetExecutionMetrics(id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get test results: %w", err)
+	}
+	if err := add("results.json", cases); err != nil {
+		return nil, err
+	}
+	manifest, err := s.db.GetArtifactManifest(id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get artifact manifest: %w", err)
+	}
+	if err := add("artifacts.json", manifest); err != nil {
+		return nil, err
+	}
+	logs, err := s.api.GetExecutionLogs(id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get logs: %w", err)
+	}
+	files = append(files, evidence.File{Name: "logs.txt", Data: []byte(logs)})
+
+	workflow, err := s.api.GetWorkflow(exec.WorkflowName)
+	if err != nil || !s.securityWorkflow(workflow.Type) {
+		return files, nil
+	}
+
+	list, err := s.api.GetArtifacts(id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list artifacts: %w", err)
+	}
+	for _, a := range list {
+		if a.Size > maxEvidenceArtifactSize {
+			continue
+		}
+		data, integrity, err := s.downloadVerifiedArtifact(s.api, id, a.Path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to download %s: %w", a.Path, err)
+		}
+		if integrity == artifacts.IntegrityMismatch {
+			return nil, fmt.Errorf("%w: %s", errEvidenceModified, a.Path)
+		}
+		files = append(files, evidence.File{Name: "findings/" + a.Path, Data: data})
+	}
+	return files, nil
+}
+
+// handleCreateEvidenceAPI packages an execution's evidence into a zip,
+// signs it and records the signature
+func (s *Server) handleCreateEvidenceAPI(w http.ResponseWriter, r *http.Request) {
+	if !s.evidenceEnabled(w, r) {
+		return
+	}
+	// Bundles hold ingested results, which cover the default cluster
+	if !s.onDefaultCluster(r) {
+		http.Error(w, fmt.Sprintf("Evidence bundles are only available on the %s cluster", s.clusters.Default()), http.StatusBadRequest)
+		return
+	}
+	id := chi.URLParam(r, "id")
+	exec, err := s.api.GetExecution(id)
+	if err != nil {
+		http.Error(w, "Execution not found", http.StatusNotFound)
+		return
+	}
+	if exec.Status != "passed" && exec.Status != "failed" {
+		http.Error(w, "Evidence can only be bundled for finished executions", http.StatusConflict)
+		return
+	}
+
+	files, err := s.evidenceFiles(exec)
+	if err != nil {
+		log.Printf("Error collecting evidence for %s: %v", id, err)
+		if errors.Is(err, errEvidenceModified) {
+			http.Error(w, err.Error(), http.StatusConflict)
+			return
+		}
+		http.Error(w, "Failed to collect evidence", http.StatusInternalServerError)
+		return
+	}
+	bundle, err := evidence.Bundle(files, exec.EndTime)
+	if err != nil {
+		log.Printf("Error bundling evidence for %s: %v", id, err)
+		http.Error(w, "Failed to bundle evidence", http.StatusInternalServerError)
+		return
+	}
+
+	names := make([]string, len(files))
+	for i, f := range files {
+		names[i] = f.Name
+	}
+	record, err := s.evidence.Sign(id, bundle, names, proxyUser(r))
+	if err != nil {
+		// A bundle whose signature wasn't recorded couldn't be verified
+		log.Printf("Error signing evidence for %s: %v", id, err)
+		http.Error(w, "Failed to record the evidence signature", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/zip")
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="evidence-%s.zip"`, id))
+	w.Header().Set("X-Evidence-Digest", record.Digest)
+	w.Header().Set("X-Evidence-Algorithm", record.Algorithm)
+	w.Header().Set("X-Evidence-Key-ID", record.KeyID)
+	w.Header().Set("X-Evidence-Signature", record.Signature)
+	w.Write(bundle)
+}
+
+// handleEvidenceRecordsAPI lists the signatures issued for an execution
+func (s *Server) handleEvidenceRecordsAPI(w http.ResponseWriter, r *http.Request) {
+	if !s.evidenceEnabled(w, r) {
+		return
+	}
+	records, err := s.evidence.Records(chi.URLParam(r, "id"))
+	if err != nil {
+		s.databaseError(w, "evidence signatures", err)
+		return
+	}
+	if records == nil {
+		records = []evidence.Record{}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(records)
+}
+
+// handleVerifyEvidenceAPI checks an uploaded bundle against the recorded
+// signatures
+func (s *Server) handleVerifyEvidenceAPI(w http.ResponseWriter, r *http.Request) {
+	if !s.evidenceEnabled(w, r) {
+		return
+	}
+	bundle, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxEvidenceBundleSize))
+	if err != nil || len(bundle) == 0 {
+		http.Error(w, "Request body must be an evidence bundle", http.StatusBadRequest)
+		return
+	}
+
+	record, err := s.evidence.Verify(bundle)
+	if err != nil && !errors.Is(err, evidence.ErrUnknownBundle) && !errors.Is(err, evidence.ErrBadSignature) {
+		s.databaseError(w, "evidence signatures", err)
+		return
+	}
+	result := map[string]interface{}{
+		"valid":  err == nil,
+		"digest": evidence.Digest(bundle),
+	}
+	if record != nil {
+		result["record"] = record
+	}
+	if err != nil {
+		result["error"] = err.Error()
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(result)
+}
+
+// handleEvidenceKeyAPI describes the signing key. Ed25519 public keys let
+// auditors verify bundles without the dashboard.
+func (s *Server) handleEvidenceKeyAPI(w http.ResponseWriter, r *http.Request) {
+	if !s.evidenceEnabled(w, r) {
+		return
+	}
+	signer := s.evidence.Signer()
+	key := map[string]string{
+		"algorithm": signer.Algorithm(),
+		"keyId":     signer.KeyID(),
+	}
+	if ed, ok := signer.(*evidence.Ed25519Signer); ok {
+		key["publicKey"] = base64.StdEncoding.EncodeToString(ed.PublicKey())
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(key)
+}
@@ -0,0 +1,148 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"html/template"
+	"log"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/testkube/dashboard/internal/charts"
+	"github.com/testkube/dashboard/internal/database"
+	"github.com/testkube/dashboard/internal/features"
+)
+
+// trendChartDays is the period covered by the pass rate and duration charts
+const trendChartDays = 30
+
+// featureEnabled reports whether a flag is on for the requesting tenant
+func (s *Server) featureEnabled(r *http.Request, flag string) bool {
+	return s.features.Enabled(flag, proxyTenant(r))
+}
+
+// trendCharts renders a workflow's pass rate and duration charts, or the
+// charts across all workflows for an empty name. They are empty unless the
+// graphs flag is on.
+func (s *Server) trendCharts(r *http.Request, workflow string) (template.HTML, template.HTML) {
+	if !s.featureEnabled(r, features.Graphs) {
+		return "", ""
+	}
+	passRate, _, err := dbRead(s, "pass-rate-trend:"+workflow, func() ([]database.DataPoint, error) {
+		return s.db.GetPassRateTrend(workflow, trendChartDays)
+	})
+	if err != nil {
+		log.Printf("Error getting pass rate trend: %v", err)
+		return "", ""
+	}
+	duration, _, err := dbRead(s, "duration-trend:"+workflow, func() ([]database.DataPoint, error) {
+		return s.db.GetDurationTrend(workflow, trendChartDays)
+	})
+	if err != nil {
+		log.Printf("Error getting duration trend: %v", err)
+		return "", ""
+	}
+	gen := charts.NewGenerator()
+	return template.HTML(gen.PassRateChart(passRate)), template.HTML(gen.DurationChart(duration))
+}
+
+func (s *Server) featureFlagsData() map[string]interface{} {
+	return map[string]interface{}{
+		"Flags":      s.features.States(""),
+		"Overrides":  s.features.Overrides(),
+		"FlagConfig": s.features.Config(),
+	}
+}
+
+// featureOverrideError responds with the status for a failed override change
+func featureOverrideError(w http.ResponseWriter, err error) {
+	if errors.Is(err, features.ErrUnknownFlag) {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	log.Printf("Error changing feature flag: %v", err)
+	http.Error(w, err.Error(), http.StatusInternalServerError)
+}
+
+// handleAdminFlags applies the feature flag override form. State "on" or
+// "off" sets an override; anything else clears it.
+func (s *Server) handleAdminFlags(w http.ResponseWriter, r *http.Request) {
+	if !s.requireAdmin(w, r) {
+		return
+	}
+	flag, tenant := r.FormValue("flag"), r.FormValue("tenant")
+
+	var err error
+	switch state := r.FormValue("state"); state {
+	case "on", "off":
+		err = s.features.SetOverride(flag, tenant, state == "on", proxyUser(r))
+	default:
+		err = s.features.ClearOverride(flag, tenant)
+	}
+	if err != nil {
+		featureOverrideError(w, err)
+		return
+	}
+	log.Printf("Feature flag %s for tenant %q changed by %q", flag, tenant, proxyUser(r))
+
+	w.Header().Set("HX-Trigger", `{"showMessage": "Feature flag saved"}`)
+	s.executeTemplate(w, "admin.html", "admin-flags", s.featureFlagsData())
+}
+
+// handleFeaturesAPI returns every flag's state for the requesting tenant
+func (s *Server) handleFeaturesAPI(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(s.features.States(proxyTenant(r)))
+}
+
+func (s *Server) handleAdminFlagsAPI(w http.ResponseWriter, r *http.Request) {
+	if !s.requireAdmin(w, r) {
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"flags":     s.features.States(""),
+		"config":    s.features.Config(),
+		"overrides": s.features.Overrides(),
+	})
+}
+
+// handleSetFeatureOverrideAPI sets an override from {"tenant": "", "enabled": true}
+func (s *Server) handleSetFeatureOverrideAPI(w http.ResponseWriter, r *http.Request) {
+	if !s.requireAdmin(w, r) {
+		return
+	}
+	var req struct {
+		Tenant  string `json:"tenant"`
+		Enabled bool   `json:"enabled"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	flag := chi.URLParam(r, "flag")
+	if err := s.features.SetOverride(flag, req.Tenant, req.Enabled, proxyUser(r)); err != nil {
+		featureOverrideError(w, err)
+		return
+	}
+	log.Printf("Feature flag %s for tenant %q changed by %q", flag, req.Tenant, proxyUser(r))
+
+	state, _ := s.features.State(flag, req.Tenant)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(state)
+}
+
+// handleClearFeatureOverrideAPI removes the override for ?tenant=, or the
+// override for everyone
+func (s *Server) handleClearFeatureOverrideAPI(w http.ResponseWriter, r *http.Request) {
+	if !s.requireAdmin(w, r) {
+		return
+	}
+	flag, tenant := chi.URLParam(r, "flag"), r.URL.Query().Get("tenant")
+	if err := s.features.ClearOverride(flag, tenant); err != nil {
+		featureOverrideError(w, err)
+		return
+	}
+	log.Printf("Feature flag %s override for tenant %q cleared by %q", flag, tenant, proxyUser(r))
+	w.WriteHeader(http.StatusNoContent)
+}
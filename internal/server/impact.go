@@ -0,0 +1,78 @@
+package server
+
+import (
+	"bufio"
+	"encoding/json"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/testkube/dashboard/internal/impact"
+)
+
+// handleTestImpactAPI recommends the workflows and tests to run for a
+// change. CI posts either JSON ({"changedFiles": [...]}) or the plain
+// output of `git diff --name-only`, one path per line.
+func (s *Server) handleTestImpactAPI(w http.ResponseWriter, r *http.Request) {
+	var changed []string
+	if strings.HasPrefix(r.Header.Get("Content-Type"), "application/json") {
+		var req struct {
+			ChangedFiles []string `json:"changedFiles"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "Invalid request body", http.StatusBadRequest)
+			return
+		}
+		changed = req.ChangedFiles
+	} else {
+		scanner := bufio.NewScanner(r.Body)
+		for scanner.Scan() {
+			if line := strings.TrimSpace(scanner.Text()); line != "" {
+				changed = append(changed, line)
+			}
+		}
+		if err := scanner.Err(); err != nil {
+			http.Error(w, "Invalid request body", http.StatusBadRequest)
+			return
+		}
+	}
+
+	if len(changed) == 0 {
+		http.Error(w, "No changed files given", http.StatusBadRequest)
+		return
+	}
+
+	days, _ := strconv.Atoi(r.URL.Query().Get("days"))
+	result, err := s.impact.Analyze(changed, days)
+	if err != nil {
+		log.Printf("Error analyzing test impact: %v", err)
+		http.Error(w, "Failed to analyze test impact", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(result)
+}
+
+// handleTestCoverageAPI records the source files a test executed, so later
+// changes to them recommend that test
+func (s *Server) handleTestCoverageAPI(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Workflow string   `json:"workflow"`
+		Test     string   `json:"test"`
+		FilePath string   `json:"filePath"`
+		Files    []string `json:"files"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	test := impact.Test{Name: req.Test, FilePath: req.FilePath}
+	if err := s.impact.RecordCoverage(req.Workflow, test, req.Files); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,135 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"slices"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/testkube/dashboard/internal/database"
+	"github.com/testkube/dashboard/internal/testkube"
+)
+
+const (
+	// insightsRuns is how many of a workflow's latest finished runs the
+	// pre-run insights are drawn from
+	insightsRuns = 20
+	// failingStreak is how many failed runs in a row warn that another run
+	// is likely to fail too
+	failingStreak = 3
+)
+
+// RunInsights is what a workflow's history says about running it again,
+// shown before a manual run so nobody waits on, or pays for, a run that
+// will go the way the last few did
+type RunInsights struct {
+	Workflow string
+	// Runs is how many finished runs the insights are drawn from
+	Runs int
+	// ExpectedDuration is the median duration of the runs that passed or
+	// failed, and LongDuration their p90
+	ExpectedDuration time.Duration
+	LongDuration     time.Duration
+	// EstimatedCost is the expected duration at REPORT_COST_PER_RUNNER_HOUR,
+	// zero when no cost is set
+	CostPerRunnerHour float64
+	EstimatedCost     float64
+	// PassRate is the percentage of the runs that passed, leaving out those
+	// that say nothing about the tests
+	PassRate int
+	// FailingStreak is how many of the latest runs failed in a row, the
+	// latest of them LastFailure, and Failing whether that's enough to warn
+	FailingStreak int
+	LastFailure   string
+	Failing       bool
+}
+
+// runInsights draws a workflow's pre-run insights from its latest ingested
+// executions
+func (s *Server) runInsights(workflow string) (RunInsights, error) {
+	insights := RunInsights{Workflow: workflow, CostPerRunnerHour: costPerRunnerHour()}
+	executions, _, err := dbRead(s, "run-insights:"+workflow, func() ([]testkube.Execution, error) {
+		// Running and queued executions take up some of the page
+		executions, _, err := s.db.QueryExecutions(database.ExecutionQuery{Workflows: []string{workflow}, Limit: 2 * insightsRuns})
+		return executions, err
+	})
+	if err != nil {
+		return insights, err
+	}
+
+	var durations []time.Duration
+	counted, passed := 0, 0
+	streakOver := false
+	for _, e := range executions {
+		outcome := e.RunOutcome()
+		if outcome == "" {
+			continue
+		}
+		if insights.Runs == insightsRuns {
+			break
+		}
+		insights.Runs++
+		// Infra errors and aborted runs end early, so they'd understate
+		// how long a run takes
+		if !testkube.CountsTowardsPassRate(outcome) {
+			continue
+		}
+		counted++
+		if e.Duration > 0 {
+			durations = append(durations, e.Duration)
+		}
+		if outcome == testkube.OutcomePassed {
+			passed++
+			streakOver = true
+		} else if !streakOver {
+			if insights.FailingStreak == 0 {
+				insights.LastFailure = e.ID
+			}
+			insights.FailingStreak++
+		}
+	}
+
+	insights.ExpectedDuration = percentileDuration(durations, 50)
+	insights.LongDuration = percentileDuration(durations, 90)
+	insights.EstimatedCost = insights.ExpectedDuration.Hours() * insights.CostPerRunnerHour
+	if counted > 0 {
+		insights.PassRate = passed * 100 / counted
+	}
+	insights.Failing = insights.FailingStreak >= failingStreak
+	return insights, nil
+}
+
+// percentileDuration returns the nearest-rank percentile of durations, zero
+// for none
+func percentileDuration(durations []time.Duration, p int) time.Duration {
+	if len(durations) == 0 {
+		return 0
+	}
+	sorted := slices.Clone(durations)
+	slices.Sort(sorted)
+	rank := max((p*len(sorted)+99)/100, 1)
+	return sorted[rank-1]
+}
+
+// handleRunInsightsAPI returns what a workflow's history says about running
+// it now
+func (s *Server) handleRunInsightsAPI(w http.ResponseWriter, r *http.Request) {
+	insights, err := s.runInsights(chi.URLParam(r, "name"))
+	if err != nil {
+		s.databaseError(w, "run insights", err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(insights)
+}
+
+// handleRunInsights renders the interstitial shown when Run Now is clicked,
+// which holds the button that starts the run. A database that can't be
+// read doesn't stop anyone running the workflow.
+func (s *Server) handleRunInsights(w http.ResponseWriter, r *http.Request) {
+	name := chi.URLParam(r, "name")
+	insights, err := s.runInsights(name)
+	data := map[string]interface{}{"Name": name, "Insights": insights, "Unavailable": err != nil}
+	s.executeTemplate(w, "workflow_detail.html", "run-insights", data)
+}
@@ -0,0 +1,60 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/testkube/dashboard/internal/testkube"
+)
+
+// listError responds to a failed workflow or execution listing, with a bad
+// request for a label selector that doesn't parse, and a 503 while the
+// Testkube API's circuit breaker is open
+func (s *Server) listError(w http.ResponseWriter, what string, err error) {
+	if errors.Is(err, testkube.ErrInvalidSelector) || errors.Is(err, errHistoryOtherCluster) {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if errors.Is(err, testkube.ErrCircuitOpen) {
+		log.Printf("Error getting %s: %v", what, err)
+		http.Error(w, "The Testkube API is unavailable, so "+what+" can't be loaded right now", http.StatusServiceUnavailable)
+		return
+	}
+	if errors.Is(err, errHistoryUnavailable) {
+		s.databaseError(w, what, err)
+		return
+	}
+	log.Printf("Error getting %s: %v", what, err)
+	http.Error(w, "Failed to load "+what, http.StatusInternalServerError)
+}
+
+// handleExecutionsAPI lists executions a page at a time, filtered by
+// ?workflow=, ?status=, a ?selector= on workflow labels, e.g.
+// "team=payments,priority in (high,critical)", the ?branch= and ?commit=
+// CI ran them for, and a ?from= and ?to= range of start times
+func (s *Server) handleExecutionsAPI(w http.ResponseWriter, r *http.Request) {
+	dr, err := parseDateRange(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	q := r.URL.Query()
+	page, err := s.executionPage(s.apiFor(r), testkube.ListOptions{
+		Workflow: q.Get("workflow"),
+		Status:   q.Get("status"),
+		Selector: strings.TrimSpace(q.Get("selector")),
+		Branch:   q.Get("branch"),
+		Commit:   q.Get("commit"),
+		Page:     queryInt(r, "page", 1),
+		PageSize: min(queryInt(r, "pageSize", testkube.DefaultPageSize), testkube.DefaultPageSize),
+	}, dr)
+	if err != nil {
+		s.listError(w, "executions", err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(page)
+}
@@ -0,0 +1,104 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"slices"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/testkube/dashboard/internal/database"
+	"github.com/testkube/dashboard/internal/testkube"
+)
+
+// legacyKinds are the v1 API's kinds, which have pages of their own under
+// /legacy/{kind}/{name} since TestWorkflow pages can't open them
+var legacyKinds = []string{testkube.KindTest, testkube.KindTestSuite}
+
+// legacyResource finds a Test or TestSuite, nil when there's none
+func (s *Server) legacyResource(api testkube.Client, kind, name string) (*testkube.Workflow, error) {
+	if !slices.Contains(legacyKinds, kind) {
+		return nil, nil
+	}
+	list, err := s.listWorkflows(api, kind, testkube.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	for _, wf := range list {
+		if wf.Name == name {
+			return &wf, nil
+		}
+	}
+	return nil, nil
+}
+
+// handleLegacyDetail shows a Test or TestSuite with its latest executions
+func (s *Server) handleLegacyDetail(w http.ResponseWriter, r *http.Request) {
+	kind, name := chi.URLParam(r, "kind"), chi.URLParam(r, "name")
+	api := s.apiFor(r)
+	resource, err := s.legacyResource(api, kind, name)
+	if err != nil {
+		s.listError(w, kind+"s", err)
+		return
+	}
+	if resource == nil {
+		http.Error(w, kind+" not found", http.StatusNotFound)
+		return
+	}
+
+	executions, err := api.GetLegacyExecutions(kind, name, testkube.ListOptions{PageSize: executionPageSize})
+	if err != nil {
+		log.Printf("Error getting executions of %s %s: %v", kind, name, err)
+	}
+	s.renderPage(w, r, "legacy_detail.html", map[string]interface{}{
+		"Resource":              resource,
+		"Executions":            executions,
+		"ExecutionsUnavailable": err != nil,
+	})
+}
+
+// handleRunLegacy starts a Test or TestSuite. Run queue limits, run windows
+// and presets are TestWorkflow features, so legacy runs start straight away.
+func (s *Server) handleRunLegacy(w http.ResponseWriter, r *http.Request) {
+	kind, name := chi.URLParam(r, "kind"), chi.URLParam(r, "name")
+	tags := triggerTags(r, testkube.TriggerManual)
+	exec, err := s.apiFor(r).RunLegacy(kind, name, tags)
+	if errors.Is(err, testkube.ErrUnknownKind) || errors.Is(err, testkube.ErrWorkflowNotFound) {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		log.Printf("Error running %s %s: %v", kind, name, err)
+		http.Error(w, "Failed to run "+kind, http.StatusInternalServerError)
+		return
+	}
+	log.Printf("Started execution %s for %s %s", exec.ID, kind, name)
+
+	s.recordEvent(database.Event{
+		Type:    activityRunTriggered,
+		Actor:   tags[testkube.TriggeredByTag],
+		Subject: name,
+		Message: fmt.Sprintf("Started %s run %s of %s", testkube.TriggerManual, exec.Name, kind),
+		URL:     fmt.Sprintf("/legacy/%s/%s", kind, name),
+	})
+	trigger, _ := json.Marshal(map[string]string{
+		"showMessage":      fmt.Sprintf("%s started successfully", kind),
+		"executionStarted": exec.ID,
+	})
+	w.Header().Set("HX-Trigger", string(trigger))
+	w.WriteHeader(http.StatusOK)
+}
+
+// handleLegacyLogs shows the output of a Test's execution below its row
+func (s *Server) handleLegacyLogs(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	logs, err := s.apiFor(r).GetLegacyExecutionLogs(id)
+	if err != nil {
+		log.Printf("Error getting logs of %s: %v", id, err)
+		http.Error(w, "Failed to load logs", http.StatusInternalServerError)
+		return
+	}
+	s.executeTemplate(w, "legacy_detail.html", "legacy-logs", map[string]interface{}{"ID": id, "Logs": logs})
+}
@@ -0,0 +1,197 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"log"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/testkube/dashboard/internal/testkube"
+)
+
+// liveActivityLimit is how many recent notifications a live feed keeps for
+// the dashboards opening next
+const liveActivityLimit = 50
+
+// liveFeed fans a cluster's execution notifications out to the dashboards
+// showing them. It only watches while someone is.
+type liveFeed struct {
+	api         testkube.Client
+	mu          sync.Mutex
+	recent      []testkube.ExecutionNotification // oldest first
+	subscribers map[chan testkube.ExecutionNotification]bool
+	stop        context.CancelFunc // nil while not watching
+	// watch counts the watches started, so a stopped one still draining
+	// doesn't deliver to the next
+	watch int
+}
+
+// Recent returns the notifications kept, newest first
+func (f *liveFeed) Recent() []testkube.ExecutionNotification {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	recent := slices.Clone(f.recent)
+	slices.Reverse(recent)
+	return recent
+}
+
+// Subscribe returns a channel of notifications from now on, starting the
+// watch for the first subscriber, and the function to leave with. The
+// channel closes if the watch ends; slow subscribers miss notifications
+// rather than holding the others up.
+func (f *liveFeed) Subscribe() (<-chan testkube.ExecutionNotification, func(), error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.stop == nil {
+		ctx, cancel := context.WithCancel(context.Background())
+		notifications, err := f.api.WatchNotifications(ctx)
+		if err != nil {
+			cancel()
+			return nil, nil, err
+		}
+		f.stop = cancel
+		f.watch++
+		go f.run(f.watch, notifications)
+	}
+
+	ch := make(chan testkube.ExecutionNotification, 16)
+	f.subscribers[ch] = true
+	leave := func() {
+		f.mu.Lock()
+		defer f.mu.Unlock()
+		delete(f.subscribers, ch)
+		if len(f.subscribers) == 0 && f.stop != nil {
+			f.stop()
+			f.stop = nil
+		}
+	}
+	return ch, leave, nil
+}
+
+// run keeps and passes on a watch's notifications until it ends
+func (f *liveFeed) run(watch int, notifications <-chan testkube.ExecutionNotification) {
+	for n := range notifications {
+		f.mu.Lock()
+		if watch == f.watch {
+			f.recent = append(f.recent, n)
+			if len(f.recent) > liveActivityLimit {
+				f.recent = slices.Delete(f.recent, 0, len(f.recent)-liveActivityLimit)
+			}
+			for ch := range f.subscribers {
+				select {
+				case ch <- n:
+				default:
+				}
+			}
+		}
+		f.mu.Unlock()
+	}
+
+	// A watch ending while it's still wanted lets its subscribers go, so
+	// they reconnect and start another
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if watch != f.watch || f.stop == nil {
+		return
+	}
+	log.Printf("Live activity watch ended with %d subscribers", len(f.subscribers))
+	f.stop()
+	f.stop = nil
+	for ch := range f.subscribers {
+		close(ch)
+		delete(f.subscribers, ch)
+	}
+}
+
+// liveFeeds holds a live feed per cluster, made as dashboards ask for them
+type liveFeeds struct {
+	mu    sync.Mutex
+	feeds map[string]*liveFeed
+}
+
+func newLiveFeeds() *liveFeeds {
+	return &liveFeeds{feeds: make(map[string]*liveFeed)}
+}
+
+// For returns the feed of the cluster
+func (l *liveFeeds) For(cluster string, api testkube.Client) *liveFeed {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	f, ok := l.feeds[cluster]
+	if !ok {
+		f = &liveFeed{api: api, subscribers: make(map[chan testkube.ExecutionNotification]bool)}
+		l.feeds[cluster] = f
+	}
+	return f
+}
+
+// liveFeed returns the feed of the request's cluster
+func (s *Server) liveFeed(r *http.Request) *liveFeed {
+	return s.live.For(s.clusterName(r), s.apiFor(r))
+}
+
+// handleLiveActivityStream streams the request's cluster's notifications to
+// the dashboard's live activity panel as rendered rows
+func (s *Server) handleLiveActivityStream(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "Streaming not supported", http.StatusInternalServerError)
+		return
+	}
+
+	notifications, leave, err := s.liveFeed(r).Subscribe()
+	if err != nil {
+		safeErr := template.HTMLEscapeString(err.Error())
+		fmt.Fprintf(w, "event: error\ndata: <div class='alert alert-danger'>%s</div>\n\n", safeErr)
+		flusher.Flush()
+		return
+	}
+	defer leave()
+	// Connect now rather than with the first notification
+	flusher.Flush()
+
+	t, ok := s.pageTemplate("dashboard.html")
+	if !ok {
+		return
+	}
+	var buf bytes.Buffer
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case n, open := <-notifications:
+			if !open {
+				return
+			}
+			buf.Reset()
+			if err := t.ExecuteTemplate(&buf, "live-activity-item", n); err != nil {
+				log.Printf("Error rendering live activity: %v", err)
+				continue
+			}
+			// Every line of the row needs its own data field
+			fmt.Fprint(w, "event: notification\n")
+			for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+				fmt.Fprintf(w, "data: %s\n", line)
+			}
+			fmt.Fprint(w, "\n")
+			flusher.Flush()
+		}
+	}
+}
+
+// handleLiveActivityAPI returns the request's cluster's recent
+// notifications, newest first
+func (s *Server) handleLiveActivityAPI(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(s.liveFeed(r).Recent())
+}
@@ -0,0 +1,207 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/testkube/dashboard/internal/testkube"
+)
+
+const (
+	// logTailLines is how much of a log the execution page shows at first
+	logTailLines = 1000
+	// maxLogPageLines caps ?limit= and ?tail=
+	maxLogPageLines = 10000
+	// defaultLogContext and maxLogContext are the lines shown around search matches
+	defaultLogContext = 2
+	maxLogContext     = 20
+	// maxLogMatches caps the matches a search returns
+	maxLogMatches = 500
+)
+
+// logRange reads ?offset=, ?limit= and ?tail= into ReadLogPage arguments.
+// Without any of them the whole log is requested.
+func logRange(r *http.Request) (offset, limit int, ranged bool, err error) {
+	q := r.URL.Query()
+	number := func(name string, def int) (int, error) {
+		val := q.Get(name)
+		if val == "" {
+			return def, nil
+		}
+		n, err := strconv.Atoi(val)
+		if err != nil || n < 0 {
+			return 0, fmt.Errorf("invalid %s %q", name, val)
+		}
+		ranged = true
+		return n, nil
+	}
+
+	if offset, err = number("offset", 0); err != nil {
+		return
+	}
+	if limit, err = number("limit", maxLogPageLines); err != nil {
+		return
+	}
+	limit = min(limit, maxLogPageLines)
+	tail, err := number("tail", 0)
+	if err != nil {
+		return
+	}
+	if tail > 0 {
+		offset = -min(tail, maxLogPageLines)
+	}
+	return offset, limit, ranged, nil
+}
+
+// logPage reads a range of an execution's log without loading all of it
+func (s *Server) logPage(api testkube.Client, id string, offset, limit int) (testkube.LogPage, error) {
+	body, err := api.OpenExecutionLogs(id)
+	if err != nil {
+		return testkube.LogPage{}, err
+	}
+	defer body.Close()
+	return testkube.ReadLogPage(body, offset, limit)
+}
+
+// logSearch greps an execution's log for the request's ?q=
+func (s *Server) logSearch(r *http.Request, id string) (testkube.LogSearch, int, error) {
+	query := strings.TrimSpace(r.URL.Query().Get("q"))
+	if query == "" {
+		return testkube.LogSearch{}, http.StatusBadRequest, fmt.Errorf("missing search query")
+	}
+	context := defaultLogContext
+	if val := r.URL.Query().Get("context"); val != "" {
+		n, err := strconv.Atoi(val)
+		if err != nil || n < 0 || n > maxLogContext {
+			return testkube.LogSearch{}, http.StatusBadRequest, fmt.Errorf("invalid context %q: use 0 to %d lines", val, maxLogContext)
+		}
+		context = n
+	}
+
+	body, err := s.apiFor(r).OpenExecutionLogs(id)
+	if err != nil {
+		log.Printf("Error opening execution logs: %v", err)
+		return testkube.LogSearch{}, http.StatusInternalServerError, fmt.Errorf("failed to load logs")
+	}
+	defer body.Close()
+	result, err := testkube.SearchLogs(body, query, context, maxLogMatches)
+	if err != nil {
+		log.Printf("Error searching execution logs: %v", err)
+		return testkube.LogSearch{}, http.StatusInternalServerError, fmt.Errorf("failed to search logs")
+	}
+	return result, http.StatusOK, nil
+}
+
+// handleExecutionLogs serves an execution's log as text. ?offset= and
+// ?limit= select a range of lines, and ?tail= the last lines; the
+// X-Log-Total-Lines header tells how many there are.
+func (s *Server) handleExecutionLogs(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	offset, limit, ranged, err := logRange(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	body, err := s.apiFor(r).OpenExecutionLogs(id)
+	if err != nil {
+		log.Printf("Error getting execution logs: %v", err)
+		http.Error(w, "Failed to load logs", http.StatusInternalServerError)
+		return
+	}
+	defer body.Close()
+
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	if !ranged {
+		if _, err := io.Copy(w, body); err != nil {
+			log.Printf("Error streaming execution logs: %v", err)
+		}
+		return
+	}
+
+	page, err := testkube.ReadLogPage(body, offset, limit)
+	if err != nil {
+		log.Printf("Error reading execution logs: %v", err)
+		http.Error(w, "Failed to load logs", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("X-Log-Offset", strconv.Itoa(page.Offset))
+	w.Header().Set("X-Log-Total-Lines", strconv.Itoa(page.Total))
+	for _, line := range page.Lines {
+		fmt.Fprintln(w, line)
+	}
+}
+
+// handleExecutionLogPage renders a range of log lines for the execution
+// page's "load earlier" button
+func (s *Server) handleExecutionLogPage(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	offset, limit, _, err := logRange(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	page, err := s.logPage(s.apiFor(r), id, offset, limit)
+	if err != nil {
+		log.Printf("Error reading execution logs: %v", err)
+		http.Error(w, "Failed to load logs", http.StatusInternalServerError)
+		return
+	}
+	s.executeTemplate(w, "execution_detail.html", "log-page", logPageData(id, page))
+}
+
+// logPageData describes a page of log lines and where the earlier ones start
+func logPageData(id string, page testkube.LogPage) map[string]interface{} {
+	earlier := max(page.Offset-logTailLines, 0)
+	return map[string]interface{}{
+		"ID":           id,
+		"Page":         page,
+		"Text":         strings.Join(page.Lines, "\n"),
+		"First":        page.Offset + 1,
+		"Last":         page.Offset + len(page.Lines),
+		"Earlier":      earlier,
+		"EarlierLimit": page.Offset - earlier,
+	}
+}
+
+// handleExecutionLogSearch renders the lines of a log matching ?q=
+func (s *Server) handleExecutionLogSearch(w http.ResponseWriter, r *http.Request) {
+	result, status, err := s.logSearch(r, chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, err.Error(), status)
+		return
+	}
+	s.executeTemplate(w, "execution_detail.html", "log-search", result)
+}
+
+func (s *Server) handleExecutionLogsAPI(w http.ResponseWriter, r *http.Request) {
+	offset, limit, _, err := logRange(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	page, err := s.logPage(s.apiFor(r), chi.URLParam(r, "id"), offset, limit)
+	if err != nil {
+		log.Printf("Error reading execution logs: %v", err)
+		http.Error(w, "Failed to load logs", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(page)
+}
+
+func (s *Server) handleExecutionLogSearchAPI(w http.ResponseWriter, r *http.Request) {
+	result, status, err := s.logSearch(r, chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, err.Error(), status)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(result)
+}
@@ -0,0 +1,94 @@
+package server
+
+import (
+	"bytes"
+	"maps"
+	"net/http"
+	"strings"
+
+	"github.com/testkube/dashboard/internal/pagecache"
+)
+
+// cachedPageHeaders are the response headers a cached page is served with
+var cachedPageHeaders = []string{"Content-Type", "Vary", "HX-Trigger"}
+
+// pageRecorder passes a response through while keeping a copy to cache
+type pageRecorder struct {
+	http.ResponseWriter
+	status int
+	body   bytes.Buffer
+}
+
+func (pr *pageRecorder) WriteHeader(status int) {
+	if pr.status == 0 {
+		pr.status = status
+	}
+	pr.ResponseWriter.WriteHeader(status)
+}
+
+func (pr *pageRecorder) Write(b []byte) (int, error) {
+	if pr.status == 0 {
+		pr.status = http.StatusOK
+	}
+	pr.body.Write(b)
+	return pr.ResponseWriter.Write(b)
+}
+
+// pageCacheKey is what a page's rendering depends on besides the data: the
+// URL with its filters, the cluster, the user (for per-user tables and
+// watchlist stars), the htmx fragment requested, whether auto-refresh is
+// paused and the feature flags on for the tenant, so switching a flag
+// shows at once
+func (s *Server) pageCacheKey(r *http.Request) string {
+	key := []string{r.URL.RequestURI(), s.clusterName(r), proxyUser(r), r.Header.Get("HX-Request"), r.Header.Get("HX-Boosted"), r.Header.Get("HX-Target")}
+	if cookie, err := r.Cookie(userCookie); err == nil {
+		key = append(key, cookie.Value)
+	}
+	if autoRefreshPaused(r) {
+		key = append(key, "autorefresh=off")
+	}
+	for _, state := range s.features.States(proxyTenant(r)) {
+		if state.Enabled {
+			key = append(key, state.Name)
+		}
+	}
+	return strings.Join(key, "\x00")
+}
+
+// cachePage serves a page from the page cache for a few seconds after it
+// was rendered, until new executions are ingested. A request with
+// "Cache-Control: no-cache", such as a hard refresh, renders it again.
+// While the database is down pages aren't cached, so the degraded banner
+// and last-known analytics show as soon as it fails.
+func (s *Server) cachePage(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !s.pages.Enabled() || r.Method != http.MethodGet || !s.dbHealth.status().Available {
+			next.ServeHTTP(w, r)
+			return
+		}
+		key := s.pageCacheKey(r)
+		if !strings.Contains(r.Header.Get("Cache-Control"), "no-cache") {
+			if page, ok := s.pages.Get(key); ok {
+				maps.Copy(w.Header(), page.Header)
+				w.Header().Set("X-Page-Cache", "hit")
+				w.WriteHeader(page.Status)
+				w.Write(page.Body)
+				return
+			}
+		}
+
+		w.Header().Set("X-Page-Cache", "miss")
+		pr := &pageRecorder{ResponseWriter: w}
+		next.ServeHTTP(pr, r)
+		if pr.status != http.StatusOK || !s.dbHealth.status().Available {
+			return
+		}
+		header := http.Header{}
+		for _, name := range cachedPageHeaders {
+			if values := w.Header().Values(name); len(values) > 0 {
+				header[name] = values
+			}
+		}
+		s.pages.Put(key, pagecache.Page{Status: pr.status, Header: header, Body: bytes.Clone(pr.body.Bytes())})
+	})
+}
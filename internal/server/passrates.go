@@ -0,0 +1,47 @@
+package server
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/testkube/dashboard/internal/baselines"
+	"github.com/testkube/dashboard/internal/testkube"
+)
+
+// passRate compares a workflow's pass rate today with its alert threshold
+func (s *Server) passRate(workflow string) (baselines.Result, error) {
+	rule := s.passRates.For(workflow)
+	// Today plus the baseline period
+	trend, err := s.db.GetPassRateTrend(workflow, rule.BaselineDays+1)
+	if err != nil {
+		return baselines.Result{}, err
+	}
+	return rule.Evaluate(workflow, trend, time.Now()), nil
+}
+
+// checkPassRate alerts when an ingested execution's workflow has dropped
+// below its pass rate threshold, and closes the alert once it recovers
+func (s *Server) checkPassRate(exec testkube.Execution) {
+	result, err := s.passRate(exec.WorkflowName)
+	if err != nil {
+		log.Printf("Error getting pass rate trend for %s: %v", exec.WorkflowName, err)
+		return
+	}
+	s.alerts.ObservePassRate(exec, result.Breached, result.Message())
+}
+
+// handlePassRateAlertAPI shows a workflow's pass rate today against the
+// threshold it alerts below
+func (s *Server) handlePassRateAlertAPI(w http.ResponseWriter, r *http.Request) {
+	result, err := s.passRate(chi.URLParam(r, "name"))
+	if err != nil {
+		log.Printf("Error getting pass rate trend: %v", err)
+		http.Error(w, "Failed to load pass rate trend", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(result)
+}
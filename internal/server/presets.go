@@ -0,0 +1,189 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/testkube/dashboard/internal/database"
+)
+
+// PresetTag records the variable preset a run was started with
+const PresetTag = "preset"
+
+var presetNamePattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._-]*$`)
+
+// errPresetNotFound is returned when a run asks for a preset the workflow
+// doesn't have
+var errPresetNotFound = errors.New("variable preset not found")
+
+// presetVariables returns the variables of a workflow's preset, or none for
+// an empty name
+func (s *Server) presetVariables(workflow, name string) (map[string]string, error) {
+	if name == "" {
+		return nil, nil
+	}
+	presets, err := s.db.GetVariablePresets(workflow)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load variable presets: %w", err)
+	}
+	for _, p := range presets {
+		if p.Name == name {
+			return p.Variables, nil
+		}
+	}
+	return nil, fmt.Errorf("%w: %s", errPresetNotFound, name)
+}
+
+// presetError responds with the status for a preset that couldn't be used
+func presetError(w http.ResponseWriter, err error) {
+	if errors.Is(err, errPresetNotFound) {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	log.Printf("Error loading variable preset: %v", err)
+	http.Error(w, "Failed to load variable preset", http.StatusInternalServerError)
+}
+
+// parseVariables parses KEY=VALUE lines from the preset form
+func parseVariables(text string) (map[string]string, error) {
+	vars := make(map[string]string)
+	for _, line := range strings.Split(text, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		key, value, found := strings.Cut(line, "=")
+		if !found {
+			return nil, fmt.Errorf("expected KEY=VALUE, got %q", line)
+		}
+		vars[strings.TrimSpace(key)] = strings.TrimSpace(value)
+	}
+	return vars, nil
+}
+
+// saveVariablePreset validates and stores a preset
+func (s *Server) saveVariablePreset(r *http.Request, preset database.VariablePreset) error {
+	if !presetNamePattern.MatchString(preset.Name) {
+		return fmt.Errorf("invalid preset name %q", preset.Name)
+	}
+	if len(preset.Variables) == 0 {
+		return fmt.Errorf("preset %s has no variables", preset.Name)
+	}
+	for key := range preset.Variables {
+		if key == "" || strings.ContainsAny(key, " \t=") {
+			return fmt.Errorf("invalid variable name %q", key)
+		}
+	}
+	preset.UpdatedBy = proxyUser(r)
+	preset.UpdatedAt = time.Now()
+	return s.db.SaveVariablePreset(preset)
+}
+
+// presetsData lists a workflow's presets for the run controls
+func (s *Server) presetsData(workflow string) map[string]interface{} {
+	presets, err := s.db.GetVariablePresets(workflow)
+	if err != nil {
+		log.Printf("Error getting variable presets: %v", err)
+	}
+	type presetRow struct {
+		database.VariablePreset
+		Text string
+	}
+	rows := make([]presetRow, len(presets))
+	for i, p := range presets {
+		keys := make([]string, 0, len(p.Variables))
+		for key := range p.Variables {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		lines := make([]string, len(keys))
+		for j, key := range keys {
+			lines[j] = key + "=" + p.Variables[key]
+		}
+		rows[i] = presetRow{VariablePreset: p, Text: strings.Join(lines, "\n")}
+	}
+	return map[string]interface{}{"Name": workflow, "Presets": rows}
+}
+
+// handleSaveVariablePreset saves a preset from the workflow page form
+func (s *Server) handleSaveVariablePreset(w http.ResponseWriter, r *http.Request) {
+	workflow := chi.URLParam(r, "name")
+	vars, err := parseVariables(r.FormValue("variables"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	preset := database.VariablePreset{Workflow: workflow, Name: strings.TrimSpace(r.FormValue("preset")), Variables: vars}
+	if err := s.saveVariablePreset(r, preset); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	trigger, _ := json.Marshal(map[string]string{"showMessage": "Saved preset " + preset.Name})
+	w.Header().Set("HX-Trigger", string(trigger))
+	s.executeTemplate(w, "workflow_detail.html", "run-presets", s.presetsData(workflow))
+}
+
+// handleDeleteVariablePreset deletes a preset from the workflow page
+func (s *Server) handleDeleteVariablePreset(w http.ResponseWriter, r *http.Request) {
+	workflow := chi.URLParam(r, "name")
+	if err := s.db.DeleteVariablePreset(workflow, chi.URLParam(r, "preset")); err != nil {
+		log.Printf("Error deleting variable preset: %v", err)
+		http.Error(w, "Failed to delete preset", http.StatusInternalServerError)
+		return
+	}
+	s.executeTemplate(w, "workflow_detail.html", "run-presets", s.presetsData(workflow))
+}
+
+func (s *Server) handleVariablePresetsAPI(w http.ResponseWriter, r *http.Request) {
+	presets, err := s.db.GetVariablePresets(chi.URLParam(r, "name"))
+	if err != nil {
+		log.Printf("Error getting variable presets: %v", err)
+		http.Error(w, "Failed to load presets", http.StatusInternalServerError)
+		return
+	}
+	if presets == nil {
+		presets = []database.VariablePreset{}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(presets)
+}
+
+// handleSaveVariablePresetAPI creates or replaces a preset from
+// {"variables": {"KEY": "value"}}
+func (s *Server) handleSaveVariablePresetAPI(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Variables map[string]string `json:"variables"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	preset := database.VariablePreset{
+		Workflow:  chi.URLParam(r, "name"),
+		Name:      chi.URLParam(r, "preset"),
+		Variables: req.Variables,
+	}
+	if err := s.saveVariablePreset(r, preset); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) handleDeleteVariablePresetAPI(w http.ResponseWriter, r *http.Request) {
+	if err := s.db.DeleteVariablePreset(chi.URLParam(r, "name"), chi.URLParam(r, "preset")); err != nil {
+		log.Printf("Error deleting variable preset: %v", err)
+		http.Error(w, "Failed to delete preset", http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
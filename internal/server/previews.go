@@ -0,0 +1,179 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/testkube/dashboard/internal/database"
+	"github.com/testkube/dashboard/internal/environments"
+	"github.com/testkube/dashboard/internal/previews"
+)
+
+// previewHookResponse says what a pull request webhook did
+type previewHookResponse struct {
+	Action      previews.Action           `json:"action"`
+	Environment *environments.Environment `json:"environment,omitempty"`
+}
+
+// handlePullRequestHook keeps a preview environment for each open pull
+// request: opening one creates the environment and comments its URL on the
+// pull request, pushes keep it alive, and merging or closing tears it down
+func (s *Server) handlePullRequestHook(w http.ResponseWriter, r *http.Request) {
+	if !s.previews.Enabled() {
+		http.Error(w, "Pull request webhooks not configured", http.StatusServiceUnavailable)
+		return
+	}
+
+	event, err := previews.ParseWebhook(r, s.previews.Secret)
+	if errors.Is(err, previews.ErrInvalidSignature) {
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
+	if errors.Is(err, previews.ErrUnsupportedEvent) {
+		writePreviewResponse(w, http.StatusOK, previewHookResponse{Action: previews.ActionIgnore})
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	pr := event.PullRequest
+	switch event.Action {
+	case previews.ActionDeploy:
+		s.deployPreview(w, r, pr)
+	case previews.ActionTeardown:
+		for _, env := range s.previewEnvironments(pr) {
+			if err := s.envMgr.Delete(env.ID); err != nil {
+				log.Printf("Failed to delete preview environment %s: %v", env.Name, err)
+				http.Error(w, "Failed to delete environment", http.StatusInternalServerError)
+				return
+			}
+			log.Printf("Deleted preview environment %s for %s", env.Name, pr.URL)
+			s.recordEvent(database.Event{
+				Type:    activityEnvironmentDeleted,
+				Actor:   pr.Author,
+				Subject: env.Name,
+				Message: "Deleted when its pull request closed",
+				URL:     pr.URL,
+			})
+		}
+		writePreviewResponse(w, http.StatusOK, previewHookResponse{Action: event.Action})
+	default:
+		writePreviewResponse(w, http.StatusOK, previewHookResponse{Action: event.Action})
+	}
+}
+
+// deployPreview creates the pull request's environment, or, when it has one,
+// pushes its expiry back to a full TTL from now
+func (s *Server) deployPreview(w http.ResponseWriter, r *http.Request, pr previews.PullRequest) {
+	if existing := s.previewEnvironments(pr); len(existing) > 0 {
+		env := existing[0]
+		if hours := hoursUntil(env.ExpiresAt, time.Now().Add(s.previews.TTL)); hours > 0 {
+			if err := s.envMgr.Extend(env.ID, hours); err != nil {
+				log.Printf("Failed to extend preview environment %s: %v", env.Name, err)
+			}
+		}
+		env, _ = s.envMgr.Get(env.ID)
+		writePreviewResponse(w, http.StatusOK, previewHookResponse{Action: previews.ActionDeploy, Environment: env})
+		return
+	}
+
+	if _, ok := s.checkQuota(r.Context(), w, s.envMgr.Namespace()); !ok {
+		return
+	}
+
+	owner := pr.Author
+	if owner == "" {
+		owner = "anonymous"
+	}
+	env, err := s.envMgr.Create(r.Context(), environments.CreateEnvironmentRequest{
+		Name:        pr.EnvironmentName(),
+		Owner:       owner,
+		Type:        environments.TypeEphemeral,
+		Branch:      pr.Branch,
+		Commit:      pr.Commit,
+		PullRequest: pr.URL,
+		TTLHours:    int(math.Ceil(s.previews.TTL.Hours())),
+		Template:    s.previews.Template,
+	})
+	if errors.Is(err, environments.ErrInvalidHostname) || errors.Is(err, environments.ErrUnknownTemplate) {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if errors.Is(err, environments.ErrHostnameInUse) {
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	}
+	if err != nil {
+		log.Printf("Failed to create preview environment for %s: %v", pr.URL, err)
+		http.Error(w, "Failed to create environment", http.StatusInternalServerError)
+		return
+	}
+
+	log.Printf("Created preview environment %s for %s", env.Name, pr.URL)
+	s.recordEvent(database.Event{
+		Type:    activityEnvironmentCreated,
+		Actor:   owner,
+		Subject: env.Name,
+		Message: fmt.Sprintf("Created preview environment for %s #%d", pr.Repository, pr.Number),
+		URL:     env.URL,
+	})
+
+	if s.commenter != nil {
+		ctx, cancel := context.WithTimeout(context.WithoutCancel(r.Context()), 15*time.Second)
+		defer cancel()
+		if err := s.commenter.Comment(ctx, pr, previewComment(env)); err != nil {
+			log.Printf("Error commenting on %s: %v", pr.URL, err)
+		}
+	}
+
+	writePreviewResponse(w, http.StatusCreated, previewHookResponse{Action: previews.ActionDeploy, Environment: env})
+}
+
+// previewEnvironments are the pull request's environments not already being
+// torn down
+func (s *Server) previewEnvironments(pr previews.PullRequest) []*environments.Environment {
+	var envs []*environments.Environment
+	for _, env := range s.envMgr.List(environments.ListEnvironmentsOptions{PullRequest: pr.URL}) {
+		if env.Status != environments.StatusDeleting {
+			envs = append(envs, env)
+		}
+	}
+	return envs
+}
+
+// previewComment is the pull request comment announcing its environment
+func previewComment(env *environments.Environment) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Preview environment **%s** is being created at %s\n", env.Name, env.URL)
+	if base := strings.TrimSuffix(os.Getenv("DASHBOARD_URL"), "/"); base != "" {
+		fmt.Fprintf(&b, "\nProvisioning progress: %s/environments/%s\n", base, url.PathEscape(env.ID))
+	}
+	fmt.Fprintf(&b, "\nIt is deleted when this pull request is merged or closed, or after %s without a push.\n",
+		formatDuration(env.ExpiresAt.Sub(env.CreatedAt)))
+	return b.String()
+}
+
+// hoursUntil is the whole number of hours from expires to at least target
+func hoursUntil(expires, target time.Time) int {
+	if !expires.Before(target) {
+		return 0
+	}
+	return int(math.Ceil(target.Sub(expires).Hours()))
+}
+
+func writePreviewResponse(w http.ResponseWriter, status int, resp previewHookResponse) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(resp)
+}
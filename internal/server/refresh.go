@@ -0,0 +1,124 @@
+package server
+
+import (
+	"fmt"
+	"log"
+	"maps"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+)
+
+const (
+	// autoRefreshParam pauses ("off") or resumes ("on") the polling of live
+	// regions, and autoRefreshCookie remembers the browser's choice, so a
+	// wallboard can be pointed at "/?autorefresh=on" once
+	autoRefreshParam  = "autorefresh"
+	autoRefreshCookie = "dashboard_autorefresh"
+	// minRefreshInterval keeps a misconfigured page from hammering the
+	// server
+	minRefreshInterval = 2 * time.Second
+)
+
+// defaultRefreshIntervals are how often each page's live regions poll.
+// Pages not listed, such as execution and workflow pages, don't poll, so
+// they don't move under someone reading them.
+var defaultRefreshIntervals = refreshPolicy{
+	"dashboard":  30 * time.Second,
+	"queue":      10 * time.Second,
+	"synthetics": 30 * time.Second,
+}
+
+// refreshPolicy is how often pages poll, by page
+type refreshPolicy map[string]time.Duration
+
+// refreshPolicyFromEnv reads REFRESH_INTERVALS, page=interval pairs such as
+// "dashboard=10s,queue=5s" overriding the defaults; an interval of 0 stops
+// a page polling
+func refreshPolicyFromEnv() refreshPolicy {
+	policy, err := parseRefreshIntervals(os.Getenv("REFRESH_INTERVALS"))
+	if err != nil {
+		log.Printf("Warning: invalid REFRESH_INTERVALS, using the defaults: %v", err)
+		return maps.Clone(defaultRefreshIntervals)
+	}
+	return policy
+}
+
+func parseRefreshIntervals(value string) (refreshPolicy, error) {
+	policy := maps.Clone(defaultRefreshIntervals)
+	for _, pair := range strings.Split(value, ",") {
+		if pair = strings.TrimSpace(pair); pair == "" {
+			continue
+		}
+		page, val, ok := strings.Cut(pair, "=")
+		page = strings.TrimSpace(page)
+		if !ok || page == "" {
+			return nil, fmt.Errorf("%q is not page=interval", pair)
+		}
+		interval, err := time.ParseDuration(strings.TrimSpace(val))
+		if err != nil || interval < 0 {
+			return nil, fmt.Errorf("invalid interval for %s: %q", page, val)
+		}
+		if interval > 0 && interval < minRefreshInterval {
+			return nil, fmt.Errorf("interval for %s is under %s", page, minRefreshInterval)
+		}
+		policy[page] = interval
+	}
+	return policy, nil
+}
+
+// refresh is how a page's live regions poll, for its templates
+type refresh struct {
+	Interval time.Duration
+	// Paused is the browser's choice to stop polling
+	Paused bool
+}
+
+// Polls reports whether the page's regions poll on their own
+func (r refresh) Polls() bool {
+	return r.Interval > 0 && !r.Paused
+}
+
+// Trigger is a live region's hx-trigger: its interval while polling, and
+// the body's "refresh" event, sent by "Refresh now", always
+func (r refresh) Trigger() string {
+	if !r.Polls() {
+		return "refresh from:body"
+	}
+	// htmx reads "1m0s" as a second, so intervals go in milliseconds
+	return fmt.Sprintf("every %dms, refresh from:body", r.Interval.Milliseconds())
+}
+
+// autoRefreshPaused reports whether the request's browser paused polling,
+// by ?autorefresh= or its remembered choice
+func autoRefreshPaused(r *http.Request) bool {
+	if value := r.URL.Query().Get(autoRefreshParam); value != "" {
+		return value == "off"
+	}
+	cookie, err := r.Cookie(autoRefreshCookie)
+	return err == nil && cookie.Value == "off"
+}
+
+// rememberAutoRefresh saves ?autorefresh= in the browser for the pages it
+// visits next. It runs before the page cache, which doesn't keep cookies.
+func rememberAutoRefresh(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if value := r.URL.Query().Get(autoRefreshParam); value == "on" || value == "off" {
+			http.SetCookie(w, &http.Cookie{
+				Name:     autoRefreshCookie,
+				Value:    value,
+				Path:     "/",
+				MaxAge:   365 * 24 * 60 * 60,
+				HttpOnly: true,
+				SameSite: http.SameSiteLaxMode,
+			})
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// refreshFor returns how a page polls for the request
+func (s *Server) refreshFor(r *http.Request, page string) refresh {
+	return refresh{Interval: s.refresh[page], Paused: autoRefreshPaused(r)}
+}
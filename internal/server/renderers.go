@@ -0,0 +1,183 @@
+package server
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/testkube/dashboard/internal/database"
+	"github.com/testkube/dashboard/internal/parsers"
+	"github.com/testkube/dashboard/internal/testkube"
+)
+
+// maxResultArtifacts caps how many of an execution's artifacts a renderer
+// downloads to build its view
+const maxResultArtifacts = 20
+
+// executionRenderer shows the results of a kind of workflow on its
+// executions' pages, in place of or alongside the generic test case table.
+// Workflow types select one by name through their Renderer.
+type executionRenderer struct {
+	// template is the fragment of execution_results.html that shows the data
+	template string
+	// testCases keeps the generic test case table on the page
+	testCases bool
+	data      func(s *Server, api testkube.Client, id string, artifacts []testkube.Artifact) (map[string]interface{}, error)
+}
+
+var executionRenderers = map[string]executionRenderer{
+	"playwright": {template: "playwright-results", testCases: true, data: (*Server).playwrightResults},
+	"k6":         {template: "k6-results", data: (*Server).k6Results},
+	"findings":   {template: "findings-results", data: (*Server).findingsResults},
+}
+
+// executionRenderer returns the renderer for a workflow's executions, if its
+// type has one
+func (s *Server) executionRenderer(api testkube.Client, workflowName string) (executionRenderer, bool) {
+	workflow, err := api.GetWorkflow(workflowName)
+	if err != nil {
+		return executionRenderer{}, false
+	}
+	renderer, ok := executionRenderers[s.types.Get(workflow.Type).Renderer]
+	return renderer, ok
+}
+
+// handleExecutionResults renders the type-specific results of an execution,
+// loaded by its page after the rest
+func (s *Server) handleExecutionResults(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	api := s.apiFor(r)
+	exec, err := api.GetExecution(id)
+	if err != nil {
+		http.Error(w, "Execution not found", http.StatusNotFound)
+		return
+	}
+	renderer, ok := s.executionRenderer(api, exec.WorkflowName)
+	if !ok {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	artifacts, err := api.GetArtifacts(id)
+	if err != nil {
+		log.Printf("Error getting artifacts: %v", err)
+		http.Error(w, "Failed to load results", http.StatusInternalServerError)
+		return
+	}
+	data, err := renderer.data(s, api, id, artifacts)
+	if err != nil {
+		log.Printf("Error building results of %s: %v", id, err)
+		http.Error(w, "Failed to load results", http.StatusInternalServerError)
+		return
+	}
+	data["ExecutionID"] = id
+	s.executeTemplate(w, "execution_results.html", renderer.template, data)
+}
+
+// resultArtifacts downloads the JSON artifacts accepted by match, skipping
+// ones too large to parse
+func (s *Server) resultArtifacts(api testkube.Client, id string, artifacts []testkube.Artifact, match func(data []byte) bool) map[string][]byte {
+	found := make(map[string][]byte)
+	for _, a := range artifacts {
+		if len(found) == maxResultArtifacts {
+			break
+		}
+		if strings.ToLower(filepath.Ext(a.Path)) != ".json" || a.Size > maxFindingsReportSize {
+			continue
+		}
+		data, err := api.DownloadArtifact(id, a.Path)
+		if err != nil {
+			log.Printf("Error downloading %s of %s: %v", a.Path, id, err)
+			continue
+		}
+		if match(data) {
+			found[a.Path] = data
+		}
+	}
+	return found
+}
+
+// k6Results shows the headline metrics of each summary in the artifacts,
+// and the metrics ingested into the database
+func (s *Server) k6Results(api testkube.Client, id string, artifacts []testkube.Artifact) (map[string]interface{}, error) {
+	type summary struct {
+		Path string
+		*parsers.K6Summary
+	}
+	var summaries []summary
+	for path, data := range s.resultArtifacts(api, id, artifacts, parsers.IsK6Summary) {
+		parsed, err := parsers.ParseK6Summary(data)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+		}
+		summaries = append(summaries, summary{Path: path, K6Summary: parsed})
+	}
+	sort.Slice(summaries, func(i, j int) bool { return summaries[i].Path < summaries[j].Path })
+
+	metrics, _, err := dbRead(s, "k6-metrics:"+id, func() ([]database.K6MetricRecord, error) { return s.db.GetK6Metrics(id) })
+	if err != nil {
+		log.Printf("Error getting k6 metrics of %s: %v", id, err)
+	}
+	return map[string]interface{}{
+		"Summaries": summaries,
+		"Metrics":   metrics,
+	}, nil
+}
+
+// findingSeverityOrder ranks severities, most severe first
+var findingSeverityOrder = map[string]int{"critical": 0, "high": 1, "error": 1, "medium": 2, "warning": 2, "low": 3}
+
+func findingRank(severity string) int {
+	if rank, ok := findingSeverityOrder[strings.ToLower(severity)]; ok {
+		return rank
+	}
+	return len(findingSeverityOrder)
+}
+
+// findingsResults lists security findings, most severe first, with a count
+// per severity
+func (s *Server) findingsResults(api testkube.Client, id string, artifacts []testkube.Artifact) (map[string]interface{}, error) {
+	var findings []parsers.Finding
+	for _, data := range s.resultArtifacts(api, id, artifacts, func(data []byte) bool { return !parsers.IsPlaywrightReport(data) }) {
+		parsed, err := parsers.ParseFindings(data)
+		if err != nil {
+			continue
+		}
+		findings = append(findings, parsed...)
+	}
+	// Scanners disagree on case, so severities are shown upper case
+	for i := range findings {
+		findings[i].Severity = strings.ToUpper(findings[i].Severity)
+	}
+	sort.SliceStable(findings, func(i, j int) bool {
+		if ri, rj := findingRank(findings[i].Severity), findingRank(findings[j].Severity); ri != rj {
+			return ri < rj
+		}
+		return findings[i].ID < findings[j].ID
+	})
+
+	counts := make(map[string]int)
+	for _, f := range findings {
+		counts[f.Severity]++
+	}
+	return map[string]interface{}{
+		"Findings":   findings,
+		"Severities": counts,
+	}, nil
+}
+
+// playwrightResults lists the execution's traces, which open in the
+// Playwright trace viewer
+func (s *Server) playwrightResults(api testkube.Client, id string, artifacts []testkube.Artifact) (map[string]interface{}, error) {
+	var traces []testkube.Artifact
+	for _, a := range artifacts {
+		if strings.HasSuffix(a.Name, "trace.zip") {
+			traces = append(traces, a)
+		}
+	}
+	return map[string]interface{}{"Traces": traces}, nil
+}
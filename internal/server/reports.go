@@ -0,0 +1,265 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/testkube/dashboard/internal/reports"
+	"github.com/testkube/dashboard/internal/testkube"
+)
+
+// flakinessWindows are the report windows offered in the UI, in days
+var flakinessWindows = []int{7, 14, 30}
+
+func (s *Server) flakinessReport(r *http.Request) (*reports.FlakinessReport, error) {
+	days := queryInt(r, "days", 7)
+	limit := queryInt(r, "limit", reports.DefaultLeaderboardSize)
+	return reports.BuildFlakinessReport(s.db, s.ownership, time.Now(), days, limit)
+}
+
+func (s *Server) handleFlakinessReport(w http.ResponseWriter, r *http.Request) {
+	report, err := s.flakinessReport(r)
+	if err != nil {
+		s.databaseError(w, "the flakiness report", err)
+		return
+	}
+
+	if r.URL.Query().Get("format") == "md" {
+		filename := fmt.Sprintf("flakiness-%s.md", report.To.Format("2006-01-02"))
+		w.Header().Set("Content-Type", "text/markdown; charset=utf-8")
+		w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+		w.Write([]byte(report.Markdown()))
+		return
+	}
+
+	data := map[string]interface{}{
+		"Report":  report,
+		"Days":    report.Days(),
+		"Windows": flakinessWindows,
+		"Watch":   s.watchButtons(r),
+	}
+
+	s.render(w, "flakiness_report.html", data)
+}
+
+func (s *Server) handleFlakinessReportAPI(w http.ResponseWriter, r *http.Request) {
+	report, err := s.flakinessReport(r)
+	if err != nil {
+		s.databaseError(w, "the flakiness report", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(report)
+}
+
+// environmentSLAWindows are the environment SLA report windows offered in
+// the UI, in days
+var environmentSLAWindows = []int{7, 30, 90}
+
+// provisioningSLO reads the promised provisioning time from
+// ENVIRONMENT_PROVISION_SLO, e.g. "5m"
+func provisioningSLO() time.Duration {
+	if value := os.Getenv("ENVIRONMENT_PROVISION_SLO"); value != "" {
+		slo, err := time.ParseDuration(value)
+		if err == nil && slo > 0 {
+			return slo
+		}
+		log.Printf("Warning: invalid ENVIRONMENT_PROVISION_SLO %q, using %s", value, reports.DefaultProvisioningSLO)
+	}
+	return reports.DefaultProvisioningSLO
+}
+
+func (s *Server) environmentSLAReport(r *http.Request) *reports.EnvironmentSLAReport {
+	days := queryInt(r, "days", 30)
+	limit := queryInt(r, "limit", reports.DefaultLeaderboardSize)
+	to := time.Now()
+	from := to.AddDate(0, 0, -days)
+	return reports.BuildEnvironmentSLAReport(s.envMgr.History(from), from, to, provisioningSLO(), reports.DefaultSLOTarget, limit)
+}
+
+func (s *Server) handleEnvironmentSLAReport(w http.ResponseWriter, r *http.Request) {
+	report := s.environmentSLAReport(r)
+
+	data := map[string]interface{}{
+		"Report":  report,
+		"Days":    report.Days(),
+		"Windows": environmentSLAWindows,
+		"Page":    "environments",
+	}
+
+	s.render(w, "environment_sla_report.html", data)
+}
+
+func (s *Server) handleEnvironmentSLAReportAPI(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(s.environmentSLAReport(r))
+}
+
+// defaultComparisonDays is the length of the windows compared without
+// ?from= and ?to=: the last week against the week before
+const defaultComparisonDays = 7
+
+// comparisonRequest is a comparison report's scope and windows, read from
+// ?workflow= or ?suite=, ?from= and ?to= for the current window and
+// ?prevFrom= and ?prevTo= for the one it's compared with
+type comparisonRequest struct {
+	Scope    reports.ComparisonScope
+	Current  reports.Window
+	Previous reports.Window
+}
+
+// errNoComparisonScope is returned when a comparison names neither a
+// workflow nor a suite
+var errNoComparisonScope = errors.New("choose a workflow or a suite to compare")
+
+func (s *Server) parseComparison(r *http.Request) (comparisonRequest, int, error) {
+	var req comparisonRequest
+	query := r.URL.Query()
+	workflow, suite := query.Get("workflow"), query.Get("suite")
+	switch {
+	case workflow != "" && suite != "":
+		return req, http.StatusBadRequest, fmt.Errorf("compare a workflow or a suite, not both")
+	case suite != "":
+		found, ok := s.suites.Get(suite)
+		if !ok {
+			return req, http.StatusNotFound, fmt.Errorf("suite %s not found", suite)
+		}
+		req.Scope = reports.ComparisonScope{Name: suite, Suite: true, Includes: found.Includes}
+	case workflow != "":
+		req.Scope = reports.ComparisonScope{Name: workflow, Includes: func(name string) bool { return name == workflow }}
+	default:
+		return req, http.StatusBadRequest, errNoComparisonScope
+	}
+
+	current, err := parseWindow(query.Get("from"), query.Get("to"))
+	if err != nil {
+		return req, http.StatusBadRequest, err
+	}
+	if current.To.IsZero() {
+		current.To = time.Now()
+	}
+	if current.From.IsZero() {
+		current.From = current.To.AddDate(0, 0, -defaultComparisonDays)
+	}
+	req.Current = current
+
+	previous, err := parseWindow(query.Get("prevFrom"), query.Get("prevTo"))
+	if err != nil {
+		return req, http.StatusBadRequest, fmt.Errorf("previous window: %w", err)
+	}
+	switch {
+	case previous.From.IsZero() && previous.To.IsZero():
+		previous = current.Before()
+	case previous.From.IsZero() || previous.To.IsZero():
+		return req, http.StatusBadRequest, fmt.Errorf("previous window: give both prevFrom and prevTo")
+	}
+	req.Previous = previous
+	return req, http.StatusOK, nil
+}
+
+// parseWindow reads a window's bounds the way parseDateRange reads ?from=
+// and ?to=
+func parseWindow(from, to string) (reports.Window, error) {
+	var w reports.Window
+	var err error
+	if w.From, err = parseRangeBound(from, false); err != nil {
+		return w, fmt.Errorf("invalid from: %w", err)
+	}
+	if w.To, err = parseRangeBound(to, true); err != nil {
+		return w, fmt.Errorf("invalid to: %w", err)
+	}
+	if !w.From.IsZero() && !w.To.IsZero() && !w.From.Before(w.To) {
+		return w, fmt.Errorf("from must be before to")
+	}
+	return w, nil
+}
+
+func (s *Server) comparisonReport(req comparisonRequest) (*reports.ComparisonReport, error) {
+	return reports.BuildComparisonReport(s.db, req.Scope, req.Current, req.Previous, reports.DefaultLeaderboardSize)
+}
+
+// handleComparisonReport compares a workflow's or suite's runs in two
+// windows, for sprint reviews and checking for regressions after a merge
+func (s *Server) handleComparisonReport(w http.ResponseWriter, r *http.Request) {
+	data := map[string]interface{}{
+		"Suites":   s.suites.List(),
+		"Workflow": r.URL.Query().Get("workflow"),
+		"Suite":    r.URL.Query().Get("suite"),
+	}
+
+	req, status, err := s.parseComparison(r)
+	if errors.Is(err, errNoComparisonScope) {
+		s.render(w, "comparison_report.html", data)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), status)
+		return
+	}
+	report, err := s.comparisonReport(req)
+	if err != nil {
+		s.databaseError(w, "the comparison report", err)
+		return
+	}
+
+	data["Report"] = report
+	data["Current"] = windowInputs(req.Current)
+	data["Previous"] = windowInputs(req.Previous)
+	s.render(w, "comparison_report.html", data)
+}
+
+func (s *Server) handleComparisonReportAPI(w http.ResponseWriter, r *http.Request) {
+	req, status, err := s.parseComparison(r)
+	if err != nil {
+		http.Error(w, err.Error(), status)
+		return
+	}
+	report, err := s.comparisonReport(req)
+	if err != nil {
+		s.databaseError(w, "the comparison report", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(report)
+}
+
+// windowInputs shows a window back in the report form
+func windowInputs(w reports.Window) dateRange {
+	return dateRange{From: w.From, To: w.To}
+}
+
+// handleOfflineBundle downloads a workflow's history over ?days= as a zip
+// of static pages, for viewing where the dashboard can't be reached
+func (s *Server) handleOfflineBundle(w http.ResponseWriter, r *http.Request) {
+	name := chi.URLParam(r, "name")
+	days := queryInt(r, "days", reports.DefaultOfflineDays)
+	executions, err := s.apiFor(r).GetExecutions(testkube.ListOptions{Workflow: name, PageSize: reports.MaxOfflineRuns})
+	if err != nil {
+		s.listError(w, "executions", err)
+		return
+	}
+	report, err := reports.BuildOfflineReport(s.db, name, executions, time.Now(), days)
+	if err != nil {
+		s.databaseError(w, "the offline bundle", err)
+		return
+	}
+	bundle, err := report.Bundle()
+	if err != nil {
+		log.Printf("Error bundling %s history: %v", name, err)
+		http.Error(w, "Failed to build the offline bundle", http.StatusInternalServerError)
+		return
+	}
+
+	filename := fmt.Sprintf("%s-history-%s.zip", name, report.GeneratedAt.Format("2006-01-02"))
+	w.Header().Set("Content-Type", "application/zip")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+	w.Write(bundle)
+}
@@ -0,0 +1,194 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"maps"
+	"net/http"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/testkube/dashboard/internal/testkube"
+)
+
+const (
+	// RerunOfTag links a rerun execution back to the execution it re-ran
+	RerunOfTag = "rerun-of"
+	// RerunTypeTag records what kind of rerun produced the execution
+	RerunTypeTag = "rerun-type"
+
+	defaultRerunVariable = "grep"
+)
+
+// projectPrefix matches the "[project] " prefix added to Playwright test names
+var projectPrefix = regexp.MustCompile(`^\[[^\]]+\] `)
+
+// handleRerunFailed starts a new run of the execution's workflow limited to
+// the test cases that failed, passing them as a grep pattern config variable.
+func (s *Server) handleRerunFailed(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+
+	exec, err := s.apiFor(r).GetExecution(id)
+	if err != nil {
+		log.Printf("Error getting execution: %v", err)
+		http.Error(w, "Execution not found", http.StatusNotFound)
+		return
+	}
+
+	testCases, err := s.db.GetExecutionMetrics(id)
+	if err != nil {
+		log.Printf("Error getting test cases: %v", err)
+		http.Error(w, "Failed to load test cases", http.StatusInternalServerError)
+		return
+	}
+
+	var failed []string
+	for _, tc := range testCases {
+		if tc.Status == "failed" {
+			failed = append(failed, tc.TestName)
+		}
+	}
+	if len(failed) == 0 {
+		http.Error(w, "Execution has no failed test cases to re-run", http.StatusConflict)
+		return
+	}
+
+	variable := os.Getenv("RERUN_FAILED_VARIABLE")
+	if variable == "" {
+		variable = defaultRerunVariable
+	}
+
+	depTags, ok := s.checkDependencies(r.Context(), w, exec.WorkflowName)
+	if !ok {
+		return
+	}
+	tags := triggerTags(r, testkube.TriggerRetry)
+	tags[RerunOfTag] = exec.ID
+	tags[RerunTypeTag] = "failed-only"
+	maps.Copy(tags, depTags)
+
+	rerun, queued, ok := s.submitRun(w, r, exec.WorkflowName, testkube.RunOptions{
+		Config: map[string]string{variable: failedTestsPattern(failed)},
+		Tags:   tags,
+	})
+	if !ok {
+		return
+	}
+	if queued != nil {
+		log.Printf("Queued re-run of %d failed tests of %s", len(failed), id)
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusAccepted)
+		json.NewEncoder(w).Encode(queued)
+		return
+	}
+
+	log.Printf("Started execution %s re-running %d failed tests of %s", rerun.ID, len(failed), id)
+
+	w.Header().Set("HX-Redirect", "/executions/"+rerun.ID)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(rerun)
+}
+
+// handleRerunExecution starts the execution's workflow again with the
+// config variables, tags and runner target the execution was started
+// with. The new run's own trigger replaces the original's.
+func (s *Server) handleRerunExecution(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	api := s.apiFor(r)
+
+	exec, err := api.GetExecution(id)
+	if err != nil {
+		log.Printf("Error getting execution: %v", err)
+		http.Error(w, "Execution not found", http.StatusNotFound)
+		return
+	}
+	inputs, err := api.GetExecutionInputs(id)
+	if errors.Is(err, testkube.ErrInputsUnavailable) {
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	}
+	if err != nil {
+		log.Printf("Error getting inputs of %s: %v", id, err)
+		http.Error(w, "Failed to load the execution's inputs", http.StatusInternalServerError)
+		return
+	}
+
+	depTags, ok := s.checkDependencies(r.Context(), w, exec.WorkflowName)
+	if !ok {
+		return
+	}
+	tags := inputs.Tags
+	if tags == nil {
+		tags = map[string]string{}
+	}
+	delete(tags, testkube.TriggeredByTag)
+	maps.Copy(tags, triggerTags(r, testkube.TriggerRetry))
+	tags[RerunOfTag] = exec.ID
+	tags[RerunTypeTag] = "identical"
+	maps.Copy(tags, depTags)
+	inputs.Tags = tags
+
+	rerun, queued, ok := s.submitRun(w, r, exec.WorkflowName, *inputs)
+	if !ok {
+		return
+	}
+	if queued != nil {
+		log.Printf("Queued re-run of %s", id)
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusAccepted)
+		json.NewEncoder(w).Encode(queued)
+		return
+	}
+
+	log.Printf("Started execution %s re-running %s", rerun.ID, id)
+
+	w.Header().Set("HX-Redirect", "/executions/"+rerun.ID)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(rerun)
+}
+
+// failedTestsPattern builds an anchored regex matching exactly the given test
+// titles. Runners match grep against the test title, so only the innermost
+// title segment is used.
+func failedTestsPattern(names []string) string {
+	seen := make(map[string]bool)
+	var titles []string
+	for _, name := range names {
+		title := projectPrefix.ReplaceAllString(name, "")
+		if i := strings.LastIndex(title, " > "); i >= 0 {
+			title = title[i+3:]
+		}
+		if !seen[title] {
+			seen[title] = true
+			titles = append(titles, regexp.QuoteMeta(title))
+		}
+	}
+	sort.Strings(titles)
+	return "^(" + strings.Join(titles, "|") + ")$"
+}
+
+// findReruns returns executions that were started as reruns of exec
+func (s *Server) findReruns(api testkube.Client, exec *testkube.Execution) []testkube.Execution {
+	executions, err := api.GetExecutions(testkube.ListOptions{
+		Workflow: exec.WorkflowName,
+		PageSize: 50,
+	})
+	if err != nil {
+		log.Printf("Error getting reruns of %s: %v", exec.ID, err)
+		return nil
+	}
+
+	var reruns []testkube.Execution
+	for _, e := range executions {
+		if e.Labels[RerunOfTag] == exec.ID {
+			reruns = append(reruns, e)
+		}
+	}
+	return reruns
+}
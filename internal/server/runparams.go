@@ -0,0 +1,146 @@
+package server
+
+import (
+	"encoding/json"
+	"log"
+	"maps"
+	"mime"
+	"net/http"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/testkube/dashboard/internal/testkube"
+)
+
+// maxRunRequestSize caps the body of a parameterized run request
+const maxRunRequestSize = 64 * 1024
+
+// configFieldPrefix prefixes run form fields that set config variables,
+// e.g. config.browser=firefox
+const configFieldPrefix = "config."
+
+// runRequest is the body of a parameterized run through the API
+type runRequest struct {
+	// Name replaces the generated execution name
+	Name   string                    `json:"name"`
+	Config map[string]string         `json:"config"`
+	Tags   map[string]string         `json:"tags"`
+	Target *testkube.ExecutionTarget `json:"target"`
+	// CI identifies the build requesting the run
+	CI ciMetadata `json:"ci"`
+}
+
+// apply adds the request's parameters to a run's options. Config variables
+// override a preset's, but tags the dashboard sets itself (trigger,
+// dependencies, CI metadata) win over the caller's.
+func (req runRequest) apply(opts *testkube.RunOptions) {
+	if len(req.Config) > 0 {
+		config := make(map[string]string, len(opts.Config)+len(req.Config))
+		maps.Copy(config, opts.Config)
+		maps.Copy(config, req.Config)
+		opts.Config = config
+	}
+	if len(req.Tags) > 0 {
+		tags := maps.Clone(req.Tags)
+		maps.Copy(tags, opts.Tags)
+		opts.Tags = tags
+	}
+	if req.Name != "" {
+		opts.Name = req.Name
+	}
+	if req.Target != nil {
+		opts.Target = req.Target
+	}
+}
+
+// formRunRequest reads the parameters of a run from the run form: a field
+// per config variable, left empty for the workflow's default, and the
+// execution name
+func formRunRequest(r *http.Request) runRequest {
+	req := runRequest{Name: strings.TrimSpace(r.FormValue("executionName"))}
+	for field, values := range r.Form {
+		key, ok := strings.CutPrefix(field, configFieldPrefix)
+		if !ok || key == "" || len(values) == 0 || values[0] == "" {
+			continue
+		}
+		if req.Config == nil {
+			req.Config = make(map[string]string)
+		}
+		req.Config[key] = values[0]
+	}
+	return req
+}
+
+// workflowParameters lists the config variables a workflow declares, for
+// the run form
+func (s *Server) workflowParameters(api testkube.Client, name string) []testkube.ConfigParameter {
+	spec, err := api.GetWorkflowSpec(name)
+	if err != nil {
+		log.Printf("Error getting spec of %s: %v", name, err)
+		return nil
+	}
+	params, err := testkube.WorkflowConfig(spec)
+	if err != nil {
+		log.Printf("Error reading config parameters of %s: %v", name, err)
+	}
+	return params
+}
+
+// decodeRunRequest reads an API run request: its JSON body, if it has one,
+// and CI metadata from the body's "ci" object, or from ci* form fields when
+// the request is a form. It writes a 400 response and returns false when
+// either is invalid.
+func decodeRunRequest(w http.ResponseWriter, r *http.Request) (runRequest, ciMetadata, bool) {
+	var req runRequest
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if r.ContentLength == 0 || mediaType == "application/x-www-form-urlencoded" || mediaType == "multipart/form-data" {
+		ci, err := parseCIMetadata(r)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return req, ci, false
+		}
+		return req, ci, true
+	}
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxRunRequestSize)).Decode(&req); err != nil {
+		http.Error(w, "Invalid run request: "+err.Error(), http.StatusBadRequest)
+		return req, ciMetadata{}, false
+	}
+	ci, err := req.CI.checked()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return req, ci, false
+	}
+	return req, ci, true
+}
+
+// handleRunWorkflowAPI starts a run with the config variables, tags, target
+// and execution name in the JSON body, going through the same checks and
+// run queue as the Run button. It responds 201 with the execution, or 202
+// with the queue entry when the run has to wait.
+func (s *Server) handleRunWorkflowAPI(w http.ResponseWriter, r *http.Request) {
+	name := chi.URLParam(r, "name")
+
+	req, ci, ok := decodeRunRequest(w, r)
+	if !ok {
+		return
+	}
+	opts, _, ok := s.prepareRun(w, r, name, testkube.TriggerCI, ci)
+	if !ok {
+		return
+	}
+	req.apply(&opts)
+	exec, queued, ok := s.submitRun(w, r, name, opts)
+	if !ok {
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if queued != nil {
+		w.WriteHeader(http.StatusAccepted)
+		json.NewEncoder(w).Encode(queued)
+		return
+	}
+	log.Printf("Started execution %s for workflow %s", exec.ID, name)
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(exec)
+}
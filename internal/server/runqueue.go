@@ -0,0 +1,173 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/testkube/dashboard/internal/runqueue"
+	"github.com/testkube/dashboard/internal/runwindows"
+	"github.com/testkube/dashboard/internal/testkube"
+)
+
+// submitRun starts a workflow run through the run window policy and the
+// concurrency queue, at the priority class in the request's "priority"
+// value. It returns the execution when the run started, or the
+// queue entry when it has to wait for its window or a slot. On failure, or
+// when the window rejects the run, it writes an error response and returns
+// false.
+func (s *Server) submitRun(w http.ResponseWriter, r *http.Request, workflow string, opts testkube.RunOptions) (*testkube.Execution, *runqueue.Entry, bool) {
+	priority, err := runqueue.ParsePriority(r.FormValue("priority"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return nil, nil, false
+	}
+
+	var exec *testkube.Execution
+	var entry *runqueue.Entry
+
+	// The run queue starts runs on the default cluster, so runs on other
+	// clusters start straight away or not at all
+	onDefault := s.onDefaultCluster(r)
+	decision := s.runWindows.Check(workflow, time.Now())
+	switch {
+	case decision.Allowed && !onDefault:
+		exec, err = s.apiFor(r).RunWorkflow(workflow, opts)
+	case decision.Allowed:
+		exec, entry, err = s.runs.Submit(workflow, priority, opts)
+	case decision.Mode == runwindows.ModeReject || decision.Next.IsZero() || !onDefault:
+		message := fmt.Sprintf("%s may not run now (%s)", workflow, decision.Reason)
+		if !decision.Next.IsZero() {
+			message += "; next allowed at " + decision.Next.Format(time.RFC3339)
+			w.Header().Set("Retry-After", strconv.Itoa(int(time.Until(decision.Next).Seconds())+1))
+		}
+		log.Printf("Rejected run of %s: %s", workflow, message)
+		http.Error(w, message, http.StatusConflict)
+		return nil, nil, false
+	default:
+		entry, err = s.runs.SubmitAfter(workflow, priority, opts, decision.Next)
+	}
+
+	if errors.Is(err, runqueue.ErrQueueFull) {
+		log.Printf("Rejected run of %s: %v", workflow, err)
+		http.Error(w, err.Error(), http.StatusTooManyRequests)
+		return nil, nil, false
+	}
+	if err != nil {
+		log.Printf("Error running workflow %s: %v", workflow, err)
+		http.Error(w, "Failed to run workflow", http.StatusInternalServerError)
+		return nil, nil, false
+	}
+	s.recordRun(workflow, opts, exec)
+	return exec, entry, true
+}
+
+// queuedRuns returns the runs of a workflow waiting for a slot
+func (s *Server) queuedRuns(workflow string) []runqueue.Entry {
+	var queued []runqueue.Entry
+	for _, entry := range s.runs.Entries() {
+		if entry.Workflow == workflow {
+			queued = append(queued, entry)
+		}
+	}
+	return queued
+}
+
+func (s *Server) runQueueData(r *http.Request) map[string]interface{} {
+	return map[string]interface{}{
+		"Entries":    s.runs.Entries(),
+		"Priorities": runqueue.Priorities,
+		"IsAdmin":    s.isAdmin(r),
+	}
+}
+
+func (s *Server) handleRunQueue(w http.ResponseWriter, r *http.Request) {
+	data := s.runQueueData(r)
+	data["Refresh"] = s.refreshFor(r, "queue")
+	s.renderPage(w, r, "queue.html", data)
+}
+
+// handleSetQueuedPriority changes a pending run's priority from the queue
+// page and returns the refreshed queue
+func (s *Server) handleSetQueuedPriority(w http.ResponseWriter, r *http.Request) {
+	if !s.requireAdmin(w, r) {
+		return
+	}
+	if !s.setQueuedPriority(w, chi.URLParam(r, "id"), r.FormValue("priority")) {
+		return
+	}
+	s.executeTemplate(w, "queue.html", "run-queue", s.runQueueData(r))
+}
+
+func (s *Server) handleCancelQueuedRun(w http.ResponseWriter, r *http.Request) {
+	if !s.requireAdmin(w, r) {
+		return
+	}
+	if err := s.runs.Cancel(chi.URLParam(r, "id")); err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	s.executeTemplate(w, "queue.html", "run-queue", s.runQueueData(r))
+}
+
+func (s *Server) setQueuedPriority(w http.ResponseWriter, id, value string) bool {
+	priority, err := runqueue.ParsePriority(value)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return false
+	}
+	if _, err := s.runs.SetPriority(id, priority); err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return false
+	}
+	log.Printf("Changed priority of queued run %s to %s", id, priority)
+	return true
+}
+
+func (s *Server) handleRunQueueAPI(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(s.runs.Entries())
+}
+
+func (s *Server) handleSetQueuedPriorityAPI(w http.ResponseWriter, r *http.Request) {
+	if !s.requireAdmin(w, r) {
+		return
+	}
+
+	var req struct {
+		Priority string `json:"priority"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Priority == "" {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	if !s.setQueuedPriority(w, chi.URLParam(r, "id"), req.Priority) {
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(s.runs.Entries())
+}
+
+func (s *Server) handleCancelQueuedRunAPI(w http.ResponseWriter, r *http.Request) {
+	if !s.requireAdmin(w, r) {
+		return
+	}
+	if err := s.runs.Cancel(chi.URLParam(r, "id")); err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// handleRunWindowAPI reports whether a workflow may run now and, if not,
+// when it next may
+func (s *Server) handleRunWindowAPI(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(s.runWindows.Check(chi.URLParam(r, "name"), time.Now()))
+}
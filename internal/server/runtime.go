@@ -0,0 +1,114 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+)
+
+// runtimeSettings are operator toggles that can be changed from the admin
+// panel without a redeploy
+type runtimeSettings struct {
+	// ReadOnly rejects changes from everything but the admin pages
+	ReadOnly bool `json:"readOnly"`
+	// DevMode re-reads templates from disk on every request
+	DevMode bool `json:"devMode"`
+}
+
+// runtimeConfig holds the runtime settings. They start from READ_ONLY and
+// DEV_MODE, and changes are saved to RUNTIME_SETTINGS_FILE when it is set.
+type runtimeConfig struct {
+	settings runtimeSettings
+	file     string
+	mu       sync.RWMutex
+}
+
+func newRuntimeConfig() (*runtimeConfig, error) {
+	c := &runtimeConfig{
+		settings: runtimeSettings{
+			ReadOnly: os.Getenv("READ_ONLY") == "true",
+			DevMode:  os.Getenv("DEV_MODE") == "true",
+		},
+		file: os.Getenv("RUNTIME_SETTINGS_FILE"),
+	}
+	if c.file == "" {
+		return c, nil
+	}
+
+	data, err := os.ReadFile(c.file)
+	if os.IsNotExist(err) {
+		return c, nil
+	}
+	if err != nil {
+		return c, fmt.Errorf("failed to read runtime settings file: %w", err)
+	}
+	if err := json.Unmarshal(data, &c.settings); err != nil {
+		return c, fmt.Errorf("failed to parse runtime settings: %w", err)
+	}
+	return c, nil
+}
+
+// Settings returns the current settings
+func (c *runtimeConfig) Settings() runtimeSettings {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.settings
+}
+
+// Persisted reports whether changes survive a restart
+func (c *runtimeConfig) Persisted() bool {
+	return c.file != ""
+}
+
+// Update replaces the settings and saves them
+func (c *runtimeConfig) Update(settings runtimeSettings) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.settings = settings
+	if c.file == "" {
+		return nil
+	}
+
+	data, err := json.MarshalIndent(settings, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode runtime settings: %w", err)
+	}
+	if err := os.WriteFile(c.file, data, 0644); err != nil {
+		return fmt.Errorf("failed to save runtime settings: %w", err)
+	}
+	return nil
+}
+
+// Export returns the settings as JSON, for configuration sync
+func (c *runtimeConfig) Export() (json.RawMessage, error) {
+	return json.Marshal(c.Settings())
+}
+
+// Import replaces the settings
+func (c *runtimeConfig) Import(data json.RawMessage) error {
+	var settings runtimeSettings
+	if err := json.Unmarshal(data, &settings); err != nil {
+		return fmt.Errorf("failed to parse runtime settings: %w", err)
+	}
+	return c.Update(settings)
+}
+
+// readOnlyGuard rejects changes while the dashboard is read-only. Admin
+// routes stay writable so the mode can be switched off again.
+func (s *Server) readOnlyGuard(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet, http.MethodHead, http.MethodOptions:
+		default:
+			admin := strings.HasPrefix(r.URL.Path, "/admin/") || strings.HasPrefix(r.URL.Path, "/api/v1/admin/")
+			if !admin && s.runtime.Settings().ReadOnly {
+				http.Error(w, "The dashboard is in read-only mode", http.StatusServiceUnavailable)
+				return
+			}
+		}
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,245 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"maps"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/testkube/dashboard/internal/testkube"
+)
+
+const (
+	// defaultRunWaitTimeout is how long run-and-wait blocks without ?timeout=
+	defaultRunWaitTimeout = 30 * time.Minute
+	// maxRunWaitTimeout caps ?timeout=, so forgotten pipelines release
+	// their connections
+	maxRunWaitTimeout = 2 * time.Hour
+)
+
+// runWaitPollInterval is how often run-and-wait checks the execution
+var runWaitPollInterval = 5 * time.Second
+
+// Run-and-wait verdicts
+const (
+	verdictPassed    = "passed"
+	verdictFailed    = "failed"
+	verdictTimeout   = "timeout"
+	verdictCancelled = "cancelled"
+)
+
+// prepareRun checks resource quota and dependencies before a run of
+// workflow, and returns its options: trigger, dependency and CI tags, and
+// the preset and run form's variables. It writes an error response and
+// returns false when the run must not start.
+func (s *Server) prepareRun(w http.ResponseWriter, r *http.Request, name, trigger string, ci ciMetadata) (testkube.RunOptions, string, bool) {
+	namespace := os.Getenv("TESTKUBE_NAMESPACE")
+	if workflow, err := s.apiFor(r).GetWorkflow(name); err == nil && workflow.Namespace != "" {
+		namespace = workflow.Namespace
+	}
+	quotaWarning, ok := s.checkQuota(r.Context(), w, namespace)
+	if !ok {
+		return testkube.RunOptions{}, "", false
+	}
+
+	depTags, ok := s.checkDependencies(r.Context(), w, name)
+	if !ok {
+		return testkube.RunOptions{}, "", false
+	}
+
+	opts := testkube.RunOptions{Tags: triggerTags(r, trigger)}
+	maps.Copy(opts.Tags, depTags)
+	maps.Copy(opts.Tags, ci.tags())
+	if preset := r.FormValue("preset"); preset != "" {
+		vars, err := s.presetVariables(name, preset)
+		if err != nil {
+			presetError(w, err)
+			return testkube.RunOptions{}, "", false
+		}
+		opts.Config = vars
+		opts.Tags[PresetTag] = preset
+	}
+	formRunRequest(r).apply(&opts)
+	return opts, quotaWarning, true
+}
+
+// runVerdict is the machine-readable outcome of a run-and-wait request
+type runVerdict struct {
+	Workflow    string `json:"workflow"`
+	ExecutionID string `json:"executionId,omitempty"`
+	// QueueID identifies the run while it waits in the run queue
+	QueueID    string        `json:"queueId,omitempty"`
+	Status     string        `json:"status,omitempty"` // the execution's last status
+	Verdict    string        `json:"verdict"`          // passed, failed, timeout or cancelled
+	Passed     bool          `json:"passed"`
+	DurationMs int64         `json:"durationMs,omitempty"`
+	URL        string        `json:"url,omitempty"`
+	Tests      *verdictTests `json:"tests,omitempty"`
+	Message    string        `json:"message,omitempty"`
+}
+
+type verdictTests struct {
+	Total  int `json:"total"`
+	Passed int `json:"passed"`
+	Failed int `json:"failed"`
+}
+
+// statusCode maps a verdict to the response status, so `curl --fail`
+// fails a pipeline step unless the run passed
+func (v runVerdict) statusCode() int {
+	switch v.Verdict {
+	case verdictPassed:
+		return http.StatusOK
+	case verdictFailed:
+		return http.StatusUnprocessableEntity
+	case verdictTimeout:
+		return http.StatusGatewayTimeout
+	default:
+		return http.StatusConflict
+	}
+}
+
+// executionVerdict is the verdict for a finished execution status, or "" for
+// one still in progress
+func executionVerdict(status string) string {
+	switch status {
+	case "passed":
+		return verdictPassed
+	case "failed", "timeout", "error":
+		return verdictFailed
+	case "aborted", "canceled", "cancelled":
+		return verdictCancelled
+	}
+	return ""
+}
+
+// handleRunAndWaitAPI starts a workflow and blocks until the run finishes or
+// ?timeout= (default 30m) passes, so pipelines can gate deploys with a
+// single request. Runs held in the run queue are waited for too. The status
+// code reflects the verdict: 200 passed, 422 failed, 504 timed out and 409
+// cancelled.
+func (s *Server) handleRunAndWaitAPI(w http.ResponseWriter, r *http.Request) {
+	name := chi.URLParam(r, "name")
+
+	timeout := defaultRunWaitTimeout
+	if val := r.URL.Query().Get("timeout"); val != "" {
+		d, err := time.ParseDuration(val)
+		if err != nil || d <= 0 || d > maxRunWaitTimeout {
+			http.Error(w, fmt.Sprintf("Invalid timeout %q: use a duration up to %s", val, maxRunWaitTimeout), http.StatusBadRequest)
+			return
+		}
+		timeout = d
+	}
+	deadline := time.Now().Add(timeout)
+
+	// Only the CI metadata of the request applies to run-and-wait
+	_, ci, ok := decodeRunRequest(w, r)
+	if !ok {
+		return
+	}
+	opts, _, ok := s.prepareRun(w, r, name, testkube.TriggerCI, ci)
+	if !ok {
+		return
+	}
+	exec, queued, ok := s.submitRun(w, r, name, opts)
+	if !ok {
+		return
+	}
+
+	verdict := runVerdict{Workflow: name}
+	if queued != nil {
+		verdict.QueueID = queued.ID
+		log.Printf("Waiting for queued run %s of %s", queued.ID, name)
+	} else {
+		verdict.ExecutionID = exec.ID
+		log.Printf("Started execution %s for workflow %s, waiting for it to finish", exec.ID, name)
+	}
+
+	ticker := time.NewTicker(runWaitPollInterval)
+	defer ticker.Stop()
+	for {
+		if s.pollRun(s.apiFor(r), &verdict) {
+			break
+		}
+		if !time.Now().Before(deadline) {
+			verdict.Verdict = verdictTimeout
+			verdict.Message = fmt.Sprintf("run did not finish within %s", timeout)
+			break
+		}
+		select {
+		case <-r.Context().Done():
+			// The caller gave up; the run carries on in Testkube
+			return
+		case <-ticker.C:
+		}
+	}
+
+	if verdict.ExecutionID != "" {
+		if base := strings.TrimSuffix(os.Getenv("DASHBOARD_URL"), "/"); base != "" {
+			verdict.URL = base + "/executions/" + verdict.ExecutionID
+			if !s.onDefaultCluster(r) {
+				verdict.URL += "?" + clusterParam + "=" + url.QueryEscape(s.clusterName(r))
+			}
+		}
+		if verdict.Verdict != verdictTimeout {
+			verdict.Tests = s.verdictTests(verdict.ExecutionID)
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(verdict.statusCode())
+	json.NewEncoder(w).Encode(verdict)
+}
+
+// pollRun updates the verdict with the run's progress on the cluster api
+// belongs to and reports whether the run is over
+func (s *Server) pollRun(api testkube.Client, v *runVerdict) bool {
+	if v.ExecutionID == "" {
+		if id, ok := s.runs.Started(v.QueueID); ok {
+			v.ExecutionID = id
+		} else if s.runs.Waiting(v.QueueID) {
+			v.Status = "waiting"
+			return false
+		} else {
+			v.Verdict = verdictCancelled
+			v.Message = "the queued run was cancelled or failed to start"
+			return true
+		}
+	}
+
+	exec, err := api.GetExecution(v.ExecutionID)
+	if err != nil {
+		// Newly started executions can take a moment to appear
+		log.Printf("Error getting execution %s: %v", v.ExecutionID, err)
+		return false
+	}
+	v.Status = exec.Status
+	v.DurationMs = exec.Duration.Milliseconds()
+	v.Verdict = executionVerdict(exec.Status)
+	v.Passed = v.Verdict == verdictPassed
+	return v.Verdict != ""
+}
+
+// verdictTests counts the execution's test results, when ingested
+func (s *Server) verdictTests(executionID string) *verdictTests {
+	cases, err := s.db.GetExecutionMetrics(executionID)
+	if err != nil || len(cases) == 0 {
+		return nil
+	}
+	tests := &verdictTests{Total: len(cases)}
+	for _, tc := range cases {
+		switch tc.Status {
+		case "passed":
+			tests.Passed++
+		case "failed":
+			tests.Failed++
+		}
+	}
+	return tests
+}
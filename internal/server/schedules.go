@@ -0,0 +1,150 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/testkube/dashboard/internal/testkube"
+)
+
+// scheduleRow is a workflow's cron trigger as the workflow page shows it
+type scheduleRow struct {
+	testkube.WorkflowSchedule
+	Index   int
+	NextRun time.Time
+}
+
+// scheduleResponse is a cron trigger in the API, with when it next fires
+type scheduleResponse struct {
+	testkube.WorkflowSchedule
+	NextRun *time.Time `json:"nextRun,omitempty"`
+}
+
+// schedulesData lists a workflow's cron triggers with their next runs
+func (s *Server) schedulesData(api testkube.Client, workflow string) map[string]interface{} {
+	list, err := api.GetWorkflowSchedules(workflow)
+	if err != nil {
+		log.Printf("Error getting schedules of %s: %v", workflow, err)
+	}
+	now := time.Now()
+	rows := make([]scheduleRow, len(list))
+	for i, sched := range list {
+		rows[i] = scheduleRow{WorkflowSchedule: sched, Index: i}
+		rows[i].NextRun, _ = sched.NextRun(now)
+	}
+	return map[string]interface{}{"Name": workflow, "Schedules": rows}
+}
+
+// changeSchedule applies a schedule form's action to a workflow's cron
+// triggers: pause, resume or delete the one at index, or save it with a
+// new cron and time zone (adding it when index is -1). It returns what the
+// change did, for the activity feed.
+func changeSchedule(list []testkube.WorkflowSchedule, action string, index int, cron, timezone string) ([]testkube.WorkflowSchedule, string, error) {
+	if action == "save" && index == -1 {
+		list = append(list, testkube.WorkflowSchedule{Cron: cron, Timezone: timezone})
+		return list, fmt.Sprintf("Added schedule %q", cron), nil
+	}
+	if index < 0 || index >= len(list) {
+		return nil, "", fmt.Errorf("%w: no schedule %d", testkube.ErrInvalidWorkflowSpec, index)
+	}
+	sched := &list[index]
+	switch action {
+	case "pause":
+		sched.Paused = true
+		return list, fmt.Sprintf("Paused schedule %q", sched.Cron), nil
+	case "resume":
+		sched.Paused = false
+		return list, fmt.Sprintf("Resumed schedule %q", sched.Cron), nil
+	case "delete":
+		message := fmt.Sprintf("Deleted schedule %q", sched.Cron)
+		return append(list[:index], list[index+1:]...), message, nil
+	case "save":
+		message := fmt.Sprintf("Changed schedule %q to %q", sched.Cron, cron)
+		sched.Cron, sched.Timezone = cron, timezone
+		return list, message, nil
+	}
+	return nil, "", fmt.Errorf("%w: unknown schedule action %q", testkube.ErrInvalidWorkflowSpec, action)
+}
+
+// handleChangeSchedule pauses, resumes, deletes, edits or adds a cron
+// trigger from the workflow page
+func (s *Server) handleChangeSchedule(w http.ResponseWriter, r *http.Request) {
+	name := chi.URLParam(r, "name")
+	api := s.apiFor(r)
+	index := -1
+	if v := r.FormValue("index"); v != "" {
+		var err error
+		if index, err = strconv.Atoi(v); err != nil {
+			http.Error(w, "Invalid schedule index", http.StatusBadRequest)
+			return
+		}
+	}
+
+	list, err := api.GetWorkflowSchedules(name)
+	if err != nil {
+		workflowError(w, err)
+		return
+	}
+	cron := strings.Join(strings.Fields(r.FormValue("cron")), " ")
+	list, message, err := changeSchedule(list, r.FormValue("action"), index, cron, strings.TrimSpace(r.FormValue("timezone")))
+	if err != nil {
+		workflowError(w, err)
+		return
+	}
+	if err := api.UpdateWorkflowSchedules(name, list); err != nil {
+		workflowError(w, err)
+		return
+	}
+	s.recordWorkflowChange(r, activityWorkflowUpdated, name, message)
+
+	trigger, _ := json.Marshal(map[string]string{"showMessage": message})
+	w.Header().Set("HX-Trigger", string(trigger))
+	s.executeTemplate(w, "workflow_detail.html", "workflow-schedules", s.schedulesData(api, name))
+}
+
+func schedulesResponse(list []testkube.WorkflowSchedule) []scheduleResponse {
+	now := time.Now()
+	resp := make([]scheduleResponse, len(list))
+	for i, sched := range list {
+		resp[i] = scheduleResponse{WorkflowSchedule: sched}
+		if next, ok := sched.NextRun(now); ok {
+			resp[i].NextRun = &next
+		}
+	}
+	return resp
+}
+
+func (s *Server) handleSchedulesAPI(w http.ResponseWriter, r *http.Request) {
+	list, err := s.apiFor(r).GetWorkflowSchedules(chi.URLParam(r, "name"))
+	if err != nil {
+		workflowError(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(schedulesResponse(list))
+}
+
+// handleUpdateSchedulesAPI replaces a workflow's cron triggers with
+// [{"cron": "0 2 * * *", "timezone": "Europe/London", "paused": false}]
+func (s *Server) handleUpdateSchedulesAPI(w http.ResponseWriter, r *http.Request) {
+	name := chi.URLParam(r, "name")
+	var list []testkube.WorkflowSchedule
+	if err := json.NewDecoder(r.Body).Decode(&list); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	api := s.apiFor(r)
+	if err := api.UpdateWorkflowSchedules(name, list); err != nil {
+		workflowError(w, err)
+		return
+	}
+	s.recordWorkflowChange(r, activityWorkflowUpdated, name, "Updated schedules")
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(schedulesResponse(list))
+}
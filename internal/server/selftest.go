@@ -0,0 +1,279 @@
+package server
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/testkube/dashboard/internal/evidence"
+	"github.com/testkube/dashboard/internal/notify"
+	"github.com/testkube/dashboard/internal/testkube"
+)
+
+// selfTestTimeout bounds each subsystem check
+const selfTestTimeout = 15 * time.Second
+
+// Self-test check outcomes
+const (
+	selfTestPass = "pass"
+	selfTestFail = "fail"
+	selfTestSkip = "skip"
+)
+
+// errNotConfigured marks a subsystem that isn't set up, which skips its check
+var errNotConfigured = errors.New("not configured")
+
+// selfTestCheck is the outcome of exercising one subsystem
+type selfTestCheck struct {
+	Name       string `json:"name"`
+	Status     string `json:"status"` // pass, fail or skip
+	Detail     string `json:"detail,omitempty"`
+	DurationMs int64  `json:"durationMs"`
+}
+
+// selfTestReport is the outcome of a full self-test
+type selfTestReport struct {
+	Passed  bool            `json:"passed"`
+	RanAt   time.Time       `json:"ranAt"`
+	Checks  []selfTestCheck `json:"checks"`
+	Skipped int             `json:"skipped"`
+	Failed  int             `json:"failed"`
+}
+
+// selfTestStep exercises a subsystem, returning a short description of what
+// it found
+type selfTestStep struct {
+	name string
+	run  func(ctx context.Context) (string, error)
+}
+
+// selfTestSteps lists the subsystem checks. With sendNotification set a
+// test message is sent through the notification webhook.
+func (s *Server) selfTestSteps(sendNotification bool) []selfTestStep {
+	return []selfTestStep{
+		{"testkube-api", s.selfTestAPI},
+		{"database", s.selfTestDatabase},
+		{"artifacts", s.selfTestArtifacts},
+		{"kubernetes", s.selfTestKubernetes},
+		{"notifications", func(ctx context.Context) (string, error) { return s.selfTestNotifications(ctx, sendNotification) }},
+		{"config-sync", s.selfTestConfigSync},
+		{"user-generator", s.selfTestUserGenerator},
+		{"evidence-signing", s.selfTestEvidence},
+	}
+}
+
+// runSelfTest runs every check concurrently
+func (s *Server) runSelfTest(ctx context.Context, sendNotification bool) selfTestReport {
+	steps := s.selfTestSteps(sendNotification)
+	checks := make([]selfTestCheck, len(steps))
+
+	var wg sync.WaitGroup
+	for i, step := range steps {
+		wg.Add(1)
+		go func(i int, step selfTestStep) {
+			defer wg.Done()
+			checks[i] = runSelfTestStep(ctx, step)
+		}(i, step)
+	}
+	wg.Wait()
+
+	report := selfTestReport{Passed: true, RanAt: time.Now(), Checks: checks}
+	for _, c := range checks {
+		switch c.Status {
+		case selfTestFail:
+			report.Failed++
+			report.Passed = false
+		case selfTestSkip:
+			report.Skipped++
+		}
+	}
+	return report
+}
+
+func runSelfTestStep(ctx context.Context, step selfTestStep) selfTestCheck {
+	ctx, cancel := context.WithTimeout(ctx, selfTestTimeout)
+	defer cancel()
+
+	type outcome struct {
+		detail string
+		err    error
+	}
+	started := time.Now()
+	done := make(chan outcome, 1)
+	go func() {
+		detail, err := step.run(ctx)
+		done <- outcome{detail, err}
+	}()
+
+	var result outcome
+	select {
+	case result = <-done:
+	case <-ctx.Done():
+		result.err = fmt.Errorf("timed out after %s", selfTestTimeout)
+	}
+
+	check := selfTestCheck{Name: step.name, Status: selfTestPass, Detail: result.detail, DurationMs: time.Since(started).Milliseconds()}
+	switch {
+	case errors.Is(result.err, errNotConfigured):
+		check.Status = selfTestSkip
+		check.Detail = result.err.Error()
+	case result.err != nil:
+		check.Status = selfTestFail
+		check.Detail = result.err.Error()
+	}
+	return check
+}
+
+func (s *Server) selfTestAPI(ctx context.Context) (string, error) {
+	workflows, err := s.api.GetWorkflows(testkube.ListOptions{})
+	if err != nil {
+		return "", fmt.Errorf("failed to list workflows: %w", err)
+	}
+	if _, err := s.api.GetExecutions(testkube.ListOptions{PageSize: 1}); err != nil {
+		return "", fmt.Errorf("failed to list executions: %w", err)
+	}
+	return fmt.Sprintf("%d workflows", len(workflows)), nil
+}
+
+func (s *Server) selfTestDatabase(ctx context.Context) (string, error) {
+	trends, err := s.db.GetTrends(7)
+	if err != nil {
+		return "", fmt.Errorf("failed to query trends: %w", err)
+	}
+	if _, err := s.db.GetTestLocations(7); err != nil {
+		return "", fmt.Errorf("failed to query test results: %w", err)
+	}
+	return fmt.Sprintf("%.1f%% pass rate over 7 days", trends.CurrentPassRate), nil
+}
+
+// selfTestArtifacts downloads the smallest artifact of the latest finished
+// execution and checks it against the manifest recorded at ingestion
+func (s *Server) selfTestArtifacts(ctx context.Context) (string, error) {
+	executions, err := s.api.GetExecutions(testkube.ListOptions{PageSize: 20})
+	if err != nil {
+		return "", fmt.Errorf("failed to list executions: %w", err)
+	}
+
+	for _, exec := range executions {
+		if exec.Status != "passed" && exec.Status != "failed" {
+			continue
+		}
+		list, err := s.api.GetArtifacts(exec.ID)
+		if err != nil {
+			return "", fmt.Errorf("failed to list artifacts of %s: %w", exec.ID, err)
+		}
+		if len(list) == 0 {
+			continue
+		}
+
+		sort.Slice(list, func(i, j int) bool { return list[i].Size < list[j].Size })
+		data, integrity, err := s.downloadVerifiedArtifact(s.api, exec.ID, list[0].Path)
+		if err != nil {
+			return "", fmt.Errorf("failed to download %s of %s: %w", list[0].Path, exec.ID, err)
+		}
+		if integrity.Warning() {
+			return "", fmt.Errorf("%s of %s does not match its manifest checksum", list[0].Path, exec.ID)
+		}
+		return fmt.Sprintf("downloaded %s of %s (%d bytes, %s)", list[0].Path, exec.ID, len(data), integrity), nil
+	}
+	return "no finished executions with artifacts to download", nil
+}
+
+func (s *Server) selfTestKubernetes(ctx context.Context) (string, error) {
+	if s.clusterEvents == nil {
+		return "", fmt.Errorf("Kubernetes API %w", errNotConfigured)
+	}
+	if err := s.clusterEvents.Poll(ctx); err != nil {
+		return "", err
+	}
+	return "listed cluster events", nil
+}
+
+func (s *Server) selfTestNotifications(ctx context.Context, send bool) (string, error) {
+	if s.notifier == nil {
+		return "", fmt.Errorf("notification webhook %w", errNotConfigured)
+	}
+	if !send {
+		return "webhook configured; no test message sent", nil
+	}
+	err := s.notifier.Send(ctx, notify.Message{
+		Title: "Dashboard self-test",
+		Text:  "This is a test notification from the dashboard self-test.",
+	})
+	if err != nil {
+		return "", err
+	}
+	return "test message sent", nil
+}
+
+func (s *Server) selfTestConfigSync(ctx context.Context) (string, error) {
+	if s.configSync == nil {
+		return "", fmt.Errorf("config sync %w", errNotConfigured)
+	}
+	status := s.configSync.Status()
+	if status.Error != "" {
+		return "", fmt.Errorf("last sync failed: %s", status.Error)
+	}
+	if status.LastSuccess.IsZero() {
+		return "no sync has completed yet", nil
+	}
+	return fmt.Sprintf("synced %s at commit %s", status.Repo, status.Commit), nil
+}
+
+func (s *Server) selfTestUserGenerator(ctx context.Context) (string, error) {
+	if s.userGen == nil {
+		return "", fmt.Errorf("user generator database %w", errNotConfigured)
+	}
+	envs, err := s.userGen.ListEnvironments()
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%d environments", len(envs)), nil
+}
+
+// selfTestEvidence signs and verifies a throwaway digest without recording it
+func (s *Server) selfTestEvidence(ctx context.Context) (string, error) {
+	if s.evidence == nil {
+		return "", errors.New("no signing key could be loaded")
+	}
+	signer := s.evidence.Signer()
+	digest, _ := hex.DecodeString(evidence.Digest([]byte("self-test")))
+	if !signer.Verify(digest, signer.Sign(digest)) {
+		return "", errors.New("signature did not verify")
+	}
+	return fmt.Sprintf("%s key %s", signer.Algorithm(), signer.KeyID()), nil
+}
+
+func (s *Server) handleSelfTestPage(w http.ResponseWriter, r *http.Request) {
+	if !s.requireAdmin(w, r) {
+		return
+	}
+	s.renderPage(w, r, "self_test.html", map[string]interface{}{
+		"Notifications": s.notifier != nil,
+		"Page":          "admin",
+	})
+}
+
+// handleRunSelfTest runs the self-test and renders the results
+func (s *Server) handleRunSelfTest(w http.ResponseWriter, r *http.Request) {
+	if !s.requireAdmin(w, r) {
+		return
+	}
+	report := s.runSelfTest(r.Context(), r.FormValue("notify") == "true")
+	s.executeTemplate(w, "self_test.html", "self-test-results", map[string]interface{}{"Report": report})
+}
+
+func (s *Server) handleSelfTestAPI(w http.ResponseWriter, r *http.Request) {
+	if !s.requireAdmin(w, r) {
+		return
+	}
+	report := s.runSelfTest(r.Context(), r.URL.Query().Get("notify") == "true")
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(report)
+}
@@ -1,103 +1,568 @@
 package server
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"html/template"
 	"log"
 	"net/http"
+	"net/url"
 	"os"
 	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/go-chi/chi/v5"
+	"github.com/testkube/dashboard/internal/baselines"
+	"github.com/testkube/dashboard/internal/configsync"
 	"github.com/testkube/dashboard/internal/database"
+	"github.com/testkube/dashboard/internal/dependencies"
 	"github.com/testkube/dashboard/internal/environments"
+	"github.com/testkube/dashboard/internal/evidence"
+	"github.com/testkube/dashboard/internal/features"
+	"github.com/testkube/dashboard/internal/humanize"
+	"github.com/testkube/dashboard/internal/impact"
+	"github.com/testkube/dashboard/internal/kube"
+	"github.com/testkube/dashboard/internal/notify"
+	"github.com/testkube/dashboard/internal/pagecache"
+	"github.com/testkube/dashboard/internal/previews"
+	"github.com/testkube/dashboard/internal/redact"
+	"github.com/testkube/dashboard/internal/runqueue"
+	"github.com/testkube/dashboard/internal/runwindows"
+	"github.com/testkube/dashboard/internal/share"
+	"github.com/testkube/dashboard/internal/suites"
+	"github.com/testkube/dashboard/internal/synthetics"
+	"github.com/testkube/dashboard/internal/tables"
 	"github.com/testkube/dashboard/internal/testkube"
+	"github.com/testkube/dashboard/internal/triage"
 	"github.com/testkube/dashboard/internal/users"
+	"github.com/testkube/dashboard/internal/visual"
+	"github.com/testkube/dashboard/internal/worker"
 )
 
+// recentFailuresWindow is how far back the dashboard lists failures
+const recentFailuresWindow = 24 * time.Hour
+
+// executionPageSize is the number of executions per page in history lists
+const executionPageSize = 20
+
 type Server struct {
 	api       testkube.Client
+	// Clients of every Testkube installation; requests choose one with
+	// ?cluster=, and api is the default
+	clusters  *testkube.ClientRegistry
 	db        database.Database
+	// Whether the database is reachable, and the last-known analytics to
+	// show while it isn't
+	dbHealth  *dbHealth
 	envMgr    *environments.Manager
 	userGen   *users.UserGenerator
+	quota     *kube.QuotaChecker
+	dependencies *dependencies.Checker
+	// Recent cluster infrastructure events for failure diagnostics
+	clusterEvents *kube.EventHistory
+	ownership *triage.Ownership
+	triage    *triage.Queue
+	alerts    *notify.Digest
+	// Per-workflow pass rate alert thresholds
+	passRates *baselines.Policy
+	// Failure notification channel, nil when not configured
+	notifier notify.Notifier
+	// Where weekly quality reports are pushed
+	weeklyChannels []notify.Notifier
+	synthetics *synthetics.Monitor
+	// Per-user sort, filter and column choices for server-side tables
+	tableStates *tables.Store
+	// Screenshot baselines and visual regression reviews
+	visual *visual.Store
+	impact *impact.Analyzer
+	// Runs waiting for a per-workflow concurrency slot
+	runs *runqueue.Queue
+	// When each workflow may be triggered
+	runWindows *runwindows.Policy
+	// Signatures issued for execution evidence bundles
+	evidence *evidence.Store
+	// Feature flags guarding optional subsystems
+	features *features.Store
+	// Public links to executions, and the rules scrubbing what they show
+	shares   *share.Store
+	redactor *redact.Redactor
+	// Workflow groups that gate deployments
+	suites *suites.Registry
+	// Read-only and dev mode toggles from the admin panel
+	runtime *runtimeConfig
+	// Ingestion worker, when running, for the admin panel
+	worker *worker.Worker
+	// Execution notifications for the dashboard's live activity panel
+	live *liveFeeds
+	// How often pages poll, from REFRESH_INTERVALS
+	refresh refreshPolicy
+	// Workflow types detected from runner images, with their presentation
+	types *testkube.TypeRegistry
+	// Preview environments for pull requests, and the commenter announcing
+	// them, nil without a GitHub or GitLab token
+	previews  previews.Config
+	commenter previews.Commenter
+	// Users allowed admin actions; empty allows everyone
+	admins map[string]bool
 	templates map[string]*template.Template
 	rootDir   string
+
+	// Rendered dashboard, workflow list and report pages, dropped when
+	// executions are ingested
+	pages *pagecache.Cache
+
+	// Configuration sections and optional GitOps sync of them
+	config     *configsync.Registry
+	configSync *configsync.Syncer
 }
 
 func NewServer(api testkube.Client, db database.Database, userGen *users.UserGenerator, rootDir string) *Server {
-	// Load templates - each page needs its own template that includes layout
-	templatesDir := filepath.Join(rootDir, "web/templates")
-	templates := make(map[string]*template.Template)
-
 	// List of page templates (each defines "content")
 	pages := []string{
 		"dashboard.html",
 		"workflow_list.html",
 		"workflow_detail.html",
 		"execution_detail.html",
+		"execution_results.html",
+		"clusters.html",
 		"environments.html",
 		"user_generator.html",
 		"k6_report.html",
 		"workflow_history.html",
+		"workflow_editor.html",
 		"artifacts.html",
+		"execution_group.html",
+		"triage.html",
+		"flakiness_report.html",
+		"synthetics.html",
+		"artifact_diff.html",
+		"visual.html",
+		"queue.html",
+		"environment_sla_report.html",
+		"comparison_report.html",
+		"environment_values.html",
+		"self_test.html",
+		"admin.html",
+		"share.html",
+		"calendar.html",
+		"activity.html",
+		"workflow_templates.html",
+		"test_links.html",
+		"archived_workflows.html",
+		"archived_workflow.html",
+		"legacy_detail.html",
+		"watchlist.html",
+		"weekly_report.html",
+		"about.html",
+	}
+
+	// Load templates - each page needs its own template that includes layout
+	templates := loadTemplates(filepath.Join(rootDir, "web/templates"), pages)
+
+	// Quota checks and event correlation need the in-cluster Kubernetes
+	// API; skip them elsewhere
+	var quota *kube.QuotaChecker
+	var clusterEvents *kube.EventHistory
+	kubeClient, err := kube.NewInClusterClient()
+	if err != nil {
+		log.Printf("Kubernetes API not available, resource quota checks and cluster event diagnostics disabled: %v", err)
+	} else {
+		quota = kube.NewQuotaChecker(kubeClient)
+		clusterEvents = kube.NewEventHistory(kubeClient)
+	}
+
+	deps, err := dependencies.NewChecker()
+	if err != nil {
+		log.Printf("Warning: failed to load workflow dependencies: %v", err)
+	}
+
+	ownership, err := triage.NewOwnership()
+	if err != nil {
+		log.Printf("Warning: failed to load ownership rules: %v", err)
+	}
+
+	// Failure notifications are only sent when a channel is configured
+	var alerts *notify.Digest
+	notifier := notify.NewNotifierFromEnv()
+	if notifier != nil {
+		notifier = recordingNotifier{Notifier: notifier, db: db}
+		alerts = notify.NewDigest(notifier)
 	}
 
-	layoutPath := filepath.Join(templatesDir, "layout.html")
-	for _, page := range pages {
-		pagePath := filepath.Join(templatesDir, page)
-		// Parse layout first, then the page template
-		t := template.Must(template.ParseFiles(layoutPath, pagePath))
-		templates[page] = t
+	passRates, err := baselines.NewPolicy()
+	if err != nil {
+		log.Printf("Warning: failed to load pass rate alert rules: %v", err)
+	}
+
+	monitor, err := synthetics.NewMonitor(db, notifier)
+	if err != nil {
+		log.Printf("Warning: failed to load synthetic checks: %v", err)
+	}
+
+	testImpact, err := impact.NewAnalyzer(db)
+	if err != nil {
+		log.Printf("Warning: failed to load test impact rules: %v", err)
+	}
+
+	runs, err := runqueue.NewQueue(api)
+	if err != nil {
+		log.Printf("Warning: failed to load run limits: %v", err)
 	}
 
-	return &Server{
-		api:       api,
-		db:        db,
-		envMgr:    environments.NewManager(),
-		userGen:   userGen,
-		templates: templates,
-		rootDir:   rootDir,
+	runWindows, err := runwindows.NewPolicy()
+	if err != nil {
+		log.Printf("Warning: failed to load run windows: %v", err)
+	}
+
+	var evidenceStore *evidence.Store
+	signer, err := evidence.NewSignerFromEnv()
+	if err != nil {
+		log.Printf("Warning: evidence bundles disabled: %v", err)
+	} else {
+		evidenceStore = evidence.NewStore(signer, db)
 	}
+
+	visualStore, err := visual.NewStore(db)
+	if err != nil {
+		log.Printf("Warning: %v", err)
+	}
+
+	flags, err := features.NewStore(db)
+	if err != nil {
+		log.Printf("Warning: failed to load feature flags: %v", err)
+	}
+
+	redactor, err := redact.NewRedactorFromEnv()
+	if err != nil {
+		log.Printf("Warning: failed to load redaction rules: %v", err)
+	}
+
+	suiteRegistry, err := suites.NewRegistry()
+	if err != nil {
+		log.Printf("Warning: failed to load suites: %v", err)
+	}
+
+	workflowTypes, err := testkube.LoadTypes()
+	if err != nil {
+		log.Printf("Warning: failed to load workflow types: %v", err)
+	}
+
+	runtime, err := newRuntimeConfig()
+	if err != nil {
+		log.Printf("Warning: failed to load runtime settings: %v", err)
+	}
+
+	// cert-manager certificate status needs the Kubernetes API too
+	envMgr := environments.NewManager()
+	if kubeClient != nil {
+		envMgr.SetCertificateGetter(kubeClient)
+	}
+
+	// Subsystems register their configuration sections before sync starts
+	config := configsync.NewRegistry()
+	config.Register("ownership", ownership)
+	config.Register("dependencies", deps)
+	config.Register("synthetics", monitor)
+	config.Register("testImpact", testImpact)
+	config.Register("runLimits", runs)
+	config.Register("runWindows", runWindows)
+	config.Register("environmentTemplates", envMgr)
+	config.Register("runtime", runtime)
+	config.Register("features", flags)
+	config.Register("redaction", redactor)
+	config.Register("suites", suiteRegistry)
+	config.Register("passRateAlerts", passRates)
+	config.Register("workflowTypes", workflowTypes)
+
+	s := &Server{
+		api:        api,
+		clusters:   testkube.NewClientRegistry(testkube.DefaultClusterName(), api),
+		db:         db,
+		dbHealth:   newDBHealth(),
+		envMgr:     envMgr,
+		userGen:    userGen,
+		quota:      quota,
+		dependencies: deps,
+		clusterEvents: clusterEvents,
+		ownership:  ownership,
+		triage:     triage.NewQueue(ownership),
+		alerts:     alerts,
+		passRates:  passRates,
+		notifier:   notifier,
+		weeklyChannels: weeklyReportChannels(notifier),
+		synthetics: monitor,
+		tableStates: tables.NewStore(),
+		visual:     visualStore,
+		impact:     testImpact,
+		runs:       runs,
+		runWindows: runWindows,
+		evidence:   evidenceStore,
+		runtime:    runtime,
+		features:   flags,
+		shares:     share.NewStore(),
+		redactor:   redactor,
+		suites:     suiteRegistry,
+		types:      workflowTypes,
+		previews:   previews.ConfigFromEnv(),
+		commenter:  previews.NewCommenterFromEnv(),
+		admins:     parseAdmins(os.Getenv("ADMIN_USERS")),
+		templates:  templates,
+		rootDir:    rootDir,
+		config:     config,
+		configSync: configsync.NewSyncerFromEnv(config),
+		pages:      pagecache.NewCache(),
+		live:       newLiveFeeds(),
+		refresh:    refreshPolicyFromEnv(),
+	}
+	envMgr.OnExpire(s.recordExpiredEnvironment)
+	envMgr.SetSmokeTestRunner(s.runSmokeTest)
+	if os.Getenv("WEEKLY_REPORTS") != "false" {
+		go s.weeklyReportLoop()
+	}
+	return s
 }
 
 func (s *Server) Router() http.Handler {
 	r := chi.NewRouter()
+	r.Use(s.readOnlyGuard)
+	r.Use(s.withCluster)
+	r.Use(rememberAutoRefresh)
 
-	// Health endpoints (no dependencies, always ready)
+	// Health endpoints (always ready; /readyz reports a degraded database)
 	r.Get("/healthz", s.handleHealthz)
 	r.Get("/readyz", s.handleReadyz)
+	r.Get("/metrics", s.handleMetrics)
 
 	// Static files
 	r.Handle("/static/*", http.StripPrefix("/static/", http.FileServer(http.Dir(filepath.Join(s.rootDir, "web/static")))))
 
 	// Main routes
-	r.Get("/", s.handleDashboard)
-	r.Get("/workflows", s.handleWorkflowList)
+	r.With(s.cachePage).Get("/", s.handleDashboard)
+	r.With(s.cachePage).Get("/workflows", s.handleWorkflowList)
+	r.Get("/workflows/new", s.handleWorkflowEditor)
+	r.Get("/workflows/archived", s.handleArchivedWorkflows)
+	r.Get("/workflows/archived/{name}", s.handleArchivedWorkflow)
+	r.Delete("/workflows/archived/{name}", s.handlePurgeWorkflow)
+	r.Post("/workflows", s.handleSaveWorkflow)
 	r.Get("/workflows/{name}", s.handleWorkflowDetail)
+	r.Delete("/workflows/{name}", s.handleDeleteWorkflow)
+	r.Get("/workflows/{name}/edit", s.handleWorkflowEditor)
+	r.Post("/workflows/{name}/edit", s.handleSaveWorkflow)
+	r.Get("/workflows/{name}/run/insights", s.handleRunInsights)
 	r.Post("/workflows/{name}/run", s.handleRunWorkflow)
+	r.Post("/workflows/{name}/presets", s.handleSaveVariablePreset)
+	r.Delete("/workflows/{name}/presets/{preset}", s.handleDeleteVariablePreset)
+	r.Post("/workflows/{name}/schedules", s.handleChangeSchedule)
 	r.Get("/workflows/{name}/history", s.handleWorkflowHistory)
+	r.Get("/workflows/{name}/history/bundle", s.handleOfflineBundle)
+	r.Get("/legacy/{kind}/{name}", s.handleLegacyDetail)
+	r.Post("/legacy/{kind}/{name}/run", s.handleRunLegacy)
+	r.Get("/legacy/{kind}/{name}/executions/{id}/logs", s.handleLegacyLogs)
+	r.Get("/workflows/{name}/runs/{group}", s.handleExecutionGroup)
+	r.Get("/templates", s.handleWorkflowTemplates)
+	r.Get("/templates/*", s.handleWorkflowTemplateDetail)
+	r.Get("/clusters/switcher", s.handleClusterSwitcher)
+	r.Get("/watchlist", s.handleWatchlist)
+	r.Post("/watchlist", s.handleSaveWatch)
+	r.Delete("/watchlist", s.handleDeleteWatch)
+	r.Post("/watchlist/star", s.handleToggleWatch)
+	r.Post("/watchlist/channel", s.handleSaveUserChannel)
 	r.Get("/executions/{id}", s.handleExecutionDetail)
 	r.Get("/executions/{id}/report", s.handleExecutionReport)
+	r.Post("/executions/{id}/rerun-failed", s.handleRerunFailed)
+	r.Post("/executions/{id}/rerun", s.handleRerunExecution)
+	r.Post("/runs/bulk", s.handleBulkRun)
+	r.Post("/executions/{id}/abort", s.handleAbortExecution)
 	r.Get("/executions/{id}/logs", s.handleExecutionLogs)
 	r.Get("/executions/{id}/logs/stream", s.handleExecutionLogsStream)
+	r.Get("/executions/{id}/logs/page", s.handleExecutionLogPage)
+	r.Get("/executions/{id}/logs/search", s.handleExecutionLogSearch)
 	r.Get("/executions/{id}/artifacts", s.handleExecutionArtifacts)
+	r.Get("/executions/{id}/artifacts.zip", s.handleArtifactsZip)
+	r.Get("/executions/{id}/results", s.handleExecutionResults)
+	r.Get("/executions/{id}/resources", s.handleExecutionResources)
 	r.Get("/executions/{id}/artifacts/*", s.handleDownloadArtifact)
+	r.Post("/executions/{id}/share", s.handleCreateShareLink)
+	r.Get("/executions/{id}/diff", s.handleArtifactDiff)
+	r.Get("/executions/{id}/diff/image", s.handleArtifactDiffImage)
+	r.Get("/executions/{id}/visual", s.handleVisual)
+	r.Get("/executions/{id}/visual/diff", s.handleVisualDiffImage)
+	r.Get("/executions/{id}/visual/baseline", s.handleVisualBaselineImage)
+	r.Post("/executions/{id}/visual/approve", s.handleApproveVisual)
+	r.Post("/executions/{id}/visual/reject", s.handleRejectVisual)
 
 	// API routes
 	r.Get("/api/v1/flaky-tests", s.handleFlakyTestsAPI)
+	r.Get("/api/v1/test-cases", s.handleTestCasesAPI)
+	r.Get("/api/v1/tests/passed-on-retry", s.handlePassedOnRetryAPI)
+	r.Get("/api/v1/tests/links", s.handleTestLinksAPI)
+	r.Get("/api/v1/watchlist", s.handleWatchlistAPI)
+	r.Put("/api/v1/watchlist", s.handleSaveWatchAPI)
+	r.Delete("/api/v1/watchlist", s.handleDeleteWatchAPI)
+	r.Put("/api/v1/watchlist/channel", s.handleSaveUserChannelAPI)
+	r.Post("/api/v1/tests/links", s.handleSaveTestLinkAPI)
+	r.Get("/api/v1/quota", s.handleQuotaAPI)
+	r.Get("/api/v1/clusters", s.handleClustersAPI)
+	r.Post("/api/v1/workflows", s.handleCreateWorkflowAPI)
+	r.Get("/api/v1/workflow-types", s.handleWorkflowTypesAPI)
+	r.Get("/api/v1/workflow-templates", s.handleWorkflowTemplatesAPI)
+	r.Get("/api/v1/workflow-templates/*", s.handleWorkflowTemplateAPI)
+	r.Get("/api/v1/workflows/{name}/spec", s.handleWorkflowSpecAPI)
+	r.Put("/api/v1/workflows/{name}", s.handleUpdateWorkflowAPI)
+	r.Delete("/api/v1/workflows/{name}", s.handleDeleteWorkflowAPI)
+	r.Get("/api/v1/workflows/archived", s.handleArchivedWorkflowsAPI)
+	r.Delete("/api/v1/workflows/archived/{name}", s.handlePurgeWorkflowAPI)
+	r.Get("/api/v1/workflows/{name}/dependencies", s.handleDependenciesAPI)
+	r.Get("/api/v1/workflows/{name}/duration-histogram", s.handleDurationHistogramAPI)
+	r.Get("/api/v1/workflows/{name}/pass-rate-alert", s.handlePassRateAlertAPI)
+	r.Get("/api/v1/workflows/{name}/presets", s.handleVariablePresetsAPI)
+	r.Get("/api/v1/workflows/{name}/schedules", s.handleSchedulesAPI)
+	r.Put("/api/v1/workflows/{name}/schedules", s.handleUpdateSchedulesAPI)
+	r.Get("/api/v1/workflows/{name}/triggers", s.handleTriggerBreakdownAPI)
+	r.Get("/api/v1/workflows/{name}/run-insights", s.handleRunInsightsAPI)
+	r.Post("/api/v1/workflows/{name}/run", s.handleRunWorkflowAPI)
+	r.Post("/api/v1/workflows/{name}/run-and-wait", s.handleRunAndWaitAPI)
+	r.Get("/api/v1/suites", s.handleSuitesAPI)
+	r.Get("/api/v1/calendar", s.handleCalendarAPI)
+	r.Get("/api/v1/executions", s.handleExecutionsAPI)
+	r.Get("/api/v1/activity", s.handleActivityAPI)
+	r.Get("/api/v1/activity/live", s.handleLiveActivityAPI)
+	r.Get("/api/v1/suites/{name}/verdict", s.handleSuiteVerdictAPI)
+	r.Put("/api/v1/workflows/{name}/presets/{preset}", s.handleSaveVariablePresetAPI)
+	r.Delete("/api/v1/workflows/{name}/presets/{preset}", s.handleDeleteVariablePresetAPI)
+	r.Get("/api/v1/alerts", s.handleAlertsAPI)
+	r.Get("/api/v1/executions/{id}/infra-events", s.handleInfraEventsAPI)
+	r.Get("/api/v1/executions/{id}/diff", s.handleArtifactDiffAPI)
+	r.Get("/api/v1/executions/{id}/artifacts/verify", s.handleVerifyArtifactsAPI)
+	r.Get("/api/v1/executions/{id}/logs", s.handleExecutionLogsAPI)
+	r.Get("/api/v1/executions/{id}/logs/search", s.handleExecutionLogSearchAPI)
+	r.Post("/api/v1/executions/{id}/abort", s.handleAbortExecutionAPI)
+	r.Post("/api/v1/executions/{id}/rerun", s.handleRerunExecution)
+	r.Post("/api/v1/runs/bulk", s.handleBulkRunAPI)
+	r.Get("/api/v1/runs/groups/{id}", s.handleRunGroupAPI)
+	r.Post("/api/v1/executions/{id}/evidence", s.handleCreateEvidenceAPI)
+	r.Get("/api/v1/executions/{id}/evidence", s.handleEvidenceRecordsAPI)
+	r.Post("/api/v1/evidence/verify", s.handleVerifyEvidenceAPI)
+	r.Get("/api/v1/evidence/key", s.handleEvidenceKeyAPI)
+	r.Get("/api/v1/executions/{id}/visual", s.handleVisualAPI)
+	r.Post("/api/v1/executions/{id}/visual/approve", s.handleReviewVisualAPI(true))
+	r.Post("/api/v1/executions/{id}/visual/reject", s.handleReviewVisualAPI(false))
+	r.Get("/api/v1/visual/baselines", s.handleBaselinesAPI)
+	r.Post("/api/v1/executions/{id}/share", s.handleCreateShareLinkAPI)
+	r.Get("/api/v1/executions/{id}/share", s.handleShareLinksAPI)
+	r.Delete("/api/v1/share/{token}", s.handleRevokeShareLinkAPI)
+
+	// Public share links; logs and artifacts are redacted
+	r.Get("/share/{token}", s.handleSharedExecution)
+	r.Get("/share/{token}/logs", s.handleSharedLogs)
+	r.Get("/share/{token}/artifacts/*", s.handleSharedArtifact)
+
+	// Runs waiting for a concurrency slot or run window
+	r.Get("/queue", s.handleRunQueue)
+	r.Get("/calendar", s.handleCalendar)
+	r.Get("/activity", s.handleActivity)
+	r.Get("/activity/live", s.handleLiveActivityStream)
+	r.Post("/queue/{id}/priority", s.handleSetQueuedPriority)
+	r.Delete("/queue/{id}", s.handleCancelQueuedRun)
+	r.Get("/api/v1/queue", s.handleRunQueueAPI)
+	r.Put("/api/v1/queue/{id}/priority", s.handleSetQueuedPriorityAPI)
+	r.Delete("/api/v1/queue/{id}", s.handleCancelQueuedRunAPI)
+	r.Get("/api/v1/workflows/{name}/run-window", s.handleRunWindowAPI)
+
+	// Test impact analysis for CI pipelines
+	r.Post("/api/v1/test-impact", s.handleTestImpactAPI)
+	r.Post("/api/v1/test-impact/coverage", s.handleTestCoverageAPI)
+
+	// Server-side table state (sort, filters, columns) per user
+	r.Get("/api/v1/tables/{table}/state", s.handleGetTableStateAPI)
+	r.Put("/api/v1/tables/{table}/state", s.handleSaveTableStateAPI)
+	r.Delete("/api/v1/tables/{table}/state", s.handleResetTableStateAPI)
+
+	// Reports
+	r.With(s.cachePage).Get("/reports/flakiness", s.handleFlakinessReport)
+	r.Get("/tests/links", s.handleTestLinks)
+	r.Post("/tests/links", s.handleSaveTestLink)
+	r.Get("/api/v1/reports/flakiness", s.handleFlakinessReportAPI)
+	r.With(s.cachePage).Get("/reports/environments", s.handleEnvironmentSLAReport)
+	r.Get("/api/v1/reports/environments", s.handleEnvironmentSLAReportAPI)
+	r.With(s.cachePage).Get("/reports/compare", s.handleComparisonReport)
+	r.Get("/api/v1/reports/compare", s.handleComparisonReportAPI)
+	r.Get("/about", s.handleAbout)
+	r.Get("/api/v1/info", s.handleServerInfoAPI)
+	r.Get("/reports/weekly", s.handleWeeklyReports)
+	r.Post("/reports/weekly", s.handleGenerateWeeklyReport)
+	r.Get("/reports/weekly/{date}", s.handleWeeklyReport)
+	r.Post("/api/v1/reports/weekly", s.handleGenerateWeeklyReportAPI)
+	r.Get("/api/v1/reports/weekly/{date}", s.handleWeeklyReportAPI)
+
+	// Synthetic uptime checks
+	r.Get("/synthetics", s.handleSynthetics)
+	r.Post("/synthetics", s.handleCreateSynthetic)
+	r.Post("/synthetics/{id}/run", s.handleRunSynthetic)
+	r.Delete("/synthetics/{id}", s.handleDeleteSynthetic)
+	r.Get("/api/v1/synthetics", s.handleSyntheticsAPI)
+	r.Post("/api/v1/synthetics", s.handleCreateSyntheticAPI)
+	r.Delete("/api/v1/synthetics/{id}", s.handleDeleteSyntheticAPI)
+	r.Get("/api/v1/synthetics/{id}/results", s.handleSyntheticResultsAPI)
+
+	// Failure triage queue
+	r.Get("/triage", s.handleTriage)
+	r.Get("/triage/{team}", s.handleTriage)
+	r.Post("/triage/items/{id}", s.handleUpdateTriage)
+	r.Get("/api/v1/triage", s.handleTriageAPI)
+	r.Post("/api/v1/triage/{id}", s.handleUpdateTriageAPI)
+
+	// Admin
+	r.Get("/admin", s.handleAdminPage)
+	r.Post("/admin/settings", s.handleAdminSettings)
+	r.Get("/api/v1/admin/status", s.handleAdminStatusAPI)
+	r.Put("/api/v1/admin/settings", s.handleAdminSettingsAPI)
+	r.Post("/admin/flags", s.handleAdminFlags)
+	r.Post("/admin/restore", s.handleAdminRestore)
+	r.Post("/admin/dead-letters/retry", s.handleRetryDeadLetter)
+	r.Get("/api/v1/admin/dead-letters", s.handleDeadLettersAPI)
+	r.Post("/api/v1/admin/dead-letters/retry", s.handleRetryDeadLetterAPI)
+	r.Post("/admin/worker/cursors/reset", s.handleResetCursor)
+	r.Get("/api/v1/admin/worker/cursors", s.handleCursorsAPI)
+	r.Post("/api/v1/admin/worker/cursors/reset", s.handleResetCursorAPI)
+	r.Get("/api/v1/admin/backup", s.handleBackupAPI)
+	r.Post("/api/v1/admin/restore", s.handleRestoreAPI)
+	r.Get("/api/v1/features", s.handleFeaturesAPI)
+	r.Get("/api/v1/admin/flags", s.handleAdminFlagsAPI)
+	r.Put("/api/v1/admin/flags/{flag}", s.handleSetFeatureOverrideAPI)
+	r.Delete("/api/v1/admin/flags/{flag}", s.handleClearFeatureOverrideAPI)
+	r.Get("/admin/self-test", s.handleSelfTestPage)
+	r.Post("/admin/self-test", s.handleRunSelfTest)
+	r.Get("/api/v1/admin/self-test", s.handleSelfTestAPI)
+
+	// Configuration import/export and GitOps sync
+	r.Get("/api/v1/config/export", s.handleConfigExportAPI)
+	r.Post("/api/v1/config/import", s.handleConfigImportAPI)
+	r.Get("/api/v1/config/sync", s.handleConfigSyncStatusAPI)
+	r.Post("/api/v1/config/sync", s.handleConfigSyncAPI)
 
 	// Environment routes (UI)
 	r.Get("/environments", s.handleEnvironmentList)
 	r.Get("/environments/{id}", s.handleEnvironmentDetail)
+	r.Get("/environments/{id}/values", s.handleEnvironmentValues)
+	r.Post("/environments/{id}/values", s.handleSetEnvironmentValues)
 
 	// Environment API routes
 	r.Get("/api/v1/environments", s.handleEnvironmentsAPI)
+	r.Get("/api/v1/environment-templates", s.handleEnvironmentTemplatesAPI)
 	r.Post("/api/v1/environments", s.handleCreateEnvironmentAPI)
+	r.Post("/hooks/pr", s.handlePullRequestHook)
 	r.Get("/api/v1/environments/{id}", s.handleGetEnvironmentAPI)
 	r.Delete("/api/v1/environments/{id}", s.handleDeleteEnvironmentAPI)
 	r.Post("/api/v1/environments/{id}/extend", s.handleExtendEnvironmentAPI)
+	r.Post("/api/v1/environments/{id}/steps/{step}/retry", s.handleRetryEnvironmentStepAPI)
+	r.Get("/api/v1/environments/{id}/values", s.handleEnvironmentValuesAPI)
+	r.Put("/api/v1/environments/{id}/values", s.handleSetEnvironmentValuesAPI)
 
 	// Tools routes
 	r.Get("/tools/user-generator", s.handleUserGeneratorPage)
@@ -110,26 +575,51 @@ func (s *Server) Router() http.Handler {
 }
 
 func (s *Server) handleDashboard(w http.ResponseWriter, r *http.Request) {
-	// Get trend data from database
-	trends, err := s.db.GetTrends(7)
+	// Get the last day's failures; Testkube filters them by start time
+	executions, err := s.apiFor(r).GetExecutions(testkube.ListOptions{
+		Status:       "failed",
+		PageSize:     10,
+		StartedAfter: time.Now().Add(-recentFailuresWindow),
+	})
 	if err != nil {
-		log.Printf("Error getting trends: %v", err)
+		log.Printf("Error getting executions: %v", err)
 	}
 
-	// Get recent failures
-	executions, err := s.api.GetExecutions(testkube.ListOptions{
-		Status:   "failed",
-		PageSize: 10,
-	})
+	// The failures table polls on its own; skip the metrics it doesn't show
+	if htmxTarget(r) == "recent-failures" {
+		s.renderPage(w, r, "dashboard.html", map[string]interface{}{"RecentFailures": executions})
+		return
+	}
+
+	// Analytics come from the database; while it's down the last-known
+	// figures are shown, or the panels are disabled
+	var analyticsAsOf time.Time
+	asOf := func(at time.Time) {
+		if !at.IsZero() && (analyticsAsOf.IsZero() || at.Before(analyticsAsOf)) {
+			analyticsAsOf = at
+		}
+	}
+
+	// Get trend data from database
+	trends, at, err := dbRead(s, "trends", func() (*database.TrendData, error) { return s.db.GetTrends(7) })
 	if err != nil {
-		log.Printf("Error getting executions: %v", err)
+		log.Printf("Error getting trends: %v", err)
 	}
+	asOf(at)
 
 	// Get flaky tests
-	flakyTests, err := s.db.GetFlakyTests(0.1)
-	if err != nil {
-		log.Printf("Error getting flaky tests: %v", err)
+	flakyTests, at, flakyErr := dbRead(s, "flaky-tests", func() ([]database.FlakyTest, error) { return s.db.GetFlakyTests(0.1) })
+	if flakyErr != nil {
+		log.Printf("Error getting flaky tests: %v", flakyErr)
+	}
+	asOf(at)
+
+	// Tests that only passed after a retry are the clearest flakiness signal
+	retryTests, at, retryErr := dbRead(s, "passed-on-retry", func() ([]database.RetryPassTest, error) { return s.db.GetPassedOnRetryTests(7, 10) })
+	if retryErr != nil {
+		log.Printf("Error getting passed-on-retry tests: %v", retryErr)
 	}
+	asOf(at)
 
 	data := map[string]interface{}{
 		"PassRate":       0,
@@ -138,134 +628,477 @@ func (s *Server) handleDashboard(w http.ResponseWriter, r *http.Request) {
 		"DurationTrend":  "0%",
 		"TotalTests":     0,
 		"FlakyTests":     flakyTests,
+		"PassedOnRetry":  retryTests,
 		"RecentFailures": executions,
 		"PassRateChart":  template.HTML(""),
 		"DurationChart":  template.HTML(""),
 		"Error":          nil,
+		// AnalyticsUnavailable disables the panels with no last-known data
+		"AnalyticsUnavailable": err != nil,
+		"FlakyUnavailable":     flakyErr != nil,
+		"RetryUnavailable":     retryErr != nil,
+		"AnalyticsAsOf":        analyticsAsOf,
+		"LiveActivity":         s.liveFeed(r).Recent(),
+		"Refresh":              s.refreshFor(r, "dashboard"),
 	}
+	data["PassRateChart"], data["DurationChart"] = s.trendCharts(r, "")
 
 	if trends != nil {
 		data["PassRate"] = int(trends.CurrentPassRate * 100)
 		data["PassRateTrend"] = trends.PassRateChange
-		data["AvgDuration"] = trends.AvgDuration.String()
+		data["AvgDuration"] = humanize.Duration(trends.AvgDuration)
 		data["DurationTrend"] = trends.DurationChange
-	} else if err != nil {
-		data["Error"] = fmt.Sprintf("Could not load trend data: %v", err)
 	}
 
-	s.render(w, "dashboard.html", data)
+	s.renderPage(w, r, "dashboard.html", data)
 }
 
 func (s *Server) handleWorkflowList(w http.ResponseWriter, r *http.Request) {
-	workflows, err := s.api.GetWorkflows()
+	selector := strings.TrimSpace(r.URL.Query().Get("selector"))
+	kind := r.URL.Query().Get("kind")
+	if kind != "" && !slices.Contains(testkube.Kinds, kind) {
+		http.Error(w, fmt.Sprintf("Unknown kind %q", kind), http.StatusBadRequest)
+		return
+	}
+	workflows, err := s.listWorkflows(s.apiFor(r), kind, testkube.ListOptions{Selector: selector})
 	if err != nil {
-		log.Printf("Error getting workflows: %v", err)
-		http.Error(w, "Failed to load workflows", http.StatusInternalServerError)
+		s.listError(w, "workflows", err)
 		return
 	}
 
+	query := strings.TrimSpace(r.URL.Query().Get("q"))
+	if query != "" {
+		var matched []testkube.Workflow
+		for _, wf := range workflows {
+			if strings.Contains(strings.ToLower(wf.Name), strings.ToLower(query)) {
+				matched = append(matched, wf)
+			}
+		}
+		workflows = matched
+	}
+
 	data := map[string]interface{}{
 		"Workflows": workflows,
+		"Query":     query,
+		"Selector":  selector,
+		"Kind":      kind,
+		"Kinds":     testkube.Kinds,
 	}
 
-	s.render(w, "workflow_list.html", data)
+	s.renderPage(w, r, "workflow_list.html", data)
+}
+
+// listWorkflows lists the workflows of a kind, or of every kind: the
+// TestWorkflows, then the v1 API's Tests and TestSuites. The workflows
+// failing to load is an error, but legacy resources failing for any reason
+// but the selector only leaves them out.
+func (s *Server) listWorkflows(api testkube.Client, kind string, opts testkube.ListOptions) ([]testkube.Workflow, error) {
+	var workflows []testkube.Workflow
+	if kind == "" || kind == testkube.KindTestWorkflow {
+		list, err := api.GetWorkflows(opts)
+		if err != nil {
+			return nil, err
+		}
+		workflows = list
+	}
+	for _, legacy := range []struct {
+		kind string
+		list func(testkube.ListOptions) ([]testkube.Workflow, error)
+	}{
+		{testkube.KindTest, api.GetTests},
+		{testkube.KindTestSuite, api.GetTestSuites},
+	} {
+		if kind != "" && kind != legacy.kind {
+			continue
+		}
+		list, err := legacy.list(opts)
+		if errors.Is(err, testkube.ErrInvalidSelector) {
+			return nil, err
+		}
+		if err != nil {
+			log.Printf("Error listing %ss: %v", legacy.kind, err)
+			continue
+		}
+		workflows = append(workflows, list...)
+	}
+	return workflows, nil
 }
 
 func (s *Server) handleWorkflowDetail(w http.ResponseWriter, r *http.Request) {
 	name := chi.URLParam(r, "name")
+	api := s.apiFor(r)
 
-	workflow, err := s.api.GetWorkflow(name)
+	workflow, err := api.GetWorkflow(name)
 	if err != nil {
+		// A removed workflow's ingested history lives on in the archive
+		if api == s.api && errors.Is(err, testkube.ErrWorkflowNotFound) {
+			if _, total, err := s.db.QueryExecutions(database.ExecutionQuery{Workflows: []string{name}, Limit: 1}); err == nil && total > 0 {
+				http.Redirect(w, r, "/workflows/archived/"+name, http.StatusSeeOther)
+				return
+			}
+		}
 		log.Printf("Error getting workflow: %v", err)
 		http.Error(w, "Workflow not found", http.StatusNotFound)
 		return
 	}
 
-	executions, err := s.api.GetExecutions(testkube.ListOptions{
+	// Recent executions are sorted and filtered server-side, then paged
+	executions, err := api.GetExecutions(testkube.ListOptions{
 		Workflow: name,
-		PageSize: 20,
+		PageSize: tableRowLimit,
 	})
 	if err != nil {
 		log.Printf("Error getting executions: %v", err)
 	}
 
+	state := s.tableState(w, r, executionTable)
+	if writeTableCSV(w, r, executionTable, state, executions, name+"-executions.csv") {
+		return
+	}
+	table := executionTable.View("executions-table", "/workflows/"+name, state, executions)
+
+	page := queryInt(r, "page", 1)
+	start := min((page-1)*executionPageSize, len(table.Rows))
+	end := min(start+executionPageSize, len(table.Rows))
+	nextPage := 0
+	if end < len(table.Rows) {
+		nextPage = page + 1
+	}
+	table.Rows = table.Rows[start:end]
+
 	data := map[string]interface{}{
-		"Name":          workflow.Name,
-		"Executions":    executions,
-		"PassRateChart": template.HTML(""),
+		"Name":           workflow.Name,
+		"Type":           workflow.Type,
+		"Templates":      workflow.Templates,
+		"Executions":     executions,
+		"ExecutionTable": table,
+		"NextPage":       nextPage,
+		"QueuedRuns":     s.queuedRuns(name),
+		"PassRateChart":  template.HTML(""),
+		"Presets":        s.presetsData(name)["Presets"],
+		"Parameters":     s.workflowParameters(api, name),
+		"Schedules":      s.schedulesData(api, name)["Schedules"],
+		"Watch":          s.watchButtons(r)(name, ""),
+		"Triggers":       triggerBreakdown(executions),
 	}
+	data["PassRateChart"], _ = s.trendCharts(r, name)
+	data["DurationHistogram"] = s.durationHistogram(r, name)
 
-	s.render(w, "workflow_detail.html", data)
+	s.renderPage(w, r, "workflow_detail.html", data)
+}
+
+// runStartedMessage tells the user a run started, with the namespace quota
+// warning and the unhealthy dependencies it started despite, if any
+func runStartedMessage(quotaWarning, unhealthy string) string {
+	if quotaWarning == "" && unhealthy == "" {
+		return "Workflow started successfully"
+	}
+	message := "Workflow started"
+	if unhealthy != "" {
+		message += fmt.Sprintf(", but these dependencies are unhealthy: %s", unhealthy)
+	}
+	if quotaWarning != "" {
+		message += fmt.Sprintf(". Warning: %s", quotaWarning)
+	}
+	return message
 }
 
 func (s *Server) handleRunWorkflow(w http.ResponseWriter, r *http.Request) {
 	name := chi.URLParam(r, "name")
 
-	exec, err := s.api.RunWorkflow(name)
+	ci, err := parseCIMetadata(r)
 	if err != nil {
-		log.Printf("Error running workflow %s: %v", name, err)
-		http.Error(w, "Failed to run workflow", http.StatusInternalServerError)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	opts, quotaWarning, ok := s.prepareRun(w, r, name, testkube.TriggerManual, ci)
+	if !ok {
+		return
+	}
+	tags := opts.Tags
+
+	exec, queued, ok := s.submitRun(w, r, name, opts)
+	if !ok {
+		return
+	}
+	if queued != nil {
+		limit, _ := s.runs.LimitFor(name)
+		message := fmt.Sprintf("%s is limited to %d concurrent runs; run queued at position %d", name, limit.MaxConcurrent, queued.Position)
+		if !queued.NotBefore.IsZero() {
+			message = fmt.Sprintf("%s is outside its run window; run queued until %s", name, queued.NotBefore.Format(time.RFC1123))
+		}
+		trigger, _ := json.Marshal(map[string]string{"showMessage": message})
+		w.Header().Set("HX-Trigger", string(trigger))
+		w.WriteHeader(http.StatusAccepted)
 		return
 	}
 
 	log.Printf("Started execution %s for workflow %s", exec.ID, name)
 
 	// Return success with HX-Trigger to show notification
-	w.Header().Set("HX-Trigger", `{"showMessage": "Workflow started successfully"}`)
+	trigger, _ := json.Marshal(map[string]string{
+		"showMessage":      runStartedMessage(quotaWarning, tags[dependencies.UnhealthyTag]),
+		"executionStarted": exec.ID,
+	})
+	w.Header().Set("HX-Trigger", string(trigger))
 	w.WriteHeader(http.StatusOK)
 }
 
+// checkQuota inspects ResourceQuota usage in namespace before resources are
+// scheduled there. It returns a warning message when usage is near the limit,
+// and writes a 409 response and returns false when the action should be
+// denied. Quota lookup failures never block the action.
+func (s *Server) checkQuota(ctx context.Context, w http.ResponseWriter, namespace string) (string, bool) {
+	if s.quota == nil || namespace == "" {
+		return "", true
+	}
+
+	status, err := s.quota.Check(ctx, namespace)
+	if err != nil {
+		log.Printf("Error checking resource quota in %s: %v", namespace, err)
+		return "", true
+	}
+
+	switch status.Level {
+	case kube.QuotaDeny:
+		log.Printf("Denied action in %s: %s", namespace, status.Message())
+		http.Error(w, status.Message(), http.StatusConflict)
+		return "", false
+	case kube.QuotaWarn:
+		return status.Message(), true
+	}
+	return "", true
+}
+
+func (s *Server) handleQuotaAPI(w http.ResponseWriter, r *http.Request) {
+	if s.quota == nil {
+		http.Error(w, "Kubernetes API not configured", http.StatusServiceUnavailable)
+		return
+	}
+
+	namespace := r.URL.Query().Get("namespace")
+	if namespace == "" {
+		namespace = s.envMgr.Namespace()
+	}
+
+	status, err := s.quota.Check(r.Context(), namespace)
+	if err != nil {
+		log.Printf("Error checking resource quota in %s: %v", namespace, err)
+		http.Error(w, "Failed to load resource quota", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(status)
+}
+
+func (s *Server) handleAlertsAPI(w http.ResponseWriter, r *http.Request) {
+	if s.alerts == nil {
+		http.Error(w, "Notifications not configured", http.StatusServiceUnavailable)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(s.alerts.Alerts())
+}
+
 func (s *Server) handleWorkflowHistory(w http.ResponseWriter, r *http.Request) {
 	name := chi.URLParam(r, "name")
-	// page := r.URL.Query().Get("page")
 
-	executions, err := s.api.GetExecutions(testkube.ListOptions{
+	dr, err := parseDateRange(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	api := s.apiFor(r)
+	opts := testkube.ListOptions{
+		Workflow: name,
+		Page:     queryInt(r, "page", 1),
+		PageSize: executionPageSize,
+		Branch:   r.URL.Query().Get("branch"),
+		Commit:   r.URL.Query().Get("commit"),
+	}
+	page, err := s.executionPage(api, opts, dr)
+	if err != nil {
+		s.listError(w, "history", err)
+		return
+	}
+	executions := page.Executions
+
+	breakdown := triggerBreakdown(executions)
+	trigger := r.URL.Query().Get("trigger")
+	if trigger != "" {
+		var matching []testkube.Execution
+		for _, exec := range executions {
+			if executionTrigger(exec) == trigger {
+				matching = append(matching, exec)
+			}
+		}
+		executions = matching
+	}
+
+	// Collapse shard executions into one row per logical run
+	data := map[string]interface{}{
+		"Name":     name,
+		"Runs":     testkube.GroupShards(executions),
+		"Triggers": breakdown,
+		"Trigger":  trigger,
+		// The filter can select a source with no finished runs to break down
+		"TriggerListed": slices.ContainsFunc(breakdown, func(t triggerStats) bool { return t.Trigger == trigger }),
+		"Page":          page,
+		"Range":         dr,
+		"Branch":        opts.Branch,
+		"Branches":      workflowBranches(api, name, opts.Branch),
+		"Commit":        opts.Commit,
+		"Source":        sourceQuery(opts),
+		"PrevPage":      page.Page - 1,
+		"NextPage":      0,
+	}
+	if page.HasNext() {
+		data["NextPage"] = page.Page + 1
+	}
+
+	s.render(w, "workflow_history.html", data)
+}
+
+func (s *Server) handleExecutionGroup(w http.ResponseWriter, r *http.Request) {
+	name := chi.URLParam(r, "name")
+	groupID := chi.URLParam(r, "group")
+
+	executions, err := s.apiFor(r).GetExecutions(testkube.ListOptions{
 		Workflow: name,
-		PageSize: 20,
+		PageSize: 200,
 	})
 	if err != nil {
 		log.Printf("Error getting executions: %v", err)
-		http.Error(w, "Failed to load history", http.StatusInternalServerError)
+		http.Error(w, "Failed to load executions", http.StatusInternalServerError)
+		return
+	}
+
+	var shards []testkube.Execution
+	for _, exec := range executions {
+		if exec.Labels[testkube.ShardGroupLabel] == groupID {
+			shards = append(shards, exec)
+		}
+	}
+	groups := testkube.GroupShards(shards)
+	if len(groups) != 1 || !groups[0].Sharded() {
+		http.Error(w, "Sharded run not found", http.StatusNotFound)
 		return
 	}
+	group := groups[0]
+
+	// Aggregate test cases across all shards
+	var testCases []database.TestCase
+	passed := 0
+	for _, shard := range group.Shards {
+		cases, err := s.db.GetExecutionMetrics(shard.ID)
+		if err != nil {
+			log.Printf("Error getting test cases for shard %s: %v", shard.ID, err)
+			continue
+		}
+		for _, tc := range cases {
+			if tc.ExecutionID == "" {
+				tc.ExecutionID = shard.ID
+			}
+			if tc.Status == "passed" {
+				passed++
+			}
+			testCases = append(testCases, tc)
+		}
+	}
 
-	log.Printf("Found %d executions for workflow %s", len(executions), name)
+	passRate := 0
+	if len(testCases) > 0 {
+		passRate = passed * 100 / len(testCases)
+	}
 
 	data := map[string]interface{}{
-		"Name":       name,
-		"Executions": executions,
+		"Name":      name,
+		"Group":     group,
+		"TestCases": testCases,
+		"PassRate":  passRate,
 	}
 
-	s.render(w, "workflow_history.html", data)
+	s.render(w, "execution_group.html", data)
 }
 
 func (s *Server) handleExecutionDetail(w http.ResponseWriter, r *http.Request) {
 	id := chi.URLParam(r, "id")
+	api := s.apiFor(r)
 
-	exec, err := s.api.GetExecution(id)
+	exec, err := api.GetExecution(id)
 	if err != nil {
 		log.Printf("Error getting execution: %v", err)
 		http.Error(w, "Execution not found", http.StatusNotFound)
 		return
 	}
 
-	testCases, err := s.db.GetExecutionMetrics(id)
+	testCases, _, testCasesErr := dbRead(s, "test-cases:"+id, func() ([]database.TestCase, error) { return s.db.GetExecutionMetrics(id) })
+	if testCasesErr != nil {
+		log.Printf("Error getting test cases: %v", testCasesErr)
+	}
+
+	failedCount := 0
+	for _, tc := range testCases {
+		if tc.Status == "failed" {
+			failedCount++
+		}
+	}
+
+	// Reports with suites, such as several projects in one run, are also
+	// shown grouped by them
+	suites, _, err := dbRead(s, "test-suites:"+id, func() ([]database.TestSuite, error) { return s.db.GetTestSuites(id) })
 	if err != nil {
-		log.Printf("Error getting test cases: %v", err)
+		log.Printf("Error getting test suites: %v", err)
+	}
+
+	state := s.tableState(w, r, testCaseTable)
+	if writeTableCSV(w, r, testCaseTable, state, testCases, id+"-tests.csv") {
+		return
 	}
 
+	renderer, hasRenderer := s.executionRenderer(api, exec.WorkflowName)
+	liveLogs := s.featureEnabled(r, features.LiveLogs)
 	data := map[string]interface{}{
-		"Execution": exec,
-		"TestCases": testCases,
+		"Execution":   exec,
+		// Workflow types with their own results view load it separately,
+		// and most don't need the test case table
+		"Results":     hasRenderer,
+		"ShowTestCases": !hasRenderer || renderer.testCases,
+		"TestCases":   testCases,
+		"TestCasesUnavailable": testCasesErr != nil,
+		"Suites":      suiteGroups(suites, testCases),
+		"TestTable":   testCaseTable.View("test-cases", "/executions/"+id, state, testCases),
+		"FailedCount": failedCount,
+		"InfraEvents": s.infraEvents(exec),
+		// Dependencies that were down when the run started, if it was tagged
+		"UnhealthyDependencies": exec.Labels[dependencies.UnhealthyTag],
+		"RerunOf":     exec.Labels[RerunOfTag],
+		"CI":          executionCIMetadata(exec.Labels),
+		"Reruns":      s.findReruns(api, exec),
+		"LiveLogs":    liveLogs,
+		"Evidence":    s.featureEnabled(r, features.Evidence),
+		// Share links and evidence bundles cover the default cluster
+		"DefaultCluster": s.onDefaultCluster(r),
+	}
+	if !liveLogs {
+		// Only the end of the log, which may be too large for the browser
+		page, err := s.logPage(api, id, -logTailLines, logTailLines)
+		if err != nil {
+			log.Printf("Error getting execution logs: %v", err)
+		}
+		data["Logs"] = logPageData(id, page)
 	}
 
-	s.render(w, "execution_detail.html", data)
+	s.renderPage(w, r, "execution_detail.html", data)
 }
 
 func (s *Server) handleExecutionReport(w http.ResponseWriter, r *http.Request) {
 	id := chi.URLParam(r, "id")
+	api := s.apiFor(r)
 
-	artifacts, err := s.api.GetArtifacts(id)
+	artifacts, err := api.GetArtifacts(id)
 	if err != nil {
 		log.Printf("Error getting artifacts: %v", err)
 		http.Error(w, "Failed to load report", http.StatusInternalServerError)
@@ -285,12 +1118,13 @@ func (s *Server) handleExecutionReport(w http.ResponseWriter, r *http.Request) {
 	}
 
 	if reportPath != "" {
-		data, err := s.api.DownloadArtifact(id, reportPath)
+		data, integrity, err := s.downloadVerifiedArtifact(api, id, reportPath)
 		if err != nil {
 			log.Printf("Error downloading artifact %s: %v", reportPath, err)
 			http.Error(w, "Failed to download report", http.StatusInternalServerError)
 			return
 		}
+		w.Header().Set(artifactIntegrityHeader, string(integrity))
 		w.Header().Set("Content-Type", "text/html")
 		w.Write(data)
 		return
@@ -299,20 +1133,10 @@ func (s *Server) handleExecutionReport(w http.ResponseWriter, r *http.Request) {
 	http.Error(w, "No HTML report found", http.StatusNotFound)
 }
 
-func (s *Server) handleExecutionLogs(w http.ResponseWriter, r *http.Request) {
-	id := chi.URLParam(r, "id")
-	logs, err := s.api.GetExecutionLogs(id)
-	if err != nil {
-		log.Printf("Error getting execution logs: %v", err)
-		http.Error(w, "Failed to load logs", http.StatusInternalServerError)
-		return
-	}
-	w.Write([]byte(logs))
-}
-
 func (s *Server) handleExecutionArtifacts(w http.ResponseWriter, r *http.Request) {
 	id := chi.URLParam(r, "id")
-	artifacts, err := s.api.GetArtifacts(id)
+	api := s.apiFor(r)
+	artifacts, err := api.GetArtifacts(id)
 	if err != nil {
 		log.Printf("Error getting artifacts: %v", err)
 		w.Header().Set("Content-Type", "text/html")
@@ -322,9 +1146,11 @@ func (s *Server) handleExecutionArtifacts(w http.ResponseWriter, r *http.Request
 		return
 	}
 
+	verify := r.URL.Query().Get("verify") == "true"
 	data := map[string]interface{}{
 		"ExecutionID": id,
-		"Artifacts":   artifacts,
+		"Artifacts":   s.checkArtifacts(api, id, artifactRows(artifacts), verify),
+		"Verified":    verify,
 	}
 
 	s.renderPartial(w, "artifacts.html", data)
@@ -334,37 +1160,43 @@ func (s *Server) handleDownloadArtifact(w http.ResponseWriter, r *http.Request)
 	id := chi.URLParam(r, "id")
 	path := chi.URLParam(r, "*")
 
-	data, err := s.api.DownloadArtifact(id, path)
-	if err != nil {
+	if contentType := artifactContentType(path); contentType != "" {
+		w.Header().Set("Content-Type", contentType)
+	}
+
+	if err := s.streamVerifiedArtifact(w, s.apiFor(r), id, path); err != nil {
 		log.Printf("Error downloading artifact %s: %v", path, err)
 		http.Error(w, "Failed to download artifact", http.StatusInternalServerError)
-		return
 	}
+}
 
-	// Detect content type
-	ext := filepath.Ext(path)
-	switch ext {
+// artifactContentType detects an artifact's content type from its extension
+func artifactContentType(path string) string {
+	switch filepath.Ext(path) {
 	case ".html":
-		w.Header().Set("Content-Type", "text/html")
+		return "text/html"
 	case ".json":
-		w.Header().Set("Content-Type", "application/json")
+		return "application/json"
 	case ".txt":
-		w.Header().Set("Content-Type", "text/plain")
+		return "text/plain"
 	case ".xml":
-		w.Header().Set("Content-Type", "application/xml")
+		return "application/xml"
 	case ".png":
-		w.Header().Set("Content-Type", "image/png")
+		return "image/png"
 	case ".jpg", ".jpeg":
-		w.Header().Set("Content-Type", "image/jpeg")
+		return "image/jpeg"
 	case ".zip":
-		w.Header().Set("Content-Type", "application/zip")
+		return "application/zip"
 	}
-
-	w.Write(data)
+	return ""
 }
 
 func (s *Server) handleExecutionLogsStream(w http.ResponseWriter, r *http.Request) {
 	id := chi.URLParam(r, "id")
+	if !s.featureEnabled(r, features.LiveLogs) {
+		http.Error(w, "Live logs are disabled", http.StatusNotFound)
+		return
+	}
 
 	// Set headers for SSE
 	w.Header().Set("Content-Type", "text/event-stream")
@@ -372,114 +1204,168 @@ func (s *Server) handleExecutionLogsStream(w http.ResponseWriter, r *http.Reques
 	w.Header().Set("Connection", "keep-alive")
 	w.Header().Set("Access-Control-Allow-Origin", "*")
 
-	// Get stream from client
-	logsCh, errCh := s.api.StreamExecutionLogs(r.Context(), id)
-
 	flusher, ok := w.(http.Flusher)
 	if !ok {
 		http.Error(w, "Streaming not supported", http.StatusInternalServerError)
 		return
 	}
 
-	for {
-		select {
-		case <-r.Context().Done():
-			return
-		case err := <-errCh:
-			if err != nil {
-				// Send error as HTML
-				safeErr := template.HTMLEscapeString(err.Error())
-				fmt.Fprintf(w, "event: error\ndata: <div class='alert alert-danger'>%s</div>\n\n", safeErr)
-				flusher.Flush()
-			}
-			return
-		case line, ok := <-logsCh:
-			if !ok {
-				// Stream ended
-				// We can send a finished event if needed, or just close connection
-				// If we close connection, client might reconnect.
-				// Better to stay open or send specific event to stop retry?
-				// HTMX SSE documentation says:
-				// "If the server closes the connection, the browser will attempt to reconnect."
-				// We can send an event that removes the sse extension or something, but easiest is
-				// just to return and let client handle disconnect/reconnect logic or just have it reconnect.
-				// But we don't want it to reconnect and stream all logs again.
-				// Sending a specific event "done" could let client JS handle it, but we want to avoid custom JS.
-				// If we just return, it reconnects.
-				// Maybe we can just hang here until context done?
-				// If execution is finished, keeping connection open is useless but harmless?
-				// Let's just return for now.
-				return
-			}
-			// Escape HTML to be safe since we insert into DOM
-			safeLine := template.HTMLEscapeString(line)
-			// Append newline for pre tag. In SSE, multiple data lines create newlines.
-			fmt.Fprintf(w, "event: log\ndata: %s\ndata:\n\n", safeLine)
+	// Get stream from client
+	lines, err := s.apiFor(r).StreamExecutionLogs(r.Context(), id)
+	if err != nil {
+		// Send error as HTML
+		safeErr := template.HTMLEscapeString(err.Error())
+		fmt.Fprintf(w, "event: error\ndata: <div class='alert alert-danger'>%s</div>\n\n", safeErr)
+		flusher.Flush()
+		return
+	}
+
+	// The channel closes when the execution finishes or the client leaves
+	for line := range lines {
+		if line.Err != nil {
+			safeErr := template.HTMLEscapeString(line.Err.Error())
+			fmt.Fprintf(w, "event: error\ndata: <div class='alert alert-danger'>%s</div>\n\n", safeErr)
 			flusher.Flush()
+			return
 		}
+		// Escape HTML to be safe since we insert into DOM
+		safeLine := template.HTMLEscapeString(line.Text)
+		// Append newline for pre tag. In SSE, multiple data lines create newlines.
+		fmt.Fprintf(w, "event: log\ndata: %s\ndata:\n\n", safeLine)
+		flusher.Flush()
 	}
 }
 
-func (s *Server) handleFlakyTestsAPI(w http.ResponseWriter, r *http.Request) {
-	flakyTests, err := s.db.GetFlakyTests(0.1)
+func (s *Server) handleConfigExportAPI(w http.ResponseWriter, r *http.Request) {
+	bundle, err := s.config.Export()
 	if err != nil {
-		log.Printf("Error getting flaky tests: %v", err)
-		http.Error(w, "Failed to load flaky tests", http.StatusInternalServerError)
+		log.Printf("Error exporting configuration: %v", err)
+		http.Error(w, "Failed to export configuration", http.StatusInternalServerError)
 		return
 	}
 
 	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(flakyTests)
+	w.Header().Set("Content-Disposition", `attachment; filename="dashboard-config.json"`)
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	enc.Encode(bundle)
 }
 
-func (s *Server) handleHealthz(w http.ResponseWriter, r *http.Request) {
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusOK)
-	json.NewEncoder(w).Encode(map[string]string{"status": "ok"})
+func (s *Server) handleConfigImportAPI(w http.ResponseWriter, r *http.Request) {
+	if !s.requireAdmin(w, r) {
+		return
+	}
+
+	var bundle configsync.Bundle
+	if err := json.NewDecoder(r.Body).Decode(&bundle); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if err := s.config.Import(&bundle); err != nil {
+		log.Printf("Error importing configuration: %v", err)
+		http.Error(w, fmt.Sprintf("Failed to import configuration: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	log.Printf("Imported configuration bundle with %d sections", len(bundle.Sections))
+	if s.configSync != nil {
+		w.Header().Set("X-Config-Sync-Warning", "Git sync is enabled; imported changes will be reported as drift")
+	}
+	w.WriteHeader(http.StatusNoContent)
 }
 
-func (s *Server) handleReadyz(w http.ResponseWriter, r *http.Request) {
+func (s *Server) handleConfigSyncStatusAPI(w http.ResponseWriter, r *http.Request) {
+	if s.configSync == nil {
+		http.Error(w, "Configuration sync not configured", http.StatusNotFound)
+		return
+	}
+
 	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusOK)
-	json.NewEncoder(w).Encode(map[string]string{"status": "ready"})
+	json.NewEncoder(w).Encode(s.configSync.Status())
 }
 
-func (s *Server) render(w http.ResponseWriter, page string, data interface{}) {
-	t, ok := s.templates[page]
-	if !ok {
-		log.Printf("Template not found: %s", page)
-		http.Error(w, "Page not found", http.StatusNotFound)
+func (s *Server) handleConfigSyncAPI(w http.ResponseWriter, r *http.Request) {
+	if !s.requireAdmin(w, r) {
+		return
+	}
+	if s.configSync == nil {
+		http.Error(w, "Configuration sync not configured", http.StatusNotFound)
 		return
 	}
-	w.Header().Set("Content-Type", "text/html")
-	if err := t.ExecuteTemplate(w, "layout", data); err != nil {
-		log.Printf("Template error: %v", err)
-		http.Error(w, "Internal server error", http.StatusInternalServerError)
+
+	status := s.configSync.SyncNow(r.Context())
+
+	w.Header().Set("Content-Type", "application/json")
+	if status.Error != "" {
+		w.WriteHeader(http.StatusBadGateway)
 	}
+	json.NewEncoder(w).Encode(status)
 }
 
-func (s *Server) renderPartial(w http.ResponseWriter, page string, data interface{}) {
-	t, ok := s.templates[page]
-	if !ok {
-		log.Printf("Template not found: %s", page)
-		http.Error(w, "Page not found", http.StatusNotFound)
+func (s *Server) handlePassedOnRetryAPI(w http.ResponseWriter, r *http.Request) {
+	days := queryInt(r, "days", 7)
+	limit := queryInt(r, "limit", 50)
+
+	tests, err := s.db.GetPassedOnRetryTests(days, limit)
+	if err != nil {
+		s.databaseError(w, "retry analysis", err)
 		return
 	}
-	w.Header().Set("Content-Type", "text/html")
-	if err := t.ExecuteTemplate(w, "content", data); err != nil {
-		log.Printf("Template error: %v", err)
-		http.Error(w, "Internal server error", http.StatusInternalServerError)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(tests)
+}
+
+// queryInt reads a positive integer query parameter, falling back to def
+func queryInt(r *http.Request, key string, def int) int {
+	if val, err := strconv.Atoi(r.URL.Query().Get(key)); err == nil && val > 0 {
+		return val
+	}
+	return def
+}
+
+func (s *Server) handleHealthz(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]string{"status": "ok"})
+}
+
+// handleReadyz stays ready while the database is down, since Testkube data
+// doesn't need it, but reports the dashboard as degraded
+func (s *Server) handleReadyz(w http.ResponseWriter, r *http.Request) {
+	db := s.pingDatabase(r.Context())
+	status := "ready"
+	if !db.Available {
+		status = "degraded"
 	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]interface{}{"status": status, "database": db})
 }
 
 // Environment handlers
 
 func (s *Server) handleEnvironmentList(w http.ResponseWriter, r *http.Request) {
-	envs := s.envMgr.List(environments.ListEnvironmentsOptions{})
+	team := r.URL.Query().Get("team")
+	envs := s.envMgr.List(environments.ListEnvironmentsOptions{Team: team})
+
+	templates := s.envMgr.Templates()
+	helmTemplates := make(map[string]bool)
+	for _, t := range templates {
+		helmTemplates[t.Name] = t.Provisioner == environments.ProvisionerHelm
+	}
 
 	data := map[string]interface{}{
-		"Environments": envs,
-		"Page":         "environments",
+		"Environments":  envs,
+		"Templates":     templates,
+		"HelmTemplates": helmTemplates,
+		"IsAdmin":       s.isAdmin(r),
+		"Editable":      s.editableEnvironments(r, envs),
+		"Team":          team,
+		"Teams":         s.environmentTeams(r),
+		"User":          proxyUser(r),
+		"Page":          "environments",
 	}
 
 	s.render(w, "environments.html", data)
@@ -507,9 +1393,9 @@ func (s *Server) handleEnvironmentDetail(w http.ResponseWriter, r *http.Request)
 }
 
 func (s *Server) handleEnvironmentsAPI(w http.ResponseWriter, r *http.Request) {
-	owner := r.URL.Query().Get("owner")
 	envs := s.envMgr.List(environments.ListEnvironmentsOptions{
-		Owner: owner,
+		Owner: r.URL.Query().Get("owner"),
+		Team:  r.URL.Query().Get("team"),
 	})
 
 	w.Header().Set("Content-Type", "application/json")
@@ -527,11 +1413,27 @@ func (s *Server) handleCreateEnvironmentAPI(w http.ResponseWriter, r *http.Reque
 	if req.Type == "" {
 		req.Type = environments.TypeEphemeral
 	}
+	if req.Owner == "" {
+		req.Owner = proxyUser(r)
+	}
 	if req.Owner == "" {
 		req.Owner = "anonymous"
 	}
 
+	quotaWarning, ok := s.checkQuota(r.Context(), w, s.envMgr.Namespace())
+	if !ok {
+		return
+	}
+
 	env, err := s.envMgr.Create(r.Context(), req)
+	if errors.Is(err, environments.ErrInvalidHostname) || errors.Is(err, environments.ErrUnknownTemplate) {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if errors.Is(err, environments.ErrHostnameInUse) {
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	}
 	if err != nil {
 		log.Printf("Failed to create environment: %v", err)
 		http.Error(w, "Failed to create environment", http.StatusInternalServerError)
@@ -539,12 +1441,27 @@ func (s *Server) handleCreateEnvironmentAPI(w http.ResponseWriter, r *http.Reque
 	}
 
 	log.Printf("Created environment %s for %s", env.Name, env.Owner)
+	s.recordEvent(database.Event{
+		Type:    activityEnvironmentCreated,
+		Actor:   env.Owner,
+		Subject: env.Name,
+		Message: fmt.Sprintf("Created %s environment", env.Type),
+		URL:     env.URL,
+	})
 
+	if quotaWarning != "" {
+		w.Header().Set("X-Quota-Warning", quotaWarning)
+	}
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusCreated)
 	json.NewEncoder(w).Encode(env)
 }
 
+func (s *Server) handleEnvironmentTemplatesAPI(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(s.envMgr.Templates())
+}
+
 func (s *Server) handleGetEnvironmentAPI(w http.ResponseWriter, r *http.Request) {
 	id := chi.URLParam(r, "id")
 
@@ -561,18 +1478,43 @@ func (s *Server) handleGetEnvironmentAPI(w http.ResponseWriter, r *http.Request)
 func (s *Server) handleDeleteEnvironmentAPI(w http.ResponseWriter, r *http.Request) {
 	id := chi.URLParam(r, "id")
 
+	env, err := s.envMgr.Get(id)
+	if err != nil {
+		http.Error(w, "Environment not found", http.StatusNotFound)
+		return
+	}
+	if !s.canEditEnvironment(r, env) {
+		http.Error(w, "Only the environment's owner, its team or an admin can delete it", http.StatusForbidden)
+		return
+	}
 	if err := s.envMgr.Delete(id); err != nil {
 		http.Error(w, "Environment not found", http.StatusNotFound)
 		return
 	}
 
 	log.Printf("Deleted environment %s", id)
+	s.recordEvent(database.Event{
+		Type:    activityEnvironmentDeleted,
+		Actor:   proxyUser(r),
+		Subject: env.Name,
+		Message: "Deleted environment",
+	})
 	w.WriteHeader(http.StatusNoContent)
 }
 
 func (s *Server) handleExtendEnvironmentAPI(w http.ResponseWriter, r *http.Request) {
 	id := chi.URLParam(r, "id")
 
+	env, err := s.envMgr.Get(id)
+	if err != nil {
+		http.Error(w, "Environment not found", http.StatusNotFound)
+		return
+	}
+	if !s.canEditEnvironment(r, env) {
+		http.Error(w, "Only the environment's owner, its team or an admin can extend it", http.StatusForbidden)
+		return
+	}
+
 	var req struct {
 		Hours int `json:"hours"`
 	}
@@ -585,13 +1527,39 @@ func (s *Server) handleExtendEnvironmentAPI(w http.ResponseWriter, r *http.Reque
 		return
 	}
 
-	env, _ := s.envMgr.Get(id)
+	env, _ = s.envMgr.Get(id)
 	log.Printf("Extended environment %s by %d hours", id, req.Hours)
 
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(env)
 }
 
+// handleRetryEnvironmentStepAPI re-runs a failed environment's provisioning
+// from the given step
+func (s *Server) handleRetryEnvironmentStepAPI(w http.ResponseWriter, r *http.Request) {
+	if !s.requireAdmin(w, r) {
+		return
+	}
+	id := chi.URLParam(r, "id")
+	step := chi.URLParam(r, "step")
+
+	if _, err := s.envMgr.Get(id); err != nil {
+		http.Error(w, "Environment not found", http.StatusNotFound)
+		return
+	}
+	if err := s.envMgr.RetryStep(id, step); err != nil {
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	}
+
+	env, _ := s.envMgr.Get(id)
+	log.Printf("Retrying environment %s from step %s", id, step)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusAccepted)
+	json.NewEncoder(w).Encode(env)
+}
+
 func formatDuration(d time.Duration) string {
 	if d < 0 {
 		return "Expired"
@@ -695,6 +1663,13 @@ func (s *Server) handleCreateUserAPI(w http.ResponseWriter, r *http.Request) {
 	}
 
 	log.Printf("Created user: %s (%s) in %s", user.Username, user.Email, user.Environment)
+	s.recordEvent(database.Event{
+		Type:    activityUserGenerated,
+		Actor:   proxyUser(r),
+		Subject: user.Username,
+		Message: fmt.Sprintf("Generated %s user in %s", user.UserType, user.Environment),
+		URL:     "/tools/user-generator?env=" + url.QueryEscape(user.Environment),
+	})
 
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusCreated)
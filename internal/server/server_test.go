@@ -1,13 +1,44 @@
 package server
 
 import (
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime/multipart"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
+	"path/filepath"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/testkube/dashboard/internal/artifacts"
+	"github.com/testkube/dashboard/internal/backup"
+	"github.com/testkube/dashboard/internal/baselines"
 	"github.com/testkube/dashboard/internal/database"
+	"github.com/testkube/dashboard/internal/environments"
+	"github.com/testkube/dashboard/internal/evidence"
+	"github.com/testkube/dashboard/internal/features"
+	"github.com/testkube/dashboard/internal/kube"
+	"github.com/testkube/dashboard/internal/parsers"
+	"github.com/testkube/dashboard/internal/previews"
+	"github.com/testkube/dashboard/internal/reports"
+	"github.com/testkube/dashboard/internal/runqueue"
+	"github.com/testkube/dashboard/internal/schedules"
+	"github.com/testkube/dashboard/internal/suites"
 	"github.com/testkube/dashboard/internal/testkube"
+	"github.com/testkube/dashboard/internal/triage"
+	"github.com/testkube/dashboard/internal/worker"
 )
 
 func TestHandleDashboard(t *testing.T) {
@@ -34,3 +65,3197 @@ func TestHandleDashboard(t *testing.T) {
 	// Check the response body
 	assert.Contains(t, rr.Body.String(), "Testkube Dashboard")
 }
+
+func TestHandleExecutionGroup(t *testing.T) {
+	api := testkube.NewMockClient()
+	db := database.NewMockDatabase()
+	srv := NewServer(api, db, nil, "../..")
+
+	req, err := http.NewRequest("GET", "/workflows/frontend-e2e/history", nil)
+	assert.NoError(t, err)
+	rr := httptest.NewRecorder()
+	srv.Router().ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Contains(t, rr.Body.String(), "/workflows/frontend-e2e/runs/frontend-e2e-sharded-1")
+	assert.NotContains(t, rr.Body.String(), "/executions/exec-shard-1-1")
+
+	req, err = http.NewRequest("GET", "/workflows/frontend-e2e/runs/frontend-e2e-sharded-2", nil)
+	assert.NoError(t, err)
+	rr = httptest.NewRecorder()
+	srv.Router().ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Contains(t, rr.Body.String(), "3/4 passed")
+	assert.Contains(t, rr.Body.String(), "/executions/exec-shard-2-4")
+}
+
+func TestHandleRerunFailed(t *testing.T) {
+	api := testkube.NewMockClient()
+	db := database.NewMockDatabase()
+	srv := NewServer(api, db, nil, "../..")
+
+	req, err := http.NewRequest("POST", "/executions/exec-1/rerun-failed", nil)
+	assert.NoError(t, err)
+	rr := httptest.NewRecorder()
+	srv.Router().ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusCreated, rr.Code)
+	assert.Contains(t, rr.Header().Get("HX-Redirect"), "/executions/")
+
+	reruns, err := api.GetExecutions(testkube.ListOptions{PageSize: 1})
+	assert.NoError(t, err)
+	assert.Equal(t, "exec-1", reruns[0].Labels[RerunOfTag])
+
+	logs, err := api.GetExecutionLogs(reruns[0].ID)
+	assert.NoError(t, err)
+	assert.Contains(t, logs, "Config grep=^(Submit Form)$")
+}
+
+func TestFailedTestsPattern(t *testing.T) {
+	pattern := failedTestsPattern([]string{
+		"[chromium] checkout > pays (card)",
+		"[firefox] checkout > pays (card)",
+		"Login",
+	})
+	assert.Equal(t, `^(Login|pays \(card\))$`, pattern)
+}
+
+func TestHandleTriage(t *testing.T) {
+	api := testkube.NewMockClient()
+	db := database.NewMockDatabase()
+	srv := NewServer(api, db, nil, "../..")
+
+	srv.ExecutionIngested(testkube.Execution{
+		ID:           "exec-1",
+		WorkflowName: "frontend-e2e",
+		Status:       "failed",
+		EndTime:      time.Now(),
+	}, []database.TestCase{
+		{TestName: "Checkout > pays", FilePath: "tests/checkout.spec.ts", Status: "failed", ErrorMessage: "timeout"},
+		{TestName: "Login > works", Status: "passed"},
+	})
+
+	req, err := http.NewRequest("GET", "/triage/unowned", nil)
+	assert.NoError(t, err)
+	rr := httptest.NewRecorder()
+	srv.Router().ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Contains(t, rr.Body.String(), "Checkout &gt; pays")
+	assert.NotContains(t, rr.Body.String(), "Login &gt; works")
+
+	items := srv.triage.List(triage.Filter{})
+	assert.Len(t, items, 1)
+
+	req, err = http.NewRequest("POST", "/api/v1/triage/"+items[0].ID, strings.NewReader(`{"state":"investigating","assignee":"alice"}`))
+	assert.NoError(t, err)
+	rr = httptest.NewRecorder()
+	srv.Router().ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	item, _ := srv.triage.Get(items[0].ID)
+	assert.Equal(t, triage.StateInvestigating, item.State)
+	assert.Equal(t, "alice", item.Assignee)
+}
+
+func TestHandleFlakinessReport(t *testing.T) {
+	api := testkube.NewMockClient()
+	db := database.NewMockDatabase()
+	srv := NewServer(api, db, nil, "../..")
+
+	start := time.Now().Add(-24 * time.Hour)
+	for i, status := range []string{"failed", "passed"} {
+		id := "flaky-" + status
+		db.InsertExecution(testkube.Execution{ID: id, WorkflowName: "e2e", StartTime: start.Add(time.Duration(i) * time.Hour)})
+		db.InsertTestCase(database.TestCase{ExecutionID: id, TestName: "Checkout", Status: status})
+	}
+
+	req, err := http.NewRequest("GET", "/reports/flakiness?days=14", nil)
+	assert.NoError(t, err)
+	rr := httptest.NewRecorder()
+	srv.Router().ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Contains(t, rr.Body.String(), "Checkout")
+
+	req, err = http.NewRequest("GET", "/reports/flakiness?format=md", nil)
+	assert.NoError(t, err)
+	rr = httptest.NewRecorder()
+	srv.Router().ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Contains(t, rr.Header().Get("Content-Type"), "text/markdown")
+	assert.Contains(t, rr.Body.String(), "| 1 | Checkout | e2e | 50% | 1 / 2 |")
+}
+
+func TestHandleEnvironmentSLAReport(t *testing.T) {
+	srv := NewServer(testkube.NewMockClient(), database.NewMockDatabase(), nil, "../..")
+
+	req, err := http.NewRequest("GET", "/reports/environments?days=7", nil)
+	assert.NoError(t, err)
+	rr := httptest.NewRecorder()
+	srv.Router().ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Contains(t, rr.Body.String(), "Environment SLA Report")
+	assert.Contains(t, rr.Body.String(), "ready within 5m")
+
+	t.Setenv("ENVIRONMENT_PROVISION_SLO", "10m")
+	req, err = http.NewRequest("GET", "/api/v1/reports/environments", nil)
+	assert.NoError(t, err)
+	rr = httptest.NewRecorder()
+	srv.Router().ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	var report struct {
+		SLO time.Duration
+	}
+	assert.NoError(t, json.NewDecoder(rr.Body).Decode(&report))
+	assert.Equal(t, 10*time.Minute, report.SLO)
+}
+
+func TestHandleComparisonReport(t *testing.T) {
+	db := database.NewMockDatabase()
+	srv := NewServer(testkube.NewMockClient(), db, nil, "../..")
+	assert.NoError(t, srv.suites.Import(json.RawMessage(`{"suites": [{"name": "shop", "workflows": ["checkout-*"]}]}`)))
+
+	now := time.Now()
+	db.InsertExecution(testkube.Execution{ID: "before", WorkflowName: "checkout-e2e", Status: "passed", StartTime: now.AddDate(0, 0, -10), Duration: time.Minute})
+	db.InsertTestCase(database.TestCase{ExecutionID: "before", TestName: "Pays by card", Status: "passed"})
+	db.InsertExecution(testkube.Execution{ID: "after", WorkflowName: "checkout-e2e", Status: "failed", StartTime: now.AddDate(0, 0, -2), Duration: 2 * time.Minute})
+	db.InsertTestCase(database.TestCase{ExecutionID: "after", TestName: "Pays by card", Status: "failed", ErrorMessage: "card declined"})
+
+	rr := httptest.NewRecorder()
+	srv.Router().ServeHTTP(rr, httptest.NewRequest("GET", "/reports/compare", nil))
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Contains(t, rr.Body.String(), `<option value="shop"`)
+
+	rr = httptest.NewRecorder()
+	srv.Router().ServeHTTP(rr, httptest.NewRequest("GET", "/reports/compare?suite=shop", nil))
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Contains(t, rr.Body.String(), "Pays by card")
+	assert.Contains(t, rr.Body.String(), "card declined")
+
+	rr = httptest.NewRecorder()
+	srv.Router().ServeHTTP(rr, httptest.NewRequest("GET", "/api/v1/reports/compare?workflow=checkout-e2e", nil))
+	assert.Equal(t, http.StatusOK, rr.Code)
+	var report reports.ComparisonReport
+	assert.NoError(t, json.NewDecoder(rr.Body).Decode(&report))
+	assert.Equal(t, 1, report.Previous.Runs)
+	assert.Equal(t, 100.0, report.Previous.PassRate)
+	assert.Equal(t, 0.0, report.Current.PassRate)
+	assert.Equal(t, 2*time.Minute, report.Current.P95Duration)
+
+	// Explicit windows: nothing ran in the previous one
+	rr = httptest.NewRecorder()
+	srv.Router().ServeHTTP(rr, httptest.NewRequest("GET", "/api/v1/reports/compare?workflow=checkout-e2e&from="+now.AddDate(0, 0, -3).Format(time.DateOnly)+"&prevFrom=2020-01-01&prevTo=2020-01-07", nil))
+	assert.Equal(t, http.StatusOK, rr.Code)
+	report = reports.ComparisonReport{}
+	assert.NoError(t, json.NewDecoder(rr.Body).Decode(&report))
+	assert.Equal(t, 0, report.Previous.Runs)
+	assert.Equal(t, 1, report.Current.Runs)
+
+	for url, code := range map[string]int{
+		"/api/v1/reports/compare":                                                     http.StatusBadRequest,
+		"/api/v1/reports/compare?suite=nope":                                          http.StatusNotFound,
+		"/api/v1/reports/compare?suite=shop&workflow=checkout-e2e":                    http.StatusBadRequest,
+		"/api/v1/reports/compare?workflow=checkout-e2e&prevFrom=2020-01-01":           http.StatusBadRequest,
+		"/api/v1/reports/compare?workflow=checkout-e2e&from=2026-02-01&to=2026-01-01": http.StatusBadRequest,
+	} {
+		rr = httptest.NewRecorder()
+		srv.Router().ServeHTTP(rr, httptest.NewRequest("GET", url, nil))
+		assert.Equal(t, code, rr.Code, url)
+	}
+}
+
+func TestTableStatePersistsPerUser(t *testing.T) {
+	db := database.NewMockDatabase()
+	srv := NewServer(testkube.NewMockClient(), db, nil, "../..")
+	for _, tc := range []database.TestCase{
+		{ExecutionID: "exec-1", TestName: "Login", Status: "passed", DurationMs: 120},
+		{ExecutionID: "exec-1", TestName: "Checkout", Status: "failed", DurationMs: 900, ErrorMessage: "timeout"},
+		{ExecutionID: "exec-1", TestName: "Search", Status: "passed", DurationMs: 1500},
+	} {
+		db.InsertTestCase(tc)
+	}
+
+	request := func(user, url string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("GET", url, nil)
+		req.Header.Set("X-Forwarded-User", user)
+		rr := httptest.NewRecorder()
+		srv.Router().ServeHTTP(rr, req)
+		return rr
+	}
+
+	rr := request("alice", "/executions/exec-1?f_status=passed&sort=duration&dir=desc")
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Contains(t, rr.Body.String(), "2 of 3")
+
+	// Alice's filter and sort stick for later requests and exports
+	rr = request("alice", "/executions/exec-1?format=csv")
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Contains(t, rr.Header().Get("Content-Type"), "text/csv")
+	assert.Equal(t, "Test Name,Status,Duration,Retries,Message\nSearch,passed,1500ms,0,\nLogin,passed,120ms,0,\n", rr.Body.String())
+
+	// Bob gets the defaults: failures first
+	rr = request("bob", "/executions/exec-1?format=csv")
+	assert.True(t, strings.HasPrefix(rr.Body.String(), "Test Name,Status,Duration,Retries,Message\nCheckout,failed"))
+
+	rr = request("alice", "/api/v1/tables/testcases/state")
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Contains(t, rr.Body.String(), `"sort":"duration"`)
+
+	req := httptest.NewRequest("DELETE", "/api/v1/tables/testcases/state", nil)
+	req.Header.Set("X-Forwarded-User", "alice")
+	rr = httptest.NewRecorder()
+	srv.Router().ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusNoContent, rr.Code)
+
+	rr = request("alice", "/executions/exec-1")
+	assert.Contains(t, rr.Body.String(), "3 of 3")
+
+	rr = request("alice", "/api/v1/tables/nope/state")
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+}
+
+func TestInfraCauseAnnotation(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"items": [{
+			"metadata": {"uid": "1"},
+			"involvedObject": {"kind": "Node", "name": "node-a"},
+			"reason": "NodeHasInsufficientMemory",
+			"lastTimestamp": "` + time.Now().UTC().Format(time.RFC3339) + `"
+		}]}`))
+	}))
+	defer ts.Close()
+
+	srv := NewServer(testkube.NewMockClient(), database.NewMockDatabase(), nil, "../..")
+	srv.clusterEvents = kube.NewEventHistory(kube.NewClient(ts.URL, "", nil))
+	assert.NoError(t, srv.clusterEvents.Poll(context.Background()))
+
+	// exec-0 is a failed execution that started just now
+	rr := httptest.NewRecorder()
+	srv.Router().ServeHTTP(rr, httptest.NewRequest("GET", "/executions/exec-0", nil))
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Contains(t, rr.Body.String(), "Possible infra cause")
+	assert.Contains(t, rr.Body.String(), "NodeHasInsufficientMemory Node node-a")
+
+	// Passing executions are never annotated
+	rr = httptest.NewRecorder()
+	srv.Router().ServeHTTP(rr, httptest.NewRequest("GET", "/api/v1/executions/exec-1/infra-events", nil))
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Contains(t, rr.Body.String(), `"possibleInfraCause":false`)
+}
+
+func TestSyntheticChecks(t *testing.T) {
+	target := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer target.Close()
+
+	srv := NewServer(testkube.NewMockClient(), database.NewMockDatabase(), nil, "../..")
+
+	form := url.Values{"name": {"staging"}, "url": {target.URL}}
+	req := httptest.NewRequest("POST", "/synthetics", strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	rr := httptest.NewRecorder()
+	srv.Router().ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.NotContains(t, rr.Body.String(), "<html")
+	assert.Contains(t, rr.Body.String(), "staging")
+	assert.Contains(t, rr.Body.String(), "expected status 200, got 503")
+
+	checks := srv.synthetics.Checks()
+	assert.Len(t, checks, 1)
+
+	rr = httptest.NewRecorder()
+	srv.Router().ServeHTTP(rr, httptest.NewRequest("GET", "/synthetics", nil))
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Contains(t, rr.Body.String(), "0.0%")
+
+	rr = httptest.NewRecorder()
+	srv.Router().ServeHTTP(rr, httptest.NewRequest("GET", "/api/v1/synthetics/"+checks[0].ID+"/results", nil))
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Contains(t, rr.Body.String(), `"statusCode":503`)
+
+	req = httptest.NewRequest("POST", "/synthetics", strings.NewReader("url=not-a-url"))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	rr = httptest.NewRecorder()
+	srv.Router().ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+}
+
+func TestRunChecksDependencies(t *testing.T) {
+	api := testkube.NewMockClient()
+	srv := NewServer(api, database.NewMockDatabase(), nil, "../..")
+	assert.NoError(t, srv.dependencies.Import([]byte(`{"rules": [
+		{"workflow": "frontend-e2e", "dependencies": [{"name": "db", "target": "postgres://127.0.0.1:1/app"}]},
+		{"workflow": "backend-*", "mode": "tag", "dependencies": [{"name": "db", "target": "postgres://127.0.0.1:1/app"}]}
+	]}`)))
+
+	rr := httptest.NewRecorder()
+	srv.Router().ServeHTTP(rr, httptest.NewRequest("POST", "/workflows/frontend-e2e/run", nil))
+	assert.Equal(t, http.StatusConflict, rr.Code)
+	assert.Contains(t, rr.Body.String(), "Dependencies of frontend-e2e are unhealthy: db")
+
+	rr = httptest.NewRecorder()
+	srv.Router().ServeHTTP(rr, httptest.NewRequest("POST", "/workflows/backend-integration/run", nil))
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Contains(t, rr.Header().Get("HX-Trigger"), "dependencies are unhealthy: db")
+
+	latest, err := api.GetExecutions(testkube.ListOptions{PageSize: 1})
+	assert.NoError(t, err)
+	assert.Equal(t, "db", latest[0].Labels["dependencies-unhealthy"])
+
+	// A quota warning is shown alongside the unhealthy dependencies
+	message := runStartedMessage("namespace CPU quota is 95% used", "db")
+	assert.Contains(t, message, "dependencies are unhealthy: db")
+	assert.Contains(t, message, "Warning: namespace CPU quota is 95% used")
+}
+
+func TestArtifactDiff(t *testing.T) {
+	srv := NewServer(testkube.NewMockClient(), database.NewMockDatabase(), nil, "../..")
+
+	rr := httptest.NewRecorder()
+	srv.Router().ServeHTTP(rr, httptest.NewRequest("GET", "/executions/exec-16/diff?path=results.json", nil))
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Contains(t, rr.Body.String(), "No differences")
+
+	// exec-0 failed after a passing run, so its report shows the failed test
+	rr = httptest.NewRecorder()
+	srv.Router().ServeHTTP(rr, httptest.NewRequest("GET", "/executions/exec-0/diff?path=results.json", nil))
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Contains(t, rr.Body.String(), "$.suites[1].specs[1].tests[0].results[0].status")
+
+	rr = httptest.NewRecorder()
+	srv.Router().ServeHTTP(rr, httptest.NewRequest("GET", "/executions/exec-0/diff?path=screenshot.png&against=exec-7", nil))
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Contains(t, rr.Body.String(), "/executions/exec-0/diff/image?path=screenshot.png&against=exec-7")
+
+	rr = httptest.NewRecorder()
+	srv.Router().ServeHTTP(rr, httptest.NewRequest("GET", "/executions/exec-0/diff/image?path=screenshot.png&against=exec-7", nil))
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, "image/png", rr.Header().Get("Content-Type"))
+
+	rr = httptest.NewRecorder()
+	srv.Router().ServeHTTP(rr, httptest.NewRequest("GET", "/api/v1/executions/exec-0/diff?path=screenshot.png", nil))
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Contains(t, rr.Body.String(), `"kind":"image"`)
+	assert.Contains(t, rr.Body.String(), `"diffPixels"`)
+
+	rr = httptest.NewRecorder()
+	srv.Router().ServeHTTP(rr, httptest.NewRequest("GET", "/executions/exec-0/diff?path=playwright-report.zip", nil))
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+}
+
+func TestEvidenceBundle(t *testing.T) {
+	api := testkube.NewMockClient()
+	srv := NewServer(api, database.NewMockDatabase(), nil, "../..")
+	scans, _ := api.GetExecutions(testkube.ListOptions{Workflow: "cluster-security", PageSize: 1})
+	assert.Len(t, scans, 1)
+	id := scans[0].ID
+
+	rr := httptest.NewRecorder()
+	srv.Router().ServeHTTP(rr, httptest.NewRequest("POST", "/api/v1/executions/"+id+"/evidence", nil))
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, "application/zip", rr.Header().Get("Content-Type"))
+	bundle := rr.Body.Bytes()
+	assert.Equal(t, evidence.Digest(bundle), rr.Header().Get("X-Evidence-Digest"))
+
+	zr, err := zip.NewReader(bytes.NewReader(bundle), int64(len(bundle)))
+	assert.NoError(t, err)
+	var names []string
+	for _, f := range zr.File {
+		names = append(names, f.Name)
+	}
+	assert.Contains(t, names, "logs.txt")
+	assert.Contains(t, names, "findings/results.json")
+
+	verify := func(body []byte) map[string]interface{} {
+		rr := httptest.NewRecorder()
+		srv.Router().ServeHTTP(rr, httptest.NewRequest("POST", "/api/v1/evidence/verify", bytes.NewReader(body)))
+		var result map[string]interface{}
+		assert.NoError(t, json.NewDecoder(rr.Body).Decode(&result))
+		return result
+	}
+	assert.Equal(t, true, verify(bundle)["valid"])
+	tampered := append([]byte(nil), bundle...)
+	tampered[len(tampered)/2] ^= 0xff
+	assert.Equal(t, false, verify(tampered)["valid"])
+
+	rr = httptest.NewRecorder()
+	srv.Router().ServeHTTP(rr, httptest.NewRequest("GET", "/api/v1/executions/"+id+"/evidence", nil))
+	assert.Contains(t, rr.Body.String(), evidence.Digest(bundle))
+
+	rr = httptest.NewRecorder()
+	srv.Router().ServeHTTP(rr, httptest.NewRequest("GET", "/api/v1/evidence/key", nil))
+	assert.Contains(t, rr.Body.String(), `"publicKey"`)
+}
+
+func TestArtifactIntegrity(t *testing.T) {
+	api := testkube.NewMockClient()
+	db := database.NewMockDatabase()
+	srv := NewServer(api, db, nil, "../..")
+
+	results, _ := api.DownloadArtifact("exec-1", "results.json")
+	assert.NoError(t, db.InsertArtifactManifest("exec-1", []database.ArtifactRecord{
+		{ExecutionID: "exec-1", Name: "results.json", Path: "results.json", Size: 1024, SHA256: artifacts.Checksum(results)},
+		{ExecutionID: "exec-1", Name: "screenshot.png", Path: "screenshot.png", Size: 512 * 1024, SHA256: artifacts.Checksum([]byte("original"))},
+		{ExecutionID: "exec-1", Name: "trivy.json", Path: "trivy.json", Size: 2048, SHA256: artifacts.Checksum([]byte("scan"))},
+	}))
+
+	rr := httptest.NewRecorder()
+	srv.Router().ServeHTTP(rr, httptest.NewRequest("GET", "/executions/exec-1/artifacts/results.json", nil))
+	assert.Equal(t, "verified", rr.Header().Get("X-Artifact-Integrity"))
+
+	rr = httptest.NewRecorder()
+	srv.Router().ServeHTTP(rr, httptest.NewRequest("GET", "/executions/exec-1/artifacts/screenshot.png", nil))
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, "mismatch", rr.Header().Get("X-Artifact-Integrity"))
+
+	rr = httptest.NewRecorder()
+	srv.Router().ServeHTTP(rr, httptest.NewRequest("GET", "/executions/exec-1/artifacts", nil))
+	assert.Contains(t, rr.Body.String(), "<code>trivy.json</code> was recorded when this execution was ingested but is no longer available")
+	assert.NotContains(t, rr.Body.String(), "has changed since")
+	assert.Contains(t, rr.Body.String(), "Verify checksums")
+
+	rr = httptest.NewRecorder()
+	srv.Router().ServeHTTP(rr, httptest.NewRequest("GET", "/api/v1/executions/exec-1/artifacts/verify", nil))
+	assert.Equal(t, http.StatusOK, rr.Code)
+	var checks []artifactVerification
+	assert.NoError(t, json.NewDecoder(rr.Body).Decode(&checks))
+	integrity := make(map[string]artifacts.Integrity)
+	for _, c := range checks {
+		integrity[c.Path] = c.Integrity
+	}
+	assert.Equal(t, map[string]artifacts.Integrity{
+		"playwright-report.zip": artifacts.IntegrityUnrecorded,
+		"results.json":          artifacts.IntegrityVerified,
+		"screenshot.png":        artifacts.IntegrityMismatch,
+		"trivy.json":            artifacts.IntegrityMissing,
+	}, integrity)
+}
+
+func TestArtifactDownloadStreamsIntegrityTrailer(t *testing.T) {
+	api := testkube.NewMockClient()
+	db := database.NewMockDatabase()
+	srv := NewServer(api, db, nil, "../..")
+	results, _ := api.DownloadArtifact("exec-1", "results.json")
+	assert.NoError(t, db.InsertArtifactManifest("exec-1", []database.ArtifactRecord{
+		{ExecutionID: "exec-1", Name: "results.json", Path: "results.json", Size: int64(len(results)), SHA256: artifacts.Checksum(results)},
+	}))
+	ts := httptest.NewServer(srv.Router())
+	defer ts.Close()
+
+	resp, err := http.Get(ts.URL + "/executions/exec-1/artifacts/results.json")
+	assert.NoError(t, err)
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(resp.Body)
+	assert.Equal(t, results, body)
+	assert.Equal(t, "verified", resp.Trailer.Get("X-Artifact-Integrity"))
+
+	// Without a recorded checksum there's nothing to wait for
+	resp, err = http.Get(ts.URL + "/executions/exec-1/artifacts/report.html")
+	assert.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, "unrecorded", resp.Header.Get("X-Artifact-Integrity"))
+}
+
+func TestVisualBaselineReview(t *testing.T) {
+	srv := NewServer(testkube.NewMockClient(), database.NewMockDatabase(), nil, "../..")
+	serve := func(method, target, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, target, strings.NewReader(body))
+		if method == "POST" && !strings.HasPrefix(body, "{") {
+			req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		}
+		rr := httptest.NewRecorder()
+		srv.Router().ServeHTTP(rr, req)
+		return rr
+	}
+
+	// No baseline yet: the screenshot awaits approval
+	rr := serve("GET", "/executions/exec-16/visual", "")
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Contains(t, rr.Body.String(), "No baseline yet")
+
+	rr = serve("POST", "/executions/exec-16/visual/approve", "path=screenshot.png")
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Contains(t, rr.Body.String(), "status-approved")
+
+	// The same screenshot matches; a moved button is flagged as a regression
+	rr = serve("GET", "/api/v1/executions/exec-32/visual", "")
+	assert.Contains(t, rr.Body.String(), `"verdict":"match"`)
+
+	rr = serve("GET", "/executions/exec-0/visual", "")
+	assert.Contains(t, rr.Body.String(), "differ from the baseline")
+	assert.Contains(t, rr.Body.String(), "/executions/exec-0/visual/diff?path=screenshot.png")
+
+	rr = serve("GET", "/executions/exec-0/visual/diff?path=screenshot.png", "")
+	assert.Equal(t, "image/png", rr.Header().Get("Content-Type"))
+	rr = serve("GET", "/executions/exec-0/visual/baseline?path=screenshot.png", "")
+	assert.Equal(t, "image/png", rr.Header().Get("Content-Type"))
+
+	rr = serve("POST", "/api/v1/executions/exec-0/visual/reject", `{"path":"screenshot.png"}`)
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Contains(t, rr.Body.String(), `"verdict":"rejected"`)
+
+	// Only changed screenshots can be rejected
+	rr = serve("POST", "/executions/exec-32/visual/reject", "path=screenshot.png")
+	assert.Equal(t, http.StatusConflict, rr.Code)
+
+	rr = serve("GET", "/api/v1/visual/baselines", "")
+	assert.Contains(t, rr.Body.String(), `"executionId":"exec-16"`)
+}
+
+func TestTestImpactAPI(t *testing.T) {
+	srv := NewServer(testkube.NewMockClient(), database.NewMockDatabase(), nil, "../..")
+
+	rr := httptest.NewRecorder()
+	body := `{"workflow":"frontend-e2e","test":"login works","files":["src/auth.ts"]}`
+	srv.Router().ServeHTTP(rr, httptest.NewRequest("POST", "/api/v1/test-impact/coverage", strings.NewReader(body)))
+	assert.Equal(t, http.StatusNoContent, rr.Code)
+
+	// Plain `git diff --name-only` output
+	rr = httptest.NewRecorder()
+	req := httptest.NewRequest("POST", "/api/v1/test-impact", strings.NewReader("src/auth.ts\nsrc/other.ts\n"))
+	req.Header.Set("Content-Type", "text/plain")
+	srv.Router().ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusOK, rr.Code)
+
+	var result struct {
+		ChangedFiles int `json:"changedFiles"`
+		Workflows    []struct {
+			Name string `json:"name"`
+		} `json:"workflows"`
+		Unmatched []string `json:"unmatched"`
+		RunAll    bool     `json:"runAll"`
+	}
+	assert.NoError(t, json.NewDecoder(rr.Body).Decode(&result))
+	assert.Equal(t, 2, result.ChangedFiles)
+	if assert.Len(t, result.Workflows, 1) {
+		assert.Equal(t, "frontend-e2e", result.Workflows[0].Name)
+	}
+	assert.Equal(t, []string{"src/other.ts"}, result.Unmatched)
+	assert.True(t, result.RunAll)
+
+	rr = httptest.NewRecorder()
+	req = httptest.NewRequest("POST", "/api/v1/test-impact", strings.NewReader(`{"changedFiles":[]}`))
+	req.Header.Set("Content-Type", "application/json")
+	srv.Router().ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+}
+
+func TestRunQueuesAtConcurrencyLimit(t *testing.T) {
+	srv := NewServer(testkube.NewMockClient(), database.NewMockDatabase(), nil, "../..")
+	assert.NoError(t, srv.runs.Import([]byte(`{"limits": [{"workflow": "api-load-test", "maxConcurrent": 1, "maxQueued": 1}]}`)))
+
+	run := func() *httptest.ResponseRecorder {
+		rr := httptest.NewRecorder()
+		srv.Router().ServeHTTP(rr, httptest.NewRequest("POST", "/workflows/api-load-test/run", nil))
+		return rr
+	}
+
+	rr := run()
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Contains(t, rr.Header().Get("HX-Trigger"), "executionStarted")
+
+	rr = run()
+	assert.Equal(t, http.StatusAccepted, rr.Code)
+	assert.Contains(t, rr.Header().Get("HX-Trigger"), "run queued at position 1")
+
+	rr = run()
+	assert.Equal(t, http.StatusTooManyRequests, rr.Code)
+
+	rr = httptest.NewRecorder()
+	srv.Router().ServeHTTP(rr, httptest.NewRequest("GET", "/workflows/api-load-test", nil))
+	assert.Contains(t, rr.Body.String(), "1 queued")
+
+	var entries []runqueue.Entry
+	rr = httptest.NewRecorder()
+	srv.Router().ServeHTTP(rr, httptest.NewRequest("GET", "/api/v1/queue", nil))
+	assert.NoError(t, json.NewDecoder(rr.Body).Decode(&entries))
+	if assert.Len(t, entries, 1) {
+		rr = httptest.NewRecorder()
+		srv.Router().ServeHTTP(rr, httptest.NewRequest("DELETE", "/api/v1/queue/"+entries[0].ID, nil))
+		assert.Equal(t, http.StatusNoContent, rr.Code)
+	}
+}
+
+func TestRunOutsideRunWindow(t *testing.T) {
+	srv := NewServer(testkube.NewMockClient(), database.NewMockDatabase(), nil, "../..")
+	// Deny every minute of the day except the next one
+	next := time.Now().UTC().Add(2 * time.Minute).Truncate(time.Minute)
+	window := fmt.Sprintf(`{"start": "%s", "end": "%s"}`, next.Format("15:04"), next.Add(-time.Minute).Format("15:04"))
+
+	assert.NoError(t, srv.runWindows.Import([]byte(`{"rules": [{"workflow": "api-load-test", "mode": "reject", "deny": [`+window+`]}]}`)))
+	rr := httptest.NewRecorder()
+	srv.Router().ServeHTTP(rr, httptest.NewRequest("POST", "/workflows/api-load-test/run", nil))
+	assert.Equal(t, http.StatusConflict, rr.Code)
+	assert.Contains(t, rr.Body.String(), "next allowed at "+next.Add(-time.Minute).Format(time.RFC3339))
+	assert.NotEmpty(t, rr.Header().Get("Retry-After"))
+
+	assert.NoError(t, srv.runWindows.Import([]byte(`{"rules": [{"workflow": "api-load-test", "deny": [`+window+`]}]}`)))
+	rr = httptest.NewRecorder()
+	srv.Router().ServeHTTP(rr, httptest.NewRequest("POST", "/workflows/api-load-test/run", nil))
+	assert.Equal(t, http.StatusAccepted, rr.Code)
+	assert.Contains(t, rr.Header().Get("HX-Trigger"), "outside its run window")
+
+	rr = httptest.NewRecorder()
+	srv.Router().ServeHTTP(rr, httptest.NewRequest("GET", "/api/v1/workflows/api-load-test/run-window", nil))
+	assert.Contains(t, rr.Body.String(), `"allowed":false`)
+}
+
+func TestQueuedRunPriority(t *testing.T) {
+	srv := NewServer(testkube.NewMockClient(), database.NewMockDatabase(), nil, "../..")
+	assert.NoError(t, srv.runs.Import([]byte(`{"limits": [{"workflow": "api-load-test", "maxConcurrent": 1}]}`)))
+
+	run := func(priority string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("POST", "/workflows/api-load-test/run", strings.NewReader("priority="+priority))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		rr := httptest.NewRecorder()
+		srv.Router().ServeHTTP(rr, req)
+		return rr
+	}
+	assert.Equal(t, http.StatusOK, run("critical").Code)
+	assert.Equal(t, http.StatusAccepted, run("bulk").Code)
+	assert.Equal(t, http.StatusBadRequest, run("urgent").Code)
+
+	entries := srv.runs.Entries()
+	if !assert.Len(t, entries, 1) {
+		return
+	}
+	assert.Equal(t, runqueue.PriorityBulk, entries[0].Priority)
+
+	rr := httptest.NewRecorder()
+	srv.Router().ServeHTTP(rr, httptest.NewRequest("GET", "/queue", nil))
+	assert.Contains(t, rr.Body.String(), `hx-post="/queue/`+entries[0].ID+`/priority"`)
+
+	// Only admins may reprioritize once ADMIN_USERS is set
+	srv.admins = parseAdmins("alice, bob")
+	req := httptest.NewRequest("PUT", "/api/v1/queue/"+entries[0].ID+"/priority", strings.NewReader(`{"priority":"critical"}`))
+	req.Header.Set("X-Forwarded-User", "mallory")
+	rr = httptest.NewRecorder()
+	srv.Router().ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusForbidden, rr.Code)
+
+	req = httptest.NewRequest("PUT", "/api/v1/queue/"+entries[0].ID+"/priority", strings.NewReader(`{"priority":"critical"}`))
+	req.Header.Set("X-Forwarded-User", "bob")
+	rr = httptest.NewRecorder()
+	srv.Router().ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, runqueue.PriorityCritical, srv.runs.Entries()[0].Priority)
+}
+
+func TestRetryEnvironmentStep(t *testing.T) {
+	srv := NewServer(testkube.NewMockClient(), database.NewMockDatabase(), nil, "../..")
+	env, err := srv.envMgr.Create(context.Background(), environments.CreateEnvironmentRequest{Name: "retry-me", Type: environments.TypeEphemeral})
+	assert.NoError(t, err)
+
+	retry := func(id, step, user string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("POST", "/api/v1/environments/"+id+"/steps/"+step+"/retry", nil)
+		req.Header.Set("X-Forwarded-User", user)
+		rr := httptest.NewRecorder()
+		srv.Router().ServeHTTP(rr, req)
+		return rr
+	}
+	assert.Equal(t, http.StatusNotFound, retry("missing", environments.StepDatabase, "").Code)
+
+	// Environments still provisioning can't be retried
+	rr := retry(env.ID, environments.StepDatabase, "")
+	assert.Equal(t, http.StatusConflict, rr.Code)
+	assert.Contains(t, rr.Body.String(), "only failed environments can be retried")
+
+	srv.admins = parseAdmins("alice")
+	assert.Equal(t, http.StatusForbidden, retry(env.ID, environments.StepDatabase, "mallory").Code)
+
+	rr = httptest.NewRecorder()
+	srv.Router().ServeHTTP(rr, httptest.NewRequest("GET", "/environments", nil))
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Contains(t, rr.Body.String(), `<span class="step-name">resources</span>`)
+}
+
+func TestEnvironmentTeams(t *testing.T) {
+	srv := NewServer(testkube.NewMockClient(), database.NewMockDatabase(), nil, "../..")
+	srv.admins = parseAdmins("admin")
+
+	req := httptest.NewRequest("POST", "/api/v1/environments", strings.NewReader(`{"name":"payments-demo","team":"payments"}`))
+	req.Header.Set("X-Forwarded-User", "alice")
+	rr := httptest.NewRecorder()
+	srv.Router().ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusCreated, rr.Code)
+	var env environments.Environment
+	assert.NoError(t, json.NewDecoder(rr.Body).Decode(&env))
+	assert.Equal(t, "alice", env.Owner, "the owner defaults to the signed-in user")
+	assert.Equal(t, "payments", env.Team)
+	_, err := srv.envMgr.Create(context.Background(), environments.CreateEnvironmentRequest{Name: "solo", Owner: "carol", Type: environments.TypeEphemeral})
+	assert.NoError(t, err)
+
+	rr = httptest.NewRecorder()
+	srv.Router().ServeHTTP(rr, httptest.NewRequest("GET", "/api/v1/environments?team=payments", nil))
+	var listed []environments.Environment
+	assert.NoError(t, json.NewDecoder(rr.Body).Decode(&listed))
+	if assert.Len(t, listed, 1) {
+		assert.Equal(t, "payments-demo", listed[0].Name)
+	}
+
+	as := func(method, path, user, groups string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(`{"hours": 2}`))
+		req.Header.Set("X-Forwarded-User", user)
+		if groups != "" {
+			req.Header.Set("X-Forwarded-Groups", groups)
+		}
+		rr := httptest.NewRecorder()
+		srv.Router().ServeHTTP(rr, req)
+		return rr
+	}
+	extend := "/api/v1/environments/" + env.ID + "/extend"
+	assert.Equal(t, http.StatusForbidden, as("POST", extend, "bob", "").Code)
+	assert.Equal(t, http.StatusForbidden, as("POST", extend, "bob", "search, growth").Code)
+	assert.Equal(t, http.StatusOK, as("POST", extend, "bob", "search, payments").Code)
+
+	rr = as("GET", "/environments?team=payments", "bob", "payments")
+	assert.Contains(t, rr.Body.String(), "payments-demo")
+	assert.NotContains(t, rr.Body.String(), "<h3>solo</h3>")
+	assert.Contains(t, rr.Body.String(), `deleteEnv('`+env.ID+`'`)
+	rr = as("GET", "/environments", "bob", "payments")
+	assert.Contains(t, rr.Body.String(), "<h3>solo</h3>")
+	assert.Equal(t, 1, strings.Count(rr.Body.String(), "deleteEnv('"), "bob can only delete their team's environment")
+
+	assert.Equal(t, http.StatusForbidden, as("DELETE", "/api/v1/environments/"+env.ID, "mallory", "").Code)
+	assert.Equal(t, http.StatusNoContent, as("DELETE", "/api/v1/environments/"+env.ID, "bob", "payments").Code)
+}
+
+// recordingCommenter keeps the comments posted on pull requests
+type recordingCommenter struct {
+	comments map[int]string
+}
+
+func (c *recordingCommenter) Comment(ctx context.Context, pr previews.PullRequest, body string) error {
+	c.comments[pr.Number] = body
+	return nil
+}
+
+func TestPullRequestHook(t *testing.T) {
+	srv := NewServer(testkube.NewMockClient(), database.NewMockDatabase(), nil, "../..")
+	commenter := &recordingCommenter{comments: map[int]string{}}
+	srv.commenter = commenter
+
+	hook := func(action, secret string) *httptest.ResponseRecorder {
+		body := `{"action":"` + action + `","pull_request":{"number":12,"html_url":"https://github.com/acme/shop/pull/12",
+			"head":{"ref":"feature/cart","sha":"abc123"},"user":{"login":"alice"}},"repository":{"full_name":"acme/shop"}}`
+		mac := hmac.New(sha256.New, []byte(secret))
+		mac.Write([]byte(body))
+		req := httptest.NewRequest("POST", "/hooks/pr", strings.NewReader(body))
+		req.Header.Set("X-GitHub-Event", "pull_request")
+		req.Header.Set("X-Hub-Signature-256", "sha256="+hex.EncodeToString(mac.Sum(nil)))
+		rr := httptest.NewRecorder()
+		srv.Router().ServeHTTP(rr, req)
+		return rr
+	}
+
+	assert.Equal(t, http.StatusServiceUnavailable, hook("opened", "").Code, "webhooks are refused without a secret")
+	srv.previews = previews.Config{Secret: "s3cret", TTL: 48 * time.Hour}
+	assert.Equal(t, http.StatusUnauthorized, hook("opened", "wrong").Code)
+
+	rr := hook("opened", "s3cret")
+	assert.Equal(t, http.StatusCreated, rr.Code)
+	var resp previewHookResponse
+	assert.NoError(t, json.NewDecoder(rr.Body).Decode(&resp))
+	if assert.NotNil(t, resp.Environment) {
+		assert.Equal(t, "pr-12-shop", resp.Environment.Name)
+		assert.Equal(t, "alice", resp.Environment.Owner)
+		assert.Equal(t, "feature/cart", resp.Environment.Branch)
+		assert.Equal(t, "https://github.com/acme/shop/pull/12", resp.Environment.PullRequest)
+		assert.Contains(t, commenter.comments[12], resp.Environment.URL)
+	}
+
+	// A push keeps the same environment
+	rr = hook("synchronize", "s3cret")
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Len(t, srv.previewEnvironments(previews.PullRequest{URL: resp.Environment.PullRequest}), 1)
+
+	assert.Equal(t, http.StatusOK, hook("labeled", "s3cret").Code)
+	assert.Equal(t, http.StatusOK, hook("closed", "s3cret").Code)
+	assert.Empty(t, srv.previewEnvironments(previews.PullRequest{URL: resp.Environment.PullRequest}), "closing tears the environment down")
+}
+
+func TestHoursUntil(t *testing.T) {
+	now := time.Now()
+	assert.Equal(t, 0, hoursUntil(now.Add(5*time.Hour), now.Add(4*time.Hour)))
+	assert.Equal(t, 3, hoursUntil(now.Add(time.Hour), now.Add(3*time.Hour+time.Minute)))
+}
+
+func TestCreateEnvironmentWithSubdomain(t *testing.T) {
+	srv := NewServer(testkube.NewMockClient(), database.NewMockDatabase(), nil, "../..")
+
+	create := func(body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("POST", "/api/v1/environments", strings.NewReader(body))
+		rr := httptest.NewRecorder()
+		srv.Router().ServeHTTP(rr, req)
+		return rr
+	}
+
+	rr := create(`{"name":"checkout","subdomain":"checkout-demo"}`)
+	assert.Equal(t, http.StatusCreated, rr.Code)
+	var env environments.Environment
+	assert.NoError(t, json.NewDecoder(rr.Body).Decode(&env))
+	assert.True(t, strings.HasPrefix(env.URL, "https://checkout-demo."))
+	if assert.NotNil(t, env.TLS) {
+		assert.Equal(t, environments.TLSWildcard, env.TLS.Mode)
+	}
+
+	assert.Equal(t, http.StatusConflict, create(`{"name":"other","subdomain":"checkout-demo"}`).Code)
+	assert.Equal(t, http.StatusBadRequest, create(`{"subdomain":"two.levels"}`).Code)
+	assert.Equal(t, http.StatusBadRequest, create(`{"template":"missing"}`).Code)
+
+	rr = httptest.NewRecorder()
+	srv.Router().ServeHTTP(rr, httptest.NewRequest("GET", "/api/v1/environment-templates", nil))
+	assert.Contains(t, rr.Body.String(), `"provisioner":"manifests"`)
+}
+
+func TestEnvironmentValues(t *testing.T) {
+	srv := NewServer(testkube.NewMockClient(), database.NewMockDatabase(), nil, "../..")
+	assert.NoError(t, srv.envMgr.Import(json.RawMessage(`{"templates": [{"name": "fern-chart", "provisioner": "helm",
+		"helm": {"chart": "fern", "values": {"replicaCount": 1, "image": {"tag": "latest"}}}}]}`)))
+	chart, err := srv.envMgr.Create(context.Background(), environments.CreateEnvironmentRequest{Name: "chart", Owner: "alice", Template: "fern-chart"})
+	assert.NoError(t, err)
+	plain, err := srv.envMgr.Create(context.Background(), environments.CreateEnvironmentRequest{Name: "plain"})
+	assert.NoError(t, err)
+
+	rr := httptest.NewRecorder()
+	srv.Router().ServeHTTP(rr, httptest.NewRequest("GET", "/environments/"+chart.ID+"/values", nil))
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Contains(t, rr.Body.String(), `name="value:replicaCount"`)
+	assert.Contains(t, rr.Body.String(), `name="new-key"`)
+
+	rr = httptest.NewRecorder()
+	srv.Router().ServeHTTP(rr, httptest.NewRequest("GET", "/api/v1/environments/"+plain.ID+"/values", nil))
+	assert.Equal(t, http.StatusConflict, rr.Code)
+
+	set := func(body, user string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("PUT", "/api/v1/environments/"+chart.ID+"/values", strings.NewReader(body))
+		req.Header.Set("X-Forwarded-User", user)
+		rr := httptest.NewRecorder()
+		srv.Router().ServeHTTP(rr, req)
+		return rr
+	}
+	assert.Equal(t, http.StatusBadRequest, set(`{"values": {"secret": "x"}}`, "").Code)
+	// The release is still being installed
+	assert.Equal(t, http.StatusConflict, set(`{"values": {"replicaCount": 2}}`, "").Code)
+
+	srv.admins = parseAdmins("bob")
+	assert.Equal(t, http.StatusForbidden, set(`{"values": {"replicaCount": 2}}`, "mallory").Code)
+	assert.Equal(t, http.StatusConflict, set(`{"values": {"replicaCount": 2}}`, "alice").Code)
+}
+
+func TestSelfTest(t *testing.T) {
+	srv := NewServer(testkube.NewMockClient(), database.NewMockDatabase(), nil, "../..")
+
+	rr := httptest.NewRecorder()
+	srv.Router().ServeHTTP(rr, httptest.NewRequest("GET", "/api/v1/admin/self-test", nil))
+	assert.Equal(t, http.StatusOK, rr.Code)
+	var report selfTestReport
+	assert.NoError(t, json.NewDecoder(rr.Body).Decode(&report))
+	assert.True(t, report.Passed)
+	statuses := make(map[string]string)
+	for _, c := range report.Checks {
+		statuses[c.Name] = c.Status
+	}
+	assert.Equal(t, selfTestPass, statuses["testkube-api"])
+	assert.Equal(t, selfTestPass, statuses["artifacts"])
+	assert.Equal(t, selfTestSkip, statuses["user-generator"])
+
+	rr = httptest.NewRecorder()
+	srv.Router().ServeHTTP(rr, httptest.NewRequest("POST", "/admin/self-test", nil))
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Contains(t, rr.Body.String(), "<td>evidence-signing</td>")
+
+	srv.admins = parseAdmins("alice")
+	rr = httptest.NewRecorder()
+	srv.Router().ServeHTTP(rr, httptest.NewRequest("GET", "/admin/self-test", nil))
+	assert.Equal(t, http.StatusForbidden, rr.Code)
+}
+
+func TestAdminPanel(t *testing.T) {
+	t.Setenv("TESTKUBE_API_TOKEN", "s3cret")
+	t.Setenv("DATABASE_URL", "postgres://dash:hunter2@db:5432/results")
+	t.Setenv("RUNTIME_SETTINGS_FILE", filepath.Join(t.TempDir(), "runtime.json"))
+	srv := NewServer(testkube.NewMockClient(), database.NewMockDatabase(), nil, "../..")
+
+	rr := httptest.NewRecorder()
+	srv.Router().ServeHTTP(rr, httptest.NewRequest("GET", "/admin", nil))
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Contains(t, rr.Body.String(), "TESTKUBE_API_TOKEN")
+	assert.NotContains(t, rr.Body.String(), "s3cret")
+	assert.NotContains(t, rr.Body.String(), "hunter2")
+
+	rr = httptest.NewRecorder()
+	srv.Router().ServeHTTP(rr, httptest.NewRequest("GET", "/api/v1/admin/status", nil))
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.NotContains(t, rr.Body.String(), "s3cret")
+	assert.Contains(t, rr.Body.String(), "postgres://dash:xxxxx@db:5432/results")
+
+	// Read-only mode rejects changes but leaves the admin pages writable
+	req := httptest.NewRequest("POST", "/admin/settings", strings.NewReader("readOnly=true"))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	rr = httptest.NewRecorder()
+	srv.Router().ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.True(t, srv.runtime.Settings().ReadOnly)
+
+	rr = httptest.NewRecorder()
+	srv.Router().ServeHTTP(rr, httptest.NewRequest("POST", "/workflows/api-health-check/run", nil))
+	assert.Equal(t, http.StatusServiceUnavailable, rr.Code)
+	rr = httptest.NewRecorder()
+	srv.Router().ServeHTTP(rr, httptest.NewRequest("GET", "/api/v1/flaky-tests", nil))
+	assert.Equal(t, http.StatusOK, rr.Code)
+
+	// Settings survive a restart
+	restarted := NewServer(testkube.NewMockClient(), database.NewMockDatabase(), nil, "../..")
+	assert.True(t, restarted.runtime.Settings().ReadOnly)
+
+	rr = httptest.NewRecorder()
+	srv.Router().ServeHTTP(rr, httptest.NewRequest("PUT", "/api/v1/admin/settings", strings.NewReader(`{"readOnly":false,"devMode":true}`)))
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, runtimeSettings{DevMode: true}, srv.runtime.Settings())
+
+	// Dev mode re-reads templates from disk
+	rr = httptest.NewRecorder()
+	srv.Router().ServeHTTP(rr, httptest.NewRequest("GET", "/admin", nil))
+	assert.Equal(t, http.StatusOK, rr.Code)
+
+	srv.admins = parseAdmins("alice")
+	rr = httptest.NewRecorder()
+	srv.Router().ServeHTTP(rr, httptest.NewRequest("PUT", "/api/v1/admin/settings", strings.NewReader(`{"readOnly":true}`)))
+	assert.Equal(t, http.StatusForbidden, rr.Code)
+
+	// Config bundles carry the runtime settings too, so only admins may
+	// import or sync them
+	bundle := `{"version":1,"sections":{"runtime":{"readOnly":true}}}`
+	for _, path := range []string{"/api/v1/config/import", "/api/v1/config/sync"} {
+		req = httptest.NewRequest("POST", path, strings.NewReader(bundle))
+		req.Header.Set("X-Forwarded-User", "mallory")
+		rr = httptest.NewRecorder()
+		srv.Router().ServeHTTP(rr, req)
+		assert.Equal(t, http.StatusForbidden, rr.Code, path)
+	}
+	assert.False(t, srv.runtime.Settings().ReadOnly)
+
+	req = httptest.NewRequest("POST", "/api/v1/config/import", strings.NewReader(bundle))
+	req.Header.Set("X-Forwarded-User", "alice")
+	rr = httptest.NewRecorder()
+	srv.Router().ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusNoContent, rr.Code)
+	assert.True(t, srv.runtime.Settings().ReadOnly)
+}
+
+func TestFeatureFlags(t *testing.T) {
+	srv := NewServer(testkube.NewMockClient(), database.NewMockDatabase(), nil, "../..")
+
+	rr := httptest.NewRecorder()
+	srv.Router().ServeHTTP(rr, httptest.NewRequest("GET", "/api/v1/features", nil))
+	assert.Equal(t, http.StatusOK, rr.Code)
+	var states []features.State
+	assert.NoError(t, json.NewDecoder(rr.Body).Decode(&states))
+	assert.Len(t, states, len(features.Flags))
+
+	// Graphs are off until switched on, here for one tenant only
+	rr = httptest.NewRecorder()
+	srv.Router().ServeHTTP(rr, httptest.NewRequest("PUT", "/api/v1/admin/flags/graphs", strings.NewReader(`{"tenant":"acme","enabled":true}`)))
+	assert.Equal(t, http.StatusOK, rr.Code)
+
+	req := httptest.NewRequest("GET", "/", nil)
+	req.Header.Set("X-Forwarded-Tenant", "acme")
+	rr = httptest.NewRecorder()
+	srv.Router().ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Contains(t, rr.Body.String(), "Pass Rate Trend")
+
+	rr = httptest.NewRecorder()
+	srv.Router().ServeHTTP(rr, httptest.NewRequest("GET", "/", nil))
+	assert.NotContains(t, rr.Body.String(), "Pass Rate Trend")
+
+	// Switching subsystems off for everyone from the admin panel
+	for _, flag := range []string{"evidence", "live-logs"} {
+		req = httptest.NewRequest("POST", "/admin/flags", strings.NewReader("state=off&flag="+flag))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		rr = httptest.NewRecorder()
+		srv.Router().ServeHTTP(rr, req)
+		assert.Equal(t, http.StatusOK, rr.Code)
+	}
+	rr = httptest.NewRecorder()
+	srv.Router().ServeHTTP(rr, httptest.NewRequest("POST", "/api/v1/executions/exec-1/evidence", nil))
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+	rr = httptest.NewRecorder()
+	srv.Router().ServeHTTP(rr, httptest.NewRequest("GET", "/executions/exec-1/logs/stream", nil))
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+	rr = httptest.NewRecorder()
+	srv.Router().ServeHTTP(rr, httptest.NewRequest("GET", "/executions/exec-1", nil))
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.NotContains(t, rr.Body.String(), "sse-connect")
+	assert.NotContains(t, rr.Body.String(), "Download evidence bundle")
+
+	rr = httptest.NewRecorder()
+	srv.Router().ServeHTTP(rr, httptest.NewRequest("DELETE", "/api/v1/admin/flags/evidence", nil))
+	assert.Equal(t, http.StatusNoContent, rr.Code)
+	assert.True(t, srv.features.Enabled(features.Evidence, ""))
+
+	rr = httptest.NewRecorder()
+	srv.Router().ServeHTTP(rr, httptest.NewRequest("PUT", "/api/v1/admin/flags/teleport", strings.NewReader(`{"enabled":true}`)))
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+}
+
+func TestBackupRestore(t *testing.T) {
+	db := database.NewMockDatabase()
+	db.InsertExecution(testkube.Execution{ID: "exec-1", WorkflowName: "api-health-check", Status: "passed"})
+	srv := NewServer(testkube.NewMockClient(), db, nil, "../..")
+	assert.NoError(t, srv.features.SetOverride(features.Graphs, "", true, ""))
+
+	rr := httptest.NewRecorder()
+	srv.Router().ServeHTTP(rr, httptest.NewRequest("GET", "/api/v1/admin/backup", nil))
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, "application/gzip", rr.Header().Get("Content-Type"))
+	archive := rr.Body.Bytes()
+
+	// Restore into a fresh dashboard from the admin panel
+	restored := database.NewMockDatabase()
+	target := NewServer(testkube.NewMockClient(), restored, nil, "../..")
+	var body bytes.Buffer
+	form := multipart.NewWriter(&body)
+	part, _ := form.CreateFormFile("archive", "backup.tar.gz")
+	part.Write(archive)
+	form.Close()
+	req := httptest.NewRequest("POST", "/admin/restore", &body)
+	req.Header.Set("Content-Type", form.FormDataContentType())
+	rr = httptest.NewRecorder()
+	target.Router().ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Contains(t, rr.Body.String(), "executions.json 1")
+
+	snapshot, _ := restored.Snapshot()
+	assert.Len(t, snapshot.Executions, 1)
+	assert.True(t, target.features.Enabled(features.Graphs, ""))
+
+	// The API takes the archive as the request body
+	rr = httptest.NewRecorder()
+	target.Router().ServeHTTP(rr, httptest.NewRequest("POST", "/api/v1/admin/restore?config=false", bytes.NewReader(archive)))
+	assert.Equal(t, http.StatusOK, rr.Code)
+	var manifest backup.Manifest
+	assert.NoError(t, json.NewDecoder(rr.Body).Decode(&manifest))
+	assert.Equal(t, 1, manifest.Rows["executions.json"])
+
+	rr = httptest.NewRecorder()
+	target.Router().ServeHTTP(rr, httptest.NewRequest("POST", "/api/v1/admin/restore", strings.NewReader("not an archive")))
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+
+	target.admins = parseAdmins("alice")
+	rr = httptest.NewRecorder()
+	target.Router().ServeHTTP(rr, httptest.NewRequest("GET", "/api/v1/admin/backup", nil))
+	assert.Equal(t, http.StatusForbidden, rr.Code)
+}
+
+func TestShareLinks(t *testing.T) {
+	srv := NewServer(testkube.NewMockClient(), database.NewMockDatabase(), nil, "../..")
+
+	rr := httptest.NewRecorder()
+	srv.Router().ServeHTTP(rr, httptest.NewRequest("POST", "/api/v1/executions/exec-1/share", strings.NewReader(`{"ttl":"24h"}`)))
+	assert.Equal(t, http.StatusCreated, rr.Code)
+	var link shareLinkResponse
+	assert.NoError(t, json.NewDecoder(rr.Body).Decode(&link))
+	assert.Equal(t, "exec-1", link.ExecutionID)
+	assert.Equal(t, "/share/"+link.Token, link.URL)
+
+	// Logs and text artifacts are scrubbed, screenshots withheld
+	rr = httptest.NewRecorder()
+	srv.Router().ServeHTTP(rr, httptest.NewRequest("GET", link.URL, nil))
+	assert.Equal(t, http.StatusOK, rr.Code)
+	body := rr.Body.String()
+	assert.Contains(t, body, "[REDACTED email]")
+	assert.NotContains(t, body, "qa-bot@example.com")
+	assert.NotContains(t, body, "10.0.4.12")
+	assert.NotContains(t, body, `href="/workflows"`, "shared pages leave out the dashboard navigation")
+
+	rr = httptest.NewRecorder()
+	srv.Router().ServeHTTP(rr, httptest.NewRequest("GET", link.URL+"/logs", nil))
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Contains(t, rr.Body.String(), "[REDACTED ipv4]")
+
+	rr = httptest.NewRecorder()
+	srv.Router().ServeHTTP(rr, httptest.NewRequest("GET", link.URL+"/artifacts/screenshot.png", nil))
+	assert.Equal(t, http.StatusForbidden, rr.Code)
+	rr = httptest.NewRecorder()
+	srv.Router().ServeHTTP(rr, httptest.NewRequest("GET", link.URL+"/artifacts/results.json", nil))
+	assert.Equal(t, http.StatusOK, rr.Code)
+
+	// With redaction off, everything is shared as is
+	rr = httptest.NewRecorder()
+	srv.Router().ServeHTTP(rr, httptest.NewRequest("PUT", "/api/v1/admin/flags/redaction", strings.NewReader(`{"enabled":false}`)))
+	assert.Equal(t, http.StatusOK, rr.Code)
+	rr = httptest.NewRecorder()
+	srv.Router().ServeHTTP(rr, httptest.NewRequest("GET", link.URL+"/logs", nil))
+	assert.Contains(t, rr.Body.String(), "qa-bot@example.com")
+	rr = httptest.NewRecorder()
+	srv.Router().ServeHTTP(rr, httptest.NewRequest("GET", link.URL+"/artifacts/screenshot.png", nil))
+	assert.Equal(t, http.StatusOK, rr.Code)
+
+	rr = httptest.NewRecorder()
+	srv.Router().ServeHTTP(rr, httptest.NewRequest("DELETE", "/api/v1/share/"+link.Token, nil))
+	assert.Equal(t, http.StatusNoContent, rr.Code)
+	rr = httptest.NewRecorder()
+	srv.Router().ServeHTTP(rr, httptest.NewRequest("GET", link.URL, nil))
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+
+	rr = httptest.NewRecorder()
+	srv.Router().ServeHTTP(rr, httptest.NewRequest("POST", "/api/v1/executions/missing/share", nil))
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+}
+
+// longLogClient's executions have logs longer than the page shows at first
+type longLogClient struct {
+	*testkube.MockClient
+}
+
+func (c longLogClient) OpenExecutionLogs(executionID string) (io.ReadCloser, error) {
+	var b strings.Builder
+	b.WriteString("started by qa-bot@example.com\n")
+	for i := 1; i <= logTailLines; i++ {
+		fmt.Fprintf(&b, "line %d from 10.0.4.12\n", i)
+	}
+	return io.NopCloser(strings.NewReader(b.String())), nil
+}
+
+func TestSharedExecutionShowsRedactedTail(t *testing.T) {
+	srv := NewServer(longLogClient{testkube.NewMockClient()}, database.NewMockDatabase(), nil, "../..")
+	rr := httptest.NewRecorder()
+	srv.Router().ServeHTTP(rr, httptest.NewRequest("POST", "/api/v1/executions/exec-1/share", nil))
+	assert.Equal(t, http.StatusCreated, rr.Code)
+	var link shareLinkResponse
+	assert.NoError(t, json.NewDecoder(rr.Body).Decode(&link))
+
+	rr = httptest.NewRecorder()
+	srv.Router().ServeHTTP(rr, httptest.NewRequest("GET", link.URL, nil))
+	assert.Equal(t, http.StatusOK, rr.Code)
+	body := rr.Body.String()
+	assert.Contains(t, body, fmt.Sprintf("Showing lines 2-%d of %d", logTailLines+1, logTailLines+1))
+	assert.NotContains(t, body, "started by", "the page shows only the log's tail")
+	assert.NotContains(t, body, "10.0.4.12")
+
+	scrubbed := scrubExecution(testkube.Execution{
+		Name:   "run for qa-bot@example.com",
+		Labels: map[string]string{"owner": "qa-bot@example.com"},
+		Steps:  []testkube.StepResult{{Name: "login", Error: "connect 10.0.4.12: refused"}},
+	}, srv.redactor.Redact)
+	assert.NotContains(t, scrubbed.Name, "qa-bot@example.com")
+	assert.NotContains(t, scrubbed.Labels["owner"], "qa-bot@example.com")
+	assert.NotContains(t, scrubbed.Steps[0].Error, "10.0.4.12")
+}
+
+func TestVariablePresets(t *testing.T) {
+	api := testkube.NewMockClient()
+	srv := NewServer(api, database.NewMockDatabase(), nil, "../..")
+
+	rr := httptest.NewRecorder()
+	srv.Router().ServeHTTP(rr, httptest.NewRequest("PUT", "/api/v1/workflows/frontend-e2e/presets/staging",
+		strings.NewReader(`{"variables":{"BASE_URL":"https://staging.example.com","WORKERS":"4"}}`)))
+	assert.Equal(t, http.StatusNoContent, rr.Code)
+
+	rr = httptest.NewRecorder()
+	srv.Router().ServeHTTP(rr, httptest.NewRequest("PUT", "/api/v1/workflows/frontend-e2e/presets/bad%20name",
+		strings.NewReader(`{"variables":{"A":"1"}}`)))
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+
+	// Presets saved from the workflow page are parsed from KEY=VALUE lines
+	req := httptest.NewRequest("POST", "/workflows/frontend-e2e/presets", strings.NewReader("preset=perf-env&variables=BASE_URL%3Dhttps%3A%2F%2Fperf.example.com%0AUSERS%3D500"))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	rr = httptest.NewRecorder()
+	srv.Router().ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Contains(t, rr.Body.String(), "USERS=500")
+
+	rr = httptest.NewRecorder()
+	srv.Router().ServeHTTP(rr, httptest.NewRequest("GET", "/api/v1/workflows/frontend-e2e/presets", nil))
+	var presets []database.VariablePreset
+	assert.NoError(t, json.NewDecoder(rr.Body).Decode(&presets))
+	if assert.Len(t, presets, 2) {
+		assert.Equal(t, "perf-env", presets[0].Name)
+		assert.Equal(t, "staging", presets[1].Name)
+	}
+
+	rr = httptest.NewRecorder()
+	srv.Router().ServeHTTP(rr, httptest.NewRequest("GET", "/workflows/frontend-e2e", nil))
+	assert.Contains(t, rr.Body.String(), `<option value="staging">staging</option>`)
+
+	// Running with a preset passes its variables and records it on the run
+	req = httptest.NewRequest("POST", "/workflows/frontend-e2e/run", strings.NewReader("preset=staging"))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	rr = httptest.NewRecorder()
+	srv.Router().ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusOK, rr.Code)
+	var trigger map[string]string
+	assert.NoError(t, json.Unmarshal([]byte(rr.Header().Get("HX-Trigger")), &trigger))
+	exec, err := api.GetExecution(trigger["executionStarted"])
+	if assert.NoError(t, err) {
+		assert.Equal(t, "staging", exec.Labels[PresetTag])
+	}
+	logs, _ := api.GetExecutionLogs(trigger["executionStarted"])
+	assert.Contains(t, logs, "Config BASE_URL=https://staging.example.com")
+	assert.Contains(t, logs, "Config WORKERS=4")
+
+	req = httptest.NewRequest("POST", "/workflows/frontend-e2e/run?preset=missing", nil)
+	rr = httptest.NewRecorder()
+	srv.Router().ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+
+	rr = httptest.NewRecorder()
+	srv.Router().ServeHTTP(rr, httptest.NewRequest("DELETE", "/api/v1/workflows/frontend-e2e/presets/staging", nil))
+	assert.Equal(t, http.StatusNoContent, rr.Code)
+	rr = httptest.NewRecorder()
+	srv.Router().ServeHTTP(rr, httptest.NewRequest("GET", "/api/v1/workflows/frontend-e2e/presets", nil))
+	assert.NotContains(t, rr.Body.String(), "staging")
+}
+
+func TestTriggerSources(t *testing.T) {
+	api := testkube.NewMockClient()
+	srv := NewServer(api, database.NewMockDatabase(), nil, "../..")
+
+	// Runs from the dashboard record who started them
+	req := httptest.NewRequest("POST", "/workflows/frontend-e2e/run", nil)
+	req.Header.Set("X-Forwarded-User", "dave")
+	rr := httptest.NewRecorder()
+	srv.Router().ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusOK, rr.Code)
+	var trigger map[string]string
+	assert.NoError(t, json.Unmarshal([]byte(rr.Header().Get("HX-Trigger")), &trigger))
+	exec, err := api.GetExecution(trigger["executionStarted"])
+	if assert.NoError(t, err) {
+		assert.Equal(t, testkube.TriggerManual, exec.Trigger)
+		assert.Equal(t, "dave", exec.TriggeredBy)
+	}
+
+	rr = httptest.NewRecorder()
+	srv.Router().ServeHTTP(rr, httptest.NewRequest("GET", "/api/v1/workflows/frontend-e2e/triggers", nil))
+	assert.Equal(t, http.StatusOK, rr.Code)
+	var breakdown []triggerStats
+	assert.NoError(t, json.NewDecoder(rr.Body).Decode(&breakdown))
+	runs := 0
+	for _, stats := range breakdown {
+		assert.Equal(t, stats.Runs, stats.Passed+stats.Failed)
+		runs += stats.Runs
+	}
+	assert.Greater(t, runs, 0)
+
+	// Filtering history by trigger
+	rr = httptest.NewRecorder()
+	srv.Router().ServeHTTP(rr, httptest.NewRequest("GET", "/workflows/frontend-e2e/history?trigger=ci", nil))
+	assert.Equal(t, http.StatusOK, rr.Code)
+	body := rr.Body.String()
+	assert.Contains(t, body, "<strong>ci</strong>")
+	assert.Contains(t, body, "<td>ci <small>by bitbucket-pipelines</small></td>")
+	assert.NotContains(t, body, "<td>manual")
+}
+
+func TestRunAndWait(t *testing.T) {
+	api := testkube.NewMockClient()
+	srv := NewServer(api, database.NewMockDatabase(), nil, "../..")
+	runWaitPollInterval = 10 * time.Millisecond
+	defer func() { runWaitPollInterval = 5 * time.Second }()
+
+	rr := httptest.NewRecorder()
+	srv.Router().ServeHTTP(rr, httptest.NewRequest("POST", "/api/v1/workflows/frontend-e2e/run-and-wait?timeout=forever", nil))
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+
+	// The mock takes seconds to finish a run, so this times out
+	rr = httptest.NewRecorder()
+	srv.Router().ServeHTTP(rr, httptest.NewRequest("POST", "/api/v1/workflows/frontend-e2e/run-and-wait?timeout=50ms", nil))
+	assert.Equal(t, http.StatusGatewayTimeout, rr.Code)
+	var verdict runVerdict
+	assert.NoError(t, json.NewDecoder(rr.Body).Decode(&verdict))
+	assert.Equal(t, verdictTimeout, verdict.Verdict)
+	assert.False(t, verdict.Passed)
+	exec, err := api.GetExecution(verdict.ExecutionID)
+	if assert.NoError(t, err) {
+		assert.Equal(t, testkube.TriggerCI, exec.Trigger)
+	}
+
+	// Finished executions get their verdict from the status
+	execs, err := api.GetExecutions(testkube.ListOptions{})
+	assert.NoError(t, err)
+	for _, e := range execs {
+		if e.Status != "passed" && e.Status != "failed" {
+			continue
+		}
+		v := runVerdict{ExecutionID: e.ID}
+		assert.True(t, srv.pollRun(api, &v))
+		assert.Equal(t, e.Status, v.Verdict)
+		assert.Equal(t, e.Status == "passed", v.Passed)
+	}
+	assert.Equal(t, http.StatusUnprocessableEntity, runVerdict{Verdict: verdictFailed}.statusCode())
+	assert.Equal(t, http.StatusConflict, runVerdict{Verdict: verdictCancelled}.statusCode())
+}
+
+func TestSuiteVerdict(t *testing.T) {
+	srv := NewServer(testkube.NewMockClient(), database.NewMockDatabase(), nil, "../..")
+	assert.NoError(t, srv.suites.Import(json.RawMessage(`{"suites": [
+		{"name": "release", "workflows": ["frontend-*", "cluster-security"], "slo": {"minPassRate": 50}}
+	]}`)))
+
+	rr := httptest.NewRecorder()
+	srv.Router().ServeHTTP(rr, httptest.NewRequest("GET", "/api/v1/suites/nope/verdict", nil))
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+
+	rr = httptest.NewRecorder()
+	srv.Router().ServeHTTP(rr, httptest.NewRequest("GET", "/api/v1/suites/release/verdict?window=forever", nil))
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+
+	rr = httptest.NewRecorder()
+	srv.Router().ServeHTTP(rr, httptest.NewRequest("GET", "/api/v1/suites/release/verdict?window=720h", nil))
+	var verdict suites.Verdict
+	assert.NoError(t, json.NewDecoder(rr.Body).Decode(&verdict))
+	assert.Equal(t, "release", verdict.Suite)
+	assert.Equal(t, "720h0m0s", verdict.Window)
+	if verdict.Passed {
+		assert.Equal(t, http.StatusOK, rr.Code)
+	} else {
+		assert.Equal(t, http.StatusUnprocessableEntity, rr.Code)
+		assert.NotEmpty(t, verdict.Reasons)
+	}
+	var names []string
+	for _, wr := range verdict.Workflows {
+		names = append(names, wr.Workflow)
+	}
+	assert.Contains(t, names, "frontend-e2e")
+	assert.Contains(t, names, "cluster-security")
+	assert.NotContains(t, names, "k8s-compliance")
+
+	rr = httptest.NewRecorder()
+	srv.Router().ServeHTTP(rr, httptest.NewRequest("GET", "/api/v1/suites", nil))
+	assert.Contains(t, rr.Body.String(), `"name":"release"`)
+}
+
+func TestPassRateAlertAPI(t *testing.T) {
+	srv := NewServer(testkube.NewMockClient(), database.NewMockDatabase(), nil, "../..")
+	assert.Error(t, srv.passRates.Import(json.RawMessage(`{"rules": [{"workflow": "smoke-*", "mode": "static", "minPassRate": 101}]}`)))
+
+	rr := httptest.NewRecorder()
+	srv.Router().ServeHTTP(rr, httptest.NewRequest("GET", "/api/v1/workflows/frontend-e2e/pass-rate-alert", nil))
+	assert.Equal(t, http.StatusOK, rr.Code)
+	var result baselines.Result
+	assert.NoError(t, json.NewDecoder(rr.Body).Decode(&result))
+	assert.Equal(t, baselines.ModeDynamic, result.Mode)
+	assert.Equal(t, baselines.DefaultBaselineDays, result.Days)
+	assert.True(t, result.Ready)
+	assert.Less(t, result.Threshold, result.Mean)
+}
+
+func TestDeadLetters(t *testing.T) {
+	db := database.NewMockDatabase()
+	srv := NewServer(testkube.NewMockClient(), db, nil, "../..")
+	assert.NoError(t, db.SaveDeadLetter(database.DeadLetter{
+		ExecutionID: "exec-1", WorkflowName: "frontend-e2e", Path: "results.json",
+		Parser: "playwright", Error: "unexpected token", Attempts: 3, LastFailed: time.Now(),
+	}))
+
+	rr := httptest.NewRecorder()
+	srv.Router().ServeHTTP(rr, httptest.NewRequest("GET", "/admin", nil))
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Contains(t, rr.Body.String(), `href="/executions/exec-1/artifacts/results.json"`)
+	assert.Contains(t, rr.Body.String(), "unexpected token")
+
+	rr = httptest.NewRecorder()
+	srv.Router().ServeHTTP(rr, httptest.NewRequest("GET", "/metrics", nil))
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Contains(t, rr.Body.String(), "testkube_dashboard_dead_letters 1")
+
+	// Retrying needs the worker
+	body := strings.NewReader(`{"executionId": "exec-1", "path": "results.json"}`)
+	rr = httptest.NewRecorder()
+	srv.Router().ServeHTTP(rr, httptest.NewRequest("POST", "/api/v1/admin/dead-letters/retry", body))
+	assert.Equal(t, http.StatusServiceUnavailable, rr.Code)
+
+	srv.SetWorker(worker.NewWorker(testkube.NewMockClient(), db))
+	body = strings.NewReader(`{"executionId": "exec-1", "path": "missing.json"}`)
+	rr = httptest.NewRecorder()
+	srv.Router().ServeHTTP(rr, httptest.NewRequest("POST", "/api/v1/admin/dead-letters/retry", body))
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+
+	// The mock's results.json isn't a Playwright report
+	form := url.Values{"executionId": {"exec-1"}, "path": {"results.json"}}
+	req := httptest.NewRequest("POST", "/admin/dead-letters/retry", strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	rr = httptest.NewRecorder()
+	srv.Router().ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Contains(t, rr.Header().Get("HX-Trigger"), "Still failing")
+
+	rr = httptest.NewRecorder()
+	srv.Router().ServeHTTP(rr, httptest.NewRequest("GET", "/metrics", nil))
+	assert.Contains(t, rr.Body.String(), `testkube_dashboard_worker_artifacts_parsed_total{parser="playwright",result="success"} 0`)
+}
+
+func TestExecutionLogRanges(t *testing.T) {
+	api := testkube.NewMockClient()
+	srv := NewServer(api, database.NewMockDatabase(), nil, "../..")
+	logs, err := api.GetExecutionLogs("exec-1")
+	assert.NoError(t, err)
+	lines := strings.Split(logs, "\n")
+
+	rr := httptest.NewRecorder()
+	srv.Router().ServeHTTP(rr, httptest.NewRequest("GET", "/executions/exec-1/logs?offset=1&limit=2", nil))
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, lines[1]+"\n"+lines[2]+"\n", rr.Body.String())
+	assert.Equal(t, fmt.Sprint(len(lines)), rr.Header().Get("X-Log-Total-Lines"))
+
+	rr = httptest.NewRecorder()
+	srv.Router().ServeHTTP(rr, httptest.NewRequest("GET", "/executions/exec-1/logs?limit=-1", nil))
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+
+	rr = httptest.NewRecorder()
+	srv.Router().ServeHTTP(rr, httptest.NewRequest("GET", "/api/v1/executions/exec-1/logs?tail=1", nil))
+	var page testkube.LogPage
+	assert.NoError(t, json.NewDecoder(rr.Body).Decode(&page))
+	assert.Equal(t, []string{lines[len(lines)-1]}, page.Lines)
+	assert.Equal(t, len(lines)-1, page.Offset)
+
+	rr = httptest.NewRecorder()
+	srv.Router().ServeHTTP(rr, httptest.NewRequest("GET", "/api/v1/executions/exec-1/logs/search?q=DATABASE&context=1", nil))
+	assert.Equal(t, http.StatusOK, rr.Code)
+	var search testkube.LogSearch
+	assert.NoError(t, json.NewDecoder(rr.Body).Decode(&search))
+	if assert.Len(t, search.Matches, 1) {
+		assert.Contains(t, search.Matches[0].Text, "Connecting to database")
+		assert.Len(t, search.Matches[0].Before, 1)
+	}
+
+	rr = httptest.NewRecorder()
+	srv.Router().ServeHTTP(rr, httptest.NewRequest("GET", "/executions/exec-1/logs/search?q=database", nil))
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Contains(t, rr.Body.String(), "1 matching lines for <code>database</code>")
+
+	rr = httptest.NewRecorder()
+	srv.Router().ServeHTTP(rr, httptest.NewRequest("GET", "/executions/exec-1/logs/search?q=", nil))
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+}
+
+func TestDurationHistogram(t *testing.T) {
+	db := database.NewMockDatabase()
+	srv := NewServer(testkube.NewMockClient(), db, nil, "../..")
+	// Fast failures around 10s and full runs around 5m average out to a
+	// duration neither kind of run takes
+	now := time.Now()
+	for i, d := range []time.Duration{9 * time.Second, 10 * time.Second, 11 * time.Second, 290 * time.Second, 300 * time.Second, 310 * time.Second} {
+		assert.NoError(t, db.InsertExecution(testkube.Execution{
+			ID: fmt.Sprintf("exec-%d", i), WorkflowName: "frontend-e2e", Status: "passed",
+			StartTime: now.Add(-time.Hour), Duration: d,
+		}))
+	}
+	assert.NoError(t, db.InsertExecution(testkube.Execution{ID: "old", WorkflowName: "frontend-e2e", StartTime: now.AddDate(0, 0, -60), Duration: time.Hour}))
+	assert.NoError(t, db.InsertExecution(testkube.Execution{ID: "other", WorkflowName: "api-tests", StartTime: now, Duration: time.Hour}))
+
+	rr := httptest.NewRecorder()
+	srv.Router().ServeHTTP(rr, httptest.NewRequest("GET", "/api/v1/workflows/frontend-e2e/duration-histogram?buckets=3", nil))
+	assert.Equal(t, http.StatusOK, rr.Code)
+	var buckets []database.DurationBucket
+	assert.NoError(t, json.NewDecoder(rr.Body).Decode(&buckets))
+	assert.Equal(t, []database.DurationBucket{
+		{LowerMs: 9000, UpperMs: 109334, Count: 3},
+		{LowerMs: 109334, UpperMs: 209668, Count: 0},
+		{LowerMs: 209668, UpperMs: 310002, Count: 3},
+	}, buckets)
+
+	// The workflow page shows the chart once graphs are on
+	rr = httptest.NewRecorder()
+	srv.Router().ServeHTTP(rr, httptest.NewRequest("GET", "/workflows/frontend-e2e", nil))
+	assert.NotContains(t, rr.Body.String(), "Duration Distribution")
+	assert.NoError(t, srv.features.SetOverride(features.Graphs, "", true, ""))
+	rr = httptest.NewRecorder()
+	srv.Router().ServeHTTP(rr, httptest.NewRequest("GET", "/workflows/frontend-e2e", nil))
+	assert.Contains(t, rr.Body.String(), "Duration Distribution")
+}
+
+func TestCalendar(t *testing.T) {
+	// A nightly schedule that stopped firing at the weekend
+	from, to, shownFrom, shownTo := calendarRange("week", time.Date(2024, 6, 5, 0, 0, 0, 0, time.UTC))
+	assert.Equal(t, time.Date(2024, 6, 3, 0, 0, 0, 0, time.UTC), from)
+	var slots []schedules.Slot
+	var executions []testkube.Execution
+	for d := from; d.Before(to); d = d.AddDate(0, 0, 1) {
+		slot := d.Add(2 * time.Hour)
+		slots = append(slots, schedules.Slot{Workflow: "nightly", Time: slot})
+		if d.Weekday() != time.Saturday && d.Weekday() != time.Sunday {
+			executions = append(executions, testkube.Execution{ID: "n-" + d.Format("0102"), WorkflowName: "nightly", Status: "passed", StartTime: slot.Add(time.Minute)})
+		}
+	}
+	executions = append(executions, testkube.Execution{ID: "manual", WorkflowName: "nightly", Status: "failed", StartTime: from.Add(14 * time.Hour)})
+
+	weeks := buildCalendar(from, to, shownFrom, shownTo, slots, executions, to.Add(time.Hour))
+	assert.Len(t, weeks, 1)
+	monday, saturday := weeks[0][0], weeks[0][5]
+	assert.Equal(t, "passed", monday.Slots[0].Status)
+	assert.Equal(t, "n-0603", monday.Slots[0].ExecutionID)
+	assert.Equal(t, map[string]int{"passed": 1, "failed": 1}, monday.Counts)
+	assert.Len(t, monday.Runs, 1, "the manual run wasn't scheduled")
+	assert.Equal(t, slotMissed, saturday.Slots[0].Status)
+	assert.Equal(t, 1, saturday.Missed)
+
+	// A month starts on the Monday before the 1st
+	from, to, shownFrom, _ = calendarRange("month", time.Date(2024, 6, 17, 0, 0, 0, 0, time.UTC))
+	assert.Equal(t, time.Date(2024, 5, 27, 0, 0, 0, 0, time.UTC), from)
+	assert.Equal(t, time.Date(2024, 7, 1, 0, 0, 0, 0, time.UTC), to)
+	assert.Equal(t, time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC), shownFrom)
+
+	api := testkube.NewMockClient()
+	assert.NoError(t, api.UpdateWorkflowSchedules("frontend-e2e", []testkube.WorkflowSchedule{{Cron: "@hourly"}}))
+	srv := NewServer(api, database.NewMockDatabase(), nil, "../..")
+	// Last week and month are over, so their unmatched slots were missed
+	lastWeek := time.Now().AddDate(0, 0, -7).Format(time.DateOnly)
+	lastMonth := time.Now().AddDate(0, -1, 0).Format(time.DateOnly)
+	rr := httptest.NewRecorder()
+	srv.Router().ServeHTTP(rr, httptest.NewRequest("GET", "/calendar?view=week&date="+lastWeek, nil))
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Contains(t, rr.Body.String(), "slot-missed")
+
+	rr = httptest.NewRecorder()
+	srv.Router().ServeHTTP(rr, httptest.NewRequest("GET", "/api/v1/calendar?workflow=frontend-e2e&date="+lastMonth, nil))
+	assert.Equal(t, http.StatusOK, rr.Code)
+	var c calendar
+	assert.NoError(t, json.NewDecoder(rr.Body).Decode(&c))
+	assert.Equal(t, "month", c.View)
+	assert.Greater(t, c.Missed, 0)
+
+	rr = httptest.NewRecorder()
+	srv.Router().ServeHTTP(rr, httptest.NewRequest("GET", "/calendar?date=yesterday", nil))
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+}
+
+func TestExecutionLogsStream(t *testing.T) {
+	srv := NewServer(testkube.NewMockClient(), database.NewMockDatabase(), nil, "../..")
+	assert.NoError(t, srv.features.SetOverride(features.LiveLogs, "", true, ""))
+
+	// A finished execution's log is sent in full, then the stream ends
+	rr := httptest.NewRecorder()
+	srv.Router().ServeHTTP(rr, httptest.NewRequest("GET", "/executions/exec-1/logs/stream", nil))
+	assert.Equal(t, "text/event-stream", rr.Header().Get("Content-Type"))
+	assert.Contains(t, rr.Body.String(), "event: log\ndata: Initializing test runner...\n")
+	assert.Contains(t, rr.Body.String(), "data: Done.\n")
+
+	rr = httptest.NewRecorder()
+	srv.Router().ServeHTTP(rr, httptest.NewRequest("GET", "/executions/missing/logs/stream", nil))
+	assert.Contains(t, rr.Body.String(), "event: error")
+}
+
+func TestAbortExecution(t *testing.T) {
+	api := testkube.NewMockClient()
+	srv := NewServer(api, database.NewMockDatabase(), nil, "../..")
+	exec, err := api.RunWorkflow("frontend-e2e", testkube.RunOptions{})
+	assert.NoError(t, err)
+
+	rr := httptest.NewRecorder()
+	srv.Router().ServeHTTP(rr, httptest.NewRequest("GET", "/executions/"+exec.ID, nil))
+	assert.Contains(t, rr.Body.String(), `hx-post="/executions/`+exec.ID+`/abort"`)
+
+	rr = httptest.NewRecorder()
+	srv.Router().ServeHTTP(rr, httptest.NewRequest("POST", "/api/v1/executions/"+exec.ID+"/abort", nil))
+	assert.Equal(t, http.StatusOK, rr.Code)
+	var aborted testkube.Execution
+	assert.NoError(t, json.NewDecoder(rr.Body).Decode(&aborted))
+	assert.Equal(t, "aborted", aborted.Status)
+	assert.False(t, aborted.EndTime.IsZero())
+
+	// Finished executions can't be aborted
+	rr = httptest.NewRecorder()
+	srv.Router().ServeHTTP(rr, httptest.NewRequest("POST", "/executions/"+exec.ID+"/abort", nil))
+	assert.Equal(t, http.StatusConflict, rr.Code)
+	rr = httptest.NewRecorder()
+	srv.Router().ServeHTTP(rr, httptest.NewRequest("POST", "/api/v1/executions/missing/abort", nil))
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+}
+
+func TestRunCIMetadata(t *testing.T) {
+	api := testkube.NewMockClient()
+	srv := NewServer(api, database.NewMockDatabase(), nil, "../..")
+	runWaitPollInterval = 10 * time.Millisecond
+	defer func() { runWaitPollInterval = 5 * time.Second }()
+
+	body := `{"ci": {"buildUrl": "https://ci.example.com/builds/1234", "pipeline": "deploy #1234", "commit": "3f2a9c1",
+		"commitMessage": "Fix checkout total rounding\n\nLong description", "author": "dana@example.com"}}`
+	req := httptest.NewRequest("POST", "/api/v1/workflows/frontend-e2e/run-and-wait?timeout=10ms", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	rr := httptest.NewRecorder()
+	srv.Router().ServeHTTP(rr, req)
+	var verdict runVerdict
+	assert.NoError(t, json.NewDecoder(rr.Body).Decode(&verdict))
+	exec, err := api.GetExecution(verdict.ExecutionID)
+	if assert.NoError(t, err) {
+		assert.Equal(t, "https://ci.example.com/builds/1234", exec.Labels[CIBuildURLTag])
+		assert.Equal(t, "Fix checkout total rounding", exec.Labels[CICommitMessageTag])
+	}
+
+	rr = httptest.NewRecorder()
+	srv.Router().ServeHTTP(rr, httptest.NewRequest("GET", "/executions/"+verdict.ExecutionID, nil))
+	assert.Contains(t, rr.Body.String(), `<a href="https://ci.example.com/builds/1234" target="_blank" rel="noopener">deploy #1234</a>`)
+	assert.Contains(t, rr.Body.String(), "dana@example.com")
+
+	// Form fields work for the run button too, and links must be http(s)
+	form := url.Values{"ciBuildUrl": {"javascript:alert(1)"}}
+	req = httptest.NewRequest("POST", "/workflows/frontend-e2e/run", strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	rr = httptest.NewRecorder()
+	srv.Router().ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+}
+
+func TestWorkflowHistoryPages(t *testing.T) {
+	api := testkube.NewMockClient()
+	srv := NewServer(api, database.NewMockDatabase(), nil, "../..")
+	// Run until the history spills onto a second page
+	for {
+		page, err := api.GetExecutionPage(testkube.ListOptions{Workflow: "frontend-e2e", PageSize: executionPageSize})
+		assert.NoError(t, err)
+		if page.HasNext() {
+			break
+		}
+		_, err = api.RunWorkflow("frontend-e2e", testkube.RunOptions{})
+		assert.NoError(t, err)
+	}
+
+	rr := httptest.NewRecorder()
+	srv.Router().ServeHTTP(rr, httptest.NewRequest("GET", "/workflows/frontend-e2e/history", nil))
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Contains(t, rr.Body.String(), "Page 1 of 2")
+	assert.Contains(t, rr.Body.String(), `href="/workflows/frontend-e2e/history?page=2"`)
+	assert.NotContains(t, rr.Body.String(), "Newer")
+
+	rr = httptest.NewRecorder()
+	srv.Router().ServeHTTP(rr, httptest.NewRequest("GET", "/workflows/frontend-e2e/history?page=2", nil))
+	assert.Contains(t, rr.Body.String(), "Page 2 of 2")
+	assert.Contains(t, rr.Body.String(), "Newer")
+	assert.NotContains(t, rr.Body.String(), "Older")
+}
+
+func TestActivity(t *testing.T) {
+	db := database.NewMockDatabase()
+	srv := NewServer(testkube.NewMockClient(), db, nil, "../..")
+
+	req := httptest.NewRequest("POST", "/workflows/frontend-e2e/run", nil)
+	req.Header.Set("X-Forwarded-User", "alice@example.com")
+	rr := httptest.NewRecorder()
+	srv.Router().ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusOK, rr.Code)
+	srv.recordEvent(database.Event{Type: activityAlertSent, Subject: "nightly failed", Time: time.Now().Add(-time.Hour)})
+	srv.recordEvent(database.Event{Type: activityUserGenerated, Actor: "bob@example.com", Subject: "old", Time: time.Now().AddDate(0, 0, -10)})
+
+	rr = httptest.NewRecorder()
+	srv.Router().ServeHTTP(rr, httptest.NewRequest("GET", "/api/v1/activity", nil))
+	assert.Equal(t, http.StatusOK, rr.Code)
+	var events []database.Event
+	assert.NoError(t, json.NewDecoder(rr.Body).Decode(&events))
+	types := make(map[string]int)
+	for i, e := range events {
+		types[e.Type]++
+		if i > 0 {
+			assert.False(t, e.Time.After(events[i-1].Time), "events are newest first")
+		}
+	}
+	assert.Equal(t, 1, types[activityRunTriggered])
+	assert.Equal(t, 1, types[activityAlertSent])
+	assert.Greater(t, types[activityScheduleFired], 0, "scheduled runs come from Testkube")
+	assert.Zero(t, types[activityUserGenerated], "events older than a week are left out")
+	assert.Equal(t, "alice@example.com", events[0].Actor)
+
+	// Filtering by actor leaves out schedules, which have none
+	rr = httptest.NewRecorder()
+	srv.Router().ServeHTTP(rr, httptest.NewRequest("GET", "/api/v1/activity?actor=alice@example.com&days=30", nil))
+	events = nil
+	assert.NoError(t, json.NewDecoder(rr.Body).Decode(&events))
+	assert.Len(t, events, 1)
+
+	rr = httptest.NewRecorder()
+	srv.Router().ServeHTTP(rr, httptest.NewRequest("GET", "/api/v1/activity?type=user.generated&days=30", nil))
+	events = nil
+	assert.NoError(t, json.NewDecoder(rr.Body).Decode(&events))
+	assert.Len(t, events, 1)
+	assert.Equal(t, "old", events[0].Subject)
+
+	rr = httptest.NewRecorder()
+	srv.Router().ServeHTTP(rr, httptest.NewRequest("GET", "/api/v1/activity?type=bogus", nil))
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+
+	rr = httptest.NewRecorder()
+	srv.Router().ServeHTTP(rr, httptest.NewRequest("GET", "/activity?type=run.triggered", nil))
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Contains(t, rr.Body.String(), "Started manual run")
+	assert.Contains(t, rr.Body.String(), `value="run.triggered" checked`)
+}
+
+func TestLabelSelectors(t *testing.T) {
+	srv := NewServer(testkube.NewMockClient(), database.NewMockDatabase(), nil, "../..")
+
+	rr := httptest.NewRecorder()
+	srv.Router().ServeHTTP(rr, httptest.NewRequest("GET", "/workflows?selector=team%3Dsecurity", nil))
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Contains(t, rr.Body.String(), "cluster-security")
+	assert.NotContains(t, rr.Body.String(), "frontend-e2e")
+
+	rr = httptest.NewRecorder()
+	srv.Router().ServeHTTP(rr, httptest.NewRequest("GET", "/api/v1/executions?selector=priority%3Dhigh&pageSize=5", nil))
+	assert.Equal(t, http.StatusOK, rr.Code)
+	var page testkube.ExecutionPage
+	assert.NoError(t, json.NewDecoder(rr.Body).Decode(&page))
+	assert.Len(t, page.Executions, 5)
+	assert.Greater(t, page.Total, 5)
+	for _, e := range page.Executions {
+		assert.Contains(t, []string{"frontend-e2e", "backend-integration", "cluster-security"}, e.WorkflowName)
+	}
+
+	for _, path := range []string{"/workflows?selector=team+in+security", "/api/v1/executions?selector=%3Dx"} {
+		rr = httptest.NewRecorder()
+		srv.Router().ServeHTTP(rr, httptest.NewRequest("GET", path, nil))
+		assert.Equal(t, http.StatusBadRequest, rr.Code, path)
+	}
+}
+
+// unavailableDatabase fails the analytics reads and pings while down
+type unavailableDatabase struct {
+	*database.MockDatabase
+	down bool
+}
+
+var errDatabaseDown = errors.New("connection refused")
+
+func (d *unavailableDatabase) Ping(ctx context.Context) error {
+	if d.down {
+		return errDatabaseDown
+	}
+	return d.MockDatabase.Ping(ctx)
+}
+
+func (d *unavailableDatabase) GetTrends(days int) (*database.TrendData, error) {
+	if d.down {
+		return nil, errDatabaseDown
+	}
+	return d.MockDatabase.GetTrends(days)
+}
+
+func (d *unavailableDatabase) GetFlakyTests(threshold float64) ([]database.FlakyTest, error) {
+	if d.down {
+		return nil, errDatabaseDown
+	}
+	return d.MockDatabase.GetFlakyTests(threshold)
+}
+
+func (d *unavailableDatabase) GetPassedOnRetryTests(days, limit int) ([]database.RetryPassTest, error) {
+	if d.down {
+		return nil, errDatabaseDown
+	}
+	return d.MockDatabase.GetPassedOnRetryTests(days, limit)
+}
+
+func (d *unavailableDatabase) GetExecutionMetrics(executionID string) ([]database.TestCase, error) {
+	if d.down {
+		return nil, errDatabaseDown
+	}
+	return d.MockDatabase.GetExecutionMetrics(executionID)
+}
+
+func TestDatabaseUnavailable(t *testing.T) {
+	db := &unavailableDatabase{MockDatabase: database.NewMockDatabase(), down: true}
+	srv := NewServer(testkube.NewMockClient(), db, nil, "../..")
+	get := func(path string) *httptest.ResponseRecorder {
+		rr := httptest.NewRecorder()
+		srv.Router().ServeHTTP(rr, httptest.NewRequest("GET", path, nil))
+		return rr
+	}
+
+	rr := get("/")
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Contains(t, rr.Body.String(), "The database has been unavailable")
+	assert.Contains(t, rr.Body.String(), "Unavailable while the database is down")
+	assert.Contains(t, rr.Body.String(), "/executions/", "recent failures come from Testkube")
+
+	rr = get("/executions/exec-1")
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Contains(t, rr.Body.String(), "Test results are stored in the database")
+
+	rr = get("/api/v1/flaky-tests")
+	assert.Equal(t, http.StatusServiceUnavailable, rr.Code)
+	assert.Equal(t, "30", rr.Header().Get("Retry-After"))
+
+	rr = get("/readyz")
+	assert.Equal(t, http.StatusOK, rr.Code)
+	var ready struct {
+		Status   string         `json:"status"`
+		Database databaseStatus `json:"database"`
+	}
+	assert.NoError(t, json.NewDecoder(rr.Body).Decode(&ready))
+	assert.Equal(t, "degraded", ready.Status)
+	assert.False(t, ready.Database.Available)
+	assert.Contains(t, ready.Database.Error, "connection refused")
+	assert.Contains(t, get("/metrics").Body.String(), "testkube_dashboard_database_up 0")
+
+	// Once a read succeeds, its result is served while the database is down
+	db.down = false
+	assert.Equal(t, http.StatusOK, get("/api/v1/flaky-tests").Code)
+	rr = get("/")
+	assert.NotContains(t, rr.Body.String(), "The database has been unavailable")
+	assert.NotContains(t, rr.Body.String(), "Analytics as of")
+
+	db.down = true
+	rr = get("/api/v1/flaky-tests")
+	assert.Equal(t, http.StatusOK, rr.Code)
+	rr = get("/")
+	assert.Contains(t, rr.Body.String(), "Analytics as of")
+	assert.NotContains(t, rr.Body.String(), "Unavailable while the database is down")
+
+	db.down = false
+	assert.Contains(t, get("/readyz").Body.String(), `"status":"ready"`)
+	assert.Contains(t, get("/metrics").Body.String(), "testkube_dashboard_database_up 1")
+
+	// Per-execution reads don't grow the last-known results without bound
+	for i := 0; i <= maxLastKnown; i++ {
+		dbRead(srv, fmt.Sprintf("test-cases:exec-%d", i), func() (int, error) { return i, nil })
+	}
+	assert.Len(t, srv.dbHealth.lastKnown, maxLastKnown)
+}
+
+func TestExecutionDateRange(t *testing.T) {
+	db := database.NewMockDatabase()
+	tuesday := time.Date(2024, 5, 7, 0, 0, 0, 0, time.Local)
+	for i, at := range []time.Time{
+		tuesday.Add(-2 * time.Hour),
+		tuesday.Add(20 * time.Hour),
+		tuesday.Add(23 * time.Hour),
+		tuesday.Add(26 * time.Hour),
+	} {
+		db.InsertExecution(testkube.Execution{ID: fmt.Sprintf("exec-night-%d", i), Name: fmt.Sprintf("night-%d", i), WorkflowName: "frontend-e2e", Status: "failed", StartTime: at})
+	}
+	db.InsertExecution(testkube.Execution{ID: "exec-other", WorkflowName: "api-smoke", Status: "passed", StartTime: tuesday.Add(21 * time.Hour)})
+	srv := NewServer(testkube.NewMockClient(), db, nil, "../..")
+	get := func(path string) *httptest.ResponseRecorder {
+		rr := httptest.NewRecorder()
+		srv.Router().ServeHTTP(rr, httptest.NewRequest("GET", path, nil))
+		return rr
+	}
+
+	rr := get("/api/v1/executions?workflow=frontend-e2e&from=2024-05-07T18:00&to=2024-05-08T00:00")
+	assert.Equal(t, http.StatusOK, rr.Code)
+	var page testkube.ExecutionPage
+	assert.NoError(t, json.NewDecoder(rr.Body).Decode(&page))
+	assert.Equal(t, 2, page.Total)
+	if assert.Len(t, page.Executions, 2) {
+		assert.Equal(t, "exec-night-2", page.Executions[0].ID, "newest first")
+		assert.Equal(t, "exec-night-1", page.Executions[1].ID)
+	}
+
+	rr = get("/api/v1/executions?from=2024-05-07&to=2024-05-07&pageSize=1&page=2")
+	assert.Equal(t, http.StatusOK, rr.Code)
+	page = testkube.ExecutionPage{}
+	assert.NoError(t, json.NewDecoder(rr.Body).Decode(&page))
+	assert.Equal(t, 3, page.Total, "a date alone for to includes the whole day")
+	assert.Len(t, page.Executions, 1)
+
+	rr = get("/workflows/frontend-e2e/history?from=2024-05-07T18:00&to=2024-05-08T00:00")
+	assert.Equal(t, http.StatusOK, rr.Code)
+	body := rr.Body.String()
+	assert.Contains(t, body, "night-1")
+	assert.Contains(t, body, "night-2")
+	assert.NotContains(t, body, "night-3")
+	assert.Contains(t, body, `value="2024-05-07T18:00"`)
+	assert.Contains(t, body, "2 executions")
+
+	for _, path := range []string{"/api/v1/executions?from=yesterday", "/workflows/frontend-e2e/history?from=2024-05-08&to=2024-05-07"} {
+		assert.Equal(t, http.StatusBadRequest, get(path).Code, path)
+	}
+}
+
+func TestWorkflowCRUD(t *testing.T) {
+	api := testkube.NewMockClient()
+	srv := NewServer(api, database.NewMockDatabase(), nil, "../..")
+	spec := `apiVersion: testworkflows.testkube.io/v1
+kind: TestWorkflow
+metadata:
+  name: checkout-e2e
+spec:
+  container:
+    image: mcr.microsoft.com/playwright:v1.47.0
+`
+	do := func(method, path, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set("X-Forwarded-User", "alice")
+		rr := httptest.NewRecorder()
+		srv.Router().ServeHTTP(rr, req)
+		return rr
+	}
+
+	rr := do("POST", "/api/v1/workflows", spec)
+	assert.Equal(t, http.StatusCreated, rr.Code)
+	assert.Contains(t, rr.Body.String(), `"Type":"playwright"`)
+	assert.Equal(t, http.StatusConflict, do("POST", "/api/v1/workflows", spec).Code)
+	assert.Equal(t, http.StatusBadRequest, do("POST", "/api/v1/workflows", "kind: Test").Code)
+
+	rr = do("GET", "/api/v1/workflows/checkout-e2e/spec", "")
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, spec, rr.Body.String())
+
+	rr = do("GET", "/workflows/checkout-e2e/edit", "")
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Contains(t, rr.Body.String(), "name: checkout-e2e")
+
+	// The editor form shows a rejected spec again with the error
+	form := url.Values{"spec": {strings.Replace(spec, "checkout-e2e", "other", 1)}}
+	req := httptest.NewRequest("POST", "/workflows/checkout-e2e/edit", strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	rr = httptest.NewRecorder()
+	srv.Router().ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+	assert.Contains(t, rr.Body.String(), "doesn&#39;t match workflow checkout-e2e")
+
+	form = url.Values{"spec": {strings.Replace(spec, "metadata:\n", "metadata:\n  labels:\n    team: payments\n", 1)}}
+	req = httptest.NewRequest("POST", "/workflows/checkout-e2e/edit", strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	rr = httptest.NewRecorder()
+	srv.Router().ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusSeeOther, rr.Code)
+	assert.Equal(t, "/workflows/checkout-e2e", rr.Header().Get("Location"))
+	wf, err := api.GetWorkflow("checkout-e2e")
+	assert.NoError(t, err)
+	assert.Equal(t, "payments", wf.Labels["team"])
+
+	rr = do("DELETE", "/workflows/checkout-e2e", "")
+	assert.Equal(t, http.StatusNoContent, rr.Code)
+	assert.Equal(t, "/workflows", rr.Header().Get("HX-Redirect"))
+	assert.Equal(t, http.StatusNotFound, do("DELETE", "/api/v1/workflows/checkout-e2e", "").Code)
+
+	rr = do("GET", "/api/v1/activity?type=workflow.created,workflow.updated,workflow.deleted", "")
+	var events []database.Event
+	assert.NoError(t, json.NewDecoder(rr.Body).Decode(&events))
+	assert.Len(t, events, 3)
+	assert.Equal(t, "alice", events[len(events)-1].Actor)
+}
+
+func TestWorkflowTypesAPI(t *testing.T) {
+	srv := NewServer(testkube.NewMockClient(), database.NewMockDatabase(), nil, "../..")
+
+	req := httptest.NewRequest("GET", "/api/v1/workflow-types", nil)
+	rr := httptest.NewRecorder()
+	srv.Router().ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusOK, rr.Code)
+	var types []testkube.WorkflowType
+	assert.NoError(t, json.NewDecoder(rr.Body).Decode(&types))
+	assert.Greater(t, len(types), 0)
+	assert.Equal(t, "playwright", types[0].Name)
+
+	// Workflows show their type's icon
+	req = httptest.NewRequest("GET", "/workflows", nil)
+	rr = httptest.NewRecorder()
+	srv.Router().ServeHTTP(rr, req)
+	assert.Contains(t, rr.Body.String(), `class="workflow-type"`)
+}
+
+func TestExecutionResultsRenderers(t *testing.T) {
+	api := testkube.NewMockClient()
+	srv := NewServer(api, database.NewMockDatabase(), nil, "../..")
+	finished := func(workflow string) testkube.Execution {
+		executions, err := api.GetExecutions(testkube.ListOptions{Workflow: workflow})
+		assert.NoError(t, err)
+		for _, e := range executions {
+			if e.Status == "passed" || e.Status == "failed" {
+				return e
+			}
+		}
+		t.Fatalf("no finished execution of %s", workflow)
+		return testkube.Execution{}
+	}
+	get := func(path string) *httptest.ResponseRecorder {
+		rr := httptest.NewRecorder()
+		srv.Router().ServeHTTP(rr, httptest.NewRequest("GET", path, nil))
+		return rr
+	}
+
+	// k6 executions show their load test metrics instead of test cases
+	exec := finished("api-load-test")
+	rr := get("/executions/" + exec.ID)
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Contains(t, rr.Body.String(), `/executions/`+exec.ID+`/results`)
+	assert.NotContains(t, rr.Body.String(), `id="test-cases"`)
+	rr = get("/executions/" + exec.ID + "/results")
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Contains(t, rr.Body.String(), "Load Test Metrics")
+	assert.Contains(t, rr.Body.String(), "summary.json")
+	assert.Contains(t, rr.Body.String(), "http_req_duration: p(95)&lt;500")
+
+	exec = finished("cluster-security")
+	rr = get("/executions/" + exec.ID + "/results")
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Contains(t, rr.Body.String(), "Security Findings")
+	assert.Contains(t, rr.Body.String(), "CVE-2023-45288")
+
+	// Types without a renderer keep the generic view
+	workflows, err := api.GetWorkflows(testkube.ListOptions{})
+	assert.NoError(t, err)
+	for _, wf := range workflows {
+		if wf.Type != "vitest" {
+			continue
+		}
+		exec = finished(wf.Name)
+		rr = get("/executions/" + exec.ID)
+		assert.Contains(t, rr.Body.String(), `id="test-cases"`)
+		assert.Equal(t, http.StatusNoContent, get("/executions/"+exec.ID+"/results").Code)
+		break
+	}
+}
+
+func TestRunWorkflowWithParameters(t *testing.T) {
+	api := testkube.NewMockClient()
+	srv := NewServer(api, database.NewMockDatabase(), nil, "../..")
+	_, err := api.CreateWorkflow([]byte(`apiVersion: testworkflows.testkube.io/v1
+kind: TestWorkflow
+metadata:
+  name: checkout-e2e
+spec:
+  config:
+    browser:
+      enum: [chromium, firefox]
+    workers:
+      type: integer
+      default: 2
+  container:
+    image: mcr.microsoft.com/playwright:v1.47.0
+`))
+	assert.NoError(t, err)
+
+	rr := httptest.NewRecorder()
+	srv.Router().ServeHTTP(rr, httptest.NewRequest("GET", "/workflows/checkout-e2e", nil))
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Contains(t, rr.Body.String(), `name="config.browser"`)
+	assert.Contains(t, rr.Body.String(), `name="config.workers" type="number"`)
+
+	// The run form sets the variables it was given, leaving the rest to
+	// the workflow's defaults
+	form := url.Values{"config.browser": {"firefox"}, "config.workers": {""}, "executionName": {"firefox-check"}}
+	req := httptest.NewRequest("POST", "/workflows/checkout-e2e/run", strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	rr = httptest.NewRecorder()
+	srv.Router().ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusOK, rr.Code)
+	executions, err := api.GetExecutions(testkube.ListOptions{Workflow: "checkout-e2e"})
+	assert.NoError(t, err)
+	assert.Len(t, executions, 1)
+	assert.Equal(t, "firefox-check", executions[0].Name)
+	logs, err := api.GetExecutionLogs(executions[0].ID)
+	assert.NoError(t, err)
+	assert.Contains(t, logs, "Config browser=firefox")
+	assert.NotContains(t, logs, "Config workers")
+
+	// The API takes the same parameters as JSON; the dashboard's own tags
+	// can't be overridden
+	body := `{"name": "api-run", "config": {"workers": "8"}, "tags": {"release": "1.2", "trigger": "schedule"}, "target": {"match": {"region": ["eu"]}}}`
+	rr = httptest.NewRecorder()
+	srv.Router().ServeHTTP(rr, httptest.NewRequest("POST", "/api/v1/workflows/checkout-e2e/run", strings.NewReader(body)))
+	assert.Equal(t, http.StatusCreated, rr.Code)
+	var exec testkube.Execution
+	assert.NoError(t, json.NewDecoder(rr.Body).Decode(&exec))
+	assert.Equal(t, "api-run", exec.Name)
+	assert.Equal(t, "1.2", exec.Labels["release"])
+	assert.Equal(t, testkube.TriggerCI, exec.Labels[testkube.TriggerTag])
+
+	// A JSON body is read once, CI metadata included
+	body = `{"config": {"browser": "webkit"}, "ci": {"pipeline": "deploy #77", "branch": "main"}}`
+	req = httptest.NewRequest("POST", "/api/v1/workflows/checkout-e2e/run", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	rr = httptest.NewRecorder()
+	srv.Router().ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusCreated, rr.Code, rr.Body.String())
+	assert.NoError(t, json.NewDecoder(rr.Body).Decode(&exec))
+	assert.Equal(t, "deploy #77", exec.Labels[CIPipelineTag])
+	assert.Equal(t, "main", exec.Labels[CIBranchTag])
+	logs, err = api.GetExecutionLogs(exec.ID)
+	assert.NoError(t, err)
+	assert.Contains(t, logs, "Config browser=webkit")
+
+	rr = httptest.NewRecorder()
+	srv.Router().ServeHTTP(rr, httptest.NewRequest("POST", "/api/v1/workflows/checkout-e2e/run", strings.NewReader(`{"config": [}`)))
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+}
+
+func TestClusters(t *testing.T) {
+	api, eu := testkube.NewMockClient(), testkube.NewMockClient()
+	srv := NewServer(api, database.NewMockDatabase(), nil, "../..")
+	clusters := testkube.NewClientRegistry("us", api)
+	assert.NoError(t, clusters.Register("eu", eu))
+	_, err := eu.CreateWorkflow([]byte(`apiVersion: testworkflows.testkube.io/v1
+kind: TestWorkflow
+metadata:
+  name: eu-smoke
+spec:
+  container:
+    image: grafana/k6:latest
+`))
+	assert.NoError(t, err)
+
+	get := func(path string, cookies ...*http.Cookie) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("GET", path, nil)
+		for _, c := range cookies {
+			req.AddCookie(c)
+		}
+		rr := httptest.NewRecorder()
+		srv.Router().ServeHTTP(rr, req)
+		return rr
+	}
+
+	// One cluster: no switcher
+	assert.Equal(t, http.StatusNoContent, get("/clusters/switcher").Code)
+	srv.SetClusters(clusters)
+
+	rr := get("/workflows")
+	assert.NotContains(t, rr.Body.String(), "eu-smoke")
+	rr = get("/workflows?cluster=eu")
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Contains(t, rr.Body.String(), "eu-smoke")
+	cookies := rr.Result().Cookies()
+	assert.Len(t, cookies, 1)
+
+	// The choice sticks for the browser's later requests
+	assert.Contains(t, get("/workflows", cookies...).Body.String(), "eu-smoke")
+	assert.Contains(t, get("/clusters/switcher", cookies...).Body.String(), `<option value="eu" selected>`)
+	assert.Equal(t, http.StatusNotFound, get("/workflows?cluster=ap").Code)
+
+	rr = get("/api/v1/clusters", cookies...)
+	assert.JSONEq(t, `[{"name":"us","default":true,"selected":false},{"name":"eu","default":false,"selected":true}]`, rr.Body.String())
+
+	// Runs start on the selected cluster, bypassing the default's run queue
+	req := httptest.NewRequest("POST", "/api/v1/workflows/eu-smoke/run", nil)
+	req.Header.Set("X-Testkube-Cluster", "eu")
+	rr = httptest.NewRecorder()
+	srv.Router().ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusCreated, rr.Code)
+	var exec testkube.Execution
+	assert.NoError(t, json.NewDecoder(rr.Body).Decode(&exec))
+	_, err = eu.GetExecution(exec.ID)
+	assert.NoError(t, err)
+	started, err := api.GetExecutions(testkube.ListOptions{Workflow: "eu-smoke"})
+	assert.NoError(t, err)
+	assert.Empty(t, started)
+
+	// The execution page's fragments read the selected cluster, and the
+	// actions covering only the default one are left out
+	assert.Equal(t, http.StatusOK, get("/executions/"+exec.ID+"/visual", cookies...).Code)
+	rr = get("/executions/"+exec.ID, cookies...)
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.NotContains(t, rr.Body.String(), "Create share link")
+	req = httptest.NewRequest("POST", "/api/v1/executions/"+exec.ID+"/share", nil)
+	req.Header.Set("X-Testkube-Cluster", "eu")
+	rr = httptest.NewRecorder()
+	srv.Router().ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+
+	// Date ranges search the default cluster's ingested history only
+	assert.Equal(t, http.StatusBadRequest, get("/api/v1/executions?cluster=eu&from=2026-01-01T00:00").Code)
+}
+
+func TestWorkflowTemplates(t *testing.T) {
+	api := testkube.NewMockClient()
+	srv := NewServer(api, database.NewMockDatabase(), nil, "../..")
+	_, err := api.CreateWorkflow([]byte(`apiVersion: testworkflows.testkube.io/v1
+kind: TestWorkflow
+metadata:
+  name: nightly-report
+spec:
+  use:
+    - name: retired-template
+  steps:
+    - name: Report
+      shell: ./report.sh
+`))
+	assert.NoError(t, err)
+
+	get := func(path string) *httptest.ResponseRecorder {
+		rr := httptest.NewRecorder()
+		srv.Router().ServeHTTP(rr, httptest.NewRequest("GET", path, nil))
+		return rr
+	}
+
+	rr := get("/api/v1/workflow-templates?selector=testkube.io/official=true")
+	assert.Equal(t, http.StatusOK, rr.Code)
+	var templates []struct {
+		Name   string   `json:"name"`
+		UsedBy []string `json:"usedBy"`
+	}
+	assert.NoError(t, json.NewDecoder(rr.Body).Decode(&templates))
+	assert.Len(t, templates, 2)
+	for _, tmpl := range templates {
+		if tmpl.Name == "official/k6/v1" {
+			assert.Contains(t, tmpl.UsedBy, "api-load-test")
+		}
+	}
+	assert.Equal(t, http.StatusBadRequest, get("/api/v1/workflow-templates?selector=team%20in").Code)
+
+	// The page also lists templates workflows use that the cluster lacks
+	rr = get("/templates")
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Contains(t, rr.Body.String(), `href="/templates/official/k6/v1"`)
+	assert.Contains(t, rr.Body.String(), "retired-template")
+
+	rr = get("/templates/official/k6/v1")
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Contains(t, rr.Body.String(), `href="/workflows/api-load-test"`)
+	assert.Equal(t, http.StatusNotFound, get("/templates/retired-template").Code)
+	assert.Equal(t, http.StatusNotFound, get("/api/v1/workflow-templates/retired-template").Code)
+
+	assert.Contains(t, get("/workflows/api-load-test").Body.String(), `href="/templates/official/k6/v1"`)
+}
+
+func TestRunSmokeTest(t *testing.T) {
+	api := testkube.NewMockClient()
+	srv := NewServer(api, database.NewMockDatabase(), nil, "../..")
+	runWaitPollInterval = 10 * time.Millisecond
+	defer func() { runWaitPollInterval = 5 * time.Second }()
+
+	// The mock takes seconds to finish a run, so this gives up first
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	env := environments.Environment{ID: "e1", Name: "feature-x", Owner: "dev@example.com"}
+	id, err := srv.runSmokeTest(ctx, env, environments.SmokeTest{Workflow: "frontend-e2e"})
+	assert.Error(t, err)
+	assert.False(t, errors.Is(err, environments.ErrSmokeTestFailed))
+
+	exec, err := api.GetExecution(id)
+	if assert.NoError(t, err) {
+		assert.Equal(t, "frontend-e2e", exec.WorkflowName)
+		assert.Equal(t, "e1", exec.Labels[SmokeEnvironmentTag])
+		assert.Equal(t, "dev@example.com", exec.TriggeredBy)
+	}
+}
+
+func TestHandleOfflineBundle(t *testing.T) {
+	srv := NewServer(testkube.NewMockClient(), database.NewMockDatabase(), nil, "../..")
+
+	req, err := http.NewRequest("GET", "/workflows/frontend-e2e/history/bundle?days=7", nil)
+	assert.NoError(t, err)
+	rr := httptest.NewRecorder()
+	srv.Router().ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, "application/zip", rr.Header().Get("Content-Type"))
+	assert.Contains(t, rr.Header().Get("Content-Disposition"), "frontend-e2e-history-")
+	zr, err := zip.NewReader(bytes.NewReader(rr.Body.Bytes()), int64(rr.Body.Len()))
+	assert.NoError(t, err)
+	names := map[string]bool{}
+	for _, f := range zr.File {
+		names[f.Name] = true
+	}
+	assert.True(t, names["index.html"])
+	assert.True(t, names["charts/pass-rate.svg"])
+	assert.True(t, names["artifacts.json"])
+}
+
+func TestWorkflowListLegacyKinds(t *testing.T) {
+	srv := NewServer(testkube.NewMockClient(), database.NewMockDatabase(), nil, "../..")
+	get := func(path string) *httptest.ResponseRecorder {
+		rr := httptest.NewRecorder()
+		srv.Router().ServeHTTP(rr, httptest.NewRequest("GET", path, nil))
+		return rr
+	}
+
+	rr := get("/workflows")
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Contains(t, rr.Body.String(), `<a href="/workflows/frontend-e2e">`)
+	assert.Contains(t, rr.Body.String(), "checkout-cypress")
+	assert.NotContains(t, rr.Body.String(), `href="/workflows/checkout-cypress"`)
+	assert.Contains(t, rr.Body.String(), `Runs <a href="/legacy/Test/checkout-cypress">checkout-cypress</a>, <a href="/legacy/Test/orders-postman">orders-postman</a>`)
+	assert.Contains(t, rr.Body.String(), `hx-post="/legacy/Test/checkout-cypress/run"`)
+
+	rr = get("/workflows?kind=TestSuite")
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Contains(t, rr.Body.String(), "nightly-regression")
+	assert.NotContains(t, rr.Body.String(), "frontend-e2e")
+
+	rr = get("/workflows?kind=Test&selector=team%3Dfrontend")
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Contains(t, rr.Body.String(), "checkout-cypress")
+	assert.NotContains(t, rr.Body.String(), "orders-postman")
+
+	assert.Equal(t, http.StatusBadRequest, get("/workflows?kind=Job").Code)
+	assert.Equal(t, http.StatusBadRequest, get("/workflows?kind=Test&selector=team+in+x").Code)
+}
+
+func TestExecutionResources(t *testing.T) {
+	api := testkube.NewMockClient()
+	srv := NewServer(api, database.NewMockDatabase(), nil, "../..")
+	executions, _ := api.GetExecutions(testkube.ListOptions{})
+	var oom, measured string
+	for _, exec := range executions {
+		usage, _ := api.GetExecutionResources(exec.ID)
+		if usage.OOMKilled() {
+			oom = exec.ID
+		} else if usage.Measured() {
+			measured = exec.ID
+		}
+	}
+
+	rr := httptest.NewRecorder()
+	srv.Router().ServeHTTP(rr, httptest.NewRequest("GET", "/executions/"+measured+"/resources", nil))
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Contains(t, rr.Body.String(), "Run tests")
+	assert.Contains(t, rr.Body.String(), "MB")
+	assert.NotContains(t, rr.Body.String(), "Out of memory")
+
+	rr = httptest.NewRecorder()
+	srv.Router().ServeHTTP(rr, httptest.NewRequest("GET", "/executions/"+oom+"/resources", nil))
+	assert.Contains(t, rr.Body.String(), "Out of memory")
+	assert.Contains(t, rr.Body.String(), "OOMKilled")
+
+	rr = httptest.NewRecorder()
+	srv.Router().ServeHTTP(rr, httptest.NewRequest("GET", "/executions/missing/resources", nil))
+	assert.Equal(t, http.StatusInternalServerError, rr.Code)
+}
+
+func TestTestLinks(t *testing.T) {
+	db := database.NewMockDatabase()
+	srv := NewServer(testkube.NewMockClient(), db, nil, "../..")
+	assert.NoError(t, db.SaveTestLink(database.TestLink{
+		Workflow: "web", From: "adds item to cart as guest", To: "adds item to cart", FilePath: "cart.spec.ts",
+		Similarity: 0.8, Status: database.TestLinkSuggested,
+	}))
+
+	rr := httptest.NewRecorder()
+	srv.Router().ServeHTTP(rr, httptest.NewRequest("GET", "/tests/links", nil))
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Contains(t, rr.Body.String(), "Needs Review (1)")
+	assert.Contains(t, rr.Body.String(), "adds item to cart as guest")
+
+	form := url.Values{"workflow": {"web"}, "from": {"adds item to cart as guest"}, "to": {"adds item to cart"}, "status": {"merged"}}
+	req := httptest.NewRequest("POST", "/tests/links", strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("X-Forwarded-User", "alice")
+	rr = httptest.NewRecorder()
+	srv.Router().ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Contains(t, rr.Body.String(), "Linked (1)")
+	links, _ := db.GetTestLinks("web")
+	assert.Len(t, links, 1)
+	assert.Equal(t, database.TestLinkMerged, links[0].Status)
+	assert.Equal(t, "alice", links[0].UpdatedBy)
+	assert.Equal(t, "cart.spec.ts", links[0].FilePath)
+
+	// Split through the API
+	rr = httptest.NewRecorder()
+	srv.Router().ServeHTTP(rr, httptest.NewRequest("POST", "/api/v1/tests/links",
+		strings.NewReader(`{"workflow": "web", "from": "adds item to cart as guest", "to": "adds item to cart", "status": "split"}`)))
+	assert.Equal(t, http.StatusNoContent, rr.Code)
+	rr = httptest.NewRecorder()
+	srv.Router().ServeHTTP(rr, httptest.NewRequest("GET", "/api/v1/tests/links?workflow=web", nil))
+	assert.Contains(t, rr.Body.String(), `"status":"split"`)
+
+	for _, body := range []string{
+		`{"workflow": "web", "from": "a", "to": "a", "status": "merged"}`,
+		`{"workflow": "web", "from": "a", "to": "b", "status": "auto"}`,
+		`{"from": "a", "to": "b", "status": "merged"}`,
+	} {
+		rr = httptest.NewRecorder()
+		srv.Router().ServeHTTP(rr, httptest.NewRequest("POST", "/api/v1/tests/links", strings.NewReader(body)))
+		assert.Equal(t, http.StatusBadRequest, rr.Code, body)
+	}
+}
+
+func TestWorkflowSchedules(t *testing.T) {
+	api := testkube.NewMockClient()
+	srv := NewServer(api, database.NewMockDatabase(), nil, "../..")
+	do := func(method, path, contentType, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set("Content-Type", contentType)
+		rr := httptest.NewRecorder()
+		srv.Router().ServeHTTP(rr, req)
+		return rr
+	}
+	form := func(values url.Values) *httptest.ResponseRecorder {
+		return do("POST", "/workflows/cluster-security/schedules", "application/x-www-form-urlencoded", values.Encode())
+	}
+
+	rr := do("GET", "/workflows/cluster-security", "", "")
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Contains(t, rr.Body.String(), "Schedules (1)")
+	assert.Contains(t, rr.Body.String(), `value="0 2 * * *"`)
+
+	rr = form(url.Values{"action": {"pause"}, "index": {"0"}})
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Contains(t, rr.Header().Get("HX-Trigger"), "Paused schedule")
+	assert.Contains(t, rr.Body.String(), "Resume")
+
+	rr = form(url.Values{"action": {"save"}, "cron": {"15  4 * * *"}, "timezone": {"Europe/London"}})
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, http.StatusBadRequest, form(url.Values{"action": {"save"}, "cron": {"not a cron"}}).Code)
+	assert.Equal(t, http.StatusBadRequest, form(url.Values{"action": {"pause"}, "index": {"5"}}).Code)
+
+	rr = do("GET", "/api/v1/workflows/cluster-security/schedules", "", "")
+	assert.Equal(t, http.StatusOK, rr.Code)
+	var list []struct {
+		testkube.WorkflowSchedule
+		NextRun *time.Time `json:"nextRun"`
+	}
+	assert.NoError(t, json.NewDecoder(rr.Body).Decode(&list))
+	if assert.Len(t, list, 2) {
+		assert.Equal(t, "15 4 * * *", list[0].Cron)
+		assert.NotNil(t, list[0].NextRun)
+		assert.True(t, list[1].Paused)
+		assert.Nil(t, list[1].NextRun)
+	}
+
+	rr = do("PUT", "/api/v1/workflows/cluster-security/schedules", "application/json", `[{"cron": "0 3 * * 1"}]`)
+	assert.Equal(t, http.StatusOK, rr.Code)
+	got, err := api.GetWorkflowSchedules("cluster-security")
+	assert.NoError(t, err)
+	assert.Equal(t, []testkube.WorkflowSchedule{{Cron: "0 3 * * 1"}}, got)
+	assert.Equal(t, http.StatusBadRequest, do("PUT", "/api/v1/workflows/cluster-security/schedules", "application/json", `[{"cron": "* *"}]`).Code)
+	assert.Equal(t, http.StatusNotFound, do("GET", "/api/v1/workflows/missing/schedules", "", "").Code)
+}
+
+func TestArchivedWorkflows(t *testing.T) {
+	api := testkube.NewMockClient()
+	db := database.NewMockDatabase()
+	srv := NewServer(api, db, nil, "../..")
+	now := time.Now()
+	for i, wf := range []string{"legacy-smoke", "legacy-smoke", "frontend-e2e"} {
+		id := fmt.Sprintf("exec-%d", i)
+		db.InsertExecution(testkube.Execution{ID: id, Name: id, WorkflowName: wf, Status: "passed", StartTime: now.Add(-time.Duration(i) * time.Hour)})
+		db.InsertTestCase(database.TestCase{ExecutionID: id, TestName: "loads", Status: "passed"})
+	}
+	db.SaveVariablePreset(database.VariablePreset{Workflow: "legacy-smoke", Name: "staging", Variables: map[string]string{"ENV": "staging"}})
+	do := func(method, path string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, nil)
+		req.Header.Set("X-Forwarded-User", "alice")
+		rr := httptest.NewRecorder()
+		srv.Router().ServeHTTP(rr, req)
+		return rr
+	}
+
+	// Only workflows gone from Testkube are archived
+	rr := do("GET", "/api/v1/workflows/archived")
+	assert.Equal(t, http.StatusOK, rr.Code)
+	var archived []database.WorkflowHistory
+	assert.NoError(t, json.NewDecoder(rr.Body).Decode(&archived))
+	if assert.Len(t, archived, 1) {
+		assert.Equal(t, "legacy-smoke", archived[0].Workflow)
+		assert.Equal(t, 2, archived[0].Executions)
+	}
+
+	rr = do("GET", "/workflows/legacy-smoke")
+	assert.Equal(t, http.StatusSeeOther, rr.Code)
+	assert.Equal(t, "/workflows/archived/legacy-smoke", rr.Header().Get("Location"))
+	rr = do("GET", "/workflows/archived/legacy-smoke")
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Contains(t, rr.Body.String(), "no longer exists in Testkube")
+	assert.Contains(t, rr.Body.String(), "1 passed, 0 failed")
+	assert.Equal(t, http.StatusSeeOther, do("GET", "/workflows/archived/frontend-e2e").Code)
+	assert.Equal(t, http.StatusNotFound, do("GET", "/workflows/archived/never-existed").Code)
+	assert.Contains(t, do("GET", "/workflows/archived").Body.String(), "legacy-smoke")
+
+	// A workflow still in Testkube can't be purged
+	assert.Equal(t, http.StatusConflict, do("DELETE", "/api/v1/workflows/archived/frontend-e2e").Code)
+
+	// Only admins may purge once ADMIN_USERS is set
+	srv.admins = parseAdmins("bob")
+	assert.NotContains(t, do("GET", "/workflows/archived/legacy-smoke").Body.String(), "Purge history")
+	assert.Equal(t, http.StatusForbidden, do("DELETE", "/workflows/archived/legacy-smoke").Code)
+	assert.Equal(t, http.StatusForbidden, do("DELETE", "/api/v1/workflows/archived/legacy-smoke").Code)
+	_, total, _ := db.QueryExecutions(database.ExecutionQuery{Workflows: []string{"legacy-smoke"}})
+	assert.Equal(t, 2, total)
+	srv.admins = parseAdmins("alice")
+
+	rr = do("DELETE", "/workflows/archived/legacy-smoke")
+	assert.Equal(t, http.StatusNoContent, rr.Code)
+	assert.Equal(t, "/workflows/archived", rr.Header().Get("HX-Redirect"))
+	_, total, _ = db.QueryExecutions(database.ExecutionQuery{Workflows: []string{"legacy-smoke"}})
+	assert.Equal(t, 0, total)
+	snapshot, _ := db.Snapshot()
+	assert.Len(t, snapshot.TestCases, 1)
+	presets, _ := db.GetVariablePresets("legacy-smoke")
+	assert.Empty(t, presets)
+	_, total, _ = db.QueryExecutions(database.ExecutionQuery{Workflows: []string{"frontend-e2e"}})
+	assert.Equal(t, 1, total)
+
+	events, _ := db.GetEvents(database.EventFilter{Types: []string{"workflow.purged"}})
+	if assert.Len(t, events, 1) {
+		assert.Equal(t, "alice", events[0].Actor)
+		assert.Equal(t, "Purged the history of 2 executions", events[0].Message)
+	}
+}
+
+func TestWatchlist(t *testing.T) {
+	db := database.NewMockDatabase()
+	srv := NewServer(testkube.NewMockClient(), db, nil, "../..")
+	do := func(method, path, user string, body url.Values) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		if user != "" {
+			req.Header.Set("X-Forwarded-User", user)
+		}
+		rr := httptest.NewRecorder()
+		srv.Router().ServeHTTP(rr, req)
+		return rr
+	}
+
+	assert.Equal(t, http.StatusUnauthorized, do("GET", "/watchlist", "", nil).Code)
+	assert.NotContains(t, do("GET", "/workflows/frontend-e2e", "", nil).Body.String(), `class="watch-button`)
+
+	// Starring from the workflow page notifies of nothing yet
+	rr := do("POST", "/watchlist/star?workflow=frontend-e2e", "alice", nil)
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Contains(t, rr.Body.String(), "watching")
+	assert.Contains(t, do("GET", "/workflows/frontend-e2e", "alice", nil).Body.String(), `class="watch-button watching"`)
+	watches, _ := db.GetWatches("alice")
+	if assert.Len(t, watches, 1) {
+		assert.Empty(t, watches[0].Events)
+	}
+
+	rr = do("POST", "/watchlist", "alice", url.Values{"workflow": {"frontend-e2e"}, "events": {"failure", "flaky"}})
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Contains(t, rr.Header().Get("HX-Trigger"), "Saved watch of frontend-e2e")
+	rr = do("POST", "/watchlist", "alice", url.Values{"workflow": {"frontend-e2e"}, "test": {"Submit Form"}, "events": {"recovery"}})
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, http.StatusBadRequest, do("POST", "/watchlist", "alice", url.Values{"workflow": {"frontend-e2e"}, "events": {"always"}}).Code)
+	watches, _ = db.GetWatches("alice")
+	if assert.Len(t, watches, 2) {
+		assert.Equal(t, []string{"failure", "flaky"}, watches[0].Events)
+		assert.Equal(t, "Submit Form", watches[1].Test)
+	}
+
+	assert.Equal(t, http.StatusBadRequest, do("POST", "/watchlist/channel", "alice", url.Values{"webhookUrl": {"not a url"}}).Code)
+	rr = do("POST", "/watchlist/channel", "alice", url.Values{"webhookUrl": {"https://hooks.example.com/alice"}})
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Contains(t, rr.Body.String(), `value="https://hooks.example.com/alice"`)
+
+	// Watchlists are per user
+	rr = do("GET", "/api/v1/watchlist", "bob", nil)
+	assert.Equal(t, "[]\n", rr.Body.String())
+	rr = do("GET", "/watchlist", "alice", nil)
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Contains(t, rr.Body.String(), "Submit Form")
+
+	rr = do("DELETE", "/watchlist?workflow=frontend-e2e&test=Submit+Form", "alice", nil)
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.NotContains(t, rr.Body.String(), "Submit Form")
+	rr = do("POST", "/watchlist/star?workflow=frontend-e2e", "alice", nil)
+	assert.NotContains(t, rr.Body.String(), "watching")
+	watches, _ = db.GetWatches("alice")
+	assert.Empty(t, watches)
+}
+
+func TestHandleRerunExecution(t *testing.T) {
+	api := testkube.NewMockClient()
+	srv := NewServer(api, database.NewMockDatabase(), nil, "../..")
+
+	original, err := api.RunWorkflow("frontend-e2e", testkube.RunOptions{
+		Config: map[string]string{"browser": "firefox"},
+		Tags:   map[string]string{testkube.TriggerTag: testkube.TriggerCI, testkube.TriggeredByTag: "pipeline", "ci-commit": "abc123"},
+	})
+	assert.NoError(t, err)
+
+	req := httptest.NewRequest("POST", "/executions/"+original.ID+"/rerun", nil)
+	req.Header.Set("X-Forwarded-User", "alice")
+	rr := httptest.NewRecorder()
+	srv.Router().ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusCreated, rr.Code)
+	assert.Contains(t, rr.Header().Get("HX-Redirect"), "/executions/")
+
+	latest, err := api.GetExecutions(testkube.ListOptions{PageSize: 1})
+	assert.NoError(t, err)
+	rerun := latest[0]
+	assert.Equal(t, original.ID, rerun.Labels[RerunOfTag])
+	assert.Equal(t, "identical", rerun.Labels[RerunTypeTag])
+	assert.Equal(t, "abc123", rerun.Labels["ci-commit"])
+	assert.Equal(t, testkube.TriggerRetry, rerun.Labels[testkube.TriggerTag])
+	assert.Equal(t, "alice", rerun.Labels[testkube.TriggeredByTag])
+	logs, err := api.GetExecutionLogs(rerun.ID)
+	assert.NoError(t, err)
+	assert.Contains(t, logs, "Config browser=firefox")
+
+	req = httptest.NewRequest("POST", "/api/v1/executions/missing/rerun", nil)
+	rr = httptest.NewRecorder()
+	srv.Router().ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+}
+
+func TestBulkRun(t *testing.T) {
+	api := testkube.NewMockClient()
+	srv := NewServer(api, database.NewMockDatabase(), nil, "../..")
+
+	body := `{"workflows": ["backend-integration", "missing"], "selector": "suite=load", "tags": {"release": "1.4"}}`
+	req := httptest.NewRequest("POST", "/api/v1/runs/bulk", strings.NewReader(body))
+	rr := httptest.NewRecorder()
+	srv.Router().ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusMultiStatus, rr.Code)
+
+	var resp bulkRunResponse
+	assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+	assert.NotEmpty(t, resp.GroupID)
+	assert.Len(t, resp.Runs, 4)
+	assert.Equal(t, "backend-integration", resp.Runs[0].Workflow)
+	assert.Equal(t, http.StatusCreated, resp.Runs[0].Status)
+	assert.Equal(t, "missing", resp.Runs[1].Workflow)
+	assert.NotEmpty(t, resp.Runs[1].Error)
+	assert.Equal(t, 1, resp.Failed)
+	assert.Equal(t, len(resp.Runs)-1, resp.Started+resp.Queued)
+
+	exec, err := api.GetExecution(resp.Runs[0].ExecutionID)
+	assert.NoError(t, err)
+	assert.Equal(t, resp.GroupID, exec.Labels[RunGroupTag])
+	assert.Equal(t, "1.4", exec.Labels["release"])
+
+	req = httptest.NewRequest("GET", "/api/v1/runs/groups/"+resp.GroupID, nil)
+	rr = httptest.NewRecorder()
+	srv.Router().ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusOK, rr.Code)
+	var group runGroup
+	assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &group))
+	assert.Equal(t, resp.Started, len(group.Executions))
+
+	// With a JSON Content-Type too, every run starts with the CI metadata
+	body = `{"workflows": ["frontend-e2e", "backend-integration"], "ci": {"pipeline": "nightly #9"}}`
+	req = httptest.NewRequest("POST", "/api/v1/runs/bulk", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	rr = httptest.NewRecorder()
+	srv.Router().ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusCreated, rr.Code, rr.Body.String())
+	assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+	assert.Equal(t, 0, resp.Failed)
+	assert.Equal(t, 2, resp.Started+resp.Queued)
+	for _, run := range resp.Runs {
+		if run.ExecutionID == "" {
+			continue
+		}
+		exec, err := api.GetExecution(run.ExecutionID)
+		assert.NoError(t, err)
+		assert.Equal(t, "nightly #9", exec.Labels[CIPipelineTag])
+	}
+
+	req = httptest.NewRequest("GET", "/api/v1/runs/groups/unknown", nil)
+	rr = httptest.NewRecorder()
+	srv.Router().ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+
+	req = httptest.NewRequest("POST", "/api/v1/runs/bulk", strings.NewReader(`{"selector": "team=nobody"}`))
+	rr = httptest.NewRecorder()
+	srv.Router().ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+}
+
+func TestPageCache(t *testing.T) {
+	srv := NewServer(testkube.NewMockClient(), database.NewMockDatabase(), nil, "../..")
+	get := func(user string, header ...string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("GET", "/workflows?selector=team%3Dfrontend", nil)
+		req.Header.Set("X-Forwarded-User", user)
+		for i := 0; i+1 < len(header); i += 2 {
+			req.Header.Set(header[i], header[i+1])
+		}
+		rr := httptest.NewRecorder()
+		srv.Router().ServeHTTP(rr, req)
+		return rr
+	}
+
+	first := get("alice")
+	assert.Equal(t, http.StatusOK, first.Code)
+	assert.Equal(t, "miss", first.Header().Get("X-Page-Cache"))
+	cached := get("alice")
+	assert.Equal(t, "hit", cached.Header().Get("X-Page-Cache"))
+	assert.Equal(t, first.Body.String(), cached.Body.String())
+	assert.Equal(t, first.Header().Get("Content-Type"), cached.Header().Get("Content-Type"))
+
+	assert.Equal(t, "miss", get("bob").Header().Get("X-Page-Cache"), "pages are cached per user")
+	assert.Equal(t, "miss", get("alice", "HX-Request", "true", "HX-Target", "workflow-rows").Header().Get("X-Page-Cache"))
+	assert.Equal(t, "miss", get("alice", "Cache-Control", "no-cache").Header().Get("X-Page-Cache"))
+
+	srv.ExecutionIngested(testkube.Execution{ID: "exec-new", WorkflowName: "frontend-e2e", Status: "passed"}, nil)
+	assert.Equal(t, "miss", get("alice").Header().Get("X-Page-Cache"), "ingestion drops cached pages")
+}
+
+func TestArtifactsZip(t *testing.T) {
+	api := testkube.NewMockClient()
+	srv := NewServer(api, database.NewMockDatabase(), nil, "../..")
+	list, err := api.GetArtifacts("exec-1")
+	assert.NoError(t, err)
+	assert.NotEmpty(t, list)
+
+	rr := httptest.NewRecorder()
+	srv.Router().ServeHTTP(rr, httptest.NewRequest("GET", "/executions/exec-1/artifacts.zip", nil))
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, "application/zip", rr.Header().Get("Content-Type"))
+	assert.Contains(t, rr.Header().Get("Content-Disposition"), "exec-1-artifacts.zip")
+
+	zr, err := zip.NewReader(bytes.NewReader(rr.Body.Bytes()), int64(rr.Body.Len()))
+	if assert.NoError(t, err) {
+		assert.Len(t, zr.File, len(list))
+		for i, f := range zr.File {
+			assert.Equal(t, zipEntryName(list[i].Path), f.Name)
+			body, err := f.Open()
+			if assert.NoError(t, err) {
+				data, _ := io.ReadAll(body)
+				expected, _ := api.DownloadArtifact("exec-1", list[i].Path)
+				assert.Equal(t, expected, data)
+			}
+		}
+	}
+
+	assert.Equal(t, "reports/index.html", zipEntryName("../../reports/index.html"))
+
+	rr = httptest.NewRecorder()
+	srv.Router().ServeHTTP(rr, httptest.NewRequest("GET", "/executions/exec-1/artifacts", nil))
+	assert.Contains(t, rr.Body.String(), "/executions/exec-1/artifacts.zip")
+}
+
+func TestWeeklyReport(t *testing.T) {
+	db := database.NewMockDatabase()
+	srv := NewServer(testkube.NewMockClient(), db, nil, "../..")
+	week := reports.WeekStart(time.Now()).AddDate(0, 0, -7)
+	for i, status := range []string{"passed", "failed", "passed"} {
+		db.InsertExecution(testkube.Execution{ID: fmt.Sprintf("w-%d", i), WorkflowName: "frontend-e2e", Status: status, StartTime: week.Add(time.Duration(i+1) * time.Hour)})
+	}
+
+	rr := httptest.NewRecorder()
+	srv.Router().ServeHTTP(rr, httptest.NewRequest("GET", "/reports/weekly", nil))
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Contains(t, rr.Body.String(), "No weekly reports yet")
+
+	rr = httptest.NewRecorder()
+	srv.Router().ServeHTTP(rr, httptest.NewRequest("POST", "/api/v1/reports/weekly", nil))
+	assert.Equal(t, http.StatusCreated, rr.Code)
+	var report reports.WeeklyReport
+	assert.NoError(t, json.NewDecoder(rr.Body).Decode(&report))
+	assert.Equal(t, 3, report.Runs)
+	assert.Equal(t, week.Format("2006-01-02"), report.Period())
+
+	rr = httptest.NewRecorder()
+	srv.Router().ServeHTTP(rr, httptest.NewRequest("GET", "/reports/weekly", nil))
+	assert.Equal(t, http.StatusFound, rr.Code)
+	assert.Equal(t, "/reports/weekly/"+report.Period(), rr.Header().Get("Location"))
+
+	// Any date in the week finds its report
+	rr = httptest.NewRecorder()
+	srv.Router().ServeHTTP(rr, httptest.NewRequest("GET", "/reports/weekly/"+week.AddDate(0, 0, 3).Format("2006-01-02"), nil))
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Contains(t, rr.Body.String(), "66.7%")
+
+	rr = httptest.NewRecorder()
+	srv.Router().ServeHTTP(rr, httptest.NewRequest("GET", "/reports/weekly/"+report.Period()+"?format=md", nil))
+	assert.Contains(t, rr.Body.String(), "# Weekly Quality Report")
+
+	rr = httptest.NewRecorder()
+	srv.Router().ServeHTTP(rr, httptest.NewRequest("GET", "/api/v1/reports/weekly/2020-01-01", nil))
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+}
+
+func TestAbout(t *testing.T) {
+	srv := NewServer(testkube.NewMockClient(), database.NewMockDatabase(), nil, "../..")
+
+	rr := httptest.NewRecorder()
+	srv.Router().ServeHTTP(rr, httptest.NewRequest("GET", "/about", nil))
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Contains(t, rr.Body.String(), "2.1.0-mock")
+	assert.Contains(t, rr.Body.String(), "standalone")
+
+	rr = httptest.NewRecorder()
+	srv.Router().ServeHTTP(rr, httptest.NewRequest("GET", "/api/v1/info", nil))
+	assert.Equal(t, http.StatusOK, rr.Code)
+	var info testkube.ServerInfo
+	assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &info))
+	assert.Equal(t, testkube.AgentStandalone, info.AgentMode)
+}
+
+func TestFlakyTestsAPIFilters(t *testing.T) {
+	db := database.NewMockDatabase()
+	srv := NewServer(testkube.NewMockClient(), db, nil, "../..")
+	assert.NoError(t, srv.ownership.Import(json.RawMessage(`{"rules": [{"workflow": "frontend-*", "team": "web"}]}`)))
+
+	now := time.Now()
+	for i, wf := range []string{"frontend-e2e", "frontend-e2e", "frontend-e2e", "api-tests", "api-tests"} {
+		id := fmt.Sprintf("f-%d", i)
+		db.InsertExecution(testkube.Execution{ID: id, WorkflowName: wf, Status: "passed", StartTime: now.Add(-time.Duration(i+1) * time.Hour)})
+		status := "passed"
+		if i%2 == 0 {
+			status = "failed"
+		}
+		db.InsertTestCase(database.TestCase{ExecutionID: id, TestName: "checkout", Status: status})
+		db.InsertTestCase(database.TestCase{ExecutionID: id, TestName: "login", Status: "passed"})
+	}
+
+	get := func(url string) []database.FlakyTest {
+		rr := httptest.NewRecorder()
+		srv.Router().ServeHTTP(rr, httptest.NewRequest("GET", url, nil))
+		assert.Equal(t, http.StatusOK, rr.Code, url)
+		var tests []database.FlakyTest
+		assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &tests))
+		return tests
+	}
+
+	tests := get("/api/v1/flaky-tests?days=7&sort=runs")
+	if assert.Len(t, tests, 2) {
+		assert.Equal(t, "frontend-e2e", tests[0].WorkflowName)
+		assert.Equal(t, 3, tests[0].TotalRuns)
+	}
+	tests = get("/api/v1/flaky-tests?days=7&team=web")
+	if assert.Len(t, tests, 1) {
+		assert.Equal(t, "frontend-e2e", tests[0].WorkflowName)
+	}
+	assert.Len(t, get("/api/v1/flaky-tests?days=7&workflow=api-tests"), 1)
+	assert.Len(t, get("/api/v1/flaky-tests?days=7&min_runs=3"), 1)
+	assert.Len(t, get("/api/v1/flaky-tests?days=7&limit=1"), 1)
+
+	for _, url := range []string{"/api/v1/flaky-tests?sort=oldest", "/api/v1/flaky-tests?days=week"} {
+		rr := httptest.NewRecorder()
+		srv.Router().ServeHTTP(rr, httptest.NewRequest("GET", url, nil))
+		assert.Equal(t, http.StatusBadRequest, rr.Code, url)
+	}
+}
+
+func TestTestCasesAPI(t *testing.T) {
+	db := database.NewMockDatabase()
+	srv := NewServer(testkube.NewMockClient(), db, nil, "../..")
+
+	now := time.Now()
+	for i := 0; i < 3; i++ {
+		id := fmt.Sprintf("tc-%d", i)
+		db.InsertExecution(testkube.Execution{ID: id, WorkflowName: "frontend-e2e", Status: "passed", StartTime: now.AddDate(0, 0, -i*3)})
+		db.InsertTestCase(database.TestCase{ExecutionID: id, TestName: "Checkout works", Status: []string{"failed", "passed", "failed"}[i]})
+		db.InsertTestCase(database.TestCase{ExecutionID: id, TestName: "login", Status: "passed"})
+	}
+
+	rr := httptest.NewRecorder()
+	srv.Router().ServeHTTP(rr, httptest.NewRequest("GET", "/api/v1/test-cases?name=checkout&status=failed", nil))
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, "2", rr.Header().Get("X-Total-Count"))
+	var results []database.TestCaseResult
+	assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &results))
+	if assert.Len(t, results, 2) {
+		assert.Equal(t, "tc-0", results[0].ExecutionID)
+		assert.Equal(t, "frontend-e2e", results[0].WorkflowName)
+	}
+
+	from := now.AddDate(0, 0, -4).Format(time.RFC3339)
+	rr = httptest.NewRecorder()
+	srv.Router().ServeHTTP(rr, httptest.NewRequest("GET", "/api/v1/test-cases?from="+url.QueryEscape(from)+"&limit=1&offset=1", nil))
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, "4", rr.Header().Get("X-Total-Count"))
+	assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &results))
+	assert.Len(t, results, 1)
+
+	rr = httptest.NewRecorder()
+	srv.Router().ServeHTTP(rr, httptest.NewRequest("GET", "/api/v1/test-cases?from=yesterday", nil))
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+}
+
+func TestIngestCursors(t *testing.T) {
+	db := database.NewMockDatabase()
+	srv := NewServer(testkube.NewMockClient(), db, nil, "../..")
+	finished := time.Now().Add(-time.Hour)
+	db.SaveIngestCursor(database.IngestCursor{Workflow: "api-tests", FinishedAt: finished, ExecutionID: "exec-7", UpdatedAt: finished})
+
+	rr := httptest.NewRecorder()
+	srv.Router().ServeHTTP(rr, httptest.NewRequest("GET", "/admin", nil))
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Contains(t, rr.Body.String(), "Ingestion Cursors")
+	assert.Contains(t, rr.Body.String(), `href="/executions/exec-7"`)
+
+	// Resetting needs the worker
+	body := strings.NewReader(`{"workflow": "api-tests"}`)
+	rr = httptest.NewRecorder()
+	srv.Router().ServeHTTP(rr, httptest.NewRequest("POST", "/api/v1/admin/worker/cursors/reset", body))
+	assert.Equal(t, http.StatusServiceUnavailable, rr.Code)
+
+	srv.SetWorker(worker.NewWorker(testkube.NewMockClient(), db))
+	body = strings.NewReader(`{"workflow": "api-tests", "to": "yesterday"}`)
+	rr = httptest.NewRecorder()
+	srv.Router().ServeHTTP(rr, httptest.NewRequest("POST", "/api/v1/admin/worker/cursors/reset", body))
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+
+	form := url.Values{"workflow": {"api-tests"}, "to": {"2026-01-02T15:04"}}
+	req := httptest.NewRequest("POST", "/admin/worker/cursors/reset", strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	rr = httptest.NewRecorder()
+	srv.Router().ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Contains(t, rr.Header().Get("HX-Trigger"), "Reset the ingestion cursor of api-tests")
+
+	rr = httptest.NewRecorder()
+	srv.Router().ServeHTTP(rr, httptest.NewRequest("GET", "/api/v1/admin/worker/cursors", nil))
+	var cursors []database.IngestCursor
+	assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &cursors))
+	if assert.Len(t, cursors, 1) {
+		assert.Empty(t, cursors[0].ExecutionID)
+		assert.Equal(t, time.Date(2026, 1, 2, 15, 4, 0, 0, time.Local), cursors[0].FinishedAt.Local())
+	}
+}
+
+func TestLiveActivity(t *testing.T) {
+	api := testkube.NewMockClient()
+	srv := NewServer(api, database.NewMockDatabase(), nil, "../..")
+	ts := httptest.NewServer(srv.Router())
+	defer ts.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	req, _ := http.NewRequestWithContext(ctx, "GET", ts.URL+"/activity/live", nil)
+	resp, err := http.DefaultClient.Do(req)
+	assert.NoError(t, err)
+	defer resp.Body.Close()
+	assert.Equal(t, "text/event-stream", resp.Header.Get("Content-Type"))
+
+	// A run being queued streams in as a row of the panel
+	exec, err := api.RunWorkflow("frontend-e2e", testkube.RunOptions{})
+	assert.NoError(t, err)
+	scanner := bufio.NewScanner(resp.Body)
+	var event []string
+	for scanner.Scan() {
+		if scanner.Text() == "" && len(event) > 0 {
+			break
+		}
+		event = append(event, scanner.Text())
+	}
+	assert.Equal(t, "event: notification", event[0])
+	assert.Contains(t, strings.Join(event, "\n"), "/executions/"+exec.ID)
+	assert.Contains(t, strings.Join(event, "\n"), "Queued")
+
+	// The dashboard opens with the notifications kept
+	rr := httptest.NewRecorder()
+	srv.Router().ServeHTTP(rr, httptest.NewRequest("GET", "/api/v1/activity/live", nil))
+	var recent []testkube.ExecutionNotification
+	assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &recent))
+	if assert.NotEmpty(t, recent) {
+		assert.Equal(t, testkube.NotificationQueued, recent[0].Kind)
+		assert.Equal(t, exec.ID, recent[0].ExecutionID)
+	}
+
+	rr = httptest.NewRecorder()
+	srv.Router().ServeHTTP(rr, httptest.NewRequest("GET", "/", nil))
+	assert.Contains(t, rr.Body.String(), `sse-connect="/activity/live"`)
+	assert.Contains(t, rr.Body.String(), "/executions/"+exec.ID)
+}
+
+func TestExecutionTestSuites(t *testing.T) {
+	db := database.NewMockDatabase()
+	srv := NewServer(testkube.NewMockClient(), db, nil, "../..")
+	cases := []database.TestCase{
+		{ExecutionID: "exec-1", TestName: "[unit] adds", Status: "passed", DurationMs: 10, Suite: "unit > math.spec.ts"},
+		{ExecutionID: "exec-1", TestName: "[unit] divides", Status: "failed", DurationMs: 20, Suite: "unit > math.spec.ts"},
+		{ExecutionID: "exec-1", TestName: "[integration] logs in", Status: "passed", DurationMs: 300, Suite: "integration > login.spec.ts"},
+	}
+	for _, tc := range cases {
+		assert.NoError(t, db.InsertTestCase(tc))
+	}
+	assert.NoError(t, db.InsertTestSuites("exec-1", parsers.BuildSuites("exec-1", cases)))
+
+	suites, err := db.GetTestSuites("exec-1")
+	assert.NoError(t, err)
+	groups := suiteGroups(suites, cases)
+	if assert.Len(t, groups, 2) {
+		assert.Equal(t, "unit", groups[0].Name)
+		if assert.Len(t, groups[0].Suites, 1) {
+			assert.Len(t, groups[0].Suites[0].Cases, 2)
+		}
+	}
+
+	rr := httptest.NewRecorder()
+	srv.Router().ServeHTTP(rr, httptest.NewRequest("GET", "/executions/exec-1", nil))
+	assert.Equal(t, http.StatusOK, rr.Code)
+	body := rr.Body.String()
+	assert.Contains(t, body, "Test Suites")
+	assert.Contains(t, body, "1 of 2 passed (50%)")
+	// Suites with failures start expanded
+	assert.Contains(t, body, `<details class="suite-group" open>`)
+	assert.Contains(t, body, `<details class="suite-group">`)
+}
+
+func TestAutoRefresh(t *testing.T) {
+	srv := NewServer(testkube.NewMockClient(), database.NewMockDatabase(), nil, "../..")
+	srv.refresh["queue"] = 5 * time.Second
+	srv.refresh["synthetics"] = 0
+
+	rr := httptest.NewRecorder()
+	srv.Router().ServeHTTP(rr, httptest.NewRequest("GET", "/queue", nil))
+	assert.Contains(t, rr.Body.String(), `hx-trigger="every 5000ms, refresh from:body"`)
+	assert.Contains(t, rr.Body.String(), "Refreshing every 5s")
+
+	rr = httptest.NewRecorder()
+	srv.Router().ServeHTTP(rr, httptest.NewRequest("GET", "/synthetics", nil))
+	assert.Contains(t, rr.Body.String(), `hx-trigger="refresh from:body"`)
+	assert.Contains(t, rr.Body.String(), "Auto-refresh is off for this page")
+
+	// Pausing is remembered by the browser
+	rr = httptest.NewRecorder()
+	srv.Router().ServeHTTP(rr, httptest.NewRequest("GET", "/queue?autorefresh=off", nil))
+	assert.Contains(t, rr.Body.String(), "Auto-refresh paused")
+	cookies := rr.Result().Cookies()
+	if assert.Len(t, cookies, 1) {
+		assert.Equal(t, autoRefreshCookie, cookies[0].Name)
+		assert.Equal(t, "off", cookies[0].Value)
+	}
+
+	req := httptest.NewRequest("GET", "/queue", nil)
+	req.AddCookie(cookies[0])
+	rr = httptest.NewRecorder()
+	srv.Router().ServeHTTP(rr, req)
+	assert.Contains(t, rr.Body.String(), `hx-trigger="refresh from:body"`)
+	assert.Contains(t, rr.Body.String(), `href="?autorefresh=on"`)
+}
+
+func TestParseRefreshIntervals(t *testing.T) {
+	policy, err := parseRefreshIntervals("dashboard=10s, queue=0, wallboard=1m")
+	assert.NoError(t, err)
+	assert.Equal(t, 10*time.Second, policy["dashboard"])
+	assert.Equal(t, time.Duration(0), policy["queue"])
+	assert.Equal(t, time.Minute, policy["wallboard"])
+	assert.Equal(t, defaultRefreshIntervals["synthetics"], policy["synthetics"])
+	// The defaults are left alone
+	assert.Equal(t, 30*time.Second, defaultRefreshIntervals["dashboard"])
+
+	for _, value := range []string{"dashboard", "dashboard=soon", "queue=-1s", "queue=500ms"} {
+		_, err := parseRefreshIntervals(value)
+		assert.Error(t, err, value)
+	}
+}
+
+func TestExecutionSteps(t *testing.T) {
+	srv := NewServer(testkube.NewMockClient(), database.NewMockDatabase(), nil, "../..")
+
+	rr := httptest.NewRecorder()
+	srv.Router().ServeHTTP(rr, httptest.NewRequest("GET", "/executions/exec-1", nil))
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Contains(t, rr.Body.String(), `<details class="step-breakdown"`)
+	assert.Contains(t, rr.Body.String(), "Steps (4)")
+	assert.Contains(t, rr.Body.String(), "Install dependencies")
+}
+
+func TestRunInsights(t *testing.T) {
+	t.Setenv("REPORT_COST_PER_RUNNER_HOUR", "3")
+	db := database.NewMockDatabase()
+	srv := NewServer(testkube.NewMockClient(), db, nil, "../..")
+
+	// Three failures in a row, one of them broken by an infra error that
+	// says nothing about the tests, after two passing runs
+	now := time.Now()
+	for i, e := range []struct {
+		status  string
+		outcome string
+		minutes int
+	}{
+		{"running", "", 0},
+		{"failed", "", 10},
+		{"failed", testkube.OutcomeInfraError, 1},
+		{"failed", "", 20},
+		{"failed", "", 30},
+		{"passed", "", 40},
+		{"passed", "", 50},
+	} {
+		assert.NoError(t, db.InsertExecution(testkube.Execution{
+			ID: fmt.Sprintf("exec-%d", i), WorkflowName: "frontend-e2e", Status: e.status, Outcome: e.outcome,
+			StartTime: now.Add(-time.Duration(i) * time.Hour), Duration: time.Duration(e.minutes) * time.Minute,
+		}))
+	}
+
+	rr := httptest.NewRecorder()
+	srv.Router().ServeHTTP(rr, httptest.NewRequest("GET", "/api/v1/workflows/frontend-e2e/run-insights", nil))
+	assert.Equal(t, http.StatusOK, rr.Code)
+	var insights RunInsights
+	assert.NoError(t, json.NewDecoder(rr.Body).Decode(&insights))
+	assert.Equal(t, 6, insights.Runs)
+	assert.Equal(t, 30*time.Minute, insights.ExpectedDuration)
+	assert.Equal(t, 50*time.Minute, insights.LongDuration)
+	assert.InDelta(t, 1.5, insights.EstimatedCost, 0.001)
+	assert.Equal(t, 40, insights.PassRate)
+	assert.Equal(t, 3, insights.FailingStreak)
+	assert.Equal(t, "exec-1", insights.LastFailure)
+	assert.True(t, insights.Failing)
+
+	// Run Now shows the insights before anything is started
+	rr = httptest.NewRecorder()
+	srv.Router().ServeHTTP(rr, httptest.NewRequest("GET", "/workflows/frontend-e2e/run/insights", nil))
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Contains(t, rr.Body.String(), "The last 3 runs failed")
+	assert.Contains(t, rr.Body.String(), "estimated cost <strong>1.50</strong>")
+	assert.Contains(t, rr.Body.String(), `hx-post="/workflows/frontend-e2e/run"`)
+
+	// A workflow with no history can still be run
+	rr = httptest.NewRecorder()
+	srv.Router().ServeHTTP(rr, httptest.NewRequest("GET", "/workflows/api-tests/run/insights", nil))
+	assert.Contains(t, rr.Body.String(), "hasn't finished a run yet")
+	assert.NotContains(t, rr.Body.String(), "Run Anyway")
+}
+
+func TestWorkflowHistoryBranches(t *testing.T) {
+	api := testkube.NewMockClient()
+	db := database.NewMockDatabase()
+	srv := NewServer(api, db, nil, "../..")
+	exec, err := api.RunWorkflow("frontend-e2e", testkube.RunOptions{Tags: map[string]string{testkube.BranchTag: "feature/search", testkube.CommitTag: "deadbee"}})
+	assert.NoError(t, err)
+
+	rr := httptest.NewRecorder()
+	srv.Router().ServeHTTP(rr, httptest.NewRequest("GET", "/workflows/frontend-e2e/history?branch=feature/search", nil))
+	assert.Equal(t, http.StatusOK, rr.Code)
+	body := rr.Body.String()
+	assert.Contains(t, body, `<option value="feature/search" selected>`)
+	assert.Contains(t, body, `<option value="main">`)
+	assert.Contains(t, body, `href="/executions/`+exec.ID+`"`)
+	assert.Contains(t, body, "1 executions")
+
+	rr = httptest.NewRecorder()
+	srv.Router().ServeHTTP(rr, httptest.NewRequest("GET", "/api/v1/executions?workflow=frontend-e2e&commit=deadbee", nil))
+	var page testkube.ExecutionPage
+	assert.NoError(t, json.NewDecoder(rr.Body).Decode(&page))
+	assert.Len(t, page.Executions, 1)
+
+	// Date ranges are served from ingested history
+	assert.NoError(t, db.InsertExecution(*exec))
+	assert.NoError(t, db.InsertExecution(testkube.Execution{ID: "on-main", WorkflowName: "frontend-e2e", Branch: "main", StartTime: exec.StartTime}))
+	from := url.QueryEscape(exec.StartTime.Add(-time.Hour).Format(dateInputLayout))
+	rr = httptest.NewRecorder()
+	srv.Router().ServeHTTP(rr, httptest.NewRequest("GET", "/workflows/frontend-e2e/history?branch=feature/search&from="+from, nil))
+	assert.Contains(t, rr.Body.String(), `href="/executions/`+exec.ID+`"`)
+	assert.NotContains(t, rr.Body.String(), `href="/executions/on-main"`)
+}
+
+func TestLegacyResources(t *testing.T) {
+	db := database.NewMockDatabase()
+	srv := NewServer(testkube.NewMockClient(), db, nil, "../..")
+	get := func(path string) *httptest.ResponseRecorder {
+		rr := httptest.NewRecorder()
+		srv.Router().ServeHTTP(rr, httptest.NewRequest("GET", path, nil))
+		return rr
+	}
+
+	rr := get("/legacy/Test/orders-postman")
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Contains(t, rr.Body.String(), `<span class="kind-legacy" title="Legacy v1 resource">Test</span>`)
+	assert.Contains(t, rr.Body.String(), `hx-post="/legacy/Test/orders-postman/run"`)
+	assert.Contains(t, rr.Body.String(), `hx-get="/legacy/Test/orders-postman/executions/legacy-orders-postman-2/logs"`)
+
+	rr = get("/legacy/Test/orders-postman/executions/legacy-orders-postman-2/logs")
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Contains(t, rr.Body.String(), "Test failed.")
+
+	// A suite's executions have no output of their own
+	rr = get("/legacy/TestSuite/nightly-regression")
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Contains(t, rr.Body.String(), `<a href="/legacy/Test/checkout-cypress">checkout-cypress</a>`)
+	assert.NotContains(t, rr.Body.String(), "/logs")
+
+	assert.Equal(t, http.StatusNotFound, get("/legacy/Test/nightly-regression").Code)
+	assert.Equal(t, http.StatusNotFound, get("/legacy/TestWorkflow/frontend-e2e").Code)
+
+	req := httptest.NewRequest("POST", "/legacy/TestSuite/nightly-regression/run", nil)
+	req.Header.Set("X-Forwarded-User", "alice@example.com")
+	rr = httptest.NewRecorder()
+	srv.Router().ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Contains(t, rr.Header().Get("HX-Trigger"), "TestSuite started successfully")
+	rr = get("/legacy/TestSuite/nightly-regression")
+	assert.Contains(t, rr.Body.String(), "by alice@example.com")
+	events, err := db.GetEvents(database.EventFilter{Types: []string{activityRunTriggered}})
+	assert.NoError(t, err)
+	if assert.Len(t, events, 1) {
+		assert.Equal(t, "/legacy/TestSuite/nightly-regression", events[0].URL)
+	}
+
+	rr = httptest.NewRecorder()
+	srv.Router().ServeHTTP(rr, httptest.NewRequest("POST", "/legacy/Test/missing/run", nil))
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+}
@@ -0,0 +1,308 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/testkube/dashboard/internal/database"
+	"github.com/testkube/dashboard/internal/features"
+	"github.com/testkube/dashboard/internal/redact"
+	"github.com/testkube/dashboard/internal/share"
+	"github.com/testkube/dashboard/internal/testkube"
+)
+
+// binaryArtifactExts are artifacts the redaction rules can't scrub, such as
+// screenshots and videos, so share links leave them out while redaction is on
+var binaryArtifactExts = map[string]bool{
+	".png": true, ".jpg": true, ".jpeg": true, ".gif": true, ".webp": true,
+	".webm": true, ".mp4": true, ".zip": true, ".gz": true, ".tgz": true,
+	".tar": true, ".pdf": true,
+}
+
+// shareLinkResponse is a share link with the address to hand out
+type shareLinkResponse struct {
+	share.Link
+	URL string `json:"url"`
+}
+
+func newShareLinkResponse(link share.Link) shareLinkResponse {
+	return shareLinkResponse{Link: link, URL: "/share/" + link.Token}
+}
+
+// createShareLink issues a link to an execution for the requesting user
+func (s *Server) createShareLink(r *http.Request, id string, ttl time.Duration) (share.Link, int, error) {
+	// Shared pages read the default cluster, which links carry no choice of
+	if !s.onDefaultCluster(r) {
+		return share.Link{}, http.StatusBadRequest, fmt.Errorf("share links are only available on the %s cluster", s.clusters.Default())
+	}
+	if _, err := s.api.GetExecution(id); err != nil {
+		return share.Link{}, http.StatusNotFound, fmt.Errorf("execution not found")
+	}
+	link, err := s.shares.Create(id, proxyTenant(r), ttl, proxyUser(r))
+	if err != nil {
+		return share.Link{}, http.StatusBadRequest, err
+	}
+	return link, http.StatusOK, nil
+}
+
+// parseShareTTL parses an expiry like "24h"; empty uses the default
+func parseShareTTL(val string) (time.Duration, error) {
+	if val == "" {
+		return 0, nil
+	}
+	ttl, err := time.ParseDuration(val)
+	if err != nil || ttl <= 0 {
+		return 0, fmt.Errorf("invalid expiry %q", val)
+	}
+	return ttl, nil
+}
+
+// handleCreateShareLink issues a link from the execution page and shows it
+func (s *Server) handleCreateShareLink(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	ttl, err := parseShareTTL(r.FormValue("ttl"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	link, status, err := s.createShareLink(r, id, ttl)
+	if err != nil {
+		http.Error(w, err.Error(), status)
+		return
+	}
+
+	data := map[string]interface{}{
+		"Link":     newShareLinkResponse(link),
+		"Redacted": s.shareRedacted(link),
+	}
+	s.executeTemplate(w, "execution_detail.html", "share-link", data)
+}
+
+// handleCreateShareLinkAPI issues a link, optionally with {"ttl": "24h"}
+func (s *Server) handleCreateShareLinkAPI(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	var req struct {
+		TTL string `json:"ttl"`
+	}
+	if r.ContentLength > 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "Invalid request body", http.StatusBadRequest)
+			return
+		}
+	}
+	ttl, err := parseShareTTL(req.TTL)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	link, status, err := s.createShareLink(r, id, ttl)
+	if err != nil {
+		http.Error(w, err.Error(), status)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(newShareLinkResponse(link))
+}
+
+// handleShareLinksAPI lists an execution's valid share links
+func (s *Server) handleShareLinksAPI(w http.ResponseWriter, r *http.Request) {
+	links := []shareLinkResponse{}
+	for _, link := range s.shares.List(chi.URLParam(r, "id")) {
+		links = append(links, newShareLinkResponse(link))
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(links)
+}
+
+// handleRevokeShareLinkAPI invalidates a share link before it expires
+func (s *Server) handleRevokeShareLinkAPI(w http.ResponseWriter, r *http.Request) {
+	if err := s.shares.Revoke(chi.URLParam(r, "token")); err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// sharedLink resolves the share link in the URL, responding with an error
+// when it is unknown, revoked or expired
+func (s *Server) sharedLink(w http.ResponseWriter, r *http.Request) (share.Link, bool) {
+	link, err := s.shares.Get(chi.URLParam(r, "token"))
+	if err != nil {
+		if errors.Is(err, share.ErrExpired) {
+			http.Error(w, "This link has expired", http.StatusGone)
+			return link, false
+		}
+		http.Error(w, "Link not found", http.StatusNotFound)
+		return link, false
+	}
+	return link, true
+}
+
+// shareRedacted reports whether a link's views are scrubbed, which the
+// redaction flag controls for the tenant that created it
+func (s *Server) shareRedacted(link share.Link) bool {
+	return s.features.Enabled(features.Redaction, link.Tenant)
+}
+
+// scrubber returns the redaction function for a link's views
+func (s *Server) scrubber(link share.Link) func(string) string {
+	if !s.shareRedacted(link) {
+		return func(text string) string { return text }
+	}
+	return s.redactor.Redact
+}
+
+// handleSharedExecution shows an execution's results, artifacts and logs
+// to someone holding a share link
+func (s *Server) handleSharedExecution(w http.ResponseWriter, r *http.Request) {
+	link, ok := s.sharedLink(w, r)
+	if !ok {
+		return
+	}
+	exec, err := s.api.GetExecution(link.ExecutionID)
+	if err != nil {
+		log.Printf("Error getting shared execution %s: %v", link.ExecutionID, err)
+		http.Error(w, "Execution not found", http.StatusNotFound)
+		return
+	}
+
+	scrub := s.scrubber(link)
+	redacted := s.shareRedacted(link)
+
+	testCases, err := s.db.GetExecutionMetrics(exec.ID)
+	if err != nil {
+		log.Printf("Error getting test cases: %v", err)
+	}
+	cases := make([]database.TestCase, len(testCases))
+	for i, tc := range testCases {
+		tc.TestName = scrub(tc.TestName)
+		tc.ErrorMessage = scrub(tc.ErrorMessage)
+		cases[i] = tc
+	}
+
+	type sharedArtifact struct {
+		testkube.Artifact
+		Withheld bool
+	}
+	var shared []sharedArtifact
+	list, err := s.api.GetArtifacts(exec.ID)
+	if err != nil {
+		log.Printf("Error getting artifacts: %v", err)
+	}
+	for _, a := range list {
+		withheld := redacted && binaryArtifactExts[strings.ToLower(filepath.Ext(a.Path))]
+		shared = append(shared, sharedArtifact{Artifact: a, Withheld: withheld})
+	}
+
+	// The page shows the log's tail, and the whole log is a download away
+	page, err := s.logPage(s.api, exec.ID, -logTailLines, logTailLines)
+	if err != nil {
+		log.Printf("Error getting execution logs: %v", err)
+	}
+	logs := logPageData(exec.ID, page)
+	logs["Text"] = scrub(logs["Text"].(string))
+
+	s.render(w, "share.html", map[string]interface{}{
+		"Shared":    true,
+		"Link":      link,
+		"Redacted":  redacted,
+		"Execution": scrubExecution(*exec, scrub),
+		"TestCases": cases,
+		"Artifacts": shared,
+		"Logs":      logs,
+	})
+}
+
+// scrubExecution returns a copy of an execution with its free text, such as
+// its name, CI details, labels and step errors, passed through scrub
+func scrubExecution(exec testkube.Execution, scrub func(string) string) testkube.Execution {
+	exec.Name = scrub(exec.Name)
+	exec.WorkflowName = scrub(exec.WorkflowName)
+	exec.Branch = scrub(exec.Branch)
+	exec.Commit = scrub(exec.Commit)
+	exec.TriggeredBy = scrub(exec.TriggeredBy)
+	if exec.Labels != nil {
+		labels := make(map[string]string, len(exec.Labels))
+		for key, value := range exec.Labels {
+			labels[key] = scrub(value)
+		}
+		exec.Labels = labels
+	}
+	steps := make([]testkube.StepResult, len(exec.Steps))
+	for i, step := range exec.Steps {
+		step.Name = scrub(step.Name)
+		step.Error = scrub(step.Error)
+		steps[i] = step
+	}
+	exec.Steps = steps
+	return exec
+}
+
+// handleSharedLogs serves an execution's logs as text to a share link holder
+func (s *Server) handleSharedLogs(w http.ResponseWriter, r *http.Request) {
+	link, ok := s.sharedLink(w, r)
+	if !ok {
+		return
+	}
+	logs, err := s.api.GetExecutionLogs(link.ExecutionID)
+	if err != nil {
+		log.Printf("Error getting execution logs: %v", err)
+		http.Error(w, "Failed to load logs", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	w.Write([]byte(s.scrubber(link)(logs)))
+}
+
+// handleSharedArtifact serves an artifact to a share link holder. With
+// redaction on, text artifacts are scrubbed and binary ones withheld.
+func (s *Server) handleSharedArtifact(w http.ResponseWriter, r *http.Request) {
+	link, ok := s.sharedLink(w, r)
+	if !ok {
+		return
+	}
+	path := chi.URLParam(r, "*")
+	redacted := s.shareRedacted(link)
+	if redacted && binaryArtifactExts[strings.ToLower(filepath.Ext(path))] {
+		http.Error(w, "Binary artifacts can't be redacted and aren't shared", http.StatusForbidden)
+		return
+	}
+
+	if !redacted {
+		if contentType := artifactContentType(path); contentType != "" {
+			w.Header().Set("Content-Type", contentType)
+		}
+		if err := s.streamVerifiedArtifact(w, s.api, link.ExecutionID, path); err != nil {
+			log.Printf("Error downloading shared artifact %s: %v", path, err)
+			http.Error(w, "Failed to download artifact", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	// Redaction needs the whole artifact
+	data, _, err := s.downloadVerifiedArtifact(s.api, link.ExecutionID, path)
+	if err != nil {
+		log.Printf("Error downloading shared artifact %s: %v", path, err)
+		http.Error(w, "Failed to download artifact", http.StatusInternalServerError)
+		return
+	}
+	if !redact.IsText(data) {
+		http.Error(w, "Binary artifacts can't be redacted and aren't shared", http.StatusForbidden)
+		return
+	}
+	data = []byte(s.redactor.Redact(string(data)))
+
+	if contentType := artifactContentType(path); contentType != "" {
+		w.Header().Set("Content-Type", contentType)
+	}
+	w.Write(data)
+}
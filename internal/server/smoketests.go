@@ -0,0 +1,60 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/testkube/dashboard/internal/environments"
+	"github.com/testkube/dashboard/internal/runqueue"
+	"github.com/testkube/dashboard/internal/testkube"
+)
+
+// SmokeEnvironmentTag records the environment a smoke test run checked
+const SmokeEnvironmentTag = "smoke-environment"
+
+// runSmokeTest starts an environment's smoke test through the run queue
+// and waits for it to finish, so the environment is marked ready only
+// when the run passes
+func (s *Server) runSmokeTest(ctx context.Context, env environments.Environment, test environments.SmokeTest) (string, error) {
+	opts := testkube.RunOptions{
+		Config: test.Config,
+		Tags: map[string]string{
+			testkube.TriggerTag:     testkube.TriggerCI,
+			testkube.TriggeredByTag: env.Owner,
+			SmokeEnvironmentTag:     env.ID,
+		},
+	}
+	exec, queued, err := s.runs.Submit(test.Workflow, runqueue.PriorityNormal, opts)
+	if err != nil {
+		return "", fmt.Errorf("failed to start %s: %w", test.Workflow, err)
+	}
+	s.recordRun(test.Workflow, opts, exec)
+
+	verdict := runVerdict{Workflow: test.Workflow}
+	if queued != nil {
+		verdict.QueueID = queued.ID
+	} else {
+		verdict.ExecutionID = exec.ID
+	}
+	log.Printf("Smoke testing environment %s with %s", env.Name, test.Workflow)
+
+	ticker := time.NewTicker(runWaitPollInterval)
+	defer ticker.Stop()
+	for !s.pollRun(s.api, &verdict) {
+		select {
+		case <-ctx.Done():
+			return verdict.ExecutionID, fmt.Errorf("%s did not finish: %w", test.Workflow, ctx.Err())
+		case <-ticker.C:
+		}
+	}
+
+	if verdict.Verdict != verdictPassed {
+		if verdict.Message != "" {
+			return verdict.ExecutionID, fmt.Errorf("%w: %s", environments.ErrSmokeTestFailed, verdict.Message)
+		}
+		return verdict.ExecutionID, fmt.Errorf("%w: %s execution %s", environments.ErrSmokeTestFailed, test.Workflow, verdict.Status)
+	}
+	return verdict.ExecutionID, nil
+}
@@ -0,0 +1,139 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/testkube/dashboard/internal/parsers"
+	"github.com/testkube/dashboard/internal/suites"
+	"github.com/testkube/dashboard/internal/testkube"
+)
+
+const (
+	// defaultVerdictWindow is the window a suite verdict covers without ?window=
+	defaultVerdictWindow = time.Hour
+	// maxVerdictWindow caps ?window=
+	maxVerdictWindow = 30 * 24 * time.Hour
+	// suiteExecutionLimit is how many recent executions of each workflow a
+	// verdict looks at
+	suiteExecutionLimit = 200
+	// maxFindingsReportSize skips scanner reports too large to parse per request
+	maxFindingsReportSize = 10 * 1024 * 1024
+)
+
+func (s *Server) handleSuitesAPI(w http.ResponseWriter, r *http.Request) {
+	list := s.suites.List()
+	if list == nil {
+		list = []suites.Suite{}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(list)
+}
+
+// handleSuiteVerdictAPI decides whether a suite can be promoted from its
+// workflows' runs in ?window= (default 1h). Failed latest runs, SLO breaches
+// and open critical security findings fail the verdict, and are listed as
+// reasons. Failing verdicts respond 422, so pipelines can gate on the status.
+func (s *Server) handleSuiteVerdictAPI(w http.ResponseWriter, r *http.Request) {
+	suite, ok := s.suites.Get(chi.URLParam(r, "name"))
+	if !ok {
+		http.Error(w, "Suite not found", http.StatusNotFound)
+		return
+	}
+
+	window := defaultVerdictWindow
+	if val := r.URL.Query().Get("window"); val != "" {
+		d, err := time.ParseDuration(val)
+		if err != nil || d <= 0 || d > maxVerdictWindow {
+			http.Error(w, fmt.Sprintf("Invalid window %q: use a duration up to %s", val, maxVerdictWindow), http.StatusBadRequest)
+			return
+		}
+		window = d
+	}
+
+	workflows, err := s.api.GetWorkflows(testkube.ListOptions{})
+	if err != nil {
+		log.Printf("Error getting workflows: %v", err)
+		http.Error(w, "Failed to load workflows", http.StatusInternalServerError)
+		return
+	}
+
+	now := time.Now()
+	var runs []suites.Runs
+	for _, wf := range workflows {
+		if !suite.Includes(wf.Name) {
+			continue
+		}
+		executions, err := s.api.GetExecutions(testkube.ListOptions{Workflow: wf.Name, PageSize: suiteExecutionLimit})
+		if err != nil {
+			log.Printf("Error getting executions of %s: %v", wf.Name, err)
+			http.Error(w, "Failed to load executions", http.StatusInternalServerError)
+			return
+		}
+		wr := suites.Runs{Workflow: wf.Name, Executions: executions}
+		if s.securityWorkflow(wf.Type) {
+			if latest := latestFinished(executions, now.Add(-window)); latest != nil {
+				wr.CriticalFindings = s.criticalFindings(latest.ID)
+			}
+		}
+		runs = append(runs, wr)
+	}
+
+	verdict := suite.Evaluate(runs, window, now)
+	status := http.StatusOK
+	if !verdict.Passed {
+		status = http.StatusUnprocessableEntity
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(verdict)
+}
+
+// latestFinished returns the newest execution started since from that
+// passed or failed, skipping infra errors and other runs that say nothing
+// about the tests
+func latestFinished(executions []testkube.Execution, from time.Time) *testkube.Execution {
+	var latest *testkube.Execution
+	for i, e := range executions {
+		if !testkube.CountsTowardsPassRate(e.RunOutcome()) || e.StartTime.Before(from) {
+			continue
+		}
+		if latest == nil || e.StartTime.After(latest.StartTime) {
+			latest = &executions[i]
+		}
+	}
+	return latest
+}
+
+// criticalFindings counts the critical findings in a security scan's JSON
+// reports. Artifacts that aren't scanner reports are skipped.
+func (s *Server) criticalFindings(executionID string) int {
+	list, err := s.api.GetArtifacts(executionID)
+	if err != nil {
+		log.Printf("Error getting artifacts of %s: %v", executionID, err)
+		return 0
+	}
+	total := 0
+	for _, a := range list {
+		if strings.ToLower(filepath.Ext(a.Path)) != ".json" || a.Size > maxFindingsReportSize {
+			continue
+		}
+		data, err := s.api.DownloadArtifact(executionID, a.Path)
+		if err != nil {
+			log.Printf("Error downloading %s of %s: %v", a.Path, executionID, err)
+			continue
+		}
+		count, err := parsers.CountCriticalFindings(data)
+		if err != nil {
+			continue
+		}
+		total += count
+	}
+	return total
+}
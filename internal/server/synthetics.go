@@ -0,0 +1,118 @@
+package server
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/testkube/dashboard/internal/synthetics"
+)
+
+func (s *Server) syntheticsData() map[string]interface{} {
+	return map[string]interface{}{
+		"Checks": s.synthetics.Checks(),
+	}
+}
+
+func (s *Server) handleSynthetics(w http.ResponseWriter, r *http.Request) {
+	data := s.syntheticsData()
+	data["Refresh"] = s.refreshFor(r, "synthetics")
+	s.renderPage(w, r, "synthetics.html", data)
+}
+
+// handleCreateSynthetic adds a check from the page form and returns the
+// refreshed list of checks
+func (s *Server) handleCreateSynthetic(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, "Invalid form", http.StatusBadRequest)
+		return
+	}
+
+	check := synthetics.Check{
+		Name:         r.PostForm.Get("name"),
+		URL:          r.PostForm.Get("url"),
+		Method:       r.PostForm.Get("method"),
+		ExpectedBody: strings.TrimSpace(r.PostForm.Get("expectedBody")),
+	}
+	check.ExpectedStatus, _ = strconv.Atoi(r.PostForm.Get("expectedStatus"))
+	check.IntervalSeconds, _ = strconv.Atoi(r.PostForm.Get("intervalSeconds"))
+
+	check, err := s.synthetics.Add(check)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// Run straight away so the new row shows a result
+	if _, err := s.synthetics.Run(r.Context(), check.ID); err != nil {
+		log.Printf("Error running synthetic check %s: %v", check.Name, err)
+	}
+
+	s.executeTemplate(w, "synthetics.html", "synthetic-checks", s.syntheticsData())
+}
+
+func (s *Server) handleRunSynthetic(w http.ResponseWriter, r *http.Request) {
+	if _, err := s.synthetics.Run(r.Context(), chi.URLParam(r, "id")); err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	s.executeTemplate(w, "synthetics.html", "synthetic-checks", s.syntheticsData())
+}
+
+func (s *Server) handleDeleteSynthetic(w http.ResponseWriter, r *http.Request) {
+	if err := s.synthetics.Remove(chi.URLParam(r, "id")); err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	s.executeTemplate(w, "synthetics.html", "synthetic-checks", s.syntheticsData())
+}
+
+func (s *Server) handleSyntheticsAPI(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(s.synthetics.Checks())
+}
+
+func (s *Server) handleCreateSyntheticAPI(w http.ResponseWriter, r *http.Request) {
+	var check synthetics.Check
+	if err := json.NewDecoder(r.Body).Decode(&check); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	check, err := s.synthetics.Add(check)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(check)
+}
+
+func (s *Server) handleDeleteSyntheticAPI(w http.ResponseWriter, r *http.Request) {
+	if err := s.synthetics.Remove(chi.URLParam(r, "id")); err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// handleSyntheticResultsAPI returns a check's results, by default for the
+// last 24 hours (?hours=N)
+func (s *Server) handleSyntheticResultsAPI(w http.ResponseWriter, r *http.Request) {
+	hours := queryInt(r, "hours", 24)
+	results, err := s.synthetics.Results(chi.URLParam(r, "id"), time.Now().Add(-time.Duration(hours)*time.Hour))
+	if err != nil {
+		log.Printf("Error getting synthetic check results: %v", err)
+		http.Error(w, "Failed to get results", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(results)
+}
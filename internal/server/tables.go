@@ -0,0 +1,304 @@
+package server
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/testkube/dashboard/internal/database"
+	"github.com/testkube/dashboard/internal/humanize"
+	"github.com/testkube/dashboard/internal/tables"
+	"github.com/testkube/dashboard/internal/testkube"
+)
+
+// tableRowLimit caps how many executions are loaded for server-side sorting
+// and filtering of execution tables
+const tableRowLimit = 200
+
+var testCaseTable = &tables.Table[database.TestCase]{
+	Name:        "testcases",
+	DefaultSort: "status",
+	Columns: []tables.Column[database.TestCase]{
+		{
+			Key: "name", Label: "Test Name", Filterable: true,
+			Value: func(tc database.TestCase) string { return tc.TestName },
+		},
+		{
+			Key: "file", Label: "File", Filterable: true, Optional: true,
+			Value: func(tc database.TestCase) string { return tc.FilePath },
+		},
+		{
+			Key: "status", Label: "Status", Filterable: true,
+			Value: func(tc database.TestCase) string { return tc.Status },
+			// Failures first when sorted ascending
+			Compare: tables.By(func(tc database.TestCase) int { return statusRank(tc.Status) }),
+			HTML: func(tc database.TestCase) template.HTML {
+				return template.HTML(fmt.Sprintf(`<span class="status-%s">%s</span>`,
+					template.HTMLEscapeString(tc.Status), template.HTMLEscapeString(tc.Status)))
+			},
+		},
+		{
+			Key: "duration", Label: "Duration",
+			Value:   func(tc database.TestCase) string { return fmt.Sprintf("%dms", tc.DurationMs) },
+			Compare: tables.By(func(tc database.TestCase) int { return tc.DurationMs }),
+		},
+		{
+			Key: "retries", Label: "Retries",
+			Value:   func(tc database.TestCase) string { return strconv.Itoa(tc.RetryCount) },
+			Compare: tables.By(func(tc database.TestCase) int { return tc.RetryCount }),
+			HTML: func(tc database.TestCase) template.HTML {
+				if tc.RetryCount == 0 {
+					return "-"
+				}
+				return template.HTML(strconv.Itoa(tc.RetryCount))
+			},
+		},
+		{
+			Key: "message", Label: "Message", Filterable: true,
+			Value: func(tc database.TestCase) string { return tc.ErrorMessage },
+		},
+	},
+	RowClass: func(tc database.TestCase) string {
+		return "test-row test-" + tc.Status
+	},
+}
+
+var executionTable = &tables.Table[testkube.Execution]{
+	Name:        "executions",
+	DefaultSort: "started",
+	DefaultDesc: true,
+	Columns: []tables.Column[testkube.Execution]{
+		{
+			Key: "name", Label: "Execution", Filterable: true,
+			Value: func(e testkube.Execution) string { return e.Name },
+			HTML: func(e testkube.Execution) template.HTML {
+				return template.HTML(fmt.Sprintf(`<a href="/executions/%s">%s</a>`,
+					template.HTMLEscapeString(e.ID), template.HTMLEscapeString(e.Name)))
+			},
+		},
+		{
+			Key: "status", Label: "Status", Filterable: true,
+			Value: func(e testkube.Execution) string { return e.Status },
+			HTML:  func(e testkube.Execution) template.HTML { return statusBadge(e.Status) },
+		},
+		{
+			Key: "started", Label: "Started",
+			Value:   func(e testkube.Execution) string { return e.StartTime.Format("2006-01-02 15:04") },
+			Compare: func(a, b testkube.Execution) int { return a.StartTime.Compare(b.StartTime) },
+		},
+		{
+			Key: "duration", Label: "Duration",
+			Value: func(e testkube.Execution) string {
+				if e.Duration == 0 {
+					return ""
+				}
+				return humanize.Duration(e.Duration)
+			},
+			Compare: tables.By(func(e testkube.Execution) time.Duration { return e.Duration }),
+			HTML: func(e testkube.Execution) template.HTML {
+				if e.Duration == 0 {
+					return "-"
+				}
+				return template.HTML(humanize.Duration(e.Duration))
+			},
+		},
+		{
+			Key: "branch", Label: "Branch", Filterable: true,
+			Value: func(e testkube.Execution) string { return e.Branch },
+		},
+		{
+			Key: "trigger", Label: "Trigger", Filterable: true,
+			Value: executionTrigger,
+			HTML: func(e testkube.Execution) template.HTML {
+				trigger := template.HTMLEscapeString(executionTrigger(e))
+				if e.TriggeredBy == "" {
+					return template.HTML(trigger)
+				}
+				return template.HTML(fmt.Sprintf(`<span title="by %s">%s</span>`, template.HTMLEscapeString(e.TriggeredBy), trigger))
+			},
+		},
+		{
+			Key: "id", Label: "ID", Optional: true,
+			Value: func(e testkube.Execution) string { return e.ID },
+		},
+		{
+			Key: "actions", Label: "Actions",
+			HTML: func(e testkube.Execution) template.HTML {
+				id := template.HTMLEscapeString(e.ID)
+				return template.HTML(fmt.Sprintf(`<a href="/executions/%s" class="btn-secondary">Details</a>
+                    <a href="/executions/%s/report" class="btn-primary" target="_blank">Report</a>`, id, id))
+			},
+		},
+	},
+}
+
+// tableSchemas are the tables whose state can be managed through the API
+var tableSchemas = map[string]tables.Schema{
+	testCaseTable.Name:  testCaseTable,
+	executionTable.Name: executionTable,
+}
+
+func statusRank(status string) int {
+	switch status {
+	case "failed":
+		return 0
+	case "running":
+		return 1
+	case "skipped":
+		return 2
+	case "passed":
+		return 3
+	default:
+		return 4
+	}
+}
+
+const userCookie = "dashboard_user"
+
+// proxyUser returns the user asserted by an authenticating proxy, if any
+func proxyUser(r *http.Request) string {
+	for _, header := range []string{"X-Forwarded-User", "X-Auth-Request-User", "X-Forwarded-Email"} {
+		if user := r.Header.Get(header); user != "" {
+			return user
+		}
+	}
+	return ""
+}
+
+// proxyTenant returns the tenant asserted by an authenticating proxy, which
+// feature flags can be set for. Empty means no tenant.
+func proxyTenant(r *http.Request) string {
+	return r.Header.Get("X-Forwarded-Tenant")
+}
+
+// proxyGroups returns the groups, i.e. teams, an authenticating proxy says
+// the user is in, from a comma-separated X-Forwarded-Groups or
+// X-Auth-Request-Groups header
+func proxyGroups(r *http.Request) []string {
+	var groups []string
+	for _, header := range []string{"X-Forwarded-Groups", "X-Auth-Request-Groups"} {
+		for _, group := range strings.Split(r.Header.Get(header), ",") {
+			if group = strings.TrimSpace(group); group != "" {
+				groups = append(groups, group)
+			}
+		}
+	}
+	return groups
+}
+
+// requestUser identifies whose table state a request uses: the user asserted
+// by an authenticating proxy when present, otherwise a long-lived browser
+// cookie issued on first visit.
+func requestUser(w http.ResponseWriter, r *http.Request) string {
+	if user := proxyUser(r); user != "" {
+		return "user:" + user
+	}
+
+	if cookie, err := r.Cookie(userCookie); err == nil && cookie.Value != "" {
+		return "browser:" + cookie.Value
+	}
+
+	bytes := make([]byte, 16)
+	rand.Read(bytes)
+	id := hex.EncodeToString(bytes)
+	http.SetCookie(w, &http.Cookie{
+		Name:     userCookie,
+		Value:    id,
+		Path:     "/",
+		MaxAge:   365 * 24 * 60 * 60,
+		HttpOnly: true,
+		SameSite: http.SameSiteLaxMode,
+	})
+	return "browser:" + id
+}
+
+// tableState returns the user's state for a table with any changes from the
+// request query applied and saved, so sorting and filters stick across visits
+func (s *Server) tableState(w http.ResponseWriter, r *http.Request, schema tables.Schema) tables.State {
+	user := requestUser(w, r)
+	name := schema.TableName()
+
+	if r.URL.Query().Get("reset") != "" {
+		s.tableStates.Reset(user, name)
+		return schema.Normalize(tables.State{})
+	}
+
+	state, _ := s.tableStates.Get(user, name)
+	if merged, changed := state.Merge(r.URL.Query()); changed {
+		state = schema.Normalize(merged)
+		s.tableStates.Save(user, name, state)
+	}
+	return schema.Normalize(state)
+}
+
+// writeTableCSV exports a table view when the request asks for CSV. It
+// reports whether the response was written.
+func writeTableCSV[T any](w http.ResponseWriter, r *http.Request, t *tables.Table[T], state tables.State, rows []T, filename string) bool {
+	if r.URL.Query().Get("format") != "csv" {
+		return false
+	}
+
+	w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+	if err := t.WriteCSV(w, state, rows); err != nil {
+		log.Printf("Error writing %s CSV: %v", t.Name, err)
+	}
+	return true
+}
+
+func (s *Server) tableSchema(w http.ResponseWriter, r *http.Request) (tables.Schema, bool) {
+	schema, ok := tableSchemas[chi.URLParam(r, "table")]
+	if !ok {
+		http.Error(w, "Unknown table", http.StatusNotFound)
+	}
+	return schema, ok
+}
+
+func (s *Server) handleGetTableStateAPI(w http.ResponseWriter, r *http.Request) {
+	schema, ok := s.tableSchema(w, r)
+	if !ok {
+		return
+	}
+
+	state, _ := s.tableStates.Get(requestUser(w, r), schema.TableName())
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(schema.Normalize(state))
+}
+
+func (s *Server) handleSaveTableStateAPI(w http.ResponseWriter, r *http.Request) {
+	schema, ok := s.tableSchema(w, r)
+	if !ok {
+		return
+	}
+
+	var state tables.State
+	if err := json.NewDecoder(r.Body).Decode(&state); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	state = schema.Normalize(state)
+	s.tableStates.Save(requestUser(w, r), schema.TableName(), state)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(state)
+}
+
+func (s *Server) handleResetTableStateAPI(w http.ResponseWriter, r *http.Request) {
+	schema, ok := s.tableSchema(w, r)
+	if !ok {
+		return
+	}
+
+	s.tableStates.Reset(requestUser(w, r), schema.TableName())
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,160 @@
+package server
+
+import (
+	"bytes"
+	"fmt"
+	"html/template"
+	"log"
+	"net/http"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/testkube/dashboard/internal/humanize"
+	"github.com/testkube/dashboard/internal/testkube"
+)
+
+// templateFuncs are shared by every page. Parsed templates are executed
+// concurrently by all requests, so these must be pure functions of their
+// arguments and never hold per-request state.
+var templateFuncs = template.FuncMap{
+	"humanizeDuration": humanize.Duration,
+	"humanizeMs":       humanize.Milliseconds,
+	"humanizeBytes":    humanize.Bytes,
+	"relativeTime":     relativeTime,
+	"statusBadge":      statusBadge,
+	"workflowType":     testkube.DefaultTypes.Get,
+}
+
+// sharedTemplates define partials available to every page
+var sharedTemplates = []string{"layout.html", "table.html", "watch.html", "refresh.html"}
+
+// loadTemplates parses the layout and shared partials once and gives each page
+// its own clone, so pages can each define "content" without overwriting one
+// another.
+func loadTemplates(dir string, pages []string) map[string]*template.Template {
+	templates, err := parseTemplates(dir, pages)
+	if err != nil {
+		panic(err)
+	}
+	return templates
+}
+
+func parseTemplates(dir string, pages []string) (map[string]*template.Template, error) {
+	shared := make([]string, len(sharedTemplates))
+	for i, name := range sharedTemplates {
+		shared[i] = filepath.Join(dir, name)
+	}
+	layout, err := template.New("layout.html").Funcs(templateFuncs).ParseFiles(shared...)
+	if err != nil {
+		return nil, err
+	}
+
+	templates := make(map[string]*template.Template, len(pages))
+	for _, page := range pages {
+		t, err := layout.Clone()
+		if err != nil {
+			return nil, err
+		}
+		if templates[page], err = t.ParseFiles(filepath.Join(dir, page)); err != nil {
+			return nil, err
+		}
+	}
+	return templates, nil
+}
+
+// pageTemplate returns a parsed page. In dev mode it is re-read from disk on
+// every request, so template edits show up without a restart.
+func (s *Server) pageTemplate(page string) (*template.Template, bool) {
+	t, ok := s.templates[page]
+	if !ok || s.runtime == nil || !s.runtime.Settings().DevMode {
+		return t, ok
+	}
+
+	reloaded, err := parseTemplates(filepath.Join(s.rootDir, "web/templates"), []string{page})
+	if err != nil {
+		log.Printf("Error reloading template %s: %v", page, err)
+		return t, ok
+	}
+	return reloaded[page], true
+}
+
+var renderBuffers = sync.Pool{
+	New: func() interface{} { return new(bytes.Buffer) },
+}
+
+// render writes the page wrapped in the layout, which carries a banner
+// while the database is unavailable
+func (s *Server) render(w http.ResponseWriter, page string, data interface{}) {
+	if m, ok := data.(map[string]interface{}); ok {
+		if status := s.dbHealth.status(); !status.Available {
+			m["Database"] = status
+		}
+	}
+	s.executeTemplate(w, page, "layout", data)
+}
+
+// renderPage writes the full page, or, for htmx requests, only the fragment
+// named after the element being swapped. A page opts a region into partial
+// updates by defining a template with the same name as the element's id, so
+// filters, pagination and polling re-render just that region.
+func (s *Server) renderPage(w http.ResponseWriter, r *http.Request, page string, data interface{}) {
+	// Fragments and full pages share URLs, so caches must key on the htmx headers
+	w.Header().Add("Vary", "HX-Request, HX-Target")
+	if target := htmxTarget(r); target != "" {
+		if t, ok := s.pageTemplate(page); ok && t.Lookup(target) != nil {
+			s.executeTemplate(w, page, target, data)
+			return
+		}
+	}
+	s.render(w, page, data)
+}
+
+// htmxTarget returns the id of the element an htmx request will swap, or ""
+// for regular and boosted navigations, which expect a full page.
+func htmxTarget(r *http.Request) string {
+	if r.Header.Get("HX-Request") != "true" || r.Header.Get("HX-Boosted") == "true" {
+		return ""
+	}
+	return r.Header.Get("HX-Target")
+}
+
+// renderPartial writes only the page's "content" block, for htmx swaps
+func (s *Server) renderPartial(w http.ResponseWriter, page string, data interface{}) {
+	s.executeTemplate(w, page, "content", data)
+}
+
+// executeTemplate renders into a per-request buffer first, so a template
+// error produces a clean 500 instead of a half-written page.
+func (s *Server) executeTemplate(w http.ResponseWriter, page, name string, data interface{}) {
+	t, ok := s.pageTemplate(page)
+	if !ok {
+		log.Printf("Template not found: %s", page)
+		http.Error(w, "Page not found", http.StatusNotFound)
+		return
+	}
+
+	buf := renderBuffers.Get().(*bytes.Buffer)
+	buf.Reset()
+	defer renderBuffers.Put(buf)
+
+	if err := t.ExecuteTemplate(buf, name, data); err != nil {
+		log.Printf("Template error in %s: %v", page, err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/html")
+	buf.WriteTo(w)
+}
+
+// relativeTime formats t relative to now, e.g. "5m ago" or "in 2h"
+func relativeTime(t time.Time) string {
+	return humanize.Relative(t, time.Now())
+}
+
+// statusBadge renders an execution or environment status as a badge
+func statusBadge(status string) template.HTML {
+	escaped := template.HTMLEscapeString(status)
+	return template.HTML(fmt.Sprintf(`<span class="status status-%s">%s</span>`, escaped, escaped))
+}
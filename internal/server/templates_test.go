@@ -0,0 +1,141 @@
+package server
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/testkube/dashboard/internal/database"
+	"github.com/testkube/dashboard/internal/testkube"
+	"github.com/testkube/dashboard/internal/triage"
+)
+
+func TestStatusBadgeEscapes(t *testing.T) {
+	assert.Equal(t, `<span class="status status-passed">passed</span>`, string(statusBadge("passed")))
+	assert.NotContains(t, string(statusBadge(`"><script>`)), "<script>")
+}
+
+// TestConcurrentRendering renders many pages in parallel with distinct data;
+// run with -race to verify templates share no per-request state.
+func TestConcurrentRendering(t *testing.T) {
+	srv := NewServer(testkube.NewMockClient(), database.NewMockDatabase(), nil, "../..")
+	router := srv.Router()
+
+	const workers = 32
+	var wg sync.WaitGroup
+	errs := make(chan error, workers)
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			team := fmt.Sprintf("team-%d", i)
+
+			req := httptest.NewRequest("GET", "/triage/"+team, nil)
+			rr := httptest.NewRecorder()
+			router.ServeHTTP(rr, req)
+
+			body := rr.Body.String()
+			if rr.Code != http.StatusOK || !strings.Contains(body, "Failure Triage: "+team+"<") {
+				errs <- fmt.Errorf("%s: unexpected response %d", team, rr.Code)
+				return
+			}
+			for j := 0; j < workers; j++ {
+				if other := fmt.Sprintf("Failure Triage: team-%d<", j); j != i && strings.Contains(body, other) {
+					errs <- fmt.Errorf("%s: response leaked data from team-%d", team, j)
+					return
+				}
+			}
+		}(i)
+	}
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		t.Error(err)
+	}
+}
+
+func TestRenderErrorDoesNotWritePartialPage(t *testing.T) {
+	srv := NewServer(testkube.NewMockClient(), database.NewMockDatabase(), nil, "../..")
+
+	rr := httptest.NewRecorder()
+	// execution_group.html dereferences .Group, which a string can't provide
+	srv.render(rr, "execution_group.html", map[string]interface{}{"Group": "broken"})
+
+	assert.Equal(t, http.StatusInternalServerError, rr.Code)
+	assert.NotContains(t, rr.Body.String(), "<html")
+}
+
+func htmxRequest(method, url, target string, body io.Reader) *http.Request {
+	req := httptest.NewRequest(method, url, body)
+	req.Header.Set("HX-Request", "true")
+	req.Header.Set("HX-Target", target)
+	return req
+}
+
+func TestRenderPageServesFragments(t *testing.T) {
+	srv := NewServer(testkube.NewMockClient(), database.NewMockDatabase(), nil, "../..")
+	router := srv.Router()
+
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, htmxRequest("GET", "/workflows?q=FRONTEND", "workflow-rows", nil))
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.NotContains(t, rr.Body.String(), "<html")
+	assert.Contains(t, rr.Body.String(), "frontend-e2e")
+	assert.NotContains(t, rr.Body.String(), "backend-integration")
+
+	// Unknown targets fall back to the full page
+	rr = httptest.NewRecorder()
+	router.ServeHTTP(rr, htmxRequest("GET", "/workflows", "no-such-region", nil))
+	assert.Contains(t, rr.Body.String(), "<html")
+
+	rr = httptest.NewRecorder()
+	router.ServeHTTP(rr, htmxRequest("GET", "/", "recent-failures", nil))
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.NotContains(t, rr.Body.String(), "Passes on Retry")
+	assert.Contains(t, rr.Body.String(), "status-failed")
+}
+
+func TestWorkflowDetailPagination(t *testing.T) {
+	srv := NewServer(testkube.NewMockClient(), database.NewMockDatabase(), nil, "../..")
+
+	// The mock has fewer executions than a page, so there's no next page
+	rr := httptest.NewRecorder()
+	srv.Router().ServeHTTP(rr, htmxRequest("GET", "/workflows/frontend-e2e", "executions-table", nil))
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.NotContains(t, rr.Body.String(), "<html")
+	assert.Contains(t, rr.Body.String(), "<thead")
+	assert.Contains(t, rr.Body.String(), "/executions/")
+	assert.NotContains(t, rr.Body.String(), "Loading more")
+
+	rr = httptest.NewRecorder()
+	srv.Router().ServeHTTP(rr, htmxRequest("GET", "/workflows/frontend-e2e?page=2", "execution-rows", nil))
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Empty(t, strings.TrimSpace(rr.Body.String()))
+}
+
+func TestUpdateTriageRefreshesSummaryOutOfBand(t *testing.T) {
+	srv := NewServer(testkube.NewMockClient(), database.NewMockDatabase(), nil, "../..")
+	item, _ := srv.triage.Report(triage.Failure{ExecutionID: "exec-1", WorkflowName: "frontend-e2e", TestName: "Checkout"})
+
+	form := url.Values{"state": {"investigating"}, "assignee": {" carol "}}
+	req := htmxRequest("POST", "/triage/items/"+item.ID, "triage-"+item.ID, strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	rr := httptest.NewRecorder()
+	srv.Router().ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	body := rr.Body.String()
+	assert.True(t, strings.HasPrefix(strings.TrimSpace(body), `<tr id="triage-`+item.ID), "row must come first so htmx parses it as a table row")
+	assert.Contains(t, body, `value="investigating" selected`)
+	assert.Contains(t, body, `id="triage-summary" class="dashboard-grid" hx-swap-oob="true"`)
+
+	updated, _ := srv.triage.Get(item.ID)
+	assert.Equal(t, "carol", updated.Assignee)
+}
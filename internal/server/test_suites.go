@@ -0,0 +1,35 @@
+package server
+
+import (
+	"github.com/testkube/dashboard/internal/database"
+)
+
+// suiteGroup is a suite on the execution page, with the suites nested in it
+// and the test cases directly in it
+type suiteGroup struct {
+	database.TestSuite
+	Suites []*suiteGroup
+	Cases  []database.TestCase
+}
+
+// suiteGroups nests an execution's suites, in the order they're stored, and
+// puts each test case in its suite
+func suiteGroups(suites []database.TestSuite, cases []database.TestCase) []*suiteGroup {
+	groups := make(map[string]*suiteGroup, len(suites))
+	var top []*suiteGroup
+	for _, suite := range suites {
+		group := &suiteGroup{TestSuite: suite}
+		groups[suite.Path] = group
+		if parent, ok := groups[suite.Parent]; ok && suite.Parent != "" {
+			parent.Suites = append(parent.Suites, group)
+		} else {
+			top = append(top, group)
+		}
+	}
+	for _, tc := range cases {
+		if group, ok := groups[tc.Suite]; ok {
+			group.Cases = append(group.Cases, tc)
+		}
+	}
+	return top
+}
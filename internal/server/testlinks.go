@@ -0,0 +1,155 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/testkube/dashboard/internal/database"
+	"github.com/testkube/dashboard/internal/testids"
+)
+
+// errInvalidTestLink is returned for a merge or split the request got wrong
+var errInvalidTestLink = errors.New("invalid test link")
+
+// testLinkGroups are a workflow's test links as the review page shows them
+type testLinkGroups struct {
+	Suggested []database.TestLink
+	Linked    []database.TestLink
+	Split     []database.TestLink
+}
+
+func groupTestLinks(links []database.TestLink) testLinkGroups {
+	var groups testLinkGroups
+	for _, l := range links {
+		switch {
+		case l.Status == database.TestLinkSuggested:
+			groups.Suggested = append(groups.Suggested, l)
+		case l.Applied():
+			groups.Linked = append(groups.Linked, l)
+		default:
+			groups.Split = append(groups.Split, l)
+		}
+	}
+	return groups
+}
+
+// saveTestLink merges two of a workflow's tests, carrying From's history
+// over to To, or splits them so their histories stay apart
+func (s *Server) saveTestLink(r *http.Request, link database.TestLink) error {
+	if link.Workflow == "" || link.From == "" || link.To == "" {
+		return fmt.Errorf("%w: a workflow and both test names are required", errInvalidTestLink)
+	}
+	if link.From == link.To {
+		return fmt.Errorf("%w: a test can't be linked to itself", errInvalidTestLink)
+	}
+	if link.Status != database.TestLinkMerged && link.Status != database.TestLinkSplit {
+		return fmt.Errorf("%w: status %q, expected %s or %s", errInvalidTestLink, link.Status, database.TestLinkMerged, database.TestLinkSplit)
+	}
+
+	existing, err := s.db.GetTestLinks(link.Workflow)
+	if err != nil {
+		return fmt.Errorf("failed to load test links: %w", err)
+	}
+	link.Similarity = testids.Similarity(link.From, link.To)
+	for _, l := range existing {
+		if l.From == link.From && l.To == link.To {
+			link.FilePath = l.FilePath
+		}
+	}
+	link.UpdatedBy = proxyUser(r)
+	link.UpdatedAt = time.Now()
+	if err := s.db.SaveTestLink(link); err != nil {
+		return fmt.Errorf("failed to save test link: %w", err)
+	}
+	return nil
+}
+
+// testLinkError responds with the status for a link that couldn't be saved
+func testLinkError(w http.ResponseWriter, err error) {
+	if errors.Is(err, errInvalidTestLink) {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	log.Printf("Error saving test link: %v", err)
+	http.Error(w, "Failed to save test link", http.StatusInternalServerError)
+}
+
+func (s *Server) testLinksData(workflow string) (map[string]interface{}, error) {
+	links, err := s.db.GetTestLinks(workflow)
+	if err != nil {
+		return nil, err
+	}
+	return map[string]interface{}{
+		"Workflow": workflow,
+		"Links":    groupTestLinks(links),
+	}, nil
+}
+
+// handleTestLinks lists the renames found at ingestion for review
+func (s *Server) handleTestLinks(w http.ResponseWriter, r *http.Request) {
+	data, err := s.testLinksData(r.URL.Query().Get("workflow"))
+	if err != nil {
+		s.databaseError(w, "test renames", err)
+		return
+	}
+	s.render(w, "test_links.html", data)
+}
+
+// handleSaveTestLink merges or splits a pair of tests from the review page
+func (s *Server) handleSaveTestLink(w http.ResponseWriter, r *http.Request) {
+	link := database.TestLink{
+		Workflow: r.FormValue("workflow"),
+		From:     r.FormValue("from"),
+		To:       r.FormValue("to"),
+		Status:   r.FormValue("status"),
+	}
+	if err := s.saveTestLink(r, link); err != nil {
+		testLinkError(w, err)
+		return
+	}
+
+	data, err := s.testLinksData(r.FormValue("filter"))
+	if err != nil {
+		s.databaseError(w, "test renames", err)
+		return
+	}
+	message := fmt.Sprintf("%q now has the history of %q", link.To, link.From)
+	if link.Status == database.TestLinkSplit {
+		message = fmt.Sprintf("%q and %q are kept apart", link.From, link.To)
+	}
+	trigger, _ := json.Marshal(map[string]string{"showMessage": message})
+	w.Header().Set("HX-Trigger", string(trigger))
+	s.executeTemplate(w, "test_links.html", "test-links", data)
+}
+
+func (s *Server) handleTestLinksAPI(w http.ResponseWriter, r *http.Request) {
+	links, err := s.db.GetTestLinks(r.URL.Query().Get("workflow"))
+	if err != nil {
+		s.databaseError(w, "test renames", err)
+		return
+	}
+	if links == nil {
+		links = []database.TestLink{}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(links)
+}
+
+// handleSaveTestLinkAPI takes {"workflow": ..., "from": ..., "to": ...,
+// "status": "merged" | "split"}
+func (s *Server) handleSaveTestLinkAPI(w http.ResponseWriter, r *http.Request) {
+	var link database.TestLink
+	if err := json.NewDecoder(r.Body).Decode(&link); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	if err := s.saveTestLink(r, link); err != nil {
+		testLinkError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,146 @@
+package server
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/testkube/dashboard/internal/database"
+	"github.com/testkube/dashboard/internal/features"
+	"github.com/testkube/dashboard/internal/humanize"
+	"github.com/testkube/dashboard/internal/testkube"
+	"github.com/testkube/dashboard/internal/triage"
+)
+
+// ExecutionIngested implements worker.Listener, filing new failures in the
+// triage queue, alerting on them and on pass rate drops, and comparing screenshots with their
+// baselines once the worker has stored their results. Cached pages are
+// dropped, as they no longer show the latest results.
+func (s *Server) ExecutionIngested(exec testkube.Execution, cases []database.TestCase) {
+	s.pages.Invalidate()
+	if created := s.triage.ReportExecution(exec, cases); created > 0 {
+		log.Printf("Triage: filed %d new failures from execution %s", created, exec.ID)
+	}
+	if s.alerts != nil && s.features.Enabled(features.Notifications, "") {
+		s.alerts.Observe(exec, cases)
+		s.checkPassRate(exec)
+	}
+	s.compareScreenshots(s.api, &exec)
+}
+
+// triageRow is a queue item with its SLA timer formatted for display
+type triageRow struct {
+	triage.Item
+	SLA      string
+	Breached bool
+	States   []triage.State
+}
+
+func triageRows(items []triage.Item, now time.Time) []triageRow {
+	rows := make([]triageRow, 0, len(items))
+	for _, item := range items {
+		row := triageRow{Item: item, Breached: item.Breached(now), States: triage.States}
+		if remaining := item.Remaining(now); item.State.Open() {
+			if remaining < 0 {
+				row.SLA = "overdue by " + humanize.Duration(remaining.Truncate(time.Minute))
+			} else {
+				row.SLA = humanize.Duration(remaining.Truncate(time.Minute)) + " left"
+			}
+		}
+		rows = append(rows, row)
+	}
+	return rows
+}
+
+func (s *Server) handleTriage(w http.ResponseWriter, r *http.Request) {
+	team := chi.URLParam(r, "team")
+	state := triage.State(r.URL.Query().Get("state"))
+
+	filter := triage.Filter{Team: team, State: state, OpenOnly: state == ""}
+	items := s.triage.List(filter)
+
+	data := map[string]interface{}{
+		"Team":   team,
+		"State":  string(state),
+		"States": triage.States,
+		"Teams":  s.triage.Summary(),
+		"Items":  triageRows(items, time.Now()),
+		"Total":  len(items),
+	}
+
+	s.renderPage(w, r, "triage.html", data)
+}
+
+// handleUpdateTriage applies a state or assignee change from the triage page
+// and returns the updated row, refreshing the team summary out of band.
+func (s *Server) handleUpdateTriage(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, "Invalid form", http.StatusBadRequest)
+		return
+	}
+
+	update := triage.Update{State: triage.State(r.PostForm.Get("state"))}
+	if _, ok := r.PostForm["assignee"]; ok {
+		assignee := strings.TrimSpace(r.PostForm.Get("assignee"))
+		update.Assignee = &assignee
+	}
+
+	if _, ok := s.triage.Get(id); !ok {
+		http.Error(w, "Triage item not found", http.StatusNotFound)
+		return
+	}
+
+	item, err := s.triage.Update(id, update)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	data := map[string]interface{}{
+		"Row":   triageRows([]triage.Item{item}, time.Now())[0],
+		"Teams": s.triage.Summary(),
+		"OOB":   true,
+	}
+
+	s.executeTemplate(w, "triage.html", "triage-update", data)
+}
+
+func (s *Server) handleTriageAPI(w http.ResponseWriter, r *http.Request) {
+	filter := triage.Filter{
+		Team:     r.URL.Query().Get("team"),
+		State:    triage.State(r.URL.Query().Get("state")),
+		OpenOnly: r.URL.Query().Get("open") == "true",
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(s.triage.List(filter))
+}
+
+func (s *Server) handleUpdateTriageAPI(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+
+	var update triage.Update
+	if err := json.NewDecoder(r.Body).Decode(&update); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if _, ok := s.triage.Get(id); !ok {
+		http.Error(w, "Triage item not found", http.StatusNotFound)
+		return
+	}
+
+	item, err := s.triage.Update(id, update)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(item)
+}
@@ -0,0 +1,112 @@
+package server
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"slices"
+	"sort"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/testkube/dashboard/internal/testkube"
+)
+
+// triggerTags records on a run that the dashboard started it, how and for whom
+func triggerTags(r *http.Request, source string) map[string]string {
+	tags := map[string]string{testkube.TriggerTag: source}
+	if user := proxyUser(r); user != "" {
+		tags[testkube.TriggeredByTag] = user
+	}
+	return tags
+}
+
+// executionTrigger is an execution's trigger source, unknown when not recorded
+func executionTrigger(e testkube.Execution) string {
+	if e.Trigger == "" {
+		return testkube.TriggerUnknown
+	}
+	return e.Trigger
+}
+
+// triggerStats is the pass rate of the finished runs from one trigger source
+type triggerStats struct {
+	Trigger  string `json:"trigger"`
+	Runs     int    `json:"runs"`
+	Passed   int    `json:"passed"`
+	Failed   int    `json:"failed"`
+	PassRate int    `json:"passRate"` // percent
+	// Excluded counts the finished runs left out of the pass rate, such
+	// as infra errors and aborted runs
+	Excluded int `json:"excluded"`
+}
+
+// triggerBreakdown splits finished executions' pass rate by trigger source,
+// so a red CI can be told apart from failing manual debug runs. Sources
+// without finished runs are left out.
+func triggerBreakdown(executions []testkube.Execution) []triggerStats {
+	counts := make(map[string]*triggerStats)
+	for _, e := range executions {
+		outcome := e.RunOutcome()
+		if outcome == "" {
+			continue
+		}
+		trigger := executionTrigger(e)
+		stats, ok := counts[trigger]
+		if !ok {
+			stats = &triggerStats{Trigger: trigger}
+			counts[trigger] = stats
+		}
+		if !testkube.CountsTowardsPassRate(outcome) {
+			stats.Excluded++
+			continue
+		}
+		stats.Runs++
+		if outcome == testkube.OutcomePassed {
+			stats.Passed++
+		} else {
+			stats.Failed++
+		}
+	}
+
+	// Known sources in their usual order, then any others tagged on runs
+	order := append([]string(nil), testkube.Triggers...)
+	var others []string
+	for trigger := range counts {
+		if !slices.Contains(testkube.Triggers, trigger) {
+			others = append(others, trigger)
+		}
+	}
+	sort.Strings(others)
+
+	var breakdown []triggerStats
+	for _, trigger := range append(order, others...) {
+		if stats, ok := counts[trigger]; ok {
+			if stats.Runs > 0 {
+				stats.PassRate = stats.Passed * 100 / stats.Runs
+			}
+			breakdown = append(breakdown, *stats)
+		}
+	}
+	return breakdown
+}
+
+// handleTriggerBreakdownAPI returns a workflow's pass rate by trigger source
+// over its recent executions
+func (s *Server) handleTriggerBreakdownAPI(w http.ResponseWriter, r *http.Request) {
+	executions, err := s.api.GetExecutions(testkube.ListOptions{
+		Workflow: chi.URLParam(r, "name"),
+		PageSize: tableRowLimit,
+	})
+	if err != nil {
+		log.Printf("Error getting executions: %v", err)
+		http.Error(w, "Failed to load executions", http.StatusInternalServerError)
+		return
+	}
+
+	breakdown := triggerBreakdown(executions)
+	if breakdown == nil {
+		breakdown = []triggerStats{}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(breakdown)
+}
@@ -0,0 +1,189 @@
+package server
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/testkube/dashboard/internal/artifacts"
+	"github.com/testkube/dashboard/internal/testkube"
+	"github.com/testkube/dashboard/internal/visual"
+)
+
+// compareScreenshots compares a finished execution's screenshot artifacts,
+// read from api, with their approved baselines. Each execution is compared
+// once.
+func (s *Server) compareScreenshots(api testkube.Client, exec *testkube.Execution) {
+	if (exec.Status != "passed" && exec.Status != "failed") || s.visual.Compared(exec.ID) {
+		return
+	}
+
+	list, err := api.GetArtifacts(exec.ID)
+	if err != nil {
+		log.Printf("Error getting artifacts of %s for visual comparison: %v", exec.ID, err)
+		return
+	}
+
+	for _, a := range list {
+		if artifacts.DiffKindFor(a.Path) != artifacts.KindImage {
+			continue
+		}
+		image, err := api.DownloadArtifact(exec.ID, a.Path)
+		if err != nil {
+			log.Printf("Error downloading screenshot %s of %s: %v", a.Path, exec.ID, err)
+			continue
+		}
+		if _, err := s.visual.Compare(exec.ID, exec.WorkflowName, a.Path, image); err != nil {
+			log.Printf("Error comparing screenshot %s of %s: %v", a.Path, exec.ID, err)
+		}
+	}
+	s.visual.MarkCompared(exec.ID)
+}
+
+// visualRow is a screenshot comparison formatted for display
+type visualRow struct {
+	visual.Comparison
+	DiffPercent float64
+}
+
+func (s *Server) renderVisual(w http.ResponseWriter, id string) {
+	var rows []visualRow
+	failed := 0
+	for _, c := range s.visual.Comparisons(id) {
+		if c.Failed() {
+			failed++
+		}
+		rows = append(rows, visualRow{Comparison: c, DiffPercent: c.Ratio * 100})
+	}
+
+	data := map[string]interface{}{
+		"ExecutionID": id,
+		"Comparisons": rows,
+		"Failed":      failed,
+		"FailPercent": s.visual.FailRatio() * 100,
+	}
+	s.renderPartial(w, "visual.html", data)
+}
+
+// handleVisual shows how an execution's screenshots compare with their
+// baselines, comparing them first if that hasn't happened yet
+func (s *Server) handleVisual(w http.ResponseWriter, r *http.Request) {
+	api := s.apiFor(r)
+	exec, err := api.GetExecution(chi.URLParam(r, "id"))
+	if err != nil {
+		log.Printf("Error getting execution: %v", err)
+		http.Error(w, "Execution not found", http.StatusNotFound)
+		return
+	}
+
+	s.compareScreenshots(api, exec)
+	s.renderVisual(w, exec.ID)
+}
+
+// reviewScreenshot approves or rejects the screenshot in the request's
+// "path" form value, writing an error response and returning false on failure
+func (s *Server) reviewScreenshot(w http.ResponseWriter, r *http.Request, id, path string, approve bool) bool {
+	if !approve {
+		if _, err := s.visual.Reject(id, path); err != nil {
+			http.Error(w, err.Error(), http.StatusConflict)
+			return false
+		}
+		return true
+	}
+
+	image, err := s.apiFor(r).DownloadArtifact(id, path)
+	if err != nil {
+		log.Printf("Error downloading screenshot %s of %s: %v", path, id, err)
+		http.Error(w, "Failed to download screenshot", http.StatusBadGateway)
+		return false
+	}
+	if _, err := s.visual.Approve(id, path, image, proxyUser(r)); err != nil {
+		http.Error(w, err.Error(), http.StatusConflict)
+		return false
+	}
+	return true
+}
+
+func (s *Server) handleApproveVisual(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	if s.reviewScreenshot(w, r, id, r.FormValue("path"), true) {
+		s.renderVisual(w, id)
+	}
+}
+
+func (s *Server) handleRejectVisual(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	if s.reviewScreenshot(w, r, id, r.FormValue("path"), false) {
+		s.renderVisual(w, id)
+	}
+}
+
+// handleVisualDiffImage serves a comparison's highlighted differences
+func (s *Server) handleVisualDiffImage(w http.ResponseWriter, r *http.Request) {
+	overlay, ok := s.visual.Overlay(chi.URLParam(r, "id"), r.URL.Query().Get("path"))
+	if !ok {
+		http.Error(w, "No comparison for this screenshot", http.StatusNotFound)
+		return
+	}
+	w.Header().Set("Content-Type", "image/png")
+	w.Write(overlay)
+}
+
+// handleVisualBaselineImage serves the baseline an execution's screenshot
+// is compared with
+func (s *Server) handleVisualBaselineImage(w http.ResponseWriter, r *http.Request) {
+	exec, err := s.apiFor(r).GetExecution(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, "Execution not found", http.StatusNotFound)
+		return
+	}
+
+	_, image, ok := s.visual.Baseline(exec.WorkflowName, r.URL.Query().Get("path"))
+	if !ok {
+		http.Error(w, "No baseline for this screenshot", http.StatusNotFound)
+		return
+	}
+	w.Header().Set("Content-Type", http.DetectContentType(image))
+	w.Write(image)
+}
+
+func (s *Server) handleVisualAPI(w http.ResponseWriter, r *http.Request) {
+	api := s.apiFor(r)
+	exec, err := api.GetExecution(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, "Execution not found", http.StatusNotFound)
+		return
+	}
+
+	s.compareScreenshots(api, exec)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(s.visual.Comparisons(exec.ID))
+}
+
+func (s *Server) handleReviewVisualAPI(approve bool) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id := chi.URLParam(r, "id")
+
+		var req struct {
+			Path string `json:"path"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Path == "" {
+			http.Error(w, "Invalid request body", http.StatusBadRequest)
+			return
+		}
+
+		if !s.reviewScreenshot(w, r, id, req.Path, approve) {
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(s.visual.Comparisons(id))
+	}
+}
+
+func (s *Server) handleBaselinesAPI(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(s.visual.Baselines())
+}
@@ -0,0 +1,309 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/testkube/dashboard/internal/database"
+)
+
+var (
+	// errNoUser is returned when the authenticating proxy doesn't say who
+	// the request is from, as watchlists are per user
+	errNoUser = errors.New("watchlists need the authenticating proxy to identify you")
+	// errInvalidWatch is returned for a watch or channel the request got wrong
+	errInvalidWatch = errors.New("invalid watch")
+)
+
+// watchButton is the star toggle for a workflow, or one of its tests
+type watchButton struct {
+	Workflow string
+	Test     string
+	Watching bool
+}
+
+// watchButtons returns the star toggles for the user's pages. Without a
+// user there's no watchlist, so there are no toggles.
+func (s *Server) watchButtons(r *http.Request) func(workflow, test string) *watchButton {
+	user := proxyUser(r)
+	watching := map[[2]string]bool{}
+	if user != "" {
+		watches, err := s.db.GetWatches(user)
+		if err != nil {
+			log.Printf("Error getting the watches of %s: %v", user, err)
+		}
+		for _, w := range watches {
+			watching[[2]string{w.Workflow, w.Test}] = true
+		}
+	}
+	return func(workflow, test string) *watchButton {
+		if user == "" {
+			return nil
+		}
+		return &watchButton{Workflow: workflow, Test: test, Watching: watching[[2]string{workflow, test}]}
+	}
+}
+
+// saveWatch validates and stores a watch for the request's user, keeping
+// when it was first starred
+func (s *Server) saveWatch(r *http.Request, watch database.Watch) error {
+	watch.User = proxyUser(r)
+	if watch.User == "" {
+		return errNoUser
+	}
+	if watch.Workflow == "" {
+		return fmt.Errorf("%w: a workflow is required", errInvalidWatch)
+	}
+	for _, event := range watch.Events {
+		if !slices.Contains(database.WatchEvents, event) {
+			return fmt.Errorf("%w: unknown event %q, expected one of %s", errInvalidWatch, event, strings.Join(database.WatchEvents, ", "))
+		}
+	}
+	if watch.Events == nil {
+		watch.Events = []string{}
+	}
+
+	watches, err := s.db.GetWatches(watch.User)
+	if err != nil {
+		return fmt.Errorf("failed to load watches: %w", err)
+	}
+	watch.CreatedAt = time.Now()
+	for _, w := range watches {
+		if w.Workflow == watch.Workflow && w.Test == watch.Test {
+			watch.CreatedAt = w.CreatedAt
+		}
+	}
+	if err := s.db.SaveWatch(watch); err != nil {
+		return fmt.Errorf("failed to save watch: %w", err)
+	}
+	return nil
+}
+
+// saveUserChannel sets where the request's user is notified. An empty URL
+// stops their notifications.
+func (s *Server) saveUserChannel(r *http.Request, webhookURL string) error {
+	user := proxyUser(r)
+	if user == "" {
+		return errNoUser
+	}
+	if webhookURL != "" {
+		u, err := url.Parse(webhookURL)
+		if err != nil || (u.Scheme != "https" && u.Scheme != "http") || u.Host == "" {
+			return fmt.Errorf("%w: webhook URL %q isn't an http(s) URL", errInvalidWatch, webhookURL)
+		}
+	}
+	channel := database.UserChannel{User: user, WebhookURL: webhookURL, UpdatedAt: time.Now()}
+	if err := s.db.SaveUserChannel(channel); err != nil {
+		return fmt.Errorf("failed to save channel: %w", err)
+	}
+	return nil
+}
+
+// watchError responds with the status for a watchlist change that failed
+func watchError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, errNoUser):
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+	case errors.Is(err, errInvalidWatch):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	default:
+		log.Printf("Error changing watchlist: %v", err)
+		http.Error(w, "Failed to change watchlist", http.StatusInternalServerError)
+	}
+}
+
+func (s *Server) watchlistData(user string) (map[string]interface{}, error) {
+	watches, err := s.db.GetWatches(user)
+	if err != nil {
+		return nil, err
+	}
+	channel, err := s.db.GetUserChannel(user)
+	if err != nil {
+		return nil, err
+	}
+	return map[string]interface{}{
+		"User":    user,
+		"Watches": watches,
+		"Channel": channel,
+		"Events":  database.WatchEvents,
+	}, nil
+}
+
+// handleWatchlist shows the user's starred workflows and tests, with the
+// events each notifies them of
+func (s *Server) handleWatchlist(w http.ResponseWriter, r *http.Request) {
+	user := proxyUser(r)
+	if user == "" {
+		watchError(w, errNoUser)
+		return
+	}
+	data, err := s.watchlistData(user)
+	if err != nil {
+		s.databaseError(w, "your watchlist", err)
+		return
+	}
+	s.render(w, "watchlist.html", data)
+}
+
+// renderWatchlist responds to a change on the watchlist page with the
+// updated list
+func (s *Server) renderWatchlist(w http.ResponseWriter, r *http.Request, message string) {
+	data, err := s.watchlistData(proxyUser(r))
+	if err != nil {
+		s.databaseError(w, "your watchlist", err)
+		return
+	}
+	trigger, _ := json.Marshal(map[string]string{"showMessage": message})
+	w.Header().Set("HX-Trigger", string(trigger))
+	s.executeTemplate(w, "watchlist.html", "watchlist", data)
+}
+
+// handleSaveWatch stars a workflow or test from the watchlist page, or
+// changes the events it notifies of
+func (s *Server) handleSaveWatch(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, "Invalid form", http.StatusBadRequest)
+		return
+	}
+	watch := database.Watch{
+		Workflow: strings.TrimSpace(r.FormValue("workflow")),
+		Test:     strings.TrimSpace(r.FormValue("test")),
+		Events:   r.Form["events"],
+	}
+	if err := s.saveWatch(r, watch); err != nil {
+		watchError(w, err)
+		return
+	}
+	s.renderWatchlist(w, r, "Saved watch of "+watchSubject(watch.Workflow, watch.Test))
+}
+
+// handleDeleteWatch unstars a workflow or test from the watchlist page
+func (s *Server) handleDeleteWatch(w http.ResponseWriter, r *http.Request) {
+	user := proxyUser(r)
+	if user == "" {
+		watchError(w, errNoUser)
+		return
+	}
+	workflow, test := r.URL.Query().Get("workflow"), r.URL.Query().Get("test")
+	if err := s.db.DeleteWatch(user, workflow, test); err != nil {
+		watchError(w, err)
+		return
+	}
+	s.renderWatchlist(w, r, "Stopped watching "+watchSubject(workflow, test))
+}
+
+// handleSaveUserChannel sets the user's webhook from the watchlist page
+func (s *Server) handleSaveUserChannel(w http.ResponseWriter, r *http.Request) {
+	webhookURL := strings.TrimSpace(r.FormValue("webhookUrl"))
+	if err := s.saveUserChannel(r, webhookURL); err != nil {
+		watchError(w, err)
+		return
+	}
+	message := "Notifications will be sent to your webhook"
+	if webhookURL == "" {
+		message = "Notifications are off"
+	}
+	s.renderWatchlist(w, r, message)
+}
+
+// handleToggleWatch stars or unstars a workflow or test from the pages
+// showing them, responding with the updated toggle. Starring notifies of
+// nothing until events are chosen on the watchlist page.
+func (s *Server) handleToggleWatch(w http.ResponseWriter, r *http.Request) {
+	user := proxyUser(r)
+	button := watchButton{Workflow: r.FormValue("workflow"), Test: r.FormValue("test")}
+	if user == "" {
+		watchError(w, errNoUser)
+		return
+	}
+	button.Watching = !s.watchButtons(r)(button.Workflow, button.Test).Watching
+
+	var err error
+	if button.Watching {
+		err = s.saveWatch(r, database.Watch{Workflow: button.Workflow, Test: button.Test})
+	} else {
+		err = s.db.DeleteWatch(user, button.Workflow, button.Test)
+	}
+	if err != nil {
+		watchError(w, err)
+		return
+	}
+	s.executeTemplate(w, "watchlist.html", "watch-button", button)
+}
+
+func (s *Server) handleWatchlistAPI(w http.ResponseWriter, r *http.Request) {
+	user := proxyUser(r)
+	if user == "" {
+		watchError(w, errNoUser)
+		return
+	}
+	watches, err := s.db.GetWatches(user)
+	if err != nil {
+		s.databaseError(w, "your watchlist", err)
+		return
+	}
+	if watches == nil {
+		watches = []database.Watch{}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(watches)
+}
+
+// handleSaveWatchAPI takes {"workflow": ..., "test": ..., "events":
+// ["failure", "recovery", "flaky"]}, leaving test out to watch the whole
+// workflow
+func (s *Server) handleSaveWatchAPI(w http.ResponseWriter, r *http.Request) {
+	var watch database.Watch
+	if err := json.NewDecoder(r.Body).Decode(&watch); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	if err := s.saveWatch(r, watch); err != nil {
+		watchError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) handleDeleteWatchAPI(w http.ResponseWriter, r *http.Request) {
+	user := proxyUser(r)
+	if user == "" {
+		watchError(w, errNoUser)
+		return
+	}
+	if err := s.db.DeleteWatch(user, r.URL.Query().Get("workflow"), r.URL.Query().Get("test")); err != nil {
+		watchError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// handleSaveUserChannelAPI takes {"webhookUrl": ...}
+func (s *Server) handleSaveUserChannelAPI(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		WebhookURL string `json:"webhookUrl"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	if err := s.saveUserChannel(r, strings.TrimSpace(req.WebhookURL)); err != nil {
+		watchError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func watchSubject(workflow, test string) string {
+	if test == "" {
+		return workflow
+	}
+	return workflow + ": " + test
+}
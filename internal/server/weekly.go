@@ -0,0 +1,260 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/testkube/dashboard/internal/database"
+	"github.com/testkube/dashboard/internal/notify"
+	"github.com/testkube/dashboard/internal/reports"
+)
+
+// weeklyReportCheckInterval is how often the server looks for a finished
+// week without a report
+const weeklyReportCheckInterval = time.Hour
+
+// weeklyReportChannels are where weekly reports are pushed: the webhook in
+// WEEKLY_REPORT_WEBHOOK_URL, or else the failure notification channel, and
+// the addresses in WEEKLY_REPORT_EMAIL_TO
+func weeklyReportChannels(notifier notify.Notifier) []notify.Notifier {
+	var channels []notify.Notifier
+	if url := os.Getenv("WEEKLY_REPORT_WEBHOOK_URL"); url != "" {
+		channels = append(channels, notify.NewWebhookNotifier(url))
+	} else if notifier != nil {
+		channels = append(channels, notifier)
+	}
+	var to []string
+	for _, addr := range strings.Split(os.Getenv("WEEKLY_REPORT_EMAIL_TO"), ",") {
+		if addr = strings.TrimSpace(addr); addr != "" {
+			to = append(to, addr)
+		}
+	}
+	if email := notify.NewEmailNotifierFromEnv(to); email != nil {
+		channels = append(channels, email)
+	}
+	return channels
+}
+
+// costPerRunnerHour reads REPORT_COST_PER_RUNNER_HOUR, the price of an
+// hour of execution time
+func costPerRunnerHour() float64 {
+	val := os.Getenv("REPORT_COST_PER_RUNNER_HOUR")
+	if val == "" {
+		return 0
+	}
+	cost, err := strconv.ParseFloat(val, 64)
+	if err != nil || cost < 0 {
+		log.Printf("Warning: invalid REPORT_COST_PER_RUNNER_HOUR %q, leaving cost out of reports", val)
+		return 0
+	}
+	return cost
+}
+
+// weeklyReportLoop generates and pushes each week's report once it has
+// ended. Reports already stored, e.g. by another replica, aren't redone.
+func (s *Server) weeklyReportLoop() {
+	ticker := time.NewTicker(weeklyReportCheckInterval)
+	defer ticker.Stop()
+	for range ticker.C {
+		week := reports.WeekStart(time.Now()).AddDate(0, 0, -7)
+		stored, err := s.db.GetReport(reports.WeeklyReportKind, week.Format("2006-01-02"))
+		if err != nil {
+			log.Printf("Error looking up weekly report: %v", err)
+			continue
+		}
+		if stored != nil {
+			continue
+		}
+		if _, err := s.generateWeeklyReport(week, true); err != nil {
+			log.Printf("Error generating weekly report: %v", err)
+		}
+	}
+}
+
+// generateWeeklyReport compiles and stores the report for the week
+// starting at week, replacing any stored before, and pushes it to the
+// report channels with push set
+func (s *Server) generateWeeklyReport(week time.Time, push bool) (*reports.WeeklyReport, error) {
+	report, err := reports.BuildWeeklyReport(s.db, s.ownership, s.envMgr.History(time.Time{}), week, time.Now(),
+		reports.WeeklyOptions{CostPerRunnerHour: costPerRunnerHour(), Limit: reports.DefaultLeaderboardSize})
+	if err != nil {
+		return nil, err
+	}
+	data, err := json.Marshal(report)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode weekly report: %w", err)
+	}
+	stored := database.StoredReport{Kind: reports.WeeklyReportKind, Period: report.Period(), GeneratedAt: report.GeneratedAt, Data: data}
+	if err := s.db.SaveReport(stored); err != nil {
+		return nil, fmt.Errorf("failed to store weekly report: %w", err)
+	}
+	log.Printf("Generated weekly report for the week of %s: %d runs", report.Period(), report.Runs)
+	if push {
+		s.pushWeeklyReport(report)
+	}
+	return report, nil
+}
+
+// pushWeeklyReport sends the report's summary, linking to it, to every
+// report channel
+func (s *Server) pushWeeklyReport(report *reports.WeeklyReport) {
+	msg := notify.Message{
+		Title: fmt.Sprintf("Weekly quality report: week of %s", report.Week.Format("Jan 02, 2006")),
+		Text:  report.Summary(),
+	}
+	if base := strings.TrimSuffix(os.Getenv("DASHBOARD_URL"), "/"); base != "" {
+		msg.URL = base + "/reports/weekly/" + report.Period()
+	}
+	for _, channel := range s.weeklyChannels {
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		if err := channel.Send(ctx, msg); err != nil {
+			log.Printf("Error pushing weekly report: %v", err)
+		}
+		cancel()
+	}
+}
+
+// storedWeeklyReport reads the report of the week a date falls in, or nil
+// if none was generated
+func (s *Server) storedWeeklyReport(date string) (*reports.WeeklyReport, error) {
+	day, err := time.Parse("2006-01-02", date)
+	if err != nil {
+		return nil, nil
+	}
+	stored, err := s.db.GetReport(reports.WeeklyReportKind, reports.WeekStart(day).Format("2006-01-02"))
+	if err != nil || stored == nil {
+		return nil, err
+	}
+	var report reports.WeeklyReport
+	if err := json.Unmarshal(stored.Data, &report); err != nil {
+		return nil, fmt.Errorf("failed to decode weekly report: %w", err)
+	}
+	return &report, nil
+}
+
+// handleWeeklyReports shows the latest weekly report
+func (s *Server) handleWeeklyReports(w http.ResponseWriter, r *http.Request) {
+	stored, err := s.db.GetReports(reports.WeeklyReportKind)
+	if err != nil {
+		s.databaseError(w, "weekly reports", err)
+		return
+	}
+	if len(stored) > 0 {
+		http.Redirect(w, r, "/reports/weekly/"+stored[0].Period, http.StatusFound)
+		return
+	}
+	s.render(w, "weekly_report.html", map[string]interface{}{"Page": "weekly"})
+}
+
+// handleWeeklyReport shows the report of the week a date falls in, or with
+// ?format=md downloads it as Markdown
+func (s *Server) handleWeeklyReport(w http.ResponseWriter, r *http.Request) {
+	report, err := s.storedWeeklyReport(chi.URLParam(r, "date"))
+	if err != nil {
+		s.databaseError(w, "the weekly report", err)
+		return
+	}
+	if report == nil {
+		http.Error(w, "No weekly report for this date", http.StatusNotFound)
+		return
+	}
+
+	if r.URL.Query().Get("format") == "md" {
+		filename := fmt.Sprintf("weekly-quality-%s.md", report.Period())
+		w.Header().Set("Content-Type", "text/markdown; charset=utf-8")
+		w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+		w.Write([]byte(report.Markdown()))
+		return
+	}
+
+	stored, err := s.db.GetReports(reports.WeeklyReportKind)
+	if err != nil {
+		s.databaseError(w, "weekly reports", err)
+		return
+	}
+	weeks := make([]string, len(stored))
+	for i, r := range stored {
+		weeks[i] = r.Period
+	}
+	s.render(w, "weekly_report.html", map[string]interface{}{
+		"Report": report,
+		"Weeks":  weeks,
+		"Page":   "weekly",
+	})
+}
+
+// weeklyReportWeek reads ?week=, any date in the week to report on,
+// defaulting to the last full week
+func weeklyReportWeek(r *http.Request) (time.Time, error) {
+	val := r.URL.Query().Get("week")
+	if val == "" {
+		val = r.FormValue("week")
+	}
+	if val == "" {
+		return reports.WeekStart(time.Now()).AddDate(0, 0, -7), nil
+	}
+	day, err := time.Parse("2006-01-02", val)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid week %q: use a date such as 2006-01-02", val)
+	}
+	return reports.WeekStart(day), nil
+}
+
+// handleGenerateWeeklyReport generates a week's report from the report
+// page and shows it
+func (s *Server) handleGenerateWeeklyReport(w http.ResponseWriter, r *http.Request) {
+	week, err := weeklyReportWeek(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	report, err := s.generateWeeklyReport(week, r.FormValue("push") == "true")
+	if err != nil {
+		s.databaseError(w, "the weekly report", err)
+		return
+	}
+	http.Redirect(w, r, "/reports/weekly/"+report.Period(), http.StatusSeeOther)
+}
+
+// handleGenerateWeeklyReportAPI generates and stores the report of the
+// week in ?week= (the last full week by default), pushing it to the report
+// channels with ?push=true
+func (s *Server) handleGenerateWeeklyReportAPI(w http.ResponseWriter, r *http.Request) {
+	week, err := weeklyReportWeek(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	report, err := s.generateWeeklyReport(week, r.URL.Query().Get("push") == "true")
+	if err != nil {
+		s.databaseError(w, "the weekly report", err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(report)
+}
+
+// handleWeeklyReportAPI returns the stored report of the week a date falls
+// in
+func (s *Server) handleWeeklyReportAPI(w http.ResponseWriter, r *http.Request) {
+	report, err := s.storedWeeklyReport(chi.URLParam(r, "date"))
+	if err != nil {
+		s.databaseError(w, "the weekly report", err)
+		return
+	}
+	if report == nil {
+		http.Error(w, "No weekly report for this date", http.StatusNotFound)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(report)
+}
@@ -0,0 +1,135 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/testkube/dashboard/internal/testkube"
+)
+
+// templateRow is a workflow template with the workflows that use it
+type templateRow struct {
+	testkube.WorkflowTemplate
+	UsedBy []string `json:"usedBy"`
+}
+
+// templateRows lists the cluster's templates, filtered by ?selector=, with
+// the workflows using each, and separately the templates workflows refer to
+// that the cluster doesn't have
+func (s *Server) templateRows(r *http.Request) ([]templateRow, []templateRow, error) {
+	api := s.apiFor(r)
+	templates, err := api.GetWorkflowTemplates(testkube.ListOptions{Selector: strings.TrimSpace(r.URL.Query().Get("selector"))})
+	if err != nil {
+		return nil, nil, err
+	}
+	workflows, err := api.GetWorkflows(testkube.ListOptions{})
+	if err != nil {
+		return nil, nil, err
+	}
+	using := testkube.WorkflowsUsing(workflows)
+
+	rows := make([]templateRow, 0, len(templates))
+	known := make(map[string]bool, len(templates))
+	for _, t := range templates {
+		known[t.Name] = true
+		rows = append(rows, templateRow{WorkflowTemplate: t, UsedBy: using[t.Name]})
+	}
+
+	// References to templates that were deleted or never created; only
+	// meaningful when the list isn't narrowed by a selector
+	var missing []templateRow
+	if strings.TrimSpace(r.URL.Query().Get("selector")) == "" {
+		for name, usedBy := range using {
+			if !known[name] {
+				missing = append(missing, templateRow{WorkflowTemplate: testkube.WorkflowTemplate{Name: name}, UsedBy: usedBy})
+			}
+		}
+		slices.SortFunc(missing, func(a, b templateRow) int { return strings.Compare(a.Name, b.Name) })
+	}
+	return rows, missing, nil
+}
+
+// handleWorkflowTemplates lists the workflow templates and which workflows
+// use them
+func (s *Server) handleWorkflowTemplates(w http.ResponseWriter, r *http.Request) {
+	rows, missing, err := s.templateRows(r)
+	if err != nil {
+		s.listError(w, "workflow templates", err)
+		return
+	}
+	s.renderPage(w, r, "workflow_templates.html", map[string]interface{}{
+		"Templates": rows,
+		"Missing":   missing,
+		"Selector":  r.URL.Query().Get("selector"),
+	})
+}
+
+// handleWorkflowTemplatesAPI lists the workflow templates, with the
+// workflows using each
+func (s *Server) handleWorkflowTemplatesAPI(w http.ResponseWriter, r *http.Request) {
+	rows, _, err := s.templateRows(r)
+	if err != nil {
+		s.listError(w, "workflow templates", err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(rows)
+}
+
+// workflowTemplate returns the template a request names, which may contain
+// slashes (e.g. "official/k6/v1"), and the workflows using it
+func (s *Server) workflowTemplate(r *http.Request) (*templateRow, error) {
+	api := s.apiFor(r)
+	name := chi.URLParam(r, "*")
+	template, err := api.GetWorkflowTemplate(name)
+	if err != nil {
+		return nil, err
+	}
+	workflows, err := api.GetWorkflows(testkube.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	return &templateRow{WorkflowTemplate: *template, UsedBy: testkube.WorkflowsUsing(workflows)[template.Name]}, nil
+}
+
+// templateError responds to a failed template lookup
+func (s *Server) templateError(w http.ResponseWriter, err error) {
+	if errors.Is(err, testkube.ErrWorkflowTemplateNotFound) {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	if errors.Is(err, testkube.ErrCircuitOpen) {
+		log.Printf("Error getting workflow template: %v", err)
+		http.Error(w, "The Testkube API is unavailable, so the template can't be loaded right now", http.StatusServiceUnavailable)
+		return
+	}
+	log.Printf("Error getting workflow template: %v", err)
+	http.Error(w, "Failed to load workflow template", http.StatusInternalServerError)
+}
+
+// handleWorkflowTemplateDetail shows a template and the workflows using it
+func (s *Server) handleWorkflowTemplateDetail(w http.ResponseWriter, r *http.Request) {
+	row, err := s.workflowTemplate(r)
+	if err != nil {
+		s.templateError(w, err)
+		return
+	}
+	s.render(w, "workflow_templates.html", map[string]interface{}{
+		"Template": row,
+	})
+}
+
+func (s *Server) handleWorkflowTemplateAPI(w http.ResponseWriter, r *http.Request) {
+	row, err := s.workflowTemplate(r)
+	if err != nil {
+		s.templateError(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(row)
+}
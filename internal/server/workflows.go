@@ -0,0 +1,211 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/testkube/dashboard/internal/database"
+	"github.com/testkube/dashboard/internal/testkube"
+)
+
+// maxWorkflowSpecSize caps an uploaded TestWorkflow definition
+const maxWorkflowSpecSize = 1 << 20
+
+// newWorkflowSpec starts the editor for a new workflow
+const newWorkflowSpec = `apiVersion: testworkflows.testkube.io/v1
+kind: TestWorkflow
+metadata:
+  name: my-workflow
+  labels:
+    team: my-team
+spec:
+  container:
+    image: mcr.microsoft.com/playwright:v1.47.0
+  steps:
+    - name: Run tests
+      shell: npx playwright test
+`
+
+// workflowStatus is the status for a failed workflow change
+func workflowStatus(err error) int {
+	switch {
+	case errors.Is(err, testkube.ErrInvalidWorkflowSpec):
+		return http.StatusBadRequest
+	case errors.Is(err, testkube.ErrWorkflowNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, testkube.ErrWorkflowExists):
+		return http.StatusConflict
+	case errors.Is(err, testkube.ErrCircuitOpen):
+		return http.StatusServiceUnavailable
+	}
+	return http.StatusInternalServerError
+}
+
+// workflowError responds to a failed workflow change
+func workflowError(w http.ResponseWriter, err error) {
+	status := workflowStatus(err)
+	if status == http.StatusInternalServerError {
+		log.Printf("Error changing workflow: %v", err)
+		http.Error(w, "Failed to change workflow", status)
+		return
+	}
+	http.Error(w, err.Error(), status)
+}
+
+// recordWorkflowChange adds a workflow change to the activity feed
+func (s *Server) recordWorkflowChange(r *http.Request, eventType, name, message string) {
+	event := database.Event{Type: eventType, Actor: proxyUser(r), Subject: name, Message: message}
+	if eventType != activityWorkflowDeleted {
+		event.URL = "/workflows/" + name
+	}
+	s.recordEvent(event)
+}
+
+// handleWorkflowEditor shows the YAML editor, for a new workflow or, with a
+// name, an existing one
+func (s *Server) handleWorkflowEditor(w http.ResponseWriter, r *http.Request) {
+	name := chi.URLParam(r, "name")
+	spec := []byte(newWorkflowSpec)
+	if name != "" {
+		var err error
+		if spec, err = s.apiFor(r).GetWorkflowSpec(name); err != nil {
+			workflowError(w, err)
+			return
+		}
+	}
+	s.render(w, "workflow_editor.html", map[string]interface{}{
+		"Name": name,
+		"Spec": string(spec),
+	})
+}
+
+// handleSaveWorkflow creates or updates a workflow from the editor form,
+// showing the editor again with the error when the change fails
+func (s *Server) handleSaveWorkflow(w http.ResponseWriter, r *http.Request) {
+	r.Body = http.MaxBytesReader(w, r.Body, maxWorkflowSpecSize)
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, "Invalid form", http.StatusBadRequest)
+		return
+	}
+	name := chi.URLParam(r, "name")
+	spec := []byte(r.PostForm.Get("spec"))
+
+	var wf *testkube.Workflow
+	var err error
+	if name == "" {
+		wf, err = s.apiFor(r).CreateWorkflow(spec)
+	} else {
+		wf, err = s.apiFor(r).UpdateWorkflow(name, spec)
+	}
+	if err != nil {
+		status := workflowStatus(err)
+		if status == http.StatusInternalServerError {
+			log.Printf("Error saving workflow: %v", err)
+		}
+		w.WriteHeader(status)
+		s.render(w, "workflow_editor.html", map[string]interface{}{
+			"Name":  name,
+			"Spec":  string(spec),
+			"Error": err.Error(),
+		})
+		return
+	}
+
+	if name == "" {
+		s.recordWorkflowChange(r, activityWorkflowCreated, wf.Name, "Created workflow")
+	} else {
+		s.recordWorkflowChange(r, activityWorkflowUpdated, wf.Name, "Updated workflow definition")
+	}
+	http.Redirect(w, r, "/workflows/"+wf.Name, http.StatusSeeOther)
+}
+
+// handleDeleteWorkflow deletes a workflow from its page, sending htmx back
+// to the workflow list
+func (s *Server) handleDeleteWorkflow(w http.ResponseWriter, r *http.Request) {
+	name := chi.URLParam(r, "name")
+	if err := s.apiFor(r).DeleteWorkflow(name); err != nil {
+		workflowError(w, err)
+		return
+	}
+	s.recordWorkflowChange(r, activityWorkflowDeleted, name, "Deleted workflow")
+	w.Header().Set("HX-Redirect", "/workflows")
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// readWorkflowSpec reads a YAML definition from an API request body
+func readWorkflowSpec(w http.ResponseWriter, r *http.Request) ([]byte, bool) {
+	spec, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxWorkflowSpecSize))
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Workflow spec must be YAML of at most %d bytes", maxWorkflowSpecSize), http.StatusBadRequest)
+		return nil, false
+	}
+	if strings.TrimSpace(string(spec)) == "" {
+		http.Error(w, "Workflow spec is empty", http.StatusBadRequest)
+		return nil, false
+	}
+	return spec, true
+}
+
+func (s *Server) handleWorkflowSpecAPI(w http.ResponseWriter, r *http.Request) {
+	spec, err := s.apiFor(r).GetWorkflowSpec(chi.URLParam(r, "name"))
+	if err != nil {
+		workflowError(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "text/yaml; charset=utf-8")
+	w.Write(spec)
+}
+
+func (s *Server) handleCreateWorkflowAPI(w http.ResponseWriter, r *http.Request) {
+	spec, ok := readWorkflowSpec(w, r)
+	if !ok {
+		return
+	}
+	wf, err := s.apiFor(r).CreateWorkflow(spec)
+	if err != nil {
+		workflowError(w, err)
+		return
+	}
+	s.recordWorkflowChange(r, activityWorkflowCreated, wf.Name, "Created workflow")
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(wf)
+}
+
+func (s *Server) handleUpdateWorkflowAPI(w http.ResponseWriter, r *http.Request) {
+	spec, ok := readWorkflowSpec(w, r)
+	if !ok {
+		return
+	}
+	wf, err := s.apiFor(r).UpdateWorkflow(chi.URLParam(r, "name"), spec)
+	if err != nil {
+		workflowError(w, err)
+		return
+	}
+	s.recordWorkflowChange(r, activityWorkflowUpdated, wf.Name, "Updated workflow definition")
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(wf)
+}
+
+func (s *Server) handleDeleteWorkflowAPI(w http.ResponseWriter, r *http.Request) {
+	name := chi.URLParam(r, "name")
+	if err := s.apiFor(r).DeleteWorkflow(name); err != nil {
+		workflowError(w, err)
+		return
+	}
+	s.recordWorkflowChange(r, activityWorkflowDeleted, name, "Deleted workflow")
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) handleWorkflowTypesAPI(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(s.types.Types())
+}
@@ -0,0 +1,157 @@
+// Package share issues expiring links that give people outside the team
+// read-only access to an execution's logs and artifacts.
+package share
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"sort"
+	"sync"
+	"time"
+)
+
+const (
+	// DefaultTTL is how long a link works when no expiry is requested
+	DefaultTTL = 7 * 24 * time.Hour
+	// MaxTTL is the longest expiry a link can be given
+	MaxTTL = 90 * 24 * time.Hour
+)
+
+var (
+	// ErrNotFound is returned for unknown and revoked links
+	ErrNotFound = errors.New("share link not found")
+	// ErrExpired is returned for links past their expiry
+	ErrExpired = errors.New("share link expired")
+)
+
+// Link grants access to one execution
+type Link struct {
+	Token       string `json:"token"`
+	ExecutionID string `json:"executionId"`
+	// Tenant the link was created in, whose feature flags apply to it
+	Tenant    string    `json:"tenant,omitempty"`
+	CreatedBy string    `json:"createdBy,omitempty"`
+	CreatedAt time.Time `json:"createdAt"`
+	ExpiresAt time.Time `json:"expiresAt"`
+}
+
+// Store keeps the share links issued
+type Store struct {
+	ttl   time.Duration
+	links map[string]*Link
+	mu    sync.RWMutex
+	now   func() time.Time
+}
+
+// NewStore creates an empty store. The default expiry is read from
+// SHARE_LINK_TTL, e.g. "72h".
+func NewStore() *Store {
+	ttl := DefaultTTL
+	if val := os.Getenv("SHARE_LINK_TTL"); val != "" {
+		if d, err := time.ParseDuration(val); err == nil && d > 0 && d <= MaxTTL {
+			ttl = d
+		} else {
+			log.Printf("Warning: invalid SHARE_LINK_TTL %q, using %s", val, DefaultTTL)
+		}
+	}
+	return &Store{ttl: ttl, links: make(map[string]*Link), now: time.Now}
+}
+
+// TTL is the expiry given to links created without one
+func (s *Store) TTL() time.Duration {
+	return s.ttl
+}
+
+// Create issues a link to an execution. A zero ttl uses the default.
+func (s *Store) Create(executionID, tenant string, ttl time.Duration, user string) (Link, error) {
+	if ttl == 0 {
+		ttl = s.ttl
+	}
+	if ttl < 0 || ttl > MaxTTL {
+		return Link{}, fmt.Errorf("share link expiry must be between 0 and %s", MaxTTL)
+	}
+
+	raw := make([]byte, 24)
+	if _, err := rand.Read(raw); err != nil {
+		return Link{}, fmt.Errorf("failed to generate share token: %w", err)
+	}
+
+	now := s.now()
+	link := &Link{
+		Token:       hex.EncodeToString(raw),
+		ExecutionID: executionID,
+		Tenant:      tenant,
+		CreatedBy:   user,
+		CreatedAt:   now,
+		ExpiresAt:   now.Add(ttl),
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.removeExpired(now)
+	s.links[link.Token] = link
+	return *link, nil
+}
+
+// removeExpired drops links past their expiry. Callers hold the lock.
+func (s *Store) removeExpired(now time.Time) {
+	for token, link := range s.links {
+		if now.After(link.ExpiresAt) {
+			delete(s.links, token)
+		}
+	}
+}
+
+// Get returns the link for a token while it is valid
+func (s *Store) Get(token string) (Link, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	link, ok := s.links[token]
+	if !ok {
+		return Link{}, ErrNotFound
+	}
+	if s.now().After(link.ExpiresAt) {
+		return Link{}, ErrExpired
+	}
+	return *link, nil
+}
+
+// List returns an execution's valid links, newest first
+func (s *Store) List(executionID string) []Link {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	now := s.now()
+	var links []Link
+	for _, link := range s.links {
+		if link.ExecutionID == executionID && !now.After(link.ExpiresAt) {
+			links = append(links, *link)
+		}
+	}
+	sort.Slice(links, func(i, j int) bool { return links[i].CreatedAt.After(links[j].CreatedAt) })
+	return links
+}
+
+// Revoke invalidates a link
+func (s *Store) Revoke(token string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.links[token]; !ok {
+		return ErrNotFound
+	}
+	delete(s.links, token)
+	return nil
+}
+
+// Len returns the number of links, including expired ones not yet removed
+func (s *Store) Len() int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return len(s.links)
+}
@@ -0,0 +1,58 @@
+package share
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestStore(t *testing.T) {
+	s := NewStore()
+	now := time.Date(2025, 3, 14, 12, 0, 0, 0, time.UTC)
+	s.now = func() time.Time { return now }
+
+	link, err := s.Create("exec-1", "", time.Hour, "alice")
+	if err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	if len(link.Token) != 48 {
+		t.Errorf("token length: got %d, expected 48", len(link.Token))
+	}
+	if !link.ExpiresAt.Equal(now.Add(time.Hour)) {
+		t.Errorf("expires at: got %s, expected %s", link.ExpiresAt, now.Add(time.Hour))
+	}
+
+	defaulted, _ := s.Create("exec-1", "", 0, "bob")
+	if !defaulted.ExpiresAt.Equal(now.Add(DefaultTTL)) {
+		t.Errorf("default expiry: got %s", defaulted.ExpiresAt)
+	}
+	if _, err := s.Create("exec-1", "", MaxTTL+time.Hour, "bob"); err == nil {
+		t.Error("expected an error for an expiry past the maximum")
+	}
+
+	got, err := s.Get(link.Token)
+	if err != nil || got.ExecutionID != "exec-1" {
+		t.Errorf("Get: got %+v, %v", got, err)
+	}
+	if len(s.List("exec-1")) != 2 {
+		t.Errorf("List: got %d links, expected 2", len(s.List("exec-1")))
+	}
+
+	now = now.Add(2 * time.Hour)
+	if _, err := s.Get(link.Token); !errors.Is(err, ErrExpired) {
+		t.Errorf("expired link: got %v, expected ErrExpired", err)
+	}
+	if len(s.List("exec-1")) != 1 {
+		t.Errorf("List should skip expired links, got %d", len(s.List("exec-1")))
+	}
+
+	if err := s.Revoke(defaulted.Token); err != nil {
+		t.Fatalf("Revoke failed: %v", err)
+	}
+	if _, err := s.Get(defaulted.Token); !errors.Is(err, ErrNotFound) {
+		t.Errorf("revoked link: got %v, expected ErrNotFound", err)
+	}
+	if _, err := s.Get("unknown"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("unknown link: got %v, expected ErrNotFound", err)
+	}
+}
@@ -0,0 +1,258 @@
+// Package subscriptions notifies users of what happens to the workflows and
+// tests on their watchlists: a failure after passing, a recovery after
+// failing, and tests newly classified as flaky. Each user gets only the
+// events they subscribed to, through their own channel, rather than the
+// team-wide alerts of package notify.
+package subscriptions
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/testkube/dashboard/internal/database"
+	"github.com/testkube/dashboard/internal/notify"
+	"github.com/testkube/dashboard/internal/testkube"
+)
+
+const (
+	// FlakyWindow is how far back a test's runs count towards classifying
+	// it as flaky
+	FlakyWindow = 7 * 24 * time.Hour
+	// FlakyThreshold is the share of a test's runs that must fail, amid
+	// passes, for it to be flaky, as on the flakiness pages
+	FlakyThreshold = 0.1
+)
+
+// Notice is an event for the watchers of a workflow, or of one of its
+// tests when Test is set
+type Notice struct {
+	Event    string
+	Workflow string
+	Test     string
+	Message  notify.Message
+}
+
+// Engine works out the notices for each ingested execution and fans them
+// out to the users watching. Register it with the worker.
+type Engine struct {
+	db      database.Database
+	baseURL string
+	// notifier delivers to a user's channel
+	notifier func(channel database.UserChannel) notify.Notifier
+}
+
+func NewEngine(db database.Database) *Engine {
+	return &Engine{
+		db:      db,
+		baseURL: strings.TrimSuffix(os.Getenv("DASHBOARD_URL"), "/"),
+		notifier: func(channel database.UserChannel) notify.Notifier {
+			return notify.NewWebhookNotifier(channel.WebhookURL)
+		},
+	}
+}
+
+// ExecutionIngested notifies the execution's watchers. Executions are
+// compared with the workflow's previous one, so notices don't depend on
+// what the dashboard saw before it last started.
+func (e *Engine) ExecutionIngested(exec testkube.Execution, cases []database.TestCase) {
+	watches, err := e.db.GetWatches("")
+	if err != nil {
+		log.Printf("Subscriptions: error getting watches: %v", err)
+		return
+	}
+	var subscribed []database.Watch
+	for _, w := range watches {
+		if w.Workflow == exec.WorkflowName && len(w.Events) > 0 {
+			subscribed = append(subscribed, w)
+		}
+	}
+	if len(subscribed) == 0 {
+		return
+	}
+
+	notices, err := e.Notices(exec, cases)
+	if err != nil {
+		log.Printf("Subscriptions: error checking execution %s: %v", exec.ID, err)
+		return
+	}
+	for user, messages := range Match(subscribed, notices) {
+		e.deliver(user, messages)
+	}
+}
+
+// Notices works out what an execution means for its workflow's watchers
+func (e *Engine) Notices(exec testkube.Execution, cases []database.TestCase) ([]Notice, error) {
+	previous, _, err := e.db.QueryExecutions(database.ExecutionQuery{
+		Workflows: []string{exec.WorkflowName},
+		To:        exec.StartTime,
+		Limit:     1,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get the previous execution: %w", err)
+	}
+	var prev *testkube.Execution
+	var prevCases []database.TestCase
+	if len(previous) > 0 {
+		prev = &previous[0]
+		if prevCases, err = e.db.GetExecutionMetrics(prev.ID); err != nil {
+			return nil, fmt.Errorf("failed to get test cases of %s: %w", prev.ID, err)
+		}
+	}
+
+	// Flaky tests in the window up to the execution, without and with it
+	from := exec.StartTime.Add(-FlakyWindow)
+	before, err := e.flakyTests(exec.WorkflowName, from, exec.StartTime)
+	if err != nil {
+		return nil, err
+	}
+	after, err := e.flakyTests(exec.WorkflowName, from, exec.StartTime.Add(time.Nanosecond))
+	if err != nil {
+		return nil, err
+	}
+
+	notices := Compare(exec, cases, prev, prevCases, e.executionURL(exec.ID))
+	var names []string
+	for name := range after {
+		if _, ok := before[name]; !ok {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		flaky := after[name]
+		notices = append(notices, Notice{
+			Event:    database.WatchFlaky,
+			Workflow: exec.WorkflowName,
+			Test:     name,
+			Message: notify.Message{
+				Title: fmt.Sprintf("Newly flaky: %s: %s", exec.WorkflowName, name),
+				Text: fmt.Sprintf("Failed %d of %d runs in the last %d days",
+					flaky.FailedRuns, flaky.TotalRuns, int(FlakyWindow.Hours()/24)),
+				URL: e.executionURL(exec.ID),
+			},
+		})
+	}
+	return notices, nil
+}
+
+func (e *Engine) flakyTests(workflow string, from, to time.Time) (map[string]database.FlakyTest, error) {
+	tests, err := e.db.GetFlakyTestsBetween(from, to)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get flaky tests: %w", err)
+	}
+	flaky := map[string]database.FlakyTest{}
+	for _, t := range tests {
+		if t.WorkflowName == workflow && t.FlakyScore >= FlakyThreshold {
+			flaky[t.TestName] = t
+		}
+	}
+	return flaky, nil
+}
+
+// Compare finds the failures and recoveries between an execution and the
+// workflow's previous one, nil for its first. A workflow or test only
+// fails when it last passed or hasn't run before, and only recovers when it
+// last failed, so watchers hear of changes rather than every red run.
+func Compare(exec testkube.Execution, cases []database.TestCase, prev *testkube.Execution, prevCases []database.TestCase, url string) []Notice {
+	var notices []Notice
+	prevStatus := ""
+	if prev != nil {
+		prevStatus = prev.Status
+	}
+	switch {
+	case exec.Status == "failed" && prevStatus != "failed":
+		notices = append(notices, Notice{
+			Event:    database.WatchFailure,
+			Workflow: exec.WorkflowName,
+			Message:  notify.Message{Title: "Failed: " + exec.WorkflowName, Text: fmt.Sprintf("Execution %s failed", exec.Name), URL: url},
+		})
+	case exec.Status == "passed" && prevStatus == "failed":
+		notices = append(notices, Notice{
+			Event:    database.WatchRecovery,
+			Workflow: exec.WorkflowName,
+			Message:  notify.Message{Title: "Recovered: " + exec.WorkflowName, Text: fmt.Sprintf("Execution %s passed", exec.Name), URL: url},
+		})
+	}
+
+	before := make(map[string]string, len(prevCases))
+	for _, tc := range prevCases {
+		before[tc.TestName] = tc.Status
+	}
+	for _, tc := range cases {
+		subject := exec.WorkflowName + ": " + tc.TestName
+		switch {
+		case tc.Status == "failed" && before[tc.TestName] != "failed":
+			notices = append(notices, Notice{
+				Event:    database.WatchFailure,
+				Workflow: exec.WorkflowName,
+				Test:     tc.TestName,
+				Message:  notify.Message{Title: "Failed: " + subject, Text: tc.ErrorMessage, URL: url},
+			})
+		case tc.Status == "passed" && before[tc.TestName] == "failed":
+			notices = append(notices, Notice{
+				Event:    database.WatchRecovery,
+				Workflow: exec.WorkflowName,
+				Test:     tc.TestName,
+				Message:  notify.Message{Title: "Recovered: " + subject, Text: fmt.Sprintf("Passed in execution %s", exec.Name), URL: url},
+			})
+		}
+	}
+	return notices
+}
+
+// Match picks each user's messages from the notices. Watching a workflow
+// covers its own failures and recoveries and every test newly flaky in it;
+// watching a test covers just that test. A message reaches a user once
+// however many of their watches match it.
+func Match(watches []database.Watch, notices []Notice) map[string][]notify.Message {
+	messages := map[string][]notify.Message{}
+	sent := map[string]bool{}
+	for _, n := range notices {
+		for _, w := range watches {
+			if w.Workflow != n.Workflow || !w.Subscribed(n.Event) {
+				continue
+			}
+			covered := w.Test == n.Test || (w.Test == "" && n.Event == database.WatchFlaky)
+			key := w.User + "\x00" + n.Message.Title
+			if !covered || sent[key] {
+				continue
+			}
+			sent[key] = true
+			messages[w.User] = append(messages[w.User], n.Message)
+		}
+	}
+	return messages
+}
+
+// deliver sends a user's messages through their channel. Users without a
+// channel keep their watchlist but aren't notified.
+func (e *Engine) deliver(user string, messages []notify.Message) {
+	channel, err := e.db.GetUserChannel(user)
+	if err != nil {
+		log.Printf("Subscriptions: error getting the channel of %s: %v", user, err)
+		return
+	}
+	if channel == nil || channel.WebhookURL == "" {
+		return
+	}
+	notifier := e.notifier(*channel)
+	for _, msg := range messages {
+		ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+		if err := notifier.Send(ctx, msg); err != nil {
+			log.Printf("Subscriptions: error notifying %s of %q: %v", user, msg.Title, err)
+		}
+		cancel()
+	}
+}
+
+func (e *Engine) executionURL(id string) string {
+	if e.baseURL == "" {
+		return ""
+	}
+	return fmt.Sprintf("%s/executions/%s", e.baseURL, id)
+}
@@ -0,0 +1,113 @@
+package subscriptions
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/testkube/dashboard/internal/database"
+	"github.com/testkube/dashboard/internal/notify"
+	"github.com/testkube/dashboard/internal/testkube"
+)
+
+type recordingNotifier struct {
+	user string
+	sent map[string][]string
+}
+
+func (n recordingNotifier) Send(ctx context.Context, msg notify.Message) error {
+	n.sent[n.user] = append(n.sent[n.user], msg.Title)
+	return nil
+}
+
+func TestCompare(t *testing.T) {
+	exec := testkube.Execution{WorkflowName: "web", Name: "web-2", Status: "failed"}
+	cases := []database.TestCase{
+		{TestName: "logs in", Status: "failed", ErrorMessage: "timeout"},
+		{TestName: "checks out", Status: "passed"},
+		{TestName: "searches", Status: "failed"},
+	}
+	prev := &testkube.Execution{WorkflowName: "web", Status: "failed"}
+	prevCases := []database.TestCase{
+		{TestName: "logs in", Status: "passed"},
+		{TestName: "checks out", Status: "failed"},
+		{TestName: "searches", Status: "failed"},
+	}
+
+	var got []string
+	for _, n := range Compare(exec, cases, prev, prevCases, "") {
+		got = append(got, n.Event+" "+n.Test)
+	}
+	// The workflow was already failing and so was "searches"
+	expected := []string{"failure logs in", "recovery checks out"}
+	if fmt.Sprint(got) != fmt.Sprint(expected) {
+		t.Errorf("got %v, expected %v", got, expected)
+	}
+
+	if notices := Compare(exec, nil, nil, nil, ""); len(notices) != 1 || notices[0].Event != database.WatchFailure {
+		t.Errorf("got %+v, expected a first failed run to be a failure", notices)
+	}
+}
+
+func TestEngine(t *testing.T) {
+	db := database.NewMockDatabase()
+	start := time.Now().Add(-time.Hour)
+	run := func(i int, status string, tests map[string]string) (testkube.Execution, []database.TestCase) {
+		exec := testkube.Execution{ID: fmt.Sprintf("web-%d", i), Name: fmt.Sprintf("web-%d", i), WorkflowName: "web", Status: status, StartTime: start.Add(time.Duration(i) * time.Minute)}
+		db.InsertExecution(exec)
+		var cases []database.TestCase
+		for name, status := range tests {
+			tc := database.TestCase{ExecutionID: exec.ID, TestName: name, Status: status}
+			db.InsertTestCase(tc)
+			cases = append(cases, tc)
+		}
+		return exec, cases
+	}
+	for i := 0; i < 9; i++ {
+		run(i, "passed", map[string]string{"logs in": "passed", "checks out": "passed"})
+	}
+
+	db.SaveWatch(database.Watch{User: "alice", Workflow: "web", Events: database.WatchEvents})
+	db.SaveWatch(database.Watch{User: "bob", Workflow: "web", Test: "checks out", Events: []string{database.WatchFailure}})
+	db.SaveWatch(database.Watch{User: "carol", Workflow: "web", Test: "logs in", Events: []string{database.WatchFailure}})
+	db.SaveWatch(database.Watch{User: "dave", Workflow: "web"})
+	for _, user := range []string{"alice", "bob", "carol", "dave"} {
+		db.SaveUserChannel(database.UserChannel{User: user, WebhookURL: "https://hooks.example.com/" + user})
+	}
+
+	sent := map[string][]string{}
+	engine := NewEngine(db)
+	engine.notifier = func(channel database.UserChannel) notify.Notifier {
+		return recordingNotifier{user: channel.User, sent: sent}
+	}
+
+	exec, cases := run(9, "failed", map[string]string{"logs in": "passed", "checks out": "failed"})
+	engine.ExecutionIngested(exec, cases)
+	for _, titles := range sent {
+		sort.Strings(titles)
+	}
+	expected := map[string][]string{
+		"alice": {"Failed: web", "Newly flaky: web: checks out"},
+		"bob":   {"Failed: web: checks out"},
+	}
+	if fmt.Sprint(sent) != fmt.Sprint(expected) {
+		t.Errorf("got %v, expected %v", sent, expected)
+	}
+
+	// Still failing isn't news
+	clear(sent)
+	exec, cases = run(10, "failed", map[string]string{"logs in": "passed", "checks out": "failed"})
+	engine.ExecutionIngested(exec, cases)
+	if len(sent) != 0 {
+		t.Errorf("got %v, expected no notices", sent)
+	}
+
+	clear(sent)
+	exec, cases = run(11, "passed", map[string]string{"logs in": "passed", "checks out": "passed"})
+	engine.ExecutionIngested(exec, cases)
+	if expected := map[string][]string{"alice": {"Recovered: web"}}; fmt.Sprint(sent) != fmt.Sprint(expected) {
+		t.Errorf("got %v, expected %v", sent, expected)
+	}
+}
@@ -0,0 +1,279 @@
+// Package suites groups the workflows that gate a deployment and decides
+// whether a suite's recent runs allow a promotion.
+package suites
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/testkube/dashboard/internal/testkube"
+)
+
+// SLO is what a suite's workflows must meet over the verdict window
+type SLO struct {
+	// MinPassRate is the lowest pass rate allowed, in percent
+	MinPassRate float64 `json:"minPassRate,omitempty"`
+	// MaxDuration is the longest p95 run duration allowed, e.g. "15m"
+	MaxDuration string `json:"maxDuration,omitempty"`
+
+	maxDuration time.Duration
+}
+
+// Suite is a named set of workflows
+type Suite struct {
+	Name      string   `json:"name"`
+	Workflows []string `json:"workflows"` // names or globs, e.g. "checkout-*"
+	SLO       SLO      `json:"slo,omitzero"`
+}
+
+// Includes reports whether a workflow belongs to the suite
+func (s Suite) Includes(workflow string) bool {
+	for _, pattern := range s.Workflows {
+		if ok, _ := path.Match(pattern, workflow); ok {
+			return true
+		}
+	}
+	return false
+}
+
+type config struct {
+	Suites []Suite `json:"suites"`
+}
+
+// Registry holds the configured suites
+type Registry struct {
+	config config
+	mu     sync.RWMutex
+}
+
+// NewRegistry creates a registry, loading suites from the JSON file in
+// SUITES_FILE when set
+func NewRegistry() (*Registry, error) {
+	r := &Registry{}
+
+	if file := os.Getenv("SUITES_FILE"); file != "" {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return r, fmt.Errorf("failed to read suites file: %w", err)
+		}
+		if err := r.Import(data); err != nil {
+			return r, err
+		}
+	}
+
+	return r, nil
+}
+
+// Get returns the suite with the given name
+func (r *Registry) Get(name string) (Suite, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, s := range r.config.Suites {
+		if s.Name == name {
+			return s, true
+		}
+	}
+	return Suite{}, false
+}
+
+// List returns the suites sorted by name
+func (r *Registry) List() []Suite {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	list := append([]Suite(nil), r.config.Suites...)
+	sort.Slice(list, func(i, j int) bool { return list[i].Name < list[j].Name })
+	return list
+}
+
+// Export returns the suites as JSON, for configuration sync
+func (r *Registry) Export() (json.RawMessage, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return json.Marshal(r.config)
+}
+
+// Import replaces the suites
+func (r *Registry) Import(data json.RawMessage) error {
+	var cfg config
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return fmt.Errorf("failed to parse suites: %w", err)
+	}
+
+	seen := make(map[string]bool)
+	for i, s := range cfg.Suites {
+		if s.Name == "" {
+			return fmt.Errorf("suite %d has no name", i+1)
+		}
+		if seen[s.Name] {
+			return fmt.Errorf("duplicate suite %q", s.Name)
+		}
+		seen[s.Name] = true
+		if len(s.Workflows) == 0 {
+			return fmt.Errorf("suite %s has no workflows", s.Name)
+		}
+		for _, pattern := range s.Workflows {
+			if _, err := path.Match(pattern, ""); err != nil {
+				return fmt.Errorf("invalid workflow pattern %q in suite %s: %w", pattern, s.Name, err)
+			}
+		}
+		if s.SLO.MinPassRate < 0 || s.SLO.MinPassRate > 100 {
+			return fmt.Errorf("minPassRate of suite %s must be between 0 and 100", s.Name)
+		}
+		if s.SLO.MaxDuration != "" {
+			d, err := time.ParseDuration(s.SLO.MaxDuration)
+			if err != nil || d <= 0 {
+				return fmt.Errorf("invalid maxDuration %q in suite %s", s.SLO.MaxDuration, s.Name)
+			}
+			cfg.Suites[i].SLO.maxDuration = d
+		}
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.config = cfg
+	return nil
+}
+
+// Reasons a suite fails its verdict
+const (
+	ReasonFailed   = "workflow-failed"  // a workflow's latest run failed
+	ReasonNoRuns   = "no-runs"          // a workflow didn't finish a run in the window
+	ReasonSLO      = "slo-breach"       // a workflow missed the suite's SLO
+	ReasonSecurity = "security-finding" // a workflow's latest scan has critical findings
+)
+
+// Reason explains why a suite can't be promoted
+type Reason struct {
+	Kind        string `json:"kind"`
+	Workflow    string `json:"workflow"`
+	ExecutionID string `json:"executionId,omitempty"`
+	Message     string `json:"message"`
+}
+
+// Runs are a workflow's finished executions in the verdict window and the
+// critical findings of its latest one, for security scanners
+type Runs struct {
+	Workflow         string
+	Executions       []testkube.Execution
+	CriticalFindings int
+}
+
+// WorkflowResult summarizes one workflow's runs in the window
+type WorkflowResult struct {
+	Workflow         string  `json:"workflow"`
+	Runs             int     `json:"runs"`
+	Passed           int     `json:"passed"`
+	Failed           int     `json:"failed"`
+	PassRate         float64 `json:"passRate"` // percent
+	P95DurationMs    int64   `json:"p95DurationMs"`
+	LatestStatus     string  `json:"latestStatus,omitempty"`
+	LatestExecution  string  `json:"latestExecutionId,omitempty"`
+	CriticalFindings int     `json:"criticalFindings"`
+}
+
+// Verdict is the aggregate outcome of a suite over a window
+type Verdict struct {
+	Suite     string           `json:"suite"`
+	Window    string           `json:"window"`
+	From      time.Time        `json:"from"`
+	To        time.Time        `json:"to"`
+	Passed    bool             `json:"passed"`
+	Verdict   string           `json:"verdict"` // pass or fail
+	Reasons   []Reason         `json:"reasons"`
+	Workflows []WorkflowResult `json:"workflows"`
+}
+
+// Evaluate decides the suite's verdict from its workflows' runs in the window
+// ending at now. It passes only when every workflow finished a run, its
+// latest run passed without critical findings, and it met the SLO.
+func (s Suite) Evaluate(runs []Runs, window time.Duration, now time.Time) Verdict {
+	v := Verdict{
+		Suite:     s.Name,
+		Window:    window.String(),
+		From:      now.Add(-window),
+		To:        now,
+		Reasons:   []Reason{},
+		Workflows: []WorkflowResult{},
+	}
+	fail := func(kind, workflow, executionID, format string, args ...interface{}) {
+		v.Reasons = append(v.Reasons, Reason{Kind: kind, Workflow: workflow, ExecutionID: executionID, Message: fmt.Sprintf(format, args...)})
+	}
+
+	if len(runs) == 0 {
+		fail(ReasonNoRuns, "", "", "no workflows match suite %s", s.Name)
+	}
+	for _, wr := range runs {
+		var execs []testkube.Execution
+		for _, e := range wr.Executions {
+			if testkube.CountsTowardsPassRate(e.RunOutcome()) && !e.StartTime.Before(v.From) && !e.StartTime.After(now) {
+				execs = append(execs, e)
+			}
+		}
+		sort.Slice(execs, func(i, j int) bool { return execs[i].StartTime.After(execs[j].StartTime) })
+
+		result := WorkflowResult{Workflow: wr.Workflow, Runs: len(execs), CriticalFindings: wr.CriticalFindings}
+		if len(execs) == 0 {
+			v.Workflows = append(v.Workflows, result)
+			fail(ReasonNoRuns, wr.Workflow, "", "%s has no finished runs in the last %s", wr.Workflow, window)
+			continue
+		}
+
+		durations := make([]time.Duration, len(execs))
+		for i, e := range execs {
+			if e.RunOutcome() == testkube.OutcomePassed {
+				result.Passed++
+			} else {
+				result.Failed++
+			}
+			durations[i] = e.Duration
+		}
+		result.PassRate = float64(result.Passed) * 100 / float64(result.Runs)
+		p95 := percentile(durations, 95)
+		result.P95DurationMs = p95.Milliseconds()
+		latest := execs[0]
+		result.LatestStatus = latest.Status
+		result.LatestExecution = latest.ID
+		v.Workflows = append(v.Workflows, result)
+
+		if latest.RunOutcome() == testkube.OutcomeFailed {
+			fail(ReasonFailed, wr.Workflow, latest.ID, "latest run of %s failed", wr.Workflow)
+		}
+		if wr.CriticalFindings > 0 {
+			fail(ReasonSecurity, wr.Workflow, latest.ID, "%s reports %d open critical findings", wr.Workflow, wr.CriticalFindings)
+		}
+		if s.SLO.MinPassRate > 0 && result.PassRate < s.SLO.MinPassRate {
+			fail(ReasonSLO, wr.Workflow, "", "pass rate of %s is %.1f%%, below the %.1f%% SLO", wr.Workflow, result.PassRate, s.SLO.MinPassRate)
+		}
+		if s.SLO.maxDuration > 0 && p95 > s.SLO.maxDuration {
+			fail(ReasonSLO, wr.Workflow, "", "p95 duration of %s is %s, above the %s SLO", wr.Workflow, p95.Round(time.Second), s.SLO.maxDuration)
+		}
+	}
+
+	v.Passed = len(v.Reasons) == 0
+	v.Verdict = "fail"
+	if v.Passed {
+		v.Verdict = "pass"
+	}
+	return v
+}
+
+// percentile returns the nearest-rank percentile of durations
+func percentile(durations []time.Duration, p int) time.Duration {
+	if len(durations) == 0 {
+		return 0
+	}
+	sorted := append([]time.Duration(nil), durations...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	rank := (p*len(sorted) + 99) / 100
+	if rank < 1 {
+		rank = 1
+	}
+	return sorted[rank-1]
+}
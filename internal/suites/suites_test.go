@@ -0,0 +1,112 @@
+package suites
+
+import (
+	"testing"
+	"time"
+
+	"github.com/testkube/dashboard/internal/testkube"
+)
+
+func newTestRegistry(t *testing.T, cfg string) *Registry {
+	t.Helper()
+	r, err := NewRegistry()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := r.Import([]byte(cfg)); err != nil {
+		t.Fatal(err)
+	}
+	return r
+}
+
+func TestImportValidates(t *testing.T) {
+	r := &Registry{}
+	for _, cfg := range []string{
+		`{"suites": [{"workflows": ["api-*"]}]}`,
+		`{"suites": [{"name": "api"}]}`,
+		`{"suites": [{"name": "api", "workflows": ["["]}]}`,
+		`{"suites": [{"name": "api", "workflows": ["api-*"], "slo": {"minPassRate": 120}}]}`,
+		`{"suites": [{"name": "api", "workflows": ["api-*"], "slo": {"maxDuration": "soon"}}]}`,
+		`{"suites": [{"name": "api", "workflows": ["a"]}, {"name": "api", "workflows": ["b"]}]}`,
+	} {
+		if err := r.Import([]byte(cfg)); err == nil {
+			t.Errorf("expected %s to be rejected", cfg)
+		}
+	}
+}
+
+func TestEvaluate(t *testing.T) {
+	r := newTestRegistry(t, `{"suites": [{
+		"name": "checkout",
+		"workflows": ["checkout-*", "cluster-security"],
+		"slo": {"minPassRate": 75, "maxDuration": "5m"}
+	}]}`)
+	suite, ok := r.Get("checkout")
+	if !ok {
+		t.Fatal("expected suite checkout")
+	}
+	if !suite.Includes("checkout-api") || suite.Includes("frontend-e2e") {
+		t.Error("expected only checkout workflows and cluster-security in the suite")
+	}
+
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	run := func(id, status string, ago, duration time.Duration) testkube.Execution {
+		return testkube.Execution{ID: id, Status: status, StartTime: now.Add(-ago), Duration: duration}
+	}
+	green := []Runs{
+		{Workflow: "checkout-api", Executions: []testkube.Execution{
+			// A cluster hiccup neither fails the suite nor counts in its pass rate
+			{ID: "a4", Status: "failed", Outcome: testkube.OutcomeInfraError, StartTime: now.Add(-5 * time.Minute)},
+			run("a3", "passed", 10*time.Minute, time.Minute),
+			run("a2", "failed", 20*time.Minute, time.Minute),
+			run("a1", "passed", 30*time.Minute, time.Minute),
+			run("a0", "passed", 40*time.Minute, time.Minute),
+			// Outside the window
+			run("old", "failed", 2*time.Hour, time.Minute),
+		}},
+		{Workflow: "cluster-security", Executions: []testkube.Execution{
+			run("s1", "passed", 15*time.Minute, 2*time.Minute),
+			run("s2", "running", time.Minute, 0),
+		}},
+	}
+	v := suite.Evaluate(green, time.Hour, now)
+	if !v.Passed || v.Verdict != "pass" || len(v.Reasons) != 0 {
+		t.Fatalf("got %+v, expected a pass", v)
+	}
+	if v.Workflows[0].Runs != 4 || v.Workflows[0].PassRate != 75 {
+		t.Errorf("got %+v, expected 4 runs at 75%%", v.Workflows[0])
+	}
+
+	red := []Runs{
+		{Workflow: "checkout-api", Executions: []testkube.Execution{
+			run("a2", "failed", 10*time.Minute, 10*time.Minute),
+			run("a1", "passed", 20*time.Minute, time.Minute),
+		}},
+		{Workflow: "checkout-web"},
+		{Workflow: "cluster-security", CriticalFindings: 3, Executions: []testkube.Execution{
+			run("s1", "passed", 15*time.Minute, 2*time.Minute),
+		}},
+	}
+	v = suite.Evaluate(red, time.Hour, now)
+	if v.Passed || v.Verdict != "fail" {
+		t.Fatalf("got %+v, expected a fail", v)
+	}
+	kinds := make(map[string]int)
+	for _, reason := range v.Reasons {
+		kinds[reason.Kind]++
+	}
+	// checkout-api fails, misses the pass rate and the duration SLO
+	expected := map[string]int{ReasonFailed: 1, ReasonSLO: 2, ReasonNoRuns: 1, ReasonSecurity: 1}
+	for kind, count := range expected {
+		if kinds[kind] != count {
+			t.Errorf("got %d %s reasons, expected %d: %+v", kinds[kind], kind, count, v.Reasons)
+		}
+	}
+	if v.Reasons[0].ExecutionID != "a2" {
+		t.Errorf("got execution %q for the failed run, expected a2", v.Reasons[0].ExecutionID)
+	}
+
+	if v := suite.Evaluate(nil, time.Hour, now); v.Passed {
+		t.Error("a suite without workflows should not pass")
+	}
+}
@@ -0,0 +1,416 @@
+package synthetics
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/testkube/dashboard/internal/database"
+	"github.com/testkube/dashboard/internal/notify"
+)
+
+const (
+	DefaultInterval = time.Minute
+	DefaultTimeout  = 10 * time.Second
+	// MinInterval keeps checks from hammering their targets
+	MinInterval = 10 * time.Second
+
+	// tickInterval is how often the monitor looks for due checks
+	tickInterval = 5 * time.Second
+	// maxBodyBytes bounds how much of a response is read to match ExpectedBody
+	maxBodyBytes = 1 << 20
+)
+
+// Check is an HTTP probe the dashboard runs on a fixed interval
+type Check struct {
+	ID              string `json:"id"`
+	Name            string `json:"name"`
+	URL             string `json:"url"`
+	Method          string `json:"method,omitempty"` // defaults to GET
+	IntervalSeconds int    `json:"intervalSeconds,omitempty"`
+	TimeoutSeconds  int    `json:"timeoutSeconds,omitempty"`
+	ExpectedStatus  int    `json:"expectedStatus,omitempty"` // defaults to 200
+	ExpectedBody    string `json:"expectedBody,omitempty"`   // substring the body must contain
+}
+
+func (c Check) Interval() time.Duration {
+	if c.IntervalSeconds <= 0 {
+		return DefaultInterval
+	}
+	return max(time.Duration(c.IntervalSeconds)*time.Second, MinInterval)
+}
+
+func (c Check) Timeout() time.Duration {
+	if c.TimeoutSeconds <= 0 {
+		return DefaultTimeout
+	}
+	return time.Duration(c.TimeoutSeconds) * time.Second
+}
+
+func (c Check) normalize() (Check, error) {
+	c.Name = strings.TrimSpace(c.Name)
+	c.URL = strings.TrimSpace(c.URL)
+	c.Method = strings.ToUpper(strings.TrimSpace(c.Method))
+	if c.Method == "" {
+		c.Method = http.MethodGet
+	}
+	if c.ExpectedStatus == 0 {
+		c.ExpectedStatus = http.StatusOK
+	}
+
+	u, err := url.Parse(c.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return c, fmt.Errorf("check URL must be an absolute http(s) URL: %q", c.URL)
+	}
+	if c.Name == "" {
+		c.Name = u.Host
+	}
+	return c, nil
+}
+
+// Status is a check with its latest result and recent uptime
+type Status struct {
+	Check
+	Last    *database.CheckResult `json:"last,omitempty"`
+	Failing bool                  `json:"failing"`
+	// Uptime is the share of successful runs in the last 24 hours, or -1
+	// when the check hasn't run yet
+	Uptime float64 `json:"uptime"`
+}
+
+type checkState struct {
+	check   Check
+	nextRun time.Time
+	last    *database.CheckResult
+	failing bool
+	running bool
+}
+
+// Monitor runs synthetic checks, stores their results with the test data
+// and notifies when a check starts failing or recovers.
+type Monitor struct {
+	db         database.Database
+	notifier   notify.Notifier
+	httpClient *http.Client
+	baseURL    string
+
+	checks map[string]*checkState
+	mu     sync.Mutex
+	now    func() time.Time
+}
+
+// NewMonitor creates a monitor, loading checks from the JSON file in
+// SYNTHETICS_FILE when set, and starts running them. notifier may be nil.
+func NewMonitor(db database.Database, notifier notify.Notifier) (*Monitor, error) {
+	m := newMonitor(db, notifier)
+	go m.runLoop()
+
+	if file := os.Getenv("SYNTHETICS_FILE"); file != "" {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return m, fmt.Errorf("failed to read synthetics file: %w", err)
+		}
+		if err := m.Import(data); err != nil {
+			return m, err
+		}
+	}
+
+	return m, nil
+}
+
+func newMonitor(db database.Database, notifier notify.Notifier) *Monitor {
+	return &Monitor{
+		db:       db,
+		notifier: notifier,
+		// Redirects are followed, so ExpectedStatus applies to the final response
+		httpClient: &http.Client{},
+		baseURL:    strings.TrimSuffix(os.Getenv("DASHBOARD_URL"), "/"),
+		checks:     make(map[string]*checkState),
+		now:        time.Now,
+	}
+}
+
+func generateID() string {
+	bytes := make([]byte, 4)
+	rand.Read(bytes)
+	return hex.EncodeToString(bytes)
+}
+
+// Add validates and schedules a check, returning it with defaults applied
+func (m *Monitor) Add(c Check) (Check, error) {
+	c, err := c.normalize()
+	if err != nil {
+		return c, err
+	}
+	if c.ID == "" {
+		c.ID = generateID()
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if state, ok := m.checks[c.ID]; ok {
+		state.check = c
+		return c, nil
+	}
+	m.checks[c.ID] = &checkState{check: c, nextRun: m.now()}
+	return c, nil
+}
+
+// Remove stops running a check. Its stored results are kept.
+func (m *Monitor) Remove(id string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, ok := m.checks[id]; !ok {
+		return fmt.Errorf("check not found: %s", id)
+	}
+	delete(m.checks, id)
+	return nil
+}
+
+// Checks returns every check with its current status, failing checks first
+func (m *Monitor) Checks() []Status {
+	m.mu.Lock()
+	statuses := make([]Status, 0, len(m.checks))
+	for _, state := range m.checks {
+		statuses = append(statuses, Status{Check: state.check, Last: state.last, Failing: state.failing, Uptime: -1})
+	}
+	m.mu.Unlock()
+
+	since := m.now().Add(-24 * time.Hour)
+	for i := range statuses {
+		results, err := m.db.GetCheckResults(statuses[i].ID, since)
+		if err != nil {
+			log.Printf("Error getting results for check %s: %v", statuses[i].Name, err)
+			continue
+		}
+		statuses[i].Uptime = uptime(results)
+	}
+
+	sort.Slice(statuses, func(i, j int) bool {
+		if statuses[i].Failing != statuses[j].Failing {
+			return statuses[i].Failing
+		}
+		return statuses[i].Name < statuses[j].Name
+	})
+	return statuses
+}
+
+func uptime(results []database.CheckResult) float64 {
+	if len(results) == 0 {
+		return -1
+	}
+	ok := 0
+	for _, r := range results {
+		if r.OK {
+			ok++
+		}
+	}
+	return float64(ok) / float64(len(results)) * 100
+}
+
+// Results returns a check's stored results since a time, oldest first
+func (m *Monitor) Results(id string, since time.Time) ([]database.CheckResult, error) {
+	return m.db.GetCheckResults(id, since)
+}
+
+func (m *Monitor) runLoop() {
+	ticker := time.NewTicker(tickInterval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		m.RunDue(context.Background())
+	}
+}
+
+// RunDue runs every check whose interval has elapsed and waits for them
+func (m *Monitor) RunDue(ctx context.Context) {
+	now := m.now()
+
+	m.mu.Lock()
+	var due []Check
+	for _, state := range m.checks {
+		if !state.running && !now.Before(state.nextRun) {
+			state.running = true
+			state.nextRun = now.Add(state.check.Interval())
+			due = append(due, state.check)
+		}
+	}
+	m.mu.Unlock()
+
+	var wg sync.WaitGroup
+	for _, c := range due {
+		wg.Add(1)
+		go func(c Check) {
+			defer wg.Done()
+			m.record(ctx, c, m.probe(ctx, c))
+
+			m.mu.Lock()
+			if state, ok := m.checks[c.ID]; ok {
+				state.running = false
+			}
+			m.mu.Unlock()
+		}(c)
+	}
+	wg.Wait()
+}
+
+// Run runs a check immediately, outside its schedule
+func (m *Monitor) Run(ctx context.Context, id string) (database.CheckResult, error) {
+	m.mu.Lock()
+	state, ok := m.checks[id]
+	if !ok {
+		m.mu.Unlock()
+		return database.CheckResult{}, fmt.Errorf("check not found: %s", id)
+	}
+	c := state.check
+	m.mu.Unlock()
+
+	result := m.probe(ctx, c)
+	m.record(ctx, c, result)
+	return result, nil
+}
+
+func (m *Monitor) probe(ctx context.Context, c Check) database.CheckResult {
+	result := database.CheckResult{CheckID: c.ID, Time: m.now()}
+
+	ctx, cancel := context.WithTimeout(ctx, c.Timeout())
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, c.Method, c.URL, nil)
+	if err != nil {
+		result.Error = fmt.Sprintf("invalid request: %v", err)
+		return result
+	}
+	req.Header.Set("User-Agent", "testkube-dashboard-synthetics")
+
+	start := time.Now()
+	resp, err := m.httpClient.Do(req)
+	if err != nil {
+		result.LatencyMs = int(time.Since(start).Milliseconds())
+		result.Error = err.Error()
+		return result
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxBodyBytes))
+	result.LatencyMs = int(time.Since(start).Milliseconds())
+	result.StatusCode = resp.StatusCode
+
+	switch {
+	case resp.StatusCode != c.ExpectedStatus:
+		result.Error = fmt.Sprintf("expected status %d, got %d", c.ExpectedStatus, resp.StatusCode)
+	case err != nil:
+		result.Error = fmt.Sprintf("failed to read body: %v", err)
+	case c.ExpectedBody != "" && !strings.Contains(string(body), c.ExpectedBody):
+		result.Error = fmt.Sprintf("body does not contain %q", c.ExpectedBody)
+	default:
+		result.OK = true
+	}
+	return result
+}
+
+// record stores a result and notifies when the check changes between
+// passing and failing
+func (m *Monitor) record(ctx context.Context, c Check, result database.CheckResult) {
+	if err := m.db.InsertCheckResult(result); err != nil {
+		log.Printf("Error storing result for check %s: %v", c.Name, err)
+	}
+
+	m.mu.Lock()
+	state, ok := m.checks[c.ID]
+	if !ok {
+		// Removed while running
+		m.mu.Unlock()
+		return
+	}
+	state.last = &result
+	changed := state.failing == result.OK
+	state.failing = !result.OK
+	m.mu.Unlock()
+
+	if !changed || m.notifier == nil {
+		return
+	}
+
+	msg := notify.Message{
+		Title: "Synthetic check recovered: " + c.Name,
+		Text:  fmt.Sprintf("%s %s is responding again (%dms)", c.Method, c.URL, result.LatencyMs),
+	}
+	if !result.OK {
+		msg.Title = "Synthetic check failing: " + c.Name
+		msg.Text = fmt.Sprintf("%s %s: %s", c.Method, c.URL, result.Error)
+	}
+	if m.baseURL != "" {
+		msg.URL = m.baseURL + "/synthetics"
+	}
+	if err := m.notifier.Send(ctx, msg); err != nil {
+		log.Printf("Error sending synthetic check notification: %v", err)
+	}
+}
+
+type syntheticsConfig struct {
+	Checks []Check `json:"checks"`
+}
+
+// Export returns the checks as JSON, for configuration sync
+func (m *Monitor) Export() (json.RawMessage, error) {
+	m.mu.Lock()
+	config := syntheticsConfig{Checks: make([]Check, 0, len(m.checks))}
+	for _, state := range m.checks {
+		config.Checks = append(config.Checks, state.check)
+	}
+	m.mu.Unlock()
+
+	sort.Slice(config.Checks, func(i, j int) bool {
+		return config.Checks[i].ID < config.Checks[j].ID
+	})
+	return json.Marshal(config)
+}
+
+// Import replaces all checks with the ones in data. Results and failing
+// state are kept for checks whose ID is unchanged.
+func (m *Monitor) Import(data json.RawMessage) error {
+	var config syntheticsConfig
+	if err := json.Unmarshal(data, &config); err != nil {
+		return fmt.Errorf("failed to parse synthetic checks: %w", err)
+	}
+
+	checks := make(map[string]Check)
+	for _, c := range config.Checks {
+		if c.ID == "" {
+			return fmt.Errorf("synthetic check %q has no id", c.Name)
+		}
+		c, err := c.normalize()
+		if err != nil {
+			return err
+		}
+		checks[c.ID] = c
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for id := range m.checks {
+		if _, ok := checks[id]; !ok {
+			delete(m.checks, id)
+		}
+	}
+	for id, c := range checks {
+		if state, ok := m.checks[id]; ok {
+			state.check = c
+		} else {
+			m.checks[id] = &checkState{check: c, nextRun: m.now()}
+		}
+	}
+	return nil
+}
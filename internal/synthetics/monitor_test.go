@@ -0,0 +1,140 @@
+package synthetics
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/testkube/dashboard/internal/database"
+	"github.com/testkube/dashboard/internal/notify"
+)
+
+type recordingNotifier struct {
+	messages []notify.Message
+	mu       sync.Mutex
+}
+
+func (n *recordingNotifier) Send(ctx context.Context, msg notify.Message) error {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.messages = append(n.messages, msg)
+	return nil
+}
+
+func TestMonitorAlertsOnTransitions(t *testing.T) {
+	healthy := true
+	var mu sync.Mutex
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		if !healthy {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Write([]byte(`{"status": "ok"}`))
+	}))
+	defer ts.Close()
+
+	db := database.NewMockDatabase()
+	notifier := &recordingNotifier{}
+	m := newMonitor(db, notifier)
+	now := time.Date(2026, 4, 1, 9, 0, 0, 0, time.UTC)
+	m.now = func() time.Time { return now }
+
+	check, err := m.Add(Check{Name: "staging api", URL: ts.URL, ExpectedBody: `"ok"`, IntervalSeconds: 60})
+	if err != nil {
+		t.Fatalf("Add failed: %v", err)
+	}
+
+	setHealthy := func(h bool) {
+		mu.Lock()
+		healthy = h
+		mu.Unlock()
+	}
+	step := func() {
+		m.RunDue(context.Background())
+		now = now.Add(time.Minute)
+	}
+
+	step()
+	setHealthy(false)
+	step()
+	step()
+	setHealthy(true)
+	step()
+
+	if len(notifier.messages) != 2 {
+		t.Fatalf("expected a failing and a recovered notification, got %+v", notifier.messages)
+	}
+	if !strings.HasPrefix(notifier.messages[0].Title, "Synthetic check failing") || !strings.Contains(notifier.messages[0].Text, "expected status 200, got 503") {
+		t.Errorf("unexpected failure message: %+v", notifier.messages[0])
+	}
+	if !strings.HasPrefix(notifier.messages[1].Title, "Synthetic check recovered") {
+		t.Errorf("unexpected recovery message: %+v", notifier.messages[1])
+	}
+
+	results, _ := db.GetCheckResults(check.ID, time.Time{})
+	if len(results) != 4 {
+		t.Fatalf("expected 4 stored results, got %d", len(results))
+	}
+
+	statuses := m.Checks()
+	if len(statuses) != 1 || statuses[0].Failing || statuses[0].Uptime != 50 {
+		t.Errorf("expected a passing check with 50%% uptime, got %+v", statuses)
+	}
+}
+
+func TestMonitorSkipsChecksThatAreNotDue(t *testing.T) {
+	calls := 0
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+	}))
+	defer ts.Close()
+
+	m := newMonitor(database.NewMockDatabase(), nil)
+	now := time.Date(2026, 4, 1, 9, 0, 0, 0, time.UTC)
+	m.now = func() time.Time { return now }
+	m.Add(Check{URL: ts.URL, IntervalSeconds: 300})
+
+	m.RunDue(context.Background())
+	now = now.Add(time.Minute)
+	m.RunDue(context.Background())
+	if calls != 1 {
+		t.Errorf("expected 1 probe within the interval, got %d", calls)
+	}
+
+	now = now.Add(5 * time.Minute)
+	m.RunDue(context.Background())
+	if calls != 2 {
+		t.Errorf("expected a second probe once due, got %d", calls)
+	}
+}
+
+func TestMonitorImportExport(t *testing.T) {
+	m := newMonitor(database.NewMockDatabase(), nil)
+	if _, err := m.Add(Check{URL: "ftp://example.com"}); err == nil {
+		t.Error("expected non-http URL to be rejected")
+	}
+
+	err := m.Import([]byte(`{"checks": [{"id": "web", "url": "https://example.com/health"}]}`))
+	if err != nil {
+		t.Fatalf("Import failed: %v", err)
+	}
+
+	data, err := m.Export()
+	if err != nil {
+		t.Fatalf("Export failed: %v", err)
+	}
+	want := `{"checks":[{"id":"web","name":"example.com","url":"https://example.com/health","method":"GET","expectedStatus":200}]}`
+	if string(data) != want {
+		t.Errorf("got %s, want %s", data, want)
+	}
+
+	if err := m.Import([]byte(`{"checks": [{"url": "https://example.com"}]}`)); err == nil {
+		t.Error("expected checks without an id to be rejected")
+	}
+}
@@ -0,0 +1,118 @@
+package tables
+
+import (
+	"maps"
+	"net/url"
+	"strings"
+	"sync"
+)
+
+// filterPrefix prefixes filter query parameters, e.g. "f_status=failed"
+const filterPrefix = "f_"
+
+// State is a user's sort, filter and column choices for one table
+type State struct {
+	Sort    string            `json:"sort,omitempty"`
+	Desc    bool              `json:"desc,omitempty"`
+	Filters map[string]string `json:"filters,omitempty"`
+	Columns []string          `json:"columns,omitempty"`
+}
+
+// Merge applies the table parameters present in q on top of s. Only the
+// parameters a control sends are changed, so sort links, filter inputs and
+// the column chooser can each update their part independently. It reports
+// whether q contained any table parameters.
+//
+//	sort=<key>&dir=asc|desc   sort order
+//	f_<key>=<text>            filter, removed when empty
+//	cols=<key>&cols=<key>     visible columns; an empty value marks the
+//	                          chooser as submitted so unchecking works
+func (s State) Merge(q url.Values) (State, bool) {
+	out := State{
+		Sort:    s.Sort,
+		Desc:    s.Desc,
+		Filters: maps.Clone(s.Filters),
+		Columns: s.Columns,
+	}
+	changed := false
+
+	if sort := q.Get("sort"); sort != "" {
+		out.Sort = sort
+		out.Desc = q.Get("dir") == "desc"
+		changed = true
+	}
+
+	for key, values := range q {
+		name, ok := strings.CutPrefix(key, filterPrefix)
+		if !ok || name == "" {
+			continue
+		}
+		changed = true
+		value := strings.TrimSpace(values[0])
+		if value == "" {
+			delete(out.Filters, name)
+			continue
+		}
+		if out.Filters == nil {
+			out.Filters = make(map[string]string)
+		}
+		out.Filters[name] = value
+	}
+
+	if cols, ok := q["cols"]; ok {
+		changed = true
+		out.Columns = nil
+		for _, col := range cols {
+			for _, key := range strings.Split(col, ",") {
+				if key = strings.TrimSpace(key); key != "" {
+					out.Columns = append(out.Columns, key)
+				}
+			}
+		}
+	}
+
+	return out, changed
+}
+
+// Store keeps table state per user in memory
+type Store struct {
+	states map[string]State
+	mu     sync.RWMutex
+}
+
+func NewStore() *Store {
+	return &Store{states: make(map[string]State)}
+}
+
+func storeKey(user, table string) string {
+	return user + "\x00" + table
+}
+
+// Get returns the user's saved state for table
+func (s *Store) Get(user, table string) (State, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	state, ok := s.states[storeKey(user, table)]
+	return state, ok
+}
+
+// Save replaces the user's state for table
+func (s *Store) Save(user, table string, state State) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.states[storeKey(user, table)] = state
+}
+
+// Reset forgets the user's state for table so defaults apply again
+func (s *Store) Reset(user, table string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.states, storeKey(user, table))
+}
+
+// Len returns the number of saved table states
+func (s *Store) Len() int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return len(s.states)
+}
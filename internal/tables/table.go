@@ -0,0 +1,294 @@
+package tables
+
+import (
+	"cmp"
+	"encoding/csv"
+	"html/template"
+	"io"
+	"net/url"
+	"slices"
+	"strings"
+)
+
+// Column describes one column of a table of T
+type Column[T any] struct {
+	Key   string
+	Label string
+	// Value is the cell's plain-text value, used for filtering, sorting and
+	// CSV export. Display-only columns (e.g. actions) leave it nil.
+	Value func(T) string
+	// Compare orders rows when sorting; defaults to comparing Value
+	Compare func(a, b T) int
+	// HTML renders the cell; defaults to the escaped Value
+	HTML func(T) template.HTML
+	// Filterable columns get a filter input
+	Filterable bool
+	// Optional columns are hidden until chosen
+	Optional bool
+}
+
+func (c Column[T]) sortable() bool {
+	return c.Value != nil || c.Compare != nil
+}
+
+func (c Column[T]) compare(a, b T) int {
+	if c.Compare != nil {
+		return c.Compare(a, b)
+	}
+	return strings.Compare(strings.ToLower(c.Value(a)), strings.ToLower(c.Value(b)))
+}
+
+func (c Column[T]) html(row T) template.HTML {
+	if c.HTML != nil {
+		return c.HTML(row)
+	}
+	if c.Value != nil {
+		return template.HTML(template.HTMLEscapeString(c.Value(row)))
+	}
+	return ""
+}
+
+// Table defines the columns of a server-side table and how its rows are
+// sorted and filtered
+type Table[T any] struct {
+	Name        string
+	Columns     []Column[T]
+	DefaultSort string
+	DefaultDesc bool
+	// RowClass optionally sets a CSS class per row
+	RowClass func(T) string
+}
+
+// Schema is the row-type independent part of a table, enough to validate state
+type Schema interface {
+	TableName() string
+	Normalize(State) State
+}
+
+func (t *Table[T]) TableName() string {
+	return t.Name
+}
+
+func (t *Table[T]) column(key string) (Column[T], bool) {
+	for _, c := range t.Columns {
+		if c.Key == key {
+			return c, true
+		}
+	}
+	return Column[T]{}, false
+}
+
+// Normalize drops unknown or unsupported keys from s and fills in defaults
+func (t *Table[T]) Normalize(s State) State {
+	out := State{Sort: t.DefaultSort, Desc: t.DefaultDesc}
+	if c, ok := t.column(s.Sort); ok && c.sortable() {
+		out.Sort, out.Desc = s.Sort, s.Desc
+	}
+
+	for key, value := range s.Filters {
+		if c, ok := t.column(key); ok && c.Filterable && value != "" {
+			if out.Filters == nil {
+				out.Filters = make(map[string]string)
+			}
+			out.Filters[key] = value
+		}
+	}
+
+	for _, key := range s.Columns {
+		if _, ok := t.column(key); ok && !slices.Contains(out.Columns, key) {
+			out.Columns = append(out.Columns, key)
+		}
+	}
+	if len(out.Columns) == 0 {
+		for _, c := range t.Columns {
+			if !c.Optional {
+				out.Columns = append(out.Columns, c.Key)
+			}
+		}
+	}
+	return out
+}
+
+// Apply filters and sorts rows according to s, returning a new slice
+func (t *Table[T]) Apply(s State, rows []T) []T {
+	s = t.Normalize(s)
+
+	out := make([]T, 0, len(rows))
+	for _, row := range rows {
+		if t.matches(s, row) {
+			out = append(out, row)
+		}
+	}
+
+	if c, ok := t.column(s.Sort); ok {
+		slices.SortStableFunc(out, func(a, b T) int {
+			if s.Desc {
+				return c.compare(b, a)
+			}
+			return c.compare(a, b)
+		})
+	}
+	return out
+}
+
+func (t *Table[T]) matches(s State, row T) bool {
+	for key, filter := range s.Filters {
+		c, _ := t.column(key)
+		if c.Value == nil || !strings.Contains(strings.ToLower(c.Value(row)), strings.ToLower(filter)) {
+			return false
+		}
+	}
+	return true
+}
+
+func (t *Table[T]) visible(s State) []Column[T] {
+	var cols []Column[T]
+	for _, key := range s.Columns {
+		if c, ok := t.column(key); ok {
+			cols = append(cols, c)
+		}
+	}
+	return cols
+}
+
+// View prepares rows for the shared table templates. id is the table
+// element's id and path the URL that serves it.
+func (t *Table[T]) View(id, path string, s State, rows []T) View {
+	s = t.Normalize(s)
+	applied := t.Apply(s, rows)
+	visible := t.visible(s)
+
+	v := View{
+		ID:    id,
+		URL:   path,
+		State: s,
+		Total: len(rows),
+		Shown: len(applied),
+	}
+
+	for _, c := range visible {
+		h := Header{Key: c.Key, Label: c.Label, Sorted: c.Key == s.Sort, Desc: s.Desc}
+		if c.sortable() {
+			desc := false
+			if h.Sorted {
+				desc = !s.Desc
+			}
+			h.SortQuery = SortQuery(c.Key, desc)
+		}
+		v.Headers = append(v.Headers, h)
+	}
+
+	for _, c := range t.Columns {
+		opt := ColumnOption{Key: c.Key, Label: c.Label, Visible: slices.Contains(s.Columns, c.Key)}
+		v.Options = append(v.Options, opt)
+		if c.Filterable {
+			v.Filters = append(v.Filters, Filter{Key: c.Key, Label: c.Label, Value: s.Filters[c.Key]})
+		}
+	}
+
+	for _, row := range applied {
+		r := Row{}
+		if t.RowClass != nil {
+			r.Class = t.RowClass(row)
+		}
+		for _, c := range visible {
+			r.Cells = append(r.Cells, c.html(row))
+		}
+		v.Rows = append(v.Rows, r)
+	}
+
+	return v
+}
+
+// WriteCSV exports the rows as they appear under s: filtered, sorted and
+// limited to the visible columns that have plain-text values
+func (t *Table[T]) WriteCSV(w io.Writer, s State, rows []T) error {
+	s = t.Normalize(s)
+
+	var cols []Column[T]
+	for _, c := range t.visible(s) {
+		if c.Value != nil {
+			cols = append(cols, c)
+		}
+	}
+
+	cw := csv.NewWriter(w)
+	header := make([]string, len(cols))
+	for i, c := range cols {
+		header[i] = c.Label
+	}
+	if err := cw.Write(header); err != nil {
+		return err
+	}
+
+	for _, row := range t.Apply(s, rows) {
+		record := make([]string, len(cols))
+		for i, c := range cols {
+			record[i] = c.Value(row)
+		}
+		if err := cw.Write(record); err != nil {
+			return err
+		}
+	}
+
+	cw.Flush()
+	return cw.Error()
+}
+
+// View is a table prepared for rendering
+type View struct {
+	ID      string
+	URL     string
+	State   State
+	Headers []Header
+	Options []ColumnOption
+	Filters []Filter
+	Rows    []Row
+	Total   int
+	Shown   int
+}
+
+// Header is a visible column heading
+type Header struct {
+	Key       string
+	Label     string
+	Sorted    bool
+	Desc      bool
+	SortQuery string // query string that sorts by this column, empty if unsortable
+}
+
+// ColumnOption is an entry in the column chooser
+type ColumnOption struct {
+	Key     string
+	Label   string
+	Visible bool
+}
+
+// Filter is a filter input for a filterable column
+type Filter struct {
+	Key   string
+	Label string
+	Value string
+}
+
+// Row is a rendered table row
+type Row struct {
+	Class string
+	Cells []template.HTML
+}
+
+// SortQuery encodes a sort change as a query string
+func SortQuery(key string, desc bool) string {
+	q := url.Values{"sort": {key}, "dir": {"asc"}}
+	if desc {
+		q.Set("dir", "desc")
+	}
+	return q.Encode()
+}
+
+// By returns a Compare func that orders rows by a typed value
+func By[T any, V cmp.Ordered](value func(T) V) func(a, b T) int {
+	return func(a, b T) int {
+		return cmp.Compare(value(a), value(b))
+	}
+}
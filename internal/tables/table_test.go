@@ -0,0 +1,148 @@
+package tables
+
+import (
+	"bytes"
+	"net/url"
+	"strconv"
+	"testing"
+)
+
+type result struct {
+	Name   string
+	Status string
+	Ms     int
+}
+
+var resultTable = &Table[result]{
+	Name:        "results",
+	DefaultSort: "name",
+	Columns: []Column[result]{
+		{Key: "name", Label: "Name", Filterable: true, Value: func(r result) string { return r.Name }},
+		{Key: "status", Label: "Status", Filterable: true, Value: func(r result) string { return r.Status }},
+		{
+			Key: "ms", Label: "Duration", Optional: true,
+			Value:   func(r result) string { return strconv.Itoa(r.Ms) },
+			Compare: By(func(r result) int { return r.Ms }),
+		},
+		{Key: "actions", Label: "Actions"},
+	},
+}
+
+var results = []result{
+	{"checkout", "failed", 900},
+	{"Login", "passed", 120},
+	{"search", "passed", 1500},
+}
+
+func names(rows []result) []string {
+	var out []string
+	for _, r := range rows {
+		out = append(out, r.Name)
+	}
+	return out
+}
+
+func TestNormalize(t *testing.T) {
+	s := resultTable.Normalize(State{
+		Sort:    "actions",
+		Filters: map[string]string{"ms": "1", "bogus": "x", "status": "failed"},
+		Columns: []string{"name", "bogus", "name"},
+	})
+
+	if s.Sort != "name" {
+		t.Errorf("expected unsortable column to fall back to default, got %q", s.Sort)
+	}
+	if len(s.Filters) != 1 || s.Filters["status"] != "failed" {
+		t.Errorf("expected only the status filter to survive, got %v", s.Filters)
+	}
+	if len(s.Columns) != 1 || s.Columns[0] != "name" {
+		t.Errorf("expected columns [name], got %v", s.Columns)
+	}
+
+	// Without a column choice the non-optional columns show
+	s = resultTable.Normalize(State{})
+	if got := len(s.Columns); got != 3 {
+		t.Errorf("expected 3 default columns, got %v", s.Columns)
+	}
+}
+
+func TestMerge(t *testing.T) {
+	base := State{Sort: "name", Filters: map[string]string{"status": "passed"}}
+
+	s, changed := base.Merge(url.Values{"page": {"2"}})
+	if changed {
+		t.Error("expected non-table parameters to leave state unchanged")
+	}
+
+	s, changed = base.Merge(url.Values{"sort": {"ms"}, "dir": {"desc"}, "f_status": {""}, "f_name": {" log "}})
+	if !changed || s.Sort != "ms" || !s.Desc {
+		t.Errorf("expected sort ms desc, got %+v", s)
+	}
+	if _, ok := s.Filters["status"]; ok || s.Filters["name"] != "log" {
+		t.Errorf("unexpected filters %v", s.Filters)
+	}
+	if base.Filters["status"] != "passed" {
+		t.Error("Merge must not modify the original state")
+	}
+
+	s, _ = base.Merge(url.Values{"cols": {"", "name,ms"}})
+	if len(s.Columns) != 2 || s.Columns[1] != "ms" {
+		t.Errorf("expected columns [name ms], got %v", s.Columns)
+	}
+}
+
+func TestApply(t *testing.T) {
+	rows := resultTable.Apply(State{}, results)
+	if got := names(rows); got[0] != "checkout" || got[1] != "Login" {
+		t.Errorf("expected case-insensitive name order, got %v", got)
+	}
+
+	rows = resultTable.Apply(State{Sort: "ms", Desc: true, Filters: map[string]string{"status": "PASS"}}, results)
+	if got := names(rows); len(got) != 2 || got[0] != "search" {
+		t.Errorf("expected passed tests slowest first, got %v", got)
+	}
+}
+
+func TestView(t *testing.T) {
+	v := resultTable.View("results", "/results", State{Filters: map[string]string{"status": "failed"}}, results)
+
+	if v.Total != 3 || v.Shown != 1 || len(v.Rows) != 1 {
+		t.Fatalf("expected 1 of 3 rows, got %d of %d", v.Shown, v.Total)
+	}
+	if len(v.Headers) != 3 || v.Headers[2].SortQuery != "" {
+		t.Errorf("expected actions header to be unsortable, got %+v", v.Headers)
+	}
+	if v.Headers[0].SortQuery != "dir=desc&sort=name" {
+		t.Errorf("expected sorted column to toggle direction, got %q", v.Headers[0].SortQuery)
+	}
+}
+
+func TestWriteCSV(t *testing.T) {
+	var buf bytes.Buffer
+	state := State{Sort: "ms", Columns: []string{"name", "ms", "actions"}}
+	if err := resultTable.WriteCSV(&buf, state, results); err != nil {
+		t.Fatal(err)
+	}
+
+	want := "Name,Duration\nLogin,120\ncheckout,900\nsearch,1500\n"
+	if buf.String() != want {
+		t.Errorf("got CSV:\n%s\nwant:\n%s", buf.String(), want)
+	}
+}
+
+func TestStore(t *testing.T) {
+	store := NewStore()
+	store.Save("alice", "results", State{Sort: "ms"})
+
+	if _, ok := store.Get("bob", "results"); ok {
+		t.Error("expected state to be per user")
+	}
+	if s, ok := store.Get("alice", "results"); !ok || s.Sort != "ms" {
+		t.Errorf("expected saved state, got %+v", s)
+	}
+
+	store.Reset("alice", "results")
+	if _, ok := store.Get("alice", "results"); ok {
+		t.Error("expected state to be reset")
+	}
+}
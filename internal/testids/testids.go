@@ -0,0 +1,217 @@
+// Package testids keeps test history stable across renames. When a test
+// disappears from a workflow's results and a similarly named one appears in
+// the same file, the new name is linked to the old one so its duration and
+// flakiness history carries over; ambiguous cases are left for someone to
+// merge or split.
+package testids
+
+import (
+	"log"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/testkube/dashboard/internal/database"
+	"github.com/testkube/dashboard/internal/testkube"
+)
+
+const (
+	// AutoThreshold is the similarity above which a rename is linked
+	// without review, when there is no other candidate
+	AutoThreshold = 0.75
+	// SuggestThreshold is the similarity above which a possible rename is
+	// suggested for review
+	SuggestThreshold = 0.5
+	// ambiguousMargin is how close a second candidate's similarity must be
+	// to the best one's for the match to need review
+	ambiguousMargin = 0.1
+)
+
+// Similarity scores how alike two test names are, from 0 to 1, as the mean
+// of their edit distance and their shared words. Case, punctuation and
+// spacing are ignored.
+func Similarity(a, b string) float64 {
+	wordsA, wordsB := words(a), words(b)
+	ja, jb := strings.Join(wordsA, " "), strings.Join(wordsB, " ")
+	if ja == jb {
+		return 1
+	}
+	longest := max(len([]rune(ja)), len([]rune(jb)))
+	edit := 1 - float64(levenshtein([]rune(ja), []rune(jb)))/float64(longest)
+
+	counts := make(map[string]int, len(wordsA))
+	for _, w := range wordsA {
+		counts[w]++
+	}
+	shared := 0
+	for _, w := range wordsB {
+		if counts[w] > 0 {
+			counts[w]--
+			shared++
+		}
+	}
+	dice := 2 * float64(shared) / float64(len(wordsA)+len(wordsB))
+	return (edit + dice) / 2
+}
+
+// words splits a test name into lower case words
+func words(name string) []string {
+	return strings.FieldsFunc(strings.ToLower(name), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+func levenshtein(a, b []rune) int {
+	prev := make([]int, len(b)+1)
+	curr := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		curr[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+	return prev[len(b)]
+}
+
+// DetectRenames compares a workflow's test cases in consecutive executions
+// and links tests that vanished to similarly named ones that appeared in
+// the same file. A pair is linked automatically when each is the other's
+// only close match; otherwise every plausible pair is suggested.
+func DetectRenames(workflow string, previous, current []database.TestCase, now time.Time) []database.TestLink {
+	before := make(map[string]string, len(previous))
+	for _, tc := range previous {
+		before[tc.TestName] = tc.FilePath
+	}
+	after := make(map[string]string, len(current))
+	for _, tc := range current {
+		after[tc.TestName] = tc.FilePath
+	}
+
+	type candidate struct {
+		from, to, file string
+		score          float64
+	}
+	var candidates []candidate
+	best := map[string]float64{} // by old and new name
+	for to, file := range after {
+		if _, ok := before[to]; ok {
+			continue
+		}
+		for from, fromFile := range before {
+			if _, ok := after[from]; ok || fromFile != file {
+				continue
+			}
+			score := Similarity(from, to)
+			if score < SuggestThreshold {
+				continue
+			}
+			candidates = append(candidates, candidate{from, to, file, score})
+			best["from:"+from] = max(best["from:"+from], score)
+			best["to:"+to] = max(best["to:"+to], score)
+		}
+	}
+
+	// A pair is ambiguous when either name has another candidate nearly
+	// as close
+	rivals := map[string]int{}
+	for _, c := range candidates {
+		if c.score >= best["from:"+c.from]-ambiguousMargin {
+			rivals["from:"+c.from]++
+		}
+		if c.score >= best["to:"+c.to]-ambiguousMargin {
+			rivals["to:"+c.to]++
+		}
+	}
+
+	links := make([]database.TestLink, 0, len(candidates))
+	for _, c := range candidates {
+		status := database.TestLinkSuggested
+		if c.score >= AutoThreshold && c.score == best["from:"+c.from] && c.score == best["to:"+c.to] &&
+			rivals["from:"+c.from] == 1 && rivals["to:"+c.to] == 1 {
+			status = database.TestLinkAuto
+		}
+		links = append(links, database.TestLink{
+			Workflow:   workflow,
+			From:       c.from,
+			To:         c.to,
+			FilePath:   c.file,
+			Similarity: c.score,
+			Status:     status,
+			UpdatedAt:  now,
+		})
+	}
+	sort.Slice(links, func(i, j int) bool {
+		if links[i].Similarity != links[j].Similarity {
+			return links[i].Similarity > links[j].Similarity
+		}
+		return links[i].From+"\x00"+links[i].To < links[j].From+"\x00"+links[j].To
+	})
+	return links
+}
+
+// Linker detects renames as executions are ingested, comparing each with
+// the workflow's previous ingested execution. Register it with the worker.
+type Linker struct {
+	db database.Database
+}
+
+func NewLinker(db database.Database) *Linker {
+	return &Linker{db: db}
+}
+
+// ExecutionIngested saves the renames between the execution and the one
+// before it. Links someone has already merged or split are left alone.
+func (l *Linker) ExecutionIngested(exec testkube.Execution, cases []database.TestCase) {
+	if len(cases) == 0 {
+		return
+	}
+	previous, _, err := l.db.QueryExecutions(database.ExecutionQuery{
+		Workflows: []string{exec.WorkflowName},
+		To:        exec.StartTime,
+		Limit:     1,
+	})
+	if err != nil {
+		log.Printf("Test IDs: error getting the execution before %s: %v", exec.ID, err)
+		return
+	}
+	if len(previous) == 0 {
+		return
+	}
+	before, err := l.db.GetExecutionMetrics(previous[0].ID)
+	if err != nil {
+		log.Printf("Test IDs: error getting test cases of %s: %v", previous[0].ID, err)
+		return
+	}
+
+	existing, err := l.db.GetTestLinks(exec.WorkflowName)
+	if err != nil {
+		log.Printf("Test IDs: error getting test links of %s: %v", exec.WorkflowName, err)
+		return
+	}
+	known := make(map[[2]string]bool, len(existing))
+	for _, link := range existing {
+		known[[2]string{link.From, link.To}] = true
+	}
+
+	for _, link := range DetectRenames(exec.WorkflowName, before, cases, time.Now()) {
+		if known[[2]string{link.From, link.To}] {
+			continue
+		}
+		if err := l.db.SaveTestLink(link); err != nil {
+			log.Printf("Test IDs: error saving test link: %v", err)
+			return
+		}
+		if link.Status == database.TestLinkAuto {
+			log.Printf("Test IDs: %s test %q was renamed %q", exec.WorkflowName, link.From, link.To)
+		}
+	}
+}
@@ -0,0 +1,107 @@
+package testids
+
+import (
+	"testing"
+	"time"
+
+	"github.com/testkube/dashboard/internal/database"
+	"github.com/testkube/dashboard/internal/testkube"
+)
+
+func TestSimilarity(t *testing.T) {
+	tests := []struct {
+		a, b     string
+		min, max float64
+	}{
+		{"Checkout: pays by card", "checkout pays by card", 1, 1},
+		{"should log in with valid credentials", "logs in with valid credentials", AutoThreshold, 1},
+		{"adds item to cart", "removes item from cart", SuggestThreshold, AutoThreshold},
+		{"adds item to cart", "exports invoices as PDF", 0, SuggestThreshold},
+	}
+	for _, tt := range tests {
+		got := Similarity(tt.a, tt.b)
+		if got < tt.min || got > tt.max {
+			t.Errorf("Similarity(%q, %q): got %.2f, expected between %.2f and %.2f", tt.a, tt.b, got, tt.min, tt.max)
+		}
+	}
+}
+
+func TestDetectRenames(t *testing.T) {
+	now := time.Now()
+	tc := func(name, file string) database.TestCase {
+		return database.TestCase{TestName: name, FilePath: file}
+	}
+	previous := []database.TestCase{
+		tc("should log in with valid credentials", "login.spec.ts"),
+		tc("shows an error for a wrong password", "login.spec.ts"),
+		tc("adds item to cart as guest", "cart.spec.ts"),
+		tc("adds item to cart as member", "cart.spec.ts"),
+		tc("exports invoices", "billing.spec.ts"),
+	}
+	current := []database.TestCase{
+		tc("logs in with valid credentials", "login.spec.ts"),
+		tc("shows an error for a wrong password", "login.spec.ts"),
+		tc("adds item to cart", "cart.spec.ts"),
+		// Same name, different file: not the same test
+		tc("exports invoice", "reports.spec.ts"),
+	}
+
+	links := DetectRenames("web", previous, current, now)
+	statuses := map[[2]string]string{}
+	for _, l := range links {
+		if l.Workflow != "web" || !l.UpdatedAt.Equal(now) {
+			t.Errorf("got %+v, expected the workflow and time", l)
+		}
+		statuses[[2]string{l.From, l.To}] = l.Status
+	}
+	expected := map[[2]string]string{
+		{"should log in with valid credentials", "logs in with valid credentials"}: database.TestLinkAuto,
+		// Both old cart tests match the new one closely, so it's for review
+		{"adds item to cart as guest", "adds item to cart"}:  database.TestLinkSuggested,
+		{"adds item to cart as member", "adds item to cart"}: database.TestLinkSuggested,
+	}
+	if len(statuses) != len(expected) {
+		t.Fatalf("got %v, expected %v", statuses, expected)
+	}
+	for pair, status := range expected {
+		if statuses[pair] != status {
+			t.Errorf("got %q for %q -> %q, expected %q", statuses[pair], pair[0], pair[1], status)
+		}
+	}
+}
+
+func TestLinker(t *testing.T) {
+	db := database.NewMockDatabase()
+	start := time.Now().Add(-time.Hour)
+	first := testkube.Execution{ID: "e1", WorkflowName: "web", StartTime: start}
+	second := testkube.Execution{ID: "e2", WorkflowName: "web", StartTime: start.Add(10 * time.Minute)}
+	db.InsertExecution(first)
+	db.InsertTestCase(database.TestCase{ExecutionID: "e1", TestName: "log in with valid credentials", FilePath: "login.spec.ts", Status: "failed"})
+	db.InsertExecution(second)
+	cases := []database.TestCase{{ExecutionID: "e2", TestName: "logs in with valid credentials", FilePath: "login.spec.ts", Status: "passed"}}
+	db.InsertTestCase(cases[0])
+
+	// Someone already said these are different tests
+	db.SaveTestLink(database.TestLink{Workflow: "web", From: "log in with valid credentials", To: "logs in with valid credentials", Status: database.TestLinkSplit})
+	linker := NewLinker(db)
+	linker.ExecutionIngested(second, cases)
+	links, _ := db.GetTestLinks("web")
+	if len(links) != 1 || links[0].Status != database.TestLinkSplit {
+		t.Fatalf("got %+v, expected the split to stand", links)
+	}
+
+	db.Restore(&database.Snapshot{Executions: []testkube.Execution{first, second}, TestCases: []database.TestCase{
+		{ExecutionID: "e1", TestName: "log in with valid credentials", FilePath: "login.spec.ts", Status: "failed"}, cases[0],
+	}})
+	linker.ExecutionIngested(second, cases)
+	links, _ = db.GetTestLinks("web")
+	if len(links) != 1 || links[0].Status != database.TestLinkAuto {
+		t.Fatalf("got %+v, expected the rename to be linked", links)
+	}
+
+	// The old name's failure now counts towards the new name
+	flaky, _ := db.GetFlakyTestsBetween(start.Add(-time.Minute), time.Now())
+	if len(flaky) != 1 || flaky[0].TestName != "logs in with valid credentials" || flaky[0].TotalRuns != 2 {
+		t.Errorf("got %+v, expected both runs under the new name", flaky)
+	}
+}
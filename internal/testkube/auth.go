@@ -0,0 +1,212 @@
+package testkube
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// tokenExpiryMargin refreshes tokens this long before they expire, so a
+// request doesn't set off with a token that lapses on the way
+const tokenExpiryMargin = 30 * time.Second
+
+// defaultTokenLifetime is assumed for tokens issued without expires_in
+const defaultTokenLifetime = 5 * time.Minute
+
+// OIDCConfig authenticates to Testkube Pro and Cloud agents with bearer
+// tokens from an OAuth2/OIDC token endpoint, exchanging a refresh token
+// when there is one and client credentials otherwise
+type OIDCConfig struct {
+	TokenURL     string
+	ClientID     string
+	ClientSecret string
+	RefreshToken string
+	Scopes       []string
+}
+
+// oidcConfigFromEnv reads TESTKUBE_OIDC_TOKEN_URL, TESTKUBE_OIDC_CLIENT_ID,
+// TESTKUBE_OIDC_CLIENT_SECRET, TESTKUBE_OIDC_REFRESH_TOKEN and the
+// space-separated TESTKUBE_OIDC_SCOPES, or returns nil without a token URL
+func oidcConfigFromEnv() *OIDCConfig {
+	tokenURL := os.Getenv("TESTKUBE_OIDC_TOKEN_URL")
+	if tokenURL == "" {
+		return nil
+	}
+	return &OIDCConfig{
+		TokenURL:     tokenURL,
+		ClientID:     os.Getenv("TESTKUBE_OIDC_CLIENT_ID"),
+		ClientSecret: os.Getenv("TESTKUBE_OIDC_CLIENT_SECRET"),
+		RefreshToken: os.Getenv("TESTKUBE_OIDC_REFRESH_TOKEN"),
+		Scopes:       strings.Fields(os.Getenv("TESTKUBE_OIDC_SCOPES")),
+	}
+}
+
+func (c OIDCConfig) validate() error {
+	if _, err := url.ParseRequestURI(c.TokenURL); err != nil {
+		return fmt.Errorf("invalid OIDC token URL %q: %w", c.TokenURL, err)
+	}
+	if c.RefreshToken == "" && (c.ClientID == "" || c.ClientSecret == "") {
+		return fmt.Errorf("OIDC needs a refresh token or a client ID and secret")
+	}
+	return nil
+}
+
+// tokenSource holds the current access token, exchanging for a new one
+// when it is about to expire or the API rejects it
+type tokenSource struct {
+	config OIDCConfig
+	client *http.Client
+	now    func() time.Time
+
+	mu           sync.Mutex
+	token        string
+	expiry       time.Time
+	refreshToken string // rotated by servers that issue a new one each time
+}
+
+func newTokenSource(config OIDCConfig) (*tokenSource, error) {
+	if err := config.validate(); err != nil {
+		return nil, err
+	}
+	return &tokenSource{
+		config:       config,
+		client:       &http.Client{Timeout: 30 * time.Second},
+		now:          time.Now,
+		refreshToken: config.RefreshToken,
+	}, nil
+}
+
+// Token returns a token valid for at least tokenExpiryMargin
+func (s *tokenSource) Token(ctx context.Context) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.token != "" && s.now().Add(tokenExpiryMargin).Before(s.expiry) {
+		return s.token, nil
+	}
+	return s.exchangeLocked(ctx)
+}
+
+// Refresh returns a new token in place of one the API rejected. Requests
+// rejected together share a single exchange.
+func (s *tokenSource) Refresh(ctx context.Context, rejected string) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.token != "" && s.token != rejected {
+		return s.token, nil
+	}
+	return s.exchangeLocked(ctx)
+}
+
+// exchangeLocked gets a new token from the token endpoint
+func (s *tokenSource) exchangeLocked(ctx context.Context) (string, error) {
+	form := url.Values{}
+	if s.refreshToken != "" {
+		form.Set("grant_type", "refresh_token")
+		form.Set("refresh_token", s.refreshToken)
+	} else {
+		form.Set("grant_type", "client_credentials")
+	}
+	if len(s.config.Scopes) > 0 {
+		form.Set("scope", strings.Join(s.config.Scopes, " "))
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", s.config.TokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", fmt.Errorf("failed to create token request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	if s.config.ClientID != "" {
+		req.SetBasicAuth(url.QueryEscape(s.config.ClientID), url.QueryEscape(s.config.ClientSecret))
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("token request failed: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return "", fmt.Errorf("token endpoint returned %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+
+	var token struct {
+		AccessToken  string `json:"access_token"`
+		ExpiresIn    int    `json:"expires_in"`
+		RefreshToken string `json:"refresh_token"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&token); err != nil {
+		return "", fmt.Errorf("failed to parse token response: %w", err)
+	}
+	if token.AccessToken == "" {
+		return "", fmt.Errorf("token endpoint returned no access token")
+	}
+
+	lifetime := defaultTokenLifetime
+	if token.ExpiresIn > 0 {
+		lifetime = time.Duration(token.ExpiresIn) * time.Second
+	}
+	s.token = token.AccessToken
+	s.expiry = s.now().Add(lifetime)
+	if token.RefreshToken != "" {
+		s.refreshToken = token.RefreshToken
+	}
+	return s.token, nil
+}
+
+// authTransport sets the bearer token on each request and, when the API
+// answers 401, refreshes the token and tries once more
+type authTransport struct {
+	next   http.RoundTripper
+	tokens *tokenSource
+}
+
+func (t *authTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	token, err := t.tokens.Token(req.Context())
+	if err != nil {
+		return nil, fmt.Errorf("failed to authenticate: %w", err)
+	}
+	resp, err := t.next.RoundTrip(withBearer(req, token, nil))
+	if err != nil || resp.StatusCode != http.StatusUnauthorized {
+		return resp, err
+	}
+
+	// The retry needs the body again
+	var body io.ReadCloser
+	if req.Body != nil && req.Body != http.NoBody {
+		if req.GetBody == nil {
+			return resp, nil
+		}
+		if body, err = req.GetBody(); err != nil {
+			return resp, nil
+		}
+	}
+	token, err = t.tokens.Refresh(req.Context(), token)
+	if err != nil {
+		if body != nil {
+			body.Close()
+		}
+		return resp, nil
+	}
+	io.Copy(io.Discard, resp.Body)
+	resp.Body.Close()
+	return t.next.RoundTrip(withBearer(req, token, body))
+}
+
+// withBearer returns a copy of req with the token set, and body when given,
+// since RoundTrippers mustn't modify the request
+func withBearer(req *http.Request, token string, body io.ReadCloser) *http.Request {
+	authed := req.Clone(req.Context())
+	authed.Header.Set("Authorization", "Bearer "+token)
+	if body != nil {
+		authed.Body = body
+	}
+	return authed
+}
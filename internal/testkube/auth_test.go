@@ -0,0 +1,100 @@
+package testkube
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestOIDCTokenRefresh(t *testing.T) {
+	var issued atomic.Int32
+	var lastRefreshToken atomic.Value
+	idp := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		if r.Form.Get("grant_type") != "refresh_token" {
+			t.Errorf("got grant %q, expected refresh_token", r.Form.Get("grant_type"))
+		}
+		lastRefreshToken.Store(r.Form.Get("refresh_token"))
+		n := issued.Add(1)
+		fmt.Fprintf(w, `{"access_token": "token-%d", "expires_in": 3600, "refresh_token": "refresh-%d"}`, n, n)
+	}))
+	defer idp.Close()
+
+	// The API revokes the first token after the health check
+	var calls atomic.Int32
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		auth := r.Header.Get("Authorization")
+		if calls.Add(1) > 2 && auth == "Bearer token-1" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		if r.URL.Path == "/v1/test-workflows/checkout-e2e" {
+			fmt.Fprintf(w, `{"name": "checkout-e2e", "labels": {"auth": %q}}`, auth)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer api.Close()
+
+	client, err := NewRealClientWithConfig(ClientConfig{
+		URL:   api.URL,
+		Token: "static",
+		OIDC:  &OIDCConfig{TokenURL: idp.URL, RefreshToken: "refresh-0"},
+	})
+	if err != nil {
+		t.Fatalf("failed to create client: %v", err)
+	}
+
+	wf, err := client.GetWorkflow("checkout-e2e")
+	if err != nil {
+		t.Fatalf("GetWorkflow failed: %v", err)
+	}
+	if wf.Labels["auth"] != "Bearer token-2" {
+		t.Errorf("got %q, expected the request retried with a refreshed token", wf.Labels["auth"])
+	}
+	if issued.Load() != 2 || lastRefreshToken.Load() != "refresh-1" {
+		t.Errorf("issued %d tokens, last with %v, expected 2 using the rotated refresh token", issued.Load(), lastRefreshToken.Load())
+	}
+}
+
+func TestTokenSourceExpiry(t *testing.T) {
+	var issued atomic.Int32
+	idp := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		id, secret, ok := r.BasicAuth()
+		if r.Form.Get("grant_type") != "client_credentials" || !ok || id != "dashboard" || secret != "s3cret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		fmt.Fprintf(w, `{"access_token": "token-%d", "expires_in": 60}`, issued.Add(1))
+	}))
+	defer idp.Close()
+
+	source, err := newTokenSource(OIDCConfig{TokenURL: idp.URL, ClientID: "dashboard", ClientSecret: "s3cret"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Now()
+	source.now = func() time.Time { return now }
+
+	for range 2 {
+		if token, err := source.Token(t.Context()); err != nil || token != "token-1" {
+			t.Errorf("got %q, %v, expected the cached token", token, err)
+		}
+	}
+	now = now.Add(45 * time.Second)
+	if token, _ := source.Token(t.Context()); token != "token-2" {
+		t.Errorf("got %q, expected a new token close to expiry", token)
+	}
+	// Requests rejected with an old token don't exchange again
+	if token, _ := source.Refresh(t.Context(), "token-1"); token != "token-2" {
+		t.Errorf("got %q, expected the current token", token)
+	}
+
+	if _, err := newTokenSource(OIDCConfig{TokenURL: idp.URL, ClientID: "dashboard"}); err == nil {
+		t.Error("expected client credentials without a secret to be rejected")
+	}
+}
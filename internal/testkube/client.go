@@ -2,7 +2,13 @@ package testkube
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"strings"
 	"time"
+
+	"github.com/testkube/dashboard/internal/humanize"
 )
 
 // Execution represents a test execution
@@ -14,8 +20,58 @@ type Execution struct {
 	StartTime    time.Time
 	EndTime      time.Time
 	Duration     time.Duration
-	Branch       string
-	Labels       map[string]string
+	// Branch and Commit are what CI ran the execution for, from its
+	// BranchTag and CommitTag tags
+	Branch string
+	Commit string
+	Labels map[string]string
+	// Trigger is what started the execution, one of Triggers, and
+	// TriggeredBy who, when known
+	Trigger     string
+	TriggeredBy string
+	// Outcome is what a finished execution means for its tests, one of
+	// Outcomes; read it through RunOutcome
+	Outcome string
+	// Steps are how the workflow's steps went, in the order they run, when
+	// the API says
+	Steps []StepResult
+	// Kind is KindTest or KindTestSuite for an execution of one of the v1
+	// API's resources, named by WorkflowName, and empty for a TestWorkflow's
+	Kind string
+}
+
+// StepResult is how one of an execution's workflow steps went
+type StepResult struct {
+	Ref       string
+	Name      string
+	Status    string // queued, running, passed, failed, skipped, aborted, timeout
+	StartTime time.Time
+	EndTime   time.Time
+	Duration  time.Duration
+	Error     string
+	// Depth is how many groups of steps the step is nested in
+	Depth int
+}
+
+// executionFields are an Execution's fields without its MarshalJSON
+type executionFields Execution
+
+// executionJSON is an Execution as the API returns it: its fields, with
+// the duration also in milliseconds and humanized
+type executionJSON struct {
+	executionFields
+	DurationMs    int64
+	DurationHuman string
+}
+
+func (e Execution) json() executionJSON {
+	return executionJSON{executionFields(e), e.Duration.Milliseconds(), humanize.Duration(e.Duration)}
+}
+
+// MarshalJSON adds DurationMs and DurationHuman, so API clients needn't
+// read Duration's nanoseconds
+func (e Execution) MarshalJSON() ([]byte, error) {
+	return json.Marshal(e.json())
 }
 
 // Workflow represents a test workflow
@@ -27,7 +83,21 @@ type Workflow struct {
 	LastRun        time.Time
 	LastStatus     string
 	PassRateLast7d int
-	Sparkline      interface{} // template.HTML or similar
+	Sparkline      interface{}       // template.HTML or similar
+	Labels         map[string]string // e.g. team, suite, priority
+	// Templates are the workflow templates the workflow uses, by name
+	Templates []string
+	// Kind is KindTestWorkflow, or for the v1 API's resources KindTest or
+	// KindTestSuite; empty reads as KindTestWorkflow
+	Kind string
+	// Tests are the tests a TestSuite runs
+	Tests []string
+}
+
+// Legacy reports whether w is a v1 Test or TestSuite rather than a
+// TestWorkflow, so can't be run, edited or opened as one
+func (w Workflow) Legacy() bool {
+	return w.Kind == KindTest || w.Kind == KindTestSuite
 }
 
 // Artifact represents a file generated by an execution
@@ -37,21 +107,195 @@ type Artifact struct {
 	Path string
 }
 
+// ErrExecutionFinished is returned when aborting an execution that has
+// already finished
+var ErrExecutionFinished = errors.New("execution has already finished")
+
+// DefaultPageSize is the page size used when ListOptions leaves it unset
+const DefaultPageSize = 100
+
 type ListOptions struct {
-	PageSize int
-	Page     int
+	PageSize int // DefaultPageSize when 0
+	Page     int // 1-based; 0 is the first page too
 	Status   string
 	Workflow string
+	// Selector filters by workflow labels, in Kubernetes label selector
+	// syntax, e.g. "team=payments,priority in (high,critical)". Executions
+	// are matched on their workflow's labels.
+	Selector string
+	// StartedAfter and StartedBefore bound executions by start time:
+	// StartedAfter is inclusive and StartedBefore exclusive, and either may
+	// be zero. Workflows have no start time, so they don't apply to them.
+	StartedAfter  time.Time
+	StartedBefore time.Time
+	// Branch and Commit filter executions by the source CI ran them for,
+	// matched on their BranchTag and CommitTag tags
+	Branch string
+	Commit string
+}
+
+// ExecutionPage is one page of executions and where it sits in the list
+type ExecutionPage struct {
+	Executions []Execution `json:"executions"`
+	Page       int         `json:"page"` // 1-based
+	PageSize   int         `json:"pageSize"`
+	// Total is the number of executions matching the request across all
+	// pages, or -1 when the Testkube server doesn't report it
+	Total int `json:"total"`
+}
+
+// TotalPages is the number of pages, or 0 when the total is unknown
+func (p ExecutionPage) TotalPages() int {
+	if p.Total < 0 || p.PageSize <= 0 {
+		return 0
+	}
+	return (p.Total + p.PageSize - 1) / p.PageSize
+}
+
+// HasNext reports whether there are executions after this page. Without a
+// total, a full page is assumed to have more after it.
+func (p ExecutionPage) HasNext() bool {
+	if p.Total < 0 {
+		return len(p.Executions) == p.PageSize
+	}
+	return p.Page*p.PageSize < p.Total
+}
+
+// startedInRange reports whether an execution started at t is within the
+// options' StartedAfter and StartedBefore
+func (o ListOptions) startedInRange(t time.Time) bool {
+	if !o.StartedAfter.IsZero() && t.Before(o.StartedAfter) {
+		return false
+	}
+	return o.StartedBefore.IsZero() || t.Before(o.StartedBefore)
+}
+
+// tagSelector is the Testkube tag selector for the options' Branch and
+// Commit, empty when neither is set
+func (o ListOptions) tagSelector() string {
+	var terms []string
+	if o.Branch != "" {
+		terms = append(terms, BranchTag+"="+o.Branch)
+	}
+	if o.Commit != "" {
+		terms = append(terms, CommitTag+"="+o.Commit)
+	}
+	return strings.Join(terms, ",")
+}
+
+// fromSource reports whether an execution is from the options' Branch and
+// Commit
+func (o ListOptions) fromSource(e Execution) bool {
+	return (o.Branch == "" || e.Branch == o.Branch) && (o.Commit == "" || e.Commit == o.Commit)
+}
+
+// normalized fills in the page and page size defaults
+func (o ListOptions) normalized() ListOptions {
+	if o.PageSize <= 0 {
+		o.PageSize = DefaultPageSize
+	}
+	if o.Page <= 0 {
+		o.Page = 1
+	}
+	return o
+}
+
+// RunOptions parameterizes a workflow run
+type RunOptions struct {
+	Config map[string]string // workflow config variables
+	Tags   map[string]string // tags recorded on the execution
+	// Name replaces the generated execution name when set
+	Name string
+	// Target selects the runners the execution runs on, when Testkube has
+	// more than one
+	Target *ExecutionTarget
+}
+
+// ExecutionTarget selects runners by their labels: Match and Not are
+// label selectors, and Replicate runs the execution once for each distinct
+// value of the labels it names
+type ExecutionTarget struct {
+	Match     map[string][]string `json:"match,omitempty"`
+	Not       map[string][]string `json:"not,omitempty"`
+	Replicate []string            `json:"replicate,omitempty"`
 }
 
 type Client interface {
 	GetExecutions(opts ListOptions) ([]Execution, error)
+	// GetExecutionPage is GetExecutions with the page's position and the
+	// total number of matching executions, for page controls
+	GetExecutionPage(opts ListOptions) (*ExecutionPage, error)
 	GetExecution(id string) (*Execution, error)
-	GetWorkflows() ([]Workflow, error)
+	// GetWorkflows lists the workflows matching opts.Selector; the other
+	// options don't apply to workflows
+	GetWorkflows(opts ListOptions) ([]Workflow, error)
 	GetWorkflow(name string) (*Workflow, error)
+	// GetWorkflowSpec returns a workflow's TestWorkflow definition as YAML
+	GetWorkflowSpec(name string) ([]byte, error)
+	// CreateWorkflow creates a workflow from its YAML definition. It fails
+	// with ErrWorkflowExists if the name is taken.
+	CreateWorkflow(spec []byte) (*Workflow, error)
+	// UpdateWorkflow replaces a workflow's definition; the name in the
+	// spec must match
+	UpdateWorkflow(name string, spec []byte) (*Workflow, error)
+	// GetWorkflowSchedules returns a workflow's cron triggers, including
+	// those paused through UpdateWorkflowSchedules
+	GetWorkflowSchedules(name string) ([]WorkflowSchedule, error)
+	// UpdateWorkflowSchedules replaces a workflow's cron triggers. Paused
+	// ones are kept but don't fire.
+	UpdateWorkflowSchedules(name string, schedules []WorkflowSchedule) error
+	// DeleteWorkflow deletes a workflow. Its executions are kept.
+	DeleteWorkflow(name string) error
+	// GetTests and GetTestSuites list the v1 API's Tests and TestSuites
+	// matching opts.Selector, as workflows of KindTest and KindTestSuite.
+	// They return none from a server without the v1 API.
+	GetTests(opts ListOptions) ([]Workflow, error)
+	GetTestSuites(opts ListOptions) ([]Workflow, error)
+	// GetLegacyExecutions lists the executions of a Test or TestSuite,
+	// newest first, a page at a time
+	GetLegacyExecutions(kind, name string, opts ListOptions) ([]Execution, error)
+	// RunLegacy starts a Test or TestSuite, labelling its execution with
+	// the tags
+	RunLegacy(kind, name string, tags map[string]string) (*Execution, error)
+	// GetLegacyExecutionLogs returns the output of a Test's execution.
+	// TestSuite executions have none of their own.
+	GetLegacyExecutionLogs(id string) (string, error)
+	GetWorkflowTemplates(opts ListOptions) ([]WorkflowTemplate, error)
+	// GetWorkflowTemplate returns ErrWorkflowTemplateNotFound for a template
+	// that doesn't exist
+	GetWorkflowTemplate(name string) (*WorkflowTemplate, error)
+	// GetExecutionResources returns where an execution's pods ran and the
+	// resources they used
+	GetExecutionResources(executionID string) (*ResourceUsage, error)
+	// GetExecutionInputs returns the config, tags and runner target an
+	// execution was started with, for running it again as it was
+	GetExecutionInputs(executionID string) (*RunOptions, error)
 	GetArtifacts(executionID string) ([]Artifact, error)
 	DownloadArtifact(executionID, path string) ([]byte, error)
-	RunWorkflow(name string) (*Execution, error)
+	// DownloadArtifactStream is DownloadArtifact without buffering the
+	// content, for artifacts too large to hold in memory. The caller must
+	// close the reader.
+	DownloadArtifactStream(executionID, path string) (io.ReadCloser, error)
+	RunWorkflow(name string, opts RunOptions) (*Execution, error)
+	// AbortExecution stops a queued or running execution, which then has
+	// the status "aborted"
+	AbortExecution(id string) error
 	GetExecutionLogs(executionID string) (string, error)
-	StreamExecutionLogs(ctx context.Context, executionID string) (<-chan string, <-chan error)
+	// OpenExecutionLogs streams an execution's log, for logs too large to
+	// hold in memory. Callers close the reader.
+	OpenExecutionLogs(executionID string) (io.ReadCloser, error)
+	// StreamExecutionLogs follows a running execution's log, line by line,
+	// until it finishes or ctx is done
+	StreamExecutionLogs(ctx context.Context, executionID string) (<-chan LogLine, error)
+	// WatchExecutions sends execution status changes, such as runs starting
+	// and finishing, until ctx is done. It fails if the API can't be
+	// reached; later errors are logged and the watch carries on.
+	WatchExecutions(ctx context.Context) (<-chan ExecutionEvent, error)
+	// WatchNotifications sends executions being queued, starting and
+	// finishing, and their steps finishing, until ctx is done. It fails
+	// like WatchExecutions.
+	WatchNotifications(ctx context.Context) (<-chan ExecutionNotification, error)
+	// GetServerInfo returns the API server's version, agent mode and
+	// feature flags
+	GetServerInfo() (*ServerInfo, error)
 }
@@ -0,0 +1,56 @@
+package testkube
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"strings"
+)
+
+// API modes: which Testkube control plane a RealClient talks to
+const (
+	// ModeOSS talks to an open source agent's API server, under /v1
+	ModeOSS = "oss"
+	// ModeCloud talks to Testkube Cloud or Pro, which serves each
+	// environment's agent API under its organization and environment
+	ModeCloud = "cloud"
+)
+
+// DefaultCloudURL is Testkube Cloud's API
+const DefaultCloudURL = "https://api.testkube.io"
+
+// CloudConfig selects the organization and environment whose agent a
+// RealClient in ModeCloud reads from. The client's Token is the API key
+// ("tkcapi_..."), unless OIDC is configured.
+type CloudConfig struct {
+	Organization string
+	Environment  string
+}
+
+// cloudConfigFromEnv reads TESTKUBE_CLOUD_ORG_ID and TESTKUBE_CLOUD_ENV_ID
+func cloudConfigFromEnv() CloudConfig {
+	return CloudConfig{
+		Organization: os.Getenv("TESTKUBE_CLOUD_ORG_ID"),
+		Environment:  os.Getenv("TESTKUBE_CLOUD_ENV_ID"),
+	}
+}
+
+// apiURL returns the root of the agent API for a client's mode, the URL
+// the workflow and execution endpoints are relative to
+func apiURL(cfg ClientConfig, baseURL string) (string, error) {
+	baseURL = strings.TrimSuffix(baseURL, "/")
+	switch cfg.Mode {
+	case "", ModeOSS:
+		return baseURL + "/v1", nil
+	case ModeCloud:
+		if cfg.Cloud.Organization == "" || cfg.Cloud.Environment == "" {
+			return "", fmt.Errorf("cloud mode needs an organization and environment ID")
+		}
+		if cfg.Token == "" && cfg.OIDC == nil {
+			return "", fmt.Errorf("cloud mode needs an API key or OIDC credentials")
+		}
+		return fmt.Sprintf("%s/organizations/%s/environments/%s/agent", baseURL,
+			url.PathEscape(cfg.Cloud.Organization), url.PathEscape(cfg.Cloud.Environment)), nil
+	}
+	return "", fmt.Errorf("unknown API mode %q: use %s or %s", cfg.Mode, ModeOSS, ModeCloud)
+}
@@ -0,0 +1,215 @@
+package testkube
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+)
+
+// DefaultCluster names the cluster of the client configured through
+// TESTKUBE_API_URL, unless TESTKUBE_CLUSTER_NAME says otherwise
+const DefaultCluster = "default"
+
+var (
+	// ErrUnknownCluster is returned for a cluster name that isn't registered
+	ErrUnknownCluster = errors.New("unknown cluster")
+	// ErrClusterExists is returned when registering a name twice
+	ErrClusterExists = errors.New("cluster already registered")
+)
+
+// ClusterConfig is one Testkube installation in the clusters file. The API
+// token is read from the environment variable named by TokenEnv, so the
+// file holds no secrets.
+type ClusterConfig struct {
+	Name      string `json:"name"`
+	URL       string `json:"url"`
+	Namespace string `json:"namespace,omitempty"`
+	TokenEnv  string `json:"tokenEnv,omitempty"`
+	// LogsTransport and LogsGRPCAddress are as in ClientConfig
+	LogsTransport   string `json:"logsTransport,omitempty"`
+	LogsGRPCAddress string `json:"logsGrpcAddress,omitempty"`
+	// OIDC authenticates with tokens from an OIDC provider instead of
+	// tokenEnv's static token
+	OIDC *ClusterOIDCConfig `json:"oidc,omitempty"`
+	// Mode, Organization and Environment select a Testkube Cloud
+	// environment, as in ClientConfig
+	Mode         string `json:"mode,omitempty"`
+	Organization string `json:"organization,omitempty"`
+	Environment  string `json:"environment,omitempty"`
+	// Timeout, CAFile, InsecureSkipVerify and ProxyURL override the
+	// TESTKUBE_* transport settings for this cluster, as in TransportConfig
+	Timeout            string `json:"timeout,omitempty"`
+	CAFile             string `json:"caFile,omitempty"`
+	InsecureSkipVerify bool   `json:"insecureSkipVerify,omitempty"`
+	ProxyURL           string `json:"proxyUrl,omitempty"`
+	// MaxConcurrentRequests and RequestsPerSecond override the TESTKUBE_*
+	// request limits for this cluster, as in LimitConfig
+	MaxConcurrentRequests int     `json:"maxConcurrentRequests,omitempty"`
+	RequestsPerSecond     float64 `json:"requestsPerSecond,omitempty"`
+}
+
+// ClusterOIDCConfig is a cluster's OIDCConfig, with its secrets read from
+// the environment variables named
+type ClusterOIDCConfig struct {
+	TokenURL        string   `json:"tokenUrl"`
+	ClientID        string   `json:"clientId,omitempty"`
+	ClientSecretEnv string   `json:"clientSecretEnv,omitempty"`
+	RefreshTokenEnv string   `json:"refreshTokenEnv,omitempty"`
+	Scopes          []string `json:"scopes,omitempty"`
+}
+
+type clustersConfig struct {
+	Clusters []ClusterConfig `json:"clusters"`
+}
+
+// ClientRegistry holds a client per Testkube installation, for dashboards
+// that span clusters. One of them is the default, used where no cluster is
+// named.
+type ClientRegistry struct {
+	mu          sync.RWMutex
+	clients     map[string]Client
+	names       []string // in registration order, the default first
+	defaultName string
+}
+
+// NewClientRegistry returns a registry with client as its default cluster
+func NewClientRegistry(name string, client Client) *ClientRegistry {
+	return &ClientRegistry{
+		clients:     map[string]Client{name: client},
+		names:       []string{name},
+		defaultName: name,
+	}
+}
+
+// DefaultClusterName is the name the default cluster is registered under,
+// TESTKUBE_CLUSTER_NAME or DefaultCluster
+func DefaultClusterName() string {
+	if name := os.Getenv("TESTKUBE_CLUSTER_NAME"); name != "" {
+		return name
+	}
+	return DefaultCluster
+}
+
+// Register adds a cluster's client
+func (r *ClientRegistry) Register(name string, client Client) error {
+	if name == "" {
+		return fmt.Errorf("cluster name is required")
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.clients[name]; ok {
+		return fmt.Errorf("%w: %s", ErrClusterExists, name)
+	}
+	r.clients[name] = client
+	r.names = append(r.names, name)
+	return nil
+}
+
+// Get returns a cluster's client; "" is the default cluster
+func (r *ClientRegistry) Get(name string) (Client, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if name == "" {
+		name = r.defaultName
+	}
+	client, ok := r.clients[name]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownCluster, name)
+	}
+	return client, nil
+}
+
+// Default returns the name of the default cluster
+func (r *ClientRegistry) Default() string {
+	return r.defaultName
+}
+
+// Names lists the clusters, the default first
+func (r *ClientRegistry) Names() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return append([]string(nil), r.names...)
+}
+
+// Load registers the clusters in the TESTKUBE_CLUSTERS_FILE JSON file,
+// {"clusters": [{"name": "eu", "url": "https://...", "tokenEnv": "EU_TOKEN"}]},
+// creating each one's client with connect
+func (r *ClientRegistry) Load(connect func(ClusterConfig) (Client, error)) error {
+	file := os.Getenv("TESTKUBE_CLUSTERS_FILE")
+	if file == "" {
+		return nil
+	}
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return fmt.Errorf("failed to read clusters file: %w", err)
+	}
+	var config clustersConfig
+	if err := json.Unmarshal(data, &config); err != nil {
+		return fmt.Errorf("failed to parse clusters file: %w", err)
+	}
+	for _, cluster := range config.Clusters {
+		if cluster.Name == "" {
+			return fmt.Errorf("cluster name is required")
+		}
+		client, err := connect(cluster)
+		if err != nil {
+			return fmt.Errorf("failed to connect to cluster %s: %w", cluster.Name, err)
+		}
+		if err := r.Register(cluster.Name, client); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ConnectCluster creates a RealClient for a cluster in the clusters file
+func ConnectCluster(cluster ClusterConfig) (Client, error) {
+	cfg := ClientConfig{
+		URL:             cluster.URL,
+		Namespace:       cluster.Namespace,
+		LogsTransport:   cluster.LogsTransport,
+		LogsGRPCAddress: cluster.LogsGRPCAddress,
+		Mode:            cluster.Mode,
+		Cloud:           CloudConfig{Organization: cluster.Organization, Environment: cluster.Environment},
+		Transport:       transportConfigFromEnv(),
+		Limits:          limitConfigFromEnv(),
+	}
+	if cluster.Timeout != "" {
+		timeout, err := time.ParseDuration(cluster.Timeout)
+		if err != nil || timeout <= 0 {
+			return nil, fmt.Errorf("invalid timeout %q", cluster.Timeout)
+		}
+		cfg.Transport.Timeout = timeout
+	}
+	if cluster.CAFile != "" {
+		cfg.Transport.CAFile = cluster.CAFile
+	}
+	if cluster.InsecureSkipVerify {
+		cfg.Transport.InsecureSkipVerify = true
+	}
+	if cluster.ProxyURL != "" {
+		cfg.Transport.ProxyURL = cluster.ProxyURL
+	}
+	if cluster.MaxConcurrentRequests > 0 {
+		cfg.Limits.MaxConcurrentRequests = cluster.MaxConcurrentRequests
+	}
+	if cluster.RequestsPerSecond > 0 {
+		cfg.Limits.RequestsPerSecond = cluster.RequestsPerSecond
+	}
+	if cluster.TokenEnv != "" {
+		cfg.Token = os.Getenv(cluster.TokenEnv)
+	}
+	if o := cluster.OIDC; o != nil {
+		cfg.OIDC = &OIDCConfig{TokenURL: o.TokenURL, ClientID: o.ClientID, Scopes: o.Scopes}
+		if o.ClientSecretEnv != "" {
+			cfg.OIDC.ClientSecret = os.Getenv(o.ClientSecretEnv)
+		}
+		if o.RefreshTokenEnv != "" {
+			cfg.OIDC.RefreshToken = os.Getenv(o.RefreshTokenEnv)
+		}
+	}
+	return NewRealClientWithConfig(cfg)
+}
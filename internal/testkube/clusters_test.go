@@ -0,0 +1,64 @@
+package testkube
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestClientRegistry(t *testing.T) {
+	primary, eu := NewMockClient(), NewMockClient()
+	r := NewClientRegistry("us", primary)
+	if err := r.Register("eu", eu); err != nil {
+		t.Fatalf("register failed: %v", err)
+	}
+	if err := r.Register("eu", eu); !errors.Is(err, ErrClusterExists) {
+		t.Errorf("got %v, expected a duplicate cluster error", err)
+	}
+
+	if got, _ := r.Get(""); got != primary {
+		t.Error("expected no name to select the default cluster")
+	}
+	if got, _ := r.Get("eu"); got != eu {
+		t.Error("expected eu's client")
+	}
+	if _, err := r.Get("ap"); !errors.Is(err, ErrUnknownCluster) {
+		t.Errorf("got %v, expected an unknown cluster error", err)
+	}
+	if got := r.Names(); !reflect.DeepEqual(got, []string{"us", "eu"}) {
+		t.Errorf("got %v, expected the default first", got)
+	}
+}
+
+func TestClientRegistryLoad(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "clusters.json")
+	os.WriteFile(file, []byte(`{"clusters": [
+		{"name": "eu", "url": "https://testkube.eu.example.com", "tokenEnv": "EU_TOKEN"},
+		{"name": "ap", "url": "https://testkube.ap.example.com", "namespace": "qa"}
+	]}`), 0o644)
+	t.Setenv("TESTKUBE_CLUSTERS_FILE", file)
+
+	var connected []ClusterConfig
+	r := NewClientRegistry(DefaultCluster, NewMockClient())
+	err := r.Load(func(cluster ClusterConfig) (Client, error) {
+		connected = append(connected, cluster)
+		return NewMockClient(), nil
+	})
+	if err != nil {
+		t.Fatalf("load failed: %v", err)
+	}
+	if got := r.Names(); !reflect.DeepEqual(got, []string{DefaultCluster, "eu", "ap"}) {
+		t.Errorf("got %v, expected the default and the file's clusters", got)
+	}
+	if len(connected) != 2 || connected[0].TokenEnv != "EU_TOKEN" || connected[1].Namespace != "qa" {
+		t.Errorf("unexpected cluster configs: %+v", connected)
+	}
+
+	// A cluster that can't be reached fails the load
+	failing := NewClientRegistry(DefaultCluster, NewMockClient())
+	if err := failing.Load(func(ClusterConfig) (Client, error) { return nil, errors.New("unreachable") }); err == nil {
+		t.Error("expected an unreachable cluster to fail the load")
+	}
+}
@@ -0,0 +1,245 @@
+package testkube
+
+import (
+	"bytes"
+	"cmp"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// apiVersion is a parsed Testkube server version (e.g. "1.17.3" or "v2.1.0").
+type apiVersion struct {
+	Raw   string
+	Major int
+	Minor int
+	Patch int
+}
+
+// parseAPIVersion parses a semver-like version string. Unknown or empty
+// versions parse to the zero value, which callers treat as "undetected".
+func parseAPIVersion(raw string) apiVersion {
+	v := apiVersion{Raw: raw}
+	s := strings.TrimPrefix(strings.TrimSpace(raw), "v")
+	// Drop pre-release/build suffixes such as "-rc1" or "+abc123"
+	if i := strings.IndexAny(s, "-+"); i >= 0 {
+		s = s[:i]
+	}
+	parts := strings.SplitN(s, ".", 3)
+	nums := []*int{&v.Major, &v.Minor, &v.Patch}
+	for i, p := range parts {
+		n, err := strconv.Atoi(p)
+		if err != nil {
+			return apiVersion{Raw: raw}
+		}
+		*nums[i] = n
+	}
+	return v
+}
+
+func (v apiVersion) known() bool {
+	return v.Major > 0 || v.Minor > 0
+}
+
+func (v apiVersion) atLeast(major, minor int) bool {
+	if v.Major != major {
+		return v.Major > major
+	}
+	return v.Minor >= minor
+}
+
+func (v apiVersion) String() string {
+	if !v.known() {
+		return "unknown"
+	}
+	return fmt.Sprintf("%d.%d.%d", v.Major, v.Minor, v.Patch)
+}
+
+// apiExecution is the wire format of a TestWorkflow execution.
+type apiExecution struct {
+	ID       string            `json:"id"`
+	Name     string            `json:"name"`
+	Number   int               `json:"number"`
+	Tags     map[string]string `json:"tags"`
+	Workflow struct {
+		Name string `json:"name"`
+	} `json:"workflow"`
+	Result struct {
+		Status         string                   `json:"status"`
+		StartTime      time.Time                `json:"startTime"`
+		EndTime        time.Time                `json:"endTime"`
+		Initialization apiStepResult            `json:"initialization"`
+		Steps          map[string]apiStepResult `json:"steps"`
+	} `json:"result"`
+	Signature      []apiSignatureStep `json:"signature"`
+	RunningContext *apiRunningContext `json:"runningContext"`
+}
+
+// apiStepResult is how an execution's initialization or one of its steps
+// went
+type apiStepResult struct {
+	Status       string    `json:"status"`
+	ErrorMessage string    `json:"errorMessage"`
+	StartedAt    time.Time `json:"startedAt"`
+	FinishedAt   time.Time `json:"finishedAt"`
+}
+
+// steps lists the execution's steps in its signature's order, named by
+// it, then any results it doesn't name by ref. Steps yet to run are queued.
+func (e apiExecution) steps() []StepResult {
+	var steps []StepResult
+	named := make(map[string]bool)
+	var walk func(signature []apiSignatureStep, depth int)
+	walk = func(signature []apiSignatureStep, depth int) {
+		for _, sig := range signature {
+			named[sig.Ref] = true
+			step := e.Result.Steps[sig.Ref].toStepResult(sig.Ref, depth)
+			step.Name = cmp.Or(sig.Name, sig.Category, sig.Ref)
+			steps = append(steps, step)
+			walk(sig.Children, depth+1)
+		}
+	}
+	walk(e.Signature, 0)
+
+	var unnamed []string
+	for ref := range e.Result.Steps {
+		if !named[ref] {
+			unnamed = append(unnamed, ref)
+		}
+	}
+	sort.Strings(unnamed)
+	for _, ref := range unnamed {
+		steps = append(steps, e.Result.Steps[ref].toStepResult(ref, 0))
+	}
+	return steps
+}
+
+func (r apiStepResult) toStepResult(ref string, depth int) StepResult {
+	step := StepResult{
+		Ref:       ref,
+		Name:      ref,
+		Status:    cmp.Or(r.Status, "queued"),
+		StartTime: r.StartedAt,
+		EndTime:   r.FinishedAt,
+		Error:     r.ErrorMessage,
+		Depth:     depth,
+	}
+	if !r.StartedAt.IsZero() && !r.FinishedAt.IsZero() {
+		step.Duration = r.FinishedAt.Sub(r.StartedAt)
+	}
+	return step
+}
+
+func (e apiExecution) toExecution() Execution {
+	exec := Execution{
+		ID:           e.ID,
+		Name:         e.Name,
+		WorkflowName: e.Workflow.Name,
+		Status:       e.Result.Status,
+		StartTime:    e.Result.StartTime,
+		EndTime:      e.Result.EndTime,
+		Branch:       e.Tags[BranchTag],
+		Commit:       e.Tags[CommitTag],
+		Labels:       e.Tags,
+		Steps:        e.steps(),
+	}
+	messages := []string{e.Result.Initialization.ErrorMessage}
+	for _, step := range e.Result.Steps {
+		messages = append(messages, step.ErrorMessage)
+	}
+	exec.Outcome = ClassifyOutcome(exec.Status, messages...)
+	exec.Trigger, exec.TriggeredBy = trigger(e.Tags, e.RunningContext)
+	if !exec.EndTime.IsZero() {
+		exec.Duration = exec.EndTime.Sub(exec.StartTime)
+	}
+	return exec
+}
+
+// apiExecutionList is a page of executions. Total is the number of
+// executions matching the request across all pages, or -1 when the server
+// doesn't say.
+type apiExecutionList struct {
+	Results []apiExecution
+	Total   int
+}
+
+// compatAdapter normalizes response shapes that differ between Testkube
+// releases so the rest of the client only deals with one model.
+type compatAdapter interface {
+	Name() string
+	DecodeExecutionList(body []byte) (apiExecutionList, error)
+}
+
+// envelopeAdapter handles 1.17+ servers, which wrap execution lists in a
+// {"totals": ..., "filtered": ..., "results": [...]} envelope.
+type envelopeAdapter struct{}
+
+func (envelopeAdapter) Name() string { return "envelope" }
+
+// apiExecutionTotals counts a workflow's executions
+type apiExecutionTotals struct {
+	Results int `json:"results"`
+}
+
+func (envelopeAdapter) DecodeExecutionList(body []byte) (apiExecutionList, error) {
+	var envelope struct {
+		Totals *apiExecutionTotals `json:"totals"`
+		// Filtered counts the executions matching the request's filters,
+		// where totals counts them all
+		Filtered *apiExecutionTotals `json:"filtered"`
+		Results  []apiExecution      `json:"results"`
+	}
+	if err := json.Unmarshal(body, &envelope); err != nil {
+		return apiExecutionList{}, err
+	}
+	list := apiExecutionList{Results: envelope.Results, Total: -1}
+	switch {
+	case envelope.Filtered != nil:
+		list.Total = envelope.Filtered.Results
+	case envelope.Totals != nil:
+		list.Total = envelope.Totals.Results
+	}
+	return list, nil
+}
+
+// legacyAdapter handles pre-1.17 servers, which return execution lists as a
+// bare JSON array.
+type legacyAdapter struct{}
+
+func (legacyAdapter) Name() string { return "legacy" }
+
+func (legacyAdapter) DecodeExecutionList(body []byte) (apiExecutionList, error) {
+	var results []apiExecution
+	if err := json.Unmarshal(body, &results); err != nil {
+		return apiExecutionList{}, err
+	}
+	return apiExecutionList{Results: results, Total: -1}, nil
+}
+
+// sniffingAdapter is used when the server version could not be detected.
+// It inspects each payload and delegates to the matching adapter.
+type sniffingAdapter struct{}
+
+func (sniffingAdapter) Name() string { return "auto" }
+
+func (sniffingAdapter) DecodeExecutionList(body []byte) (apiExecutionList, error) {
+	if trimmed := bytes.TrimSpace(body); len(trimmed) > 0 && trimmed[0] == '[' {
+		return legacyAdapter{}.DecodeExecutionList(body)
+	}
+	return envelopeAdapter{}.DecodeExecutionList(body)
+}
+
+// adapterFor picks the response adapter for a server version.
+func adapterFor(v apiVersion) compatAdapter {
+	switch {
+	case !v.known():
+		return sniffingAdapter{}
+	case v.atLeast(1, 17):
+		return envelopeAdapter{}
+	default:
+		return legacyAdapter{}
+	}
+}
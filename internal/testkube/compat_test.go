@@ -0,0 +1,156 @@
+package testkube
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseAPIVersion(t *testing.T) {
+	tests := []struct {
+		raw      string
+		expected string
+		known    bool
+	}{
+		{"1.17.3", "1.17.3", true},
+		{"v2.1.0", "2.1.0", true},
+		{"1.16.0-rc1", "1.16.0", true},
+		{"2.0", "2.0.0", true},
+		{"", "unknown", false},
+		{"dev", "unknown", false},
+	}
+
+	for _, tt := range tests {
+		v := parseAPIVersion(tt.raw)
+		if v.String() != tt.expected {
+			t.Errorf("parseAPIVersion(%q) = %s, expected %s", tt.raw, v, tt.expected)
+		}
+		if v.known() != tt.known {
+			t.Errorf("parseAPIVersion(%q).known() = %v, expected %v", tt.raw, v.known(), tt.known)
+		}
+	}
+}
+
+func TestAdapterFor(t *testing.T) {
+	tests := []struct {
+		version  string
+		expected string
+	}{
+		{"1.16.9", "legacy"},
+		{"1.17.0", "envelope"},
+		{"2.1.0", "envelope"},
+		{"", "auto"},
+	}
+
+	for _, tt := range tests {
+		adapter := adapterFor(parseAPIVersion(tt.version))
+		if adapter.Name() != tt.expected {
+			t.Errorf("adapterFor(%q) = %s, expected %s", tt.version, adapter.Name(), tt.expected)
+		}
+	}
+}
+
+func TestRealClient_LegacyExecutionList(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/health":
+			w.WriteHeader(http.StatusOK)
+		case "/v1/info":
+			w.Write([]byte(`{"version": "1.16.4", "namespace": "testkube"}`))
+		case "/v1/test-workflow-executions":
+			w.Write([]byte(`[{"id": "abc", "name": "wf-1-1", "workflow": {"name": "wf-1"}, "result": {"status": "failed"}}]`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer ts.Close()
+
+	os.Setenv("TESTKUBE_API_URL", ts.URL)
+	defer os.Unsetenv("TESTKUBE_API_URL")
+
+	client, err := NewRealClient()
+	if err != nil {
+		t.Fatalf("failed to create client: %v", err)
+	}
+
+	if client.adapter.Name() != "legacy" {
+		t.Errorf("expected legacy adapter, got %s", client.adapter.Name())
+	}
+
+	executions, err := client.GetExecutions(ListOptions{})
+	if err != nil {
+		t.Fatalf("GetExecutions failed: %v", err)
+	}
+	if len(executions) != 1 || executions[0].WorkflowName != "wf-1" {
+		t.Errorf("unexpected executions: %+v", executions)
+	}
+}
+
+func TestSniffingAdapter(t *testing.T) {
+	envelope := []byte(`{"totals": {"results": 1}, "results": [{"id": "1"}]}`)
+	bare := []byte(` [{"id": "2"}]`)
+
+	for _, body := range [][]byte{envelope, bare} {
+		list, err := sniffingAdapter{}.DecodeExecutionList(body)
+		if err != nil {
+			t.Fatalf("DecodeExecutionList(%s) failed: %v", body, err)
+		}
+		if len(list.Results) != 1 {
+			t.Errorf("DecodeExecutionList(%s) returned %d results, expected 1", body, len(list.Results))
+		}
+	}
+
+	// Bare arrays carry no total; envelopes count the filtered executions
+	if list, _ := (legacyAdapter{}).DecodeExecutionList(bare); list.Total != -1 {
+		t.Errorf("got total %d for a bare array, expected -1", list.Total)
+	}
+	filtered := []byte(`{"totals": {"results": 250}, "filtered": {"results": 42}, "results": []}`)
+	if list, _ := (envelopeAdapter{}).DecodeExecutionList(filtered); list.Total != 42 {
+		t.Errorf("got total %d, expected the 42 filtered executions", list.Total)
+	}
+}
+
+func TestExecutionSteps(t *testing.T) {
+	var e apiExecution
+	err := json.Unmarshal([]byte(`{
+		"id": "exec-1",
+		"signature": [
+			{"ref": "r1", "name": "Install"},
+			{"ref": "r2", "name": "Tests", "children": [{"ref": "r3", "category": "Run shell command"}]},
+			{"ref": "r4", "name": "Upload"}
+		],
+		"result": {"status": "failed", "steps": {
+			"r1": {"status": "passed", "startedAt": "2024-05-01T12:00:00Z", "finishedAt": "2024-05-01T12:00:30Z"},
+			"r3": {"status": "failed", "errorMessage": "process exited with code 1", "startedAt": "2024-05-01T12:00:30Z", "finishedAt": "2024-05-01T12:02:00Z"},
+			"r2": {"status": "failed"},
+			"r9": {"status": "skipped"}
+		}}
+	}`), &e)
+	if err != nil {
+		t.Fatalf("Unmarshal failed: %v", err)
+	}
+
+	steps := e.toExecution().Steps
+	var names []string
+	for _, step := range steps {
+		names = append(names, step.Name)
+	}
+	// Signature order, then results the signature doesn't name
+	expected := []string{"Install", "Tests", "Run shell command", "Upload", "r9"}
+	if strings.Join(names, ",") != strings.Join(expected, ",") {
+		t.Fatalf("got steps %v, expected %v", names, expected)
+	}
+	if steps[0].Duration != 30*time.Second || steps[0].Status != "passed" {
+		t.Errorf("got %+v, expected Install to pass in 30s", steps[0])
+	}
+	if steps[2].Depth != 1 || steps[2].Error != "process exited with code 1" || steps[2].Duration != 90*time.Second {
+		t.Errorf("got %+v, expected the nested step's failure", steps[2])
+	}
+	if steps[3].Status != "queued" || steps[3].Duration != 0 {
+		t.Errorf("got %+v, expected Upload yet to run", steps[3])
+	}
+}
@@ -0,0 +1,169 @@
+package testkube
+
+import (
+	"sync"
+	"time"
+)
+
+const (
+	// DefaultEnrichmentTTL is how long the workflow list's last run and pass
+	// rate are reused before executions are read again
+	DefaultEnrichmentTTL = 30 * time.Second
+
+	// enrichmentPageSize is how many of the latest executions, across all
+	// workflows, one refresh reads
+	enrichmentPageSize = 500
+
+	// enrichmentRuns is how many of a workflow's latest executions its
+	// last-7-days pass rate is computed over
+	enrichmentRuns = 10
+)
+
+// workflowStats is what the workflow list shows of a workflow's executions
+type workflowStats struct {
+	LastRun        time.Time
+	LastStatus     string
+	PassRateLast7d int
+}
+
+func (s workflowStats) apply(wf *Workflow) {
+	wf.LastRun = s.LastRun
+	wf.LastStatus = s.LastStatus
+	wf.PassRateLast7d = s.PassRateLast7d
+}
+
+// computeWorkflowStats summarizes a workflow's executions, newest first
+func computeWorkflowStats(executions []Execution, now time.Time) workflowStats {
+	var stats workflowStats
+	if len(executions) == 0 {
+		return stats
+	}
+	if len(executions) > enrichmentRuns {
+		executions = executions[:enrichmentRuns]
+	}
+	stats.LastRun = executions[0].StartTime
+	stats.LastStatus = executions[0].Status
+
+	sevenDaysAgo := now.AddDate(0, 0, -7)
+	passed, total := 0, 0
+	for _, exec := range executions {
+		outcome := exec.RunOutcome()
+		if exec.StartTime.After(sevenDaysAgo) && CountsTowardsPassRate(outcome) {
+			total++
+			if outcome == OutcomePassed {
+				passed++
+			}
+		}
+	}
+	if total > 0 {
+		stats.PassRateLast7d = (passed * 100) / total
+	}
+	return stats
+}
+
+// enrichmentCache holds the workflow list's execution stats so that listing
+// workflows doesn't read every workflow's executions. One request for the
+// latest executions covers the workflows that ran recently; only those
+// whose latest runs are older than that page are looked up on their own.
+// Executions are read without the lock held, so a slow request doesn't
+// hold up other lookups.
+type enrichmentCache struct {
+	mu      sync.Mutex
+	ttl     time.Duration
+	fetched time.Time
+	stats   map[string]workflowStats
+	// generation counts invalidations, so that a read started before one
+	// isn't taken as fresh
+	generation int
+}
+
+func newEnrichmentCache(ttl time.Duration) *enrichmentCache {
+	return &enrichmentCache{ttl: ttl, stats: map[string]workflowStats{}}
+}
+
+// invalidate makes the next lookup read executions again, after a run or
+// abort
+func (e *enrichmentCache) invalidate() {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.fetched = time.Time{}
+	e.generation++
+}
+
+// lookup returns the stats of the named workflows, reading executions with
+// list when the cache has expired or lacks one of them. Workflows whose
+// executions can't be read are left out.
+func (e *enrichmentCache) lookup(names []string, list func(ListOptions) ([]Execution, error)) map[string]workflowStats {
+	now := time.Now()
+	e.refresh(names, list, now)
+
+	e.mu.Lock()
+	result := make(map[string]workflowStats, len(names))
+	var missing []string
+	for _, name := range names {
+		if stats, ok := e.stats[name]; ok {
+			result[name] = stats
+		} else {
+			missing = append(missing, name)
+		}
+	}
+	e.mu.Unlock()
+
+	for _, name := range missing {
+		executions, err := list(ListOptions{Workflow: name, PageSize: enrichmentRuns})
+		if err != nil {
+			continue
+		}
+		stats := computeWorkflowStats(executions, now)
+		e.mu.Lock()
+		e.stats[name] = stats
+		e.mu.Unlock()
+		result[name] = stats
+	}
+	return result
+}
+
+// refresh reads the latest executions into the cache when it has expired
+func (e *enrichmentCache) refresh(names []string, list func(ListOptions) ([]Execution, error), now time.Time) {
+	e.mu.Lock()
+	fresh := !e.fetched.IsZero() && now.Sub(e.fetched) < e.ttl
+	generation := e.generation
+	e.mu.Unlock()
+	if fresh {
+		return
+	}
+
+	executions, err := list(ListOptions{PageSize: enrichmentPageSize})
+	if err != nil {
+		return
+	}
+	byWorkflow := map[string][]Execution{}
+	for _, exec := range executions {
+		byWorkflow[exec.WorkflowName] = append(byWorkflow[exec.WorkflowName], exec)
+	}
+	stats := map[string]workflowStats{}
+	for name, runs := range byWorkflow {
+		stats[name] = computeWorkflowStats(runs, now)
+	}
+	// A short page holds every execution, so workflows missing from it have
+	// never run
+	if len(executions) < enrichmentPageSize {
+		for _, name := range names {
+			if _, ok := stats[name]; !ok {
+				stats[name] = workflowStats{}
+			}
+		}
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	// A lookup that started later may have refreshed the cache already
+	if e.fetched.After(now) {
+		return
+	}
+	e.stats = stats
+	// Stats read before an invalidation are used, but read again next time
+	if e.generation == generation {
+		e.fetched = now
+	}
+}
@@ -0,0 +1,44 @@
+package testkube
+
+import (
+	"testing"
+	"time"
+)
+
+func TestEnrichmentLookupDoesNotWaitOnOtherFetches(t *testing.T) {
+	// A full page of one workflow's runs, so others are looked up on their own
+	page := make([]Execution, enrichmentPageSize)
+	for i := range page {
+		page[i] = Execution{ID: "recent", WorkflowName: "recent", Status: "passed", StartTime: time.Now()}
+	}
+	started, release := make(chan struct{}), make(chan struct{})
+	list := func(opts ListOptions) ([]Execution, error) {
+		if opts.Workflow == "old" {
+			close(started)
+			<-release
+			return []Execution{{WorkflowName: "old", Status: "failed", StartTime: time.Now()}}, nil
+		}
+		return page, nil
+	}
+
+	e := newEnrichmentCache(time.Hour)
+	done := make(chan map[string]workflowStats)
+	go func() { done <- e.lookup([]string{"old"}, list) }()
+	<-started
+
+	result := make(chan map[string]workflowStats)
+	go func() { result <- e.lookup([]string{"recent"}, list) }()
+	select {
+	case stats := <-result:
+		if stats["recent"].LastStatus != "passed" {
+			t.Errorf("got %+v, expected recent's cached stats", stats)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("lookup waited on another workflow's fetch")
+	}
+
+	close(release)
+	if stats := <-done; stats["old"].LastStatus != "failed" {
+		t.Errorf("got %+v, expected old's own stats", stats)
+	}
+}
@@ -0,0 +1,130 @@
+package testkube
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+)
+
+// Agent modes a Testkube API server runs in
+const (
+	// AgentStandalone is an open source agent managed on its own
+	AgentStandalone = "standalone"
+	// AgentConnected is an agent connected to a Testkube Pro or Cloud
+	// control plane
+	AgentConnected = "connected"
+)
+
+// ServerInfo is what the Testkube API server reports about itself, with
+// where the dashboard reaches it, so operators can confirm what they're
+// connected to
+type ServerInfo struct {
+	// APIURL is the API root the dashboard's requests go to
+	APIURL    string `json:"apiUrl"`
+	Version   string `json:"version"`
+	Commit    string `json:"commit,omitempty"`
+	Namespace string `json:"namespace,omitempty"`
+	// AgentMode is AgentStandalone or AgentConnected
+	AgentMode      string `json:"agentMode"`
+	Organization   string `json:"organization,omitempty"`
+	Environment    string `json:"environment,omitempty"`
+	HelmChart      string `json:"helmChart,omitempty"`
+	DashboardURI   string `json:"dashboardUri,omitempty"`
+	ResponseShapes string `json:"responseShapes"` // the response adapter in use
+	// Features are the server's feature flags, by name
+	Features map[string]bool `json:"features"`
+}
+
+// FeatureNames lists the features by name, for display
+func (i ServerInfo) FeatureNames() []string {
+	names := make([]string, 0, len(i.Features))
+	for name := range i.Features {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// apiInfo is the API's /info response
+type apiInfo struct {
+	Version               string                     `json:"version"`
+	Commit                string                     `json:"commit"`
+	Namespace             string                     `json:"namespace"`
+	Context               string                     `json:"context"`
+	OrgID                 string                     `json:"orgId"`
+	EnvID                 string                     `json:"envId"`
+	HelmchartVersion      string                     `json:"helmchartVersion"`
+	DashboardURI          string                     `json:"dashboardUri"`
+	EnableSecretEndpoint  bool                       `json:"enableSecretEndpoint"`
+	DisableSecretCreation bool                       `json:"disableSecretCreation"`
+	Features              map[string]json.RawMessage `json:"features"`
+}
+
+// fetchInfo reads the API's /info endpoint
+func (c *RealClient) fetchInfo() (*apiInfo, error) {
+	req, err := http.NewRequest("GET", fmt.Sprintf("%s/info", c.apiURL), nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	if c.token != "" {
+		req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", c.token))
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("API request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("API returned %d", resp.StatusCode)
+	}
+
+	var info apiInfo
+	if err := json.NewDecoder(resp.Body).Decode(&info); err != nil {
+		return nil, fmt.Errorf("failed to parse response: %w", err)
+	}
+	return &info, nil
+}
+
+// GetServerInfo reads the server's version, agent mode and feature flags
+func (c *RealClient) GetServerInfo() (*ServerInfo, error) {
+	info, err := c.fetchInfo()
+	if err != nil {
+		return nil, err
+	}
+	return info.serverInfo(c.apiURL, c.adapter.Name()), nil
+}
+
+func (a *apiInfo) serverInfo(apiURL, adapter string) *ServerInfo {
+	info := &ServerInfo{
+		APIURL:         apiURL,
+		Version:        a.Version,
+		Commit:         a.Commit,
+		Namespace:      a.Namespace,
+		AgentMode:      AgentStandalone,
+		Organization:   a.OrgID,
+		Environment:    a.EnvID,
+		HelmChart:      a.HelmchartVersion,
+		DashboardURI:   a.DashboardURI,
+		ResponseShapes: adapter,
+		Features: map[string]bool{
+			"secretEndpoint":        a.EnableSecretEndpoint,
+			"disableSecretCreation": a.DisableSecretCreation,
+		},
+	}
+	if a.Context == "cloud" || a.OrgID != "" {
+		info.AgentMode = AgentConnected
+	}
+	// Features holds flags and settings such as container whitelists;
+	// only the flags are shown
+	for name, raw := range a.Features {
+		var enabled bool
+		if json.Unmarshal(raw, &enabled) == nil {
+			info.Features[name] = enabled
+		}
+	}
+	return info
+}
@@ -0,0 +1,66 @@
+package testkube
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+)
+
+func TestRealClient_GetServerInfo(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/health":
+			w.WriteHeader(http.StatusOK)
+		case "/v1/info":
+			w.Write([]byte(`{"version": "2.1.52", "commit": "abc123", "namespace": "testkube", "context": "cloud",
+				"orgId": "tkcorg_1", "envId": "tkcenv_2", "enableSecretEndpoint": true,
+				"features": {"logsV2": true, "legacyTests": false, "whitelistedContainers": ["init", "logs"]}}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer ts.Close()
+
+	os.Setenv("TESTKUBE_API_URL", ts.URL)
+	defer os.Unsetenv("TESTKUBE_API_URL")
+
+	client, err := NewRealClient()
+	if err != nil {
+		t.Fatalf("failed to create client: %v", err)
+	}
+	info, err := client.GetServerInfo()
+	if err != nil {
+		t.Fatalf("GetServerInfo failed: %v", err)
+	}
+
+	if info.Version != "2.1.52" || info.Namespace != "testkube" {
+		t.Errorf("got version %q in %q, expected 2.1.52 in testkube", info.Version, info.Namespace)
+	}
+	if info.AgentMode != AgentConnected || info.Organization != "tkcorg_1" || info.Environment != "tkcenv_2" {
+		t.Errorf("got agent mode %q for %q/%q, expected a connected agent", info.AgentMode, info.Organization, info.Environment)
+	}
+	if info.ResponseShapes != "envelope" {
+		t.Errorf("got response shapes %q, expected envelope", info.ResponseShapes)
+	}
+	expected := map[string]bool{"logsV2": true, "legacyTests": false, "secretEndpoint": true, "disableSecretCreation": false}
+	if len(info.Features) != len(expected) {
+		t.Errorf("got features %v, expected %v", info.Features, expected)
+	}
+	for name, enabled := range expected {
+		if got, ok := info.Features[name]; !ok || got != enabled {
+			t.Errorf("got %s=%v, expected %v", name, got, enabled)
+		}
+	}
+}
+
+func TestServerInfo_StandaloneAgent(t *testing.T) {
+	info := (&apiInfo{Version: "2.0.0", Context: "oss"}).serverInfo("http://testkube/v1", "envelope")
+	if info.AgentMode != AgentStandalone {
+		t.Errorf("got agent mode %q, expected %q", info.AgentMode, AgentStandalone)
+	}
+	names := info.FeatureNames()
+	if len(names) != 2 || names[0] != "disableSecretCreation" || names[1] != "secretEndpoint" {
+		t.Errorf("got feature names %v, expected them sorted", names)
+	}
+}
@@ -0,0 +1,164 @@
+package testkube
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Kinds of Testkube resource the workflow list shows. Tests and TestSuites
+// are the v1 API's resources that TestWorkflows replaced; installations
+// upgraded from it often still have many.
+const (
+	KindTestWorkflow = "TestWorkflow"
+	KindTest         = "Test"
+	KindTestSuite    = "TestSuite"
+)
+
+// Kinds lists the kinds in the order the workflow list offers them
+var Kinds = []string{KindTestWorkflow, KindTest, KindTestSuite}
+
+// legacyTestResponse is a Test in the v1 API's JSON representation
+type legacyTestResponse struct {
+	Name      string            `json:"name"`
+	Namespace string            `json:"namespace"`
+	Type      string            `json:"type"`
+	Labels    map[string]string `json:"labels"`
+	Created   time.Time         `json:"created"`
+}
+
+func (t legacyTestResponse) workflow() Workflow {
+	return Workflow{
+		Name:      t.Name,
+		Namespace: t.Namespace,
+		Kind:      KindTest,
+		Type:      legacyTestType(t.Type),
+		Labels:    t.Labels,
+		Created:   t.Created,
+	}
+}
+
+// legacyTestSuiteResponse is a TestSuite in the v1 API's JSON
+// representation. Steps run in batches; before and after batches wrap them.
+type legacyTestSuiteResponse struct {
+	Name      string                   `json:"name"`
+	Namespace string                   `json:"namespace"`
+	Labels    map[string]string        `json:"labels"`
+	Created   time.Time                `json:"created"`
+	Before    []map[string]interface{} `json:"before"`
+	Steps     []map[string]interface{} `json:"steps"`
+	After     []map[string]interface{} `json:"after"`
+}
+
+func (s legacyTestSuiteResponse) workflow() Workflow {
+	var batches []map[string]interface{}
+	batches = append(batches, s.Before...)
+	batches = append(batches, s.Steps...)
+	batches = append(batches, s.After...)
+	return Workflow{
+		Name:      s.Name,
+		Namespace: s.Namespace,
+		Kind:      KindTestSuite,
+		Labels:    s.Labels,
+		Created:   s.Created,
+		Tests:     suiteTests(batches),
+	}
+}
+
+// legacyTestType maps a Test's executor type, e.g. "cypress/project", to
+// the runner it names
+func legacyTestType(testType string) string {
+	runner, _, _ := strings.Cut(testType, "/")
+	return runner
+}
+
+// suiteTests returns the tests a suite's step batches execute, in order:
+// {"execute": [{"test": name}, {"delay": ...}]} since TestSuite v3, and
+// {"execute": {"name": name}} before it
+func suiteTests(batches []map[string]interface{}) []string {
+	var tests []string
+	seen := map[string]bool{}
+	add := func(name interface{}) {
+		if s, ok := name.(string); ok && s != "" && !seen[s] {
+			seen[s] = true
+			tests = append(tests, s)
+		}
+	}
+	for _, batch := range batches {
+		switch execute := batch["execute"].(type) {
+		case []interface{}:
+			for _, step := range execute {
+				if m, ok := step.(map[string]interface{}); ok {
+					add(m["test"])
+				}
+			}
+		case map[string]interface{}:
+			add(execute["name"])
+		}
+	}
+	return tests
+}
+
+// ErrUnknownKind is returned for a kind of resource other than Kinds
+var ErrUnknownKind = errors.New("unknown kind")
+
+// legacyResource is the v1 API's path for the resources of a legacy kind
+func legacyResource(kind string) (string, error) {
+	switch kind {
+	case KindTest:
+		return "tests", nil
+	case KindTestSuite:
+		return "test-suites", nil
+	}
+	return "", fmt.Errorf("%w: %q is not a legacy kind", ErrUnknownKind, kind)
+}
+
+// legacyExecutionResponse is an execution of a Test or TestSuite in the v1
+// API's JSON: a summary when listed, or the execution a run starts, whose
+// status a Test keeps in its result
+type legacyExecutionResponse struct {
+	ID            string `json:"id"`
+	Name          string `json:"name"`
+	TestName      string `json:"testName"`
+	TestSuiteName string `json:"testSuiteName"`
+	TestSuite     *struct {
+		Name string `json:"name"`
+	} `json:"testSuite"`
+	Status          string `json:"status"`
+	ExecutionResult *struct {
+		Status string `json:"status"`
+		Output string `json:"output"`
+	} `json:"executionResult"`
+	StartTime time.Time         `json:"startTime"`
+	EndTime   time.Time         `json:"endTime"`
+	Labels    map[string]string `json:"labels"`
+}
+
+func (e legacyExecutionResponse) execution(kind string) Execution {
+	exec := Execution{
+		ID:           e.ID,
+		Name:         e.Name,
+		Kind:         kind,
+		WorkflowName: e.TestName,
+		Status:       e.Status,
+		StartTime:    e.StartTime,
+		EndTime:      e.EndTime,
+		Labels:       e.Labels,
+	}
+	if exec.WorkflowName == "" {
+		exec.WorkflowName = e.TestSuiteName
+	}
+	if e.TestSuite != nil && exec.WorkflowName == "" {
+		exec.WorkflowName = e.TestSuite.Name
+	}
+	if e.ExecutionResult != nil && exec.Status == "" {
+		exec.Status = e.ExecutionResult.Status
+	}
+	exec.Outcome = ClassifyOutcome(exec.Status)
+	exec.Trigger, exec.TriggeredBy = trigger(e.Labels, nil)
+	if !exec.StartTime.IsZero() && !exec.EndTime.IsZero() {
+		exec.Duration = exec.EndTime.Sub(exec.StartTime)
+	}
+	return exec
+}
@@ -0,0 +1,37 @@
+package testkube
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestLegacyTestType(t *testing.T) {
+	for testType, expected := range map[string]string{
+		"cypress/project":    "cypress",
+		"k6/script":          "k6",
+		"postman/collection": "postman",
+		"curl":               "curl",
+		"":                   "",
+	} {
+		if got := legacyTestType(testType); got != expected {
+			t.Errorf("legacyTestType(%q): got %q, expected %q", testType, got, expected)
+		}
+	}
+}
+
+func TestSuiteTests(t *testing.T) {
+	batches := []map[string]interface{}{
+		// TestSuite v3
+		{"execute": []interface{}{
+			map[string]interface{}{"test": "login"},
+			map[string]interface{}{"delay": "5s"},
+			map[string]interface{}{"test": "checkout"},
+		}},
+		// TestSuite v2
+		{"stopOnFailure": true, "execute": map[string]interface{}{"name": "payments"}},
+		{"execute": []interface{}{map[string]interface{}{"test": "login"}}},
+	}
+	if got := strings.Join(suiteTests(batches), ","); got != "login,checkout,payments" {
+		t.Errorf("got %q, expected each test once, in order", got)
+	}
+}
@@ -0,0 +1,155 @@
+package testkube
+
+import (
+	"context"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultMaxConcurrentRequests caps the requests a client has in flight
+// to the API server, so bursts such as the workflow list's enrichment and
+// the worker's polling queue up in the dashboard instead of piling onto a
+// small API server
+const DefaultMaxConcurrentRequests = 16
+
+// LimitConfig is how hard a RealClient may press the API server. Zero
+// values disable a limit.
+type LimitConfig struct {
+	// MaxConcurrentRequests caps the requests in flight; a request holds
+	// its slot until its response has been read or closed
+	MaxConcurrentRequests int
+	// RequestsPerSecond paces requests, letting up to Burst through at
+	// once after a quiet spell
+	RequestsPerSecond float64
+	Burst             int
+}
+
+// limitConfigFromEnv reads TESTKUBE_MAX_CONCURRENT_REQUESTS,
+// TESTKUBE_REQUESTS_PER_SECOND and TESTKUBE_REQUEST_BURST
+func limitConfigFromEnv() LimitConfig {
+	cfg := LimitConfig{
+		MaxConcurrentRequests: intFromEnv("TESTKUBE_MAX_CONCURRENT_REQUESTS", DefaultMaxConcurrentRequests),
+		Burst:                 intFromEnv("TESTKUBE_REQUEST_BURST", 0),
+	}
+	if val := os.Getenv("TESTKUBE_REQUESTS_PER_SECOND"); val != "" {
+		rps, err := strconv.ParseFloat(val, 64)
+		if err != nil || rps < 0 {
+			log.Printf("Warning: invalid TESTKUBE_REQUESTS_PER_SECOND %q, not pacing requests", val)
+		} else {
+			cfg.RequestsPerSecond = rps
+		}
+	}
+	return cfg
+}
+
+// wrap puts the limits in front of next, or returns next without any
+func (cfg LimitConfig) wrap(next http.RoundTripper) http.RoundTripper {
+	if cfg.MaxConcurrentRequests <= 0 && cfg.RequestsPerSecond <= 0 {
+		return next
+	}
+	t := &limitTransport{next: next}
+	if cfg.MaxConcurrentRequests > 0 {
+		t.slots = make(chan struct{}, cfg.MaxConcurrentRequests)
+	}
+	if cfg.RequestsPerSecond > 0 {
+		t.pacer = newPacer(cfg.RequestsPerSecond, cfg.Burst)
+	}
+	return t
+}
+
+// limitTransport makes requests wait for a slot and their turn. It sits
+// under the retries, so every attempt counts.
+type limitTransport struct {
+	next  http.RoundTripper
+	slots chan struct{} // nil without a cap
+	pacer *pacer        // nil without pacing
+}
+
+func (t *limitTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if t.pacer != nil {
+		if err := t.pacer.wait(req.Context()); err != nil {
+			return nil, err
+		}
+	}
+	if t.slots == nil {
+		return t.next.RoundTrip(req)
+	}
+
+	select {
+	case t.slots <- struct{}{}:
+	case <-req.Context().Done():
+		return nil, req.Context().Err()
+	}
+	release := sync.OnceFunc(func() { <-t.slots })
+	resp, err := t.next.RoundTrip(req)
+	if err != nil {
+		release()
+		return nil, err
+	}
+	// An event stream stays open while the execution runs, so it only
+	// holds a slot until it's connected
+	if strings.HasPrefix(resp.Header.Get("Content-Type"), "text/event-stream") {
+		release()
+		return resp, nil
+	}
+	resp.Body = &slotBody{ReadCloser: resp.Body, release: release}
+	return resp, nil
+}
+
+// slotBody frees its request's slot once read to the end or closed
+type slotBody struct {
+	io.ReadCloser
+	release func()
+}
+
+func (b *slotBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	if err == io.EOF {
+		b.release()
+	}
+	return n, err
+}
+
+func (b *slotBody) Close() error {
+	defer b.release()
+	return b.ReadCloser.Close()
+}
+
+// pacer spaces requests interval apart, letting a burst through at once
+// when requests have been scarcer than that. It schedules each request
+// as it arrives, so waiting requests go in order.
+type pacer struct {
+	mu       sync.Mutex
+	interval time.Duration
+	burst    time.Duration // how far ahead of the schedule a request may go
+	next     time.Time     // when the schedule has room for the next request
+	sleep    func(ctx context.Context, d time.Duration) error
+}
+
+func newPacer(perSecond float64, burst int) *pacer {
+	interval := time.Duration(float64(time.Second) / perSecond)
+	return &pacer{interval: interval, burst: time.Duration(max(burst, 1)-1) * interval, sleep: sleepContext}
+}
+
+// wait blocks until the request's turn, or ctx is done
+func (p *pacer) wait(ctx context.Context) error {
+	p.mu.Lock()
+	now := time.Now()
+	if p.next.Before(now) {
+		p.next = now
+	}
+	at := p.next.Add(-p.burst)
+	p.next = p.next.Add(p.interval)
+	p.mu.Unlock()
+
+	if d := at.Sub(now); d > 0 {
+		return p.sleep(ctx, d)
+	}
+	return nil
+}
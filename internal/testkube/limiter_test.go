@@ -0,0 +1,136 @@
+package testkube
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestLimitTransport_MaxConcurrentRequests(t *testing.T) {
+	var inFlight, peak atomic.Int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := inFlight.Add(1)
+		defer inFlight.Add(-1)
+		for {
+			p := peak.Load()
+			if n <= p || peak.CompareAndSwap(p, n) {
+				break
+			}
+		}
+		time.Sleep(20 * time.Millisecond)
+		w.Write([]byte("ok"))
+	}))
+	defer ts.Close()
+
+	client := &http.Client{Transport: LimitConfig{MaxConcurrentRequests: 2}.wrap(http.DefaultTransport)}
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			resp, err := client.Get(ts.URL)
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			io.ReadAll(resp.Body)
+			resp.Body.Close()
+		}()
+	}
+	wg.Wait()
+
+	if got := peak.Load(); got != 2 {
+		t.Errorf("got %d requests in flight at most, expected 2", got)
+	}
+}
+
+func TestLimitTransport_SlotHeldUntilClose(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/stream" {
+			w.Header().Set("Content-Type", "text/event-stream")
+		}
+		w.Write([]byte("ok"))
+	}))
+	defer ts.Close()
+
+	transport := LimitConfig{MaxConcurrentRequests: 1}.wrap(http.DefaultTransport).(*limitTransport)
+	client := &http.Client{Transport: transport}
+
+	resp, err := client.Get(ts.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(transport.slots) != 1 {
+		t.Errorf("got %d slots taken before the body was closed, expected 1", len(transport.slots))
+	}
+	resp.Body.Close()
+	resp.Body.Close()
+	if len(transport.slots) != 0 {
+		t.Errorf("got %d slots taken after the body was closed, expected 0", len(transport.slots))
+	}
+
+	// Event streams stay open, so they give their slot back once connected
+	stream, err := client.Get(ts.URL + "/stream")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer stream.Body.Close()
+	if len(transport.slots) != 0 {
+		t.Errorf("got %d slots taken by an open event stream, expected 0", len(transport.slots))
+	}
+
+	// A request waiting for a slot gives up with its context
+	transport.slots <- struct{}{}
+	defer func() { <-transport.slots }()
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	req, _ := http.NewRequestWithContext(ctx, "GET", ts.URL, nil)
+	if _, err := client.Do(req); err == nil {
+		t.Error("expected a request without a free slot to time out")
+	}
+}
+
+func TestPacer(t *testing.T) {
+	p := newPacer(10, 3)
+	var waits []time.Duration
+	p.sleep = func(ctx context.Context, d time.Duration) error {
+		waits = append(waits, d)
+		return nil
+	}
+
+	for i := 0; i < 5; i++ {
+		if err := p.wait(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// The burst of 3 goes straight through; the rest are 100ms apart
+	if len(waits) != 2 {
+		t.Fatalf("got waits %v, expected 2", waits)
+	}
+	for i, expected := range []time.Duration{100 * time.Millisecond, 200 * time.Millisecond} {
+		if waits[i] < expected-10*time.Millisecond || waits[i] > expected {
+			t.Errorf("got wait %s, expected about %s", waits[i], expected)
+		}
+	}
+}
+
+func TestLimitConfigFromEnv(t *testing.T) {
+	t.Setenv("TESTKUBE_MAX_CONCURRENT_REQUESTS", "4")
+	t.Setenv("TESTKUBE_REQUESTS_PER_SECOND", "2.5")
+	t.Setenv("TESTKUBE_REQUEST_BURST", "5")
+
+	cfg := limitConfigFromEnv()
+	if cfg != (LimitConfig{MaxConcurrentRequests: 4, RequestsPerSecond: 2.5, Burst: 5}) {
+		t.Errorf("got %+v, expected 4 concurrent requests at 2.5/s in bursts of 5", cfg)
+	}
+
+	if next := http.DefaultTransport; (LimitConfig{}).wrap(next) != next {
+		t.Error("expected no limits to leave the transport as is")
+	}
+}
@@ -0,0 +1,219 @@
+package testkube
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"io"
+	"strings"
+	"time"
+)
+
+// MaxLogLineLength truncates very long log lines, such as minified bundles
+// echoed by a build, in pages and search results
+const MaxLogLineLength = 4096
+
+// LogPage is a range of a log's lines
+type LogPage struct {
+	// Offset is the 0-based number of the first line returned
+	Offset int      `json:"offset"`
+	Lines  []string `json:"lines"`
+	// Total is the number of lines in the log
+	Total int `json:"total"`
+}
+
+// LogMatch is a log line matching a search, with the lines around it
+type LogMatch struct {
+	Line   int      `json:"line"` // 1-based
+	Text   string   `json:"text"`
+	Before []string `json:"before,omitempty"`
+	After  []string `json:"after,omitempty"`
+}
+
+// LogSearch is the result of searching a log
+type LogSearch struct {
+	Query   string     `json:"query"`
+	Matches []LogMatch `json:"matches"`
+	// Truncated is set when there were more matches than returned
+	Truncated bool `json:"truncated"`
+	Total     int  `json:"totalLines"`
+}
+
+// eachLine calls fn with every line of r, numbered from 0, without holding
+// more than one line in memory
+func eachLine(r io.Reader, fn func(n int, line string)) (int, error) {
+	br := bufio.NewReaderSize(r, 64*1024)
+	n := 0
+	for {
+		line, err := br.ReadString('\n')
+		if line != "" {
+			line = strings.TrimRight(line, "\r\n")
+			if len(line) > MaxLogLineLength {
+				line = line[:MaxLogLineLength] + "…"
+			}
+			fn(n, line)
+			n++
+		}
+		if errors.Is(err, io.EOF) {
+			return n, nil
+		}
+		if err != nil {
+			return n, err
+		}
+	}
+}
+
+// ReadLogPage returns up to limit lines of a log starting at offset. A
+// negative offset counts from the end, so -100 is the last 100 lines.
+func ReadLogPage(r io.Reader, offset, limit int) (LogPage, error) {
+	if offset < 0 {
+		return tailLog(r, -offset, limit)
+	}
+	page := LogPage{Offset: offset, Lines: []string{}}
+	total, err := eachLine(r, func(n int, line string) {
+		if n >= offset && len(page.Lines) < limit {
+			page.Lines = append(page.Lines, line)
+		}
+	})
+	page.Total = total
+	return page, err
+}
+
+// tailLog keeps the last n lines in a ring, returning up to limit of them
+func tailLog(r io.Reader, n, limit int) (LogPage, error) {
+	ring := make([]string, n)
+	total, err := eachLine(r, func(i int, line string) {
+		ring[i%n] = line
+	})
+	if err != nil {
+		return LogPage{}, err
+	}
+
+	start := max(total-n, 0)
+	page := LogPage{Offset: start, Lines: []string{}, Total: total}
+	for i := start; i < total && len(page.Lines) < limit; i++ {
+		page.Lines = append(page.Lines, ring[i%n])
+	}
+	return page, nil
+}
+
+// SearchLogs returns up to maxMatches lines matching query, case-insensitive,
+// each with up to context lines before and after it
+func SearchLogs(r io.Reader, query string, context, maxMatches int) (LogSearch, error) {
+	result := LogSearch{Query: query, Matches: []LogMatch{}}
+	needle := strings.ToLower(query)
+
+	// before holds the last context lines; open are matches still collecting
+	// the lines after them
+	var before []string
+	var open []int
+	total, err := eachLine(r, func(n int, line string) {
+		still := open[:0]
+		for _, i := range open {
+			m := &result.Matches[i]
+			m.After = append(m.After, line)
+			if len(m.After) < context {
+				still = append(still, i)
+			}
+		}
+		open = still
+
+		if strings.Contains(strings.ToLower(line), needle) {
+			if len(result.Matches) >= maxMatches {
+				result.Truncated = true
+			} else {
+				result.Matches = append(result.Matches, LogMatch{
+					Line:   n + 1,
+					Text:   line,
+					Before: append([]string(nil), before...),
+				})
+				if context > 0 {
+					open = append(open, len(result.Matches)-1)
+				}
+			}
+		}
+
+		if context > 0 {
+			before = append(before, line)
+			if len(before) > context {
+				before = before[1:]
+			}
+		}
+	})
+	result.Total = total
+	return result, err
+}
+
+// LogLine is a line of a live execution log
+type LogLine struct {
+	Time time.Time `json:"time,omitzero"`
+	// Step is the reference of the workflow step that wrote the line, when known
+	Step string `json:"step,omitempty"`
+	Text string `json:"text"`
+	// Err is set on the last line sent when the stream broke off early
+	Err error `json:"-"`
+}
+
+// logNotification is the part of a Testkube execution notification carrying
+// log output. Notifications about results and outputs have no log.
+type logNotification struct {
+	Ts  time.Time `json:"ts"`
+	Ref string    `json:"ref"`
+	Log string    `json:"log"`
+}
+
+// readLogNotifications reads a server-sent event stream of execution
+// notifications, calling fn with each log line until the stream ends or fn
+// returns false
+func readLogNotifications(r io.Reader, fn func(LogLine) bool) error {
+	return readServerSentEvents(r, func(data string) bool {
+		var n logNotification
+		if err := json.Unmarshal([]byte(data), &n); err != nil || n.Log == "" {
+			return true
+		}
+		for _, text := range strings.Split(strings.TrimRight(n.Log, "\r\n"), "\n") {
+			if !fn(LogLine{Time: n.Ts, Step: n.Ref, Text: strings.TrimRight(text, "\r")}) {
+				return false
+			}
+		}
+		return true
+	})
+}
+
+// readServerSentEvents reads a server-sent event stream, calling fn with
+// each event's data until the stream ends or fn returns false
+func readServerSentEvents(r io.Reader, fn func(data string) bool) error {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+
+	// dispatch sends the event collected so far
+	var data strings.Builder
+	dispatch := func() bool {
+		event := data.String()
+		data.Reset()
+		return fn(event)
+	}
+
+	for scanner.Scan() {
+		line := scanner.Text()
+		if rest, ok := strings.CutPrefix(line, "data:"); ok {
+			if data.Len() > 0 {
+				data.WriteByte('\n')
+			}
+			data.WriteString(strings.TrimPrefix(rest, " "))
+			continue
+		}
+		// A blank line ends an event; event names, ids and comments don't
+		// matter here
+		if line == "" && data.Len() > 0 && !dispatch() {
+			return nil
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+	if data.Len() > 0 {
+		dispatch()
+	}
+	return nil
+}
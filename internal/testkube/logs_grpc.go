@@ -0,0 +1,311 @@
+package testkube
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// Log transports: where a RealClient reads execution logs from
+const (
+	// LogsTransportHTTP reads logs from the API server's HTTP endpoints
+	LogsTransportHTTP = "http"
+	// LogsTransportGRPC reads logs from the Testkube logs service over gRPC
+	LogsTransportGRPC = "grpc"
+)
+
+// DefaultLogsGRPCAddress is the in-cluster address of the logs service
+const DefaultLogsGRPCAddress = "testkube-logs:9090"
+
+// grpcLogsMethod streams an execution's logs from the logs service
+const grpcLogsMethod = "/logs.LogsService/Logs"
+
+// maxGRPCMessageSize caps a single log message from the logs service
+const maxGRPCMessageSize = 4 << 20
+
+// Field numbers of the logs service's messages, from Testkube's
+// pkg/logs/pb/logs.proto
+const (
+	logRequestExecutionIDField = 2
+
+	logTimeField    = 1 // google.protobuf.Timestamp
+	logContentField = 2
+	logSourceField  = 3
+
+	timestampSecondsField = 1
+	timestampNanosField   = 2
+)
+
+var errMalformedProto = errors.New("malformed protobuf message")
+
+// grpcLogs reads execution logs from the Testkube logs service. It speaks
+// just enough gRPC, over the standard library's HTTP/2, for the one
+// server-streaming call it needs.
+type grpcLogs struct {
+	baseURL string
+	token   string
+	client  *http.Client
+}
+
+// newGRPCLogs connects to the logs service at address: host:port for
+// plaintext HTTP/2, as in-cluster services usually are, or an https:// URL
+// for TLS, through the API client's base transport so its CAs and proxy
+// apply. It authenticates with tokens when set, and token otherwise.
+func newGRPCLogs(address, token string, tokens *tokenSource, base *http.Transport) (*grpcLogs, error) {
+	if address == "" {
+		address = DefaultLogsGRPCAddress
+	}
+	var protocols http.Protocols
+	baseURL := address
+	switch {
+	case strings.HasPrefix(address, "https://"):
+		protocols.SetHTTP2(true)
+	case strings.HasPrefix(address, "http://"):
+		protocols.SetUnencryptedHTTP2(true)
+	default:
+		protocols.SetUnencryptedHTTP2(true)
+		baseURL = "http://" + address
+	}
+	if _, err := url.Parse(baseURL); err != nil {
+		return nil, fmt.Errorf("invalid logs service address %q: %w", address, err)
+	}
+
+	h2 := base.Clone()
+	h2.Protocols = &protocols
+	var transport http.RoundTripper = h2
+	if tokens != nil {
+		transport = &authTransport{next: h2, tokens: tokens}
+	}
+	// No client timeout: the call lasts as long as the execution runs
+	return &grpcLogs{
+		baseURL: strings.TrimSuffix(baseURL, "/"),
+		token:   token,
+		client:  &http.Client{Transport: transport},
+	}, nil
+}
+
+// call starts the Logs call for an execution
+func (g *grpcLogs) call(ctx context.Context, executionID string) (*http.Response, error) {
+	request := appendProtoString(nil, logRequestExecutionIDField, executionID)
+	req, err := http.NewRequestWithContext(ctx, "POST", g.baseURL+grpcLogsMethod, bytes.NewReader(grpcFrame(request)))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/grpc")
+	req.Header.Set("TE", "trailers")
+	if g.token != "" {
+		req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", g.token))
+	}
+
+	resp, err := g.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("logs service request failed: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return nil, fmt.Errorf("logs service returned %d", resp.StatusCode)
+	}
+	// Calls failing before any message answer with just the status headers
+	if err := grpcStatus(resp.Header); err != nil {
+		resp.Body.Close()
+		return nil, err
+	}
+	return resp, nil
+}
+
+// read calls fn with each line of the call's log messages until the call
+// ends or fn returns false
+func (g *grpcLogs) read(resp *http.Response, fn func(LogLine) bool) error {
+	header := make([]byte, 5)
+	for {
+		if _, err := io.ReadFull(resp.Body, header); errors.Is(err, io.EOF) {
+			break
+		} else if err != nil {
+			return err
+		}
+		if header[0] != 0 {
+			return errors.New("compressed gRPC messages are not supported")
+		}
+		size := binary.BigEndian.Uint32(header[1:])
+		if size > maxGRPCMessageSize {
+			return fmt.Errorf("gRPC message of %d bytes is too large", size)
+		}
+		msg := make([]byte, size)
+		if _, err := io.ReadFull(resp.Body, msg); err != nil {
+			return err
+		}
+
+		log, err := decodeLog(msg)
+		if err != nil {
+			return err
+		}
+		if log.Text == "" {
+			continue
+		}
+		for _, text := range strings.Split(strings.TrimRight(log.Text, "\r\n"), "\n") {
+			if !fn(LogLine{Time: log.Time, Step: log.Step, Text: strings.TrimRight(text, "\r")}) {
+				return nil
+			}
+		}
+	}
+	return grpcStatus(resp.Trailer)
+}
+
+// open returns an execution's whole log, as text
+func (g *grpcLogs) open(ctx context.Context, executionID string) (io.ReadCloser, error) {
+	resp, err := g.call(ctx, executionID)
+	if err != nil {
+		return nil, err
+	}
+
+	pr, pw := io.Pipe()
+	go func() {
+		defer resp.Body.Close()
+		err := g.read(resp, func(line LogLine) bool {
+			_, err := io.WriteString(pw, line.Text+"\n")
+			return err == nil
+		})
+		pw.CloseWithError(err)
+	}()
+	return pr, nil
+}
+
+// stream follows an execution's log, like RealClient.StreamExecutionLogs
+func (g *grpcLogs) stream(ctx context.Context, executionID string) (<-chan LogLine, error) {
+	resp, err := g.call(ctx, executionID)
+	if err != nil {
+		return nil, err
+	}
+
+	lines := make(chan LogLine)
+	go func() {
+		defer close(lines)
+		defer resp.Body.Close()
+
+		send := func(line LogLine) bool {
+			select {
+			case <-ctx.Done():
+				return false
+			case lines <- line:
+				return true
+			}
+		}
+		if err := g.read(resp, send); err != nil && ctx.Err() == nil {
+			send(LogLine{Time: time.Now(), Err: fmt.Errorf("error reading logs: %w", err)})
+		}
+	}()
+	return lines, nil
+}
+
+// grpcStatus returns the error a call's status reports, if any
+func grpcStatus(h http.Header) error {
+	status := h.Get("Grpc-Status")
+	if status == "" || status == "0" {
+		return nil
+	}
+	message, err := url.PathUnescape(h.Get("Grpc-Message"))
+	if err != nil {
+		message = h.Get("Grpc-Message")
+	}
+	return fmt.Errorf("logs service returned gRPC status %s: %s", status, message)
+}
+
+// grpcFrame prefixes a message with gRPC's uncompressed-flag and length
+func grpcFrame(msg []byte) []byte {
+	frame := make([]byte, 5, 5+len(msg))
+	binary.BigEndian.PutUint32(frame[1:], uint32(len(msg)))
+	return append(frame, msg...)
+}
+
+// decodeLog reads a Log message into a LogLine, with the message's content
+// as Text and its source as Step
+func decodeLog(msg []byte) (LogLine, error) {
+	var line LogLine
+	err := eachProtoField(msg, func(field int, _ uint64, data []byte) error {
+		switch field {
+		case logContentField:
+			line.Text = string(data)
+		case logSourceField:
+			line.Step = string(data)
+		case logTimeField:
+			var seconds, nanos uint64
+			if err := eachProtoField(data, func(field int, v uint64, _ []byte) error {
+				switch field {
+				case timestampSecondsField:
+					seconds = v
+				case timestampNanosField:
+					nanos = v
+				}
+				return nil
+			}); err != nil {
+				return err
+			}
+			line.Time = time.Unix(int64(seconds), int64(nanos))
+		}
+		return nil
+	})
+	return line, err
+}
+
+// appendProtoString appends a length-delimited field to a protobuf message
+func appendProtoString(b []byte, field int, s string) []byte {
+	b = binary.AppendUvarint(b, uint64(field)<<3|2)
+	b = binary.AppendUvarint(b, uint64(len(s)))
+	return append(b, s...)
+}
+
+// eachProtoField calls fn with each field of a protobuf message: varint
+// fields with their value, length-delimited ones with their bytes. Fixed
+// width fields are skipped.
+func eachProtoField(msg []byte, fn func(field int, varint uint64, data []byte) error) error {
+	for len(msg) > 0 {
+		key, n := binary.Uvarint(msg)
+		if n <= 0 {
+			return errMalformedProto
+		}
+		msg = msg[n:]
+		field := int(key >> 3)
+
+		var err error
+		switch key & 7 {
+		case 0: // varint
+			v, n := binary.Uvarint(msg)
+			if n <= 0 {
+				return errMalformedProto
+			}
+			msg = msg[n:]
+			err = fn(field, v, nil)
+		case 1: // 64-bit
+			if len(msg) < 8 {
+				return errMalformedProto
+			}
+			msg = msg[8:]
+		case 2: // length-delimited
+			size, n := binary.Uvarint(msg)
+			if n <= 0 || size > uint64(len(msg)-n) {
+				return errMalformedProto
+			}
+			data := msg[n : n+int(size)]
+			msg = msg[n+int(size):]
+			err = fn(field, 0, data)
+		case 5: // 32-bit
+			if len(msg) < 4 {
+				return errMalformedProto
+			}
+			msg = msg[4:]
+		default:
+			return errMalformedProto
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,100 @@
+package testkube
+
+import (
+	"context"
+	"encoding/binary"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// encodeLog builds a logs service Log message
+func encodeLog(seconds uint64, content, source string) []byte {
+	var ts []byte
+	ts = binary.AppendUvarint(ts, timestampSecondsField<<3)
+	ts = binary.AppendUvarint(ts, seconds)
+	msg := appendProtoString(nil, logTimeField, string(ts))
+	msg = appendProtoString(msg, logContentField, content)
+	return appendProtoString(msg, logSourceField, source)
+}
+
+// newLogsService serves the logs service's Logs call over plaintext HTTP/2
+func newLogsService(t *testing.T) *httptest.Server {
+	ts := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.ProtoMajor != 2 || r.URL.Path != grpcLogsMethod || r.Header.Get("Content-Type") != "application/grpc" {
+			t.Errorf("got %s %s %s, expected a gRPC call of %s", r.Proto, r.Method, r.URL.Path, grpcLogsMethod)
+		}
+		body, _ := io.ReadAll(r.Body)
+		var executionID string
+		eachProtoField(body[5:], func(field int, _ uint64, data []byte) error {
+			if field == logRequestExecutionIDField {
+				executionID = string(data)
+			}
+			return nil
+		})
+
+		w.Header().Set("Content-Type", "application/grpc")
+		w.Header().Set("Trailer", "Grpc-Status, Grpc-Message")
+		if executionID != "exec-1" {
+			w.Header().Set("Grpc-Status", "5")
+			w.Header().Set("Grpc-Message", "execution%20not%20found")
+			return
+		}
+		w.Write(grpcFrame(encodeLog(1717408800, "Cloning repository...\nInstalling dependencies...\n", "setup")))
+		w.Write(grpcFrame(encodeLog(1717408809, "Running tests...", "run")))
+		w.Header().Set("Grpc-Status", "0")
+	}))
+	ts.Config.Protocols = new(http.Protocols)
+	ts.Config.Protocols.SetUnencryptedHTTP2(true)
+	ts.Start()
+	return ts
+}
+
+func TestGRPCLogs(t *testing.T) {
+	ts := newLogsService(t)
+	defer ts.Close()
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.Contains(r.URL.Path, "/logs") || strings.Contains(r.URL.Path, "/notifications") {
+			t.Errorf("got %s, expected logs to be read from the logs service", r.URL.Path)
+		}
+	}))
+	defer api.Close()
+
+	client, err := NewRealClientWithConfig(ClientConfig{
+		URL:             api.URL,
+		LogsTransport:   LogsTransportGRPC,
+		LogsGRPCAddress: strings.TrimPrefix(ts.URL, "http://"),
+	})
+	if err != nil {
+		t.Fatalf("failed to create client: %v", err)
+	}
+
+	lines, err := client.StreamExecutionLogs(context.Background(), "exec-1")
+	if err != nil {
+		t.Fatalf("StreamExecutionLogs failed: %v", err)
+	}
+	var got []LogLine
+	for line := range lines {
+		got = append(got, line)
+	}
+	if len(got) != 3 || got[0].Text != "Cloning repository..." || got[0].Step != "setup" || got[0].Time.Unix() != 1717408800 {
+		t.Fatalf("got %+v, expected 3 lines starting with the setup step", got)
+	}
+	if got[2].Text != "Running tests..." || got[2].Err != nil {
+		t.Errorf("got %+v, expected the run step's line", got[2])
+	}
+
+	log, err := client.GetExecutionLogs("exec-1")
+	if err != nil || log != "Cloning repository...\nInstalling dependencies...\nRunning tests...\n" {
+		t.Errorf("got %q, %v, expected the whole log", log, err)
+	}
+	if _, err := client.GetExecutionLogs("missing"); err == nil || !strings.Contains(err.Error(), "execution not found") {
+		t.Errorf("got %v, expected the gRPC status as an error", err)
+	}
+
+	if _, err := NewRealClientWithConfig(ClientConfig{URL: api.URL, LogsTransport: "websocket"}); err == nil {
+		t.Error("expected an unknown logs transport to be rejected")
+	}
+}
@@ -0,0 +1,71 @@
+package testkube
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func numberedLog(n int) string {
+	lines := make([]string, n)
+	for i := range lines {
+		lines[i] = fmt.Sprintf("line %d", i+1)
+	}
+	return strings.Join(lines, "\n") + "\n"
+}
+
+func TestReadLogPage(t *testing.T) {
+	page, err := ReadLogPage(strings.NewReader(numberedLog(10)), 2, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if page.Offset != 2 || page.Total != 10 || strings.Join(page.Lines, ",") != "line 3,line 4,line 5" {
+		t.Errorf("got %+v, expected lines 3-5 of 10", page)
+	}
+
+	page, err = ReadLogPage(strings.NewReader(numberedLog(10)), -4, 100)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if page.Offset != 6 || strings.Join(page.Lines, ",") != "line 7,line 8,line 9,line 10" {
+		t.Errorf("got %+v, expected the last 4 lines", page)
+	}
+
+	// Tail longer than the log, and a log without a trailing newline
+	page, err = ReadLogPage(strings.NewReader("a\r\nb"), -10, 100)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if page.Offset != 0 || page.Total != 2 || strings.Join(page.Lines, ",") != "a,b" {
+		t.Errorf("got %+v, expected both lines", page)
+	}
+
+	long := strings.Repeat("x", MaxLogLineLength+10)
+	page, _ = ReadLogPage(strings.NewReader(long), 0, 1)
+	if len(page.Lines[0]) > MaxLogLineLength+len("…") {
+		t.Errorf("got a %d byte line, expected it truncated", len(page.Lines[0]))
+	}
+}
+
+func TestSearchLogs(t *testing.T) {
+	log := "start\nconnecting\nERROR: timeout\nretrying\nok\nerror again\nend\n"
+	result, err := SearchLogs(strings.NewReader(log), "error", 1, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Total != 7 || len(result.Matches) != 2 || result.Truncated {
+		t.Fatalf("got %+v, expected 2 matches in 7 lines", result)
+	}
+	first := result.Matches[0]
+	if first.Line != 3 || first.Text != "ERROR: timeout" || strings.Join(first.Before, ",") != "connecting" || strings.Join(first.After, ",") != "retrying" {
+		t.Errorf("got %+v, expected line 3 with one line of context", first)
+	}
+	if last := result.Matches[1]; last.Line != 6 || strings.Join(last.After, ",") != "end" {
+		t.Errorf("got %+v, expected line 6 followed by end", last)
+	}
+
+	result, _ = SearchLogs(strings.NewReader(log), "e", 0, 2)
+	if len(result.Matches) != 2 || !result.Truncated || result.Matches[0].Before != nil {
+		t.Errorf("got %+v, expected 2 matches without context, truncated", result)
+	}
+}
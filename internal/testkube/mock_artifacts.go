@@ -0,0 +1,211 @@
+package testkube
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+)
+
+// genericArtifacts are what the mock's executions of types without
+// simulated output produce
+var genericArtifacts = []Artifact{
+	{Name: "playwright-report.zip", Size: 1024 * 1024, Path: "playwright-report.zip"},
+	{Name: "results.json", Size: 1024, Path: "results.json"},
+	{Name: "screenshot.png", Size: 512 * 1024, Path: "screenshot.png"},
+}
+
+// mockTracePath is where a failed Playwright run keeps the trace of its
+// failing test
+const mockTracePath = "test-results/checkout-applies-discount-code/trace.zip"
+
+// mockArtifacts lists the artifacts a finished execution of a workflow type
+// produces, shaped like the tool's real output so that the worker's parsers
+// and the execution results renderers can be developed against the mock
+func mockArtifacts(workflowType, status string) []Artifact {
+	switch workflowType {
+	case "playwright":
+		list := []Artifact{
+			{Name: "playwright-report.zip", Size: 1024 * 1024, Path: "playwright-report.zip"},
+			{Name: "results.json", Size: 4 * 1024, Path: "results.json"},
+			{Name: "screenshot.png", Size: 512 * 1024, Path: "screenshot.png"},
+		}
+		if status == "failed" {
+			list = append(list, Artifact{Name: "trace.zip", Size: 2 * 1024 * 1024, Path: mockTracePath})
+		}
+		return list
+	case "k6":
+		return []Artifact{
+			{Name: "summary.json", Size: 2 * 1024, Path: "summary.json"},
+		}
+	case "trivy":
+		return []Artifact{
+			{Name: "results.json", Size: 8 * 1024, Path: "results.json"},
+		}
+	}
+	return genericArtifacts
+}
+
+// mockArtifactContent returns the simulated content of an artifact of a
+// workflow type, if the type has any
+func mockArtifactContent(executionID, workflowType, status, path string) ([]byte, bool) {
+	switch {
+	case workflowType == "playwright" && path == "results.json":
+		return mockPlaywrightReport(status == "failed"), true
+	case workflowType == "k6" && path == "summary.json":
+		return mockK6Summary(executionID, status == "failed"), true
+	case workflowType == "trivy" && path == "results.json":
+		return mockTrivyReport(status == "failed"), true
+	}
+	return nil, false
+}
+
+// mockPlaywrightReport is a Playwright JSON reporter report. Timings don't
+// vary between executions, so reports of runs with the same outcome are
+// identical and artifact diffs only show the failure.
+func mockPlaywrightReport(failed bool) []byte {
+	type result struct {
+		Status   string            `json:"status"`
+		Duration int               `json:"duration"`
+		Retry    int               `json:"retry"`
+		Error    map[string]string `json:"error,omitempty"`
+	}
+	type test struct {
+		ProjectName string   `json:"projectName"`
+		Results     []result `json:"results"`
+	}
+	type spec struct {
+		Title string `json:"title"`
+		File  string `json:"file"`
+		Line  int    `json:"line"`
+		Tests []test `json:"tests"`
+	}
+	type suite struct {
+		Title string `json:"title"`
+		File  string `json:"file"`
+		Specs []spec `json:"specs"`
+	}
+	passed := func(duration int) []test {
+		return []test{{ProjectName: "chromium", Results: []result{{Status: "passed", Duration: duration}}}}
+	}
+
+	// Failed runs fail the discount test on both attempts; the cart test is
+	// flaky, passing on its retry, in every run
+	discount := passed(2380)
+	if failed {
+		discount = []test{{ProjectName: "chromium", Results: []result{
+			{Status: "failed", Duration: 5012, Error: map[string]string{"message": "Timed out 5000ms waiting for expect(locator).toHaveText(\"$45.00\")"}},
+			{Status: "failed", Duration: 5009, Retry: 1, Error: map[string]string{"message": "Timed out 5000ms waiting for expect(locator).toHaveText(\"$45.00\")"}},
+		}}}
+	}
+	report := struct {
+		Suites []suite        `json:"suites"`
+		Stats  map[string]int `json:"stats"`
+	}{
+		Suites: []suite{
+			{Title: "login.spec.ts", File: "login.spec.ts", Specs: []spec{
+				{Title: "signs in with valid credentials", File: "login.spec.ts", Line: 5, Tests: passed(1840)},
+				{Title: "shows an error for a wrong password", File: "login.spec.ts", Line: 18, Tests: passed(960)},
+			}},
+			{Title: "checkout.spec.ts", File: "checkout.spec.ts", Specs: []spec{
+				{Title: "completes a purchase", File: "checkout.spec.ts", Line: 7, Tests: passed(4120)},
+				{Title: "applies discount code", File: "checkout.spec.ts", Line: 31, Tests: discount},
+				{Title: "keeps the cart after reload", File: "checkout.spec.ts", Line: 52, Tests: []test{{ProjectName: "chromium", Results: []result{
+					{Status: "failed", Duration: 3100, Error: map[string]string{"message": "expect(received).toBe(expected)"}},
+					{Status: "passed", Duration: 1450, Retry: 1},
+				}}}},
+			}},
+		},
+		Stats: map[string]int{"expected": 4, "unexpected": 0, "flaky": 1, "skipped": 0},
+	}
+	if failed {
+		report.Stats = map[string]int{"expected": 3, "unexpected": 1, "flaky": 1, "skipped": 0}
+	}
+	return mockJSON(report)
+}
+
+// mockK6Summary is the file written by `k6 run --summary-export`. Latencies
+// vary between executions; failed runs cross their thresholds.
+func mockK6Summary(executionID string, failed bool) []byte {
+	h := fnv.New32a()
+	h.Write([]byte(executionID))
+	jitter := float64(h.Sum32() % 60)
+
+	p95, failedRate, checksFailed := 180+jitter, 0.002, 3
+	if failed {
+		p95, failedRate, checksFailed = 620+jitter, 0.034, 412
+	}
+	requests := 12000 + int(jitter)*10
+	summary := map[string]interface{}{
+		"metrics": map[string]interface{}{
+			"http_reqs": map[string]float64{"count": float64(requests), "rate": float64(requests) / 60},
+			"http_req_failed": map[string]interface{}{
+				"passes": int(float64(requests) * failedRate), "fails": requests - int(float64(requests)*failedRate),
+				"value":      failedRate,
+				"thresholds": map[string]bool{"rate<0.01": failedRate >= 0.01},
+			},
+			"http_req_duration": map[string]interface{}{
+				"avg": p95 * 0.55, "min": 12.4, "med": p95 * 0.5, "max": p95 * 2.1,
+				"p(90)": p95 * 0.85, "p(95)": p95,
+				"thresholds": map[string]bool{"p(95)<500": p95 >= 500},
+			},
+			"iterations": map[string]float64{"count": float64(requests) / 4, "rate": float64(requests) / 240},
+			"vus_max":    map[string]float64{"value": 50, "min": 50, "max": 50},
+			"checks":     map[string]interface{}{"passes": requests - checksFailed, "fails": checksFailed, "value": 1 - float64(checksFailed)/float64(requests)},
+		},
+	}
+	return mockJSON(summary)
+}
+
+// mockTrivyReport is a Trivy JSON report of an image scan. Failed scans
+// include critical vulnerabilities.
+func mockTrivyReport(failed bool) []byte {
+	type vulnerability struct {
+		VulnerabilityID  string `json:"VulnerabilityID"`
+		PkgName          string `json:"PkgName"`
+		InstalledVersion string `json:"InstalledVersion"`
+		FixedVersion     string `json:"FixedVersion,omitempty"`
+		Severity         string `json:"Severity"`
+		Title            string `json:"Title"`
+	}
+	type result struct {
+		Target          string          `json:"Target"`
+		Class           string          `json:"Class"`
+		Type            string          `json:"Type"`
+		Vulnerabilities []vulnerability `json:"Vulnerabilities"`
+	}
+	const image = "registry.example.com/shop/api:1.4.2"
+
+	osPackages := []vulnerability{
+		{VulnerabilityID: "CVE-2024-2511", PkgName: "libssl3", InstalledVersion: "3.0.11-1", FixedVersion: "3.0.13-1", Severity: "MEDIUM", Title: "openssl: Unbounded memory growth with session handling in TLSv1.3"},
+		{VulnerabilityID: "CVE-2023-50495", PkgName: "ncurses-base", InstalledVersion: "6.4-4", Severity: "LOW", Title: "ncurses: segmentation fault via _nc_wrap_entry()"},
+	}
+	modules := []vulnerability{
+		{VulnerabilityID: "CVE-2023-45288", PkgName: "golang.org/x/net", InstalledVersion: "v0.17.0", FixedVersion: "0.23.0", Severity: "HIGH", Title: "golang: net/http, x/net/http2: unlimited number of CONTINUATION frames causes DoS"},
+	}
+	if failed {
+		osPackages = append(osPackages, vulnerability{VulnerabilityID: "CVE-2024-45491", PkgName: "libexpat1", InstalledVersion: "2.5.0-1", FixedVersion: "2.5.0-1+deb12u1", Severity: "CRITICAL", Title: "libexpat: Integer Overflow or Wraparound"})
+		modules = append(modules, vulnerability{VulnerabilityID: "CVE-2024-24790", PkgName: "stdlib", InstalledVersion: "1.21.4", FixedVersion: "1.21.11", Severity: "CRITICAL", Title: "golang: net/netip: Unexpected behavior from Is methods for IPv4-mapped IPv6 addresses"})
+	}
+	report := map[string]interface{}{
+		"SchemaVersion": 2,
+		"ArtifactName":  image,
+		"ArtifactType":  "container_image",
+		"Results": []result{
+			{Target: fmt.Sprintf("%s (debian 12.5)", image), Class: "os-pkgs", Type: "debian", Vulnerabilities: osPackages},
+			{Target: "app/server", Class: "lang-pkgs", Type: "gobinary", Vulnerabilities: modules},
+		},
+	}
+	return mockJSON(report)
+}
+
+// mockJSON encodes v the way the tools write their reports, indented and
+// without escaping characters such as the < in k6 thresholds
+func mockJSON(v interface{}) []byte {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", "  ")
+	enc.Encode(v)
+	return buf.Bytes()
+}
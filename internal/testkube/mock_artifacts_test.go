@@ -0,0 +1,83 @@
+package testkube
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestMockArtifactsPerType(t *testing.T) {
+	c := NewMockClient()
+	executions, err := c.GetExecutions(ListOptions{PageSize: 1000})
+	if err != nil {
+		t.Fatalf("GetExecutions failed: %v", err)
+	}
+	// finished returns a finished execution of a workflow with the status
+	finished := func(workflow, status string) Execution {
+		t.Helper()
+		for _, e := range executions {
+			if e.WorkflowName == workflow && e.Status == status {
+				return e
+			}
+		}
+		t.Fatalf("no %s execution of %s", status, workflow)
+		return Execution{}
+	}
+	paths := func(id string) []string {
+		list, err := c.GetArtifacts(id)
+		if err != nil {
+			t.Fatalf("GetArtifacts failed: %v", err)
+		}
+		var paths []string
+		for _, a := range list {
+			paths = append(paths, a.Path)
+		}
+		return paths
+	}
+	download := func(id, path string) map[string]interface{} {
+		data, err := c.DownloadArtifact(id, path)
+		if err != nil {
+			t.Fatalf("DownloadArtifact failed: %v", err)
+		}
+		var report map[string]interface{}
+		if err := json.Unmarshal(data, &report); err != nil {
+			t.Fatalf("%s of %s isn't JSON: %v", path, id, err)
+		}
+		return report
+	}
+
+	// Playwright: a JSON report, with a trace of the failing test
+	passed, failed := finished("frontend-e2e", "passed"), finished("frontend-e2e", "failed")
+	if got := strings.Join(paths(passed.ID), ","); got != "playwright-report.zip,results.json,screenshot.png" {
+		t.Errorf("passed Playwright artifacts: got %s", got)
+	}
+	if got := paths(failed.ID); got[len(got)-1] != mockTracePath {
+		t.Errorf("failed Playwright artifacts: got %v, expected a trace", got)
+	}
+	if report := download(passed.ID, "results.json"); report["suites"] == nil {
+		t.Errorf("expected a Playwright report, got %v", report)
+	}
+
+	// k6: a summary export whose thresholds fail with the run
+	k6 := download(finished("api-load-test", "passed").ID, "summary.json")
+	duration := k6["metrics"].(map[string]interface{})["http_req_duration"].(map[string]interface{})
+	if crossed := duration["thresholds"].(map[string]interface{})["p(95)<500"]; crossed != false {
+		t.Errorf("expected the passed run to stay under its p95 threshold, got %v", duration)
+	}
+	if !strings.Contains(string(mockK6Summary("exec-x", true)), `"p(95)<500": true`) {
+		t.Error("expected a failed run to cross its p95 threshold")
+	}
+
+	// Trivy: critical vulnerabilities only in failed scans
+	for status, critical := range map[string]bool{"passed": false, "failed": true} {
+		data, _ := c.DownloadArtifact(finished("cluster-security", status).ID, "results.json")
+		if got := strings.Contains(string(data), `"Severity": "CRITICAL"`); got != critical {
+			t.Errorf("%s scan: got critical findings %v, expected %v", status, got, critical)
+		}
+	}
+
+	// Other types keep the generic artifacts
+	if got := strings.Join(paths(finished("backend-integration", "passed").ID), ","); got != "playwright-report.zip,results.json,screenshot.png" {
+		t.Errorf("generic artifacts: got %s", got)
+	}
+}
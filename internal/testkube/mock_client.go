@@ -1,9 +1,19 @@
 package testkube
 
 import (
+	"bytes"
+	"cmp"
 	"context"
 	"fmt"
+	"hash/fnv"
+	"image"
+	"image/color"
+	"image/png"
+	"io"
+	"maps"
 	"math/rand"
+	"slices"
+	"sort"
 	"strings"
 	"sync"
 	"time"
@@ -12,18 +22,37 @@ import (
 type MockClient struct {
 	executions []Execution
 	workflows  []Workflow
+	templates  []WorkflowTemplate
+	tests      []Workflow        // legacy Tests and TestSuites
+	legacyRuns []Execution       // their executions, newest first
+	specs      map[string][]byte // definitions of workflows created or updated
+	inputs     map[string]RunOptions
 	logs       map[string][]string
+	steps      map[string][]ExecutionNotification // steps finished, by execution
+	watch      sharedWatch
 	mu         sync.RWMutex
 }
 
+// NewMockClient creates a mock client with the fixtures in
+// MOCK_FIXTURES_FILE when set, or else generated workflows and history
 func NewMockClient() *MockClient {
-	c := &MockClient{
-		logs: make(map[string][]string),
+	if c := mockClientFromEnv(); c != nil {
+		return c
 	}
+	c := newMockClient()
 	c.generateMockData()
 	return c
 }
 
+func newMockClient() *MockClient {
+	return &MockClient{
+		specs:  make(map[string][]byte),
+		logs:   make(map[string][]string),
+		inputs: make(map[string]RunOptions),
+		steps:  make(map[string][]ExecutionNotification),
+	}
+}
+
 func (c *MockClient) generateMockData() {
 	c.mu.Lock()
 	defer c.mu.Unlock()
@@ -96,6 +125,78 @@ func (c *MockClient) generateMockData() {
 		},
 	}
 
+	for i := range c.workflows {
+		c.workflows[i].Labels = mockLabels(c.workflows[i])
+		c.workflows[i].Templates = mockTemplateRefs(c.workflows[i])
+	}
+
+	legacy := time.Now().Add(-400 * 24 * time.Hour)
+	c.tests = []Workflow{
+		{Name: "checkout-cypress", Namespace: "testkube", Kind: KindTest, Type: "cypress", Created: legacy,
+			Labels: map[string]string{"team": "frontend", "suite": "regression"}},
+		{Name: "orders-postman", Namespace: "testkube", Kind: KindTest, Type: "postman", Created: legacy.Add(7 * 24 * time.Hour),
+			Labels: map[string]string{"team": "backend", "suite": "regression"}},
+		{Name: "search-k6", Namespace: "testkube", Kind: KindTest, Type: "k6", Created: legacy.Add(30 * 24 * time.Hour),
+			Labels: map[string]string{"team": "performance", "suite": "load"}},
+		{Name: "nightly-regression", Namespace: "testkube", Kind: KindTestSuite, Created: legacy.Add(60 * 24 * time.Hour),
+			Labels: map[string]string{"team": "platform", "suite": "regression"}, Tests: []string{"checkout-cypress", "orders-postman"}},
+	}
+	// Legacy resources still run nightly, as they did before the upgrade
+	for day := 1; day <= 3; day++ {
+		for _, t := range c.tests {
+			status := "passed"
+			if t.Name == "orders-postman" && day == 2 {
+				status = "failed"
+			}
+			start := time.Now().AddDate(0, 0, -day).Truncate(24 * time.Hour).Add(2 * time.Hour)
+			exec := Execution{
+				ID:           fmt.Sprintf("legacy-%s-%d", t.Name, day),
+				Name:         fmt.Sprintf("%s-%d", t.Name, 4-day),
+				Kind:         t.Kind,
+				WorkflowName: t.Name,
+				Status:       status,
+				StartTime:    start,
+				EndTime:      start.Add(3 * time.Minute),
+				Duration:     3 * time.Minute,
+				Trigger:      TriggerSchedule,
+				Outcome:      ClassifyOutcome(status),
+			}
+			c.legacyRuns = append(c.legacyRuns, exec)
+			if t.Kind == KindTest {
+				c.logs[exec.ID] = []string{fmt.Sprintf("Running %s (%s)...", t.Name, t.Type), "Test " + status + "."}
+			}
+		}
+	}
+
+	created := time.Now().Add(-120 * 24 * time.Hour)
+	c.templates = []WorkflowTemplate{
+		{
+			Name: "official/playwright/v1", Namespace: "testkube", Created: created,
+			Description: "Run Playwright tests", Image: "mcr.microsoft.com/playwright:v1.44.0",
+			Parameters: []string{"args", "version"}, Labels: map[string]string{"testkube.io/official": "true"},
+		},
+		{
+			Name: "official/k6/v1", Namespace: "testkube", Created: created,
+			Description: "Run k6 load tests", Image: "grafana/k6:0.49.0",
+			Parameters: []string{"params", "version"}, Labels: map[string]string{"testkube.io/official": "true"},
+		},
+		{
+			Name: "git-clone", Namespace: "testkube", Created: created.Add(30 * 24 * time.Hour),
+			Description: "Check out the repository under test", Parameters: []string{"revision"},
+			Labels: map[string]string{"team": "platform"},
+		},
+		{
+			Name: "security/report-upload", Namespace: "testkube", Created: created.Add(60 * 24 * time.Hour),
+			Description: "Upload scan findings to DefectDojo", Image: "curlimages/curl:8.7.1",
+			Labels: map[string]string{"team": "security"},
+		},
+		{
+			Name: "slack-notify", Namespace: "testkube", Created: created.Add(90 * 24 * time.Hour),
+			Description: "Post the result to Slack", Parameters: []string{"channel"},
+			Labels: map[string]string{"team": "platform"},
+		},
+	}
+
 	// Generate executions
 	for i := 0; i < 50; i++ {
 		status := "passed"
@@ -106,6 +207,24 @@ func (c *MockClient) generateMockData() {
 		wf := c.workflows[i%len(c.workflows)]
 		id := fmt.Sprintf("exec-%d", i)
 
+		// Mostly nightly schedules and CI, with some manual debug runs
+		trigger, triggeredBy := TriggerSchedule, ""
+		switch {
+		case i%5 == 0:
+			trigger, triggeredBy = TriggerManual, "alice@example.com"
+		case i%2 == 0:
+			trigger, triggeredBy = TriggerCI, "bitbucket-pipelines"
+		}
+		// CI also tests pull request branches, each at a commit of its own
+		branch, commit, labels := "main", "", map[string]string(nil)
+		if trigger == TriggerCI {
+			if i%3 != 0 {
+				branch = mockFeatureBranches[i%len(mockFeatureBranches)]
+			}
+			commit = fmt.Sprintf("%07x", 0x3a1f9c0+i*7919)
+			labels = map[string]string{BranchTag: branch, CommitTag: commit}
+		}
+
 		c.executions = append(c.executions, Execution{
 			ID:           id,
 			Name:         fmt.Sprintf("%s-%d", wf.Name, i),
@@ -114,27 +233,101 @@ func (c *MockClient) generateMockData() {
 			StartTime:    time.Now().Add(time.Duration(-i) * time.Hour),
 			EndTime:      time.Now().Add(time.Duration(-i)*time.Hour + 2*time.Minute),
 			Duration:     2 * time.Minute,
-			Branch:       "main",
+			Branch:       branch,
+			Commit:       commit,
+			Labels:       labels,
+			Trigger:      trigger,
+			TriggeredBy:  triggeredBy,
+			Outcome:      ClassifyOutcome(status),
 		})
 
+		// One failure is a cluster hiccup rather than the tests
+		if i == 21 {
+			c.executions[len(c.executions)-1].Outcome = OutcomeInfraError
+			c.logs[id] = []string{
+				"Initializing test runner...",
+				"Back-off pulling image \"mcr.microsoft.com/playwright:v1.40.0\": ImagePullBackOff",
+				"Execution failed before any tests ran.",
+			}
+			continue
+		}
+
 		// Pre-fill logs for historical executions
 		c.logs[id] = []string{
 			"Initializing test runner...",
 			"Cloning repository...",
 			"Installing dependencies...",
+			"Connecting to database at 10.0.4.12:5432...",
+			"Logging in as qa-bot@example.com...",
 			"Running tests...",
 			"Test passed successfully.",
 			"Uploading artifacts...",
 			"Done.",
 		}
 	}
+
+	// Sharded Playwright runs (4 shards each) for the shard grouping views
+	for run, hoursAgo := range []int{3, 27, 51} {
+		groupID := fmt.Sprintf("frontend-e2e-sharded-%d", run+1)
+		for shard := 1; shard <= 4; shard++ {
+			status := "passed"
+			if run == 1 && shard == 3 {
+				status = "failed"
+			}
+			id := fmt.Sprintf("exec-shard-%d-%d", run+1, shard)
+			start := time.Now().Add(time.Duration(-hoursAgo)*time.Hour + time.Duration(shard)*time.Second)
+			duration := time.Duration(90+shard*10) * time.Second
+			c.executions = append(c.executions, Execution{
+				ID:           id,
+				Name:         fmt.Sprintf("%s-shard-%d", groupID, shard),
+				WorkflowName: "frontend-e2e",
+				Status:       status,
+				StartTime:    start,
+				EndTime:      start.Add(duration),
+				Duration:     duration,
+				Branch:       "main",
+				Labels: map[string]string{
+					ShardLabel:      fmt.Sprintf("%d/4", shard),
+					ShardGroupLabel: groupID,
+				},
+				Trigger:     TriggerCI,
+				TriggeredBy: "bitbucket-pipelines",
+			})
+			c.logs[id] = []string{
+				fmt.Sprintf("Running shard %d/4...", shard),
+				"Done.",
+			}
+		}
+	}
+
+	// Keep newest first, matching the Testkube API ordering
+	sort.Slice(c.executions, func(i, j int) bool {
+		return c.executions[i].StartTime.After(c.executions[j].StartTime)
+	})
 }
 
 func (c *MockClient) GetExecutions(opts ListOptions) ([]Execution, error) {
+	page, err := c.GetExecutionPage(opts)
+	if err != nil {
+		return nil, err
+	}
+	return page.Executions, nil
+}
+
+func (c *MockClient) GetExecutionPage(opts ListOptions) (*ExecutionPage, error) {
 	c.mu.RLock()
 	defer c.mu.RUnlock()
+	opts = opts.normalized()
+	selector, err := ParseSelector(opts.Selector)
+	if err != nil {
+		return nil, err
+	}
+	labels := make(map[string]map[string]string, len(c.workflows))
+	for _, wf := range c.workflows {
+		labels[wf.Name] = wf.Labels
+	}
 
-	// Simple filtering
+	// Simple filtering; executions are kept newest first
 	var result []Execution
 	for _, e := range c.executions {
 		if opts.Workflow != "" && e.WorkflowName != opts.Workflow {
@@ -143,47 +336,220 @@ func (c *MockClient) GetExecutions(opts ListOptions) ([]Execution, error) {
 		if opts.Status != "" && e.Status != opts.Status {
 			continue
 		}
+		if !selector.Matches(labels[e.WorkflowName]) || !opts.startedInRange(e.StartTime) || !opts.fromSource(e) {
+			continue
+		}
 		result = append(result, e)
 	}
 
-	// Sort by StartTime DESC
-	// (Assuming they are already somewhat sorted, but let's be safe if we were really implementing this)
-	// For mock, c.executions is roughly sorted by generation order which is time descending?
-	// Actually loop generated 0 to 50, with 0 being NOW. So index 0 is newest.
-	// We should probably just return them.
+	start := min((opts.Page-1)*opts.PageSize, len(result))
+	end := min(start+opts.PageSize, len(result))
+	return &ExecutionPage{
+		Executions: append([]Execution{}, result[start:end]...),
+		Page:       opts.Page,
+		PageSize:   opts.PageSize,
+		Total:      len(result),
+	}, nil
+}
+
+func (c *MockClient) GetExecution(id string) (*Execution, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	for _, e := range c.executions {
+		if e.ID == id {
+			// Runs started through the mock have inputs
+			_, simulated := c.inputs[id]
+			e.Steps = mockSteps(e, simulated, c.steps[id])
+			return &e, nil
+		}
+	}
+	return nil, fmt.Errorf("execution not found")
+}
 
-	// Pagination (naive)
-	start := (opts.Page - 1) * opts.PageSize
-	if start < 0 {
-		start = 0
+func (c *MockClient) GetWorkflows(opts ListOptions) ([]Workflow, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	selector, err := ParseSelector(opts.Selector)
+	if err != nil {
+		return nil, err
+	}
+	if len(selector) == 0 {
+		return c.workflows, nil
 	}
-	if start >= len(result) {
-		return []Execution{}, nil
+	var result []Workflow
+	for _, wf := range c.workflows {
+		if selector.Matches(wf.Labels) {
+			result = append(result, wf)
+		}
 	}
-	end := start + opts.PageSize
-	if end > len(result) {
-		end = len(result)
+	return result, nil
+}
+
+// mockFeatureBranches are the pull request branches CI runs mock workflows
+// for
+var mockFeatureBranches = []string{"feature/checkout-redesign", "fix/login-timeout"}
+
+// mockLabels gives a mock workflow the team, suite and priority labels
+// real workflows carry
+func mockLabels(wf Workflow) map[string]string {
+	labels := map[string]string{"team": "platform", "suite": "regression", "priority": "low"}
+	switch wf.Type {
+	case "playwright":
+		labels["team"], labels["suite"] = "frontend", "smoke"
+	case "vitest":
+		labels["team"] = "backend"
+	case "k6", "emqtt-bench":
+		labels["team"], labels["suite"] = "performance", "load"
+	case "trivy", "kubescape", "semgrep", "defectdojo", "emba", "sonarqube":
+		labels["team"], labels["suite"] = "security", "security"
 	}
+	switch wf.Name {
+	case "frontend-e2e", "backend-integration", "cluster-security":
+		labels["priority"] = "high"
+	}
+	return labels
+}
 
-	return result[start:end], nil
+// mockTemplateRefs gives a mock workflow the templates real workflows of its
+// type use
+func mockTemplateRefs(wf Workflow) []string {
+	switch wf.Type {
+	case "playwright":
+		return []string{"git-clone", "official/playwright/v1"}
+	case "vitest":
+		return []string{"git-clone"}
+	case "k6":
+		return []string{"git-clone", "official/k6/v1"}
+	case "trivy", "kubescape", "semgrep", "emba":
+		return []string{"security/report-upload"}
+	}
+	return nil
 }
 
-func (c *MockClient) GetExecution(id string) (*Execution, error) {
+func (c *MockClient) GetTests(opts ListOptions) ([]Workflow, error) {
+	return c.legacy(KindTest, opts)
+}
+
+func (c *MockClient) GetTestSuites(opts ListOptions) ([]Workflow, error) {
+	return c.legacy(KindTestSuite, opts)
+}
+
+func (c *MockClient) GetLegacyExecutions(kind, name string, opts ListOptions) ([]Execution, error) {
+	if _, err := legacyResource(kind); err != nil {
+		return nil, err
+	}
 	c.mu.RLock()
 	defer c.mu.RUnlock()
+	opts = opts.normalized()
+	var result []Execution
+	for _, e := range c.legacyRuns {
+		if e.Kind == kind && e.WorkflowName == name {
+			result = append(result, e)
+		}
+	}
+	sort.SliceStable(result, func(i, j int) bool { return result[i].StartTime.After(result[j].StartTime) })
+	start := min((opts.Page-1)*opts.PageSize, len(result))
+	end := min(start+opts.PageSize, len(result))
+	return append([]Execution{}, result[start:end]...), nil
+}
 
-	for _, e := range c.executions {
+func (c *MockClient) RunLegacy(kind, name string, tags map[string]string) (*Execution, error) {
+	if _, err := legacyResource(kind); err != nil {
+		return nil, err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if !slices.ContainsFunc(c.tests, func(t Workflow) bool { return t.Kind == kind && t.Name == name }) {
+		return nil, fmt.Errorf("%s %s: %w", kind, name, ErrWorkflowNotFound)
+	}
+	exec := Execution{
+		ID:           fmt.Sprintf("legacy-run-%d", len(c.legacyRuns)+1),
+		Name:         fmt.Sprintf("%s-%d", name, len(c.legacyRuns)+1),
+		Kind:         kind,
+		WorkflowName: name,
+		Status:       "running",
+		StartTime:    time.Now(),
+		Labels:       tags,
+	}
+	exec.Trigger, exec.TriggeredBy = trigger(tags, nil)
+	c.legacyRuns = append(c.legacyRuns, exec)
+	if kind == KindTest {
+		c.logs[exec.ID] = []string{fmt.Sprintf("Running %s...", name)}
+	}
+	go c.finishLegacyRun(exec.ID)
+	return &exec, nil
+}
+
+// finishLegacyRun passes a Test or TestSuite run after a few seconds
+func (c *MockClient) finishLegacyRun(id string) {
+	time.Sleep(3 * time.Second)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for i, e := range c.legacyRuns {
 		if e.ID == id {
-			return &e, nil
+			c.legacyRuns[i].Status, c.legacyRuns[i].Outcome = "passed", OutcomePassed
+			c.legacyRuns[i].EndTime = time.Now()
+			c.legacyRuns[i].Duration = c.legacyRuns[i].EndTime.Sub(e.StartTime)
+			if logs, ok := c.logs[id]; ok {
+				c.logs[id] = append(logs, "Test passed.")
+			}
 		}
 	}
-	return nil, fmt.Errorf("execution not found")
 }
 
-func (c *MockClient) GetWorkflows() ([]Workflow, error) {
+func (c *MockClient) GetLegacyExecutionLogs(id string) (string, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	for _, e := range c.legacyRuns {
+		if e.ID == id {
+			return strings.Join(c.logs[id], "\n"), nil
+		}
+	}
+	return "", fmt.Errorf("execution not found")
+}
+
+func (c *MockClient) legacy(kind string, opts ListOptions) ([]Workflow, error) {
 	c.mu.RLock()
 	defer c.mu.RUnlock()
-	return c.workflows, nil
+	selector, err := ParseSelector(opts.Selector)
+	if err != nil {
+		return nil, err
+	}
+	var result []Workflow
+	for _, t := range c.tests {
+		if t.Kind == kind && selector.Matches(t.Labels) {
+			result = append(result, t)
+		}
+	}
+	return result, nil
+}
+
+func (c *MockClient) GetWorkflowTemplates(opts ListOptions) ([]WorkflowTemplate, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	selector, err := ParseSelector(opts.Selector)
+	if err != nil {
+		return nil, err
+	}
+	var result []WorkflowTemplate
+	for _, t := range c.templates {
+		if selector.Matches(t.Labels) {
+			result = append(result, t)
+		}
+	}
+	return result, nil
+}
+
+func (c *MockClient) GetWorkflowTemplate(name string) (*WorkflowTemplate, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	for _, t := range c.templates {
+		if t.Name == TemplateName(name) {
+			return &t, nil
+		}
+	}
+	return nil, fmt.Errorf("template %s: %w", name, ErrWorkflowTemplateNotFound)
 }
 
 func (c *MockClient) GetWorkflow(name string) (*Workflow, error) {
@@ -194,10 +560,93 @@ func (c *MockClient) GetWorkflow(name string) (*Workflow, error) {
 			return &wf, nil
 		}
 	}
-	return nil, fmt.Errorf("workflow not found: %s", name)
+	return nil, fmt.Errorf("workflow %s: %w", name, ErrWorkflowNotFound)
+}
+
+func (c *MockClient) GetWorkflowSpec(name string) ([]byte, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if spec, ok := c.specs[name]; ok {
+		return spec, nil
+	}
+	for _, wf := range c.workflows {
+		if wf.Name == name {
+			return mockWorkflowSpec(wf), nil
+		}
+	}
+	return nil, fmt.Errorf("workflow %s: %w", name, ErrWorkflowNotFound)
+}
+
+func (c *MockClient) CreateWorkflow(spec []byte) (*Workflow, error) {
+	wf, err := ParseWorkflowSpec(spec)
+	if err != nil {
+		return nil, err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, existing := range c.workflows {
+		if existing.Name == wf.Name {
+			return nil, fmt.Errorf("workflow %s: %w", wf.Name, ErrWorkflowExists)
+		}
+	}
+	if wf.Namespace == "" {
+		wf.Namespace = "testkube"
+	}
+	wf.Created = time.Now()
+	c.workflows = append(c.workflows, *wf)
+	c.specs[wf.Name] = spec
+	return wf, nil
+}
+
+func (c *MockClient) UpdateWorkflow(name string, spec []byte) (*Workflow, error) {
+	wf, err := ParseWorkflowSpec(spec)
+	if err != nil {
+		return nil, err
+	}
+	if wf.Name != name {
+		return nil, fmt.Errorf("%w: metadata.name %q doesn't match workflow %s", ErrInvalidWorkflowSpec, wf.Name, name)
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for i, existing := range c.workflows {
+		if existing.Name != name {
+			continue
+		}
+		// The definition changes; the run history doesn't
+		updated := existing
+		updated.Type, updated.Labels, updated.Templates = wf.Type, wf.Labels, wf.Templates
+		if wf.Namespace != "" {
+			updated.Namespace = wf.Namespace
+		}
+		c.workflows[i] = updated
+		c.specs[name] = spec
+		return &updated, nil
+	}
+	return nil, fmt.Errorf("workflow %s: %w", name, ErrWorkflowNotFound)
+}
+
+func (c *MockClient) GetWorkflowSchedules(name string) ([]WorkflowSchedule, error) {
+	return getSchedules(c, name)
+}
+
+func (c *MockClient) UpdateWorkflowSchedules(name string, schedules []WorkflowSchedule) error {
+	return updateSchedules(c, name, schedules)
+}
+
+func (c *MockClient) DeleteWorkflow(name string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for i, wf := range c.workflows {
+		if wf.Name == name {
+			c.workflows = slices.Delete(c.workflows, i, i+1)
+			delete(c.specs, name)
+			return nil
+		}
+	}
+	return fmt.Errorf("workflow %s: %w", name, ErrWorkflowNotFound)
 }
 
-func (c *MockClient) RunWorkflow(name string) (*Execution, error) {
+func (c *MockClient) RunWorkflow(name string, opts RunOptions) (*Execution, error) {
 	c.mu.Lock()
 	defer c.mu.Unlock()
 
@@ -210,7 +659,7 @@ func (c *MockClient) RunWorkflow(name string) (*Execution, error) {
 		}
 	}
 	if workflow == nil {
-		return nil, fmt.Errorf("workflow not found: %s", name)
+		return nil, fmt.Errorf("workflow %s: %w", name, ErrWorkflowNotFound)
 	}
 
 	// Create a new execution
@@ -221,14 +670,32 @@ func (c *MockClient) RunWorkflow(name string) (*Execution, error) {
 		WorkflowName: name,
 		Status:       "queued",
 		StartTime:    time.Now(),
-		Branch:       "main",
+		Branch:       cmp.Or(opts.Tags[BranchTag], "main"),
+		Commit:       opts.Tags[CommitTag],
+		Labels:       opts.Tags,
+	}
+	if opts.Name != "" {
+		exec.Name = opts.Name
 	}
+	// Runs reach Testkube through its API, like a pipeline's would
+	rc := &apiRunningContext{}
+	rc.Interface.Type = "api"
+	exec.Trigger, exec.TriggeredBy = trigger(opts.Tags, rc)
 
 	// Prepend to executions (so it appears first)
 	c.executions = append([]Execution{*exec}, c.executions...)
+	c.inputs[newID] = RunOptions{Config: maps.Clone(opts.Config), Tags: maps.Clone(opts.Tags), Target: opts.Target}
 
 	// Initialize logs
 	c.logs[newID] = []string{"Job queued..."}
+	keys := make([]string, 0, len(opts.Config))
+	for key := range opts.Config {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		c.logs[newID] = append(c.logs[newID], fmt.Sprintf("Config %s=%s", key, opts.Config[key]))
+	}
 
 	// Start background simulation
 	go c.simulateExecution(newID)
@@ -236,6 +703,66 @@ func (c *MockClient) RunWorkflow(name string) (*Execution, error) {
 	return exec, nil
 }
 
+// mockWorkflowSteps are the steps of every simulated run, with the share
+// of its time each takes
+var mockWorkflowSteps = []struct {
+	ref, name, log string
+	share          float64
+}{
+	{"clone", "Clone repository", "Cloning git repository...", 0.1},
+	{"cache", "Restore cache", "Restoring cache...", 0.1},
+	{"install", "Install dependencies", "Installing npm dependencies...", 0.2},
+	{"test", "Run tests", "Running tests...", 0.6},
+}
+
+// mockSteps describes an execution's steps: those a simulated run
+// finished, the one it's on and those it has yet to run, or for generated
+// history, its duration shared out with the last step deciding the status
+func mockSteps(exec Execution, simulated bool, finished []ExecutionNotification) []StepResult {
+	done := make(map[string]ExecutionNotification, len(finished))
+	for _, n := range finished {
+		done[n.Step] = n
+	}
+	history := !simulated && !slices.Contains(activeExecutionStatuses, exec.Status)
+
+	steps := make([]StepResult, len(mockWorkflowSteps))
+	start := exec.StartTime
+	current := false // whether a step is under way, or was when aborted
+	for i, s := range mockWorkflowSteps {
+		step := StepResult{Ref: s.ref, Name: s.name, Status: "queued"}
+		n, ok := done[s.ref]
+		switch {
+		case ok:
+			step.Status, step.StartTime, step.EndTime = n.Status, start, n.Time
+		case history:
+			step.Status, step.StartTime = "passed", start
+			step.EndTime = start.Add(time.Duration(float64(exec.Duration) * s.share))
+			if i == len(mockWorkflowSteps)-1 && exec.Status != "passed" {
+				step.Status = exec.Status
+				if exec.Status == "failed" {
+					step.Error = "process exited with code 1: 2 tests failed"
+				}
+			}
+		case current:
+			if exec.Status == "aborted" {
+				step.Status = "skipped"
+			}
+		case exec.Status == "running":
+			step.Status, step.StartTime, current = "running", start, true
+		case exec.Status == "aborted":
+			step.Status, step.StartTime, step.EndTime, current = "aborted", start, exec.EndTime, true
+		}
+		if !step.StartTime.IsZero() && !step.EndTime.IsZero() {
+			step.Duration = step.EndTime.Sub(step.StartTime)
+			start = step.EndTime
+		}
+		steps[i] = step
+	}
+	return steps
+}
+
+// simulateExecution plays out a run. An aborted run's simulation carries on,
+// but updateStatus and appendLog leave it alone.
 func (c *MockClient) simulateExecution(id string) {
 	// Simulate Queued -> Running
 	time.Sleep(2 * time.Second)
@@ -243,17 +770,13 @@ func (c *MockClient) simulateExecution(id string) {
 	c.appendLog(id, "Job started.")
 	c.appendLog(id, "Pulling container image...")
 
-	// Simulate Running steps
-	steps := []string{
-		"Cloning git repository...",
-		"Restoring cache...",
-		"Installing npm dependencies...",
-		"Running tests...",
-	}
-
-	for _, step := range steps {
+	// Simulate Running steps, each finishing as the next begins
+	for i, step := range mockWorkflowSteps {
 		time.Sleep(2 * time.Second)
-		c.appendLog(id, step)
+		if i > 0 {
+			c.finishStep(id, mockWorkflowSteps[i-1].ref, "passed")
+		}
+		c.appendLog(id, step.log)
 	}
 
 	// Determine pass/fail (randomly, mostly pass)
@@ -265,6 +788,7 @@ func (c *MockClient) simulateExecution(id string) {
 	} else {
 		c.appendLog(id, "Success: All tests passed.")
 	}
+	c.finishStep(id, mockWorkflowSteps[len(mockWorkflowSteps)-1].ref, finalStatus)
 
 	time.Sleep(1 * time.Second)
 	c.appendLog(id, "Uploading artifacts...")
@@ -274,11 +798,33 @@ func (c *MockClient) simulateExecution(id string) {
 	c.updateStatus(id, finalStatus)
 }
 
+func (c *MockClient) AbortExecution(id string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for i, e := range c.executions {
+		if e.ID != id {
+			continue
+		}
+		if e.Status != "queued" && e.Status != "running" {
+			return ErrExecutionFinished
+		}
+		c.executions[i].Status = "aborted"
+		c.executions[i].EndTime = time.Now()
+		c.executions[i].Duration = c.executions[i].EndTime.Sub(e.StartTime)
+		c.logs[id] = append(c.logs[id], fmt.Sprintf("[%s] Execution aborted.", time.Now().Format("15:04:05")))
+		return nil
+	}
+	return fmt.Errorf("execution not found")
+}
+
 func (c *MockClient) updateStatus(id, status string) {
 	c.mu.Lock()
 	defer c.mu.Unlock()
 	for i, e := range c.executions {
 		if e.ID == id {
+			if e.Status == "aborted" {
+				return
+			}
 			c.executions[i].Status = status
 			if status == "passed" || status == "failed" {
 				c.executions[i].EndTime = time.Now()
@@ -292,46 +838,164 @@ func (c *MockClient) updateStatus(id, status string) {
 func (c *MockClient) appendLog(id, line string) {
 	c.mu.Lock()
 	defer c.mu.Unlock()
+	for _, e := range c.executions {
+		if e.ID == id && e.Status == "aborted" {
+			return
+		}
+	}
 	timestamp := time.Now().Format("15:04:05")
 	c.logs[id] = append(c.logs[id], fmt.Sprintf("[%s] %s", timestamp, line))
 }
 
-func (c *MockClient) GetArtifacts(executionID string) ([]Artifact, error) {
-	// Only return artifacts if finished (simple check)
+// finishStep records a simulated step finishing, unless its execution was
+// aborted
+func (c *MockClient) finishStep(id, ref, status string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, e := range c.executions {
+		if e.ID != id {
+			continue
+		}
+		if e.Status == "aborted" {
+			return
+		}
+		c.steps[id] = append(c.steps[id], ExecutionNotification{
+			Time: time.Now(), Kind: NotificationStepFinished, ExecutionID: id, Workflow: e.WorkflowName, Step: ref, Status: status,
+		})
+		return
+	}
+}
+
+// executionOutcome returns the type of an execution's workflow and its
+// status, which decide the artifacts the mock simulates for it
+func (c *MockClient) executionOutcome(executionID string) (workflowType, status string) {
 	c.mu.RLock()
-	var status string
+	defer c.mu.RUnlock()
 	for _, e := range c.executions {
-		if e.ID == executionID {
-			status = e.Status
-			break
+		if e.ID != executionID {
+			continue
+		}
+		for _, wf := range c.workflows {
+			if wf.Name == e.WorkflowName {
+				return wf.Type, e.Status
+			}
 		}
+		return "", e.Status
 	}
-	c.mu.RUnlock()
+	return "", ""
+}
 
+func (c *MockClient) GetExecutionResources(executionID string) (*ResourceUsage, error) {
+	exec, err := c.GetExecution(executionID)
+	if err != nil {
+		return nil, err
+	}
+	return mockResourceUsage(exec), nil
+}
+
+// GetExecutionInputs returns what a run started through the mock was given.
+// Generated executions ran with their workflow's defaults and their tags.
+func (c *MockClient) GetExecutionInputs(executionID string) (*RunOptions, error) {
+	exec, err := c.GetExecution(executionID)
+	if err != nil {
+		return nil, err
+	}
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if opts, ok := c.inputs[executionID]; ok {
+		return &RunOptions{Config: maps.Clone(opts.Config), Tags: maps.Clone(opts.Tags), Target: opts.Target}, nil
+	}
+	return &RunOptions{Tags: maps.Clone(exec.Labels)}, nil
+}
+
+// mockResourceUsage makes up steady usage per execution, with one in four
+// failed runs killed for running out of memory
+func mockResourceUsage(exec *Execution) *ResourceUsage {
+	usage := &ResourceUsage{ExecutionID: exec.ID, Namespace: "testkube", Runner: "mock-agent"}
+	if exec.Status == "queued" {
+		return usage
+	}
+	h := fnv.New32a()
+	h.Write([]byte(exec.ID))
+	seed := h.Sum32()
+	cpu := float64(200 + seed%800)
+	memory := float64(256+seed%768) * 1024 * 1024
+	usage.CPUMillicores = ResourceStats{Avg: cpu * 0.6, Max: cpu}
+	usage.MemoryBytes = ResourceStats{Avg: memory * 0.7, Max: memory}
+	usage.Steps = []StepResourceUsage{
+		{Ref: "setup", Name: "Set up", CPUMillicores: ResourceStats{Avg: 50, Max: 120}, MemoryBytes: ResourceStats{Avg: 64 << 20, Max: 96 << 20}},
+		{Ref: "run", Name: "Run tests", CPUMillicores: usage.CPUMillicores, MemoryBytes: usage.MemoryBytes},
+	}
+	if exec.Status == "failed" && seed%4 == 0 {
+		usage.Steps[1].OOMKilled = true
+		usage.Steps[1].Error = "the container was OOMKilled"
+	}
+	return usage
+}
+
+func (c *MockClient) GetArtifacts(executionID string) ([]Artifact, error) {
+	// Only return artifacts if finished (simple check)
+	workflowType, status := c.executionOutcome(executionID)
 	if status != "passed" && status != "failed" {
 		return []Artifact{}, nil
 	}
-
-	return []Artifact{
-		{Name: "playwright-report.zip", Size: 1024 * 1024, Path: "playwright-report.zip"},
-		{Name: "results.json", Size: 1024, Path: "results.json"},
-		{Name: "screenshot.png", Size: 512 * 1024, Path: "screenshot.png"},
-	}, nil
+	return slices.Clone(mockArtifacts(workflowType, status)), nil
 }
 
 func (c *MockClient) DownloadArtifact(executionID, path string) ([]byte, error) {
+	workflowType, status := c.executionOutcome(executionID)
+	if data, ok := mockArtifactContent(executionID, workflowType, status, path); ok {
+		return data, nil
+	}
 	if strings.HasSuffix(path, ".json") {
 		return []byte(`{"metrics": {"http_req_duration": {"type": "trend", "values": {"min": 50, "max": 200, "avg": 120, "p(95)": 180, "p(99)": 195}}}}`), nil
 	}
 	if strings.HasSuffix(path, ".html") {
 		return []byte(`<html><body><h1>Mock Report</h1><p>This is a simulated report for execution ` + executionID + `</p></body></html>`), nil
 	}
+	if strings.HasSuffix(path, ".png") {
+		return mockScreenshot(executionID), nil
+	}
 	if strings.HasSuffix(path, ".xml") { // JUnit
 		return []byte(`<testsuites><testsuite name="mock" tests="1" failures="0"><testcase name="mock_test" time="0.1"/></testsuite></testsuites>`), nil
 	}
 	return []byte("mock artifact content"), nil
 }
 
+func (c *MockClient) DownloadArtifactStream(executionID, path string) (io.ReadCloser, error) {
+	data, err := c.DownloadArtifact(executionID, path)
+	if err != nil {
+		return nil, err
+	}
+	return io.NopCloser(bytes.NewReader(data)), nil
+}
+
+// mockScreenshot draws a small page with a button whose position shifts
+// between executions, so screenshot diffs have something to show
+func mockScreenshot(executionID string) []byte {
+	h := fnv.New32a()
+	h.Write([]byte(executionID))
+	offset := int(h.Sum32()%3) * 6
+
+	img := image.NewRGBA(image.Rect(0, 0, 160, 100))
+	for y := 0; y < 100; y++ {
+		for x := 0; x < 160; x++ {
+			c := color.RGBA{R: 255, G: 255, B: 255, A: 255}
+			switch {
+			case y < 16:
+				c = color.RGBA{R: 51, G: 51, B: 51, A: 255} // header
+			case x >= 40+offset && x < 120+offset && y >= 60 && y < 80:
+				c = color.RGBA{R: 0, G: 123, B: 255, A: 255} // button
+			}
+			img.Set(x, y, c)
+		}
+	}
+
+	var buf bytes.Buffer
+	png.Encode(&buf, img)
+	return buf.Bytes()
+}
+
 func (c *MockClient) GetExecutionLogs(executionID string) (string, error) {
 	c.mu.RLock()
 	defer c.mu.RUnlock()
@@ -341,81 +1005,131 @@ func (c *MockClient) GetExecutionLogs(executionID string) (string, error) {
 	return "", fmt.Errorf("logs not found")
 }
 
-func (c *MockClient) StreamExecutionLogs(ctx context.Context, executionID string) (<-chan string, <-chan error) {
-	logsCh := make(chan string)
-	errCh := make(chan error)
-
-	go func() {
-		defer close(logsCh)
-		defer close(errCh)
+func (c *MockClient) OpenExecutionLogs(executionID string) (io.ReadCloser, error) {
+	logs, err := c.GetExecutionLogs(executionID)
+	if err != nil {
+		return nil, err
+	}
+	return io.NopCloser(strings.NewReader(logs)), nil
+}
 
-		// Send existing logs
+func (c *MockClient) StreamExecutionLogs(ctx context.Context, executionID string) (<-chan LogLine, error) {
+	// logs returns the lines so far and whether the execution has finished
+	logs := func() ([]string, bool) {
 		c.mu.RLock()
-		existingLogs := c.logs[executionID]
-		status := "unknown"
+		defer c.mu.RUnlock()
 		for _, e := range c.executions {
 			if e.ID == executionID {
-				status = e.Status
-				break
+				return c.logs[executionID], e.Status == "passed" || e.Status == "failed" || e.Status == "aborted"
 			}
 		}
-		c.mu.RUnlock()
+		return c.logs[executionID], true
+	}
+	if _, err := c.GetExecution(executionID); err != nil {
+		return nil, err
+	}
 
-		for _, logLine := range existingLogs {
+	lines := make(chan LogLine)
+	go func() {
+		defer close(lines)
+
+		// Send the lines written so far, then poll for more while the
+		// simulated execution runs
+		sent := 0
+		ticker := time.NewTicker(500 * time.Millisecond)
+		defer ticker.Stop()
+		for {
+			current, finished := logs()
+			for ; sent < len(current); sent++ {
+				select {
+				case <-ctx.Done():
+					return
+				case lines <- LogLine{Text: current[sent]}:
+				}
+			}
+			if finished {
+				return
+			}
 			select {
 			case <-ctx.Done():
 				return
-			case logsCh <- logLine:
+			case <-ticker.C:
 			}
 		}
+	}()
 
-		if status == "passed" || status == "failed" {
-			return
-		}
+	return lines, nil
+}
 
-		// Simulate streaming new logs if running
-		// Since in mock mode the simulation runs in background and updates c.logs,
-		// we need to poll for changes or just simulate delays here.
-		// For simplicity, we just assume the simulation is fast enough or we just replay.
-		// A better approach would be to subscribe to updates, but for mock polling is fine.
+// WatchExecutions follows the simulated executions as runs start and finish
+func (c *MockClient) WatchExecutions(ctx context.Context) (<-chan ExecutionEvent, error) {
+	return c.watch.Watch(ctx, mockWatchInterval, func() ([]Execution, error) {
+		return c.GetExecutions(ListOptions{PageSize: watchPageSize})
+	})
+}
 
-		lastIndex := len(existingLogs)
-		ticker := time.NewTicker(500 * time.Millisecond)
-		defer ticker.Stop()
+// WatchNotifications follows the simulated executions as runs are queued,
+// start and finish, and as their steps finish
+func (c *MockClient) WatchNotifications(ctx context.Context) (<-chan ExecutionNotification, error) {
+	events, err := c.WatchExecutions(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return watchNotifications(ctx, events, c.followSteps), nil
+}
 
+// followSteps polls a simulated execution for its steps finishing
+func (c *MockClient) followSteps(ctx context.Context, exec Execution) (<-chan ExecutionNotification, error) {
+	// finished returns the steps finished so far and whether the execution
+	// has
+	finished := func() ([]ExecutionNotification, bool) {
+		c.mu.RLock()
+		defer c.mu.RUnlock()
+		for _, e := range c.executions {
+			if e.ID == exec.ID {
+				return c.steps[exec.ID], e.Status != "queued" && e.Status != "running"
+			}
+		}
+		return c.steps[exec.ID], true
+	}
+
+	steps := make(chan ExecutionNotification)
+	go func() {
+		defer close(steps)
+		sent := 0
+		ticker := time.NewTicker(mockWatchInterval)
+		defer ticker.Stop()
 		for {
+			current, done := finished()
+			for ; sent < len(current); sent++ {
+				select {
+				case <-ctx.Done():
+					return
+				case steps <- current[sent]:
+				}
+			}
+			if done {
+				return
+			}
 			select {
 			case <-ctx.Done():
 				return
 			case <-ticker.C:
-				c.mu.RLock()
-				currentLogs := c.logs[executionID]
-				currentStatus := ""
-				for _, e := range c.executions {
-					if e.ID == executionID {
-						currentStatus = e.Status
-						break
-					}
-				}
-				c.mu.RUnlock()
-
-				if len(currentLogs) > lastIndex {
-					for i := lastIndex; i < len(currentLogs); i++ {
-						select {
-						case <-ctx.Done():
-							return
-						case logsCh <- currentLogs[i]:
-						}
-					}
-					lastIndex = len(currentLogs)
-				}
-
-				if currentStatus == "passed" || currentStatus == "failed" {
-					return
-				}
 			}
 		}
 	}()
+	return steps, nil
+}
 
-	return logsCh, errCh
+// GetServerInfo describes a standalone agent, as the simulated data has
+// no control plane
+func (c *MockClient) GetServerInfo() (*ServerInfo, error) {
+	return &ServerInfo{
+		APIURL:         "mock://testkube-api-server/v1",
+		Version:        "2.1.0-mock",
+		Namespace:      "testkube",
+		AgentMode:      AgentStandalone,
+		ResponseShapes: "mock",
+		Features:       map[string]bool{"logsV2": false, "secretEndpoint": true},
+	}, nil
 }
@@ -0,0 +1,116 @@
+package testkube
+
+import (
+	"testing"
+	"time"
+)
+
+func TestMockClient_StartedRange(t *testing.T) {
+	c := NewMockClient()
+	now := time.Now()
+	opts := ListOptions{PageSize: 500, StartedAfter: now.Add(-24 * time.Hour), StartedBefore: now.Add(-2 * time.Hour)}
+
+	page, err := c.GetExecutionPage(opts)
+	if err != nil {
+		t.Fatalf("GetExecutionPage failed: %v", err)
+	}
+	if len(page.Executions) == 0 || page.Total != len(page.Executions) {
+		t.Fatalf("got %d executions of %d, expected some in the range", len(page.Executions), page.Total)
+	}
+	for _, e := range page.Executions {
+		if e.StartTime.Before(opts.StartedAfter) || !e.StartTime.Before(opts.StartedBefore) {
+			t.Errorf("got %s started %v, expected it between %v and %v", e.ID, e.StartTime, opts.StartedAfter, opts.StartedBefore)
+		}
+	}
+
+	all, err := c.GetExecutionPage(ListOptions{PageSize: 500})
+	if err != nil || all.Total <= page.Total {
+		t.Errorf("got %d executions without a range, expected more than %d", all.Total, page.Total)
+	}
+}
+
+func TestMockClient_BranchAndCommit(t *testing.T) {
+	c := NewMockClient()
+	branch := mockFeatureBranches[0]
+
+	page, err := c.GetExecutionPage(ListOptions{PageSize: 500, Branch: branch})
+	if err != nil {
+		t.Fatalf("GetExecutionPage failed: %v", err)
+	}
+	if len(page.Executions) == 0 {
+		t.Fatalf("got no executions on %s, expected some", branch)
+	}
+	for _, e := range page.Executions {
+		if e.Branch != branch || e.Labels[BranchTag] != branch {
+			t.Errorf("got %s on %q, expected %s", e.ID, e.Branch, branch)
+		}
+	}
+
+	commit := page.Executions[0].Commit
+	page, err = c.GetExecutionPage(ListOptions{PageSize: 500, Branch: branch, Commit: commit})
+	if err != nil || len(page.Executions) != 1 || page.Executions[0].Commit != commit {
+		t.Errorf("got %v (%v), expected the one execution at %s", page, err, commit)
+	}
+	page, err = c.GetExecutionPage(ListOptions{PageSize: 500, Branch: "main", Commit: commit})
+	if err != nil || len(page.Executions) != 0 {
+		t.Errorf("got %v (%v), expected no execution at %s on main", page, err, commit)
+	}
+}
+
+func TestMockClient_GetExecutionResources(t *testing.T) {
+	c := NewMockClient()
+	executions, _ := c.GetExecutions(ListOptions{})
+	oomKilled := false
+	for _, exec := range executions {
+		usage, err := c.GetExecutionResources(exec.ID)
+		if err != nil {
+			t.Fatalf("GetExecutionResources(%s) failed: %v", exec.ID, err)
+		}
+		if exec.Status != "queued" && !usage.Measured() {
+			t.Errorf("got no usage for %s execution %s", exec.Status, exec.ID)
+		}
+		if usage.OOMKilled() && exec.Status != "failed" {
+			t.Errorf("got an OOM kill in %s execution %s, expected only failed ones", exec.Status, exec.ID)
+		}
+		oomKilled = oomKilled || usage.OOMKilled()
+	}
+	if !oomKilled {
+		t.Error("expected some failed executions to be OOMKilled")
+	}
+	if _, err := c.GetExecutionResources("missing"); err == nil {
+		t.Error("expected an error for a missing execution")
+	}
+}
+
+func TestMockSteps(t *testing.T) {
+	start := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+
+	failed := mockSteps(Execution{Status: "failed", StartTime: start, Duration: 100 * time.Second}, false, nil)
+	if len(failed) != len(mockWorkflowSteps) {
+		t.Fatalf("got %d steps, expected %d", len(failed), len(mockWorkflowSteps))
+	}
+	last := failed[len(failed)-1]
+	if failed[0].Status != "passed" || last.Status != "failed" || last.Error == "" || last.Duration != 60*time.Second {
+		t.Errorf("got %+v, expected the last step to fail after the others passed", failed)
+	}
+
+	// A simulated run that has finished cloning is installing
+	running := mockSteps(Execution{Status: "running", StartTime: start}, true, []ExecutionNotification{
+		{Step: "clone", Status: "passed", Time: start.Add(2 * time.Second)},
+	})
+	var statuses []string
+	for _, step := range running {
+		statuses = append(statuses, step.Status)
+	}
+	expected := []string{"passed", "running", "queued", "queued"}
+	for i := range expected {
+		if statuses[i] != expected[i] {
+			t.Fatalf("got %v, expected %v", statuses, expected)
+		}
+	}
+
+	aborted := mockSteps(Execution{Status: "aborted", StartTime: start, EndTime: start.Add(time.Second)}, true, nil)
+	if aborted[0].Status != "aborted" || aborted[1].Status != "skipped" {
+		t.Errorf("got %+v, expected the first step aborted and the rest skipped", aborted)
+	}
+}
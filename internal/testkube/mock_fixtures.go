@@ -0,0 +1,198 @@
+package testkube
+
+import (
+	"bytes"
+	"cmp"
+	"fmt"
+	"log"
+	"os"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// mockFixturesEnv names the JSON or YAML file NewMockClient loads its
+// workflows, executions and logs from, in place of the generated ones
+const mockFixturesEnv = "MOCK_FIXTURES_FILE"
+
+// mockFixtures is a fixture file's dataset. JSON is read as YAML, which it
+// is a subset of.
+type mockFixtures struct {
+	Workflows  []workflowFixture  `yaml:"workflows"`
+	Executions []executionFixture `yaml:"executions"`
+}
+
+type workflowFixture struct {
+	Name      string            `yaml:"name"`
+	Namespace string            `yaml:"namespace"` // "testkube" when empty
+	Type      string            `yaml:"type"`      // CustomType when empty
+	Labels    map[string]string `yaml:"labels"`
+	Templates []string          `yaml:"templates"`
+	Created   fixtureTime       `yaml:"created"`
+}
+
+type executionFixture struct {
+	ID          string            `yaml:"id"`
+	Name        string            `yaml:"name"` // the ID when empty
+	Workflow    string            `yaml:"workflow"`
+	Status      string            `yaml:"status"`
+	Outcome     string            `yaml:"outcome"` // classified from the status when empty
+	Started     fixtureTime       `yaml:"started"`
+	Duration    time.Duration     `yaml:"duration"`
+	Branch      string            `yaml:"branch"`
+	Commit      string            `yaml:"commit"`
+	Trigger     string            `yaml:"trigger"`
+	TriggeredBy string            `yaml:"triggeredBy"`
+	Labels      map[string]string `yaml:"labels"`
+	Logs        []string          `yaml:"logs"`
+}
+
+// fixtureTime is an RFC 3339 time, or how long before the fixtures were
+// loaded, e.g. "36h", so a fixture's history stays recent however old the
+// file is
+type fixtureTime struct {
+	time.Time
+}
+
+func (t *fixtureTime) UnmarshalYAML(node *yaml.Node) error {
+	if at, err := time.Parse(time.RFC3339, node.Value); err == nil {
+		t.Time = at
+		return nil
+	}
+	ago, err := time.ParseDuration(node.Value)
+	if err != nil || ago < 0 {
+		return fmt.Errorf("line %d: %q is neither an RFC 3339 time nor a duration ago", node.Line, node.Value)
+	}
+	t.Time = time.Now().Add(-ago)
+	return nil
+}
+
+// LoadMockClient creates a mock client holding the workflows, executions
+// and logs of a JSON or YAML fixture file, instead of the generated ones.
+// Runs started on it are simulated as usual.
+func LoadMockClient(file string) (*MockClient, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read mock fixtures: %w", err)
+	}
+	var fixtures mockFixtures
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(&fixtures); err != nil {
+		return nil, fmt.Errorf("invalid mock fixtures in %s: %w", file, err)
+	}
+
+	c := newMockClient()
+	if err := c.loadFixtures(fixtures); err != nil {
+		return nil, fmt.Errorf("invalid mock fixtures in %s: %w", file, err)
+	}
+	return c, nil
+}
+
+// mockClientFromEnv loads the fixtures in MOCK_FIXTURES_FILE, nil when it
+// isn't set or can't be loaded
+func mockClientFromEnv() *MockClient {
+	file := os.Getenv(mockFixturesEnv)
+	if file == "" {
+		return nil
+	}
+	c, err := LoadMockClient(file)
+	if err != nil {
+		log.Printf("Warning: %v; using the generated mock data", err)
+		return nil
+	}
+	return c
+}
+
+func (c *MockClient) loadFixtures(fixtures mockFixtures) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	workflows := make(map[string]int, len(fixtures.Workflows))
+	for _, f := range fixtures.Workflows {
+		if f.Name == "" {
+			return fmt.Errorf("a workflow has no name")
+		}
+		if _, ok := workflows[f.Name]; ok {
+			return fmt.Errorf("workflow %s is listed twice", f.Name)
+		}
+		workflows[f.Name] = len(c.workflows)
+		c.workflows = append(c.workflows, Workflow{
+			Name:      f.Name,
+			Namespace: cmp.Or(f.Namespace, "testkube"),
+			Type:      cmp.Or(f.Type, CustomType),
+			Labels:    f.Labels,
+			Templates: f.Templates,
+			Created:   f.Created.Time,
+		})
+	}
+
+	ids := make(map[string]bool, len(fixtures.Executions))
+	for _, f := range fixtures.Executions {
+		if f.ID == "" || f.Status == "" {
+			return fmt.Errorf("executions need an id and a status")
+		}
+		if ids[f.ID] {
+			return fmt.Errorf("execution %s is listed twice", f.ID)
+		}
+		ids[f.ID] = true
+		if _, ok := workflows[f.Workflow]; !ok {
+			return fmt.Errorf("execution %s is of unknown workflow %q", f.ID, f.Workflow)
+		}
+		if f.Started.IsZero() {
+			return fmt.Errorf("execution %s has no start time", f.ID)
+		}
+
+		exec := Execution{
+			ID:           f.ID,
+			Name:         cmp.Or(f.Name, f.ID),
+			WorkflowName: f.Workflow,
+			Status:       f.Status,
+			StartTime:    f.Started.Time,
+			Duration:     f.Duration,
+			Branch:       f.Branch,
+			Commit:       f.Commit,
+			Labels:       f.Labels,
+			Trigger:      f.Trigger,
+			TriggeredBy:  f.TriggeredBy,
+			Outcome:      cmp.Or(f.Outcome, ClassifyOutcome(f.Status)),
+		}
+		if !slices.Contains(activeExecutionStatuses, strings.ToLower(f.Status)) {
+			exec.EndTime = exec.StartTime.Add(exec.Duration)
+		}
+		c.executions = append(c.executions, exec)
+		if len(f.Logs) > 0 {
+			c.logs[f.ID] = f.Logs
+		}
+	}
+
+	// Keep newest first, matching the Testkube API ordering
+	sort.SliceStable(c.executions, func(i, j int) bool {
+		return c.executions[i].StartTime.After(c.executions[j].StartTime)
+	})
+
+	// Workflows' last runs and pass rates follow from their executions
+	weekAgo := time.Now().AddDate(0, 0, -7)
+	counted := make(map[string][2]int) // passed, counted
+	for _, e := range c.executions {
+		wf := &c.workflows[workflows[e.WorkflowName]]
+		if wf.LastRun.IsZero() {
+			wf.LastRun, wf.LastStatus = e.StartTime, e.Status
+		}
+		if outcome := e.RunOutcome(); CountsTowardsPassRate(outcome) && !e.StartTime.Before(weekAgo) {
+			n := counted[e.WorkflowName]
+			if outcome == OutcomePassed {
+				n[0]++
+			}
+			n[1]++
+			counted[e.WorkflowName] = n
+		}
+	}
+	for name, n := range counted {
+		c.workflows[workflows[name]].PassRateLast7d = n[0] * 100 / n[1]
+	}
+	return nil
+}
@@ -0,0 +1,104 @@
+package testkube
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+const yamlFixtures = `
+workflows:
+  - name: checkout-e2e
+    type: playwright
+    labels: {team: payments}
+  - name: ledger-api
+executions:
+  - id: checkout-1
+    workflow: checkout-e2e
+    status: failed
+    started: 2h
+    duration: 4m
+    branch: feature/wallets
+    logs: ["Running 42 tests...", "1 failed"]
+  - id: checkout-0
+    workflow: checkout-e2e
+    status: passed
+    started: 26h
+    duration: 3m
+  - id: ledger-0
+    workflow: ledger-api
+    status: running
+    started: 2024-06-01T09:00:00Z
+`
+
+func writeFixtures(t *testing.T, name, content string) string {
+	t.Helper()
+	file := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(file, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return file
+}
+
+func TestLoadMockClient(t *testing.T) {
+	c, err := LoadMockClient(writeFixtures(t, "fixtures.yaml", yamlFixtures))
+	if err != nil {
+		t.Fatalf("LoadMockClient failed: %v", err)
+	}
+
+	workflows, _ := c.GetWorkflows(ListOptions{Selector: "team=payments"})
+	if len(workflows) != 1 || workflows[0].Name != "checkout-e2e" || workflows[0].Namespace != "testkube" {
+		t.Fatalf("got %v, expected checkout-e2e in the testkube namespace", workflows)
+	}
+	if wf := workflows[0]; wf.LastStatus != "failed" || wf.PassRateLast7d != 50 {
+		t.Errorf("got last status %s and pass rate %d, expected failed and 50", wf.LastStatus, wf.PassRateLast7d)
+	}
+
+	executions, _ := c.GetExecutions(ListOptions{})
+	if len(executions) != 3 || executions[0].ID != "checkout-1" {
+		t.Fatalf("got %v, expected the 3 executions newest first", executions)
+	}
+	exec := executions[0]
+	if exec.Duration != 4*time.Minute || !exec.EndTime.Equal(exec.StartTime.Add(4*time.Minute)) || exec.Outcome != OutcomeFailed {
+		t.Errorf("got %v, expected a failed run of 4m", exec)
+	}
+	if ago := time.Since(exec.StartTime); ago < 2*time.Hour || ago > 2*time.Hour+time.Minute {
+		t.Errorf("got started %v ago, expected 2h", ago)
+	}
+	if logs, _ := c.GetExecutionLogs("checkout-1"); !strings.Contains(logs, "1 failed") {
+		t.Errorf("got logs %q, expected the fixture's", logs)
+	}
+	if running := executions[2]; !running.EndTime.IsZero() || running.StartTime.Year() != 2024 {
+		t.Errorf("got %v, expected an unfinished run started in 2024", running)
+	}
+
+	// JSON fixtures read the same
+	c, err = LoadMockClient(writeFixtures(t, "fixtures.json", `{"workflows": [{"name": "smoke"}], "executions": [{"id": "s-1", "workflow": "smoke", "status": "passed", "started": "1h", "duration": "30s"}]}`))
+	if err != nil {
+		t.Fatalf("LoadMockClient failed: %v", err)
+	}
+	if exec, err := c.GetExecution("s-1"); err != nil || exec.Duration != 30*time.Second {
+		t.Errorf("got %v (%v), expected s-1 of 30s", exec, err)
+	}
+}
+
+func TestLoadMockClient_Invalid(t *testing.T) {
+	for name, content := range map[string]string{
+		"unknown workflow": "executions: [{id: a, workflow: missing, status: passed, started: 1h}]",
+		"unknown field":    "workflows: [{name: a, team: payments}]",
+		"bad time":         "workflows: [{name: a}]\nexecutions: [{id: a, workflow: a, status: passed, started: yesterday}]",
+		"duplicate":        "workflows: [{name: a}, {name: a}]",
+	} {
+		if _, err := LoadMockClient(writeFixtures(t, "fixtures.yaml", content)); err == nil {
+			t.Errorf("%s: got no error, expected the fixtures rejected", name)
+		}
+	}
+
+	// The generated data stands in for fixtures that can't be loaded
+	t.Setenv(mockFixturesEnv, filepath.Join(t.TempDir(), "missing.yaml"))
+	if workflows, _ := NewMockClient().GetWorkflows(ListOptions{}); len(workflows) == 0 {
+		t.Error("got no workflows, expected the generated ones")
+	}
+}
@@ -0,0 +1,216 @@
+package testkube
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"sync"
+	"time"
+)
+
+// Kinds of ExecutionNotification
+const (
+	NotificationQueued       = "queued"
+	NotificationStarted      = "started"
+	NotificationStepFinished = "step-finished"
+	NotificationFinished     = "finished"
+)
+
+// finishedStepStatuses are the states of steps that have ended
+var finishedStepStatuses = []string{"passed", "failed", "skipped", "aborted", "timeout"}
+
+// ExecutionNotification is something that happened to an execution: it was
+// queued, started, finished, or one of its steps finished
+type ExecutionNotification struct {
+	Time        time.Time `json:"time"`
+	Kind        string    `json:"kind"`
+	ExecutionID string    `json:"executionId"`
+	Workflow    string    `json:"workflow"`
+	// Step is the reference of the step a step-finished notification is
+	// about
+	Step string `json:"step,omitempty"`
+	// Status is the execution's status, or the step's
+	Status string `json:"status"`
+}
+
+// notificationsOf turns a status change into the notifications it stands
+// for. An execution first seen running was queued unnoticed, and one first
+// seen finished ran unnoticed.
+func notificationsOf(event ExecutionEvent) []ExecutionNotification {
+	exec := event.Execution
+	notification := func(kind string, at time.Time) ExecutionNotification {
+		if at.IsZero() {
+			at = time.Now()
+		}
+		return ExecutionNotification{Time: at, Kind: kind, ExecutionID: exec.ID, Workflow: exec.WorkflowName, Status: exec.Status}
+	}
+	switch {
+	case event.Finished():
+		return []ExecutionNotification{notification(NotificationFinished, exec.EndTime)}
+	case exec.Status == "queued" && event.Previous == "":
+		return []ExecutionNotification{notification(NotificationQueued, exec.StartTime)}
+	case exec.Status == "running" && (event.Previous == "" || event.Previous == "queued"):
+		return []ExecutionNotification{notification(NotificationStarted, exec.StartTime)}
+	}
+	return nil
+}
+
+// stepFollower streams the step-finished notifications of a running
+// execution until it ends or ctx is done
+type stepFollower func(ctx context.Context, exec Execution) (<-chan ExecutionNotification, error)
+
+// watchNotifications sends the notifications of a watch's status changes,
+// following each execution that starts for its steps. The channel closes
+// once the watch and every execution followed have ended.
+func watchNotifications(ctx context.Context, events <-chan ExecutionEvent, follow stepFollower) <-chan ExecutionNotification {
+	out := make(chan ExecutionNotification)
+	send := func(n ExecutionNotification) bool {
+		select {
+		case <-ctx.Done():
+			return false
+		case out <- n:
+			return true
+		}
+	}
+
+	var followers sync.WaitGroup
+	go func() {
+		defer func() {
+			followers.Wait()
+			close(out)
+		}()
+
+		following := make(map[string]bool)
+		for event := range events {
+			for _, n := range notificationsOf(event) {
+				if !send(n) {
+					return
+				}
+			}
+			exec := event.Execution
+			if event.Finished() {
+				delete(following, exec.ID)
+				continue
+			}
+			if exec.Status != "running" || following[exec.ID] {
+				continue
+			}
+			steps, err := follow(ctx, exec)
+			if err != nil {
+				// The execution's start and finish are still sent
+				continue
+			}
+			following[exec.ID] = true
+			followers.Add(1)
+			go func() {
+				defer followers.Done()
+				for n := range steps {
+					if !send(n) {
+						return
+					}
+				}
+			}()
+		}
+	}()
+	return out
+}
+
+// stepResultNotification is the part of a Testkube execution notification
+// carrying the execution's result, with its steps' statuses
+type stepResultNotification struct {
+	Ts     time.Time `json:"ts"`
+	Result *struct {
+		Steps map[string]struct {
+			Status     string    `json:"status"`
+			FinishedAt time.Time `json:"finishedAt"`
+		} `json:"steps"`
+	} `json:"result"`
+}
+
+// readStepNotifications reads a server-sent event stream of execution
+// notifications, calling fn as each of the execution's steps finishes
+// until the stream ends or fn returns false
+func readStepNotifications(r io.Reader, exec Execution, fn func(ExecutionNotification) bool) error {
+	finished := make(map[string]bool)
+	return readServerSentEvents(r, func(data string) bool {
+		var n stepResultNotification
+		if json.Unmarshal([]byte(data), &n) != nil || n.Result == nil {
+			return true
+		}
+		// Steps are reported by reference, so they're sent in a stable order
+		refs := make([]string, 0, len(n.Result.Steps))
+		for ref := range n.Result.Steps {
+			refs = append(refs, ref)
+		}
+		slices.Sort(refs)
+		for _, ref := range refs {
+			step := n.Result.Steps[ref]
+			if finished[ref] || !slices.Contains(finishedStepStatuses, step.Status) {
+				continue
+			}
+			finished[ref] = true
+			at := step.FinishedAt
+			if at.IsZero() {
+				at = n.Ts
+			}
+			if !fn(ExecutionNotification{Time: at, Kind: NotificationStepFinished, ExecutionID: exec.ID, Workflow: exec.WorkflowName, Step: ref, Status: step.Status}) {
+				return false
+			}
+		}
+		return true
+	})
+}
+
+// WatchNotifications sends executions being queued, starting and finishing
+// as the watch sees them, and their steps finishing from each running
+// execution's notification stream
+func (c *RealClient) WatchNotifications(ctx context.Context) (<-chan ExecutionNotification, error) {
+	events, err := c.WatchExecutions(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return watchNotifications(ctx, events, c.followSteps), nil
+}
+
+// followSteps reads a running execution's notification stream for its
+// steps finishing
+func (c *RealClient) followSteps(ctx context.Context, exec Execution) (<-chan ExecutionNotification, error) {
+	apiURL := fmt.Sprintf("%s/test-workflow-executions/%s/notifications/stream", c.apiURL, exec.ID)
+	req, err := http.NewRequestWithContext(ctx, "GET", apiURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Accept", "text/event-stream")
+	if c.token != "" {
+		req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", c.token))
+	}
+
+	// The stream lasts as long as the execution, so it has no timeout
+	client := &http.Client{Transport: c.httpClient.Transport}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("API request failed: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return nil, fmt.Errorf("API returned %d", resp.StatusCode)
+	}
+
+	steps := make(chan ExecutionNotification)
+	go func() {
+		defer close(steps)
+		defer resp.Body.Close()
+		readStepNotifications(resp.Body, exec, func(n ExecutionNotification) bool {
+			select {
+			case <-ctx.Done():
+				return false
+			case steps <- n:
+				return true
+			}
+		})
+	}()
+	return steps, nil
+}
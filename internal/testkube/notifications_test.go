@@ -0,0 +1,119 @@
+package testkube
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestWatchNotifications(t *testing.T) {
+	events := make(chan ExecutionEvent, 4)
+	events <- ExecutionEvent{Execution: Execution{ID: "a", WorkflowName: "wf", Status: "queued"}}
+	events <- ExecutionEvent{Execution: Execution{ID: "a", WorkflowName: "wf", Status: "running"}, Previous: "queued"}
+	events <- ExecutionEvent{Execution: Execution{ID: "b", WorkflowName: "other", Status: "running"}}
+	close(events)
+
+	// a's steps come from its stream; b's can't be followed
+	follow := func(ctx context.Context, exec Execution) (<-chan ExecutionNotification, error) {
+		if exec.ID == "b" {
+			return nil, errors.New("stream unavailable")
+		}
+		steps := make(chan ExecutionNotification, 1)
+		steps <- ExecutionNotification{Kind: NotificationStepFinished, ExecutionID: exec.ID, Step: "test", Status: "passed"}
+		close(steps)
+		return steps, nil
+	}
+
+	var got []ExecutionNotification
+	timeout := time.After(5 * time.Second)
+	notifications := watchNotifications(context.Background(), events, follow)
+	for done := false; !done; {
+		select {
+		case n, ok := <-notifications:
+			if !ok {
+				done = true
+				break
+			}
+			got = append(got, n)
+		case <-timeout:
+			t.Fatalf("got %d notifications before the timeout, expected the channel to close", len(got))
+		}
+	}
+
+	counts := make(map[string]int)
+	for _, n := range got {
+		counts[n.ExecutionID+" "+n.Kind]++
+	}
+	expected := map[string]int{"a queued": 1, "a started": 1, "a step-finished": 1, "b started": 1}
+	if len(counts) != len(expected) {
+		t.Errorf("got %v, expected %v", counts, expected)
+	}
+	for key, count := range expected {
+		if counts[key] != count {
+			t.Errorf("got %d %q notifications, expected %d", counts[key], key, count)
+		}
+	}
+}
+
+func TestNotificationsOf(t *testing.T) {
+	finished := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		event    ExecutionEvent
+		expected string
+	}{
+		{ExecutionEvent{Execution: Execution{Status: "queued"}}, NotificationQueued},
+		{ExecutionEvent{Execution: Execution{Status: "running"}, Previous: "queued"}, NotificationStarted},
+		{ExecutionEvent{Execution: Execution{Status: "failed", EndTime: finished}, Previous: "running"}, NotificationFinished},
+		// Watches report no other changes, but a repeat isn't news
+		{ExecutionEvent{Execution: Execution{Status: "running"}, Previous: "running"}, ""},
+	}
+	for _, tt := range tests {
+		got := notificationsOf(tt.event)
+		kind := ""
+		if len(got) > 0 {
+			kind = got[0].Kind
+		}
+		if kind != tt.expected {
+			t.Errorf("got %q for %+v, expected %q", kind, tt.event, tt.expected)
+		}
+	}
+	if got := notificationsOf(tests[2].event); !got[0].Time.Equal(finished) {
+		t.Errorf("got %v, expected the finish time %v", got[0].Time, finished)
+	}
+}
+
+func TestReadStepNotifications(t *testing.T) {
+	stream := strings.Join([]string{
+		`data: {"ts":"2024-05-01T12:00:00Z","ref":"clone","log":"Cloning..."}`,
+		``,
+		`data: {"ts":"2024-05-01T12:00:05Z","result":{"status":"running","steps":{"clone":{"status":"passed","finishedAt":"2024-05-01T12:00:04Z"},"test":{"status":"running"}}}}`,
+		``,
+		// clone is reported again with every result
+		`data: {"ts":"2024-05-01T12:01:00Z","result":{"status":"failed","steps":{"clone":{"status":"passed","finishedAt":"2024-05-01T12:00:04Z"},"test":{"status":"failed"}}}}`,
+		``,
+	}, "\n")
+
+	var got []ExecutionNotification
+	err := readStepNotifications(strings.NewReader(stream), Execution{ID: "exec-1", WorkflowName: "wf"}, func(n ExecutionNotification) bool {
+		got = append(got, n)
+		return true
+	})
+	if err != nil {
+		t.Fatalf("readStepNotifications failed: %v", err)
+	}
+	if len(got) != 2 {
+		t.Fatalf("got %d notifications, expected 2: %+v", len(got), got)
+	}
+	if got[0].Step != "clone" || got[0].Status != "passed" || got[0].Time.Format(time.RFC3339) != "2024-05-01T12:00:04Z" {
+		t.Errorf("got %+v, expected clone to pass at its finish time", got[0])
+	}
+	// Without a finish time the notification's time stands in
+	if got[1].Step != "test" || got[1].Status != "failed" || got[1].Time.Format(time.RFC3339) != "2024-05-01T12:01:00Z" {
+		t.Errorf("got %+v, expected test to fail at the notification's time", got[1])
+	}
+	if got[1].Kind != NotificationStepFinished || got[1].Workflow != "wf" || got[1].ExecutionID != "exec-1" {
+		t.Errorf("got %+v, expected a step-finished notification of exec-1", got[1])
+	}
+}
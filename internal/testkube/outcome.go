@@ -0,0 +1,100 @@
+package testkube
+
+import (
+	"regexp"
+	"strings"
+)
+
+// Outcomes of a finished execution. Failed is reserved for the tests
+// failing; a run that never got to test anything is an infra error, and
+// one that ran out of time timed out.
+const (
+	OutcomePassed     = "passed"
+	OutcomeFailed     = "failed"
+	OutcomeInfraError = "infra-error"
+	OutcomeTimedOut   = "timed-out"
+	OutcomeAborted    = "aborted"
+	OutcomeSkipped    = "skipped"
+)
+
+// Outcomes lists the outcomes in the order reports show them
+var Outcomes = []string{OutcomePassed, OutcomeFailed, OutcomeInfraError, OutcomeTimedOut, OutcomeAborted, OutcomeSkipped}
+
+// outcomeSignature recognizes a failure that isn't the tests' fault from an
+// error message or the logs
+type outcomeSignature struct {
+	outcome string
+	pattern *regexp.Regexp
+}
+
+// outcomeSignatures are checked in order, so a pod evicted for running out
+// of time reads as an infra error
+var outcomeSignatures = []outcomeSignature{
+	{OutcomeInfraError, regexp.MustCompile(`ImagePullBackOff|ErrImagePull|CreateContainerConfigError|InvalidImageName`)},
+	{OutcomeInfraError, regexp.MustCompile(`FailedScheduling|Insufficient (cpu|memory)|node\(s\) didn't match|Unschedulable`)},
+	{OutcomeInfraError, regexp.MustCompile(`(?i)pod .*\bevicted\b|the node was low on resource|NodeLost|node \S+ (is not ready|was lost)`)},
+	{OutcomeInfraError, regexp.MustCompile(`(?i)failed to create (the )?pod|no runners? (is |are )?available`)},
+	{OutcomeTimedOut, regexp.MustCompile(`(?i)activeDeadlineSeconds|\bDeadlineExceeded\b|(step|execution|workflow) timed out`)},
+}
+
+// ClassifyOutcome maps an execution's Testkube status, with the error
+// messages of its initialization and steps, to its outcome. It returns ""
+// for an execution that hasn't finished.
+func ClassifyOutcome(status string, messages ...string) string {
+	switch strings.ToLower(status) {
+	case "passed":
+		return OutcomePassed
+	case "failed":
+		for _, message := range messages {
+			if outcome := matchSignature(message); outcome != "" {
+				return outcome
+			}
+		}
+		return OutcomeFailed
+	case "aborted", "canceled", "cancelled":
+		return OutcomeAborted
+	case "timeout", "timed-out":
+		return OutcomeTimedOut
+	case "error":
+		return OutcomeInfraError
+	case "skipped":
+		return OutcomeSkipped
+	}
+	return ""
+}
+
+// ClassifyLogs refines a failed execution's outcome from its logs, for
+// failures the API reports without a message. It returns OutcomeFailed when
+// no signature matches.
+func ClassifyLogs(logs string) string {
+	if outcome := matchSignature(logs); outcome != "" {
+		return outcome
+	}
+	return OutcomeFailed
+}
+
+func matchSignature(text string) string {
+	for _, sig := range outcomeSignatures {
+		if sig.pattern.MatchString(text) {
+			return sig.outcome
+		}
+	}
+	return ""
+}
+
+// CountsTowardsPassRate reports whether runs with the outcome are counted
+// in pass rates. Infra errors, timeouts, aborted and skipped runs say
+// nothing about the tests, so one cluster hiccup doesn't move a team's
+// quality metrics.
+func CountsTowardsPassRate(outcome string) bool {
+	return outcome == OutcomePassed || outcome == OutcomeFailed
+}
+
+// RunOutcome is the execution's outcome, classified from its status alone
+// when it wasn't recorded
+func (e Execution) RunOutcome() string {
+	if e.Outcome != "" {
+		return e.Outcome
+	}
+	return ClassifyOutcome(e.Status)
+}
@@ -0,0 +1,68 @@
+package testkube
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestClassifyOutcome(t *testing.T) {
+	tests := []struct {
+		status   string
+		messages []string
+		expected string
+	}{
+		{"passed", nil, OutcomePassed},
+		{"failed", []string{"process exited with code 1"}, OutcomeFailed},
+		{"failed", []string{"", `Back-off pulling image "playwright:v1.40": ImagePullBackOff`}, OutcomeInfraError},
+		{"failed", []string{"0/3 nodes are available: 3 Insufficient memory."}, OutcomeInfraError},
+		{"failed", []string{"Job was active longer than specified deadline: DeadlineExceeded"}, OutcomeTimedOut},
+		{"aborted", nil, OutcomeAborted},
+		{"canceled", nil, OutcomeAborted},
+		{"timeout", nil, OutcomeTimedOut},
+		{"skipped", nil, OutcomeSkipped},
+		{"running", nil, ""},
+		{"queued", nil, ""},
+	}
+	for _, tt := range tests {
+		if got := ClassifyOutcome(tt.status, tt.messages...); got != tt.expected {
+			t.Errorf("ClassifyOutcome(%q, %q): got %q, expected %q", tt.status, tt.messages, got, tt.expected)
+		}
+	}
+}
+
+func TestClassifyLogs(t *testing.T) {
+	if got := ClassifyLogs("Running tests...\nExpected 200, got 500\n"); got != OutcomeFailed {
+		t.Errorf("got %q, expected a test failure", got)
+	}
+	// Application logs mentioning evictions or timeouts aren't infra errors
+	if got := ClassifyLogs("cache entry evicted\nrequest timed out after 5s\n"); got != OutcomeFailed {
+		t.Errorf("got %q, expected a test failure", got)
+	}
+	if got := ClassifyLogs("The node was low on resource: memory. Container runner was using 2Gi"); got != OutcomeInfraError {
+		t.Errorf("got %q, expected an infra error", got)
+	}
+}
+
+func TestExecutionOutcomeFromAPI(t *testing.T) {
+	var e apiExecution
+	body := `{"id": "e1", "workflow": {"name": "web"}, "result": {
+		"status": "failed",
+		"initialization": {"status": "passed"},
+		"steps": {"abc": {"status": "failed", "errorMessage": "pod web-e1 was evicted: The node was low on resource: memory"}}
+	}}`
+	if err := json.Unmarshal([]byte(body), &e); err != nil {
+		t.Fatal(err)
+	}
+	exec := e.toExecution()
+	if exec.Status != "failed" || exec.Outcome != OutcomeInfraError {
+		t.Errorf("got status %q and outcome %q, expected a failed status with an infra error outcome", exec.Status, exec.Outcome)
+	}
+	if CountsTowardsPassRate(exec.RunOutcome()) {
+		t.Error("expected infra errors to be left out of pass rates")
+	}
+
+	// Executions stored before outcomes were recorded fall back on their status
+	if got := (Execution{Status: "failed"}).RunOutcome(); got != OutcomeFailed {
+		t.Errorf("got %q, expected %q", got, OutcomeFailed)
+	}
+}
@@ -1,45 +1,155 @@
 package testkube
 
 import (
-	"bufio"
+	"bytes"
 	"context"
 	"encoding/json"
 	"fmt"
 	"io"
+	"log"
 	"net/http"
 	"net/url"
 	"os"
+	"strconv"
 	"strings"
 	"time"
 )
 
 type RealClient struct {
-	baseURL    string
+	baseURL string
+	// apiURL is the root of the workflow and execution endpoints: the
+	// baseURL's /v1, or in cloud mode the environment's agent API
+	apiURL     string
 	httpClient *http.Client
 	token      string
 	namespace  string
+
+	// downloadClient is httpClient with the longer download timeout
+	downloadClient *http.Client
+
+	// Detected server version and the response adapter chosen for it
+	version apiVersion
+	adapter compatAdapter
+
+	// Last run and pass rate of the workflow list, refreshed every
+	// TESTKUBE_ENRICHMENT_TTL
+	enrichment *enrichmentCache
+
+	// How often WatchExecutions looks for changes
+	watchInterval time.Duration
+	watch         sharedWatch
+
+	// grpcLogs reads logs from the logs service instead of the API server,
+	// when set
+	grpcLogs *grpcLogs
 }
 
-// NewRealClient creates a client that connects to the actual Testkube API server
+// ClientConfig is where a RealClient connects to. Empty fields take the
+// in-cluster defaults.
+type ClientConfig struct {
+	URL       string
+	Namespace string
+	Token     string
+	// LogsTransport is LogsTransportHTTP (the default) or LogsTransportGRPC,
+	// which reads logs from the logs service at LogsGRPCAddress
+	LogsTransport   string
+	LogsGRPCAddress string
+	// OIDC, when set, replaces Token with tokens from an OIDC provider,
+	// refreshed as they expire or are rejected
+	OIDC *OIDCConfig
+	// Mode is ModeOSS (the default) or ModeCloud, for the Testkube Cloud or
+	// Pro environment in Cloud, with URL defaulting to DefaultCloudURL
+	Mode  string
+	Cloud CloudConfig
+	// Transport sets the timeouts, trusted CAs and proxy
+	Transport TransportConfig
+	// Limits caps and paces the requests to the API server
+	Limits LimitConfig
+}
+
+// NewRealClient creates a client that connects to the actual Testkube API
+// server configured through TESTKUBE_API_URL, TESTKUBE_NAMESPACE and
+// TESTKUBE_API_TOKEN (or the TESTKUBE_OIDC_* settings, for Testkube Pro
+// and Cloud agents), reading logs as TESTKUBE_LOGS_TRANSPORT and
+// TESTKUBE_LOGS_GRPC_ADDRESS say. TESTKUBE_API_MODE=cloud targets the
+// Testkube Cloud environment in TESTKUBE_CLOUD_ORG_ID and
+// TESTKUBE_CLOUD_ENV_ID instead, with TESTKUBE_API_TOKEN as its API key.
+// TESTKUBE_REQUEST_TIMEOUT, TESTKUBE_DOWNLOAD_TIMEOUT, TESTKUBE_CA_FILE,
+// TESTKUBE_INSECURE_SKIP_VERIFY and TESTKUBE_PROXY_URL configure the
+// connection, and TESTKUBE_MAX_CONCURRENT_REQUESTS,
+// TESTKUBE_REQUESTS_PER_SECOND and TESTKUBE_REQUEST_BURST how hard it's
+// used.
 func NewRealClient() (*RealClient, error) {
-	// Get API URL from environment, with sensible default for in-cluster deployment
-	baseURL := os.Getenv("TESTKUBE_API_URL")
+	return NewRealClientWithConfig(ClientConfig{
+		URL:             os.Getenv("TESTKUBE_API_URL"),
+		Namespace:       os.Getenv("TESTKUBE_NAMESPACE"),
+		Token:           os.Getenv("TESTKUBE_API_TOKEN"),
+		LogsTransport:   os.Getenv("TESTKUBE_LOGS_TRANSPORT"),
+		LogsGRPCAddress: os.Getenv("TESTKUBE_LOGS_GRPC_ADDRESS"),
+		OIDC:            oidcConfigFromEnv(),
+		Mode:            os.Getenv("TESTKUBE_API_MODE"),
+		Cloud:           cloudConfigFromEnv(),
+		Transport:       transportConfigFromEnv(),
+		Limits:          limitConfigFromEnv(),
+	})
+}
+
+// NewRealClientWithConfig creates a client for the Testkube API server in cfg
+func NewRealClientWithConfig(cfg ClientConfig) (*RealClient, error) {
+	// Sensible defaults for in-cluster deployment
+	baseURL := cfg.URL
+	if baseURL == "" && cfg.Mode == ModeCloud {
+		baseURL = DefaultCloudURL
+	}
 	if baseURL == "" {
 		baseURL = "http://testkube-api-server:8088"
 	}
+	apiURL, err := apiURL(cfg, baseURL)
+	if err != nil {
+		return nil, err
+	}
 
-	namespace := os.Getenv("TESTKUBE_NAMESPACE")
+	namespace := cfg.Namespace
 	if namespace == "" {
 		namespace = "testkube"
 	}
 
+	base, err := cfg.Transport.transport()
+	if err != nil {
+		return nil, err
+	}
+	var transport http.RoundTripper = newResilientTransport(cfg.Limits.wrap(base), retryPolicyFromEnv(), breakerPolicyFromEnv())
+	var tokens *tokenSource
+	if cfg.OIDC != nil {
+		if tokens, err = newTokenSource(*cfg.OIDC); err != nil {
+			return nil, err
+		}
+		transport = &authTransport{next: transport, tokens: tokens}
+	}
+
+	timeout, downloadTimeout := cfg.Transport.timeouts()
 	client := &RealClient{
-		baseURL:   baseURL,
-		namespace: namespace,
-		token:     os.Getenv("TESTKUBE_API_TOKEN"),
-		httpClient: &http.Client{
-			Timeout: 30 * time.Second,
-		},
+		baseURL:        baseURL,
+		apiURL:         apiURL,
+		namespace:      namespace,
+		token:          cfg.Token,
+		httpClient:     &http.Client{Timeout: timeout, Transport: transport},
+		downloadClient: &http.Client{Timeout: downloadTimeout, Transport: transport},
+		adapter:        sniffingAdapter{},
+		enrichment:     newEnrichmentCache(durationFromEnv("TESTKUBE_ENRICHMENT_TTL", DefaultEnrichmentTTL)),
+		watchInterval:  durationFromEnv("TESTKUBE_WATCH_INTERVAL", DefaultWatchInterval),
+	}
+
+	switch cfg.LogsTransport {
+	case "", LogsTransportHTTP:
+	case LogsTransportGRPC:
+		logs, err := newGRPCLogs(cfg.LogsGRPCAddress, cfg.Token, tokens, base)
+		if err != nil {
+			return nil, err
+		}
+		client.grpcLogs = logs
+	default:
+		return nil, fmt.Errorf("unknown logs transport %q: use %s or %s", cfg.LogsTransport, LogsTransportHTTP, LogsTransportGRPC)
 	}
 
 	// Validate connection
@@ -47,6 +157,12 @@ func NewRealClient() (*RealClient, error) {
 		return nil, fmt.Errorf("testkube API health check failed: %w", err)
 	}
 
+	// Version detection is best-effort: older servers may not expose /info
+	if err := client.detectVersion(); err != nil {
+		log.Printf("Warning: could not detect Testkube API version, auto-detecting response shapes: %v", err)
+	}
+	log.Printf("Testkube API version %s (response adapter: %s)", client.version, client.adapter.Name())
+
 	return client, nil
 }
 
@@ -65,23 +181,59 @@ func (c *RealClient) healthCheck() error {
 	return nil
 }
 
+// detectVersion queries the info endpoint and selects the response adapter
+// matching the server's version range.
+func (c *RealClient) detectVersion() error {
+	info, err := c.fetchInfo()
+	if err != nil {
+		return err
+	}
+
+	c.version = parseAPIVersion(info.Version)
+	c.adapter = adapterFor(c.version)
+	return nil
+}
+
 func (c *RealClient) GetExecutions(opts ListOptions) ([]Execution, error) {
-	// Build query parameters
-	params := url.Values{}
-	if opts.PageSize > 0 {
-		params.Set("pageSize", fmt.Sprintf("%d", opts.PageSize))
+	page, err := c.GetExecutionPage(opts)
+	if err != nil {
+		return nil, err
 	}
-	if opts.Page > 0 {
-		params.Set("page", fmt.Sprintf("%d", opts.Page))
+	return page.Executions, nil
+}
+
+func (c *RealClient) GetExecutionPage(opts ListOptions) (*ExecutionPage, error) {
+	opts = opts.normalized()
+	if _, err := ParseSelector(opts.Selector); err != nil {
+		return nil, err
 	}
+
+	// Build query parameters; Testkube numbers pages from 0
+	params := url.Values{}
+	params.Set("pageSize", strconv.Itoa(opts.PageSize))
+	params.Set("page", strconv.Itoa(opts.Page-1))
 	if opts.Status != "" {
 		params.Set("status", opts.Status)
 	}
+	if opts.Selector != "" {
+		params.Set("selector", opts.Selector)
+	}
+	if tags := opts.tagSelector(); tags != "" {
+		params.Set("tagSelector", tags)
+	}
+	// Testkube filters by whole UTC days, with both dates inclusive; the
+	// page is trimmed to the exact bounds below
+	if !opts.StartedAfter.IsZero() {
+		params.Set("startDate", opts.StartedAfter.UTC().Format(time.DateOnly))
+	}
+	if !opts.StartedBefore.IsZero() {
+		params.Set("endDate", opts.StartedBefore.UTC().Format(time.DateOnly))
+	}
 
 	// Make API request
-	apiURL := fmt.Sprintf("%s/v1/test-workflow-executions?%s", c.baseURL, params.Encode())
+	apiURL := fmt.Sprintf("%s/test-workflow-executions?%s", c.apiURL, params.Encode())
 	if opts.Workflow != "" {
-		apiURL = fmt.Sprintf("%s/v1/test-workflows/%s/executions?%s", c.baseURL, opts.Workflow, params.Encode())
+		apiURL = fmt.Sprintf("%s/test-workflows/%s/executions?%s", c.apiURL, opts.Workflow, params.Encode())
 	}
 	req, err := http.NewRequest("GET", apiURL, nil)
 	if err != nil {
@@ -104,51 +256,42 @@ func (c *RealClient) GetExecutions(opts ListOptions) ([]Execution, error) {
 		return nil, fmt.Errorf("API returned %d: %s", resp.StatusCode, string(body))
 	}
 
-	// Parse response
-	var apiResponse struct {
-		Results []struct {
-			ID     string    `json:"id"`
-			Name   string    `json:"name"`
-			Number int       `json:"number"`
-			Workflow struct {
-				Name string `json:"name"`
-			} `json:"workflow"`
-			Result struct {
-				Status    string    `json:"status"`
-				StartTime time.Time `json:"startTime"`
-				EndTime   time.Time `json:"endTime"`
-			} `json:"result"`
-		} `json:"results"`
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response: %w", err)
 	}
 
-	if err := json.NewDecoder(resp.Body).Decode(&apiResponse); err != nil {
+	// Parse response using the adapter for the detected server version
+	list, err := c.adapter.DecodeExecutionList(body)
+	if err != nil {
 		return nil, fmt.Errorf("failed to parse response: %w", err)
 	}
 
 	// Convert to our model
-	executions := make([]Execution, 0, len(apiResponse.Results))
-	for _, item := range apiResponse.Results {
-		exec := Execution{
-			ID:           item.ID,
-			Name:         item.Name,
-			WorkflowName: item.Workflow.Name,
-			Status:       item.Result.Status,
-			StartTime:    item.Result.StartTime,
-			EndTime:      item.Result.EndTime,
-		}
-
-		if !exec.EndTime.IsZero() {
-			exec.Duration = exec.EndTime.Sub(exec.StartTime)
+	result := &ExecutionPage{
+		Executions: make([]Execution, 0, len(list.Results)),
+		Page:       opts.Page,
+		PageSize:   opts.PageSize,
+		Total:      list.Total,
+	}
+	for _, item := range list.Results {
+		exec := item.toExecution()
+		if opts.startedInRange(exec.StartTime) {
+			result.Executions = append(result.Executions, exec)
 		}
-
-		executions = append(executions, exec)
+	}
+	// A short page is the last, so the total follows from it. An empty page
+	// past the first may be anywhere past the end. Trimming to the date
+	// bounds doesn't shorten the page the server sent.
+	if result.Total < 0 && len(list.Results) < opts.PageSize && (len(list.Results) > 0 || opts.Page == 1) {
+		result.Total = (opts.Page-1)*opts.PageSize + len(result.Executions)
 	}
 
-	return executions, nil
+	return result, nil
 }
 
 func (c *RealClient) GetExecution(id string) (*Execution, error) {
-	apiURL := fmt.Sprintf("%s/v1/test-workflow-executions/%s", c.baseURL, id)
+	apiURL := fmt.Sprintf("%s/test-workflow-executions/%s", c.apiURL, id)
 	req, err := http.NewRequest("GET", apiURL, nil)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create request: %w", err)
@@ -171,42 +314,65 @@ func (c *RealClient) GetExecution(id string) (*Execution, error) {
 		return nil, fmt.Errorf("API returned %d", resp.StatusCode)
 	}
 
-	var apiResponse struct {
-		ID     string    `json:"id"`
-		Name   string    `json:"name"`
-		Number int       `json:"number"`
-		Workflow struct {
-			Name string `json:"name"`
-		} `json:"workflow"`
-		Result struct {
-			Status    string    `json:"status"`
-			StartTime time.Time `json:"startTime"`
-			EndTime   time.Time `json:"endTime"`
-		} `json:"result"`
+	var apiResponse apiExecution
+	if err := json.NewDecoder(resp.Body).Decode(&apiResponse); err != nil {
+		return nil, fmt.Errorf("failed to parse response: %w", err)
+	}
+
+	exec := apiResponse.toExecution()
+	return &exec, nil
+}
+
+func (c *RealClient) GetExecutionResources(id string) (*ResourceUsage, error) {
+	resp, err := c.workflowRequest("GET", fmt.Sprintf("%s/test-workflow-executions/%s", c.apiURL, id), nil, "application/json")
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, fmt.Errorf("execution %s not found", id)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("API returned %d", resp.StatusCode)
 	}
 
+	var apiResponse apiExecutionResources
 	if err := json.NewDecoder(resp.Body).Decode(&apiResponse); err != nil {
 		return nil, fmt.Errorf("failed to parse response: %w", err)
 	}
+	return apiResponse.usage(), nil
+}
 
-	exec := &Execution{
-		ID:           apiResponse.ID,
-		Name:         apiResponse.Name,
-		WorkflowName: apiResponse.Workflow.Name,
-		Status:       apiResponse.Result.Status,
-		StartTime:    apiResponse.Result.StartTime,
-		EndTime:      apiResponse.Result.EndTime,
+func (c *RealClient) GetExecutionInputs(id string) (*RunOptions, error) {
+	resp, err := c.workflowRequest("GET", fmt.Sprintf("%s/test-workflow-executions/%s", c.apiURL, id), nil, "application/json")
+	if err != nil {
+		return nil, err
 	}
+	defer resp.Body.Close()
 
-	if !exec.EndTime.IsZero() {
-		exec.Duration = exec.EndTime.Sub(exec.StartTime)
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, fmt.Errorf("execution %s not found", id)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("API returned %d", resp.StatusCode)
 	}
 
-	return exec, nil
+	var apiResponse apiExecutionInputs
+	if err := json.NewDecoder(resp.Body).Decode(&apiResponse); err != nil {
+		return nil, fmt.Errorf("failed to parse response: %w", err)
+	}
+	return apiResponse.inputs()
 }
 
-func (c *RealClient) GetWorkflows() ([]Workflow, error) {
-	apiURL := fmt.Sprintf("%s/v1/test-workflows", c.baseURL)
+func (c *RealClient) GetWorkflows(opts ListOptions) ([]Workflow, error) {
+	if _, err := ParseSelector(opts.Selector); err != nil {
+		return nil, err
+	}
+	apiURL := fmt.Sprintf("%s/test-workflows", c.apiURL)
+	if opts.Selector != "" {
+		apiURL += "?" + url.Values{"selector": {opts.Selector}}.Encode()
+	}
 	req, err := http.NewRequest("GET", apiURL, nil)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create request: %w", err)
@@ -227,54 +393,37 @@ func (c *RealClient) GetWorkflows() ([]Workflow, error) {
 	}
 
 	var apiResponse []struct {
-		Name      string    `json:"name"`
-		Namespace string    `json:"namespace"`
-		Created   time.Time `json:"created"`
-		Spec      struct {
-			Container struct {
-				Image string `json:"image"`
-			} `json:"container"`
-		} `json:"spec"`
+		Name      string                 `json:"name"`
+		Namespace string                 `json:"namespace"`
+		Labels    map[string]string      `json:"labels"`
+		Created   time.Time              `json:"created"`
+		Spec      map[string]interface{} `json:"spec"`
 	}
 
 	if err := json.NewDecoder(resp.Body).Decode(&apiResponse); err != nil {
 		return nil, fmt.Errorf("failed to parse response: %w", err)
 	}
 
+	names := make([]string, 0, len(apiResponse))
+	for _, item := range apiResponse {
+		names = append(names, item.Name)
+	}
+	stats := c.enrichment.lookup(names, c.GetExecutions)
+
 	workflows := make([]Workflow, 0, len(apiResponse))
 	for _, item := range apiResponse {
 		wf := Workflow{
 			Name:      item.Name,
 			Namespace: item.Namespace,
+			Labels:    item.Labels,
 			Created:   item.Created,
-			Type:      extractWorkflowType(item.Spec.Container.Image),
+			Type:      DefaultTypes.DetectWorkflow(item.Labels, item.Spec),
+			Templates: templateRefs(item.Spec),
 		}
 
 		// Enrich with execution data
-		executions, err := c.GetExecutions(ListOptions{
-			Workflow: item.Name,
-			PageSize: 10,
-		})
-		if err == nil && len(executions) > 0 {
-			// Get latest execution for LastRun and LastStatus
-			wf.LastRun = executions[0].StartTime
-			wf.LastStatus = executions[0].Status
-
-			// Calculate pass rate for last 7 days
-			sevenDaysAgo := time.Now().AddDate(0, 0, -7)
-			passed := 0
-			total := 0
-			for _, exec := range executions {
-				if exec.StartTime.After(sevenDaysAgo) {
-					total++
-					if exec.Status == "passed" {
-						passed++
-					}
-				}
-			}
-			if total > 0 {
-				wf.PassRateLast7d = (passed * 100) / total
-			}
+		if ws, ok := stats[item.Name]; ok {
+			ws.apply(&wf)
 		}
 
 		workflows = append(workflows, wf)
@@ -284,7 +433,7 @@ func (c *RealClient) GetWorkflows() ([]Workflow, error) {
 }
 
 func (c *RealClient) GetArtifacts(executionID string) ([]Artifact, error) {
-	apiURL := fmt.Sprintf("%s/v1/test-workflow-executions/%s/artifacts", c.baseURL, executionID)
+	apiURL := fmt.Sprintf("%s/test-workflow-executions/%s/artifacts", c.apiURL, executionID)
 	req, err := http.NewRequest("GET", apiURL, nil)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create request: %w", err)
@@ -326,8 +475,23 @@ func (c *RealClient) GetArtifacts(executionID string) ([]Artifact, error) {
 }
 
 func (c *RealClient) DownloadArtifact(executionID, path string) ([]byte, error) {
-	apiURL := fmt.Sprintf("%s/v1/test-workflow-executions/%s/artifacts/%s",
-		c.baseURL, executionID, url.PathEscape(path))
+	body, err := c.DownloadArtifactStream(executionID, path)
+	if err != nil {
+		return nil, err
+	}
+	defer body.Close()
+
+	data, err := io.ReadAll(body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response: %w", err)
+	}
+
+	return data, nil
+}
+
+func (c *RealClient) DownloadArtifactStream(executionID, path string) (io.ReadCloser, error) {
+	apiURL := fmt.Sprintf("%s/test-workflow-executions/%s/artifacts/%s",
+		c.apiURL, executionID, url.PathEscape(path))
 
 	req, err := http.NewRequest("GET", apiURL, nil)
 	if err != nil {
@@ -338,26 +502,21 @@ func (c *RealClient) DownloadArtifact(executionID, path string) ([]byte, error)
 		req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", c.token))
 	}
 
-	resp, err := c.httpClient.Do(req)
+	resp, err := c.downloadClient.Do(req)
 	if err != nil {
 		return nil, fmt.Errorf("API request failed: %w", err)
 	}
-	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
 		return nil, fmt.Errorf("API returned %d", resp.StatusCode)
 	}
 
-	data, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return nil, fmt.Errorf("failed to read response: %w", err)
-	}
-
-	return data, nil
+	return resp.Body, nil
 }
 
 func (c *RealClient) GetWorkflow(name string) (*Workflow, error) {
-	apiURL := fmt.Sprintf("%s/v1/test-workflows/%s", c.baseURL, name)
+	apiURL := fmt.Sprintf("%s/test-workflows/%s", c.apiURL, name)
 	req, err := http.NewRequest("GET", apiURL, nil)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create request: %w", err)
@@ -374,40 +533,402 @@ func (c *RealClient) GetWorkflow(name string) (*Workflow, error) {
 	defer resp.Body.Close()
 
 	if resp.StatusCode == http.StatusNotFound {
-		return nil, fmt.Errorf("workflow %s not found", name)
+		return nil, fmt.Errorf("workflow %s: %w", name, ErrWorkflowNotFound)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("API returned %d", resp.StatusCode)
+	}
+
+	return decodeWorkflow(resp.Body)
+}
+
+func (c *RealClient) GetWorkflowTemplates(opts ListOptions) ([]WorkflowTemplate, error) {
+	if _, err := ParseSelector(opts.Selector); err != nil {
+		return nil, err
+	}
+	apiURL := fmt.Sprintf("%s/test-workflow-templates", c.apiURL)
+	if opts.Selector != "" {
+		apiURL += "?" + url.Values{"selector": {opts.Selector}}.Encode()
+	}
+	resp, err := c.workflowRequest("GET", apiURL, nil, "application/json")
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("API returned %d", resp.StatusCode)
+	}
+
+	var apiResponse []workflowTemplateResponse
+	if err := json.NewDecoder(resp.Body).Decode(&apiResponse); err != nil {
+		return nil, fmt.Errorf("failed to parse response: %w", err)
+	}
+
+	templates := make([]WorkflowTemplate, 0, len(apiResponse))
+	for _, item := range apiResponse {
+		templates = append(templates, item.template())
+	}
+	return templates, nil
+}
+
+func (c *RealClient) GetWorkflowTemplate(name string) (*WorkflowTemplate, error) {
+	// Resource names can't contain slashes, so "official/k6/v1" is stored
+	// as "official--k6--v1"
+	resource := strings.ReplaceAll(name, "/", "--")
+	resp, err := c.workflowRequest("GET", fmt.Sprintf("%s/test-workflow-templates/%s", c.apiURL, url.PathEscape(resource)), nil, "application/json")
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, fmt.Errorf("template %s: %w", name, ErrWorkflowTemplateNotFound)
 	}
 	if resp.StatusCode != http.StatusOK {
 		return nil, fmt.Errorf("API returned %d", resp.StatusCode)
 	}
 
+	var apiResponse workflowTemplateResponse
+	if err := json.NewDecoder(resp.Body).Decode(&apiResponse); err != nil {
+		return nil, fmt.Errorf("failed to parse response: %w", err)
+	}
+	template := apiResponse.template()
+	return &template, nil
+}
+
+func (c *RealClient) GetTests(opts ListOptions) ([]Workflow, error) {
+	var apiResponse []legacyTestResponse
+	if err := c.getLegacy("tests", opts, &apiResponse); err != nil {
+		return nil, err
+	}
+	tests := make([]Workflow, 0, len(apiResponse))
+	for _, item := range apiResponse {
+		tests = append(tests, item.workflow())
+	}
+	return tests, nil
+}
+
+func (c *RealClient) GetTestSuites(opts ListOptions) ([]Workflow, error) {
+	var apiResponse []legacyTestSuiteResponse
+	if err := c.getLegacy("test-suites", opts, &apiResponse); err != nil {
+		return nil, err
+	}
+	suites := make([]Workflow, 0, len(apiResponse))
+	for _, item := range apiResponse {
+		suites = append(suites, item.workflow())
+	}
+	return suites, nil
+}
+
+func (c *RealClient) GetLegacyExecutions(kind, name string, opts ListOptions) ([]Execution, error) {
+	resource, err := legacyResource(kind)
+	if err != nil {
+		return nil, err
+	}
+	opts = opts.normalized()
+	params := url.Values{}
+	params.Set("pageSize", strconv.Itoa(opts.PageSize))
+	params.Set("page", strconv.Itoa(opts.Page-1))
+	apiURL := fmt.Sprintf("%s/%s/%s/executions?%s", c.apiURL, resource, name, params.Encode())
+	resp, err := c.workflowRequest("GET", apiURL, nil, "application/json")
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("API returned %d: %s", resp.StatusCode, string(body))
+	}
 	var apiResponse struct {
-		Name      string    `json:"name"`
-		Namespace string    `json:"namespace"`
-		Created   time.Time `json:"created"`
-		Spec      struct {
-			Container struct {
-				Image string `json:"image"`
-			} `json:"container"`
-		} `json:"spec"`
+		Results []legacyExecutionResponse `json:"results"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&apiResponse); err != nil {
+		return nil, fmt.Errorf("failed to parse response: %w", err)
+	}
+	executions := make([]Execution, 0, len(apiResponse.Results))
+	for _, item := range apiResponse.Results {
+		executions = append(executions, item.execution(kind))
+	}
+	return executions, nil
+}
+
+func (c *RealClient) RunLegacy(kind, name string, tags map[string]string) (*Execution, error) {
+	resource, err := legacyResource(kind)
+	if err != nil {
+		return nil, err
+	}
+	payload, err := json.Marshal(struct {
+		ExecutionLabels map[string]string `json:"executionLabels,omitempty"`
+	}{tags})
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode run options: %w", err)
+	}
+
+	apiURL := fmt.Sprintf("%s/%s/%s/executions", c.apiURL, resource, name)
+	req, err := http.NewRequest("POST", apiURL, bytes.NewReader(payload))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if c.token != "" {
+		req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", c.token))
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("API request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusCreated {
+		body, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("API returned %d: %s", resp.StatusCode, string(body))
+	}
+	var apiResponse legacyExecutionResponse
+	if err := json.NewDecoder(resp.Body).Decode(&apiResponse); err != nil {
+		return nil, fmt.Errorf("failed to parse response: %w", err)
+	}
+	exec := apiResponse.execution(kind)
+	if exec.WorkflowName == "" {
+		exec.WorkflowName = name
+	}
+	return &exec, nil
+}
+
+func (c *RealClient) GetLegacyExecutionLogs(id string) (string, error) {
+	apiURL := fmt.Sprintf("%s/executions/%s", c.apiURL, id)
+	resp, err := c.workflowRequest("GET", apiURL, nil, "application/json")
+	if err != nil {
+		return "", err
 	}
+	defer resp.Body.Close()
 
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return "", fmt.Errorf("API returned %d: %s", resp.StatusCode, string(body))
+	}
+	var apiResponse legacyExecutionResponse
 	if err := json.NewDecoder(resp.Body).Decode(&apiResponse); err != nil {
+		return "", fmt.Errorf("failed to parse response: %w", err)
+	}
+	if apiResponse.ExecutionResult == nil {
+		return "", nil
+	}
+	return apiResponse.ExecutionResult.Output, nil
+}
+
+// getLegacy lists one of the v1 API's resources into v. Servers that
+// dropped the v1 API answer 404, which lists none.
+func (c *RealClient) getLegacy(resource string, opts ListOptions, v interface{}) error {
+	if _, err := ParseSelector(opts.Selector); err != nil {
+		return err
+	}
+	apiURL := fmt.Sprintf("%s/%s", c.apiURL, resource)
+	if opts.Selector != "" {
+		apiURL += "?" + url.Values{"selector": {opts.Selector}}.Encode()
+	}
+	resp, err := c.workflowRequest("GET", apiURL, nil, "application/json")
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("API returned %d", resp.StatusCode)
+	}
+	if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+		return fmt.Errorf("failed to parse response: %w", err)
+	}
+	return nil
+}
+
+// workflowTemplateResponse is a template in the API's JSON representation
+type workflowTemplateResponse struct {
+	Name        string                 `json:"name"`
+	Namespace   string                 `json:"namespace"`
+	Description string                 `json:"description"`
+	Labels      map[string]string      `json:"labels"`
+	Created     time.Time              `json:"created"`
+	Spec        map[string]interface{} `json:"spec"`
+}
+
+func (t workflowTemplateResponse) template() WorkflowTemplate {
+	return WorkflowTemplate{
+		Name:        TemplateName(t.Name),
+		Namespace:   t.Namespace,
+		Description: t.Description,
+		Labels:      t.Labels,
+		Created:     t.Created,
+		Image:       specImage(t.Spec),
+		Parameters:  specParameters(t.Spec),
+	}
+}
+
+// decodeWorkflow reads a workflow from the API's JSON representation
+func decodeWorkflow(body io.Reader) (*Workflow, error) {
+	var apiResponse struct {
+		Name      string                 `json:"name"`
+		Namespace string                 `json:"namespace"`
+		Labels    map[string]string      `json:"labels"`
+		Created   time.Time              `json:"created"`
+		Spec      map[string]interface{} `json:"spec"`
+	}
+
+	if err := json.NewDecoder(body).Decode(&apiResponse); err != nil {
 		return nil, fmt.Errorf("failed to parse response: %w", err)
 	}
 
-	wf := &Workflow{
+	return &Workflow{
 		Name:      apiResponse.Name,
 		Namespace: apiResponse.Namespace,
+		Labels:    apiResponse.Labels,
 		Created:   apiResponse.Created,
-		Type:      extractWorkflowType(apiResponse.Spec.Container.Image),
+		Type:      DefaultTypes.DetectWorkflow(apiResponse.Labels, apiResponse.Spec),
+		Templates: templateRefs(apiResponse.Spec),
+	}, nil
+}
+
+// workflowRequest makes a request to a workflow endpoint, sending spec as
+// YAML when there is one
+func (c *RealClient) workflowRequest(method, apiURL string, spec []byte, accept string) (*http.Response, error) {
+	var body io.Reader
+	if spec != nil {
+		body = bytes.NewReader(spec)
+	}
+	req, err := http.NewRequest(method, apiURL, body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	if spec != nil {
+		req.Header.Set("Content-Type", "text/yaml")
+	}
+	req.Header.Set("Accept", accept)
+	if c.token != "" {
+		req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", c.token))
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("API request failed: %w", err)
+	}
+	return resp, nil
+}
+
+// workflowError reads the error from a failed workflow request
+func workflowError(resp *http.Response, name string) error {
+	body, _ := io.ReadAll(resp.Body)
+	switch resp.StatusCode {
+	case http.StatusNotFound:
+		return fmt.Errorf("workflow %s: %w", name, ErrWorkflowNotFound)
+	case http.StatusConflict:
+		return fmt.Errorf("workflow %s: %w", name, ErrWorkflowExists)
+	case http.StatusBadRequest, http.StatusUnprocessableEntity:
+		return fmt.Errorf("%w: %s", ErrInvalidWorkflowSpec, strings.TrimSpace(string(body)))
+	}
+	return fmt.Errorf("API returned %d: %s", resp.StatusCode, string(body))
+}
+
+func (c *RealClient) GetWorkflowSpec(name string) ([]byte, error) {
+	resp, err := c.workflowRequest("GET", fmt.Sprintf("%s/test-workflows/%s", c.apiURL, name), nil, "text/yaml")
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, workflowError(resp, name)
+	}
+	spec, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response: %w", err)
+	}
+	return spec, nil
+}
+
+func (c *RealClient) CreateWorkflow(spec []byte) (*Workflow, error) {
+	wf, err := ParseWorkflowSpec(spec)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := c.workflowRequest("POST", fmt.Sprintf("%s/test-workflows", c.apiURL), spec, "application/json")
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusCreated {
+		return nil, workflowError(resp, wf.Name)
+	}
+	return decodeWorkflow(resp.Body)
+}
+
+func (c *RealClient) UpdateWorkflow(name string, spec []byte) (*Workflow, error) {
+	wf, err := ParseWorkflowSpec(spec)
+	if err != nil {
+		return nil, err
+	}
+	if wf.Name != name {
+		return nil, fmt.Errorf("%w: metadata.name %q doesn't match workflow %s", ErrInvalidWorkflowSpec, wf.Name, name)
+	}
+
+	resp, err := c.workflowRequest("PUT", fmt.Sprintf("%s/test-workflows/%s", c.apiURL, name), spec, "application/json")
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, workflowError(resp, name)
+	}
+	return decodeWorkflow(resp.Body)
+}
+
+func (c *RealClient) GetWorkflowSchedules(name string) ([]WorkflowSchedule, error) {
+	return getSchedules(c, name)
+}
+
+func (c *RealClient) UpdateWorkflowSchedules(name string, schedules []WorkflowSchedule) error {
+	return updateSchedules(c, name, schedules)
+}
+
+func (c *RealClient) DeleteWorkflow(name string) error {
+	resp, err := c.workflowRequest("DELETE", fmt.Sprintf("%s/test-workflows/%s", c.apiURL, name), nil, "application/json")
+	if err != nil {
+		return err
 	}
+	defer resp.Body.Close()
 
-	return wf, nil
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusNoContent {
+		return workflowError(resp, name)
+	}
+	return nil
 }
 
-func (c *RealClient) RunWorkflow(name string) (*Execution, error) {
-	apiURL := fmt.Sprintf("%s/v1/test-workflows/%s/executions", c.baseURL, name)
-	req, err := http.NewRequest("POST", apiURL, strings.NewReader("{}"))
+func (c *RealClient) RunWorkflow(name string, opts RunOptions) (*Execution, error) {
+	payload, err := json.Marshal(struct {
+		Name   string            `json:"name,omitempty"`
+		Config map[string]string `json:"config,omitempty"`
+		Tags   map[string]string `json:"tags,omitempty"`
+		Target *ExecutionTarget  `json:"target,omitempty"`
+	}{
+		Name:   opts.Name,
+		Config: opts.Config,
+		Tags:   opts.Tags,
+		Target: opts.Target,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode run options: %w", err)
+	}
+
+	apiURL := fmt.Sprintf("%s/test-workflows/%s/executions", c.apiURL, name)
+	req, err := http.NewRequest("POST", apiURL, bytes.NewReader(payload))
 	if err != nil {
 		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
@@ -428,41 +949,21 @@ func (c *RealClient) RunWorkflow(name string) (*Execution, error) {
 		return nil, fmt.Errorf("API returned %d: %s", resp.StatusCode, string(body))
 	}
 
-	var apiResponse struct {
-		ID     string `json:"id"`
-		Name   string `json:"name"`
-		Number int    `json:"number"`
-		Workflow struct {
-			Name string `json:"name"`
-		} `json:"workflow"`
-		Result struct {
-			Status    string    `json:"status"`
-			StartTime time.Time `json:"startTime"`
-			EndTime   time.Time `json:"endTime"`
-		} `json:"result"`
-	}
-
+	var apiResponse apiExecution
 	if err := json.NewDecoder(resp.Body).Decode(&apiResponse); err != nil {
 		return nil, fmt.Errorf("failed to parse response: %w", err)
 	}
 
-	exec := &Execution{
-		ID:           apiResponse.ID,
-		Name:         apiResponse.Name,
-		WorkflowName: apiResponse.Workflow.Name,
-		Status:       apiResponse.Result.Status,
-		StartTime:    apiResponse.Result.StartTime,
-		EndTime:      apiResponse.Result.EndTime,
-	}
-
-	return exec, nil
+	c.enrichment.invalidate()
+	exec := apiResponse.toExecution()
+	return &exec, nil
 }
 
-func (c *RealClient) GetExecutionLogs(executionID string) (string, error) {
-	apiURL := fmt.Sprintf("%s/v1/test-workflow-executions/%s/logs", c.baseURL, executionID)
-	req, err := http.NewRequest("GET", apiURL, nil)
+func (c *RealClient) AbortExecution(id string) error {
+	apiURL := fmt.Sprintf("%s/test-workflow-executions/%s/abort", c.apiURL, id)
+	req, err := http.NewRequest("POST", apiURL, nil)
 	if err != nil {
-		return "", fmt.Errorf("failed to create request: %w", err)
+		return fmt.Errorf("failed to create request: %w", err)
 	}
 
 	if c.token != "" {
@@ -471,15 +972,32 @@ func (c *RealClient) GetExecutionLogs(executionID string) (string, error) {
 
 	resp, err := c.httpClient.Do(req)
 	if err != nil {
-		return "", fmt.Errorf("API request failed: %w", err)
+		return fmt.Errorf("API request failed: %w", err)
 	}
 	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK {
-		return "", fmt.Errorf("API returned %d", resp.StatusCode)
+	switch resp.StatusCode {
+	case http.StatusOK, http.StatusNoContent:
+		c.enrichment.invalidate()
+		return nil
+	case http.StatusNotFound:
+		return fmt.Errorf("execution %s not found", id)
+	case http.StatusConflict, http.StatusBadRequest:
+		// The API refuses to abort an execution that is no longer running
+		return fmt.Errorf("execution %s: %w", id, ErrExecutionFinished)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	return fmt.Errorf("API returned %d: %s", resp.StatusCode, string(body))
+}
+
+func (c *RealClient) GetExecutionLogs(executionID string) (string, error) {
+	body, err := c.OpenExecutionLogs(executionID)
+	if err != nil {
+		return "", err
 	}
+	defer body.Close()
 
-	data, err := io.ReadAll(resp.Body)
+	data, err := io.ReadAll(body)
 	if err != nil {
 		return "", fmt.Errorf("failed to read response: %w", err)
 	}
@@ -487,101 +1005,97 @@ func (c *RealClient) GetExecutionLogs(executionID string) (string, error) {
 	return string(data), nil
 }
 
-func (c *RealClient) StreamExecutionLogs(ctx context.Context, executionID string) (<-chan string, <-chan error) {
-	logsCh := make(chan string)
-	errCh := make(chan error)
+func (c *RealClient) OpenExecutionLogs(executionID string) (io.ReadCloser, error) {
+	if c.grpcLogs != nil {
+		return c.grpcLogs.open(context.Background(), executionID)
+	}
 
-	go func() {
-		defer close(logsCh)
-		defer close(errCh)
+	apiURL := fmt.Sprintf("%s/test-workflow-executions/%s/logs", c.apiURL, executionID)
+	req, err := http.NewRequest("GET", apiURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
 
-		apiURL := fmt.Sprintf("%s/v1/test-workflow-executions/%s/logs", c.baseURL, executionID)
-		req, err := http.NewRequest("GET", apiURL, nil)
-		if err != nil {
-			errCh <- fmt.Errorf("failed to create request: %w", err)
-			return
-		}
+	if c.token != "" {
+		req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", c.token))
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("API request failed: %w", err)
+	}
 
-		req = req.WithContext(ctx)
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return nil, fmt.Errorf("API returned %d", resp.StatusCode)
+	}
 
-		if c.token != "" {
-			req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", c.token))
-		}
+	return resp.Body, nil
+}
 
-		// Use a client without timeout for streaming
-		client := &http.Client{}
+// StreamExecutionLogs follows an execution's notification stream, sending
+// its log lines as they are written. The channel closes when the execution
+// finishes, the stream breaks off (the last line then has Err set) or ctx
+// is done.
+func (c *RealClient) StreamExecutionLogs(ctx context.Context, executionID string) (<-chan LogLine, error) {
+	if c.grpcLogs != nil {
+		return c.grpcLogs.stream(ctx, executionID)
+	}
 
-		resp, err := client.Do(req)
-		if err != nil {
-			errCh <- fmt.Errorf("API request failed: %w", err)
-			return
-		}
+	apiURL := fmt.Sprintf("%s/test-workflow-executions/%s/notifications/stream", c.apiURL, executionID)
+	req, err := http.NewRequestWithContext(ctx, "GET", apiURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Accept", "text/event-stream")
+	if c.token != "" {
+		req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", c.token))
+	}
+
+	// Use a client without timeout for streaming
+	client := &http.Client{Transport: c.httpClient.Transport}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("API request failed: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
 		defer resp.Body.Close()
+		body, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("API returned %d: %s", resp.StatusCode, string(body))
+	}
 
-		if resp.StatusCode != http.StatusOK {
-			body, _ := io.ReadAll(resp.Body)
-			errCh <- fmt.Errorf("API returned %d: %s", resp.StatusCode, string(body))
-			return
-		}
+	lines := make(chan LogLine)
+	go func() {
+		defer close(lines)
+		defer resp.Body.Close()
 
-		reader := bufio.NewScanner(resp.Body)
-		for reader.Scan() {
+		send := func(line LogLine) bool {
 			select {
 			case <-ctx.Done():
-				return
-			case logsCh <- reader.Text():
+				return false
+			case lines <- line:
+				return true
 			}
 		}
-
-		if err := reader.Err(); err != nil {
-			errCh <- fmt.Errorf("error reading logs: %w", err)
+		if err := readLogNotifications(resp.Body, send); err != nil && ctx.Err() == nil {
+			send(LogLine{Time: time.Now(), Err: fmt.Errorf("error reading logs: %w", err)})
 		}
 	}()
 
-	return logsCh, errCh
+	return lines, nil
 }
 
-// Helper function to extract workflow type from container image
+// extractWorkflowType detects a workflow's type from its container image
+// with the default type registry
 func extractWorkflowType(image string) string {
-	lowerImage := strings.ToLower(image)
-	switch {
-	case strings.Contains(lowerImage, "playwright"):
-		return "playwright"
-	case strings.Contains(lowerImage, "vitest"):
-		return "vitest"
-	case strings.Contains(lowerImage, "k6"):
-		return "k6"
-	case strings.Contains(lowerImage, "postman"):
-		return "postman"
-	case strings.Contains(lowerImage, "cypress"):
-		return "cypress"
-	case strings.Contains(lowerImage, "trivy"):
-		return "trivy"
-	case strings.Contains(lowerImage, "kubescape"):
-		return "kubescape"
-	case strings.Contains(lowerImage, "sonarqube"):
-		return "sonarqube"
-	case strings.Contains(lowerImage, "semgrep"):
-		return "semgrep"
-	case strings.Contains(lowerImage, "defectdojo") || strings.Contains(lowerImage, "defect-dojo"):
-		return "defectdojo"
-	case strings.Contains(lowerImage, "chaos-mesh") || strings.Contains(lowerImage, "chaosmesh"):
-		return "chaosmesh"
-	case strings.Contains(lowerImage, "signoz"):
-		return "signoz"
-	case strings.Contains(lowerImage, "testtrace"):
-		return "testtrace"
-	case strings.Contains(lowerImage, "infracost"):
-		return "infracost"
-	case strings.Contains(lowerImage, "emba"):
-		return "emba"
-	case strings.Contains(lowerImage, "emqtt-bench"):
-		return "emqtt-bench"
-	case strings.Contains(lowerImage, "thingboard") || strings.Contains(lowerImage, "thingsboard"):
-		return "thingboard"
-	case strings.Contains(lowerImage, "kubekert"):
-		return "kubekert"
-	default:
-		return "custom"
-	}
+	return DefaultTypes.Detect(image)
+}
+
+// WatchExecutions checks the latest executions every TESTKUBE_WATCH_INTERVAL
+// and sends the ones whose status changed. Every watch shares the one check.
+func (c *RealClient) WatchExecutions(ctx context.Context) (<-chan ExecutionEvent, error) {
+	return c.watch.Watch(ctx, c.watchInterval, func() ([]Execution, error) {
+		return c.GetExecutions(ListOptions{PageSize: watchPageSize})
+	})
 }
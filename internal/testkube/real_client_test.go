@@ -1,10 +1,15 @@
 package testkube
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
 	"net/http"
 	"net/http/httptest"
 	"os"
+	"strings"
 	"testing"
 	"time"
 )
@@ -169,7 +174,7 @@ func TestRealClient_GetWorkflows(t *testing.T) {
 		t.Fatalf("failed to create client: %v", err)
 	}
 
-	workflows, err := client.GetWorkflows()
+	workflows, err := client.GetWorkflows(ListOptions{})
 	if err != nil {
 		t.Fatalf("GetWorkflows failed: %v", err)
 	}
@@ -188,6 +193,378 @@ func TestRealClient_GetWorkflows(t *testing.T) {
 	}
 }
 
+func TestRealClient_GetWorkflowsEnrichment(t *testing.T) {
+	now := time.Now()
+	var bulkRequests, workflowRequests int
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/health":
+			w.WriteHeader(http.StatusOK)
+		case r.URL.Path == "/v1/test-workflows":
+			var workflows []map[string]string
+			for i := 0; i < 80; i++ {
+				workflows = append(workflows, map[string]string{"name": fmt.Sprintf("wf-%d", i)})
+			}
+			json.NewEncoder(w).Encode(workflows)
+		case r.URL.Path == "/v1/test-workflow-executions":
+			bulkRequests++
+			// Only the first 40 workflows have run: wf-0 failed last, after
+			// three passes
+			var results []map[string]interface{}
+			for i := 0; i < 40; i++ {
+				status := "passed"
+				if i == 0 {
+					status = "failed"
+				}
+				results = append(results, map[string]interface{}{
+					"id":       fmt.Sprintf("exec-%d", i),
+					"workflow": map[string]string{"name": fmt.Sprintf("wf-%d", i)},
+					"result":   map[string]interface{}{"status": status, "startTime": now.Add(-time.Minute)},
+				})
+			}
+			for i := 0; i < 3; i++ {
+				results = append(results, map[string]interface{}{
+					"id":       fmt.Sprintf("exec-0-%d", i),
+					"workflow": map[string]string{"name": "wf-0"},
+					"result":   map[string]interface{}{"status": "passed", "startTime": now.Add(-time.Hour)},
+				})
+			}
+			json.NewEncoder(w).Encode(map[string]interface{}{"totals": map[string]int{"results": len(results)}, "results": results})
+		case strings.HasSuffix(r.URL.Path, "/executions"):
+			workflowRequests++
+			w.WriteHeader(http.StatusNotFound)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer ts.Close()
+
+	client, err := NewRealClientWithConfig(ClientConfig{URL: ts.URL})
+	if err != nil {
+		t.Fatalf("failed to create client: %v", err)
+	}
+
+	workflows, err := client.GetWorkflows(ListOptions{})
+	if err != nil {
+		t.Fatalf("GetWorkflows failed: %v", err)
+	}
+	if len(workflows) != 80 {
+		t.Fatalf("expected 80 workflows, got %d", len(workflows))
+	}
+	if bulkRequests != 1 || workflowRequests != 0 {
+		t.Errorf("expected 1 executions request, got %d bulk and %d per workflow", bulkRequests, workflowRequests)
+	}
+	if workflows[0].LastStatus != "failed" || workflows[0].PassRateLast7d != 75 {
+		t.Errorf("expected wf-0 failed with 75%% pass rate, got %s with %d%%", workflows[0].LastStatus, workflows[0].PassRateLast7d)
+	}
+	if workflows[1].LastStatus != "passed" || workflows[1].PassRateLast7d != 100 {
+		t.Errorf("expected wf-1 passed with 100%% pass rate, got %s with %d%%", workflows[1].LastStatus, workflows[1].PassRateLast7d)
+	}
+	if !workflows[50].LastRun.IsZero() {
+		t.Errorf("expected wf-50 never to have run, got %s", workflows[50].LastRun)
+	}
+
+	// Within the TTL the list reuses the stats
+	if _, err := client.GetWorkflows(ListOptions{}); err != nil {
+		t.Fatalf("GetWorkflows failed: %v", err)
+	}
+	if bulkRequests != 1 {
+		t.Errorf("expected cached stats, got %d executions requests", bulkRequests)
+	}
+
+	client.enrichment.invalidate()
+	if _, err := client.GetWorkflows(ListOptions{}); err != nil {
+		t.Fatalf("GetWorkflows failed: %v", err)
+	}
+	if bulkRequests != 2 {
+		t.Errorf("expected stats to be read again after invalidation, got %d executions requests", bulkRequests)
+	}
+}
+
+func TestRealClient_StreamExecutionLogs(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/health" {
+			w.WriteHeader(http.StatusOK)
+			return
+		}
+		if r.URL.Path != "/v1/test-workflow-executions/exec-1/notifications/stream" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "text/event-stream")
+		fmt.Fprint(w, ": keepalive\n\n")
+		fmt.Fprint(w, "event: message\ndata: {\"ts\":\"2024-06-03T10:00:00Z\",\"ref\":\"setup\",\"log\":\"Cloning repository...\\nInstalling dependencies...\\n\"}\n\n")
+		fmt.Fprint(w, "data: {\"ts\":\"2024-06-03T10:00:05Z\",\"result\":{\"status\":\"running\"}}\n\n")
+		fmt.Fprint(w, "data: {\"ts\":\"2024-06-03T10:00:09Z\",\"ref\":\"run\",\"log\":\"Running tests...\"}\n")
+	}))
+	defer ts.Close()
+
+	os.Setenv("TESTKUBE_API_URL", ts.URL)
+	defer os.Unsetenv("TESTKUBE_API_URL")
+	client, err := NewRealClient()
+	if err != nil {
+		t.Fatalf("failed to create client: %v", err)
+	}
+
+	lines, err := client.StreamExecutionLogs(context.Background(), "exec-1")
+	if err != nil {
+		t.Fatalf("StreamExecutionLogs failed: %v", err)
+	}
+	var got []LogLine
+	for line := range lines {
+		got = append(got, line)
+	}
+	if len(got) != 3 {
+		t.Fatalf("got %+v, expected 3 lines", got)
+	}
+	if got[0].Text != "Cloning repository..." || got[0].Step != "setup" || got[0].Time.Second() != 0 {
+		t.Errorf("got %+v, expected the first setup line", got[0])
+	}
+	if got[2].Text != "Running tests..." || got[2].Step != "run" || got[2].Err != nil {
+		t.Errorf("got %+v, expected the last event without a trailing blank line", got[2])
+	}
+
+	if _, err := client.StreamExecutionLogs(context.Background(), "missing"); err == nil {
+		t.Error("expected an error for a missing execution")
+	}
+}
+
+func TestRealClient_AbortExecution(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/health":
+			w.WriteHeader(http.StatusOK)
+		case r.Method == "POST" && r.URL.Path == "/v1/test-workflow-executions/exec-1/abort":
+			w.WriteHeader(http.StatusNoContent)
+		case r.Method == "POST" && r.URL.Path == "/v1/test-workflow-executions/exec-2/abort":
+			http.Error(w, "execution is not running", http.StatusConflict)
+		case r.Method == "POST" && r.URL.Path == "/v1/test-workflow-executions/exec-3/abort":
+			http.Error(w, "execution already finished", http.StatusBadRequest)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer ts.Close()
+
+	os.Setenv("TESTKUBE_API_URL", ts.URL)
+	defer os.Unsetenv("TESTKUBE_API_URL")
+	client, err := NewRealClient()
+	if err != nil {
+		t.Fatalf("failed to create client: %v", err)
+	}
+
+	if err := client.AbortExecution("exec-1"); err != nil {
+		t.Errorf("AbortExecution failed: %v", err)
+	}
+	if err := client.AbortExecution("missing"); err == nil {
+		t.Error("expected an error for a missing execution")
+	}
+	for _, id := range []string{"exec-2", "exec-3"} {
+		if err := client.AbortExecution(id); !errors.Is(err, ErrExecutionFinished) {
+			t.Errorf("AbortExecution(%s) = %v, expected ErrExecutionFinished", id, err)
+		}
+	}
+}
+
+func TestRealClient_GetExecutionPage(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/health":
+			w.WriteHeader(http.StatusOK)
+			return
+		case "/v1/test-workflow-executions":
+		default:
+			http.NotFound(w, r)
+			return
+		}
+		// Testkube pages are 0-based
+		if r.URL.Query().Get("page") != "1" || r.URL.Query().Get("pageSize") != "2" {
+			t.Errorf("got page=%s pageSize=%s, expected page=1 pageSize=2", r.URL.Query().Get("page"), r.URL.Query().Get("pageSize"))
+		}
+		if got := r.URL.Query().Get("selector"); got != "team=payments,priority in (high)" {
+			t.Errorf("got selector %q, expected it passed through", got)
+		}
+		if got := r.URL.Query().Get("tagSelector"); got != "ci-branch=feature/login,ci-commit=abc123" {
+			t.Errorf("got tagSelector %q, expected the branch and commit tags", got)
+		}
+		fmt.Fprint(w, `{"totals": {"results": 9}, "filtered": {"results": 5}, "results": [{"id": "e3", "tags": {"ci-branch": "feature/login", "ci-commit": "abc123"}}, {"id": "e4"}]}`)
+	}))
+	defer ts.Close()
+
+	os.Setenv("TESTKUBE_API_URL", ts.URL)
+	defer os.Unsetenv("TESTKUBE_API_URL")
+	client, err := NewRealClient()
+	if err != nil {
+		t.Fatalf("failed to create client: %v", err)
+	}
+
+	page, err := client.GetExecutionPage(ListOptions{Page: 2, PageSize: 2, Selector: "team=payments,priority in (high)", Branch: "feature/login", Commit: "abc123"})
+	if err != nil {
+		t.Fatalf("GetExecutionPage failed: %v", err)
+	}
+	if len(page.Executions) != 2 || page.Page != 2 || page.Total != 5 {
+		t.Errorf("got %d executions on page %d of %d total, expected 2 on page 2 of 5", len(page.Executions), page.Page, page.Total)
+	}
+	if e := page.Executions[0]; e.Branch != "feature/login" || e.Commit != "abc123" {
+		t.Errorf("got branch %q at %q, expected feature/login at abc123", e.Branch, e.Commit)
+	}
+	if page.TotalPages() != 3 || !page.HasNext() {
+		t.Errorf("got %d pages, expected 3 with a next page", page.TotalPages())
+	}
+
+	if _, err := client.GetExecutionPage(ListOptions{Selector: "team in payments"}); !errors.Is(err, ErrInvalidSelector) {
+		t.Errorf("got %v, expected an invalid selector error", err)
+	}
+}
+
+func TestRealClient_WorkflowCRUD(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/health":
+			w.WriteHeader(http.StatusOK)
+		case r.Method == "POST" && r.URL.Path == "/v1/test-workflows":
+			if ct := r.Header.Get("Content-Type"); ct != "text/yaml" {
+				t.Errorf("got Content-Type %q, expected text/yaml", ct)
+			}
+			w.WriteHeader(http.StatusCreated)
+			fmt.Fprint(w, `{"name": "checkout-e2e", "namespace": "testkube", "spec": {"container": {"image": "playwright:v1"}}}`)
+		case r.Method == "GET" && r.URL.Path == "/v1/test-workflows/checkout-e2e":
+			if accept := r.Header.Get("Accept"); accept != "text/yaml" {
+				t.Errorf("got Accept %q, expected text/yaml", accept)
+			}
+			fmt.Fprint(w, testWorkflowSpec)
+		case r.Method == "PUT" && r.URL.Path == "/v1/test-workflows/checkout-e2e":
+			fmt.Fprint(w, `{"name": "checkout-e2e", "labels": {"team": "checkout"}}`)
+		case r.Method == "DELETE" && r.URL.Path == "/v1/test-workflows/checkout-e2e":
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer ts.Close()
+
+	os.Setenv("TESTKUBE_API_URL", ts.URL)
+	defer os.Unsetenv("TESTKUBE_API_URL")
+	client, err := NewRealClient()
+	if err != nil {
+		t.Fatalf("failed to create client: %v", err)
+	}
+
+	wf, err := client.CreateWorkflow([]byte(testWorkflowSpec))
+	if err != nil {
+		t.Fatalf("CreateWorkflow failed: %v", err)
+	}
+	if wf.Name != "checkout-e2e" || wf.Type != "playwright" {
+		t.Errorf("got %+v, expected the created playwright workflow", wf)
+	}
+	if _, err := client.CreateWorkflow([]byte("kind: Test")); !errors.Is(err, ErrInvalidWorkflowSpec) {
+		t.Errorf("got %v, expected an invalid spec to be rejected before calling the API", err)
+	}
+
+	spec, err := client.GetWorkflowSpec("checkout-e2e")
+	if err != nil || string(spec) != testWorkflowSpec {
+		t.Errorf("got %q, %v, expected the workflow's YAML", spec, err)
+	}
+	if _, err := client.GetWorkflowSpec("missing"); !errors.Is(err, ErrWorkflowNotFound) {
+		t.Errorf("got %v, expected ErrWorkflowNotFound", err)
+	}
+
+	if wf, err := client.UpdateWorkflow("checkout-e2e", []byte(testWorkflowSpec)); err != nil || wf.Labels["team"] != "checkout" {
+		t.Errorf("got %+v, %v, expected the updated workflow", wf, err)
+	}
+	if err := client.DeleteWorkflow("checkout-e2e"); err != nil {
+		t.Errorf("DeleteWorkflow failed: %v", err)
+	}
+	if err := client.DeleteWorkflow("missing"); !errors.Is(err, ErrWorkflowNotFound) {
+		t.Errorf("got %v, expected ErrWorkflowNotFound", err)
+	}
+}
+
+func TestRealClient_WorkflowTemplates(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/health":
+			w.WriteHeader(http.StatusOK)
+		case "/v1/test-workflow-templates":
+			if selector := r.URL.Query().Get("selector"); selector != "testkube.io/official=true" {
+				t.Errorf("got selector %q, expected the label selector", selector)
+			}
+			fmt.Fprint(w, `[{"name": "official--k6--v1", "namespace": "testkube", "spec": {"container": {"image": "grafana/k6:0.49.0"}, "config": {"version": {}, "params": {}}}}]`)
+		case "/v1/test-workflow-templates/official--k6--v1":
+			fmt.Fprint(w, `{"name": "official--k6--v1", "description": "Run k6 load tests"}`)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer ts.Close()
+
+	os.Setenv("TESTKUBE_API_URL", ts.URL)
+	defer os.Unsetenv("TESTKUBE_API_URL")
+	client, err := NewRealClient()
+	if err != nil {
+		t.Fatalf("failed to create client: %v", err)
+	}
+
+	templates, err := client.GetWorkflowTemplates(ListOptions{Selector: "testkube.io/official=true"})
+	if err != nil {
+		t.Fatalf("GetWorkflowTemplates failed: %v", err)
+	}
+	if len(templates) != 1 || templates[0].Name != "official/k6/v1" || templates[0].Image != "grafana/k6:0.49.0" ||
+		strings.Join(templates[0].Parameters, ",") != "params,version" {
+		t.Errorf("got %+v, expected the k6 template", templates)
+	}
+
+	template, err := client.GetWorkflowTemplate("official/k6/v1")
+	if err != nil || template.Description != "Run k6 load tests" {
+		t.Errorf("got %+v, %v, expected the k6 template", template, err)
+	}
+	if _, err := client.GetWorkflowTemplate("missing"); !errors.Is(err, ErrWorkflowTemplateNotFound) {
+		t.Errorf("got %v, expected ErrWorkflowTemplateNotFound", err)
+	}
+}
+
+func TestRealClient_RunWorkflow(t *testing.T) {
+	var body map[string]interface{}
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/health" {
+			w.WriteHeader(http.StatusOK)
+			return
+		}
+		if r.Method != "POST" || r.URL.Path != "/v1/test-workflows/checkout-e2e/executions" {
+			http.NotFound(w, r)
+			return
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+		fmt.Fprint(w, `{"id": "exec-1", "name": "release-check", "workflow": {"name": "checkout-e2e"}, "result": {"status": "queued"}}`)
+	}))
+	defer ts.Close()
+
+	os.Setenv("TESTKUBE_API_URL", ts.URL)
+	defer os.Unsetenv("TESTKUBE_API_URL")
+	client, err := NewRealClient()
+	if err != nil {
+		t.Fatalf("failed to create client: %v", err)
+	}
+
+	exec, err := client.RunWorkflow("checkout-e2e", RunOptions{
+		Name:   "release-check",
+		Config: map[string]string{"browser": "firefox"},
+		Tags:   map[string]string{"release": "1.2"},
+		Target: &ExecutionTarget{Match: map[string][]string{"region": {"eu"}}},
+	})
+	if err != nil {
+		t.Fatalf("RunWorkflow failed: %v", err)
+	}
+	if exec.ID != "exec-1" {
+		t.Errorf("got execution %s, expected exec-1", exec.ID)
+	}
+	payload, _ := json.Marshal(body)
+	expected := `{"config":{"browser":"firefox"},"name":"release-check","tags":{"release":"1.2"},"target":{"match":{"region":["eu"]}}}`
+	if string(payload) != expected {
+		t.Errorf("got payload %s, expected %s", payload, expected)
+	}
+}
+
 func TestExtractWorkflowType(t *testing.T) {
 	tests := []struct {
 		image    string
@@ -219,3 +596,307 @@ func TestExtractWorkflowType(t *testing.T) {
 		}
 	}
 }
+
+func TestRealClient_CloudMode(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/health":
+			w.WriteHeader(http.StatusOK)
+		case "/organizations/tkcorg_1/environments/tkcenv_2/agent/test-workflows":
+			if auth := r.Header.Get("Authorization"); auth != "Bearer tkcapi_key" {
+				t.Errorf("got Authorization %q, expected the API key", auth)
+			}
+			fmt.Fprint(w, `[{"name": "checkout-e2e", "namespace": "testkube"}]`)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer ts.Close()
+
+	client, err := NewRealClientWithConfig(ClientConfig{
+		URL:   ts.URL,
+		Token: "tkcapi_key",
+		Mode:  ModeCloud,
+		Cloud: CloudConfig{Organization: "tkcorg_1", Environment: "tkcenv_2"},
+	})
+	if err != nil {
+		t.Fatalf("failed to create client: %v", err)
+	}
+	workflows, err := client.GetWorkflows(ListOptions{})
+	if err != nil || len(workflows) != 1 || workflows[0].Name != "checkout-e2e" {
+		t.Errorf("got %+v, %v, expected the environment's workflow", workflows, err)
+	}
+
+	for _, cfg := range []ClientConfig{
+		{URL: ts.URL, Mode: ModeCloud, Token: "tkcapi_key", Cloud: CloudConfig{Organization: "tkcorg_1"}},
+		{URL: ts.URL, Mode: ModeCloud, Cloud: CloudConfig{Organization: "tkcorg_1", Environment: "tkcenv_2"}},
+		{URL: ts.URL, Mode: "enterprise"},
+	} {
+		if _, err := NewRealClientWithConfig(cfg); err == nil {
+			t.Errorf("got no error for %+v, expected the config to be rejected", cfg)
+		}
+	}
+}
+
+func TestRealClient_LegacyTests(t *testing.T) {
+	suites := true
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/health":
+			w.WriteHeader(http.StatusOK)
+		case r.URL.Path == "/v1/tests":
+			if selector := r.URL.Query().Get("selector"); selector != "team=frontend" {
+				t.Errorf("got selector %q, expected the label selector", selector)
+			}
+			fmt.Fprint(w, `[{"name": "checkout-cypress", "namespace": "testkube", "type": "cypress/project", "labels": {"team": "frontend"}}]`)
+		case r.URL.Path == "/v1/test-suites" && suites:
+			fmt.Fprint(w, `[{"name": "nightly", "namespace": "testkube", "before": [{"execute": [{"test": "seed"}]}],
+				"steps": [{"execute": [{"test": "checkout-cypress"}, {"delay": "1s"}]}]}]`)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer ts.Close()
+
+	client, err := NewRealClientWithConfig(ClientConfig{URL: ts.URL})
+	if err != nil {
+		t.Fatalf("failed to create client: %v", err)
+	}
+
+	tests, err := client.GetTests(ListOptions{Selector: "team=frontend"})
+	if err != nil || len(tests) != 1 || tests[0].Kind != KindTest || tests[0].Type != "cypress" || !tests[0].Legacy() {
+		t.Errorf("got %+v, %v, expected the cypress Test", tests, err)
+	}
+	list, err := client.GetTestSuites(ListOptions{})
+	if err != nil || len(list) != 1 || list[0].Kind != KindTestSuite || strings.Join(list[0].Tests, ",") != "seed,checkout-cypress" {
+		t.Errorf("got %+v, %v, expected the nightly TestSuite", list, err)
+	}
+
+	// Servers without the v1 API have none
+	suites = false
+	if list, err := client.GetTestSuites(ListOptions{}); err != nil || len(list) != 0 {
+		t.Errorf("got %+v, %v, expected no suites", list, err)
+	}
+}
+
+func TestRealClient_LegacyExecutions(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/health":
+			w.WriteHeader(http.StatusOK)
+		case r.URL.Path == "/v1/tests/checkout-cypress/executions" && r.Method == "GET":
+			if r.URL.Query().Get("page") != "0" {
+				t.Errorf("got page %s, expected the first", r.URL.Query().Get("page"))
+			}
+			fmt.Fprint(w, `{"totals": {"results": 1}, "results": [{"id": "t-1", "name": "checkout-cypress-1", "testName": "checkout-cypress", "status": "failed",
+				"startTime": "2024-06-01T02:00:00Z", "endTime": "2024-06-01T02:03:00Z", "labels": {"trigger": "schedule"}}]}`)
+		case r.URL.Path == "/v1/test-suites/nightly/executions" && r.Method == "POST":
+			var body struct {
+				ExecutionLabels map[string]string `json:"executionLabels"`
+			}
+			json.NewDecoder(r.Body).Decode(&body)
+			if body.ExecutionLabels["triggered-by"] != "alice" {
+				t.Errorf("got labels %v, expected the run's tags", body.ExecutionLabels)
+			}
+			w.WriteHeader(http.StatusCreated)
+			fmt.Fprint(w, `{"id": "s-2", "name": "nightly-2", "testSuite": {"name": "nightly"}, "status": "queued", "labels": {"triggered-by": "alice"}}`)
+		case r.URL.Path == "/v1/executions/t-1":
+			fmt.Fprint(w, `{"id": "t-1", "testName": "checkout-cypress", "executionResult": {"status": "failed", "output": "1 failing"}}`)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer ts.Close()
+
+	client, err := NewRealClientWithConfig(ClientConfig{URL: ts.URL})
+	if err != nil {
+		t.Fatalf("failed to create client: %v", err)
+	}
+
+	executions, err := client.GetLegacyExecutions(KindTest, "checkout-cypress", ListOptions{})
+	if err != nil || len(executions) != 1 {
+		t.Fatalf("got %+v, %v, expected one execution", executions, err)
+	}
+	if e := executions[0]; e.Kind != KindTest || e.WorkflowName != "checkout-cypress" || e.Duration != 3*time.Minute || e.Trigger != TriggerSchedule || e.Outcome != OutcomeFailed {
+		t.Errorf("got %+v, expected a failed scheduled run of 3m", e)
+	}
+
+	exec, err := client.RunLegacy(KindTestSuite, "nightly", map[string]string{TriggeredByTag: "alice"})
+	if err != nil || exec.ID != "s-2" || exec.WorkflowName != "nightly" || exec.Kind != KindTestSuite {
+		t.Errorf("got %+v, %v, expected the suite's new execution", exec, err)
+	}
+	if logs, err := client.GetLegacyExecutionLogs("t-1"); err != nil || logs != "1 failing" {
+		t.Errorf("got %q, %v, expected the test's output", logs, err)
+	}
+	if _, err := client.RunLegacy(KindTestWorkflow, "nightly", nil); !errors.Is(err, ErrUnknownKind) {
+		t.Errorf("got %v, expected an unknown kind error", err)
+	}
+}
+
+func TestRealClient_StartedRange(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/health":
+			w.WriteHeader(http.StatusOK)
+			return
+		case "/v1/test-workflow-executions":
+		default:
+			http.NotFound(w, r)
+			return
+		}
+		if start, end := r.URL.Query().Get("startDate"), r.URL.Query().Get("endDate"); start != "2026-03-09" || end != "2026-03-10" {
+			t.Errorf("got startDate=%s endDate=%s, expected the days spanning the range", start, end)
+		}
+		// The server filters by whole days
+		fmt.Fprint(w, `{"results": [
+			{"id": "e3", "result": {"startTime": "2026-03-10T23:00:00Z"}},
+			{"id": "e2", "result": {"startTime": "2026-03-10T09:00:00Z"}},
+			{"id": "e1", "result": {"startTime": "2026-03-09T08:00:00Z"}}]}`)
+	}))
+	defer ts.Close()
+
+	client, err := NewRealClientWithConfig(ClientConfig{URL: ts.URL})
+	if err != nil {
+		t.Fatalf("failed to create client: %v", err)
+	}
+	executions, err := client.GetExecutions(ListOptions{
+		StartedAfter:  time.Date(2026, 3, 9, 12, 0, 0, 0, time.UTC),
+		StartedBefore: time.Date(2026, 3, 10, 12, 0, 0, 0, time.UTC),
+	})
+	if err != nil || len(executions) != 1 || executions[0].ID != "e2" {
+		t.Errorf("got %+v, %v, expected only the execution in the range", executions, err)
+	}
+}
+
+func TestRealClient_DownloadArtifactStream(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.EscapedPath() {
+		case "/health":
+			w.WriteHeader(http.StatusOK)
+		case "/v1/test-workflow-executions/exec-1/artifacts/trace%2Ftrace.zip":
+			fmt.Fprint(w, "trace contents")
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer ts.Close()
+
+	client, err := NewRealClientWithConfig(ClientConfig{URL: ts.URL})
+	if err != nil {
+		t.Fatalf("failed to create client: %v", err)
+	}
+
+	body, err := client.DownloadArtifactStream("exec-1", "trace/trace.zip")
+	if err != nil {
+		t.Fatalf("DownloadArtifactStream failed: %v", err)
+	}
+	data, err := io.ReadAll(body)
+	body.Close()
+	if err != nil || string(data) != "trace contents" {
+		t.Errorf("got %q, %v, expected the artifact's content", data, err)
+	}
+
+	if _, err := client.DownloadArtifactStream("exec-1", "missing.zip"); err == nil {
+		t.Error("expected an error for a missing artifact")
+	}
+	if data, err := client.DownloadArtifact("exec-1", "trace/trace.zip"); err != nil || string(data) != "trace contents" {
+		t.Errorf("got %q, %v, expected DownloadArtifact to read the stream", data, err)
+	}
+}
+
+func TestRealClient_GetExecutionResources(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/health":
+			w.WriteHeader(http.StatusOK)
+		case "/v1/test-workflow-executions/exec-1":
+			fmt.Fprint(w, `{"id": "exec-1", "namespace": "testkube", "runnerId": "agent-eu",
+				"signature": [{"ref": "r1", "name": "Install"}, {"ref": "r2", "category": "Run shell command"}],
+				"result": {"steps": {"r2": {"status": "failed", "errorMessage": "Container terminated: OOMKilled"}}},
+				"resourceAggregations": {
+					"global": {"cpu": {"millicores": {"avg": 400, "max": 900}}, "memory": {"used": {"avg": 1048576, "max": 2097152}}},
+					"step": [{"ref": "r2", "aggregations": {"cpu": {"millicores": {"avg": 600, "max": 900}}}},
+						{"ref": "r1", "aggregations": {"cpu": {"millicores": {"avg": 100, "max": 200}}}}]}}`)
+		case "/v1/test-workflow-executions/exec-2":
+			fmt.Fprint(w, `{"id": "exec-2", "namespace": "testkube", "result": {"steps": {}}}`)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer ts.Close()
+
+	client, err := NewRealClientWithConfig(ClientConfig{URL: ts.URL})
+	if err != nil {
+		t.Fatalf("failed to create client: %v", err)
+	}
+
+	usage, err := client.GetExecutionResources("exec-1")
+	if err != nil {
+		t.Fatalf("GetExecutionResources failed: %v", err)
+	}
+	if usage.Namespace != "testkube" || usage.Runner != "agent-eu" || usage.CPUMillicores.Max != 900 || usage.MemoryBytes.Max != 2097152 {
+		t.Errorf("got %+v, expected the execution's placement and global usage", usage)
+	}
+	if len(usage.Steps) != 2 || usage.Steps[0].Name != "Install" || usage.Steps[1].Name != "Run shell command" {
+		t.Fatalf("got %+v, expected the steps in signature order", usage.Steps)
+	}
+	if !usage.OOMKilled() || !usage.Steps[1].OOMKilled || usage.Steps[1].CPUMillicores.Avg != 600 {
+		t.Errorf("got %+v, expected the second step to be OOMKilled", usage.Steps[1])
+	}
+
+	// Agents without metrics collection report none
+	usage, err = client.GetExecutionResources("exec-2")
+	if err != nil || usage.Measured() || usage.OOMKilled() {
+		t.Errorf("got %+v, %v, expected no usage", usage, err)
+	}
+	if _, err := client.GetExecutionResources("missing"); err == nil {
+		t.Error("expected an error for a missing execution")
+	}
+}
+
+func TestRealClient_GetExecutionInputs(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/health":
+			w.WriteHeader(http.StatusOK)
+		case "/v1/test-workflow-executions/exec-1":
+			fmt.Fprint(w, `{"id": "exec-1", "workflow": {"name": "web"}, "tags": {"trigger": "ci", "ci-commit": "abc123"},
+				"configParams": {
+					"browser": {"value": "firefox", "defaultValue": "chromium"},
+					"workers": {"defaultValue": "4"},
+					"grep": {"value": "", "emptyValue": true, "defaultValue": "@smoke"}},
+				"runnerTarget": {"match": {"region": ["eu"]}}}`)
+		case "/v1/test-workflow-executions/exec-2":
+			fmt.Fprint(w, `{"id": "exec-2", "configParams": {"token": {"sensitive": true}, "browser": {"value": "firefox"}}}`)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer ts.Close()
+
+	client, err := NewRealClientWithConfig(ClientConfig{URL: ts.URL})
+	if err != nil {
+		t.Fatalf("failed to create client: %v", err)
+	}
+
+	opts, err := client.GetExecutionInputs("exec-1")
+	if err != nil {
+		t.Fatalf("GetExecutionInputs failed: %v", err)
+	}
+	// Defaults the execution used are passed explicitly, and empty values
+	// stay empty
+	expected := map[string]string{"browser": "firefox", "workers": "4", "grep": ""}
+	if fmt.Sprint(opts.Config) != fmt.Sprint(expected) {
+		t.Errorf("got config %v, expected %v", opts.Config, expected)
+	}
+	if opts.Tags["ci-commit"] != "abc123" || opts.Target == nil || opts.Target.Match["region"][0] != "eu" {
+		t.Errorf("got %+v, expected the execution's tags and runner target", opts)
+	}
+
+	if _, err := client.GetExecutionInputs("exec-2"); !errors.Is(err, ErrInputsUnavailable) {
+		t.Errorf("got %v, expected ErrInputsUnavailable for a sensitive variable", err)
+	}
+	if _, err := client.GetExecutionInputs("missing"); err == nil {
+		t.Error("expected an error for a missing execution")
+	}
+}
@@ -0,0 +1,65 @@
+package testkube
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"sort"
+	"strings"
+)
+
+// ErrInputsUnavailable is returned for an execution whose inputs can't be
+// replayed as they were, e.g. because a config value was sensitive or
+// truncated by the API
+var ErrInputsUnavailable = errors.New("execution inputs unavailable")
+
+// apiConfigParam is a config variable as an execution recorded it
+type apiConfigParam struct {
+	Value        string `json:"value"`
+	EmptyValue   bool   `json:"emptyValue"`
+	DefaultValue string `json:"defaultValue"`
+	Truncated    bool   `json:"truncated"`
+	Sensitive    bool   `json:"sensitive"`
+}
+
+// apiExecutionInputs is the part of an execution's JSON representation
+// recording what it was started with
+type apiExecutionInputs struct {
+	ID           string                    `json:"id"`
+	Workflow     apiWorkflowRef            `json:"workflow"`
+	Tags         map[string]string         `json:"tags"`
+	ConfigParams map[string]apiConfigParam `json:"configParams"`
+	RunnerTarget *ExecutionTarget          `json:"runnerTarget"`
+}
+
+// apiWorkflowRef names an execution's workflow
+type apiWorkflowRef struct {
+	Name string `json:"name"`
+}
+
+// inputs returns the options that start the execution again. Variables
+// left at their default are passed explicitly, so a workflow whose default
+// changed since replays the value the execution used.
+func (e apiExecutionInputs) inputs() (*RunOptions, error) {
+	opts := &RunOptions{Tags: maps.Clone(e.Tags), Target: e.RunnerTarget}
+	var unavailable []string
+	for name, p := range e.ConfigParams {
+		if p.Sensitive || p.Truncated {
+			unavailable = append(unavailable, name)
+			continue
+		}
+		value := p.Value
+		if value == "" && !p.EmptyValue {
+			value = p.DefaultValue
+		}
+		if opts.Config == nil {
+			opts.Config = make(map[string]string, len(e.ConfigParams))
+		}
+		opts.Config[name] = value
+	}
+	if len(unavailable) > 0 {
+		sort.Strings(unavailable)
+		return nil, fmt.Errorf("%w: execution %s has sensitive or truncated config %s", ErrInputsUnavailable, e.ID, strings.Join(unavailable, ", "))
+	}
+	return opts, nil
+}
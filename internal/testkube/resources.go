@@ -0,0 +1,166 @@
+package testkube
+
+import (
+	"sort"
+	"strings"
+)
+
+// ResourceStats summarizes a resource metric sampled while an execution ran
+type ResourceStats struct {
+	Avg float64
+	Max float64
+}
+
+// ResourceUsage is an execution's pod resource usage and placement, as far
+// as the Testkube API reports them. Agents only aggregate usage when their
+// metrics collection is enabled, so Measured may be false even for finished
+// executions.
+type ResourceUsage struct {
+	ExecutionID string
+	// Namespace and Runner say where the execution's pods were scheduled:
+	// the namespace and, with several runner agents, the agent that ran it
+	Namespace     string
+	Runner        string
+	CPUMillicores ResourceStats
+	MemoryBytes   ResourceStats
+	Steps         []StepResourceUsage
+}
+
+// StepResourceUsage is one workflow step's share of the usage
+type StepResourceUsage struct {
+	Ref           string
+	Name          string
+	CPUMillicores ResourceStats
+	MemoryBytes   ResourceStats
+	// OOMKilled reports whether the step's container was killed for
+	// exceeding its memory limit
+	OOMKilled bool
+	Error     string
+}
+
+// Measured reports whether the agent reported usage for the execution
+func (u *ResourceUsage) Measured() bool {
+	return u.CPUMillicores.Max > 0 || u.MemoryBytes.Max > 0
+}
+
+// OOMKilled reports whether any step was killed for running out of memory
+func (u *ResourceUsage) OOMKilled() bool {
+	for _, step := range u.Steps {
+		if step.OOMKilled {
+			return true
+		}
+	}
+	return false
+}
+
+// apiResourceAggregation is one metric's statistics in the API's
+// resourceAggregations, e.g. global.cpu.millicores
+type apiResourceAggregation struct {
+	Avg float64 `json:"avg"`
+	Max float64 `json:"max"`
+}
+
+// apiResourceAggregations are an execution's metrics by measurement and
+// field, e.g. {"cpu": {"millicores": ...}, "memory": {"used": ...}}
+type apiResourceAggregations map[string]map[string]apiResourceAggregation
+
+func (a apiResourceAggregations) stats(measurement, field string) ResourceStats {
+	agg := a[measurement][field]
+	return ResourceStats{Avg: agg.Avg, Max: agg.Max}
+}
+
+// apiSignatureStep is a step in an execution's signature, which names the
+// steps its results and aggregations refer to by ref
+type apiSignatureStep struct {
+	Ref      string             `json:"ref"`
+	Name     string             `json:"name"`
+	Category string             `json:"category"`
+	Children []apiSignatureStep `json:"children"`
+}
+
+// apiExecutionResources is the part of an execution's JSON representation
+// describing its pods
+type apiExecutionResources struct {
+	ID        string             `json:"id"`
+	Namespace string             `json:"namespace"`
+	RunnerID  string             `json:"runnerId"`
+	Signature []apiSignatureStep `json:"signature"`
+	Result    struct {
+		Steps map[string]struct {
+			Status       string `json:"status"`
+			ErrorMessage string `json:"errorMessage"`
+		} `json:"steps"`
+	} `json:"result"`
+	ResourceAggregations *struct {
+		Global apiResourceAggregations `json:"global"`
+		Step   []struct {
+			Ref          string                  `json:"ref"`
+			Aggregations apiResourceAggregations `json:"aggregations"`
+		} `json:"step"`
+	} `json:"resourceAggregations"`
+}
+
+func (e apiExecutionResources) usage() *ResourceUsage {
+	usage := &ResourceUsage{ExecutionID: e.ID, Namespace: e.Namespace, Runner: e.RunnerID}
+	names := map[string]string{}
+	order := map[string]int{}
+	var walk func(steps []apiSignatureStep)
+	walk = func(steps []apiSignatureStep) {
+		for _, step := range steps {
+			names[step.Ref] = step.Name
+			if step.Name == "" {
+				names[step.Ref] = step.Category
+			}
+			order[step.Ref] = len(order)
+			walk(step.Children)
+		}
+	}
+	walk(e.Signature)
+
+	index := map[string]int{}
+	step := func(ref string) *StepResourceUsage {
+		i, ok := index[ref]
+		if !ok {
+			i = len(usage.Steps)
+			index[ref] = i
+			usage.Steps = append(usage.Steps, StepResourceUsage{Ref: ref, Name: names[ref]})
+		}
+		return &usage.Steps[i]
+	}
+
+	if agg := e.ResourceAggregations; agg != nil {
+		usage.CPUMillicores = agg.Global.stats("cpu", "millicores")
+		usage.MemoryBytes = agg.Global.stats("memory", "used")
+		for _, s := range agg.Step {
+			step(s.Ref).CPUMillicores = s.Aggregations.stats("cpu", "millicores")
+			step(s.Ref).MemoryBytes = s.Aggregations.stats("memory", "used")
+		}
+	}
+	for ref, result := range e.Result.Steps {
+		if oomKilled(result.ErrorMessage) {
+			s := step(ref)
+			s.OOMKilled = true
+			s.Error = result.ErrorMessage
+		}
+	}
+
+	sort.SliceStable(usage.Steps, func(i, j int) bool {
+		oi, iok := order[usage.Steps[i].Ref]
+		oj, jok := order[usage.Steps[j].Ref]
+		if iok != jok {
+			return iok
+		}
+		if !iok {
+			return usage.Steps[i].Ref < usage.Steps[j].Ref
+		}
+		return oi < oj
+	})
+	return usage
+}
+
+// oomKilled reports whether a step's error says its container ran out of
+// memory, as Kubernetes reports with the OOMKilled termination reason
+func oomKilled(message string) bool {
+	message = strings.ToLower(message)
+	return strings.Contains(message, "oomkilled") || strings.Contains(message, "out of memory")
+}
@@ -0,0 +1,278 @@
+package testkube
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"math/rand"
+	"net/http"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// ErrCircuitOpen is returned without calling the Testkube API while the
+// circuit breaker is open after repeated failures
+var ErrCircuitOpen = errors.New("testkube API circuit breaker open")
+
+// RetryPolicy controls how failed Testkube API calls are retried. Only
+// idempotent requests are retried, after connection errors and responses
+// that say to try again later (429, 502, 503 and 504).
+type RetryPolicy struct {
+	Retries    int           // attempts after the first
+	Backoff    time.Duration // wait before the first retry, doubled for each one after
+	MaxBackoff time.Duration // cap on a single wait, including Retry-After
+	Jitter     float64       // fraction of each wait that is randomized, 0 to 1
+}
+
+// BreakerPolicy controls when the client stops calling an API that keeps
+// failing. After Threshold consecutive failed calls the circuit opens and
+// calls fail fast with ErrCircuitOpen; once Cooldown has passed a single
+// trial call is let through, and its success closes the circuit again.
+type BreakerPolicy struct {
+	Threshold int // 0 disables the breaker
+	Cooldown  time.Duration
+}
+
+var (
+	DefaultRetryPolicy   = RetryPolicy{Retries: 3, Backoff: 200 * time.Millisecond, MaxBackoff: 5 * time.Second, Jitter: 0.2}
+	DefaultBreakerPolicy = BreakerPolicy{Threshold: 5, Cooldown: 30 * time.Second}
+)
+
+// retryPolicyFromEnv reads TESTKUBE_RETRIES, TESTKUBE_RETRY_BACKOFF,
+// TESTKUBE_RETRY_MAX_BACKOFF and TESTKUBE_RETRY_JITTER
+func retryPolicyFromEnv() RetryPolicy {
+	policy := DefaultRetryPolicy
+	policy.Retries = intFromEnv("TESTKUBE_RETRIES", policy.Retries)
+	policy.Backoff = durationFromEnv("TESTKUBE_RETRY_BACKOFF", policy.Backoff)
+	policy.MaxBackoff = durationFromEnv("TESTKUBE_RETRY_MAX_BACKOFF", policy.MaxBackoff)
+	if val := os.Getenv("TESTKUBE_RETRY_JITTER"); val != "" {
+		if f, err := strconv.ParseFloat(val, 64); err == nil && f >= 0 && f <= 1 {
+			policy.Jitter = f
+		} else {
+			log.Printf("Warning: invalid TESTKUBE_RETRY_JITTER %q, using %g", val, policy.Jitter)
+		}
+	}
+	return policy
+}
+
+// breakerPolicyFromEnv reads TESTKUBE_BREAKER_THRESHOLD and
+// TESTKUBE_BREAKER_COOLDOWN
+func breakerPolicyFromEnv() BreakerPolicy {
+	return BreakerPolicy{
+		Threshold: intFromEnv("TESTKUBE_BREAKER_THRESHOLD", DefaultBreakerPolicy.Threshold),
+		Cooldown:  durationFromEnv("TESTKUBE_BREAKER_COOLDOWN", DefaultBreakerPolicy.Cooldown),
+	}
+}
+
+func intFromEnv(key string, def int) int {
+	val := os.Getenv(key)
+	if val == "" {
+		return def
+	}
+	n, err := strconv.Atoi(val)
+	if err != nil || n < 0 {
+		log.Printf("Warning: invalid %s %q, using %d", key, val, def)
+		return def
+	}
+	return n
+}
+
+func durationFromEnv(key string, def time.Duration) time.Duration {
+	val := os.Getenv(key)
+	if val == "" {
+		return def
+	}
+	d, err := time.ParseDuration(val)
+	if err != nil || d <= 0 {
+		log.Printf("Warning: invalid %s %q, using %s", key, val, def)
+		return def
+	}
+	return d
+}
+
+// resilientTransport retries transient failures and trips a circuit
+// breaker when the API keeps failing, so a blip in the Testkube API doesn't
+// fail a page and an outage doesn't tie up every request in timeouts
+type resilientTransport struct {
+	next    http.RoundTripper
+	policy  RetryPolicy
+	breaker *breaker
+	sleep   func(ctx context.Context, d time.Duration) error
+}
+
+func newResilientTransport(next http.RoundTripper, retry RetryPolicy, breaker BreakerPolicy) *resilientTransport {
+	return &resilientTransport{
+		next:    next,
+		policy:  retry,
+		breaker: newBreaker(breaker),
+		sleep:   sleepContext,
+	}
+}
+
+func (t *resilientTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if err := t.breaker.allow(); err != nil {
+		return nil, err
+	}
+
+	retries := t.policy.Retries
+	if !idempotent(req.Method) {
+		retries = 0
+	}
+	for attempt := 0; ; attempt++ {
+		// RoundTrippers mustn't modify the request, so retries send a copy
+		// with the body rewound
+		try := req
+		if attempt > 0 && req.GetBody != nil {
+			body, err := req.GetBody()
+			if err != nil {
+				t.breaker.record(false)
+				return nil, err
+			}
+			try = req.Clone(req.Context())
+			try.Body = body
+		}
+
+		resp, err := t.next.RoundTrip(try)
+		if err == nil && !transientStatus(resp.StatusCode) {
+			t.breaker.record(true)
+			return resp, nil
+		}
+		// A caller giving up says nothing about the API's health
+		if errors.Is(req.Context().Err(), context.Canceled) {
+			t.breaker.abandon()
+			return resp, err
+		}
+		if attempt >= retries || req.Context().Err() != nil {
+			t.breaker.record(false)
+			return resp, err
+		}
+
+		wait := t.backoff(attempt, resp)
+		if resp != nil {
+			io.Copy(io.Discard, resp.Body)
+			resp.Body.Close()
+		}
+		if err := t.sleep(req.Context(), wait); err != nil {
+			if errors.Is(err, context.Canceled) {
+				t.breaker.abandon()
+			} else {
+				t.breaker.record(false)
+			}
+			return nil, err
+		}
+	}
+}
+
+// backoff is the wait before retry attempt+1: exponential with jitter, or
+// what the server asked for in Retry-After, up to MaxBackoff
+func (t *resilientTransport) backoff(attempt int, resp *http.Response) time.Duration {
+	if resp != nil {
+		if secs, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil && secs >= 0 {
+			return min(time.Duration(secs)*time.Second, t.policy.MaxBackoff)
+		}
+	}
+	wait := t.policy.Backoff << attempt
+	if wait <= 0 || wait > t.policy.MaxBackoff {
+		wait = t.policy.MaxBackoff
+	}
+	if t.policy.Jitter > 0 {
+		spread := float64(wait) * t.policy.Jitter
+		wait = time.Duration(float64(wait) - spread + rand.Float64()*2*spread)
+	}
+	return wait
+}
+
+func idempotent(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodPut, http.MethodDelete:
+		return true
+	}
+	return false
+}
+
+func transientStatus(code int) bool {
+	switch code {
+	case http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
+
+func sleepContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// breaker counts consecutive failed calls, opening the circuit at the
+// policy's threshold
+type breaker struct {
+	policy BreakerPolicy
+	now    func() time.Time
+
+	mu        sync.Mutex
+	failures  int
+	openUntil time.Time
+	trial     bool // a call is testing whether the API has recovered
+}
+
+func newBreaker(policy BreakerPolicy) *breaker {
+	return &breaker{policy: policy, now: time.Now}
+}
+
+// allow reports whether a call may go ahead
+func (b *breaker) allow() error {
+	if b.policy.Threshold <= 0 {
+		return nil
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.failures < b.policy.Threshold {
+		return nil
+	}
+	if b.trial || b.now().Before(b.openUntil) {
+		return fmt.Errorf("%w after %d failed calls, retrying after %s", ErrCircuitOpen, b.failures, b.openUntil.Format(time.TimeOnly))
+	}
+	b.trial = true
+	return nil
+}
+
+// abandon notes a call that ended without an answer either way
+func (b *breaker) abandon() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.trial = false
+}
+
+// record notes whether a call succeeded
+func (b *breaker) record(ok bool) {
+	if b.policy.Threshold <= 0 {
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.trial = false
+	if ok {
+		if b.failures >= b.policy.Threshold {
+			log.Printf("Testkube API recovered, closing circuit breaker")
+		}
+		b.failures = 0
+		return
+	}
+	b.failures++
+	if b.failures >= b.policy.Threshold {
+		if b.failures == b.policy.Threshold {
+			log.Printf("Testkube API failed %d calls in a row, opening circuit breaker for %s", b.failures, b.policy.Cooldown)
+		}
+		b.openUntil = b.now().Add(b.policy.Cooldown)
+	}
+}
@@ -0,0 +1,164 @@
+package testkube
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// testTransport returns a transport that records its waits instead of
+// sleeping
+func testTransport(retry RetryPolicy, breaker BreakerPolicy) (*resilientTransport, *[]time.Duration) {
+	var waits []time.Duration
+	t := newResilientTransport(http.DefaultTransport, retry, breaker)
+	t.sleep = func(ctx context.Context, d time.Duration) error {
+		waits = append(waits, d)
+		return nil
+	}
+	return t, &waits
+}
+
+func TestRetryTransientFailures(t *testing.T) {
+	calls := 0
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		if calls < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Write([]byte("ok"))
+	}))
+	defer ts.Close()
+
+	transport, waits := testTransport(RetryPolicy{Retries: 3, Backoff: 100 * time.Millisecond, MaxBackoff: time.Second}, BreakerPolicy{})
+	client := &http.Client{Transport: transport}
+	resp, err := client.Get(ts.URL)
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("got status %d, expected 200", resp.StatusCode)
+	}
+	if calls != 3 {
+		t.Errorf("got %d calls, expected 3", calls)
+	}
+	expected := []time.Duration{100 * time.Millisecond, 200 * time.Millisecond}
+	if len(*waits) != 2 || (*waits)[0] != expected[0] || (*waits)[1] != expected[1] {
+		t.Errorf("got waits %v, expected %v", *waits, expected)
+	}
+}
+
+func TestRetryGivesUp(t *testing.T) {
+	calls := 0
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.Header().Set("Retry-After", "120")
+		w.WriteHeader(http.StatusTooManyRequests)
+	}))
+	defer ts.Close()
+
+	transport, waits := testTransport(RetryPolicy{Retries: 2, Backoff: 10 * time.Millisecond, MaxBackoff: time.Second}, BreakerPolicy{})
+	client := &http.Client{Transport: transport}
+	resp, err := client.Get(ts.URL)
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusTooManyRequests {
+		t.Errorf("got status %d, expected the last response's 429", resp.StatusCode)
+	}
+	if calls != 3 {
+		t.Errorf("got %d calls, expected 3", calls)
+	}
+	for _, wait := range *waits {
+		if wait != time.Second {
+			t.Errorf("got wait %s, expected Retry-After capped at 1s", wait)
+		}
+	}
+
+	// Runs aren't idempotent, so a failed one is never repeated
+	calls = 0
+	resp, err = client.Post(ts.URL, "application/json", strings.NewReader("{}"))
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	resp.Body.Close()
+	if calls != 1 {
+		t.Errorf("got %d calls for a POST, expected 1", calls)
+	}
+}
+
+func TestRetryJitter(t *testing.T) {
+	transport, _ := testTransport(RetryPolicy{Backoff: time.Second, MaxBackoff: 10 * time.Second, Jitter: 0.5}, BreakerPolicy{})
+	for i := 0; i < 100; i++ {
+		wait := transport.backoff(1, nil)
+		if wait < time.Second || wait > 3*time.Second {
+			t.Fatalf("got wait %s, expected 2s ± 50%%", wait)
+		}
+	}
+	if wait := transport.backoff(10, nil); wait < 5*time.Second || wait > 15*time.Second {
+		t.Errorf("got wait %s, expected about the 10s cap", wait)
+	}
+}
+
+func TestCircuitBreaker(t *testing.T) {
+	healthy := false
+	calls := 0
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		if !healthy {
+			w.WriteHeader(http.StatusBadGateway)
+		}
+	}))
+	defer ts.Close()
+
+	now := time.Now()
+	transport, _ := testTransport(RetryPolicy{}, BreakerPolicy{Threshold: 2, Cooldown: time.Minute})
+	transport.breaker.now = func() time.Time { return now }
+	client := &http.Client{Transport: transport}
+	get := func() error {
+		resp, err := client.Get(ts.URL)
+		if err == nil {
+			resp.Body.Close()
+		}
+		return err
+	}
+
+	for i := 0; i < 2; i++ {
+		if err := get(); err != nil {
+			t.Fatalf("call %d: expected the failed response, got %v", i+1, err)
+		}
+	}
+	if err := get(); !errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("got %v, expected ErrCircuitOpen", err)
+	}
+	if calls != 2 {
+		t.Errorf("got %d calls, expected the open circuit to fail fast", calls)
+	}
+
+	// After the cooldown a failed trial call keeps it open
+	now = now.Add(time.Minute)
+	if err := get(); err != nil {
+		t.Fatalf("expected a trial call, got %v", err)
+	}
+	if err := get(); !errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("got %v, expected ErrCircuitOpen after a failed trial", err)
+	}
+
+	// and a successful one closes it
+	healthy = true
+	now = now.Add(time.Minute)
+	for i := 0; i < 3; i++ {
+		if err := get(); err != nil {
+			t.Fatalf("call %d after recovery: %v", i+1, err)
+		}
+	}
+	if calls != 6 {
+		t.Errorf("got %d calls, expected 6", calls)
+	}
+}
@@ -0,0 +1,218 @@
+package testkube
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/testkube/dashboard/internal/schedules"
+	"gopkg.in/yaml.v3"
+)
+
+// pausedSchedulesAnnotation keeps a workflow's paused cron triggers. Testkube
+// has no way to pause a trigger, so pausing takes it out of spec.events and
+// resuming puts it back.
+const pausedSchedulesAnnotation = "dashboard.testkube.io/paused-schedules"
+
+// WorkflowSchedule is a cron trigger from a TestWorkflow's spec.events
+type WorkflowSchedule struct {
+	Cron     string `json:"cron" yaml:"cron"`
+	Timezone string `json:"timezone,omitempty" yaml:"timezone,omitempty"`
+	Paused   bool   `json:"paused,omitempty" yaml:"-"`
+}
+
+// location is the schedule's time zone, UTC when it has none
+func (s WorkflowSchedule) location() (*time.Location, error) {
+	if s.Timezone == "" {
+		return time.UTC, nil
+	}
+	return time.LoadLocation(s.Timezone)
+}
+
+// Validate checks the cron expression and time zone
+func (s WorkflowSchedule) Validate() error {
+	if _, err := schedules.ParseCron(s.Cron); err != nil {
+		return err
+	}
+	if _, err := s.location(); err != nil {
+		return fmt.Errorf("invalid timezone %q: %w", s.Timezone, err)
+	}
+	return nil
+}
+
+// NextRun returns when the schedule next fires after a time, or false for a
+// paused or invalid schedule, or one that never fires
+func (s WorkflowSchedule) NextRun(after time.Time) (time.Time, bool) {
+	if s.Paused {
+		return time.Time{}, false
+	}
+	cron, err := schedules.ParseCron(s.Cron)
+	if err != nil {
+		return time.Time{}, false
+	}
+	loc, err := s.location()
+	if err != nil {
+		return time.Time{}, false
+	}
+	return cron.Next(after, loc)
+}
+
+// Between returns the times from from, inclusive, to to, exclusive, that
+// the schedule fires at, none for a paused or invalid schedule
+func (s WorkflowSchedule) Between(from, to time.Time) []time.Time {
+	if s.Paused {
+		return nil
+	}
+	cron, err := schedules.ParseCron(s.Cron)
+	if err != nil {
+		return nil
+	}
+	loc, err := s.location()
+	if err != nil {
+		return nil
+	}
+	return cron.Between(from, to, loc)
+}
+
+// cronEvent is a spec.events entry with a cronjob trigger
+type cronEvent struct {
+	Cronjob *WorkflowSchedule `yaml:"cronjob"`
+}
+
+// WorkflowSchedules reads the cron triggers from a TestWorkflow definition,
+// active ones first, then the ones the dashboard paused
+func WorkflowSchedules(spec []byte) ([]WorkflowSchedule, error) {
+	var m struct {
+		Metadata struct {
+			Annotations map[string]string `yaml:"annotations"`
+		} `yaml:"metadata"`
+		Spec struct {
+			Events []cronEvent `yaml:"events"`
+		} `yaml:"spec"`
+	}
+	if err := yaml.Unmarshal(spec, &m); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidWorkflowSpec, err)
+	}
+
+	result := []WorkflowSchedule{}
+	for _, e := range m.Spec.Events {
+		if e.Cronjob != nil {
+			result = append(result, *e.Cronjob)
+		}
+	}
+	if paused := m.Metadata.Annotations[pausedSchedulesAnnotation]; paused != "" {
+		var list []WorkflowSchedule
+		if err := json.Unmarshal([]byte(paused), &list); err != nil {
+			return nil, fmt.Errorf("%w: annotation %s: %v", ErrInvalidWorkflowSpec, pausedSchedulesAnnotation, err)
+		}
+		for _, s := range list {
+			s.Paused = true
+			result = append(result, s)
+		}
+	}
+	return result, nil
+}
+
+// SetWorkflowSchedules replaces the cron triggers in a TestWorkflow
+// definition, keeping its other events. Triggers that are unchanged keep
+// the labels and config they were defined with.
+func SetWorkflowSchedules(spec []byte, list []WorkflowSchedule) ([]byte, error) {
+	for _, s := range list {
+		if err := s.Validate(); err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidWorkflowSpec, err)
+		}
+	}
+
+	var m map[string]interface{}
+	if err := yaml.Unmarshal(spec, &m); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidWorkflowSpec, err)
+	}
+	body, _ := m["spec"].(map[string]interface{})
+	if body == nil {
+		return nil, fmt.Errorf("%w: spec is empty", ErrInvalidWorkflowSpec)
+	}
+
+	// Existing cron triggers by schedule, to keep what else they set
+	existing := map[WorkflowSchedule]interface{}{}
+	var events []interface{}
+	current, _ := body["events"].([]interface{})
+	for _, e := range current {
+		event, _ := e.(map[string]interface{})
+		cronjob, ok := event["cronjob"].(map[string]interface{})
+		if !ok {
+			events = append(events, e)
+			continue
+		}
+		cron, _ := cronjob["cron"].(string)
+		timezone, _ := cronjob["timezone"].(string)
+		existing[WorkflowSchedule{Cron: cron, Timezone: timezone}] = e
+	}
+
+	var paused []WorkflowSchedule
+	for _, s := range list {
+		if s.Paused {
+			paused = append(paused, WorkflowSchedule{Cron: s.Cron, Timezone: s.Timezone})
+			continue
+		}
+		if e, ok := existing[WorkflowSchedule{Cron: s.Cron, Timezone: s.Timezone}]; ok {
+			events = append(events, e)
+			continue
+		}
+		cronjob := map[string]interface{}{"cron": s.Cron}
+		if s.Timezone != "" {
+			cronjob["timezone"] = s.Timezone
+		}
+		events = append(events, map[string]interface{}{"cronjob": cronjob})
+	}
+	if len(events) > 0 {
+		body["events"] = events
+	} else {
+		delete(body, "events")
+	}
+
+	metadata, _ := m["metadata"].(map[string]interface{})
+	if metadata == nil {
+		metadata = map[string]interface{}{}
+		m["metadata"] = metadata
+	}
+	annotations, _ := metadata["annotations"].(map[string]interface{})
+	if len(paused) > 0 {
+		if annotations == nil {
+			annotations = map[string]interface{}{}
+			metadata["annotations"] = annotations
+		}
+		data, _ := json.Marshal(paused)
+		annotations[pausedSchedulesAnnotation] = string(data)
+	} else if annotations != nil {
+		delete(annotations, pausedSchedulesAnnotation)
+		if len(annotations) == 0 {
+			delete(metadata, "annotations")
+		}
+	}
+
+	return yaml.Marshal(m)
+}
+
+// updateSchedules rewrites a workflow's cron triggers through its
+// definition, for clients with no schedule endpoint of their own
+func updateSchedules(c Client, name string, list []WorkflowSchedule) error {
+	spec, err := c.GetWorkflowSpec(name)
+	if err != nil {
+		return err
+	}
+	updated, err := SetWorkflowSchedules(spec, list)
+	if err != nil {
+		return err
+	}
+	_, err = c.UpdateWorkflow(name, updated)
+	return err
+}
+
+// getSchedules reads a workflow's cron triggers from its definition
+func getSchedules(c Client, name string) ([]WorkflowSchedule, error) {
+	spec, err := c.GetWorkflowSpec(name)
+	if err != nil {
+		return nil, err
+	}
+	return WorkflowSchedules(spec)
+}
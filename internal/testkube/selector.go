@@ -0,0 +1,132 @@
+package testkube
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// ErrInvalidSelector is returned for a label selector that doesn't parse
+var ErrInvalidSelector = errors.New("invalid label selector")
+
+// Selector is a parsed Kubernetes label selector, as Testkube accepts for
+// filtering workflows and executions, e.g. "team=payments,priority in (high,critical),!deprecated"
+type Selector []requirement
+
+type requirement struct {
+	key    string
+	op     string // =, !=, in, notin, exists or !exists
+	values []string
+}
+
+// ParseSelector parses a comma separated list of requirements: key=value,
+// key==value, key!=value, key in (a,b), key notin (a,b), key and !key. An
+// empty selector matches everything.
+func ParseSelector(s string) (Selector, error) {
+	var sel Selector
+	for _, part := range splitRequirements(s) {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			if strings.TrimSpace(s) == "" {
+				continue
+			}
+			return nil, fmt.Errorf("%w %q: empty requirement", ErrInvalidSelector, s)
+		}
+		req, err := parseRequirement(part)
+		if err != nil {
+			return nil, fmt.Errorf("%w %q: %v", ErrInvalidSelector, s, err)
+		}
+		sel = append(sel, req)
+	}
+	return sel, nil
+}
+
+// splitRequirements splits on the commas outside of value lists
+func splitRequirements(s string) []string {
+	var parts []string
+	depth, start := 0, 0
+	for i, r := range s {
+		switch r {
+		case '(':
+			depth++
+		case ')':
+			depth--
+		case ',':
+			if depth == 0 {
+				parts = append(parts, s[start:i])
+				start = i + 1
+			}
+		}
+	}
+	return append(parts, s[start:])
+}
+
+func parseRequirement(part string) (requirement, error) {
+	if key, ok := strings.CutPrefix(part, "!"); ok {
+		return labelKey(strings.TrimSpace(key), "!exists", nil)
+	}
+	for _, op := range []string{"!=", "==", "="} {
+		if key, value, ok := strings.Cut(part, op); ok {
+			if op == "==" {
+				op = "="
+			}
+			return labelKey(strings.TrimSpace(key), op, []string{strings.TrimSpace(value)})
+		}
+	}
+	fields := strings.Fields(part)
+	if len(fields) == 1 {
+		return labelKey(fields[0], "exists", nil)
+	}
+	if len(fields) < 2 || (fields[1] != "in" && fields[1] != "notin") {
+		return requirement{}, fmt.Errorf("can't parse %q", part)
+	}
+	list := strings.TrimSpace(strings.Join(fields[2:], " "))
+	if !strings.HasPrefix(list, "(") || !strings.HasSuffix(list, ")") {
+		return requirement{}, fmt.Errorf("%s needs a parenthesized list of values", fields[1])
+	}
+	var values []string
+	for _, v := range strings.Split(list[1:len(list)-1], ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			values = append(values, v)
+		}
+	}
+	if len(values) == 0 {
+		return requirement{}, fmt.Errorf("%s needs at least one value", fields[1])
+	}
+	return labelKey(fields[0], fields[1], values)
+}
+
+// labelKey checks a requirement's key before building it
+func labelKey(key, op string, values []string) (requirement, error) {
+	if key == "" || strings.ContainsAny(key, " ()!=,") {
+		return requirement{}, fmt.Errorf("invalid label key %q", key)
+	}
+	return requirement{key: key, op: op, values: values}, nil
+}
+
+// Matches reports whether labels satisfy every requirement
+func (sel Selector) Matches(labels map[string]string) bool {
+	for _, req := range sel {
+		value, ok := labels[req.key]
+		var match bool
+		switch req.op {
+		case "=":
+			match = ok && value == req.values[0]
+		case "!=":
+			match = !ok || value != req.values[0]
+		case "in":
+			match = ok && slices.Contains(req.values, value)
+		case "notin":
+			match = !ok || !slices.Contains(req.values, value)
+		case "exists":
+			match = ok
+		case "!exists":
+			match = !ok
+		}
+		if !match {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,65 @@
+package testkube
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestSelector(t *testing.T) {
+	labels := map[string]string{"team": "payments", "suite": "smoke", "priority": "high"}
+
+	for _, tc := range []struct {
+		selector string
+		match    bool
+	}{
+		{"", true},
+		{"team=payments", true},
+		{"team==payments", true},
+		{"team!=payments", false},
+		{"team=payments,suite=regression", false},
+		{"priority in (high, critical)", true},
+		{"priority notin (high,critical),team=payments", false},
+		{"suite", true},
+		{"!suite", false},
+		{"!deprecated, team = payments", true},
+		{"owner!=bob", true},
+	} {
+		sel, err := ParseSelector(tc.selector)
+		if err != nil {
+			t.Fatalf("%q: %v", tc.selector, err)
+		}
+		if got := sel.Matches(labels); got != tc.match {
+			t.Errorf("%q: got %v, expected %v", tc.selector, got, tc.match)
+		}
+	}
+
+	for _, selector := range []string{"team in payments", "priority in ()", "a,,b", "=payments", "team in (a", "a b c"} {
+		if _, err := ParseSelector(selector); !errors.Is(err, ErrInvalidSelector) {
+			t.Errorf("%q: got %v, expected an invalid selector error", selector, err)
+		}
+	}
+}
+
+func TestMockClientSelector(t *testing.T) {
+	c := NewMockClient()
+	workflows, err := c.GetWorkflows(ListOptions{Selector: "team=frontend,priority=high"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(workflows) != 1 || workflows[0].Name != "frontend-e2e" {
+		t.Errorf("got %+v, expected only frontend-e2e", workflows)
+	}
+
+	executions, err := c.GetExecutions(ListOptions{Selector: "team in (performance)"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(executions) == 0 {
+		t.Fatal("expected executions of the performance team's workflows")
+	}
+	for _, e := range executions {
+		if e.WorkflowName != "api-load-test" && e.WorkflowName != "mqtt-load-test" {
+			t.Errorf("got an execution of %s, expected only load test workflows", e.WorkflowName)
+		}
+	}
+}
@@ -0,0 +1,162 @@
+package testkube
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// ShardLabel marks an execution as one shard of a parallelized run, as "index/total" (e.g. "2/4")
+	ShardLabel = "shard"
+	// ShardGroupLabel is shared by all shard executions of one logical run
+	ShardGroupLabel = "shard-group"
+)
+
+// Shard returns the shard index and total parsed from the execution's labels
+func (e Execution) Shard() (index, total int, ok bool) {
+	value, found := e.Labels[ShardLabel]
+	if !found || e.Labels[ShardGroupLabel] == "" {
+		return 0, 0, false
+	}
+	parts := strings.SplitN(value, "/", 2)
+	if len(parts) != 2 {
+		return 0, 0, false
+	}
+	index, err1 := strconv.Atoi(parts[0])
+	total, err2 := strconv.Atoi(parts[1])
+	if err1 != nil || err2 != nil || index < 1 || total < 1 {
+		return 0, 0, false
+	}
+	return index, total, true
+}
+
+// ExecutionGroup is one logical run: either a single execution or all shards
+// of a sharded run combined. The embedded Execution holds aggregated values.
+type ExecutionGroup struct {
+	Execution
+	GroupID    string
+	Shards     []Execution
+	ShardTotal int
+}
+
+// MarshalJSON writes the group's own fields alongside its Execution's, which
+// would otherwise replace them by promoting Execution.MarshalJSON
+func (g ExecutionGroup) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		executionJSON
+		GroupID    string
+		Shards     []Execution
+		ShardTotal int
+	}{g.Execution.json(), g.GroupID, g.Shards, g.ShardTotal})
+}
+
+// Sharded reports whether the group was assembled from shard executions
+func (g ExecutionGroup) Sharded() bool {
+	return g.GroupID != ""
+}
+
+// PassedShards counts shards that passed
+func (g ExecutionGroup) PassedShards() int {
+	passed := 0
+	for _, s := range g.Shards {
+		if s.Status == "passed" {
+			passed++
+		}
+	}
+	return passed
+}
+
+// GroupShards collapses shard executions into logical runs, preserving the
+// order of the first shard seen for each group.
+func GroupShards(executions []Execution) []ExecutionGroup {
+	var groups []ExecutionGroup
+	index := make(map[string]int)
+
+	for _, exec := range executions {
+		_, total, ok := exec.Shard()
+		if !ok {
+			groups = append(groups, ExecutionGroup{Execution: exec})
+			continue
+		}
+
+		groupID := exec.Labels[ShardGroupLabel]
+		if i, seen := index[groupID]; seen {
+			groups[i].Shards = append(groups[i].Shards, exec)
+			continue
+		}
+		index[groupID] = len(groups)
+		groups = append(groups, ExecutionGroup{
+			GroupID:    groupID,
+			Shards:     []Execution{exec},
+			ShardTotal: total,
+		})
+	}
+
+	for i := range groups {
+		if groups[i].Sharded() {
+			groups[i].aggregate()
+		}
+	}
+
+	return groups
+}
+
+// aggregate fills the embedded Execution from the group's shards
+func (g *ExecutionGroup) aggregate() {
+	sort.Slice(g.Shards, func(i, j int) bool {
+		a, _, _ := g.Shards[i].Shard()
+		b, _, _ := g.Shards[j].Shard()
+		return a < b
+	})
+
+	first := g.Shards[0]
+	agg := Execution{
+		ID:           g.GroupID,
+		Name:         fmt.Sprintf("%s (%d shards)", g.GroupID, g.ShardTotal),
+		WorkflowName: first.WorkflowName,
+		Branch:       first.Branch,
+		StartTime:    first.StartTime,
+		EndTime:      first.EndTime,
+		Labels:       first.Labels,
+	}
+
+	finished := true
+	statuses := make(map[string]int)
+	for _, s := range g.Shards {
+		statuses[s.Status]++
+		if s.StartTime.Before(agg.StartTime) {
+			agg.StartTime = s.StartTime
+		}
+		if s.EndTime.IsZero() {
+			finished = false
+		} else if s.EndTime.After(agg.EndTime) {
+			agg.EndTime = s.EndTime
+		}
+	}
+
+	// Missing shards mean the run hasn't fully started yet
+	switch {
+	case statuses["failed"] > 0:
+		agg.Status = "failed"
+	case statuses["running"] > 0:
+		agg.Status = "running"
+	case statuses["queued"] > 0 || len(g.Shards) < g.ShardTotal:
+		agg.Status = "queued"
+	case statuses["passed"] == len(g.Shards):
+		agg.Status = "passed"
+	default:
+		agg.Status = first.Status
+	}
+
+	if finished && len(g.Shards) >= g.ShardTotal {
+		agg.Duration = agg.EndTime.Sub(agg.StartTime)
+	} else {
+		agg.EndTime = time.Time{}
+	}
+
+	g.Execution = agg
+}
@@ -0,0 +1,93 @@
+package testkube
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+func TestGroupShards(t *testing.T) {
+	now := time.Now()
+	shard := func(id, index, status string, start time.Duration) Execution {
+		return Execution{
+			ID:           id,
+			WorkflowName: "e2e",
+			Status:       status,
+			StartTime:    now.Add(start),
+			EndTime:      now.Add(start + time.Minute),
+			Labels:       map[string]string{ShardLabel: index, ShardGroupLabel: "run-1"},
+		}
+	}
+
+	executions := []Execution{
+		{ID: "single", WorkflowName: "e2e", Status: "passed"},
+		shard("s2", "2/3", "failed", 10*time.Second),
+		shard("s1", "1/3", "passed", 0),
+		shard("s3", "3/3", "passed", 5*time.Second),
+	}
+
+	groups := GroupShards(executions)
+	if len(groups) != 2 {
+		t.Fatalf("expected 2 groups, got %d", len(groups))
+	}
+	if groups[0].Sharded() || groups[0].ID != "single" {
+		t.Errorf("expected unsharded execution first, got %+v", groups[0])
+	}
+
+	g := groups[1]
+	if !g.Sharded() || g.GroupID != "run-1" || len(g.Shards) != 3 {
+		t.Fatalf("unexpected shard group: %+v", g)
+	}
+	if g.Shards[0].ID != "s1" || g.Shards[2].ID != "s3" {
+		t.Errorf("shards not ordered by index: %s, %s, %s", g.Shards[0].ID, g.Shards[1].ID, g.Shards[2].ID)
+	}
+	if g.Status != "failed" {
+		t.Errorf("expected aggregated status failed, got %s", g.Status)
+	}
+	if g.PassedShards() != 2 {
+		t.Errorf("expected 2 passed shards, got %d", g.PassedShards())
+	}
+	if g.Duration != 70*time.Second {
+		t.Errorf("expected combined duration 70s, got %s", g.Duration)
+	}
+}
+
+func TestGroupShards_Incomplete(t *testing.T) {
+	executions := []Execution{
+		{ID: "s1", Status: "passed", EndTime: time.Now(), Labels: map[string]string{ShardLabel: "1/2", ShardGroupLabel: "run-2"}},
+	}
+
+	groups := GroupShards(executions)
+	if len(groups) != 1 || groups[0].Status != "queued" {
+		t.Errorf("expected run with missing shards to be queued, got %+v", groups)
+	}
+}
+
+func TestExecutionJSON(t *testing.T) {
+	exec := Execution{ID: "e1", Status: "passed", Duration: 3*time.Minute + 12*time.Second}
+	data, err := json.Marshal(exec)
+	if err != nil {
+		t.Fatalf("Marshal failed: %v", err)
+	}
+	var fields map[string]interface{}
+	json.Unmarshal(data, &fields)
+	if fields["DurationMs"] != float64(192000) || fields["DurationHuman"] != "3m 12s" || fields["ID"] != "e1" {
+		t.Errorf("got %s, expected the duration in milliseconds and humanized alongside the fields", data)
+	}
+	var decoded Execution
+	if err := json.Unmarshal(data, &decoded); err != nil || decoded.Duration != exec.Duration {
+		t.Errorf("got %+v, %v, expected the execution to read back", decoded, err)
+	}
+
+	group := ExecutionGroup{Execution: exec, GroupID: "g1", Shards: []Execution{exec}, ShardTotal: 2}
+	data, err = json.Marshal(group)
+	if err != nil {
+		t.Fatalf("Marshal failed: %v", err)
+	}
+	fields = nil
+	json.Unmarshal(data, &fields)
+	shards, _ := fields["Shards"].([]interface{})
+	if fields["GroupID"] != "g1" || fields["ShardTotal"] != float64(2) || fields["DurationHuman"] != "3m 12s" || len(shards) != 1 {
+		t.Errorf("got %s, expected the group's fields with its execution's", data)
+	}
+}
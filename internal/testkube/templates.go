@@ -0,0 +1,107 @@
+package testkube
+
+import (
+	"errors"
+	"slices"
+	"strings"
+	"time"
+)
+
+// ErrWorkflowTemplateNotFound is returned for a template that doesn't exist
+var ErrWorkflowTemplateNotFound = errors.New("workflow template not found")
+
+// WorkflowTemplate is a TestWorkflowTemplate, a reusable piece of workflow
+// spec that workflows and their steps include with `use` or `template`
+type WorkflowTemplate struct {
+	Name        string            `json:"name"`
+	Namespace   string            `json:"namespace"`
+	Description string            `json:"description,omitempty"`
+	Labels      map[string]string `json:"labels,omitempty"`
+	Created     time.Time         `json:"created"`
+	// Image is the container image the template sets, if any
+	Image string `json:"image,omitempty"`
+	// Parameters are the names of the config variables the template takes
+	Parameters []string `json:"parameters,omitempty"`
+}
+
+// TemplateName is the name workflows refer to a template by. Testkube
+// stores "official/k6/v1" as the resource "official--k6--v1"; both read as
+// the former.
+func TemplateName(name string) string {
+	return strings.ReplaceAll(name, "--", "/")
+}
+
+// templateRefs returns the templates a workflow spec uses, sorted: those in
+// `use` lists and `template` references at any depth, so templates pulled
+// into setup, steps, parallel blocks and services all count
+func templateRefs(spec interface{}) []string {
+	var refs []string
+	var walk func(node interface{})
+	walk = func(node interface{}) {
+		switch n := node.(type) {
+		case map[string]interface{}:
+			for key, value := range n {
+				switch key {
+				case "use":
+					if list, ok := value.([]interface{}); ok {
+						for _, item := range list {
+							refs = appendTemplateRef(refs, item)
+						}
+					}
+				case "template":
+					refs = appendTemplateRef(refs, value)
+				}
+				walk(value)
+			}
+		case []interface{}:
+			for _, item := range n {
+				walk(item)
+			}
+		}
+	}
+	walk(spec)
+
+	slices.Sort(refs)
+	return slices.Compact(refs)
+}
+
+func appendTemplateRef(refs []string, ref interface{}) []string {
+	if m, ok := ref.(map[string]interface{}); ok {
+		if name, ok := m["name"].(string); ok && name != "" {
+			return append(refs, TemplateName(name))
+		}
+	}
+	return refs
+}
+
+// specImage is the container image a workflow or template spec sets
+func specImage(spec map[string]interface{}) string {
+	container, _ := spec["container"].(map[string]interface{})
+	image, _ := container["image"].(string)
+	return image
+}
+
+// specParameters are the names of the config variables a spec declares
+func specParameters(spec map[string]interface{}) []string {
+	config, _ := spec["config"].(map[string]interface{})
+	names := make([]string, 0, len(config))
+	for name := range config {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return names
+}
+
+// WorkflowsUsing returns the workflows using each template, by template name
+func WorkflowsUsing(workflows []Workflow) map[string][]string {
+	using := make(map[string][]string)
+	for _, wf := range workflows {
+		for _, name := range wf.Templates {
+			using[name] = append(using[name], wf.Name)
+		}
+	}
+	for name := range using {
+		slices.Sort(using[name])
+	}
+	return using
+}
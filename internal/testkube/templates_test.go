@@ -0,0 +1,45 @@
+package testkube
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestTemplateRefs(t *testing.T) {
+	spec := strings.Replace(testWorkflowSpec, "spec:\n", `spec:
+  use:
+    - name: git-clone
+  setup:
+    - template:
+        name: official--k6--v1
+  services:
+    db:
+      use:
+        - name: postgres
+        - name: git-clone
+`, 1)
+	wf, err := ParseWorkflowSpec([]byte(spec))
+	if err != nil {
+		t.Fatalf("ParseWorkflowSpec failed: %v", err)
+	}
+	expected := []string{"git-clone", "official/k6/v1", "postgres"}
+	if !reflect.DeepEqual(wf.Templates, expected) {
+		t.Errorf("got %v, expected %v", wf.Templates, expected)
+	}
+}
+
+func TestWorkflowsUsing(t *testing.T) {
+	using := WorkflowsUsing([]Workflow{
+		{Name: "load", Templates: []string{"git-clone", "official/k6/v1"}},
+		{Name: "e2e", Templates: []string{"git-clone"}},
+		{Name: "lint"},
+	})
+	expected := map[string][]string{
+		"git-clone":      {"e2e", "load"},
+		"official/k6/v1": {"load"},
+	}
+	if !reflect.DeepEqual(using, expected) {
+		t.Errorf("got %v, expected %v", using, expected)
+	}
+}
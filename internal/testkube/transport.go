@@ -0,0 +1,105 @@
+package testkube
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"time"
+)
+
+const (
+	// DefaultRequestTimeout bounds an API request, response body included
+	DefaultRequestTimeout = 30 * time.Second
+	// DefaultDownloadTimeout bounds an artifact download, which can be far
+	// larger than any API response
+	DefaultDownloadTimeout = 10 * time.Minute
+)
+
+// TransportConfig is how a RealClient's requests reach the API server.
+// Zero values take the defaults: the system's trusted CAs, and the proxy
+// in HTTPS_PROXY, HTTP_PROXY and NO_PROXY.
+type TransportConfig struct {
+	Timeout         time.Duration
+	DownloadTimeout time.Duration
+	// CAFile is a PEM bundle of CAs trusted besides the system's, for API
+	// servers with certificates from an internal CA
+	CAFile             string
+	InsecureSkipVerify bool
+	// ProxyURL sends every request through this proxy, NO_PROXY or not
+	ProxyURL string
+}
+
+// transportConfigFromEnv reads TESTKUBE_REQUEST_TIMEOUT,
+// TESTKUBE_DOWNLOAD_TIMEOUT, TESTKUBE_CA_FILE,
+// TESTKUBE_INSECURE_SKIP_VERIFY and TESTKUBE_PROXY_URL
+func transportConfigFromEnv() TransportConfig {
+	cfg := TransportConfig{
+		Timeout:         durationFromEnv("TESTKUBE_REQUEST_TIMEOUT", DefaultRequestTimeout),
+		DownloadTimeout: durationFromEnv("TESTKUBE_DOWNLOAD_TIMEOUT", DefaultDownloadTimeout),
+		CAFile:          os.Getenv("TESTKUBE_CA_FILE"),
+		ProxyURL:        os.Getenv("TESTKUBE_PROXY_URL"),
+	}
+	if val := os.Getenv("TESTKUBE_INSECURE_SKIP_VERIFY"); val != "" {
+		skip, err := strconv.ParseBool(val)
+		if err != nil {
+			log.Printf("Warning: invalid TESTKUBE_INSECURE_SKIP_VERIFY %q, verifying certificates", val)
+		}
+		cfg.InsecureSkipVerify = skip
+	}
+	return cfg
+}
+
+// timeouts returns the request and download timeouts, defaulted
+func (cfg TransportConfig) timeouts() (request, download time.Duration) {
+	request, download = cfg.Timeout, cfg.DownloadTimeout
+	if request <= 0 {
+		request = DefaultRequestTimeout
+	}
+	if download <= 0 {
+		download = DefaultDownloadTimeout
+	}
+	return request, download
+}
+
+// transport builds the HTTP transport under the client's retries and
+// authentication
+func (cfg TransportConfig) transport() (*http.Transport, error) {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	if cfg.ProxyURL != "" {
+		proxy, err := url.Parse(cfg.ProxyURL)
+		if err != nil || proxy.Host == "" {
+			return nil, fmt.Errorf("invalid proxy URL %q", cfg.ProxyURL)
+		}
+		transport.Proxy = http.ProxyURL(proxy)
+	}
+	if cfg.CAFile == "" && !cfg.InsecureSkipVerify {
+		return transport, nil
+	}
+
+	tlsConfig := &tls.Config{MinVersion: tls.VersionTLS12}
+	if cfg.CAFile != "" {
+		pem, err := os.ReadFile(cfg.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read CA file: %w", err)
+		}
+		pool, err := x509.SystemCertPool()
+		if err != nil {
+			pool = x509.NewCertPool()
+		}
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificates found in CA file %s", cfg.CAFile)
+		}
+		tlsConfig.RootCAs = pool
+	}
+	if cfg.InsecureSkipVerify {
+		log.Printf("Warning: not verifying the Testkube API's TLS certificate")
+		tlsConfig.InsecureSkipVerify = true
+	}
+	transport.TLSClientConfig = tlsConfig
+	return transport, nil
+}
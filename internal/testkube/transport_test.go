@@ -0,0 +1,76 @@
+package testkube
+
+import (
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestTransportConfigFromEnv(t *testing.T) {
+	t.Setenv("TESTKUBE_REQUEST_TIMEOUT", "2m")
+	t.Setenv("TESTKUBE_INSECURE_SKIP_VERIFY", "true")
+	t.Setenv("TESTKUBE_PROXY_URL", "http://proxy.corp:3128")
+
+	cfg := transportConfigFromEnv()
+	if cfg.Timeout != 2*time.Minute || cfg.DownloadTimeout != DefaultDownloadTimeout {
+		t.Errorf("got timeouts %s and %s, expected 2m0s and %s", cfg.Timeout, cfg.DownloadTimeout, DefaultDownloadTimeout)
+	}
+	if !cfg.InsecureSkipVerify || cfg.ProxyURL != "http://proxy.corp:3128" {
+		t.Errorf("got %+v, expected insecure and proxied", cfg)
+	}
+
+	if _, err := (TransportConfig{ProxyURL: "proxy.corp"}).transport(); err == nil {
+		t.Error("expected an error for a proxy URL without a scheme")
+	}
+	if _, err := (TransportConfig{CAFile: filepath.Join(t.TempDir(), "missing.pem")}).transport(); err == nil {
+		t.Error("expected an error for a missing CA file")
+	}
+}
+
+func TestRealClient_CustomCA(t *testing.T) {
+	t.Setenv("TESTKUBE_RETRIES", "0")
+	ts := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer ts.Close()
+
+	if _, err := NewRealClientWithConfig(ClientConfig{URL: ts.URL}); err == nil {
+		t.Fatal("expected the internal CA's certificate to be rejected")
+	}
+
+	caFile := filepath.Join(t.TempDir(), "ca.pem")
+	ca := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: ts.Certificate().Raw})
+	if err := os.WriteFile(caFile, ca, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := NewRealClientWithConfig(ClientConfig{URL: ts.URL, Transport: TransportConfig{CAFile: caFile}}); err != nil {
+		t.Errorf("got %v, expected the CA file to be trusted", err)
+	}
+	if _, err := NewRealClientWithConfig(ClientConfig{URL: ts.URL, Transport: TransportConfig{InsecureSkipVerify: true}}); err != nil {
+		t.Errorf("got %v, expected verification to be skipped", err)
+	}
+}
+
+func TestRealClient_Proxy(t *testing.T) {
+	var proxied []string
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		proxied = append(proxied, r.URL.String())
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer proxy.Close()
+
+	_, err := NewRealClientWithConfig(ClientConfig{
+		URL:       "http://testkube-api.internal:8088",
+		Transport: TransportConfig{ProxyURL: proxy.URL},
+	})
+	if err != nil {
+		t.Fatalf("failed to connect through the proxy: %v", err)
+	}
+	if len(proxied) == 0 || proxied[0] != "http://testkube-api.internal:8088/health" {
+		t.Errorf("got %v, expected the health check through the proxy", proxied)
+	}
+}
@@ -0,0 +1,66 @@
+package testkube
+
+// Trigger sources: what started an execution
+const (
+	TriggerManual   = "manual"   // someone pressed run in a UI or ran the CLI
+	TriggerSchedule = "schedule" // a cron schedule
+	TriggerCI       = "ci"       // a pipeline, webhook or Kubernetes event trigger
+	TriggerRetry    = "retry"    // a re-run of an earlier execution
+	TriggerUnknown  = "unknown"
+)
+
+// Triggers lists the trigger sources, for filters and breakdowns
+var Triggers = []string{TriggerManual, TriggerSchedule, TriggerCI, TriggerRetry, TriggerUnknown}
+
+const (
+	// TriggerTag records the trigger source of runs started by the dashboard,
+	// which Testkube itself only sees as API calls
+	TriggerTag = "trigger"
+	// TriggeredByTag records who started a run from the dashboard
+	TriggeredByTag = "triggered-by"
+	// BranchTag and CommitTag record the branch and commit a CI run tested
+	BranchTag = "ci-branch"
+	CommitTag = "ci-commit"
+)
+
+// apiRunningContext is how Testkube records what started an execution
+type apiRunningContext struct {
+	Interface struct {
+		Name string `json:"name"`
+		Type string `json:"type"` // cli, ui, api, internal
+	} `json:"interface"`
+	Actor struct {
+		Name  string `json:"name"`
+		Email string `json:"email"`
+		Type  string `json:"type"` // cron, testtrigger, user, testworkflow, testworkflowexecution, program
+	} `json:"actor"`
+}
+
+// trigger resolves the source and actor of an execution. Tags set by the
+// dashboard win, since its runs all reach Testkube through the API.
+func trigger(tags map[string]string, rc *apiRunningContext) (string, string) {
+	if source := tags[TriggerTag]; source != "" {
+		return source, tags[TriggeredByTag]
+	}
+	if rc == nil {
+		return TriggerUnknown, ""
+	}
+
+	by := rc.Actor.Email
+	if by == "" {
+		by = rc.Actor.Name
+	}
+	switch rc.Actor.Type {
+	case "cron":
+		return TriggerSchedule, ""
+	case "testtrigger", "testworkflow", "testworkflowexecution":
+		return TriggerCI, by
+	}
+	switch rc.Interface.Type {
+	case "ui", "cli":
+		return TriggerManual, by
+	case "api":
+		return TriggerCI, by
+	}
+	return TriggerUnknown, by
+}
@@ -0,0 +1,33 @@
+package testkube
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestExecutionTrigger(t *testing.T) {
+	tests := []struct {
+		body        string
+		trigger     string
+		triggeredBy string
+	}{
+		{`{"runningContext": {"interface": {"type": "internal"}, "actor": {"type": "cron", "name": "cron"}}}`, TriggerSchedule, ""},
+		{`{"runningContext": {"interface": {"type": "ui"}, "actor": {"type": "user", "email": "alice@example.com"}}}`, TriggerManual, "alice@example.com"},
+		{`{"runningContext": {"interface": {"type": "cli"}, "actor": {"type": "user", "name": "bob"}}}`, TriggerManual, "bob"},
+		{`{"runningContext": {"interface": {"type": "api"}, "actor": {"type": "program", "name": "jenkins"}}}`, TriggerCI, "jenkins"},
+		{`{"runningContext": {"interface": {"type": "internal"}, "actor": {"type": "testtrigger", "name": "on-deploy"}}}`, TriggerCI, "on-deploy"},
+		// Runs started by the dashboard are tagged with their real source
+		{`{"tags": {"trigger": "retry", "triggered-by": "carol"}, "runningContext": {"interface": {"type": "api"}}}`, TriggerRetry, "carol"},
+		{`{}`, TriggerUnknown, ""},
+	}
+	for _, tt := range tests {
+		var e apiExecution
+		if err := json.Unmarshal([]byte(tt.body), &e); err != nil {
+			t.Fatalf("failed to parse %s: %v", tt.body, err)
+		}
+		exec := e.toExecution()
+		if exec.Trigger != tt.trigger || exec.TriggeredBy != tt.triggeredBy {
+			t.Errorf("%s: got %s by %q, expected %s by %q", tt.body, exec.Trigger, exec.TriggeredBy, tt.trigger, tt.triggeredBy)
+		}
+	}
+}
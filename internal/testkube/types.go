@@ -0,0 +1,237 @@
+package testkube
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+)
+
+// CustomType is the type of workflows no registered type recognizes
+const CustomType = "custom"
+
+// TypeLabel sets a workflow's type explicitly, overriding detection from
+// its images
+const TypeLabel = "testworkflows.testkube.io/type"
+
+// Workflow type categories, which group types for features that apply to
+// every runner of a kind
+const (
+	CategoryE2E           = "e2e"
+	CategoryUnit          = "unit"
+	CategoryAPI           = "api"
+	CategoryLoad          = "load"
+	CategorySecurity      = "security"
+	CategoryChaos         = "chaos"
+	CategoryObservability = "observability"
+	CategoryCost          = "cost"
+)
+
+// WorkflowType describes a kind of runner: how to recognize its container
+// images, and how the dashboard shows and ingests its results
+type WorkflowType struct {
+	Name string `json:"name"`
+	// Patterns are matched against the container image, ignoring case; an
+	// image containing any of them is of this type
+	Patterns []string `json:"patterns"`
+	Icon     string   `json:"icon,omitempty"`
+	Category string   `json:"category,omitempty"`
+	// Parser names the ingestion worker parser that reads the type's result
+	// artifacts, and Renderer the view its execution results are shown with
+	Parser   string `json:"parser,omitempty"`
+	Renderer string `json:"renderer,omitempty"`
+}
+
+// builtinTypes are the runners known out of the box, in detection order
+var builtinTypes = []WorkflowType{
+	{Name: "playwright", Patterns: []string{"playwright"}, Icon: "🎭", Category: CategoryE2E, Parser: "playwright", Renderer: "playwright"},
+	{Name: "vitest", Patterns: []string{"vitest"}, Icon: "⚡", Category: CategoryUnit},
+	{Name: "k6", Patterns: []string{"k6"}, Icon: "📈", Category: CategoryLoad, Renderer: "k6"},
+	{Name: "postman", Patterns: []string{"postman"}, Icon: "📮", Category: CategoryAPI},
+	{Name: "cypress", Patterns: []string{"cypress"}, Icon: "🌲", Category: CategoryE2E},
+	{Name: "trivy", Patterns: []string{"trivy"}, Icon: "🛡", Category: CategorySecurity, Renderer: "findings"},
+	{Name: "kubescape", Patterns: []string{"kubescape"}, Icon: "🛡", Category: CategorySecurity, Renderer: "findings"},
+	{Name: "sonarqube", Patterns: []string{"sonarqube"}, Icon: "🛡", Category: CategorySecurity, Renderer: "findings"},
+	{Name: "semgrep", Patterns: []string{"semgrep"}, Icon: "🛡", Category: CategorySecurity, Renderer: "findings"},
+	{Name: "defectdojo", Patterns: []string{"defectdojo", "defect-dojo"}, Icon: "🛡", Category: CategorySecurity, Renderer: "findings"},
+	{Name: "chaosmesh", Patterns: []string{"chaos-mesh", "chaosmesh"}, Icon: "💥", Category: CategoryChaos},
+	{Name: "signoz", Patterns: []string{"signoz"}, Icon: "📡", Category: CategoryObservability},
+	{Name: "testtrace", Patterns: []string{"testtrace"}, Icon: "🔍", Category: CategoryObservability},
+	{Name: "infracost", Patterns: []string{"infracost"}, Icon: "💰", Category: CategoryCost},
+	{Name: "emba", Patterns: []string{"emba"}, Icon: "🛡", Category: CategorySecurity, Renderer: "findings"},
+	{Name: "emqtt-bench", Patterns: []string{"emqtt-bench"}, Icon: "📶", Category: CategoryLoad},
+	{Name: "thingboard", Patterns: []string{"thingboard", "thingsboard"}, Icon: "📟"},
+	{Name: "kubekert", Patterns: []string{"kubekert"}, Icon: "📜"},
+}
+
+// customType is what images no registered type recognizes are
+var customType = WorkflowType{Name: CustomType, Icon: "⚙"}
+
+// TypeRegistry detects workflow types from container images. Types loaded
+// from configuration are tried before the built-in ones, so they can
+// claim new runner images or override a built-in type of the same name.
+type TypeRegistry struct {
+	mu         sync.RWMutex
+	configured []WorkflowType
+}
+
+type typeConfig struct {
+	Types []WorkflowType `json:"types"`
+}
+
+// DefaultTypes is the registry clients detect workflow types with
+var DefaultTypes = &TypeRegistry{}
+
+// LoadTypes loads the JSON file in WORKFLOW_TYPES_FILE, when set, into
+// DefaultTypes and returns it
+func LoadTypes() (*TypeRegistry, error) {
+	if file := os.Getenv("WORKFLOW_TYPES_FILE"); file != "" {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return DefaultTypes, fmt.Errorf("failed to read workflow types file: %w", err)
+		}
+		if err := DefaultTypes.Import(data); err != nil {
+			return DefaultTypes, err
+		}
+	}
+	return DefaultTypes, nil
+}
+
+// Detect returns the name of the type an image belongs to, or CustomType
+func (r *TypeRegistry) Detect(image string) string {
+	image = strings.ToLower(image)
+	for _, t := range r.Types() {
+		for _, pattern := range t.Patterns {
+			if pattern != "" && strings.Contains(image, strings.ToLower(pattern)) {
+				return t.Name
+			}
+		}
+	}
+	return CustomType
+}
+
+// DetectWorkflow returns a workflow's type: the one its TypeLabel names,
+// or else the first image in its spec that a registered type recognizes,
+// looking at spec.container and then at the containers of its setup,
+// steps, parallel blocks and after steps, in order. Workflows whose images
+// are all generic, e.g. node or alpine running a script, are recognized by
+// the templates they use, such as official/k6/v1.
+func (r *TypeRegistry) DetectWorkflow(labels map[string]string, spec map[string]interface{}) string {
+	if name := strings.TrimSpace(labels[TypeLabel]); name != "" {
+		return name
+	}
+	for _, image := range specImages(spec) {
+		if t := r.Detect(image); t != CustomType {
+			return t
+		}
+	}
+	for _, ref := range templateRefs(spec) {
+		if t := r.Detect(ref); t != CustomType {
+			return t
+		}
+	}
+	return CustomType
+}
+
+// specImages lists the container images in a workflow spec, the workflow's
+// own first. Services are left out: they are the databases and browsers a
+// test talks to rather than its runner.
+func specImages(spec map[string]interface{}) []string {
+	var images []string
+	var walk func(node map[string]interface{})
+	walk = func(node map[string]interface{}) {
+		for _, key := range []string{"container", "run"} {
+			if c, ok := node[key].(map[string]interface{}); ok {
+				if image, ok := c["image"].(string); ok && image != "" {
+					images = append(images, image)
+				}
+			}
+		}
+		if parallel, ok := node["parallel"].(map[string]interface{}); ok {
+			walk(parallel)
+		}
+		for _, key := range []string{"setup", "steps", "after"} {
+			steps, _ := node[key].([]interface{})
+			for _, step := range steps {
+				if m, ok := step.(map[string]interface{}); ok {
+					walk(m)
+				}
+			}
+		}
+	}
+	walk(spec)
+	return images
+}
+
+// Get returns a type by name. Unknown names get the custom type's
+// presentation under their own name.
+func (r *TypeRegistry) Get(name string) WorkflowType {
+	for _, t := range r.Types() {
+		if t.Name == name {
+			return t
+		}
+	}
+	t := customType
+	if name != "" {
+		t.Name = name
+	}
+	return t
+}
+
+// Types lists the registered types in detection order: configured, then
+// the built-in types they don't override
+func (r *TypeRegistry) Types() []WorkflowType {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	types := append([]WorkflowType{}, r.configured...)
+	for _, t := range builtinTypes {
+		if !r.configuredLocked(t.Name) {
+			types = append(types, t)
+		}
+	}
+	return types
+}
+
+func (r *TypeRegistry) configuredLocked(name string) bool {
+	for _, t := range r.configured {
+		if t.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// Export returns the configured types as JSON, for configuration sync
+func (r *TypeRegistry) Export() (json.RawMessage, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return json.Marshal(typeConfig{Types: r.configured})
+}
+
+// Import replaces the configured types
+func (r *TypeRegistry) Import(data json.RawMessage) error {
+	var cfg typeConfig
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return fmt.Errorf("failed to parse workflow types: %w", err)
+	}
+
+	seen := make(map[string]bool)
+	for _, t := range cfg.Types {
+		if t.Name == "" || t.Name == CustomType {
+			return fmt.Errorf("workflow types need a name other than %q", CustomType)
+		}
+		if seen[t.Name] {
+			return fmt.Errorf("workflow type %s is defined twice", t.Name)
+		}
+		seen[t.Name] = true
+		if len(t.Patterns) == 0 {
+			return fmt.Errorf("workflow type %s needs at least one image pattern", t.Name)
+		}
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.configured = cfg.Types
+	return nil
+}
@@ -0,0 +1,168 @@
+package testkube
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+func TestTypeRegistryDetect(t *testing.T) {
+	r := &TypeRegistry{}
+	tests := []struct {
+		image    string
+		expected string
+	}{
+		{"mcr.microsoft.com/playwright:v1.47.0", "playwright"},
+		{"ghcr.io/org/Chaos-Mesh-runner:1", "chaosmesh"},
+		{"grafana/k6:0.50", "k6"},
+		{"registry.local/unknown:latest", CustomType},
+		{"", CustomType},
+	}
+	for _, tt := range tests {
+		if got := r.Detect(tt.image); got != tt.expected {
+			t.Errorf("Detect(%q) = %s, expected %s", tt.image, got, tt.expected)
+		}
+	}
+
+	if got := r.Get("trivy").Category; got != CategorySecurity {
+		t.Errorf("got category %q for trivy, expected %s", got, CategorySecurity)
+	}
+	if got := r.Get("nightwatch"); got.Name != "nightwatch" || got.Icon != customType.Icon {
+		t.Errorf("got %+v for an unknown type, expected the custom presentation", got)
+	}
+}
+
+func TestTypeRegistryDetectWorkflow(t *testing.T) {
+	r := &TypeRegistry{}
+	tests := []struct {
+		name     string
+		labels   map[string]string
+		spec     string
+		expected string
+	}{
+		{"workflow container", nil, `
+container:
+  image: mcr.microsoft.com/playwright:v1.47.0
+steps:
+- shell: npx playwright test`, "playwright"},
+		{"step container", nil, `
+steps:
+- name: checkout
+  shell: git clone
+- name: load test
+  run:
+    image: grafana/k6:0.50
+    args: [run, test.js]`, "k6"},
+		{"generic image then a step's", nil, `
+container:
+  image: node:20
+setup:
+- container:
+    image: alpine
+steps:
+- parallel:
+    count: 2
+    container:
+      image: cypress/included:13
+    shell: cypress run`, "cypress"},
+		{"services aren't the runner", nil, `
+services:
+  db:
+    image: trivy-db-mirror
+steps:
+- shell: npm test
+  container:
+    image: node:20`, CustomType},
+		{"template", nil, `
+steps:
+- use:
+  - name: official/k6/v1`, "k6"},
+		{"label", map[string]string{TypeLabel: "gatling"}, `
+container:
+  image: grafana/k6:0.50`, "gatling"},
+	}
+	for _, tt := range tests {
+		var spec map[string]interface{}
+		if err := yaml.Unmarshal([]byte(tt.spec), &spec); err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		if got := r.DetectWorkflow(tt.labels, spec); got != tt.expected {
+			t.Errorf("%s: got %s, expected %s", tt.name, got, tt.expected)
+		}
+	}
+}
+
+func TestTypeRegistryImport(t *testing.T) {
+	r := &TypeRegistry{}
+	err := r.Import([]byte(`{"types": [
+		{"name": "nightwatch", "patterns": ["nightwatch"], "icon": "🦉", "category": "e2e"},
+		{"name": "k6", "patterns": ["load-runner"], "category": "load"}
+	]}`))
+	if err != nil {
+		t.Fatalf("import failed: %v", err)
+	}
+
+	if got := r.Detect("org/nightwatch:2"); got != "nightwatch" {
+		t.Errorf("got %s, expected the configured type", got)
+	}
+	// A configured type replaces the built-in one of the same name
+	if got := r.Detect("org/load-runner:1"); got != "k6" {
+		t.Errorf("got %s, expected k6 from its configured pattern", got)
+	}
+	if got := r.Detect("grafana/k6:0.50"); got != CustomType {
+		t.Errorf("got %s, expected the built-in k6 pattern to be overridden", got)
+	}
+	if got := r.Detect("mcr.microsoft.com/playwright:v1"); got != "playwright" {
+		t.Errorf("got %s, expected built-in types to still apply", got)
+	}
+	if n, expected := len(r.Types()), len(builtinTypes)+1; n != expected {
+		t.Errorf("got %d types, expected %d", n, expected)
+	}
+
+	exported, err := r.Export()
+	if err != nil {
+		t.Fatalf("export failed: %v", err)
+	}
+	copied := &TypeRegistry{}
+	if err := copied.Import(exported); err != nil {
+		t.Fatalf("import of export failed: %v", err)
+	}
+	if got := copied.Detect("org/nightwatch:2"); got != "nightwatch" {
+		t.Errorf("got %s after a round trip, expected nightwatch", got)
+	}
+
+	for _, bad := range []string{
+		`{"types": [{"name": "nopatterns"}]}`,
+		`{"types": [{"name": "custom", "patterns": ["x"]}]}`,
+		`{"types": [{"name": "a", "patterns": ["x"]}, {"name": "a", "patterns": ["y"]}]}`,
+		`not json`,
+	} {
+		if err := r.Import([]byte(bad)); err == nil {
+			t.Errorf("expected an error importing %s", bad)
+		}
+	}
+	if got := r.Detect("org/nightwatch:2"); got != "nightwatch" {
+		t.Errorf("got %s, expected a failed import to keep the previous types", got)
+	}
+}
+
+func TestLoadTypes(t *testing.T) {
+	defer DefaultTypes.Import([]byte(`{"types": []}`))
+
+	file := filepath.Join(t.TempDir(), "types.json")
+	os.WriteFile(file, []byte(`{"types": [{"name": "gatling", "patterns": ["gatling"], "category": "load"}]}`), 0o644)
+	t.Setenv("WORKFLOW_TYPES_FILE", file)
+
+	r, err := LoadTypes()
+	if err != nil {
+		t.Fatalf("load failed: %v", err)
+	}
+	if got := extractWorkflowType("org/gatling:3"); got != "gatling" {
+		t.Errorf("got %s, expected the loaded type to apply to clients", got)
+	}
+	if r != DefaultTypes {
+		t.Errorf("expected LoadTypes to return the default registry")
+	}
+}
@@ -0,0 +1,174 @@
+package testkube
+
+import (
+	"context"
+	"log"
+	"maps"
+	"slices"
+	"sync"
+	"time"
+)
+
+const (
+	// DefaultWatchInterval is how often RealClient looks for execution
+	// changes, unless TESTKUBE_WATCH_INTERVAL says otherwise
+	DefaultWatchInterval = 5 * time.Second
+
+	// watchPageSize is how many of the latest executions each check reads
+	watchPageSize = 100
+
+	// mockWatchInterval is how often the mock's watches look for changes to
+	// its simulated executions
+	mockWatchInterval = 500 * time.Millisecond
+)
+
+// activeExecutionStatuses are the states of executions that haven't finished
+var activeExecutionStatuses = []string{"queued", "running", "paused"}
+
+// ExecutionEvent is a change in an execution's status
+type ExecutionEvent struct {
+	Execution Execution
+	// Previous is the status before the change, empty for an execution
+	// started since the watch began
+	Previous string
+}
+
+// Finished reports whether the change ended the execution
+func (e ExecutionEvent) Finished() bool {
+	return e.Execution.Status != "" && !slices.Contains(activeExecutionStatuses, e.Execution.Status)
+}
+
+// sharedWatch polls the latest executions for every consumer of a client's
+// WatchExecutions, so that a page of executions is listed once each
+// interval however many consumers there are. The poll starts with the first
+// consumer and stops when the last one's context is done. Executions that
+// drop off the page while they run aren't followed, and a consumer slow to
+// receive holds back the others. The zero value is ready to use.
+type sharedWatch struct {
+	mu       sync.Mutex
+	watchers map[*watcher]bool
+	// stop ends the running poll, nil when there is none
+	stop context.CancelFunc
+}
+
+// watcher is one consumer of a sharedWatch
+type watcher struct {
+	ctx    context.Context
+	events chan ExecutionEvent
+
+	mu     sync.Mutex
+	closed bool
+}
+
+// Watch sends the changes between successive calls to list, every interval
+// until ctx is done, oldest execution first. A watch joining a poll already
+// running shares its interval and list, and sees the changes from then on.
+func (s *sharedWatch) Watch(ctx context.Context, interval time.Duration, list func() ([]Execution, error)) (<-chan ExecutionEvent, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.stop == nil {
+		executions, err := list()
+		if err != nil {
+			return nil, err
+		}
+		pollCtx, stop := context.WithCancel(context.Background())
+		s.stop = stop
+		go s.poll(pollCtx, interval, list, statusesOf(executions))
+	}
+
+	w := &watcher{ctx: ctx, events: make(chan ExecutionEvent)}
+	if s.watchers == nil {
+		s.watchers = make(map[*watcher]bool)
+	}
+	s.watchers[w] = true
+	go func() {
+		<-ctx.Done()
+		s.mu.Lock()
+		delete(s.watchers, w)
+		if len(s.watchers) == 0 {
+			s.stop()
+			s.stop = nil
+		}
+		s.mu.Unlock()
+		w.close()
+	}()
+	return w.events, nil
+}
+
+func (s *sharedWatch) poll(ctx context.Context, interval time.Duration, list func() ([]Execution, error), seen map[string]string) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		executions, err := list()
+		if err != nil {
+			log.Printf("Warning: failed to check for execution changes: %v", err)
+			continue
+		}
+		var events []ExecutionEvent
+		for i := len(executions) - 1; i >= 0; i-- {
+			exec := executions[i]
+			previous, known := seen[exec.ID]
+			if known && previous == exec.Status {
+				continue
+			}
+			events = append(events, ExecutionEvent{Execution: exec, Previous: previous})
+		}
+		seen = statusesOf(executions)
+
+		for _, w := range s.watching(ctx) {
+			for _, event := range events {
+				if !w.send(event) {
+					break
+				}
+			}
+		}
+	}
+}
+
+// watching returns the consumers of the poll, none once ctx is done and a
+// later poll may have taken over
+func (s *sharedWatch) watching(ctx context.Context) []*watcher {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if ctx.Err() != nil {
+		return nil
+	}
+	return slices.Collect(maps.Keys(s.watchers))
+}
+
+// send sends event unless the watch is over, reporting whether it did
+func (w *watcher) send(event ExecutionEvent) bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.closed {
+		return false
+	}
+	select {
+	case <-w.ctx.Done():
+		return false
+	case w.events <- event:
+		return true
+	}
+}
+
+func (w *watcher) close() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.closed = true
+	close(w.events)
+}
+
+func statusesOf(executions []Execution) map[string]string {
+	statuses := make(map[string]string, len(executions))
+	for _, exec := range executions {
+		statuses[exec.ID] = exec.Status
+	}
+	return statuses
+}
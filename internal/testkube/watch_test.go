@@ -0,0 +1,154 @@
+package testkube
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestSharedWatch(t *testing.T) {
+	var mu sync.Mutex
+	pages := [][]Execution{
+		{{ID: "b", Status: "running"}, {ID: "a", Status: "passed"}},
+		// b finishes and c starts
+		{{ID: "c", Status: "queued"}, {ID: "b", Status: "failed"}, {ID: "a", Status: "passed"}},
+	}
+	calls := 0
+	list := func() ([]Execution, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		calls++
+		switch {
+		case calls == 2:
+			return nil, errors.New("API unavailable")
+		case calls > 2:
+			return pages[1], nil
+		}
+		return pages[0], nil
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	var watch sharedWatch
+	events, err := watch.Watch(ctx, 10*time.Millisecond, list)
+	if err != nil {
+		t.Fatalf("Watch failed: %v", err)
+	}
+
+	var got []ExecutionEvent
+	timeout := time.After(5 * time.Second)
+	for len(got) < 2 {
+		select {
+		case event := <-events:
+			got = append(got, event)
+		case <-timeout:
+			t.Fatalf("got %d events, expected 2", len(got))
+		}
+	}
+	// Older executions come first
+	if got[0].Execution.ID != "b" || got[0].Previous != "running" || !got[0].Finished() {
+		t.Errorf("got %+v, expected b to finish", got[0])
+	}
+	if got[1].Execution.ID != "c" || got[1].Previous != "" || got[1].Finished() {
+		t.Errorf("got %+v, expected c to start", got[1])
+	}
+
+	cancel()
+	for range events {
+		// A watch closes its channel when ctx is done
+	}
+
+	var unavailable sharedWatch
+	if _, err := unavailable.Watch(context.Background(), time.Second, func() ([]Execution, error) {
+		return nil, errors.New("API unavailable")
+	}); err == nil {
+		t.Error("expected an error when the API can't be reached")
+	}
+}
+
+func TestSharedWatch_ListsOnceForEveryConsumer(t *testing.T) {
+	var mu sync.Mutex
+	calls := 0
+	// The first poll waits for both watches to start
+	joined := make(chan struct{})
+	list := func() ([]Execution, error) {
+		mu.Lock()
+		calls++
+		n := calls
+		mu.Unlock()
+		if n == 1 {
+			return []Execution{{ID: "a", Status: "running"}}, nil
+		}
+		if n == 2 {
+			<-joined
+		}
+		return []Execution{{ID: "a", Status: "passed"}}, nil
+	}
+
+	var watch sharedWatch
+	ctx, cancel := context.WithCancel(context.Background())
+	first, err := watch.Watch(ctx, 10*time.Millisecond, list)
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := watch.Watch(ctx, 10*time.Millisecond, list)
+	if err != nil {
+		t.Fatal(err)
+	}
+	close(joined)
+
+	// Both watches see the change from a single poll, so no more than the
+	// starting list and one poll have run when the first of them sees it
+	listed := 0
+	for received := 0; received < 2; received++ {
+		var event ExecutionEvent
+		select {
+		case event = <-first:
+			first = nil
+		case event = <-second:
+			second = nil
+		case <-time.After(5 * time.Second):
+			t.Fatal("expected both watches to see a finish")
+		}
+		if event.Execution.ID != "a" || !event.Finished() {
+			t.Errorf("got %+v, expected a to finish", event)
+		}
+		if received == 0 {
+			mu.Lock()
+			listed = calls
+			mu.Unlock()
+		}
+	}
+	if listed != 2 {
+		t.Errorf("listed %d times, expected one poll shared by both watches", listed)
+	}
+	cancel()
+
+	// The poll stops with the last watch, and the next watch starts another
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		watch.mu.Lock()
+		stopped := watch.stop == nil
+		watch.mu.Unlock()
+		if stopped {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("expected the poll to stop with the last watch")
+		}
+		time.Sleep(time.Millisecond)
+	}
+	mu.Lock()
+	before := calls
+	mu.Unlock()
+	if _, err := watch.Watch(context.Background(), time.Hour, list); err != nil {
+		t.Fatal(err)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if calls == before {
+		t.Error("expected a new watch to list again")
+	}
+}
@@ -0,0 +1,149 @@
+package testkube
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+
+	"gopkg.in/yaml.v3"
+)
+
+var (
+	// ErrWorkflowNotFound is returned for a workflow that doesn't exist
+	ErrWorkflowNotFound = errors.New("workflow not found")
+	// ErrWorkflowExists is returned when creating a workflow whose name is taken
+	ErrWorkflowExists = errors.New("workflow already exists")
+	// ErrInvalidWorkflowSpec is returned for YAML that isn't a TestWorkflow
+	// definition the API would accept
+	ErrInvalidWorkflowSpec = errors.New("invalid workflow spec")
+)
+
+const (
+	workflowAPIVersion = "testworkflows.testkube.io/v1"
+	workflowKind       = KindTestWorkflow
+)
+
+// workflowNamePattern is a Kubernetes resource name (DNS-1123 subdomain label)
+var workflowNamePattern = regexp.MustCompile(`^[a-z0-9]([-a-z0-9]*[a-z0-9])?$`)
+
+// workflowManifest is a TestWorkflow definition, as kubectl and the
+// Testkube API read it
+type workflowManifest struct {
+	APIVersion string `yaml:"apiVersion"`
+	Kind       string `yaml:"kind"`
+	Metadata   struct {
+		Name      string            `yaml:"name"`
+		Namespace string            `yaml:"namespace,omitempty"`
+		Labels    map[string]string `yaml:"labels,omitempty"`
+	} `yaml:"metadata"`
+	Spec map[string]interface{} `yaml:"spec"`
+}
+
+// ParseWorkflowSpec checks a TestWorkflow's YAML definition, returning the
+// workflow it describes
+func ParseWorkflowSpec(spec []byte) (*Workflow, error) {
+	var m workflowManifest
+	if err := yaml.Unmarshal(spec, &m); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidWorkflowSpec, err)
+	}
+	if m.Kind != workflowKind {
+		return nil, fmt.Errorf("%w: kind is %q, expected %s", ErrInvalidWorkflowSpec, m.Kind, workflowKind)
+	}
+	if m.APIVersion != "" && m.APIVersion != workflowAPIVersion {
+		return nil, fmt.Errorf("%w: apiVersion is %q, expected %s", ErrInvalidWorkflowSpec, m.APIVersion, workflowAPIVersion)
+	}
+	if !workflowNamePattern.MatchString(m.Metadata.Name) || len(m.Metadata.Name) > 63 {
+		return nil, fmt.Errorf("%w: metadata.name %q must be lowercase letters, digits and dashes", ErrInvalidWorkflowSpec, m.Metadata.Name)
+	}
+	if len(m.Spec) == 0 {
+		return nil, fmt.Errorf("%w: spec is empty", ErrInvalidWorkflowSpec)
+	}
+
+	return &Workflow{
+		Name:      m.Metadata.Name,
+		Namespace: m.Metadata.Namespace,
+		Labels:    m.Metadata.Labels,
+		Type:      DefaultTypes.DetectWorkflow(m.Metadata.Labels, m.Spec),
+		Templates: templateRefs(m.Spec),
+	}, nil
+}
+
+// ConfigParameter is a config variable a workflow declares in spec.config,
+// which runs can set
+type ConfigParameter struct {
+	Name        string   `json:"name"`
+	Type        string   `json:"type"` // string, integer, number or boolean
+	Description string   `json:"description,omitempty"`
+	Default     string   `json:"default,omitempty"`
+	Enum        []string `json:"enum,omitempty"`
+	Sensitive   bool     `json:"sensitive,omitempty"`
+}
+
+// WorkflowConfig lists the config parameters a TestWorkflow definition
+// declares, sorted by name
+func WorkflowConfig(spec []byte) ([]ConfigParameter, error) {
+	var m struct {
+		Spec struct {
+			Config map[string]struct {
+				Type        string        `yaml:"type"`
+				Description string        `yaml:"description"`
+				Default     interface{}   `yaml:"default"`
+				Enum        []interface{} `yaml:"enum"`
+				Sensitive   bool          `yaml:"sensitive"`
+			} `yaml:"config"`
+		} `yaml:"spec"`
+	}
+	if err := yaml.Unmarshal(spec, &m); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidWorkflowSpec, err)
+	}
+
+	params := make([]ConfigParameter, 0, len(m.Spec.Config))
+	for name, c := range m.Spec.Config {
+		p := ConfigParameter{Name: name, Type: c.Type, Description: c.Description, Sensitive: c.Sensitive}
+		if p.Type == "" {
+			p.Type = "string"
+		}
+		if c.Default != nil {
+			p.Default = fmt.Sprint(c.Default)
+		}
+		for _, v := range c.Enum {
+			p.Enum = append(p.Enum, fmt.Sprint(v))
+		}
+		params = append(params, p)
+	}
+	sort.Slice(params, func(i, j int) bool { return params[i].Name < params[j].Name })
+	return params, nil
+}
+
+// mockSchedules are the cron triggers of mock workflows that run on a
+// schedule
+var mockSchedules = map[string]string{
+	"api-load-test":    "0 */6 * * *",
+	"cluster-security": "0 2 * * *",
+	"k8s-compliance":   "30 3 * * 1",
+}
+
+// mockWorkflowSpec writes a definition for a generated mock workflow, whose
+// container image names its type
+func mockWorkflowSpec(wf Workflow) []byte {
+	var m workflowManifest
+	m.APIVersion, m.Kind = workflowAPIVersion, workflowKind
+	m.Metadata.Name, m.Metadata.Namespace, m.Metadata.Labels = wf.Name, wf.Namespace, wf.Labels
+	m.Spec = map[string]interface{}{
+		"container": map[string]interface{}{"image": fmt.Sprintf("testkube/%s:latest", wf.Type)},
+		"steps":     []map[string]interface{}{{"name": "Run tests", "shell": "run-tests"}},
+	}
+	if cron, ok := mockSchedules[wf.Name]; ok {
+		m.Spec["events"] = []map[string]interface{}{{"cronjob": map[string]interface{}{"cron": cron}}}
+	}
+	if len(wf.Templates) > 0 {
+		use := make([]map[string]interface{}, 0, len(wf.Templates))
+		for _, name := range wf.Templates {
+			use = append(use, map[string]interface{}{"name": name})
+		}
+		m.Spec["use"] = use
+	}
+	spec, _ := yaml.Marshal(m)
+	return spec
+}
@@ -0,0 +1,213 @@
+package testkube
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+const testWorkflowSpec = `apiVersion: testworkflows.testkube.io/v1
+kind: TestWorkflow
+metadata:
+  name: checkout-e2e
+  labels:
+    team: payments
+spec:
+  container:
+    image: mcr.microsoft.com/playwright:v1.47.0
+  steps:
+    - name: Run tests
+      shell: npx playwright test
+`
+
+func TestParseWorkflowSpec(t *testing.T) {
+	wf, err := ParseWorkflowSpec([]byte(testWorkflowSpec))
+	if err != nil {
+		t.Fatalf("ParseWorkflowSpec failed: %v", err)
+	}
+	if wf.Name != "checkout-e2e" || wf.Type != "playwright" || wf.Labels["team"] != "payments" {
+		t.Errorf("got %+v, expected checkout-e2e, a playwright workflow owned by payments", wf)
+	}
+
+	invalid := map[string]string{
+		"not yaml":     "kind: [",
+		"wrong kind":   strings.Replace(testWorkflowSpec, "kind: TestWorkflow", "kind: Test", 1),
+		"bad name":     strings.Replace(testWorkflowSpec, "name: checkout-e2e", "name: Checkout_E2E", 1),
+		"missing spec": strings.Split(testWorkflowSpec, "spec:")[0],
+	}
+	for name, spec := range invalid {
+		if _, err := ParseWorkflowSpec([]byte(spec)); !errors.Is(err, ErrInvalidWorkflowSpec) {
+			t.Errorf("%s: got %v, expected ErrInvalidWorkflowSpec", name, err)
+		}
+	}
+}
+
+func TestWorkflowConfig(t *testing.T) {
+	spec := strings.Replace(testWorkflowSpec, "spec:\n", `spec:
+  config:
+    workers:
+      type: integer
+      default: 4
+    browser:
+      description: Browser to run in
+      enum: [chromium, firefox]
+    token:
+      type: string
+      sensitive: true
+`, 1)
+	params, err := WorkflowConfig([]byte(spec))
+	if err != nil {
+		t.Fatalf("WorkflowConfig failed: %v", err)
+	}
+	if len(params) != 3 {
+		t.Fatalf("got %d parameters, expected 3", len(params))
+	}
+	browser, token, workers := params[0], params[1], params[2]
+	if browser.Name != "browser" || browser.Type != "string" || len(browser.Enum) != 2 {
+		t.Errorf("got %+v, expected a string browser parameter with two choices", browser)
+	}
+	if !token.Sensitive {
+		t.Errorf("expected token to be sensitive")
+	}
+	if workers.Type != "integer" || workers.Default != "4" {
+		t.Errorf("got %+v, expected an integer defaulting to 4", workers)
+	}
+
+	params, err = WorkflowConfig([]byte(testWorkflowSpec))
+	if err != nil || len(params) != 0 {
+		t.Errorf("got %v, %v, expected no parameters", params, err)
+	}
+}
+
+func TestMockClientWorkflowCRUD(t *testing.T) {
+	c := NewMockClient()
+
+	// Generated workflows have a definition that round-trips
+	spec, err := c.GetWorkflowSpec("frontend-e2e")
+	if err != nil {
+		t.Fatalf("GetWorkflowSpec failed: %v", err)
+	}
+	if wf, err := ParseWorkflowSpec(spec); err != nil || wf.Type != "playwright" {
+		t.Errorf("got %+v, %v, expected frontend-e2e's spec to parse as playwright", wf, err)
+	}
+
+	if _, err := c.CreateWorkflow([]byte(testWorkflowSpec)); err != nil {
+		t.Fatalf("CreateWorkflow failed: %v", err)
+	}
+	if _, err := c.CreateWorkflow([]byte(testWorkflowSpec)); !errors.Is(err, ErrWorkflowExists) {
+		t.Errorf("got %v, expected ErrWorkflowExists", err)
+	}
+
+	updated := strings.Replace(testWorkflowSpec, "team: payments", "team: checkout", 1)
+	wf, err := c.UpdateWorkflow("checkout-e2e", []byte(updated))
+	if err != nil {
+		t.Fatalf("UpdateWorkflow failed: %v", err)
+	}
+	if wf.Labels["team"] != "checkout" {
+		t.Errorf("got team %q, expected checkout", wf.Labels["team"])
+	}
+	if _, err := c.UpdateWorkflow("frontend-e2e", []byte(updated)); !errors.Is(err, ErrInvalidWorkflowSpec) {
+		t.Errorf("got %v, expected a name mismatch to be invalid", err)
+	}
+
+	if err := c.DeleteWorkflow("checkout-e2e"); err != nil {
+		t.Fatalf("DeleteWorkflow failed: %v", err)
+	}
+	if _, err := c.GetWorkflowSpec("checkout-e2e"); !errors.Is(err, ErrWorkflowNotFound) {
+		t.Errorf("got %v, expected ErrWorkflowNotFound after deleting", err)
+	}
+	if err := c.DeleteWorkflow("checkout-e2e"); !errors.Is(err, ErrWorkflowNotFound) {
+		t.Errorf("got %v, expected ErrWorkflowNotFound", err)
+	}
+}
+
+func TestWorkflowSchedules(t *testing.T) {
+	spec := testWorkflowSpec + `  events:
+    - cronjob:
+        cron: "0 2 * * *"
+        labels:
+          suite: nightly
+    - cronjob:
+        cron: "*/30 * * * *"
+        timezone: Europe/London
+    - kubernetesObject:
+        resource: deployment
+        event: modified
+`
+	list, err := WorkflowSchedules([]byte(spec))
+	if err != nil {
+		t.Fatalf("WorkflowSchedules failed: %v", err)
+	}
+	if len(list) != 2 || list[0].Cron != "0 2 * * *" || list[1].Timezone != "Europe/London" {
+		t.Fatalf("got %+v, expected the two cron triggers", list)
+	}
+
+	// Pausing takes the trigger out of spec.events, keeping the others and
+	// the labels of the one left unchanged
+	list[1].Paused = true
+	updated, err := SetWorkflowSchedules([]byte(spec), list)
+	if err != nil {
+		t.Fatalf("SetWorkflowSchedules failed: %v", err)
+	}
+	for _, want := range []string{"suite: nightly", "kubernetesObject", pausedSchedulesAnnotation} {
+		if !strings.Contains(string(updated), want) {
+			t.Errorf("got\n%s\nexpected it to contain %q", updated, want)
+		}
+	}
+	if strings.Contains(string(updated), "cron: '*/30 * * * *'") {
+		t.Errorf("got\n%s\nexpected the paused trigger out of spec.events", updated)
+	}
+	got, err := WorkflowSchedules(updated)
+	if err != nil {
+		t.Fatalf("WorkflowSchedules failed: %v", err)
+	}
+	if len(got) != 2 || got[0].Paused || !got[1].Paused || got[1].Timezone != "Europe/London" {
+		t.Errorf("got %+v, expected the second trigger paused", got)
+	}
+
+	// Resuming puts it back and drops the annotation
+	got[1].Paused = false
+	resumed, err := SetWorkflowSchedules(updated, got)
+	if err != nil {
+		t.Fatalf("SetWorkflowSchedules failed: %v", err)
+	}
+	if strings.Contains(string(resumed), pausedSchedulesAnnotation) {
+		t.Errorf("got\n%s\nexpected no paused schedules annotation", resumed)
+	}
+	if got, _ := WorkflowSchedules(resumed); len(got) != 2 || got[1].Paused {
+		t.Errorf("got %+v, expected both triggers active", got)
+	}
+
+	for _, bad := range []WorkflowSchedule{{Cron: "61 * * * *"}, {Cron: "0 2 * * *", Timezone: "Mars/Olympus"}} {
+		if _, err := SetWorkflowSchedules([]byte(spec), []WorkflowSchedule{bad}); !errors.Is(err, ErrInvalidWorkflowSpec) {
+			t.Errorf("got %v for %+v, expected ErrInvalidWorkflowSpec", err, bad)
+		}
+	}
+}
+
+func TestWorkflowScheduleNextRun(t *testing.T) {
+	after := time.Date(2024, 3, 10, 12, 0, 0, 0, time.UTC)
+	next, ok := WorkflowSchedule{Cron: "0 2 * * *", Timezone: "America/New_York"}.NextRun(after)
+	if expected := time.Date(2024, 3, 11, 6, 0, 0, 0, time.UTC); !ok || !next.Equal(expected) {
+		t.Errorf("got %v, %v, expected %v", next, ok, expected)
+	}
+	if _, ok := (WorkflowSchedule{Cron: "0 2 * * *", Paused: true}).NextRun(after); ok {
+		t.Error("expected a paused schedule to have no next run")
+	}
+	if _, ok := (WorkflowSchedule{Cron: "0 0 30 2 *"}).NextRun(after); ok {
+		t.Error("expected a schedule for February 30th to never run")
+	}
+}
+
+func TestWorkflowScheduleBetween(t *testing.T) {
+	from := time.Date(2024, 6, 3, 0, 0, 0, 0, time.UTC)
+	times := WorkflowSchedule{Cron: "0 2 * * *", Timezone: "Europe/London"}.Between(from, from.AddDate(0, 0, 2))
+	// 02:00 in London is 01:00 UTC in summer
+	if len(times) != 2 || !times[0].Equal(time.Date(2024, 6, 3, 1, 0, 0, 0, time.UTC)) {
+		t.Errorf("got %v, expected two runs at 01:00 UTC", times)
+	}
+	if times := (WorkflowSchedule{Cron: "0 2 * * *", Paused: true}).Between(from, from.AddDate(0, 0, 2)); len(times) != 0 {
+		t.Errorf("got %v, expected a paused schedule never to run", times)
+	}
+}
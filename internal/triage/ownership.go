@@ -0,0 +1,116 @@
+package triage
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path"
+	"strings"
+	"sync"
+)
+
+const UnownedTeam = "unowned"
+
+// OwnershipRule maps workflows and/or test file paths to an owning team.
+// Empty matchers match everything; the first matching rule wins.
+type OwnershipRule struct {
+	Workflow   string `json:"workflow,omitempty"`   // glob, e.g. "frontend-*"
+	PathPrefix string `json:"pathPrefix,omitempty"` // e.g. "tests/checkout/"
+	Team       string `json:"team"`
+	Owner      string `json:"owner,omitempty"` // default assignee
+}
+
+type ownershipConfig struct {
+	Rules       []OwnershipRule `json:"rules"`
+	DefaultTeam string          `json:"defaultTeam,omitempty"`
+}
+
+// Ownership resolves which team owns a failure
+type Ownership struct {
+	config ownershipConfig
+	mu     sync.RWMutex
+}
+
+// NewOwnership creates an ownership mapping, loading rules from the JSON
+// file in OWNERSHIP_FILE when set.
+func NewOwnership() (*Ownership, error) {
+	o := &Ownership{config: ownershipConfig{DefaultTeam: UnownedTeam}}
+
+	if file := os.Getenv("OWNERSHIP_FILE"); file != "" {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return o, fmt.Errorf("failed to read ownership file: %w", err)
+		}
+		if err := o.Import(data); err != nil {
+			return o, err
+		}
+	}
+
+	return o, nil
+}
+
+// Resolve returns the team and default owner for a failure
+func (o *Ownership) Resolve(workflow, filePath string) (team, owner string) {
+	o.mu.RLock()
+	defer o.mu.RUnlock()
+
+	for _, rule := range o.config.Rules {
+		if rule.Workflow != "" {
+			if ok, _ := path.Match(rule.Workflow, workflow); !ok {
+				continue
+			}
+		}
+		if rule.PathPrefix != "" && !strings.HasPrefix(filePath, rule.PathPrefix) {
+			continue
+		}
+		return rule.Team, rule.Owner
+	}
+	return o.config.DefaultTeam, ""
+}
+
+// Teams returns every team named in the ownership rules
+func (o *Ownership) Teams() []string {
+	o.mu.RLock()
+	defer o.mu.RUnlock()
+
+	seen := map[string]bool{o.config.DefaultTeam: true}
+	teams := []string{o.config.DefaultTeam}
+	for _, rule := range o.config.Rules {
+		if !seen[rule.Team] {
+			seen[rule.Team] = true
+			teams = append(teams, rule.Team)
+		}
+	}
+	return teams
+}
+
+// Export implements configsync.Section
+func (o *Ownership) Export() (json.RawMessage, error) {
+	o.mu.RLock()
+	defer o.mu.RUnlock()
+	return json.Marshal(o.config)
+}
+
+// Import implements configsync.Section
+func (o *Ownership) Import(data json.RawMessage) error {
+	var config ownershipConfig
+	if err := json.Unmarshal(data, &config); err != nil {
+		return fmt.Errorf("invalid ownership rules: %w", err)
+	}
+	for i, rule := range config.Rules {
+		if rule.Team == "" {
+			return fmt.Errorf("ownership rule %d has no team", i)
+		}
+		if _, err := path.Match(rule.Workflow, ""); err != nil {
+			return fmt.Errorf("ownership rule %d has invalid workflow pattern: %w", i, err)
+		}
+	}
+	if config.DefaultTeam == "" {
+		config.DefaultTeam = UnownedTeam
+	}
+
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.config = config
+	return nil
+}
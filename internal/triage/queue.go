@@ -0,0 +1,386 @@
+package triage
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"os"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/testkube/dashboard/internal/database"
+	"github.com/testkube/dashboard/internal/testkube"
+)
+
+// State is the triage state of a failure
+type State string
+
+const (
+	StateNew           State = "new"
+	StateInvestigating State = "investigating"
+	StateFixed         State = "fixed"
+	StateWontFix       State = "wont-fix"
+)
+
+// States lists triage states in workflow order
+var States = []State{StateNew, StateInvestigating, StateFixed, StateWontFix}
+
+// Open reports whether the item still needs attention
+func (s State) Open() bool {
+	return s == StateNew || s == StateInvestigating
+}
+
+// Valid reports whether s is a known triage state
+func (s State) Valid() bool {
+	for _, state := range States {
+		if s == state {
+			return true
+		}
+	}
+	return false
+}
+
+const (
+	// DefaultAckSLA is how long a new failure may wait before someone picks it up
+	DefaultAckSLA = 24 * time.Hour
+	// DefaultResolveSLA is how long an investigation may run before it is overdue
+	DefaultResolveSLA = 72 * time.Hour
+)
+
+// Failure is a single failed test (or failed execution without test results)
+// reported to the queue
+type Failure struct {
+	ExecutionID  string
+	WorkflowName string
+	TestName     string
+	FilePath     string
+	ErrorMessage string
+	FailedAt     time.Time
+}
+
+// Item is a failure in the triage queue. Repeated failures of the same test
+// are folded into the open item rather than creating new ones.
+type Item struct {
+	ID              string    `json:"id"`
+	WorkflowName    string    `json:"workflowName"`
+	TestName        string    `json:"testName,omitempty"`
+	FilePath        string    `json:"filePath,omitempty"`
+	ErrorMessage    string    `json:"errorMessage,omitempty"`
+	Team            string    `json:"team"`
+	Assignee        string    `json:"assignee,omitempty"`
+	State           State     `json:"state"`
+	Occurrences     int       `json:"occurrences"`
+	LastExecutionID string    `json:"lastExecutionId"`
+	CreatedAt       time.Time `json:"createdAt"`
+	UpdatedAt       time.Time `json:"updatedAt"`
+	LastSeen        time.Time `json:"lastSeen"`
+	// SLADue is when the current state's SLA expires; zero for closed items
+	SLADue time.Time `json:"slaDue,omitempty"`
+}
+
+// Breached reports whether the item is past its SLA
+func (i Item) Breached(now time.Time) bool {
+	return !i.SLADue.IsZero() && now.After(i.SLADue)
+}
+
+// Remaining returns the time left on the SLA, negative once breached
+func (i Item) Remaining(now time.Time) time.Duration {
+	if i.SLADue.IsZero() {
+		return 0
+	}
+	return i.SLADue.Sub(now)
+}
+
+// Update is a state or assignment change to an item
+type Update struct {
+	State    State   `json:"state,omitempty"`
+	Assignee *string `json:"assignee,omitempty"`
+}
+
+// Filter selects queue items
+type Filter struct {
+	Team     string
+	State    State
+	OpenOnly bool
+}
+
+// Queue holds failures awaiting triage and assigns them to owners
+type Queue struct {
+	ownership  *Ownership
+	ackSLA     time.Duration
+	resolveSLA time.Duration
+
+	items map[string]*Item
+	// open indexes open items by workflow and test so repeats are deduplicated
+	open map[string]string
+	mu   sync.RWMutex
+	now  func() time.Time
+}
+
+func NewQueue(ownership *Ownership) *Queue {
+	return &Queue{
+		ownership:  ownership,
+		ackSLA:     durationFromEnv("TRIAGE_ACK_SLA", DefaultAckSLA),
+		resolveSLA: durationFromEnv("TRIAGE_RESOLVE_SLA", DefaultResolveSLA),
+		items:      make(map[string]*Item),
+		open:       make(map[string]string),
+		now:        time.Now,
+	}
+}
+
+func durationFromEnv(key string, def time.Duration) time.Duration {
+	val := os.Getenv(key)
+	if val == "" {
+		return def
+	}
+	d, err := time.ParseDuration(val)
+	if err != nil || d <= 0 {
+		log.Printf("Warning: invalid %s %q, using %s", key, val, def)
+		return def
+	}
+	return d
+}
+
+func generateID() string {
+	bytes := make([]byte, 6)
+	rand.Read(bytes)
+	return hex.EncodeToString(bytes)
+}
+
+func failureKey(workflow, test string) string {
+	return workflow + "\x00" + test
+}
+
+// Report adds a failure to the queue, auto-assigning new items via the
+// ownership mapping. It returns the item and whether it was newly created.
+func (q *Queue) Report(f Failure) (Item, bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	seen := f.FailedAt
+	if seen.IsZero() {
+		seen = q.now()
+	}
+
+	key := failureKey(f.WorkflowName, f.TestName)
+	if id, ok := q.open[key]; ok {
+		item := q.items[id]
+		item.Occurrences++
+		item.LastExecutionID = f.ExecutionID
+		if seen.After(item.LastSeen) {
+			item.LastSeen = seen
+		}
+		if f.ErrorMessage != "" {
+			item.ErrorMessage = f.ErrorMessage
+		}
+		return *item, false
+	}
+
+	team, owner := q.ownership.Resolve(f.WorkflowName, f.FilePath)
+	now := q.now()
+	item := &Item{
+		ID:              generateID(),
+		WorkflowName:    f.WorkflowName,
+		TestName:        f.TestName,
+		FilePath:        f.FilePath,
+		ErrorMessage:    f.ErrorMessage,
+		Team:            team,
+		Assignee:        owner,
+		State:           StateNew,
+		Occurrences:     1,
+		LastExecutionID: f.ExecutionID,
+		CreatedAt:       now,
+		UpdatedAt:       now,
+		LastSeen:        seen,
+		SLADue:          now.Add(q.ackSLA),
+	}
+	q.items[item.ID] = item
+	q.open[key] = item.ID
+
+	return *item, true
+}
+
+// Update changes an item's state and/or assignee. Each state change restarts
+// the SLA timer for the new state.
+func (q *Queue) Update(id string, u Update) (Item, error) {
+	if u.State != "" && !u.State.Valid() {
+		return Item{}, fmt.Errorf("invalid state %q", u.State)
+	}
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	item, ok := q.items[id]
+	if !ok {
+		return Item{}, fmt.Errorf("triage item %s not found", id)
+	}
+
+	now := q.now()
+	if u.Assignee != nil {
+		item.Assignee = *u.Assignee
+	}
+	if u.State != "" && u.State != item.State {
+		key := failureKey(item.WorkflowName, item.TestName)
+		if u.State.Open() && !item.State.Open() {
+			// Reopening: only one open item may exist per test
+			if other, exists := q.open[key]; exists && other != item.ID {
+				return Item{}, fmt.Errorf("test already has an open triage item %s", other)
+			}
+			q.open[key] = item.ID
+		} else if !u.State.Open() {
+			delete(q.open, key)
+		}
+
+		item.State = u.State
+		switch u.State {
+		case StateNew:
+			item.SLADue = now.Add(q.ackSLA)
+		case StateInvestigating:
+			item.SLADue = now.Add(q.resolveSLA)
+		default:
+			item.SLADue = time.Time{}
+		}
+	}
+	item.UpdatedAt = now
+
+	return *item, nil
+}
+
+// Get returns a single item
+func (q *Queue) Get(id string) (Item, bool) {
+	q.mu.RLock()
+	defer q.mu.RUnlock()
+	item, ok := q.items[id]
+	if !ok {
+		return Item{}, false
+	}
+	return *item, true
+}
+
+// List returns matching items, open items with the nearest SLA first
+func (q *Queue) List(filter Filter) []Item {
+	q.mu.RLock()
+	defer q.mu.RUnlock()
+
+	var items []Item
+	for _, item := range q.items {
+		if filter.Team != "" && item.Team != filter.Team {
+			continue
+		}
+		if filter.State != "" && item.State != filter.State {
+			continue
+		}
+		if filter.OpenOnly && !item.State.Open() {
+			continue
+		}
+		items = append(items, *item)
+	}
+
+	sort.Slice(items, func(i, j int) bool {
+		a, b := items[i], items[j]
+		if a.State.Open() != b.State.Open() {
+			return a.State.Open()
+		}
+		if a.State.Open() && !a.SLADue.Equal(b.SLADue) {
+			return a.SLADue.Before(b.SLADue)
+		}
+		return a.UpdatedAt.After(b.UpdatedAt)
+	})
+
+	return items
+}
+
+// TeamSummary counts a team's items by state
+type TeamSummary struct {
+	Team     string
+	Counts   map[State]int
+	Open     int
+	Breached int
+}
+
+// Summary returns per-team counts for every team with items or ownership rules
+func (q *Queue) Summary() []TeamSummary {
+	now := q.now()
+	byTeam := make(map[string]*TeamSummary)
+	get := func(team string) *TeamSummary {
+		if s, ok := byTeam[team]; ok {
+			return s
+		}
+		s := &TeamSummary{Team: team, Counts: make(map[State]int)}
+		byTeam[team] = s
+		return s
+	}
+
+	for _, team := range q.ownership.Teams() {
+		get(team)
+	}
+
+	q.mu.RLock()
+	for _, item := range q.items {
+		s := get(item.Team)
+		s.Counts[item.State]++
+		if item.State.Open() {
+			s.Open++
+			if item.Breached(now) {
+				s.Breached++
+			}
+		}
+	}
+	q.mu.RUnlock()
+
+	summaries := make([]TeamSummary, 0, len(byTeam))
+	for _, s := range byTeam {
+		summaries = append(summaries, *s)
+	}
+	sort.Slice(summaries, func(i, j int) bool {
+		if summaries[i].Open != summaries[j].Open {
+			return summaries[i].Open > summaries[j].Open
+		}
+		return summaries[i].Team < summaries[j].Team
+	})
+	return summaries
+}
+
+// ReportExecution files every failed test case of a finished execution. A
+// failed execution without parsed test results is filed as a single
+// workflow-level failure. It returns the number of newly created items.
+func (q *Queue) ReportExecution(exec testkube.Execution, cases []database.TestCase) int {
+	if exec.Status != "failed" {
+		return 0
+	}
+
+	failedAt := exec.EndTime
+	created := 0
+	report := func(f Failure) {
+		if _, isNew := q.Report(f); isNew {
+			created++
+		}
+	}
+
+	failedCases := 0
+	for _, tc := range cases {
+		if tc.Status != "failed" {
+			continue
+		}
+		failedCases++
+		report(Failure{
+			ExecutionID:  exec.ID,
+			WorkflowName: exec.WorkflowName,
+			TestName:     tc.TestName,
+			FilePath:     tc.FilePath,
+			ErrorMessage: tc.ErrorMessage,
+			FailedAt:     failedAt,
+		})
+	}
+	if failedCases == 0 {
+		report(Failure{
+			ExecutionID:  exec.ID,
+			WorkflowName: exec.WorkflowName,
+			FailedAt:     failedAt,
+		})
+	}
+
+	return created
+}
@@ -0,0 +1,113 @@
+package triage
+
+import (
+	"testing"
+	"time"
+)
+
+func newTestQueue(t *testing.T, rules string) (*Queue, *time.Time) {
+	t.Helper()
+	ownership, err := NewOwnership()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rules != "" {
+		if err := ownership.Import([]byte(rules)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	now := time.Date(2026, 1, 5, 9, 0, 0, 0, time.UTC)
+	q := NewQueue(ownership)
+	q.now = func() time.Time { return now }
+	return q, &now
+}
+
+func TestOwnershipResolve(t *testing.T) {
+	q, _ := newTestQueue(t, `{"rules": [
+		{"workflow": "frontend-*", "pathPrefix": "tests/checkout/", "team": "payments", "owner": "alice"},
+		{"workflow": "frontend-*", "team": "web"}
+	]}`)
+
+	tests := []struct {
+		workflow, path, team, owner string
+	}{
+		{"frontend-e2e", "tests/checkout/pay.spec.ts", "payments", "alice"},
+		{"frontend-e2e", "tests/login.spec.ts", "web", ""},
+		{"backend-api", "tests/checkout/pay.spec.ts", UnownedTeam, ""},
+	}
+	for _, tt := range tests {
+		team, owner := q.ownership.Resolve(tt.workflow, tt.path)
+		if team != tt.team || owner != tt.owner {
+			t.Errorf("Resolve(%q, %q) = %q, %q; want %q, %q", tt.workflow, tt.path, team, owner, tt.team, tt.owner)
+		}
+	}
+
+	if err := q.ownership.Import([]byte(`{"rules": [{"workflow": "x"}]}`)); err == nil {
+		t.Error("expected error for rule without team")
+	}
+}
+
+func TestQueueReportDeduplicates(t *testing.T) {
+	q, _ := newTestQueue(t, `{"rules": [{"workflow": "frontend-*", "team": "web", "owner": "bob"}]}`)
+
+	first, created := q.Report(Failure{ExecutionID: "e1", WorkflowName: "frontend-e2e", TestName: "login"})
+	if !created || first.Team != "web" || first.Assignee != "bob" || first.State != StateNew {
+		t.Fatalf("unexpected first item: %+v (created=%v)", first, created)
+	}
+
+	second, created := q.Report(Failure{ExecutionID: "e2", WorkflowName: "frontend-e2e", TestName: "login"})
+	if created || second.ID != first.ID || second.Occurrences != 2 || second.LastExecutionID != "e2" {
+		t.Fatalf("expected repeat to fold into existing item, got %+v (created=%v)", second, created)
+	}
+
+	// Once fixed, a new failure opens a fresh item
+	if _, err := q.Update(first.ID, Update{State: StateFixed}); err != nil {
+		t.Fatal(err)
+	}
+	third, created := q.Report(Failure{ExecutionID: "e3", WorkflowName: "frontend-e2e", TestName: "login"})
+	if !created || third.ID == first.ID {
+		t.Fatalf("expected new item after fix, got %+v", third)
+	}
+
+	// The fixed item can't be reopened while another is open for the same test
+	if _, err := q.Update(first.ID, Update{State: StateNew}); err == nil {
+		t.Error("expected error reopening duplicate item")
+	}
+}
+
+func TestQueueSLA(t *testing.T) {
+	q, now := newTestQueue(t, "")
+	q.ackSLA = time.Hour
+	q.resolveSLA = 4 * time.Hour
+
+	item, _ := q.Report(Failure{WorkflowName: "api", TestName: "health"})
+	if !item.SLADue.Equal(now.Add(time.Hour)) {
+		t.Errorf("SLADue = %v, want ack SLA", item.SLADue)
+	}
+
+	*now = now.Add(2 * time.Hour)
+	if !item.Breached(*now) {
+		t.Error("expected item to breach ack SLA")
+	}
+	if s := q.Summary(); s[0].Team != UnownedTeam || s[0].Breached != 1 {
+		t.Errorf("unexpected summary: %+v", s)
+	}
+
+	item, err := q.Update(item.ID, Update{State: StateInvestigating})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !item.SLADue.Equal(now.Add(4*time.Hour)) || item.Breached(*now) {
+		t.Errorf("expected resolve SLA to restart, got %v", item.SLADue)
+	}
+
+	item, _ = q.Update(item.ID, Update{State: StateWontFix})
+	if !item.SLADue.IsZero() || len(q.List(Filter{OpenOnly: true})) != 0 {
+		t.Error("closed items should have no SLA and not be listed as open")
+	}
+
+	if _, err := q.Update(item.ID, Update{State: "done"}); err == nil {
+		t.Error("expected error for invalid state")
+	}
+}
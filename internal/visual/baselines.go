@@ -0,0 +1,334 @@
+package visual
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/testkube/dashboard/internal/artifacts"
+	"github.com/testkube/dashboard/internal/database"
+)
+
+const (
+	// DefaultFailRatio is the share of differing pixels above which a
+	// screenshot counts as a visual regression
+	DefaultFailRatio = 0.001
+	// maxCompared bounds the executions whose comparisons are kept; the
+	// earliest compared makes room, and is compared again if viewed
+	maxCompared = 500
+)
+
+// Verdict is the outcome of comparing a screenshot with its baseline
+type Verdict string
+
+const (
+	// VerdictNew means there is no baseline yet
+	VerdictNew Verdict = "new"
+	// VerdictMatch means the screenshot is within the threshold of its baseline
+	VerdictMatch Verdict = "match"
+	// VerdictChanged means the screenshot differs beyond the threshold and
+	// needs review
+	VerdictChanged Verdict = "changed"
+	// VerdictApproved means a reviewer accepted the screenshot as the new baseline
+	VerdictApproved Verdict = "approved"
+	// VerdictRejected means a reviewer confirmed the change is a regression
+	VerdictRejected Verdict = "rejected"
+)
+
+// Baseline is the approved screenshot for one test screenshot of a workflow
+type Baseline struct {
+	Workflow    string    `json:"workflow"`
+	Path        string    `json:"path"`
+	ExecutionID string    `json:"executionId"`
+	ApprovedAt  time.Time `json:"approvedAt"`
+	ApprovedBy  string    `json:"approvedBy,omitempty"`
+
+	image []byte
+}
+
+// Comparison is a screenshot from an execution compared with its baseline
+type Comparison struct {
+	ExecutionID string  `json:"executionId"`
+	Workflow    string  `json:"workflow"`
+	Path        string  `json:"path"`
+	Verdict     Verdict `json:"verdict"`
+	// BaselineExecutionID is the execution the baseline came from, if any
+	BaselineExecutionID string    `json:"baselineExecutionId,omitempty"`
+	DiffPixels          int       `json:"diffPixels"`
+	Ratio               float64   `json:"ratio"`
+	ComparedAt          time.Time `json:"comparedAt"`
+
+	// overlay highlights the differences; kept so it needn't be recomputed
+	overlay []byte
+}
+
+// Failed reports whether the screenshot is a visual regression
+func (c Comparison) Failed() bool {
+	return c.Verdict == VerdictChanged || c.Verdict == VerdictRejected
+}
+
+// NeedsReview reports whether the screenshot is waiting for approve/reject
+func (c Comparison) NeedsReview() bool {
+	return c.Verdict == VerdictChanged || c.Verdict == VerdictNew
+}
+
+// Store keeps screenshot baselines, saved in the database, and the latest
+// comparisons made against them
+type Store struct {
+	db          database.Database
+	failRatio   float64
+	baselines   map[string]*Baseline              // by workflow + path
+	comparisons map[string]map[string]*Comparison // by execution, then path
+	compared    []string                          // executions, earliest compared first
+	mu          sync.RWMutex
+	now         func() time.Time
+}
+
+// NewStore creates a store holding the baselines saved in db. The failure
+// threshold is read from VISUAL_FAIL_RATIO, e.g. "0.01" for 1% of pixels.
+// A store is returned even when the baselines can't be loaded.
+func NewStore(db database.Database) (*Store, error) {
+	failRatio := DefaultFailRatio
+	if val := os.Getenv("VISUAL_FAIL_RATIO"); val != "" {
+		if f, err := strconv.ParseFloat(val, 64); err == nil && f >= 0 && f < 1 {
+			failRatio = f
+		} else {
+			log.Printf("Warning: invalid VISUAL_FAIL_RATIO %q, using %g", val, DefaultFailRatio)
+		}
+	}
+
+	s := &Store{
+		db:          db,
+		failRatio:   failRatio,
+		baselines:   make(map[string]*Baseline),
+		comparisons: make(map[string]map[string]*Comparison),
+		now:         time.Now,
+	}
+	saved, err := db.GetVisualBaselines()
+	if err != nil {
+		return s, fmt.Errorf("failed to load screenshot baselines: %w", err)
+	}
+	for _, b := range saved {
+		s.baselines[baselineKey(b.Workflow, b.Path)] = &Baseline{
+			Workflow:    b.Workflow,
+			Path:        b.Path,
+			ExecutionID: b.ExecutionID,
+			ApprovedAt:  b.ApprovedAt,
+			ApprovedBy:  b.ApprovedBy,
+			image:       b.Image,
+		}
+	}
+	return s, nil
+}
+
+// FailRatio is the pixel ratio above which screenshots fail
+func (s *Store) FailRatio() float64 {
+	return s.failRatio
+}
+
+func baselineKey(workflow, path string) string {
+	return workflow + "\x00" + path
+}
+
+// Baseline returns the baseline for a workflow's screenshot and its image
+func (s *Store) Baseline(workflow, path string) (Baseline, []byte, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	b, ok := s.baselines[baselineKey(workflow, path)]
+	if !ok {
+		return Baseline{}, nil, false
+	}
+	return *b, b.image, true
+}
+
+// Baselines lists every baseline, by workflow then path
+func (s *Store) Baselines() []Baseline {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	list := make([]Baseline, 0, len(s.baselines))
+	for _, b := range s.baselines {
+		list = append(list, *b)
+	}
+	sort.Slice(list, func(i, j int) bool {
+		if list[i].Workflow != list[j].Workflow {
+			return list[i].Workflow < list[j].Workflow
+		}
+		return list[i].Path < list[j].Path
+	})
+	return list
+}
+
+// Compare checks an execution's screenshot against the baseline and records
+// the result. Screenshots without a baseline are recorded as new.
+func (s *Store) Compare(executionID, workflow, path string, image []byte) (Comparison, error) {
+	c := &Comparison{
+		ExecutionID: executionID,
+		Workflow:    workflow,
+		Path:        path,
+		Verdict:     VerdictNew,
+		ComparedAt:  s.now(),
+	}
+
+	baseline, baselineImage, ok := s.Baseline(workflow, path)
+	if ok {
+		diff, err := artifacts.DiffImages(baselineImage, image, artifacts.DefaultImageThreshold)
+		if err != nil {
+			return Comparison{}, err
+		}
+		c.BaselineExecutionID = baseline.ExecutionID
+		c.DiffPixels = diff.DiffPixels
+		c.Ratio = diff.Ratio
+		c.overlay = diff.Overlay
+		c.Verdict = VerdictMatch
+		if diff.Ratio > s.failRatio {
+			c.Verdict = VerdictChanged
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.track(executionID)
+	s.comparisons[executionID][path] = c
+	return *c, nil
+}
+
+// track starts keeping an execution's comparisons, dropping the earliest
+// compared execution's when there are too many. The caller holds the lock.
+func (s *Store) track(executionID string) {
+	if s.comparisons[executionID] != nil {
+		return
+	}
+	if len(s.compared) >= maxCompared {
+		delete(s.comparisons, s.compared[0])
+		s.compared = s.compared[1:]
+	}
+	s.comparisons[executionID] = make(map[string]*Comparison)
+	s.compared = append(s.compared, executionID)
+}
+
+// Compared reports whether an execution's screenshots have been compared
+func (s *Store) Compared(executionID string) bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	_, ok := s.comparisons[executionID]
+	return ok
+}
+
+// MarkCompared records that an execution was checked, even if it had no
+// screenshots, so it isn't checked again
+func (s *Store) MarkCompared(executionID string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.track(executionID)
+}
+
+// Comparisons returns an execution's comparisons, failures first
+func (s *Store) Comparisons(executionID string) []Comparison {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	list := make([]Comparison, 0, len(s.comparisons[executionID]))
+	for _, c := range s.comparisons[executionID] {
+		list = append(list, *c)
+	}
+	sort.Slice(list, func(i, j int) bool {
+		if list[i].Failed() != list[j].Failed() {
+			return list[i].Failed()
+		}
+		return list[i].Path < list[j].Path
+	})
+	return list
+}
+
+// Overlay returns the highlighted difference image for a comparison
+func (s *Store) Overlay(executionID, path string) ([]byte, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	c, ok := s.comparisons[executionID][path]
+	if !ok || c.overlay == nil {
+		return nil, false
+	}
+	return c.overlay, true
+}
+
+// Approve makes an execution's screenshot the baseline for its workflow,
+// saving it to the database
+func (s *Store) Approve(executionID, path string, image []byte, user string) (Comparison, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	c, ok := s.comparisons[executionID][path]
+	if !ok {
+		return Comparison{}, fmt.Errorf("screenshot %s of execution %s has not been compared", path, executionID)
+	}
+
+	baseline := &Baseline{
+		Workflow:    c.Workflow,
+		Path:        path,
+		ExecutionID: executionID,
+		ApprovedAt:  s.now(),
+		ApprovedBy:  user,
+		image:       image,
+	}
+	err := s.db.SaveVisualBaseline(database.VisualBaseline{
+		Workflow:    baseline.Workflow,
+		Path:        baseline.Path,
+		ExecutionID: baseline.ExecutionID,
+		ApprovedAt:  baseline.ApprovedAt,
+		ApprovedBy:  baseline.ApprovedBy,
+		Image:       image,
+	})
+	if err != nil {
+		return Comparison{}, fmt.Errorf("failed to save baseline: %w", err)
+	}
+	s.baselines[baselineKey(c.Workflow, path)] = baseline
+	c.Verdict = VerdictApproved
+	return *c, nil
+}
+
+// Reject confirms a changed screenshot as a regression, keeping the baseline
+func (s *Store) Reject(executionID, path string) (Comparison, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	c, ok := s.comparisons[executionID][path]
+	if !ok {
+		return Comparison{}, fmt.Errorf("screenshot %s of execution %s has not been compared", path, executionID)
+	}
+	if c.Verdict != VerdictChanged {
+		return Comparison{}, fmt.Errorf("only changed screenshots can be rejected, %s is %s", path, c.Verdict)
+	}
+	c.Verdict = VerdictRejected
+	return *c, nil
+}
+
+// Stats describes what the store holds in memory
+type Stats struct {
+	Baselines   int   `json:"baselines"`
+	Comparisons int   `json:"comparisons"`
+	Bytes       int64 `json:"bytes"` // baseline images and cached overlays
+}
+
+// Stats counts the baselines and cached comparisons
+func (s *Store) Stats() Stats {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	stats := Stats{Baselines: len(s.baselines)}
+	for _, b := range s.baselines {
+		stats.Bytes += int64(len(b.image))
+	}
+	for _, byPath := range s.comparisons {
+		stats.Comparisons += len(byPath)
+		for _, c := range byPath {
+			stats.Bytes += int64(len(c.overlay))
+		}
+	}
+	return stats
+}
@@ -0,0 +1,156 @@
+package visual
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"testing"
+	"time"
+
+	"github.com/testkube/dashboard/internal/database"
+)
+
+func screenshot(t *testing.T, boxX int) []byte {
+	t.Helper()
+	img := image.NewRGBA(image.Rect(0, 0, 100, 100))
+	for y := 0; y < 100; y++ {
+		for x := 0; x < 100; x++ {
+			c := color.RGBA{R: 255, G: 255, B: 255, A: 255}
+			if x >= boxX && x < boxX+20 && y >= 40 && y < 60 {
+				c = color.RGBA{B: 255, A: 255}
+			}
+			img.Set(x, y, c)
+		}
+	}
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func newTestStore() *Store {
+	s, _ := NewStore(database.NewMockDatabase())
+	s.now = func() time.Time { return time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC) }
+	return s
+}
+
+func TestBaselineWorkflow(t *testing.T) {
+	s := newTestStore()
+	original := screenshot(t, 10)
+
+	c, err := s.Compare("exec-1", "frontend", "home.png", original)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.Verdict != VerdictNew || !c.NeedsReview() || c.Failed() {
+		t.Fatalf("first screenshot: got %+v, want new and awaiting review", c)
+	}
+
+	if _, err := s.Approve("exec-1", "home.png", original, "alice"); err != nil {
+		t.Fatal(err)
+	}
+	baseline, _, ok := s.Baseline("frontend", "home.png")
+	if !ok || baseline.ExecutionID != "exec-1" || baseline.ApprovedBy != "alice" {
+		t.Fatalf("baseline = %+v, %v", baseline, ok)
+	}
+
+	c, _ = s.Compare("exec-2", "frontend", "home.png", screenshot(t, 10))
+	if c.Verdict != VerdictMatch || c.BaselineExecutionID != "exec-1" {
+		t.Errorf("identical screenshot: got %+v, want match", c)
+	}
+
+	c, _ = s.Compare("exec-3", "frontend", "home.png", screenshot(t, 30))
+	if c.Verdict != VerdictChanged || !c.Failed() || c.DiffPixels == 0 {
+		t.Fatalf("moved box: got %+v, want changed", c)
+	}
+	if _, ok := s.Overlay("exec-3", "home.png"); !ok {
+		t.Error("expected a diff overlay for the changed screenshot")
+	}
+
+	c, err = s.Reject("exec-3", "home.png")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.Verdict != VerdictRejected || !c.Failed() || c.NeedsReview() {
+		t.Errorf("rejected: got %+v", c)
+	}
+	if baseline, _, _ := s.Baseline("frontend", "home.png"); baseline.ExecutionID != "exec-1" {
+		t.Errorf("reject replaced the baseline with %s", baseline.ExecutionID)
+	}
+
+	if _, err := s.Reject("exec-2", "home.png"); err == nil {
+		t.Error("expected an error rejecting a matching screenshot")
+	}
+	if _, err := s.Approve("exec-9", "home.png", original, ""); err == nil {
+		t.Error("expected an error approving a screenshot that wasn't compared")
+	}
+}
+
+func TestBaselinesOutliveTheStore(t *testing.T) {
+	db := database.NewMockDatabase()
+	s, _ := NewStore(db)
+	s.Compare("exec-1", "frontend", "home.png", screenshot(t, 10))
+	if _, err := s.Approve("exec-1", "home.png", screenshot(t, 10), "alice"); err != nil {
+		t.Fatal(err)
+	}
+
+	restarted, err := NewStore(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if baseline, image, ok := restarted.Baseline("frontend", "home.png"); !ok || baseline.ApprovedBy != "alice" || len(image) == 0 {
+		t.Fatalf("baseline after restart = %+v, %v", baseline, ok)
+	}
+	if c, _ := restarted.Compare("exec-2", "frontend", "home.png", screenshot(t, 10)); c.Verdict != VerdictMatch {
+		t.Errorf("got %s, want match against the saved baseline", c.Verdict)
+	}
+}
+
+func TestComparisonsAreBounded(t *testing.T) {
+	s := newTestStore()
+	for i := 0; i <= maxCompared; i++ {
+		s.MarkCompared(fmt.Sprintf("exec-%d", i))
+	}
+	if s.Compared("exec-0") || !s.Compared("exec-1") || !s.Compared(fmt.Sprintf("exec-%d", maxCompared)) {
+		t.Error("expected the earliest compared execution to make room")
+	}
+	if len(s.comparisons) != maxCompared {
+		t.Errorf("got %d executions compared, want %d", len(s.comparisons), maxCompared)
+	}
+}
+
+func TestFailRatioThreshold(t *testing.T) {
+	s := newTestStore()
+	s.failRatio = 0.5
+	s.Compare("exec-1", "frontend", "home.png", screenshot(t, 10))
+	s.Approve("exec-1", "home.png", screenshot(t, 10), "")
+
+	// The moved box changes 8% of pixels, under the threshold
+	c, _ := s.Compare("exec-2", "frontend", "home.png", screenshot(t, 30))
+	if c.Verdict != VerdictMatch {
+		t.Errorf("got %s, want match under a 50%% threshold (ratio %.3f)", c.Verdict, c.Ratio)
+	}
+}
+
+func TestComparisonsListFailuresFirst(t *testing.T) {
+	s := newTestStore()
+	for _, path := range []string{"a.png", "b.png"} {
+		s.Compare("exec-1", "frontend", path, screenshot(t, 10))
+		s.Approve("exec-1", path, screenshot(t, 10), "")
+	}
+
+	s.Compare("exec-2", "frontend", "a.png", screenshot(t, 10))
+	s.Compare("exec-2", "frontend", "b.png", screenshot(t, 50))
+	s.MarkCompared("exec-2")
+
+	list := s.Comparisons("exec-2")
+	if len(list) != 2 || list[0].Path != "b.png" || !list[0].Failed() {
+		t.Errorf("comparisons = %+v, want b.png (changed) first", list)
+	}
+	if !s.Compared("exec-2") || s.Compared("exec-3") {
+		t.Error("Compared should only report executions that were checked")
+	}
+}
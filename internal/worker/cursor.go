@@ -0,0 +1,211 @@
+package worker
+
+import (
+	"fmt"
+	"log"
+	"sort"
+	"time"
+
+	"github.com/testkube/dashboard/internal/database"
+	"github.com/testkube/dashboard/internal/testkube"
+)
+
+const (
+	// DefaultCatchUpPages is how many pages of executions a poll reads back
+	// through at most for executions that finished while the worker was down
+	DefaultCatchUpPages = 10
+	// pollPageSize is how many executions a poll reads a page
+	pollPageSize = 100
+)
+
+// finishedAt is when an execution finished, or as near as it says
+func finishedAt(exec testkube.Execution) time.Time {
+	switch {
+	case !exec.EndTime.IsZero():
+		return exec.EndTime
+	case exec.Duration > 0:
+		return exec.StartTime.Add(exec.Duration)
+	}
+	return exec.StartTime
+}
+
+// behind reports whether a cursor has passed an execution
+func behind(cursor database.IngestCursor, exec testkube.Execution) bool {
+	return passed(cursor, finishedAt(exec), exec.ID)
+}
+
+// passed reports whether a cursor has passed the execution with an ID that
+// finished at a time
+func passed(cursor database.IngestCursor, at time.Time, id string) bool {
+	return at.Before(cursor.FinishedAt) || (at.Equal(cursor.FinishedAt) && id <= cursor.ExecutionID)
+}
+
+// ingestOrder sorts executions in the order cursors pass them
+func ingestOrder(executions []testkube.Execution) {
+	sort.SliceStable(executions, func(i, j int) bool {
+		a, b := finishedAt(executions[i]), finishedAt(executions[j])
+		if !a.Equal(b) {
+			return a.Before(b)
+		}
+		return executions[i].ID < executions[j].ID
+	})
+}
+
+// loadCursors reads the cursors stored by earlier runs, once
+func (w *Worker) loadCursors() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.cursors != nil {
+		return nil
+	}
+	cursors, err := w.db.GetIngestCursors()
+	if err != nil {
+		return fmt.Errorf("failed to load ingestion cursors: %w", err)
+	}
+	w.cursors = make(map[string]database.IngestCursor, len(cursors))
+	for _, c := range cursors {
+		w.cursors[c.Workflow] = c
+	}
+	return nil
+}
+
+// done reports whether an execution was ingested, by this run or, going by
+// its workflow's cursor, an earlier one
+func (w *Worker) done(exec testkube.Execution) bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if _, ok := w.processed[exec.ID]; ok {
+		return true
+	}
+	cursor, ok := w.cursors[exec.WorkflowName]
+	return ok && behind(cursor, exec)
+}
+
+// behindCursor reports whether an execution's workflow's cursor has passed
+// it
+func (w *Worker) behindCursor(exec testkube.Execution) bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	cursor, ok := w.cursors[exec.WorkflowName]
+	return ok && behind(cursor, exec)
+}
+
+// isHeld reports whether a workflow waits on an execution that failed to
+// ingest, which its later executions mustn't overtake
+func (w *Worker) isHeld(workflow string) bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	_, held := w.held[workflow]
+	return held
+}
+
+// hold holds back exec's workflow until the poll has ingested exec
+func (w *Worker) hold(exec testkube.Execution) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.held == nil {
+		w.held = make(map[string]string)
+	}
+	w.held[exec.WorkflowName] = exec.ID
+}
+
+// advanceCursor moves the workflow's cursor up to an ingested execution,
+// forgetting the executions of the workflow it passes, which it now
+// reports done
+func (w *Worker) advanceCursor(exec testkube.Execution) {
+	if err := w.loadCursors(); err != nil {
+		log.Printf("Worker: not saving the ingestion cursor of %s: %v", exec.WorkflowName, err)
+		return
+	}
+	w.mu.Lock()
+	cursor, ok := w.cursors[exec.WorkflowName]
+	if ok && behind(cursor, exec) {
+		w.mu.Unlock()
+		return
+	}
+	cursor = database.IngestCursor{Workflow: exec.WorkflowName, FinishedAt: finishedAt(exec), ExecutionID: exec.ID, UpdatedAt: time.Now()}
+	w.cursors[exec.WorkflowName] = cursor
+	for id, p := range w.processed {
+		if p.workflow == exec.WorkflowName && passed(cursor, p.finishedAt, id) {
+			delete(w.processed, id)
+		}
+	}
+	w.mu.Unlock()
+
+	if err := w.db.SaveIngestCursor(cursor); err != nil {
+		log.Printf("Worker: error saving the ingestion cursor of %s: %v", exec.WorkflowName, err)
+	}
+}
+
+// resumePoint is when the cursors last moved to: executions started since
+// may have finished while the worker was down
+func (w *Worker) resumePoint() time.Time {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	var latest time.Time
+	for _, c := range w.cursors {
+		if c.FinishedAt.After(latest) {
+			latest = c.FinishedAt
+		}
+	}
+	return latest
+}
+
+// listExecutions reads the newest page of executions, and the pages after
+// it while they may hold executions that finished since the cursors last
+// moved, up to WORKER_CATCHUP_PAGES
+func (w *Worker) listExecutions() ([]testkube.Execution, error) {
+	resume := w.resumePoint()
+	seen := make(map[string]bool)
+	var executions []testkube.Execution
+	for page := 1; page <= w.catchUpPages; page++ {
+		list, err := w.api.GetExecutions(testkube.ListOptions{Page: page, PageSize: pollPageSize})
+		if err != nil {
+			if page == 1 {
+				return nil, err
+			}
+			log.Printf("Worker: error getting page %d of executions, catching up on the rest next poll: %v", page, err)
+			break
+		}
+		// Executions started since the last page shift the pages along
+		for _, exec := range list {
+			if !seen[exec.ID] {
+				seen[exec.ID] = true
+				executions = append(executions, exec)
+			}
+		}
+		if len(list) < pollPageSize || resume.IsZero() || list[len(list)-1].StartTime.Before(resume) {
+			break
+		}
+	}
+	return executions, nil
+}
+
+// ResetCursor moves a workflow's cursor to a time, or to now for a zero
+// time. Its executions that finished after it are ingested on the next
+// poll, again if they were before; those that finished before it are
+// skipped, including any holding the workflow back.
+func (w *Worker) ResetCursor(workflow string, to time.Time) error {
+	if err := w.loadCursors(); err != nil {
+		return err
+	}
+	if to.IsZero() {
+		to = time.Now()
+	}
+	cursor := database.IngestCursor{Workflow: workflow, FinishedAt: to, UpdatedAt: time.Now()}
+	if err := w.db.SaveIngestCursor(cursor); err != nil {
+		return fmt.Errorf("failed to save the ingestion cursor: %w", err)
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.cursors[workflow] = cursor
+	delete(w.held, workflow)
+	for id, p := range w.processed {
+		if p.workflow == workflow {
+			delete(w.processed, id)
+		}
+	}
+	log.Printf("Worker: reset the ingestion cursor of %s to %s", workflow, to.Format(time.RFC3339))
+	return nil
+}
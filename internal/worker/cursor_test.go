@@ -0,0 +1,205 @@
+package worker
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/testkube/dashboard/internal/database"
+	"github.com/testkube/dashboard/internal/testkube"
+)
+
+// historyClient lists a fixed history of passed executions, newest first,
+// without artifacts, failing to list the artifacts of those in failing
+type historyClient struct {
+	*testkube.MockClient
+	executions []testkube.Execution
+	failing    map[string]bool
+}
+
+func newHistoryClient(start time.Time, workflows ...string) *historyClient {
+	c := &historyClient{MockClient: testkube.NewMockClient(), failing: map[string]bool{}}
+	for i, wf := range workflows {
+		c.add(fmt.Sprintf("e-%03d", i), wf, start.Add(time.Duration(i)*time.Minute))
+	}
+	return c
+}
+
+func (c *historyClient) add(id, workflow string, started time.Time) {
+	exec := testkube.Execution{ID: id, WorkflowName: workflow, Status: "passed", StartTime: started, EndTime: started.Add(30 * time.Second)}
+	c.executions = append([]testkube.Execution{exec}, c.executions...)
+}
+
+func (c *historyClient) GetExecutions(opts testkube.ListOptions) ([]testkube.Execution, error) {
+	page := max(opts.Page, 1)
+	start := min((page-1)*opts.PageSize, len(c.executions))
+	end := min(start+opts.PageSize, len(c.executions))
+	return c.executions[start:end], nil
+}
+
+func (c *historyClient) GetArtifacts(executionID string) ([]testkube.Artifact, error) {
+	if c.failing[executionID] {
+		return nil, fmt.Errorf("artifact storage unavailable")
+	}
+	return nil, nil
+}
+
+func ingested(db *database.MockDatabase) map[string]int {
+	executions, _, _ := db.QueryExecutions(database.ExecutionQuery{})
+	counts := make(map[string]int)
+	for _, e := range executions {
+		counts[e.ID]++
+	}
+	return counts
+}
+
+func TestWorker_ResumesFromCursors(t *testing.T) {
+	start := time.Now().Add(-time.Hour)
+	api := newHistoryClient(start, "api", "web", "api")
+	db := database.NewMockDatabase()
+	NewWorker(api, db).poll()
+
+	cursors, _ := db.GetIngestCursors()
+	if len(cursors) != 2 || cursors[0].Workflow != "api" || cursors[0].ExecutionID != "e-002" {
+		t.Fatalf("unexpected cursors: %+v", cursors)
+	}
+
+	// The restarted worker ingests what finished while it was down, once
+	api.add("e-003", "web", start.Add(10*time.Minute))
+	NewWorker(api, db).poll()
+	counts := ingested(db)
+	if len(counts) != 4 {
+		t.Errorf("got %d executions ingested, expected 4: %v", len(counts), counts)
+	}
+	for id, n := range counts {
+		if n != 1 {
+			t.Errorf("%s ingested %d times, expected once", id, n)
+		}
+	}
+}
+
+func TestWorker_FailureHoldsWorkflowBack(t *testing.T) {
+	api := newHistoryClient(time.Now().Add(-time.Hour), "api", "api", "web")
+	api.failing["e-000"] = true
+	db := database.NewMockDatabase()
+	w := NewWorker(api, db)
+	w.poll()
+
+	counts := ingested(db)
+	if counts["e-001"] != 0 || counts["e-002"] != 1 {
+		t.Errorf("expected only the other workflow's execution to be ingested, got %v", counts)
+	}
+	stats := w.Stats()
+	if stats.Held["api"] != "e-000" || stats.Pending != 2 {
+		t.Errorf("expected api to be held back by e-000 with 2 pending: %+v", stats)
+	}
+
+	api.failing["e-000"] = false
+	w.poll()
+	if counts := ingested(db); counts["e-000"] != 1 || counts["e-001"] != 1 {
+		t.Errorf("expected the held executions to be ingested in order, got %v", counts)
+	}
+	if stats := w.Stats(); stats.Held != nil || stats.Pending != 0 {
+		t.Errorf("expected nothing held or pending: %+v", stats)
+	}
+}
+
+func TestWorker_ResetCursor(t *testing.T) {
+	start := time.Now().Add(-time.Hour)
+	api := newHistoryClient(start, "api", "api")
+	api.failing["e-000"] = true
+	db := database.NewMockDatabase()
+	w := NewWorker(api, db)
+	w.poll()
+
+	// Skipping past the execution that keeps failing lets the rest through
+	if err := w.ResetCursor("api", start.Add(time.Minute)); err != nil {
+		t.Fatalf("ResetCursor failed: %v", err)
+	}
+	w.poll()
+	if counts := ingested(db); counts["e-000"] != 0 || counts["e-001"] != 1 {
+		t.Errorf("expected only e-001 to be ingested, got %v", counts)
+	}
+
+	// Resetting to an earlier time ingests again what finished since
+	api.failing["e-000"] = false
+	if err := w.ResetCursor("api", start.Add(-time.Minute)); err != nil {
+		t.Fatalf("ResetCursor failed: %v", err)
+	}
+	w.poll()
+	if counts := ingested(db); counts["e-000"] != 1 || counts["e-001"] != 2 {
+		t.Errorf("expected both executions to be ingested again, got %v", counts)
+	}
+}
+
+func TestWorker_CatchesUpAcrossPages(t *testing.T) {
+	start := time.Now().Add(-24 * time.Hour)
+	api := newHistoryClient(start, "api")
+	db := database.NewMockDatabase()
+	NewWorker(api, db).poll()
+
+	// More executions finished while the worker was down than fit a page
+	for i := 1; i <= pollPageSize+20; i++ {
+		api.add(fmt.Sprintf("e-%03d", i), "api", start.Add(time.Duration(i)*time.Minute))
+	}
+	NewWorker(api, db).poll()
+	if counts := ingested(db); len(counts) != pollPageSize+21 {
+		t.Errorf("got %d executions ingested, expected %d", len(counts), pollPageSize+21)
+	}
+}
+
+func TestWorker_WatchedFailureHoldsWorkflowBack(t *testing.T) {
+	start := time.Now().Add(-time.Hour)
+	api := newHistoryClient(start)
+	api.add("e-000", "api", start)
+	api.add("e-001", "api", start.Add(time.Minute))
+	api.failing["e-000"] = true
+	db := database.NewMockDatabase()
+	w := NewWorker(api, db)
+
+	// The watch delivers the failing execution, then a later one that
+	// mustn't move the cursor past it
+	w.ingestWatched(api.executions[1])
+	w.ingestWatched(api.executions[0])
+	if counts := ingested(db); len(counts) != 0 {
+		t.Errorf("expected nothing ingested while api is held, got %v", counts)
+	}
+	if stats := w.Stats(); stats.Held["api"] != "e-000" {
+		t.Errorf("expected api to be held back by e-000: %+v", stats)
+	}
+
+	api.failing["e-000"] = false
+	w.poll()
+	if counts := ingested(db); counts["e-000"] != 1 || counts["e-001"] != 1 {
+		t.Errorf("expected the poll to ingest both executions, got %v", counts)
+	}
+	if stats := w.Stats(); stats.Held != nil {
+		t.Errorf("expected nothing held: %+v", stats)
+	}
+}
+
+func TestWorker_WatchedExecutionsFinishingOutOfOrder(t *testing.T) {
+	start := time.Now().Add(-time.Hour)
+	api := newHistoryClient(start)
+	// a started first but finished after b, as parallel runs may
+	a := testkube.Execution{ID: "e-a", WorkflowName: "api", Status: "passed", StartTime: start, EndTime: start.Add(10 * time.Minute)}
+	b := testkube.Execution{ID: "e-b", WorkflowName: "api", Status: "passed", StartTime: start.Add(time.Minute), EndTime: start.Add(2 * time.Minute)}
+	api.executions = []testkube.Execution{b, a}
+	db := database.NewMockDatabase()
+	w := NewWorker(api, db)
+
+	// The watch reports them in the order they started
+	w.ingestWatched(a)
+	w.ingestWatched(b)
+	w.poll()
+	if counts := ingested(db); counts["e-a"] != 1 || counts["e-b"] != 1 {
+		t.Errorf("expected both executions ingested once, got %v", counts)
+	}
+	cursors, _ := db.GetIngestCursors()
+	if len(cursors) != 1 || cursors[0].ExecutionID != "e-a" {
+		t.Errorf("expected the cursor at the last to finish, got %+v", cursors)
+	}
+	if stats := w.Stats(); stats.Processed != 2 || len(w.processed) != 0 {
+		t.Errorf("expected 2 processed and none kept once the cursor passed them, got %d and %v", stats.Processed, w.processed)
+	}
+}
@@ -0,0 +1,64 @@
+package worker
+
+import (
+	"log"
+	"os"
+	"strings"
+
+	"github.com/testkube/dashboard/internal/testkube"
+)
+
+// DefaultInheritedLabels are the workflow labels copied onto ingested
+// executions unless WORKER_INHERIT_LABELS says otherwise
+var DefaultInheritedLabels = []string{"team", "component", "criticality"}
+
+// inheritedLabelsFromEnv reads the comma-separated WORKER_INHERIT_LABELS;
+// set but empty, no labels are inherited
+func inheritedLabelsFromEnv() []string {
+	val, ok := os.LookupEnv("WORKER_INHERIT_LABELS")
+	if !ok {
+		return DefaultInheritedLabels
+	}
+	var keys []string
+	for _, key := range strings.Split(val, ",") {
+		if key = strings.TrimSpace(key); key != "" {
+			keys = append(keys, key)
+		}
+	}
+	return keys
+}
+
+// workflowOf returns an execution's workflow, or nil when it can't be
+// read, as when it was deleted since it ran
+func (w *Worker) workflowOf(exec testkube.Execution) *testkube.Workflow {
+	if exec.WorkflowName == "" {
+		return nil
+	}
+	workflow, err := w.api.GetWorkflow(exec.WorkflowName)
+	if err != nil {
+		log.Printf("Worker: no workflow for execution %s: %v", exec.ID, err)
+		return nil
+	}
+	return workflow
+}
+
+// inheritLabels copies the inherited labels from an execution's workflow
+// onto it, so analytics can slice by them even when Testkube doesn't tag
+// executions. A tag the run set itself overrides the workflow's label.
+func (w *Worker) inheritLabels(exec testkube.Execution, workflow *testkube.Workflow) testkube.Execution {
+	if len(w.inherited) == 0 || workflow == nil {
+		return exec
+	}
+
+	labels := make(map[string]string, len(exec.Labels)+len(w.inherited))
+	for _, key := range w.inherited {
+		if value, ok := workflow.Labels[key]; ok {
+			labels[key] = value
+		}
+	}
+	for key, value := range exec.Labels {
+		labels[key] = value
+	}
+	exec.Labels = labels
+	return exec
+}
@@ -0,0 +1,92 @@
+package worker
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"sync"
+)
+
+// parserCounts are the outcomes of one parser's attempts
+type parserCounts struct {
+	succeeded    int64
+	failed       int64
+	deadLettered int64
+}
+
+// metrics counts the worker's ingestion outcomes for Prometheus
+type metrics struct {
+	executions int64
+	parsers    map[string]*parserCounts
+	mu         sync.Mutex
+}
+
+func newMetrics() metrics {
+	return metrics{parsers: make(map[string]*parserCounts)}
+}
+
+// counts must be called with m.mu held
+func (m *metrics) counts(parser string) *parserCounts {
+	c, ok := m.parsers[parser]
+	if !ok {
+		c = &parserCounts{}
+		m.parsers[parser] = c
+	}
+	return c
+}
+
+func (m *metrics) parsed(parser string, ok bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if ok {
+		m.counts(parser).succeeded++
+	} else {
+		m.counts(parser).failed++
+	}
+}
+
+func (m *metrics) deadLettered(parser string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.counts(parser).deadLettered++
+}
+
+func (m *metrics) ingested() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.executions++
+}
+
+func (m *metrics) write(out io.Writer, stats Stats) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	// Every known parser is listed, so rates work before its first failure
+	for _, p := range resultParsers {
+		m.counts(p.name)
+	}
+	names := make([]string, 0, len(m.parsers))
+	for name := range m.parsers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	fmt.Fprintln(out, "# HELP testkube_dashboard_worker_artifacts_parsed_total Result artifacts parsed by the ingestion worker, by parser and outcome.")
+	fmt.Fprintln(out, "# TYPE testkube_dashboard_worker_artifacts_parsed_total counter")
+	for _, name := range names {
+		c := m.parsers[name]
+		fmt.Fprintf(out, "testkube_dashboard_worker_artifacts_parsed_total{parser=%q,result=\"success\"} %d\n", name, c.succeeded)
+		fmt.Fprintf(out, "testkube_dashboard_worker_artifacts_parsed_total{parser=%q,result=\"failure\"} %d\n", name, c.failed)
+	}
+	fmt.Fprintln(out, "# HELP testkube_dashboard_worker_artifacts_dead_lettered_total Result artifacts moved to the dead-letter table after repeated parse failures.")
+	fmt.Fprintln(out, "# TYPE testkube_dashboard_worker_artifacts_dead_lettered_total counter")
+	for _, name := range names {
+		fmt.Fprintf(out, "testkube_dashboard_worker_artifacts_dead_lettered_total{parser=%q} %d\n", name, m.parsers[name].deadLettered)
+	}
+	fmt.Fprintln(out, "# HELP testkube_dashboard_worker_executions_ingested_total Executions ingested since the worker started.")
+	fmt.Fprintln(out, "# TYPE testkube_dashboard_worker_executions_ingested_total counter")
+	fmt.Fprintf(out, "testkube_dashboard_worker_executions_ingested_total %d\n", m.executions)
+	fmt.Fprintln(out, "# HELP testkube_dashboard_worker_pending_executions Finished executions from the last poll not yet ingested.")
+	fmt.Fprintln(out, "# TYPE testkube_dashboard_worker_pending_executions gauge")
+	fmt.Fprintf(out, "testkube_dashboard_worker_pending_executions %d\n", stats.Pending)
+}
@@ -0,0 +1,532 @@
+package worker
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/testkube/dashboard/internal/artifacts"
+	"github.com/testkube/dashboard/internal/database"
+	"github.com/testkube/dashboard/internal/parsers"
+	"github.com/testkube/dashboard/internal/testkube"
+)
+
+const (
+	DefaultPollInterval = 1 * time.Minute
+	// DefaultMaxParseAttempts is how many polls an artifact may fail to parse
+	// in before it moves to the dead-letter table
+	DefaultMaxParseAttempts = 3
+	// Artifacts larger than this are not downloaded for parsing or hashed
+	// for the artifact manifest
+	maxParseSize = 50 * 1024 * 1024
+)
+
+// resultParser turns a structured result artifact into test cases
+type resultParser struct {
+	name   string
+	detect func(name string, data []byte) bool
+	parse  func(executionID string, data []byte) ([]database.TestCase, error)
+}
+
+var resultParsers = []resultParser{
+	{
+		name: "playwright",
+		detect: func(name string, data []byte) bool {
+			return strings.HasSuffix(name, ".json") && parsers.IsPlaywrightReport(data)
+		},
+		parse: parsers.ParsePlaywright,
+	},
+}
+
+// detectParser returns the one of candidates for an artifact, if it holds
+// results
+func detectParser(candidates []resultParser, name string, data []byte) (resultParser, bool) {
+	for _, p := range candidates {
+		if p.detect(name, data) {
+			return p, true
+		}
+	}
+	return resultParser{}, false
+}
+
+// parsersFor returns the parsers to try on a workflow's artifacts: the one
+// its type names, or every parser when it names none or its workflow is
+// unknown
+func (w *Worker) parsersFor(workflow *testkube.Workflow) []resultParser {
+	if workflow == nil {
+		return resultParsers
+	}
+	name := w.types.Get(workflow.Type).Parser
+	if name == "" {
+		return resultParsers
+	}
+	for _, p := range resultParsers {
+		if p.name == name {
+			return []resultParser{p}
+		}
+	}
+	log.Printf("Worker: workflow type %s names unknown parser %q, trying every parser", workflow.Type, name)
+	return resultParsers
+}
+
+// Listener is notified after an execution and its test cases are stored
+type Listener interface {
+	ExecutionIngested(exec testkube.Execution, cases []database.TestCase)
+}
+
+// Worker ingests finished executions into the database by downloading and
+// parsing their structured result artifacts. Each workflow's executions are
+// ingested in the order they finished, and its cursor is stored as they
+// are, so a restarted worker resumes where it left off.
+type Worker struct {
+	api          testkube.Client
+	db           database.Database
+	interval     time.Duration
+	catchUpPages int
+
+	listeners []Listener
+	// processed holds the executions this run ingested that their
+	// workflows' cursors haven't passed yet
+	processed map[string]processedExecution
+	// cursors are the workflows' ingestion cursors, nil until loaded, and
+	// held the workflows waiting on an execution that failed to ingest
+	cursors map[string]database.IngestCursor
+	held    map[string]string
+	stats   Stats
+	// maxAttempts is how many times an artifact may fail to parse before it
+	// is dead-lettered, and attempts counts the failures so far
+	maxAttempts int
+	attempts    map[string]int
+	// inherited are the workflow labels copied onto each execution
+	inherited []string
+	metrics   metrics
+	// types names the parser each workflow type's artifacts are read with
+	types *testkube.TypeRegistry
+	mu    sync.Mutex
+}
+
+// Stats describes the worker's progress, for the admin panel
+type Stats struct {
+	Interval  time.Duration `json:"interval"`
+	Processed int           `json:"processed"`
+	// Pending is the finished executions from the last poll not yet ingested
+	Pending   int       `json:"pending"`
+	LastPoll  time.Time `json:"lastPoll,omitempty"`
+	LastError string    `json:"lastError,omitempty"`
+	// Held maps the workflows whose later executions wait on one that failed
+	// to ingest to that execution
+	Held map[string]string `json:"held,omitempty"`
+}
+
+func NewWorker(api testkube.Client, db database.Database) *Worker {
+	interval := DefaultPollInterval
+	if val := os.Getenv("WORKER_POLL_INTERVAL"); val != "" {
+		if d, err := time.ParseDuration(val); err == nil && d > 0 {
+			interval = d
+		} else {
+			log.Printf("Warning: invalid WORKER_POLL_INTERVAL %q, using %s", val, interval)
+		}
+	}
+
+	maxAttempts := DefaultMaxParseAttempts
+	if val := os.Getenv("WORKER_MAX_PARSE_ATTEMPTS"); val != "" {
+		if n, err := strconv.Atoi(val); err == nil && n > 0 {
+			maxAttempts = n
+		} else {
+			log.Printf("Warning: invalid WORKER_MAX_PARSE_ATTEMPTS %q, using %d", val, maxAttempts)
+		}
+	}
+
+	catchUpPages := DefaultCatchUpPages
+	if val := os.Getenv("WORKER_CATCHUP_PAGES"); val != "" {
+		if n, err := strconv.Atoi(val); err == nil && n > 0 {
+			catchUpPages = n
+		} else {
+			log.Printf("Warning: invalid WORKER_CATCHUP_PAGES %q, using %d", val, catchUpPages)
+		}
+	}
+
+	return &Worker{
+		api:          api,
+		db:           db,
+		interval:     interval,
+		catchUpPages: catchUpPages,
+		processed:    make(map[string]processedExecution),
+		maxAttempts:  maxAttempts,
+		attempts:     make(map[string]int),
+		inherited:    inheritedLabelsFromEnv(),
+		metrics:      newMetrics(),
+		types:        testkube.DefaultTypes,
+	}
+}
+
+// AddListener registers l to be notified of every ingested execution. It must
+// be called before Run.
+func (w *Worker) AddListener(l Listener) {
+	w.listeners = append(w.listeners, l)
+}
+
+// Run ingests executions as they finish, and polls for finished executions
+// the watch missed, until ctx is cancelled
+func (w *Worker) Run(ctx context.Context) {
+	log.Printf("Ingestion worker started (interval %s)", w.interval)
+
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+
+	events, err := w.api.WatchExecutions(ctx)
+	if err != nil {
+		log.Printf("Worker: not watching executions, polling only: %v", err)
+	}
+
+	w.poll()
+	for {
+		select {
+		case <-ctx.Done():
+			log.Println("Ingestion worker stopped.")
+			return
+		case <-ticker.C:
+			w.poll()
+		case event, ok := <-events:
+			if !ok {
+				events = nil
+				continue
+			}
+			w.ingestWatched(event.Execution)
+		}
+	}
+}
+
+// ingestWatched ingests an execution the watch saw finish. The watch sees
+// executions in the order they started, so only the poll, which ingests
+// them in the order they finished, moves the cursors past them. A held
+// workflow's executions are left to the poll, and one that fails holds its
+// workflow back until then.
+func (w *Worker) ingestWatched(exec testkube.Execution) {
+	if !isFinished(exec) || w.done(exec) || w.isHeld(exec.WorkflowName) {
+		return
+	}
+	if err := w.ingest(exec); err != nil {
+		w.hold(exec)
+	}
+}
+
+// poll ingests the finished executions not yet ingested, oldest first. An
+// execution that fails holds back the rest of its workflow until the next
+// poll, so its cursor never passes an execution that wasn't ingested.
+func (w *Worker) poll() {
+	if err := w.loadCursors(); err != nil {
+		log.Printf("Worker: %v", err)
+		w.recordPoll(w.Stats().Pending, err)
+		return
+	}
+	executions, err := w.listExecutions()
+	if err != nil {
+		log.Printf("Worker: error getting executions: %v", err)
+		w.recordPoll(w.Stats().Pending, err)
+		return
+	}
+
+	// Executions the watch ingested are still passed in order, to move
+	// their workflows' cursors
+	var pending []testkube.Execution
+	waiting := 0
+	for _, exec := range executions {
+		if isFinished(exec) && !w.behindCursor(exec) {
+			pending = append(pending, exec)
+			if !w.isProcessed(exec.ID) {
+				waiting++
+			}
+		}
+	}
+	ingestOrder(pending)
+	w.recordPoll(waiting, nil)
+
+	held := make(map[string]string)
+	var remaining int
+	var lastErr error
+	for _, exec := range pending {
+		ingested := w.isProcessed(exec.ID)
+		if _, ok := held[exec.WorkflowName]; ok {
+			if !ingested {
+				remaining++
+			}
+			continue
+		}
+		if !ingested {
+			if err := w.ingest(exec); err != nil {
+				held[exec.WorkflowName] = exec.ID
+				remaining++
+				lastErr = fmt.Errorf("execution %s: %w", exec.ID, err)
+				continue
+			}
+		}
+		w.advanceCursor(exec)
+	}
+	w.mu.Lock()
+	w.held = held
+	w.mu.Unlock()
+	if waiting > 0 {
+		w.recordPoll(remaining, lastErr)
+	}
+}
+
+// ingest processes a finished execution, logging the outcome
+func (w *Worker) ingest(exec testkube.Execution) error {
+	count, err := w.ProcessExecution(exec)
+	if err != nil {
+		log.Printf("Worker: error processing execution %s: %v", exec.ID, err)
+		return err
+	}
+	if count > 0 {
+		log.Printf("Worker: ingested %d test cases from execution %s", count, exec.ID)
+	}
+	return nil
+}
+
+// recordPoll updates the stats with the executions still waiting to be
+// ingested and the latest error
+func (w *Worker) recordPoll(pending int, err error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.stats.Pending = pending
+	w.stats.LastPoll = time.Now()
+	w.stats.LastError = ""
+	if err != nil {
+		w.stats.LastError = err.Error()
+	}
+}
+
+// Stats returns the worker's progress
+func (w *Worker) Stats() Stats {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	stats := w.stats
+	stats.Interval = w.interval
+	if len(w.held) > 0 {
+		stats.Held = make(map[string]string, len(w.held))
+		for workflow, id := range w.held {
+			stats.Held[workflow] = id
+		}
+	}
+	return stats
+}
+
+// ProcessExecution stores the execution, with the labels it inherits from
+// its workflow and its outcome, a manifest of its artifacts with their
+// checksums and any test cases parsed from them, returning the number of
+// test cases recorded. An artifact that fails to parse fails the execution,
+// to be retried on the next poll, until it has failed
+// WORKER_MAX_PARSE_ATTEMPTS times; then it moves to the dead-letter table
+// and the rest of the execution is ingested without it.
+func (w *Worker) ProcessExecution(exec testkube.Execution) (int, error) {
+	list, err := w.api.GetArtifacts(exec.ID)
+	if err != nil {
+		return 0, fmt.Errorf("failed to list artifacts: %w", err)
+	}
+
+	workflow := w.workflowOf(exec)
+	candidates := w.parsersFor(workflow)
+	var cases []database.TestCase
+	manifest := make([]database.ArtifactRecord, 0, len(list))
+	now := time.Now()
+	for _, artifact := range list {
+		record := database.ArtifactRecord{
+			ExecutionID: exec.ID,
+			Name:        artifact.Name,
+			Path:        artifact.Path,
+			Size:        artifact.Size,
+			RecordedAt:  now,
+		}
+		if artifact.Size > maxParseSize {
+			manifest = append(manifest, record)
+			continue
+		}
+
+		data, err := w.api.DownloadArtifact(exec.ID, artifact.Path)
+		if err != nil {
+			return 0, fmt.Errorf("failed to download %s: %w", artifact.Path, err)
+		}
+		record.SHA256 = artifacts.Checksum(data)
+		manifest = append(manifest, record)
+
+		p, ok := detectParser(candidates, artifact.Name, data)
+		if !ok {
+			continue
+		}
+		parsed, err := p.parse(exec.ID, data)
+		if err != nil {
+			w.metrics.parsed(p.name, false)
+			if !w.parseFailed(exec, artifact.Path, p.name, err) {
+				return 0, fmt.Errorf("failed to parse %s: %w", artifact.Path, err)
+			}
+			continue
+		}
+		w.metrics.parsed(p.name, true)
+		cases = append(cases, parsed...)
+	}
+
+	exec = w.classifyOutcome(w.inheritLabels(exec, workflow))
+	if err := w.db.InsertExecution(exec); err != nil {
+		return 0, fmt.Errorf("failed to store execution: %w", err)
+	}
+	for _, tc := range cases {
+		if err := w.db.InsertTestCase(tc); err != nil {
+			return 0, fmt.Errorf("failed to store test case: %w", err)
+		}
+	}
+	if err := w.db.InsertTestSuites(exec.ID, parsers.BuildSuites(exec.ID, cases)); err != nil {
+		return 0, fmt.Errorf("failed to store test suites: %w", err)
+	}
+	if err := w.db.InsertArtifactManifest(exec.ID, manifest); err != nil {
+		return 0, fmt.Errorf("failed to store artifact manifest: %w", err)
+	}
+
+	w.markProcessed(exec)
+	w.metrics.ingested()
+	for _, l := range w.listeners {
+		l.ExecutionIngested(exec, cases)
+	}
+	return len(cases), nil
+}
+
+// parseFailed counts a failure to parse an artifact, and moves the artifact
+// to the dead-letter table once it has failed too often, reporting whether
+// it did
+func (w *Worker) parseFailed(exec testkube.Execution, path, parser string, parseErr error) bool {
+	key := exec.ID + "\x00" + path
+	w.mu.Lock()
+	w.attempts[key]++
+	attempts := w.attempts[key]
+	w.mu.Unlock()
+	if attempts < w.maxAttempts {
+		return false
+	}
+
+	now := time.Now()
+	letter := database.DeadLetter{
+		ExecutionID:  exec.ID,
+		WorkflowName: exec.WorkflowName,
+		Path:         path,
+		Parser:       parser,
+		Error:        parseErr.Error(),
+		Attempts:     attempts,
+		FirstFailed:  now,
+		LastFailed:   now,
+	}
+	if err := w.db.SaveDeadLetter(letter); err != nil {
+		log.Printf("Worker: error dead-lettering %s of execution %s: %v", path, exec.ID, err)
+		return false
+	}
+	log.Printf("Worker: gave up parsing %s of execution %s after %d attempts: %v", path, exec.ID, attempts, parseErr)
+
+	w.mu.Lock()
+	delete(w.attempts, key)
+	w.mu.Unlock()
+	w.metrics.deadLettered(parser)
+	return true
+}
+
+// RetryDeadLetter parses a dead-lettered artifact again, e.g. after a parser
+// fix, with the parser its workflow's type names, storing its test cases and
+// removing it from the dead-letter table when it succeeds. It returns the
+// number of test cases recorded.
+func (w *Worker) RetryDeadLetter(letter database.DeadLetter) (int, error) {
+	data, err := w.api.DownloadArtifact(letter.ExecutionID, letter.Path)
+	if err != nil {
+		return 0, fmt.Errorf("failed to download %s: %w", letter.Path, err)
+	}
+
+	workflow := w.workflowOf(testkube.Execution{ID: letter.ExecutionID, WorkflowName: letter.WorkflowName})
+	p, ok := detectParser(w.parsersFor(workflow), letter.Path, data)
+	if !ok {
+		return 0, fmt.Errorf("no parser recognizes %s", letter.Path)
+	}
+	cases, err := p.parse(letter.ExecutionID, data)
+	if err != nil {
+		w.metrics.parsed(p.name, false)
+		letter.Parser = p.name
+		letter.Error = err.Error()
+		letter.Attempts++
+		letter.LastFailed = time.Now()
+		if saveErr := w.db.SaveDeadLetter(letter); saveErr != nil {
+			log.Printf("Worker: error updating dead letter %s: %v", letter.Path, saveErr)
+		}
+		return 0, fmt.Errorf("failed to parse %s: %w", letter.Path, err)
+	}
+	w.metrics.parsed(p.name, true)
+
+	for _, tc := range cases {
+		if err := w.db.InsertTestCase(tc); err != nil {
+			return 0, fmt.Errorf("failed to store test case: %w", err)
+		}
+	}
+	// The execution's other artifacts may have suites of their own
+	stored, err := w.db.GetExecutionMetrics(letter.ExecutionID)
+	if err != nil {
+		return 0, fmt.Errorf("failed to read test cases: %w", err)
+	}
+	if err := w.db.InsertTestSuites(letter.ExecutionID, parsers.BuildSuites(letter.ExecutionID, stored)); err != nil {
+		return 0, fmt.Errorf("failed to store test suites: %w", err)
+	}
+	if err := w.db.DeleteDeadLetter(letter.ExecutionID, letter.Path); err != nil {
+		return 0, fmt.Errorf("failed to remove dead letter: %w", err)
+	}
+	return len(cases), nil
+}
+
+// WriteMetrics writes the worker's counters in the Prometheus text format
+func (w *Worker) WriteMetrics(out io.Writer) {
+	w.metrics.write(out, w.Stats())
+}
+
+// processedExecution is where an ingested execution falls in its
+// workflow's order
+type processedExecution struct {
+	workflow   string
+	finishedAt time.Time
+}
+
+func (w *Worker) isProcessed(id string) bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	_, ok := w.processed[id]
+	return ok
+}
+
+func (w *Worker) markProcessed(exec testkube.Execution) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.processed[exec.ID] = processedExecution{workflow: exec.WorkflowName, finishedAt: finishedAt(exec)}
+	w.stats.Processed++
+}
+
+// isFinished reports whether an execution has an outcome to ingest,
+// including infra errors and aborted runs, which are stored for their own
+// counts though pass rates leave them out
+func isFinished(exec testkube.Execution) bool {
+	return exec.RunOutcome() != ""
+}
+
+// classifyOutcome records the execution's outcome. A failure the API gave
+// no reason for is checked against the logs, which show the image pulls,
+// evictions and timeouts that aren't the tests' fault.
+func (w *Worker) classifyOutcome(exec testkube.Execution) testkube.Execution {
+	exec.Outcome = exec.RunOutcome()
+	if exec.Outcome != testkube.OutcomeFailed {
+		return exec
+	}
+	logs, err := w.api.GetExecutionLogs(exec.ID)
+	if err != nil {
+		log.Printf("Worker: error getting logs of %s to classify its failure: %v", exec.ID, err)
+		return exec
+	}
+	exec.Outcome = testkube.ClassifyLogs(logs)
+	return exec
+}
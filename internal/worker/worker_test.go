@@ -0,0 +1,358 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/testkube/dashboard/internal/artifacts"
+	"github.com/testkube/dashboard/internal/database"
+	"github.com/testkube/dashboard/internal/testkube"
+)
+
+// playwrightClient serves a Playwright report for every JSON artifact
+type playwrightClient struct {
+	*testkube.MockClient
+}
+
+func (c playwrightClient) DownloadArtifact(executionID, path string) ([]byte, error) {
+	return []byte(`{"suites": [{"title": "cart.spec.ts", "file": "cart.spec.ts", "specs": [{
+		"title": "adds item",
+		"tests": [{"results": [
+			{"status": "failed", "retry": 0, "error": {"message": "flaky"}},
+			{"status": "passed", "retry": 1}
+		]}]
+	}]}]}`), nil
+}
+
+func TestWorker_RecordsRetries(t *testing.T) {
+	api := playwrightClient{testkube.NewMockClient()}
+	db := database.NewMockDatabase()
+	w := NewWorker(api, db)
+
+	exec, err := api.GetExecution("exec-1")
+	if err != nil {
+		t.Fatalf("GetExecution failed: %v", err)
+	}
+
+	count, err := w.ProcessExecution(*exec)
+	if err != nil {
+		t.Fatalf("ProcessExecution failed: %v", err)
+	}
+	if count != 1 {
+		t.Fatalf("expected 1 test case, got %d", count)
+	}
+
+	retried, err := db.GetPassedOnRetryTests(7, 10)
+	if err != nil {
+		t.Fatalf("GetPassedOnRetryTests failed: %v", err)
+	}
+	if len(retried) != 1 || retried[0].TestName != "adds item" || retried[0].PassedOnRetry != 1 {
+		t.Errorf("unexpected retry analysis: %+v", retried)
+	}
+
+	suites, err := db.GetTestSuites(exec.ID)
+	if err != nil {
+		t.Fatalf("GetTestSuites failed: %v", err)
+	}
+	if len(suites) != 1 || suites[0].Path != "cart.spec.ts" || suites[0].Passed != 1 {
+		t.Errorf("unexpected test suites: %+v", suites)
+	}
+}
+
+func TestWorker_RecordsArtifactManifest(t *testing.T) {
+	api := testkube.NewMockClient()
+	db := database.NewMockDatabase()
+	w := NewWorker(api, db)
+
+	exec, err := api.GetExecution("exec-1")
+	if err != nil {
+		t.Fatalf("GetExecution failed: %v", err)
+	}
+	if _, err := w.ProcessExecution(*exec); err != nil {
+		t.Fatalf("ProcessExecution failed: %v", err)
+	}
+
+	manifest, err := db.GetArtifactManifest(exec.ID)
+	if err != nil {
+		t.Fatalf("GetArtifactManifest failed: %v", err)
+	}
+	listed, _ := api.GetArtifacts(exec.ID)
+	if len(manifest) != len(listed) {
+		t.Fatalf("expected %d manifest entries, got %+v", len(listed), manifest)
+	}
+	for _, record := range manifest {
+		data, _ := api.DownloadArtifact(exec.ID, record.Path)
+		if record.SHA256 != artifacts.Checksum(data) {
+			t.Errorf("%s: checksum %s does not match the downloaded content", record.Path, record.SHA256)
+		}
+	}
+}
+
+func TestWorker_Stats(t *testing.T) {
+	w := NewWorker(testkube.NewMockClient(), database.NewMockDatabase())
+	w.poll()
+
+	stats := w.Stats()
+	if stats.Processed == 0 {
+		t.Errorf("expected finished executions to be ingested: %+v", stats)
+	}
+	if stats.Pending != 0 || stats.LastError != "" {
+		t.Errorf("expected nothing left pending: %+v", stats)
+	}
+	if stats.LastPoll.IsZero() {
+		t.Error("expected the poll time to be recorded")
+	}
+	if stats.Interval != DefaultPollInterval {
+		t.Errorf("interval: got %s, expected %s", stats.Interval, DefaultPollInterval)
+	}
+}
+
+// brokenClient serves a malformed Playwright report for every JSON artifact
+// until fixed
+type brokenClient struct {
+	*testkube.MockClient
+	fixed bool
+}
+
+func (c *brokenClient) DownloadArtifact(executionID, path string) ([]byte, error) {
+	if c.fixed {
+		return playwrightClient{c.MockClient}.DownloadArtifact(executionID, path)
+	}
+	return []byte(`{"suites": [{"title": "cart.spec.ts", "specs": "unexpected"}]}`), nil
+}
+
+func TestWorker_DeadLettersUnparsableArtifacts(t *testing.T) {
+	api := &brokenClient{MockClient: testkube.NewMockClient()}
+	db := database.NewMockDatabase()
+	w := NewWorker(api, db)
+
+	exec, err := api.GetExecution("exec-1")
+	if err != nil {
+		t.Fatalf("GetExecution failed: %v", err)
+	}
+	for i := 1; i < DefaultMaxParseAttempts; i++ {
+		if _, err := w.ProcessExecution(*exec); err == nil {
+			t.Fatalf("attempt %d: expected the parse failure to fail the execution", i)
+		}
+	}
+	if _, err := w.ProcessExecution(*exec); err != nil {
+		t.Fatalf("expected the execution to be ingested without the artifact, got %v", err)
+	}
+	if !w.isProcessed(exec.ID) {
+		t.Error("expected the execution to be marked processed")
+	}
+
+	letters, err := db.GetDeadLetters()
+	if err != nil {
+		t.Fatalf("GetDeadLetters failed: %v", err)
+	}
+	if len(letters) != 1 || letters[0].Path != "results.json" || letters[0].Parser != "playwright" || letters[0].Attempts != DefaultMaxParseAttempts {
+		t.Fatalf("unexpected dead letters: %+v", letters)
+	}
+
+	var out strings.Builder
+	w.WriteMetrics(&out)
+	for _, line := range []string{
+		`testkube_dashboard_worker_artifacts_parsed_total{parser="playwright",result="failure"} 3`,
+		`testkube_dashboard_worker_artifacts_dead_lettered_total{parser="playwright"} 1`,
+		`testkube_dashboard_worker_executions_ingested_total 1`,
+	} {
+		if !strings.Contains(out.String(), line) {
+			t.Errorf("expected metrics to contain %q, got:\n%s", line, out.String())
+		}
+	}
+
+	// Still broken: the dead letter stays, with the attempt counted
+	if _, err := w.RetryDeadLetter(letters[0]); err == nil {
+		t.Fatal("expected the retry to fail while the artifact is broken")
+	}
+	letters, _ = db.GetDeadLetters()
+	if len(letters) != 1 || letters[0].Attempts != DefaultMaxParseAttempts+1 {
+		t.Fatalf("expected the failed retry to be recorded: %+v", letters)
+	}
+
+	api.fixed = true
+	count, err := w.RetryDeadLetter(letters[0])
+	if err != nil || count != 1 {
+		t.Fatalf("expected the retry to record 1 test case, got %d, %v", count, err)
+	}
+	if letters, _ := db.GetDeadLetters(); len(letters) != 0 {
+		t.Errorf("expected the dead letter to be removed: %+v", letters)
+	}
+	cases, _ := db.GetExecutionMetrics(exec.ID)
+	if len(cases) != 1 {
+		t.Errorf("expected the retried test case to be stored, got %+v", cases)
+	}
+	if suites, _ := db.GetTestSuites(exec.ID); len(suites) != 1 || suites[0].Total != 1 {
+		t.Errorf("expected the retried test case's suite to be stored, got %+v", suites)
+	}
+}
+
+// watchClient lists no executions, so that only its watch's events are
+// ingested
+type watchClient struct {
+	*testkube.MockClient
+	events chan testkube.ExecutionEvent
+}
+
+func (c watchClient) GetExecutions(opts testkube.ListOptions) ([]testkube.Execution, error) {
+	return nil, nil
+}
+
+func (c watchClient) WatchExecutions(ctx context.Context) (<-chan testkube.ExecutionEvent, error) {
+	return c.events, nil
+}
+
+func TestWorker_IngestsWatchedExecutions(t *testing.T) {
+	api := watchClient{testkube.NewMockClient(), make(chan testkube.ExecutionEvent)}
+	w := NewWorker(api, database.NewMockDatabase())
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go w.Run(ctx)
+
+	exec, err := api.GetExecution("exec-1")
+	if err != nil {
+		t.Fatalf("GetExecution failed: %v", err)
+	}
+	running := *exec
+	running.Status = "running"
+	api.events <- testkube.ExecutionEvent{Execution: running, Previous: "queued"}
+	api.events <- testkube.ExecutionEvent{Execution: *exec, Previous: "running"}
+
+	deadline := time.Now().Add(5 * time.Second)
+	for w.Stats().Processed == 0 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if processed := w.Stats().Processed; processed != 1 {
+		t.Errorf("got %d processed executions, expected 1", processed)
+	}
+}
+
+func TestWorker_InheritsWorkflowLabels(t *testing.T) {
+	api := testkube.NewMockClient()
+	db := database.NewMockDatabase()
+	w := NewWorker(api, db)
+	w.inherited = []string{"team", "priority", "criticality"}
+
+	// frontend-e2e is labelled team=frontend and priority=high; the run
+	// overrides the priority
+	exec := testkube.Execution{ID: "exec-labels", WorkflowName: "frontend-e2e", Status: "passed", StartTime: time.Now(),
+		Labels: map[string]string{"priority": "low", "ci": "true"}}
+	if _, err := w.ProcessExecution(exec); err != nil {
+		t.Fatalf("ProcessExecution failed: %v", err)
+	}
+
+	stored, _, err := db.QueryExecutions(database.ExecutionQuery{Labels: map[string]string{"team": "frontend"}})
+	if err != nil || len(stored) != 1 {
+		t.Fatalf("got %+v, %v, expected the execution by its inherited team", stored, err)
+	}
+	labels := stored[0].Labels
+	if labels["team"] != "frontend" || labels["priority"] != "low" || labels["ci"] != "true" || labels["suite"] != "" {
+		t.Errorf("got labels %v, expected the team inherited, the run's own tags kept and other labels left out", labels)
+	}
+	if _, ok := labels["criticality"]; ok {
+		t.Errorf("got labels %v, expected no criticality, which the workflow doesn't have", labels)
+	}
+	if exec.Labels["team"] != "" {
+		t.Errorf("got %v, expected the caller's labels left alone", exec.Labels)
+	}
+
+	// Executions of deleted workflows are still ingested
+	orphan := testkube.Execution{ID: "exec-orphan", WorkflowName: "deleted", Status: "failed", StartTime: time.Now()}
+	if _, err := w.ProcessExecution(orphan); err != nil {
+		t.Errorf("ProcessExecution failed: %v", err)
+	}
+}
+
+func TestWorker_ParsersFor(t *testing.T) {
+	w := NewWorker(testkube.NewMockClient(), database.NewMockDatabase())
+	w.types = &testkube.TypeRegistry{}
+	if err := w.types.Import([]byte(`{"types": [{"name": "junit-runner", "patterns": ["junit"], "parser": "junit"}]}`)); err != nil {
+		t.Fatal(err)
+	}
+
+	if list := w.parsersFor(&testkube.Workflow{Type: "playwright"}); len(list) != 1 || list[0].name != "playwright" {
+		t.Errorf("got %d parsers, expected only the one the playwright type names", len(list))
+	}
+	for _, workflow := range []*testkube.Workflow{{Type: "k6"}, {Type: "junit-runner"}, nil} {
+		if list := w.parsersFor(workflow); len(list) != len(resultParsers) {
+			t.Errorf("got %d parsers for %+v, expected every parser", len(list), workflow)
+		}
+	}
+}
+
+func TestWorker_RetryDeadLetterUsesTypeParser(t *testing.T) {
+	// A parser ahead of playwright would claim the report if every parser
+	// were tried
+	saved := resultParsers
+	t.Cleanup(func() { resultParsers = saved })
+	resultParsers = append([]resultParser{{
+		name:   "greedy",
+		detect: func(string, []byte) bool { return true },
+		parse: func(string, []byte) ([]database.TestCase, error) {
+			return nil, errors.New("not a greedy report")
+		},
+	}}, saved...)
+
+	db := database.NewMockDatabase()
+	w := NewWorker(playwrightClient{testkube.NewMockClient()}, db)
+	letter := database.DeadLetter{ExecutionID: "exec-1", WorkflowName: "frontend-e2e", Path: "results.json", Parser: "playwright", Attempts: DefaultMaxParseAttempts}
+	if err := db.SaveDeadLetter(letter); err != nil {
+		t.Fatal(err)
+	}
+
+	count, err := w.RetryDeadLetter(letter)
+	if err != nil || count != 1 {
+		t.Fatalf("expected the playwright type's parser to record 1 test case, got %d, %v", count, err)
+	}
+	if letters, _ := db.GetDeadLetters(); len(letters) != 0 {
+		t.Errorf("expected the dead letter to be removed: %+v", letters)
+	}
+}
+
+func TestInheritedLabelsFromEnv(t *testing.T) {
+	t.Setenv("WORKER_INHERIT_LABELS", " team, tier ,")
+	if got := strings.Join(inheritedLabelsFromEnv(), ","); got != "team,tier" {
+		t.Errorf("got %q, expected team,tier", got)
+	}
+	t.Setenv("WORKER_INHERIT_LABELS", "")
+	if got := inheritedLabelsFromEnv(); len(got) != 0 {
+		t.Errorf("got %v, expected none", got)
+	}
+}
+
+func TestWorker_ClassifiesOutcome(t *testing.T) {
+	api := testkube.NewMockClient()
+	db := database.NewMockDatabase()
+	w := NewWorker(api, db)
+
+	// exec-21 failed pulling its image, which only its logs say
+	exec, err := api.GetExecution("exec-21")
+	if err != nil {
+		t.Fatalf("GetExecution failed: %v", err)
+	}
+	exec.Outcome = ""
+	if _, err := w.ProcessExecution(*exec); err != nil {
+		t.Fatalf("ProcessExecution failed: %v", err)
+	}
+	aborted := testkube.Execution{ID: "exec-aborted", WorkflowName: "frontend-e2e", Status: "aborted", StartTime: time.Now()}
+	if !isFinished(aborted) {
+		t.Error("expected aborted executions to be ingested")
+	}
+	if _, err := w.ProcessExecution(aborted); err != nil {
+		t.Fatalf("ProcessExecution failed: %v", err)
+	}
+
+	for outcome, expected := range map[string]string{testkube.OutcomeInfraError: "exec-21", testkube.OutcomeAborted: "exec-aborted"} {
+		stored, _, err := db.QueryExecutions(database.ExecutionQuery{Outcome: outcome})
+		if err != nil || len(stored) != 1 || stored[0].ID != expected {
+			t.Errorf("got %+v, %v, expected %s stored as %s", stored, err, expected, outcome)
+		}
+	}
+	if isFinished(testkube.Execution{Status: "running"}) {
+		t.Error("expected running executions to wait")
+	}
+}